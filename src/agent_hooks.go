@@ -0,0 +1,164 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	agentHooksEnv = "LISA_AGENT_HOOKS"
+
+	sessionHookTurnComplete    = "turn_complete"
+	sessionHookNeedsInput      = "needs_input"
+	sessionHookPromptSubmitted = "prompt_submitted"
+
+	sessionHookMessageMaxRunes = 240
+)
+
+var sessionHookEvents = []string{sessionHookTurnComplete, sessionHookNeedsInput, sessionHookPromptSubmitted}
+
+// agentHooksEnabled reports whether spawn wires agent lifecycle callbacks.
+// Hooks are on unless LISA_AGENT_HOOKS is set to a false value.
+func agentHooksEnabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(agentHooksEnv))) {
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+func sessionClaudeSettingsFile(projectRoot, session string) string {
+	return fmt.Sprintf("/tmp/.lisa-%s-session-%s-claude-settings.json", projectHash(projectRoot), sessionArtifactID(session))
+}
+
+func lisaHookBinary() string {
+	if binPath, err := osExecutableFn(); err == nil && strings.TrimSpace(binPath) != "" {
+		return binPath
+	}
+	return "lisa"
+}
+
+// applyAgentHookArgs adds the per-session hook wiring to agentArgs: a
+// generated --settings file for Claude and a notify program for Codex.
+// Caller-supplied settings or notify config is left untouched.
+func applyAgentHookArgs(agent, projectRoot, session, agentArgs string) (string, bool) {
+	trimmed := strings.TrimSpace(agentArgs)
+	extra := ""
+	switch agent {
+	case "claude":
+		if hasFlagToken(trimmed, "--settings") {
+			return agentArgs, false
+		}
+		extra = "--settings " + shellQuote(sessionClaudeSettingsFile(projectRoot, session))
+	case "codex":
+		if strings.Contains(trimmed, "notify=") {
+			return agentArgs, false
+		}
+		notify := []string{lisaHookBinary(), "hook", "--agent", "codex", "--session", session, "--project-root", projectRoot}
+		quoted := make([]string, 0, len(notify))
+		for _, part := range notify {
+			quoted = append(quoted, strconv.Quote(part))
+		}
+		extra = "-c " + shellQuote("notify=["+strings.Join(quoted, ",")+"]")
+	default:
+		return agentArgs, false
+	}
+	if trimmed == "" {
+		return extra, true
+	}
+	return trimmed + " " + extra, true
+}
+
+// writeClaudeHookSettings writes the settings file passed via --settings.
+// Claude merges it with the user's own settings, so only hooks live here.
+func writeClaudeHookSettings(projectRoot, session string) error {
+	command := fmt.Sprintf("%s hook --agent claude --session %s --project-root %s",
+		shellQuote(lisaHookBinary()), shellQuote(session), shellQuote(projectRoot))
+	entry := []map[string]any{{
+		"hooks": []map[string]any{{"type": "command", "command": command}},
+	}}
+	settings := map[string]any{
+		"hooks": map[string]any{
+			"Stop":             entry,
+			"Notification":     entry,
+			"UserPromptSubmit": entry,
+		},
+	}
+	raw, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(sessionClaudeSettingsFile(projectRoot, session), raw)
+}
+
+// sessionHookEventFromPayload maps a Claude hook or Codex notify payload to a
+// lisa hook event. Unknown payloads map to "".
+func sessionHookEventFromPayload(payload map[string]any) (event, message string) {
+	name, _ := payload["hook_event_name"].(string)
+	if name == "" {
+		name, _ = payload["type"].(string)
+	}
+	switch name {
+	case "Stop", "agent-turn-complete":
+		event = sessionHookTurnComplete
+	case "Notification":
+		event = sessionHookNeedsInput
+	case "UserPromptSubmit":
+		event = sessionHookPromptSubmitted
+	}
+	for _, key := range []string{"message", "last-assistant-message"} {
+		if value, ok := payload[key].(string); ok && strings.TrimSpace(value) != "" {
+			message = strings.TrimSpace(value)
+			break
+		}
+	}
+	return event, message
+}
+
+func recordSessionHookEvent(projectRoot, session, event, message string, at time.Time) error {
+	if runes := []rune(message); len(runes) > sessionHookMessageMaxRunes {
+		message = string(runes[:sessionHookMessageMaxRunes]) + "..."
+	}
+	message = redactOutputText(message)
+	statePath := sessionStateFile(projectRoot, session)
+	_, err := withStateFileLockFn(statePath, func() error {
+		state, loadErr := loadSessionStateWithError(statePath)
+		if loadErr != nil {
+			state = sessionState{}
+		}
+		if event == sessionHookTurnComplete {
+			// Reuse the transcript turn-complete marker so callers that only
+			// know that signal benefit from the hook too.
+			state.LastTurnCompleteAtNanos = at.UnixNano()
+			state.LastTurnCompleteInputNanos = stateLastInputAtNanos(state)
+			state.LastTurnCompleteFileAge = 0
+		}
+		state.LastHookEvent = event
+		state.LastHookAtNanos = at.UnixNano()
+		state.LastHookMessage = message
+		return saveSessionState(statePath, state)
+	})
+	if err != nil {
+		return err
+	}
+	return appendSessionEventFn(projectRoot, session, sessionEvent{
+		At:      at.UTC().Format(time.RFC3339Nano),
+		Type:    "hook",
+		Session: session,
+		Reason:  "hook_" + event,
+	})
+}
+
+// freshSessionHookEvent returns the last hook event unless lisa sent input
+// after it, which starts a new turn.
+func freshSessionHookEvent(state sessionState) string {
+	if state.LastHookAtNanos <= 0 || state.LastHookAtNanos < stateLastInputAtNanos(state) {
+		return ""
+	}
+	return state.LastHookEvent
+}
@@ -0,0 +1,425 @@
+package app
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	auditLogEnv           = "LISA_AUDIT_LOG"
+	auditLockTimeoutMS    = 5000
+	auditRedacted         = "[REDACTED]"
+	auditMaxLineBytes     = 4 << 20
+	auditGenesisPrevHash  = "0000000000000000000000000000000000000000000000000000000000000000"
+	auditHeadFileSuffix   = ".head"
+	auditLockFileSuffix   = ".lock"
+	auditDefaultListLimit = 50
+)
+
+var (
+	recordAuditFn = recordAudit
+
+	// auditSessionNote lets a command report the session it acted on when the
+	// name was not passed on the command line (e.g. auto-named spawns).
+	auditSessionNote = ""
+
+	auditSecretFlags = map[string]bool{
+		"--token":    true,
+		"--secret":   true,
+		"--password": true,
+		"--api-key":  true,
+	}
+)
+
+// auditEntry is one line of the append-only audit log. Hash covers every
+// other field plus PrevHash, chaining each entry to the one before it.
+type auditEntry struct {
+	Seq           int      `json:"seq"`
+	At            string   `json:"at"`
+	Command       string   `json:"command"`
+	Args          []string `json:"args"`
+	Session       string   `json:"session,omitempty"`
+	Text          string   `json:"text,omitempty"`
+	CallerSession string   `json:"callerSession,omitempty"`
+	Cwd           string   `json:"cwd,omitempty"`
+	User          string   `json:"user,omitempty"`
+	PID           int      `json:"pid"`
+	ExitCode      int      `json:"exitCode"`
+	OK            bool     `json:"ok"`
+	PrevHash      string   `json:"prevHash"`
+	Hash          string   `json:"hash"`
+}
+
+type auditHead struct {
+	Seq  int    `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// auditCommandName returns the audited command name for argv, or "" when the
+// invocation does not mutate sessions, tokens, queues, mail, or artifacts.
+// The guard policy evaluates exactly the commands named here.
+func auditCommandName(args []string) string {
+	if len(args) == 0 || auditSkipsInvocation(args[1:]) {
+		return ""
+	}
+	sub := ""
+	if len(args) > 1 {
+		sub = args[1]
+	}
+	switch args[0] {
+	case "cleanup":
+		return args[0]
+	case "session":
+		switch sub {
+		case "spawn", "send", "answer", "turn", "stop", "kill", "kill-all":
+			return "session " + sub
+		case "checkpoint":
+			action := ""
+			if len(args) > 2 && !strings.HasPrefix(args[2], "-") {
+				action = args[2]
+			}
+			if value := strings.ToLower(strings.TrimSpace(auditFlagValue(args[2:], "--action"))); value == "export" || value == "import" {
+				action = value
+			}
+			if strings.EqualFold(strings.TrimSpace(action), "import") {
+				return "session checkpoint import"
+			}
+		case "inbox":
+			if len(args) > 2 {
+				switch action := strings.ToLower(strings.TrimSpace(args[2])); action {
+				case "clear", "deliver":
+					return "session inbox " + action
+				}
+			}
+		}
+	case "oauth":
+		switch sub {
+		case "add", "remove":
+			return "oauth " + sub
+		}
+	case "queue":
+		switch sub {
+		case "add", "cancel", "drain", "work":
+			return "queue " + sub
+		}
+	case "msg":
+		switch sub {
+		case "post", "ack":
+			return "msg " + sub
+		}
+	case "result":
+		if sub == "put" {
+			return "result put"
+		}
+	}
+	return ""
+}
+
+// auditValuelessFlags are the audited commands' flags that take no value; any
+// other flag consumes the next argument, as the hand-rolled parsers do.
+var auditValuelessFlags = map[string]bool{
+	"--json": true, "--json-min": true, "--force": true, "--all": true,
+	"--enter": true, "--stdin": true, "--recursive": true, "--project-only": true,
+	"--cleanup-all-hashes": true, "--include-tmux-default": true,
+	"--detect-nested": true, "--no-dangerously-skip-permissions": true,
+	"--stop-on-waiting": true, "--waiting-requires-turn-complete": true,
+	"--auto-recover": true, "--include-diff": true, "--apply-diff": true,
+	"--spawn": true, "--cancel-queued": true, "--keep-sessions": true,
+	"--watch": true, guardPolicyConfirmArg: true,
+}
+
+// auditSkipsInvocation reports whether --help, -h, or --dry-run appears at a
+// flag position. The same word given as a flag value (`--text -h`) is text
+// for the command and must not skip the audit or the guard.
+func auditSkipsInvocation(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--help", "-h", "--dry-run":
+			return true
+		}
+		if strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") && !auditValuelessFlags[arg] {
+			i++
+		}
+	}
+	return false
+}
+
+func auditLogPath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv(auditLogEnv)); path != "" {
+		return expandAndCleanPath(path)
+	}
+	home, err := osUserHomeDirFn()
+	if err != nil || strings.TrimSpace(home) == "" {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".lisa", "audit.jsonl"), nil
+}
+
+func redactAuditValue(value string) string {
+	return applyRedactionRules(value, []string{"secrets", "tokens", "keys", "entropy"})
+}
+
+// redactAuditArgs masks values of secret-bearing flags and scrubs inline
+// secrets from every other argument.
+func redactAuditArgs(args []string) []string {
+	out := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if name, _, hasValue := strings.Cut(arg, "="); hasValue && auditSecretFlags[name] {
+			out[i] = name + "=" + auditRedacted
+			continue
+		}
+		out[i] = redactAuditValue(arg)
+		if auditSecretFlags[arg] && i+1 < len(args) {
+			out[i+1] = auditRedacted
+			i++
+		}
+	}
+	return out
+}
+
+func auditFlagValue(args []string, flag string) string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// recordAudit appends one entry for a finished mutating command. Failures
+// only warn: auditing must never change a command's outcome.
+func recordAudit(command string, args []string, exitCode int) {
+	entry := auditEntry{
+		At:            nowFn().UTC().Format(time.RFC3339Nano),
+		Command:       command,
+		Args:          redactAuditArgs(args),
+		Session:       auditFlagValue(args, "--session"),
+		CallerSession: strings.TrimSpace(os.Getenv("LISA_SESSION_NAME")),
+		PID:           os.Getpid(),
+		ExitCode:      exitCode,
+		OK:            exitCode == 0,
+	}
+	if entry.Session == "" {
+		entry.Session = auditSessionNote
+	}
+	if entry.Session == "" {
+		entry.Session = auditFlagValue(args, "--subtree")
+	}
+	for _, flag := range []string{"--text", "--keys", "--prompt"} {
+		if value := auditFlagValue(args, flag); value != "" {
+			entry.Text = redactAuditValue(value)
+			break
+		}
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		entry.Cwd = cwd
+	}
+	if current, err := user.Current(); err == nil {
+		entry.User = current.Username
+	}
+	if err := appendAuditEntry(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "audit warning: %v\n", err)
+	}
+}
+
+// recordAutomaticAudit logs input or a kill that lisa performs on its own
+// (inbox deliveries, prompt auto-answers, limit kills), where no command line
+// names the session or the text.
+func recordAutomaticAudit(command, session, text string, keys []string, err error) {
+	args := []string{"--session", session}
+	if text != "" {
+		args = append(args, "--text", text)
+	} else if len(keys) > 0 {
+		args = append(args, "--keys", strings.Join(keys, " "))
+	}
+	code := 0
+	if err != nil {
+		code = 1
+	}
+	recordAuditFn(command, args, code)
+}
+
+func auditEntryHash(entry auditEntry) string {
+	entry.Hash = ""
+	raw, _ := json.Marshal(entry)
+	sum := sha256.Sum256(append([]byte(entry.PrevHash+"\n"), raw...))
+	return hex.EncodeToString(sum[:])
+}
+
+func appendAuditEntry(entry auditEntry) error {
+	path, err := auditLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return withExclusiveFileLock(path+auditLockFileSuffix, auditLockTimeoutMS, func() error {
+		head, err := loadAuditHead(path)
+		if err != nil {
+			return err
+		}
+		entry.Seq = head.Seq + 1
+		entry.PrevHash = head.Hash
+		entry.Hash = auditEntryHash(entry)
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(append(line, '\n')); err != nil {
+			_ = file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		headRaw, _ := json.Marshal(auditHead{Seq: entry.Seq, Hash: entry.Hash})
+		return writeFileAtomic(path+auditHeadFileSuffix, headRaw)
+	})
+}
+
+// loadAuditHead returns the last seq/hash, from the head file when present or
+// by scanning the log (first append after the head file went missing).
+func loadAuditHead(path string) (auditHead, error) {
+	head := auditHead{Hash: auditGenesisPrevHash}
+	raw, err := os.ReadFile(path + auditHeadFileSuffix)
+	if err == nil {
+		if jsonErr := json.Unmarshal(raw, &head); jsonErr != nil {
+			return head, fmt.Errorf("invalid audit head %s: %w", path+auditHeadFileSuffix, jsonErr)
+		}
+		return head, nil
+	}
+	if !os.IsNotExist(err) {
+		return head, err
+	}
+	entries, err := readAuditEntries(path)
+	if err != nil {
+		return head, err
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		head = auditHead{Seq: last.Seq, Hash: last.Hash}
+	}
+	return head, nil
+}
+
+func readAuditEntries(path string) ([]auditEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	entries := []auditEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), auditMaxLineBytes)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		entry := auditEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return entries, fmt.Errorf("line %d: invalid entry: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+type auditVerifyResult struct {
+	OK       bool     `json:"ok"`
+	Path     string   `json:"path"`
+	Entries  int      `json:"entries"`
+	HeadSeq  int      `json:"headSeq"`
+	BrokenAt int      `json:"brokenAt,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// verifyAuditLog walks the chain: sequence numbers must be contiguous, each
+// prevHash must equal the previous hash, each hash must recompute, and the
+// head file must point at the last entry (catching tail truncation).
+func verifyAuditLog(path string) auditVerifyResult {
+	result := auditVerifyResult{OK: true, Path: path}
+	fail := func(seq int, format string, args ...any) {
+		if result.BrokenAt == 0 {
+			result.BrokenAt = seq
+		}
+		result.OK = false
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+	}
+	entries, err := readAuditEntries(path)
+	result.Entries = len(entries)
+	if err != nil {
+		fail(len(entries)+1, "%v", err)
+	}
+	prev := auditGenesisPrevHash
+	for i, entry := range entries {
+		want := i + 1
+		if entry.Seq != want {
+			fail(want, "seq %d: expected seq %d", entry.Seq, want)
+		}
+		if entry.PrevHash != prev {
+			fail(want, "seq %d: prevHash does not match previous entry", entry.Seq)
+		}
+		if auditEntryHash(entry) != entry.Hash {
+			fail(want, "seq %d: hash mismatch (entry modified)", entry.Seq)
+		}
+		prev = entry.Hash
+	}
+	raw, err := os.ReadFile(path + auditHeadFileSuffix)
+	switch {
+	case err == nil:
+		head := auditHead{}
+		if jsonErr := json.Unmarshal(raw, &head); jsonErr != nil {
+			fail(len(entries)+1, "invalid head file: %v", jsonErr)
+			break
+		}
+		result.HeadSeq = head.Seq
+		if head.Seq != len(entries) || (len(entries) > 0 && head.Hash != prev) {
+			fail(minInt(head.Seq, len(entries))+1, "head seq %d does not match last entry %d (log truncated or rewritten)", head.Seq, len(entries))
+		}
+	case os.IsNotExist(err):
+		if len(entries) > 0 {
+			fail(len(entries)+1, "head file missing")
+		}
+	default:
+		fail(len(entries)+1, "read head file: %v", err)
+	}
+	return result
+}
+
+func findAuditEntry(entries []auditEntry, seq int) (auditEntry, bool) {
+	for _, entry := range entries {
+		if entry.Seq == seq {
+			return entry, true
+		}
+	}
+	return auditEntry{}, false
+}
+
+func formatAuditLine(entry auditEntry) string {
+	session := entry.Session
+	if session == "" {
+		session = "-"
+	}
+	return strings.Join([]string{strconv.Itoa(entry.Seq), entry.At, entry.Command, session, strconv.Itoa(entry.ExitCode)}, "\t")
+}
@@ -0,0 +1,208 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain keeps test runs (including spawned lisa binaries) out of the
+// user's real audit log, and keeps cleanup tests away from real mailboxes.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "lisa-audit-test-")
+	if err == nil {
+		_ = os.Setenv(auditLogEnv, filepath.Join(dir, "audit.jsonl"))
+		_ = os.Setenv(historyDirEnv, filepath.Join(dir, "history"))
+		mailboxArtifactGlob = filepath.Join(dir, ".lisa-*-mailbox*")
+	}
+	code := m.Run()
+	if err == nil {
+		_ = os.RemoveAll(dir)
+	}
+	os.Exit(code)
+}
+
+func auditTestLog(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv(auditLogEnv, path)
+	return path
+}
+
+func TestAuditCommandNameSelectsMutatingCommands(t *testing.T) {
+	cases := map[string]string{
+		"session spawn --prompt hi":                          "session spawn",
+		"session send --session s":                           "session send",
+		"session kill-all":                                   "session kill-all",
+		"oauth add --stdin":                                  "oauth add",
+		"cleanup --json":                                     "cleanup",
+		"session spawn --dry-run":                            "",
+		"session kill --help":                                "",
+		"session status --session s":                         "",
+		"oauth list":                                         "",
+		"audit verify":                                       "",
+		"session":                                            "",
+		"queue add --prompt anything":                        "queue add",
+		"queue list":                                         "",
+		"queue cancel --all":                                 "queue cancel",
+		"queue drain --cancel-queued":                        "queue drain",
+		"queue work --watch":                                 "queue work",
+		"msg post --to s --body hi":                          "msg post",
+		"msg read --session s":                               "",
+		"msg ack --session s --seq 3":                        "msg ack",
+		"result put --status success":                        "result put",
+		"result get --session s":                             "",
+		"hook --event Stop":                                  "",
+		"session checkpoint import --archive a.tgz":          "session checkpoint import",
+		"session checkpoint --action import --archive a.tgz": "session checkpoint import",
+		"session checkpoint export --session s":              "",
+		"session checkpoint save --session s":                "",
+		"session inbox clear --session s":                    "session inbox clear",
+		"session inbox deliver --session s":                  "session inbox deliver",
+		"session inbox --session s":                          "",
+		"session send --session s --text -h":                 "session send",
+		"session send --text --dry-run --session s":          "session send",
+		"queue add --prompt --help --json":                   "queue add",
+		"session send --session s --enter --help":            "",
+		"session spawn --agent-args --dry-run --prompt x":    "session spawn",
+		"session spawn --json --dry-run":                     "",
+		"session send --session=s -h":                        "",
+	}
+	for raw, want := range cases {
+		if got := auditCommandName(strings.Fields(raw)); got != want {
+			t.Fatalf("auditCommandName(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestRecordAuditChainsEntriesAndRedactsSecrets(t *testing.T) {
+	path := auditTestLog(t)
+	t.Setenv("LISA_SESSION_NAME", "lisa-parent")
+	recordAudit("oauth add", []string{"oauth", "add", "--token", "sk-ant-REDACTED", "--json"}, 0)
+	recordAudit("session send", []string{"session", "send", "--session", "lisa-child", "--text", "use password=hunter2 now", "--enter"}, 0)
+	auditSessionNote = "lisa-auto"
+	t.Cleanup(func() { auditSessionNote = "" })
+	recordAudit("session spawn", []string{"session", "spawn", "--prompt", "build it"}, 1)
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read audit log failed: %v", err)
+	}
+	if strings.Contains(string(raw), "hunter2") || strings.Contains(string(raw), "abcdefghijklmnop") {
+		t.Fatalf("secrets leaked into audit log: %s", raw)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdAuditList([]string{"--json"}); code != 0 {
+			t.Fatalf("audit list failed: %d", code)
+		}
+	})
+	var payload struct {
+		Total   int          `json:"total"`
+		Entries []auditEntry `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("parse list failed: %v (%q)", err, stdout)
+	}
+	if payload.Total != 3 || payload.Entries[0].Args[3] != auditRedacted || payload.Entries[0].PrevHash != auditGenesisPrevHash {
+		t.Fatalf("unexpected first entry: %+v", payload.Entries)
+	}
+	send := payload.Entries[1]
+	if send.Seq != 2 || send.Session != "lisa-child" || send.CallerSession != "lisa-parent" || send.PrevHash != payload.Entries[0].Hash || !strings.Contains(send.Text, "[REDACTED_SECRET]") {
+		t.Fatalf("unexpected send entry: %+v", send)
+	}
+	if spawn := payload.Entries[2]; spawn.Session != "lisa-auto" || spawn.OK || spawn.ExitCode != 1 || spawn.Text != "build it" {
+		t.Fatalf("unexpected spawn entry: %+v", spawn)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdAuditList([]string{"--command", "send", "--caller", "lisa-parent", "--json"}); code != 0 {
+			t.Fatalf("filtered audit list failed: %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"count":1`) {
+		t.Fatalf("expected one filtered entry, got %q", stdout)
+	}
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdAuditShow([]string{"--seq", "3"}); code != 0 {
+			t.Fatalf("audit show failed: %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "command: session spawn") || !strings.Contains(stdout, "exit: 1") {
+		t.Fatalf("unexpected show output: %q", stdout)
+	}
+	if result := verifyAuditLog(path); !result.OK || result.Entries != 3 || result.HeadSeq != 3 {
+		t.Fatalf("expected intact chain, got %+v", result)
+	}
+}
+
+func TestAuditVerifyDetectsEditsAndTruncation(t *testing.T) {
+	path := auditTestLog(t)
+	for _, text := range []string{"one", "two", "three"} {
+		recordAudit("session send", []string{"session", "send", "--session", "lisa-a", "--text", text}, 0)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read audit log failed: %v", err)
+	}
+	original := string(raw)
+
+	if err := os.WriteFile(path, []byte(strings.Replace(original, `"text":"two"`, `"text":"TWO"`, 1)), 0o600); err != nil {
+		t.Fatalf("tamper failed: %v", err)
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdAuditVerify([]string{"--json"}); code != 1 {
+			t.Fatalf("expected verify failure, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"audit_chain_broken"`) || !strings.Contains(stdout, `"brokenAt":2`) {
+		t.Fatalf("unexpected verify payload: %q", stdout)
+	}
+
+	lines := strings.SplitAfter(original, "\n")
+	if err := os.WriteFile(path, []byte(strings.Join(lines[:2], "")), 0o600); err != nil {
+		t.Fatalf("truncate failed: %v", err)
+	}
+	if result := verifyAuditLog(path); result.OK || result.BrokenAt != 3 {
+		t.Fatalf("expected truncation detected at seq 3, got %+v", result)
+	}
+}
+
+func TestRunRecordsAuditEntryForFailedOAuthRemove(t *testing.T) {
+	path := auditTestLog(t)
+	home := t.TempDir()
+	origHome := oauthUserHomeDirFn
+	t.Cleanup(func() { oauthUserHomeDirFn = origHome })
+	oauthUserHomeDirFn = func() (string, error) { return home, nil }
+
+	_, _ = captureOutput(t, func() {
+		if code := Run([]string{"oauth", "remove", "--id", "missing", "--json"}); code == 0 {
+			t.Fatalf("expected oauth remove of unknown id to fail")
+		}
+	})
+	entries, err := readAuditEntries(path)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %d (%v)", len(entries), err)
+	}
+	if entries[0].Command != "oauth remove" || entries[0].OK || entries[0].Cwd == "" || entries[0].PID != os.Getpid() {
+		t.Fatalf("unexpected audit entry: %+v", entries[0])
+	}
+}
+
+// stubAuditRecords captures recordAuditFn calls as "command session text".
+func stubAuditRecords(t *testing.T) *[]string {
+	t.Helper()
+	orig := recordAuditFn
+	t.Cleanup(func() { recordAuditFn = orig })
+	records := []string{}
+	recordAuditFn = func(command string, args []string, exitCode int) {
+		text := auditFlagValue(args, "--text")
+		if text == "" {
+			text = auditFlagValue(args, "--keys")
+		}
+		records = append(records, strings.TrimSpace(command+" "+auditFlagValue(args, "--session")+" "+text))
+	}
+	return &records
+}
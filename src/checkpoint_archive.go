@@ -0,0 +1,465 @@
+package app
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	checkpointArchiveVersion  = "1"
+	checkpointArchiveDir      = "lisa-checkpoint"
+	checkpointArchiveMaxBytes = 256 << 20
+)
+
+// checkpointArchiveFiles is the closed set of entries an archive may carry;
+// anything else is rejected on import.
+var checkpointArchiveFiles = map[string]bool{
+	"manifest.json":    true,
+	"manifest.sha256":  true,
+	"meta.json":        true,
+	"state.json":       true,
+	"events.jsonl":     true,
+	"memory.json":      true,
+	"objective.json":   true,
+	"lane.json":        true,
+	"transcript.jsonl": true,
+	"output.txt":       true,
+	"worktree.diff":    true,
+}
+
+type checkpointArchiveFile struct {
+	Name   string `json:"name"`
+	Bytes  int    `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+type checkpointArchiveManifest struct {
+	Version             string                  `json:"version"`
+	ExportedAt          string                  `json:"exportedAt"`
+	Session             string                  `json:"session"`
+	Agent               string                  `json:"agent"`
+	Mode                string                  `json:"mode"`
+	SourceProjectRoot   string                  `json:"sourceProjectRoot"`
+	Lane                string                  `json:"lane,omitempty"`
+	ObjectiveID         string                  `json:"objectiveId,omitempty"`
+	TranscriptSessionID string                  `json:"transcriptSessionId,omitempty"`
+	TranscriptName      string                  `json:"transcriptName,omitempty"`
+	GitHead             string                  `json:"gitHead,omitempty"`
+	UntrackedFiles      []string                `json:"untrackedFiles,omitempty"`
+	UntrackedOmitted    bool                    `json:"untrackedOmitted,omitempty"`
+	Redactions          int                     `json:"redactions"`
+	Files               []checkpointArchiveFile `json:"files"`
+}
+
+// Manifest values end up in filesystem paths and in the resume command, so
+// import accepts only plain identifiers and file names.
+var (
+	checkpointTranscriptIDRe   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,127}$`)
+	checkpointTranscriptNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,254}\.jsonl$`)
+)
+
+// checkpointArchive is an archive's content keyed by entry name.
+type checkpointArchive struct {
+	Manifest checkpointArchiveManifest
+	Files    map[string][]byte
+}
+
+// collectCheckpointArchive gathers every artifact needed to continue a session
+// elsewhere. Missing optional artifacts become warnings, not errors.
+func collectCheckpointArchive(projectRoot, session string, includeDiff bool) (checkpointArchive, []string, error) {
+	archive := checkpointArchive{Files: map[string][]byte{}}
+	warnings := []string{}
+	meta, err := loadSessionMeta(projectRoot, session)
+	if err != nil {
+		return archive, warnings, fmt.Errorf("cannot load session metadata: %w", err)
+	}
+	manifest := checkpointArchiveManifest{
+		Version:           checkpointArchiveVersion,
+		ExportedAt:        nowFn().UTC().Format(time.RFC3339),
+		Session:           session,
+		Agent:             normalizeAgent(meta.Agent),
+		Mode:              normalizeMode(meta.Mode),
+		SourceProjectRoot: projectRoot,
+		Lane:              meta.Lane,
+		ObjectiveID:       meta.ObjectiveID,
+	}
+	if archive.Files["meta.json"], err = json.MarshalIndent(meta, "", "  "); err != nil {
+		return archive, warnings, err
+	}
+	state, stateErr := loadSessionStateWithError(sessionStateFile(projectRoot, session))
+	if stateErr == nil {
+		archive.Files["state.json"], _ = json.MarshalIndent(state, "", "  ")
+	} else if !os.IsNotExist(stateErr) {
+		warnings = append(warnings, "state: "+stateErr.Error())
+	}
+	if raw, readErr := readSessionEventLog(sessionEventsFile(projectRoot, session)); readErr == nil && len(raw) > 0 {
+		archive.Files["events.jsonl"] = raw
+	}
+	if memory, ok, memErr := loadSessionMemory(projectRoot, session); memErr == nil && ok {
+		archive.Files["memory.json"], _ = json.MarshalIndent(memory, "", "  ")
+	}
+	if id := strings.TrimSpace(meta.ObjectiveID); id != "" {
+		if store, storeErr := loadObjectiveStore(projectRoot); storeErr == nil {
+			if record, ok := store.Objectives[id]; ok {
+				archive.Files["objective.json"], _ = json.MarshalIndent(record, "", "  ")
+			}
+		}
+	}
+	if lane := strings.TrimSpace(meta.Lane); lane != "" {
+		if record, ok, laneErr := loadLaneRecord(projectRoot, lane); laneErr == nil && ok {
+			archive.Files["lane.json"], _ = json.MarshalIndent(record, "", "  ")
+		}
+	}
+	if raw, readErr := os.ReadFile(sessionOutputFile(projectRoot, session)); readErr == nil && len(raw) > 0 {
+		redacted := redactOutputText(string(raw))
+		archive.Files["output.txt"] = []byte(redacted)
+	}
+
+	transcriptPath, transcriptID, transcriptErr := sessionTranscriptFile(projectRoot, session, meta, state)
+	if transcriptErr != nil {
+		warnings = append(warnings, "transcript: "+transcriptErr.Error())
+	} else if raw, readErr := os.ReadFile(transcriptPath); readErr != nil {
+		warnings = append(warnings, "transcript: "+readErr.Error())
+	} else {
+		redacted, count := redactJSONLines(raw)
+		archive.Files["transcript.jsonl"] = redacted
+		manifest.Redactions += count
+		manifest.TranscriptSessionID = transcriptID
+		manifest.TranscriptName = filepath.Base(transcriptPath)
+	}
+
+	if includeDiff {
+		head, headErr := runCmd("git", "-C", projectRoot, "rev-parse", "HEAD")
+		diff, untracked, omitted, diffErr := checkpointWorktreeDiff(projectRoot)
+		if headErr != nil || diffErr != nil {
+			detail := head
+			if diffErr != nil {
+				detail += " " + diffErr.Error()
+			}
+			warnings = append(warnings, "worktree diff unavailable: "+strings.TrimSpace(detail))
+		} else {
+			manifest.GitHead = strings.TrimSpace(head)
+			manifest.UntrackedFiles = untracked
+			manifest.UntrackedOmitted = omitted
+			archive.Files["worktree.diff"] = []byte(diff)
+			if omitted {
+				warnings = append(warnings, fmt.Sprintf("worktree diff omits %d untracked file(s): %s", len(untracked), strings.Join(untracked, ", ")))
+			}
+		}
+	}
+	archive.Manifest = manifest
+	return archive, warnings, nil
+}
+
+// checkpointWorktreeDiff diffs the worktree against HEAD, untracked
+// (non-ignored) files included. They are staged into a throwaway index via
+// GIT_INDEX_FILE so the user's real index is never touched. If that index
+// cannot be built it falls back to the tracked-only diff and reports the
+// untracked files as omitted.
+func checkpointWorktreeDiff(projectRoot string) (string, []string, bool, error) {
+	untracked := checkpointUntrackedFiles(projectRoot)
+	if len(untracked) > 0 {
+		if diff, err := checkpointStagedDiff(projectRoot); err == nil {
+			return diff, untracked, false, nil
+		}
+	}
+	diff, err := runCmd("git", "-C", projectRoot, "diff", "--binary", "HEAD")
+	if err != nil {
+		return "", nil, false, fmt.Errorf("%s", strings.TrimSpace(diff))
+	}
+	return diff, untracked, len(untracked) > 0, nil
+}
+
+func checkpointStagedDiff(projectRoot string) (string, error) {
+	dir, err := os.MkdirTemp("", "lisa-checkpoint-index-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(dir, "index")}
+	if out, err := runCmdEnv(env, "git", "-C", projectRoot, "read-tree", "HEAD"); err != nil {
+		return "", fmt.Errorf("git read-tree: %s", strings.TrimSpace(out))
+	}
+	if out, err := runCmdEnv(env, "git", "-C", projectRoot, "add", "-A"); err != nil {
+		return "", fmt.Errorf("git add: %s", strings.TrimSpace(out))
+	}
+	return runCmdEnv(env, "git", "-C", projectRoot, "diff", "--cached", "--binary", "HEAD")
+}
+
+// checkpointUntrackedFiles lists untracked, non-ignored files repo-wide.
+func checkpointUntrackedFiles(projectRoot string) []string {
+	out, err := runCmd("git", "-C", projectRoot, "ls-files", "--others", "--exclude-standard", "--full-name", "-z", "--", ":/")
+	if err != nil {
+		return nil
+	}
+	files := []string{}
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+// sessionTranscriptFile locates the agent's native transcript JSONL.
+func sessionTranscriptFile(projectRoot, session string, meta sessionMeta, state sessionState) (string, string, error) {
+	if normalizeAgent(meta.Agent) == "codex" {
+		sessionID := strings.TrimSpace(state.CodexSessionID)
+		if sessionID == "" {
+			found, err := findCodexSessionIDFn(meta.Prompt, meta.CreatedAt)
+			if err != nil {
+				return "", "", err
+			}
+			sessionID = found
+		}
+		path, err := findCodexSessionFileFn(sessionID)
+		return path, sessionID, err
+	}
+	sessionID := strings.TrimSpace(state.ClaudeSessionID)
+	if sessionID == "" {
+		found, err := findClaudeSessionIDFn(meta.ProjectRoot, meta.Prompt, meta.CreatedAt)
+		if err != nil {
+			return "", "", err
+		}
+		sessionID = found
+	}
+	return filepath.Join(claudeProjectDir(meta.ProjectRoot), sessionID+".jsonl"), sessionID, nil
+}
+
+// redactJSONLines redacts each JSONL record independently so the transcript
+// stays loadable by the agent after import.
+func redactJSONLines(raw []byte) ([]byte, int) {
+	var out bytes.Buffer
+	total := 0
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 64*1024), checkpointArchiveMaxBytes)
+	for scanner.Scan() {
+		line, count := redactOutputJSON(scanner.Bytes())
+		total += count
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+	if scanner.Err() != nil {
+		return raw, 0
+	}
+	return out.Bytes(), total
+}
+
+func writeCheckpointArchive(path string, archive checkpointArchive) error {
+	names := make([]string, 0, len(archive.Files))
+	for name := range archive.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	archive.Manifest.Files = archive.Manifest.Files[:0]
+	for _, name := range names {
+		sum := sha256.Sum256(archive.Files[name])
+		archive.Manifest.Files = append(archive.Manifest.Files, checkpointArchiveFile{
+			Name:   name,
+			Bytes:  len(archive.Files[name]),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+	manifest, err := json.MarshalIndent(archive.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	modTime := nowFn().UTC()
+	write := func(name string, data []byte) error {
+		header := &tar.Header{
+			Name:    checkpointArchiveDir + "/" + name,
+			Mode:    0o600,
+			Size:    int64(len(data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	manifestSum := sha256.Sum256(manifest)
+	if err := write("manifest.json", manifest); err != nil {
+		return err
+	}
+	if err := write("manifest.sha256", []byte(hex.EncodeToString(manifestSum[:])+"\n")); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := write(name, archive.Files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// readCheckpointArchive loads and verifies an archive against its manifest.
+func readCheckpointArchive(path string) (checkpointArchive, error) {
+	archive := checkpointArchive{Files: map[string][]byte{}}
+	file, err := os.Open(path)
+	if err != nil {
+		return archive, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return archive, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return archive, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := strings.TrimPrefix(header.Name, checkpointArchiveDir+"/")
+		if !checkpointArchiveFiles[name] {
+			return archive, fmt.Errorf("unexpected archive entry: %s", header.Name)
+		}
+		if header.Size > checkpointArchiveMaxBytes {
+			return archive, fmt.Errorf("archive entry too large: %s", name)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, checkpointArchiveMaxBytes+1))
+		if err != nil {
+			return archive, err
+		}
+		archive.Files[name] = data
+	}
+	rawManifest, ok := archive.Files["manifest.json"]
+	if !ok {
+		return archive, fmt.Errorf("archive has no manifest.json")
+	}
+	manifestSum := sha256.Sum256(rawManifest)
+	if strings.TrimSpace(string(archive.Files["manifest.sha256"])) != hex.EncodeToString(manifestSum[:]) {
+		return archive, fmt.Errorf("checksum mismatch for manifest.json")
+	}
+	if err := json.Unmarshal(rawManifest, &archive.Manifest); err != nil {
+		return archive, fmt.Errorf("invalid manifest: %w", err)
+	}
+	delete(archive.Files, "manifest.json")
+	delete(archive.Files, "manifest.sha256")
+	if archive.Manifest.Version != checkpointArchiveVersion {
+		return archive, fmt.Errorf("unsupported archive version: %q", archive.Manifest.Version)
+	}
+	listed := map[string]bool{}
+	for _, entry := range archive.Manifest.Files {
+		data, ok := archive.Files[entry.Name]
+		if !ok {
+			return archive, fmt.Errorf("archive missing %s", entry.Name)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != entry.SHA256 {
+			return archive, fmt.Errorf("checksum mismatch for %s", entry.Name)
+		}
+		listed[entry.Name] = true
+	}
+	for name := range archive.Files {
+		if !listed[name] {
+			return archive, fmt.Errorf("archive entry %s is not in the manifest", name)
+		}
+	}
+	if _, ok := archive.Files["meta.json"]; !ok {
+		return archive, fmt.Errorf("archive has no meta.json")
+	}
+	if err := validateCheckpointManifest(&archive.Manifest); err != nil {
+		return archive, err
+	}
+	return archive, nil
+}
+
+// validateCheckpointManifest rejects manifest values that could escape the
+// transcript directories or reach the shell through the resume command, and
+// canonicalizes agent and mode.
+func validateCheckpointManifest(manifest *checkpointArchiveManifest) error {
+	if strings.TrimSpace(manifest.Agent) == "" {
+		return fmt.Errorf("manifest has no agent")
+	}
+	agent, err := parseAgent(manifest.Agent)
+	if err != nil {
+		return fmt.Errorf("invalid manifest agent: %w", err)
+	}
+	if strings.TrimSpace(manifest.Mode) == "" {
+		return fmt.Errorf("manifest has no mode")
+	}
+	mode, err := parseMode(manifest.Mode)
+	if err != nil {
+		return fmt.Errorf("invalid manifest mode: %w", err)
+	}
+	manifest.Agent, manifest.Mode = agent, mode
+	if !strings.HasPrefix(manifest.Session, "lisa-") {
+		return fmt.Errorf("invalid manifest session: %q", manifest.Session)
+	}
+	if id := manifest.TranscriptSessionID; id != "" && !checkpointTranscriptIDRe.MatchString(id) {
+		return fmt.Errorf("invalid manifest transcriptSessionId: %q", id)
+	}
+	if name := manifest.TranscriptName; name != "" && !checkpointTranscriptNameRe.MatchString(name) {
+		return fmt.Errorf("invalid manifest transcriptName: %q", name)
+	}
+	return nil
+}
+
+// buildCheckpointResumePrompt summarizes the imported work for a fresh spawn.
+func buildCheckpointResumePrompt(manifest checkpointArchiveManifest, meta sessionMeta, archive checkpointArchive) string {
+	lines := []string{
+		fmt.Sprintf("Resume the task moved from %s (session %s).", manifest.SourceProjectRoot, manifest.Session),
+	}
+	if goal := strings.TrimSpace(meta.ObjectiveGoal); goal != "" {
+		lines = append(lines, "Objective: "+goal)
+	}
+	if acceptance := strings.TrimSpace(meta.ObjectiveAcceptance); acceptance != "" {
+		lines = append(lines, "Acceptance: "+acceptance)
+	}
+	if prompt := strings.TrimSpace(meta.Prompt); prompt != "" {
+		lines = append(lines, "Original prompt: "+prompt)
+	}
+	if raw, ok := archive.Files["memory.json"]; ok {
+		memory := sessionMemoryRecord{}
+		if json.Unmarshal(raw, &memory) == nil && len(memory.Lines) > 0 {
+			tail := memory.Lines
+			if len(tail) > 10 {
+				tail = tail[len(tail)-10:]
+			}
+			lines = append(lines, "Notes so far:")
+			for _, line := range tail {
+				lines = append(lines, "- "+line)
+			}
+		}
+	}
+	if _, ok := archive.Files["worktree.diff"]; ok {
+		lines = append(lines, "Uncommitted changes from the source worktree were exported; check git status before continuing.")
+	}
+	lines = append(lines, "Continue from where the previous run stopped.")
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,320 @@
+package app
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func cleanupCheckpointSession(t *testing.T, projectRoot, session string) {
+	t.Helper()
+	t.Cleanup(func() {
+		for _, path := range []string{
+			sessionMetaFile(projectRoot, session),
+			sessionStateFile(projectRoot, session),
+			sessionEventsFile(projectRoot, session),
+			sessionEventCountFile(sessionEventsFile(projectRoot, session)),
+			sessionOutputFile(projectRoot, session),
+		} {
+			_ = os.Remove(path)
+		}
+	})
+}
+
+func seedCheckpointSession(t *testing.T, projectRoot, session string) {
+	t.Helper()
+	cleanupCheckpointSession(t, projectRoot, session)
+	meta := sessionMeta{Session: session, Agent: "claude", Mode: "interactive", ProjectRoot: projectRoot, Lane: "backend", ObjectiveID: "ship", Prompt: "build it", CreatedAt: "2026-10-01T00:00:00Z"}
+	if err := saveSessionMeta(projectRoot, session, meta); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+	if err := saveSessionState(sessionStateFile(projectRoot, session), sessionState{ClaudeSessionID: "abc-123", LastSessionState: "in_progress"}); err != nil {
+		t.Fatalf("save state failed: %v", err)
+	}
+	if err := saveObjectiveStore(projectRoot, sessionObjectiveStore{CurrentID: "ship", Objectives: map[string]sessionObjectiveRecord{"ship": {ID: "ship", Goal: "ship the parser"}}}); err != nil {
+		t.Fatalf("save objective failed: %v", err)
+	}
+	if err := saveLaneStore(projectRoot, sessionLaneStore{Lanes: map[string]sessionLaneRecord{"backend": {Name: "backend", Goal: "api work"}}}); err != nil {
+		t.Fatalf("save lane failed: %v", err)
+	}
+	transcript := filepath.Join(claudeProjectDir(projectRoot), "abc-123.jsonl")
+	if err := os.MkdirAll(filepath.Dir(transcript), 0o700); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(transcript, []byte(`{"type":"user","text":"token=supersecretvalue"}`+"\n"), 0o600); err != nil {
+		t.Fatalf("write transcript failed: %v", err)
+	}
+}
+
+func TestCheckpointExportImportRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useOutputRedaction(t, "")
+	source := canonicalProjectRoot(t.TempDir())
+	target := canonicalProjectRoot(t.TempDir())
+	session := "lisa-checkpoint-source"
+	seedCheckpointSession(t, source, session)
+	archivePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionCheckpoint([]string{"export", "--session", session, "--project-root", source, "--archive", archivePath, "--json"}); code != 0 {
+			t.Fatalf("expected export success, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"transcript.jsonl"`) || !strings.Contains(stdout, `"lane.json"`) || !strings.Contains(stdout, `"redactions":1`) {
+		t.Fatalf("unexpected export payload: %s", stdout)
+	}
+
+	renamed := "lisa-checkpoint-imported"
+	cleanupCheckpointSession(t, target, renamed)
+	t.Setenv("HOME", t.TempDir())
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdSessionCheckpoint([]string{"import", "--archive", archivePath, "--project-root", target, "--session", renamed, "--json"}); code != 0 {
+			t.Fatalf("expected import success, got %d", code)
+		}
+	})
+	payload := map[string]any{}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("invalid import json: %v (%q)", err, stdout)
+	}
+	resume, _ := payload["resume"].(map[string]any)
+	command, _ := resume["command"].(string)
+	if !strings.Contains(command, "'--resume abc-123'") || !strings.Contains(command, "'--lane' 'backend'") || !strings.Contains(command, target) {
+		t.Fatalf("unexpected resume command: %q", command)
+	}
+
+	meta, err := loadSessionMeta(target, renamed)
+	if err != nil || meta.ProjectRoot != target || meta.Session != renamed {
+		t.Fatalf("expected rewritten meta, got %+v (%v)", meta, err)
+	}
+	raw, err := os.ReadFile(filepath.Join(claudeProjectDir(target), "abc-123.jsonl"))
+	if err != nil || strings.Contains(string(raw), "supersecretvalue") {
+		t.Fatalf("expected redacted transcript in new claude project dir, got %q (%v)", raw, err)
+	}
+	if store, _ := loadObjectiveStore(target); store.CurrentID != "ship" {
+		t.Fatalf("expected objective imported as current, got %+v", store)
+	}
+	if _, ok, _ := loadLaneRecord(target, "backend"); !ok {
+		t.Fatalf("expected lane imported")
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := cmdSessionCheckpoint([]string{"import", "--archive", archivePath, "--project-root", target, "--session", renamed, "--json"}); code == 0 {
+			t.Fatalf("expected existing session to require --force")
+		}
+	})
+}
+
+func TestCheckpointImportRejectsTamperedArchive(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	source := canonicalProjectRoot(t.TempDir())
+	session := "lisa-checkpoint-tamper"
+	seedCheckpointSession(t, source, session)
+	archive, _, err := collectCheckpointArchive(source, session, false)
+	if err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+	good := filepath.Join(t.TempDir(), "good.tar.gz")
+	if err := writeCheckpointArchive(good, archive); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.tar.gz")
+	rewriteCheckpointEntry(t, good, bad, "state.json", []byte(`{"lastSessionState":"completed"}`))
+	if _, err := readCheckpointArchive(bad); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+	stdout, _ := captureOutput(t, func() {
+		cmdSessionCheckpoint([]string{"--action", "import", "--archive", bad, "--project-root", t.TempDir(), "--json"})
+	})
+	if !strings.Contains(stdout, `"errorCode":"checkpoint_archive_invalid"`) {
+		t.Fatalf("expected invalid archive error, got %q", stdout)
+	}
+}
+
+func TestCheckpointImportRejectsHostileManifest(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	source := canonicalProjectRoot(t.TempDir())
+	session := "lisa-checkpoint-hostile"
+	seedCheckpointSession(t, source, session)
+	archive, _, err := collectCheckpointArchive(source, session, false)
+	if err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+
+	origRun, origExe := runLisaSubcommandFn, osExecutableFn
+	t.Cleanup(func() { runLisaSubcommandFn, osExecutableFn = origRun, origExe })
+	osExecutableFn = func() (string, error) { return "/bin/lisa", nil }
+	runLisaSubcommandFn = func(bin string, args ...string) (string, string, error) {
+		t.Fatalf("hostile archive must not spawn: %v", args)
+		return "", "", nil
+	}
+
+	cases := map[string]func(m *checkpointArchiveManifest){
+		"traversal id":  func(m *checkpointArchiveManifest) { m.TranscriptSessionID = "../../../.ssh/authorized_keys" },
+		"shell id":      func(m *checkpointArchiveManifest) { m.TranscriptSessionID = "abc; touch /tmp/pwned $(id)" },
+		"bad agent":     func(m *checkpointArchiveManifest) { m.Agent = "sh -c evil" },
+		"bad mode":      func(m *checkpointArchiveManifest) { m.Mode = "yolo" },
+		"traversal log": func(m *checkpointArchiveManifest) { m.TranscriptName = "../../.bashrc" },
+	}
+	for name, mutate := range cases {
+		hostile := archive
+		mutate(&hostile.Manifest)
+		path := filepath.Join(t.TempDir(), "hostile.tar.gz")
+		if err := writeCheckpointArchive(path, hostile); err != nil {
+			t.Fatalf("%s: write failed: %v", name, err)
+		}
+		target := canonicalProjectRoot(t.TempDir())
+		cleanupCheckpointSession(t, target, session)
+		stdout, _ := captureOutput(t, func() {
+			if code := cmdSessionCheckpoint([]string{"import", "--archive", path, "--project-root", target, "--spawn", "--json"}); code == 0 {
+				t.Fatalf("%s: expected import to fail", name)
+			}
+		})
+		if !strings.Contains(stdout, `"errorCode":"checkpoint_archive_invalid"`) {
+			t.Fatalf("%s: expected invalid archive error, got %q", name, stdout)
+		}
+		if fileExists(sessionMetaFile(target, session)) {
+			t.Fatalf("%s: expected nothing restored", name)
+		}
+	}
+
+	// The manifest itself is checksummed, so editing it in place fails.
+	good := filepath.Join(t.TempDir(), "good.tar.gz")
+	if err := writeCheckpointArchive(good, archive); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	edited := filepath.Join(t.TempDir(), "edited.tar.gz")
+	manifest, _ := json.Marshal(archive.Manifest)
+	rewriteCheckpointEntry(t, good, edited, "manifest.json", manifest)
+	if _, err := readCheckpointArchive(edited); err == nil || !strings.Contains(err.Error(), "manifest.json") {
+		t.Fatalf("expected manifest checksum error, got %v", err)
+	}
+}
+
+func TestCheckpointWorktreeDiffIncludesUntrackedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	repo := canonicalProjectRoot(t.TempDir())
+	gitRun := func(args ...string) string {
+		t.Helper()
+		out, err := runCmd("git", append([]string{"-C", repo, "-c", "user.email=t@example.com", "-c", "user.name=t"}, args...)...)
+		if err != nil {
+			t.Fatalf("git %v failed: %v (%s)", args, err, out)
+		}
+		return out
+	}
+	gitRun("init", "-q")
+	writeRepoFile := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s failed: %v", name, err)
+		}
+	}
+	writeRepoFile("tracked.txt", "v1\n")
+	writeRepoFile(".gitignore", "ignored.txt\n")
+	gitRun("add", "-A")
+	gitRun("commit", "-qm", "base")
+	writeRepoFile("tracked.txt", "v2\n")
+	writeRepoFile("new.txt", "brand new\n")
+	writeRepoFile("ignored.txt", "build output\n")
+
+	session := "lisa-checkpoint-untracked"
+	seedCheckpointSession(t, repo, session)
+	archive, _, err := collectCheckpointArchive(repo, session, true)
+	if err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+	diff := string(archive.Files["worktree.diff"])
+	if !strings.Contains(diff, "+v2") || !strings.Contains(diff, "b/new.txt") || strings.Contains(diff, "ignored.txt") {
+		t.Fatalf("expected tracked and untracked changes only, got %q", diff)
+	}
+	if got := archive.Manifest.UntrackedFiles; len(got) != 1 || got[0] != "new.txt" || archive.Manifest.UntrackedOmitted {
+		t.Fatalf("unexpected untracked manifest: %v omitted=%v", got, archive.Manifest.UntrackedOmitted)
+	}
+	if status := gitRun("status", "--porcelain"); !strings.Contains(status, "?? new.txt") || !strings.Contains(status, " M tracked.txt") {
+		t.Fatalf("expected the real index untouched, got %q", status)
+	}
+}
+
+func TestCheckpointImportSpawnRunsResumeCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	source := canonicalProjectRoot(t.TempDir())
+	target := canonicalProjectRoot(t.TempDir())
+	session := "lisa-checkpoint-spawn"
+	seedCheckpointSession(t, source, session)
+	cleanupCheckpointSession(t, target, session)
+	archivePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	_, _ = captureOutput(t, func() {
+		cmdSessionCheckpoint([]string{"export", "--session", session, "--project-root", source, "--archive", archivePath, "--json"})
+	})
+
+	origRun, origExe := runLisaSubcommandFn, osExecutableFn
+	t.Cleanup(func() { runLisaSubcommandFn, osExecutableFn = origRun, origExe })
+	osExecutableFn = func() (string, error) { return "/bin/lisa", nil }
+	var gotArgs []string
+	runLisaSubcommandFn = func(bin string, args ...string) (string, string, error) {
+		gotArgs = args
+		return `{"session":"` + session + `","status":"spawned"}`, "", nil
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionCheckpoint([]string{"import", "--archive", archivePath, "--project-root", target, "--spawn", "--json"}); code != 0 {
+			t.Fatalf("expected spawn import success, got %d", code)
+		}
+	})
+	joined := strings.Join(gotArgs, " ")
+	if !strings.HasPrefix(joined, "session spawn --session "+session) || !strings.Contains(joined, "--prompt") || !strings.HasSuffix(joined, "--json") {
+		t.Fatalf("unexpected spawn args: %v", gotArgs)
+	}
+	if !strings.Contains(stdout, `"status":"spawned"`) {
+		t.Fatalf("expected spawn result embedded, got %s", stdout)
+	}
+}
+
+func rewriteCheckpointEntry(t *testing.T, src, dst, name string, body []byte) {
+	t.Helper()
+	in, err := os.Open(src)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		t.Fatalf("gzip failed: %v", err)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	defer out.Close()
+	gzw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gzw)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar read failed: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		if filepath.Base(header.Name) == name {
+			data = body
+			header.Size = int64(len(body))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("tar header failed: %v", err)
+		}
+		_, _ = tw.Write(data)
+	}
+	_ = tw.Close()
+	_ = gzw.Close()
+}
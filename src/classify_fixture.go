@@ -0,0 +1,486 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	classifyFixtureVersion      = 1
+	classifyFixtureManifest     = "fixture.json"
+	classifyFixtureCaptureLines = 220
+	classifyFixtureTranscriptN  = 20
+)
+
+// classifyFixtureEnvKeys are the environment knobs computeSessionStatus reads.
+var classifyFixtureEnvKeys = []string{
+	"LISA_HEARTBEAT_STALE_SECONDS",
+	"LISA_OUTPUT_STALE_SECONDS",
+	"LISA_PROCESS_SCAN_INTERVAL_SECONDS",
+	"LISA_AGENT_PROCESS_MATCH",
+	"LISA_AGENT_PROCESS_MATCH_CLAUDE",
+	"LISA_AGENT_PROCESS_MATCH_CODEX",
+}
+
+// classifyFixture is the manifest written by `session status --record`. Bulky
+// inputs live next to it as separate files so fixtures stay reviewable.
+type classifyFixture struct {
+	Version         int                             `json:"version"`
+	RecordedAt      string                          `json:"recordedAt"`
+	Session         string                          `json:"session"`
+	ProjectRoot     string                          `json:"projectRoot,omitempty"`
+	AgentHint       string                          `json:"agentHint"`
+	ModeHint        string                          `json:"modeHint"`
+	Env             map[string]string               `json:"env,omitempty"`
+	HasSession      bool                            `json:"hasSession"`
+	TmuxDisplay     map[string]classifyFixtureValue `json:"tmuxDisplay,omitempty"`
+	PaneStatus      *classifyFixtureValue           `json:"paneStatus,omitempty"`
+	PaneCaptureErr  string                          `json:"paneCaptureError,omitempty"`
+	Processes       []classifyFixtureProcess        `json:"processes"`
+	ProcessScanErr  string                          `json:"processScanError,omitempty"`
+	ProcessScanned  bool                            `json:"processScanned"`
+	HeartbeatAge    *int                            `json:"heartbeatAgeSeconds,omitempty"`
+	TranscriptAgent string                          `json:"transcriptAgent,omitempty"`
+	TranscriptError string                          `json:"transcriptError,omitempty"`
+	Files           []string                        `json:"files"`
+	Expected        classifyFixtureExpect           `json:"expected"`
+}
+
+type classifyFixtureValue struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+type classifyFixtureProcess struct {
+	PID     int     `json:"pid"`
+	PPID    int     `json:"ppid"`
+	CPU     float64 `json:"cpu"`
+	Command string  `json:"command"`
+}
+
+type classifyFixtureExpect struct {
+	Status               string `json:"status"`
+	SessionState         string `json:"sessionState"`
+	ClassificationReason string `json:"classificationReason"`
+}
+
+type classifyReplayResult struct {
+	Fixture  string
+	Manifest classifyFixture
+	Status   sessionStatus
+	Match    bool
+}
+
+func classifyFixtureValueOf(output string, err error) classifyFixtureValue {
+	value := classifyFixtureValue{Output: output}
+	if err != nil {
+		value.Error = err.Error()
+	}
+	return value
+}
+
+func (value classifyFixtureValue) result() (string, error) {
+	if value.Error != "" {
+		return value.Output, errors.New(value.Error)
+	}
+	return value.Output, nil
+}
+
+// recordSessionStatusFixture runs computeSessionStatus with every external
+// read (tmux, process table, clock) wrapped, then writes what it saw to dir.
+func recordSessionStatusFixture(dir, session, projectRoot, agentHint, modeHint string, full bool) (sessionStatus, error) {
+	recordedAt := nowFn()
+	fixture := classifyFixture{
+		Version:     classifyFixtureVersion,
+		RecordedAt:  recordedAt.UTC().Format(time.RFC3339Nano),
+		Session:     session,
+		ProjectRoot: projectRoot,
+		AgentHint:   agentHint,
+		ModeHint:    modeHint,
+		Env:         map[string]string{},
+		TmuxDisplay: map[string]classifyFixtureValue{},
+	}
+	for _, key := range classifyFixtureEnvKeys {
+		if value, ok := os.LookupEnv(key); ok {
+			fixture.Env[key] = value
+		}
+	}
+
+	// Snapshot inputs before compute mutates the state file.
+	files := map[string][]byte{}
+	for name, path := range map[string]string{
+		"meta.json":           sessionMetaFile(projectRoot, session),
+		"state.json":          sessionStateFile(projectRoot, session),
+		"done.txt":            sessionDoneFile(projectRoot, session),
+		"classify-rules.json": classificationRulesPath(projectRoot),
+	} {
+		if path == "" {
+			continue
+		}
+		if raw, err := os.ReadFile(path); err == nil {
+			files[name] = raw
+		}
+	}
+	if age, seen := sessionHeartbeatAge(projectRoot, session, recordedAt.Unix()); seen {
+		fixture.HeartbeatAge = &age
+	}
+
+	capture := ""
+	captureLines := 0
+	var scanned []processInfo
+	origNow := nowFn
+	origHas := tmuxHasSessionFn
+	origDisplay := tmuxDisplayFn
+	origPaneStatus := tmuxPaneStatusFn
+	origList := listProcessesCachedFn
+	origCapture := tmuxCapturePaneFn
+	nowFn = func() time.Time { return recordedAt }
+	tmuxHasSessionFn = func(name string) bool {
+		fixture.HasSession = origHas(name)
+		return fixture.HasSession
+	}
+	tmuxDisplayFn = func(name, format string) (string, error) {
+		out, err := origDisplay(name, format)
+		fixture.TmuxDisplay[format] = classifyFixtureValueOf(out, err)
+		return out, err
+	}
+	tmuxPaneStatusFn = func(name string) (string, error) {
+		out, err := origPaneStatus(name)
+		value := classifyFixtureValueOf(out, err)
+		fixture.PaneStatus = &value
+		return out, err
+	}
+	listProcessesCachedFn = func() ([]processInfo, error) {
+		procs, err := origList()
+		fixture.ProcessScanned = true
+		if err != nil {
+			fixture.ProcessScanErr = err.Error()
+		}
+		scanned = procs
+		return procs, err
+	}
+	tmuxCapturePaneFn = func(name string, lines int) (string, error) {
+		out, err := origCapture(name, lines)
+		if err != nil {
+			fixture.PaneCaptureErr = err.Error()
+		} else if lines > captureLines {
+			capture, captureLines = out, lines
+		}
+		return out, err
+	}
+	status, err := computeSessionStatusFn(session, projectRoot, agentHint, modeHint, full, 0)
+	nowFn = origNow
+	tmuxHasSessionFn = origHas
+	tmuxDisplayFn = origDisplay
+	tmuxPaneStatusFn = origPaneStatus
+	listProcessesCachedFn = origList
+	tmuxCapturePaneFn = origCapture
+	if err != nil {
+		return status, err
+	}
+
+	if captureLines == 0 && fixture.HasSession {
+		if out, captureErr := tmuxCapturePaneFn(session, classifyFixtureCaptureLines); captureErr == nil {
+			capture = out
+		} else {
+			fixture.PaneCaptureErr = captureErr.Error()
+		}
+	}
+	files["pane.txt"] = []byte(capture)
+
+	fixture.Processes = paneProcessSubtree(scanned, classifyFixturePanePID(fixture))
+
+	if agent := transcriptCaptureAgent(session, projectRoot); agent != "" {
+		fixture.TranscriptAgent = agent
+		if _, messages, transcriptErr := captureSessionTranscriptFn(session, projectRoot); transcriptErr != nil {
+			fixture.TranscriptError = transcriptErr.Error()
+		} else {
+			if len(messages) > classifyFixtureTranscriptN {
+				messages = messages[len(messages)-classifyFixtureTranscriptN:]
+			}
+			files["transcript.jsonl"] = []byte(formatTranscriptJSONL(messages))
+		}
+	}
+
+	statusJSON, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return status, err
+	}
+	files["status.json"] = append(statusJSON, '\n')
+	fixture.Expected = classifyFixtureExpect{
+		Status:               status.Status,
+		SessionState:         status.SessionState,
+		ClassificationReason: status.ClassificationReason,
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return status, err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return status, err
+		}
+		fixture.Files = append(fixture.Files, name)
+	}
+	sort.Strings(fixture.Files)
+	manifest, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return status, err
+	}
+	return status, os.WriteFile(filepath.Join(dir, classifyFixtureManifest), append(manifest, '\n'), 0o644)
+}
+
+func classifyFixturePanePID(fixture classifyFixture) int {
+	for format, value := range fixture.TmuxDisplay {
+		if value.Error != "" {
+			continue
+		}
+		var raw string
+		switch {
+		case strings.HasSuffix(format, "#{pane_pid}") && strings.Contains(format, "\t"):
+			parts := strings.Split(value.Output, "\t")
+			raw = parts[len(parts)-1]
+		case format == "#{pane_pid}":
+			raw = value.Output
+		default:
+			continue
+		}
+		if pid, err := strconv.Atoi(strings.TrimSpace(raw)); err == nil {
+			return pid
+		}
+	}
+	return 0
+}
+
+// paneProcessSubtree keeps the pane process and its descendants; the rest of
+// the host process table is irrelevant to classification and stays private.
+func paneProcessSubtree(procs []processInfo, panePID int) []classifyFixtureProcess {
+	out := []classifyFixtureProcess{}
+	if panePID <= 0 {
+		return out
+	}
+	children := map[int][]processInfo{}
+	for _, p := range procs {
+		children[p.PPID] = append(children[p.PPID], p)
+	}
+	keep := map[int]bool{panePID: true}
+	queue := []int{panePID}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, child := range children[cur] {
+			if !keep[child.PID] {
+				keep[child.PID] = true
+				queue = append(queue, child.PID)
+			}
+		}
+	}
+	for _, p := range procs {
+		if keep[p.PID] {
+			out = append(out, classifyFixtureProcess{PID: p.PID, PPID: p.PPID, CPU: p.CPU, Command: p.Command})
+		}
+	}
+	return out
+}
+
+func loadClassifyFixture(dir string) (classifyFixture, error) {
+	fixture := classifyFixture{}
+	raw, err := os.ReadFile(filepath.Join(dir, classifyFixtureManifest))
+	if err != nil {
+		return fixture, err
+	}
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		return fixture, fmt.Errorf("invalid %s: %w", classifyFixtureManifest, err)
+	}
+	if fixture.Version != classifyFixtureVersion {
+		return fixture, fmt.Errorf("unsupported fixture version %d (expected %d)", fixture.Version, classifyFixtureVersion)
+	}
+	if strings.TrimSpace(fixture.Session) == "" {
+		return fixture, fmt.Errorf("fixture is missing session")
+	}
+	if _, err := time.Parse(time.RFC3339Nano, fixture.RecordedAt); err != nil {
+		return fixture, fmt.Errorf("invalid recordedAt: %w", err)
+	}
+	return fixture, nil
+}
+
+func readClassifyFixtureFile(dir, name string) ([]byte, bool) {
+	raw, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, false
+	}
+	return raw, true
+}
+
+// replayClassifyFixture rebuilds the recorded artifacts under a scratch
+// project root and runs computeSessionStatus against the recorded tmux and
+// process observations with the clock frozen at recordedAt.
+func replayClassifyFixture(dir string) (classifyReplayResult, error) {
+	result := classifyReplayResult{Fixture: dir}
+	fixture, err := loadClassifyFixture(dir)
+	if err != nil {
+		return result, err
+	}
+	result.Manifest = fixture
+	recordedAt, _ := time.Parse(time.RFC3339Nano, fixture.RecordedAt)
+
+	scratch, err := os.MkdirTemp("", "lisa-classify-fixture-")
+	if err != nil {
+		return result, err
+	}
+	projectRoot := canonicalProjectRoot(scratch)
+	session := fixture.Session
+	defer func() {
+		_ = cleanupSessionArtifactsWithOptions(projectRoot, session, cleanupOptions{})
+		_ = os.RemoveAll(scratch)
+	}()
+
+	if raw, ok := readClassifyFixtureFile(dir, "meta.json"); ok {
+		meta := sessionMeta{}
+		if err := json.Unmarshal(raw, &meta); err == nil {
+			// Never let a replay prune a real OAuth token.
+			meta.OAuthTokenID = ""
+			meta.ProjectRoot = projectRoot
+			if raw, err = json.Marshal(meta); err != nil {
+				return result, err
+			}
+		}
+		if err := os.WriteFile(sessionMetaFile(projectRoot, session), raw, 0o600); err != nil {
+			return result, err
+		}
+	}
+	if raw, ok := readClassifyFixtureFile(dir, "state.json"); ok {
+		if err := os.WriteFile(sessionStateFile(projectRoot, session), raw, 0o600); err != nil {
+			return result, err
+		}
+	}
+	if raw, ok := readClassifyFixtureFile(dir, "done.txt"); ok {
+		if err := os.WriteFile(sessionDoneFile(projectRoot, session), raw, 0o600); err != nil {
+			return result, err
+		}
+	}
+	if fixture.HeartbeatAge != nil {
+		path := sessionHeartbeatFile(projectRoot, session)
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			return result, err
+		}
+		mtime := recordedAt.Add(-time.Duration(*fixture.HeartbeatAge) * time.Second)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			return result, err
+		}
+	}
+	if raw, ok := readClassifyFixtureFile(dir, "classify-rules.json"); ok {
+		rulesDir := filepath.Join(projectRoot, ".lisa")
+		if err := os.MkdirAll(rulesDir, 0o755); err != nil {
+			return result, err
+		}
+		if err := os.WriteFile(filepath.Join(rulesDir, "classify-rules.json"), raw, 0o600); err != nil {
+			return result, err
+		}
+	}
+	pane, _ := readClassifyFixtureFile(dir, "pane.txt")
+
+	restores := []func(){withProjectRuntimeEnv(projectRoot), replaceEnvScoped(classifyRulesFileEnv, "", false)}
+	for _, key := range classifyFixtureEnvKeys {
+		value, ok := fixture.Env[key]
+		restores = append(restores, replaceEnvScoped(key, value, ok))
+	}
+	defer func() {
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+	}()
+
+	procs := make([]processInfo, 0, len(fixture.Processes))
+	for _, p := range fixture.Processes {
+		procs = append(procs, processInfo{PID: p.PID, PPID: p.PPID, CPU: p.CPU, Command: p.Command})
+	}
+	origNow := nowFn
+	origHas := tmuxHasSessionFn
+	origDisplay := tmuxDisplayFn
+	origPaneStatus := tmuxPaneStatusFn
+	origList := listProcessesCachedFn
+	origDetect := detectAgentProcessFn
+	origInspect := inspectPaneProcessTreeFn
+	origCapture := tmuxCapturePaneFn
+	origAppend := appendSessionEventFn
+	defer func() {
+		nowFn = origNow
+		tmuxHasSessionFn = origHas
+		tmuxDisplayFn = origDisplay
+		tmuxPaneStatusFn = origPaneStatus
+		listProcessesCachedFn = origList
+		detectAgentProcessFn = origDetect
+		inspectPaneProcessTreeFn = origInspect
+		tmuxCapturePaneFn = origCapture
+		appendSessionEventFn = origAppend
+	}()
+	nowFn = func() time.Time { return recordedAt }
+	tmuxHasSessionFn = func(string) bool { return fixture.HasSession }
+	tmuxDisplayFn = func(_, format string) (string, error) {
+		value, ok := fixture.TmuxDisplay[format]
+		if !ok {
+			return "", fmt.Errorf("fixture has no tmux display output for %q", format)
+		}
+		return value.result()
+	}
+	tmuxPaneStatusFn = func(string) (string, error) {
+		if fixture.PaneStatus == nil {
+			return "", fmt.Errorf("fixture has no tmux pane status")
+		}
+		return fixture.PaneStatus.result()
+	}
+	listProcessesCachedFn = func() ([]processInfo, error) {
+		if !fixture.ProcessScanned {
+			return nil, fmt.Errorf("fixture has no process scan")
+		}
+		if fixture.ProcessScanErr != "" {
+			return nil, errors.New(fixture.ProcessScanErr)
+		}
+		return append([]processInfo(nil), procs...), nil
+	}
+	detectAgentProcessFn = detectAgentProcess
+	inspectPaneProcessTreeFn = inspectPaneProcessTree
+	tmuxCapturePaneFn = func(_ string, lines int) (string, error) {
+		if fixture.PaneCaptureErr != "" {
+			return "", errors.New(fixture.PaneCaptureErr)
+		}
+		all := strings.Split(strings.TrimRight(string(pane), "\n"), "\n")
+		if lines > 0 && len(all) > lines {
+			all = all[len(all)-lines:]
+		}
+		return strings.Join(all, "\n") + "\n", nil
+	}
+	appendSessionEventFn = func(string, string, sessionEvent) error { return nil }
+
+	status, err := computeSessionStatus(session, projectRoot, fixture.AgentHint, fixture.ModeHint, false, 0)
+	if err != nil {
+		return result, err
+	}
+	result.Status = status
+	result.Match = status.Status == fixture.Expected.Status &&
+		status.SessionState == fixture.Expected.SessionState &&
+		status.ClassificationReason == fixture.Expected.ClassificationReason
+	return result, nil
+}
+
+// replaceEnvScoped sets (present=true) or unsets key and returns a restore func.
+func replaceEnvScoped(key, value string, present bool) func() {
+	if present {
+		return setEnvScoped(key, value)
+	}
+	prev, hadPrev := os.LookupEnv(key)
+	_ = os.Unsetenv(key)
+	return func() {
+		if hadPrev {
+			_ = os.Setenv(key, prev)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyFixtureCorpus(t *testing.T) {
+	dirs, err := resolveClassifyFixtureDirs(filepath.Join("testdata", "classify-fixtures"))
+	if err != nil {
+		t.Fatalf("resolve corpus failed: %v", err)
+	}
+	if len(dirs) < 2 {
+		t.Fatalf("expected fixture corpus, got %v", dirs)
+	}
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			result, err := replayClassifyFixture(dir)
+			if err != nil {
+				t.Fatalf("replay failed: %v", err)
+			}
+			if !result.Match {
+				want := result.Manifest.Expected
+				t.Fatalf("replay got %s/%s (%s), want %s/%s (%s)",
+					result.Status.SessionState, result.Status.Status, result.Status.ClassificationReason,
+					want.SessionState, want.Status, want.ClassificationReason)
+			}
+		})
+	}
+}
+
+func TestSessionStatusRecordReplaysDeterministically(t *testing.T) {
+	projectRoot := t.TempDir()
+	session := "lisa-record-fixture"
+	if err := saveSessionMeta(projectRoot, session, sessionMeta{
+		Session:     session,
+		Agent:       "claude",
+		Mode:        "exec",
+		ProjectRoot: projectRoot,
+	}); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+	t.Cleanup(func() { _ = cleanupSessionArtifactsWithOptions(projectRoot, session, cleanupOptions{}) })
+	if err := os.WriteFile(sessionHeartbeatFile(projectRoot, session), nil, 0o600); err != nil {
+		t.Fatalf("write heartbeat failed: %v", err)
+	}
+
+	origHas := tmuxHasSessionFn
+	origDisplay := tmuxDisplayFn
+	origList := listProcessesCachedFn
+	origCapture := tmuxCapturePaneFn
+	origDetect := detectAgentProcessFn
+	t.Cleanup(func() {
+		tmuxHasSessionFn = origHas
+		tmuxDisplayFn = origDisplay
+		listProcessesCachedFn = origList
+		tmuxCapturePaneFn = origCapture
+		detectAgentProcessFn = origDetect
+	})
+	tmuxHasSessionFn = func(string) bool { return true }
+	tmuxDisplayFn = func(_, format string) (string, error) { return "0\t\tclaude\t700", nil }
+	detectAgentProcessFn = detectAgentProcess
+	listProcessesCachedFn = func() ([]processInfo, error) {
+		return []processInfo{
+			{PID: 700, PPID: 1, CPU: 0, Command: "bash"},
+			{PID: 701, PPID: 700, CPU: 12.5, Command: "claude -p hello"},
+			{PID: 999, PPID: 1, CPU: 3, Command: "ssh secret-host"},
+		}, nil
+	}
+	tmuxCapturePaneFn = func(string, int) (string, error) { return "working...\n", nil }
+
+	fixtureDir := filepath.Join(t.TempDir(), "fixture")
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionStatus([]string{"--session", session, "--project-root", projectRoot, "--record", fixtureDir, "--json"})
+		if code != 0 {
+			t.Fatalf("expected status success, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"recordDir":"`+fixtureDir+`"`) || !strings.Contains(stdout, `"sessionState":"in_progress"`) {
+		t.Fatalf("unexpected status payload: %q", stdout)
+	}
+
+	fixture, err := loadClassifyFixture(fixtureDir)
+	if err != nil {
+		t.Fatalf("load fixture failed: %v", err)
+	}
+	if len(fixture.Processes) != 2 || fixture.Processes[1].PID != 701 {
+		t.Fatalf("expected only the pane process subtree, got %+v", fixture.Processes)
+	}
+	if fixture.HeartbeatAge == nil || fixture.Expected.ClassificationReason != "agent_pid_alive" {
+		t.Fatalf("unexpected fixture manifest: %+v", fixture)
+	}
+	for _, name := range []string{"meta.json", "pane.txt", "status.json"} {
+		if _, err := os.Stat(filepath.Join(fixtureDir, name)); err != nil {
+			t.Fatalf("expected %s in fixture: %v", name, err)
+		}
+	}
+
+	// Replay must not touch live tmux or the process table.
+	tmuxHasSessionFn = func(string) bool { t.Fatalf("replay hit live tmux"); return false }
+	listProcessesCachedFn = func() ([]processInfo, error) { t.Fatalf("replay hit live ps"); return nil, nil }
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdClassify([]string{"--fixture", fixtureDir, "--trace", "--json"}); code != 0 {
+			t.Fatalf("expected replay match, got %d", code)
+		}
+	})
+	var payload struct {
+		OK       bool             `json:"ok"`
+		Matched  int              `json:"matched"`
+		Fixtures []map[string]any `json:"fixtures"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("failed to parse classify payload: %v (%q)", err, stdout)
+	}
+	if !payload.OK || payload.Matched != 1 || payload.Fixtures[0]["trace"] == nil {
+		t.Fatalf("unexpected classify payload: %s", stdout)
+	}
+
+	fixture.Expected.SessionState = "waiting_input"
+	raw, _ := json.Marshal(fixture)
+	if err := os.WriteFile(filepath.Join(fixtureDir, classifyFixtureManifest), raw, 0o644); err != nil {
+		t.Fatalf("rewrite manifest failed: %v", err)
+	}
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdClassify([]string{"--fixture", fixtureDir, "--json"}); code != 1 {
+			t.Fatalf("expected mismatch exit 1, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"fixture_mismatch"`) {
+		t.Fatalf("unexpected mismatch payload: %q", stdout)
+	}
+}
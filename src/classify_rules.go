@@ -0,0 +1,497 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	classifyRulesFileEnv     = "LISA_CLASSIFY_RULES_FILE"
+	defaultClassifyPaneLines = 40
+	classifyRulesCacheTTL    = time.Second
+)
+
+// classificationRule is one ordered entry of the session state machine. Rules
+// are evaluated by ascending priority; the first rule whose conditions all
+// hold decides status/sessionState/classificationReason.
+type classificationRule struct {
+	Name     string                 `json:"name"`
+	Priority int                    `json:"priority,omitempty"`
+	Agent    string                 `json:"agent,omitempty"`
+	Mode     string                 `json:"mode,omitempty"`
+	When     classificationRuleWhen `json:"when"`
+	State    string                 `json:"state,omitempty"`
+	Status   string                 `json:"status,omitempty"`
+	Reason   string                 `json:"reason,omitempty"`
+	Disabled bool                   `json:"disabled,omitempty"`
+
+	source    string
+	paneRegex *regexp.Regexp
+}
+
+type classificationRuleWhen struct {
+	Facts     map[string]bool `json:"facts,omitempty"`
+	MinPolls  int             `json:"minPolls,omitempty"`
+	MaxPolls  int             `json:"maxPolls,omitempty"`
+	PaneRegex string          `json:"paneRegex,omitempty"`
+	PaneLines int             `json:"paneLines,omitempty"`
+}
+
+type classificationThresholds struct {
+	AgentCPUBusy          float64 `json:"agentCpuBusy,omitempty"`
+	GracePolls            int     `json:"gracePolls,omitempty"`
+	InteractiveIdlePolls  int     `json:"interactiveIdlePolls,omitempty"`
+	HeartbeatStaleSeconds int     `json:"heartbeatStaleSeconds,omitempty"`
+}
+
+type classificationRulesFile struct {
+	ReplaceDefaults     bool                     `json:"replaceDefaults,omitempty"`
+	Thresholds          classificationThresholds `json:"thresholds"`
+	ShellPromptPatterns []string                 `json:"shellPromptPatterns,omitempty"`
+	Rules               []classificationRule     `json:"rules"`
+}
+
+type classificationRuleSet struct {
+	Source       string
+	Error        string
+	Thresholds   classificationThresholds
+	Rules        []classificationRule
+	ShellPrompts []*regexp.Regexp
+}
+
+// classificationFacts are the observed inputs rules match against.
+type classificationFacts struct {
+	Agent     string
+	Mode      string
+	PollCount int
+	Bools     map[string]bool
+	paneTail  func(lines int) string
+}
+
+type classificationRuleTrace struct {
+	Rule     string `json:"rule"`
+	Priority int    `json:"priority"`
+	Source   string `json:"source"`
+	Fired    bool   `json:"fired"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+type classificationTrace struct {
+	RulesSource string                    `json:"rulesSource"`
+	RulesError  string                    `json:"rulesError,omitempty"`
+	Facts       map[string]any            `json:"facts"`
+	Rules       []classificationRuleTrace `json:"rules"`
+	Fired       string                    `json:"fired,omitempty"`
+}
+
+func defaultClassificationThresholds() classificationThresholds {
+	return classificationThresholds{
+		AgentCPUBusy:          agentCPUBusyThreshold,
+		GracePolls:            3,
+		InteractiveIdlePolls:  3,
+		HeartbeatStaleSeconds: defaultHeartbeatStaleSecs,
+	}
+}
+
+// defaultClassificationRules mirrors the built-in state machine. Priorities are
+// spaced by 100 so project rules can slot in between. Agent hook rules sit
+// just after the terminal rules: a native lifecycle callback outranks every
+// CPU, pane and transcript heuristic while the agent process is still up.
+func defaultClassificationRules(t classificationThresholds) []classificationRule {
+	rules := []classificationRule{
+		{Name: "pane_crashed", When: classificationRuleWhen{Facts: map[string]bool{"paneCrashed": true}}, State: "crashed"},
+		{Name: "pane_exited_zero", When: classificationRuleWhen{Facts: map[string]bool{"paneExited": true, "paneExitZero": true}}, State: "completed"},
+		{Name: "pane_exited_nonzero", When: classificationRuleWhen{Facts: map[string]bool{"paneExited": true}}, State: "crashed"},
+		{Name: "done_file_completed", Reason: "done_file", When: classificationRuleWhen{Facts: map[string]bool{"doneFile": true, "doneFileExitZero": true}}, State: "completed"},
+		{Name: "done_file_crashed", Reason: "done_file", When: classificationRuleWhen{Facts: map[string]bool{"doneFile": true}}, State: "crashed"},
+		{Name: "interactive_idle_cpu", When: classificationRuleWhen{Facts: map[string]bool{"agentPidAlive": true, "interactive": true, "agentBusy": false}, MinPolls: t.InteractiveIdlePolls + 1}, State: "waiting_input"},
+		{Name: "agent_pid_alive", When: classificationRuleWhen{Facts: map[string]bool{"agentPidAlive": true}}, State: "in_progress"},
+		{Name: "interactive_child_process", When: classificationRuleWhen{Facts: map[string]bool{"interactiveShellProbe": true, "paneChildProcess": true}}, State: "in_progress"},
+		{Name: "interactive_shell_busy", When: classificationRuleWhen{Facts: map[string]bool{"interactiveShellProbe": true, "paneBusy": true}}, State: "in_progress"},
+		{Name: "interactive_shell_idle", When: classificationRuleWhen{Facts: map[string]bool{"interactiveShellProbe": true}}, State: "waiting_input"},
+		{Name: "heartbeat_fresh_agent_pid_unresolved", When: classificationRuleWhen{Facts: map[string]bool{"heartbeatFresh": true}}, State: "in_progress"},
+		{Name: "non_shell_command", When: classificationRuleWhen{Facts: map[string]bool{"paneIsShell": false}}, State: "in_progress"},
+		{Name: "done_file_read_error", When: classificationRuleWhen{Facts: map[string]bool{"doneFileReadError": true}}, State: "degraded"},
+		{Name: "agent_scan_error", When: classificationRuleWhen{Facts: map[string]bool{"agentScanError": true}}, State: "degraded"},
+		{Name: "grace_period_just_started", When: classificationRuleWhen{MaxPolls: t.GracePolls}, State: "just_started"},
+		{Name: "stuck_marker_run_mismatch", When: classificationRuleWhen{Facts: map[string]bool{"doneFileRunMismatch": true}}, State: "stuck"},
+		{Name: "stuck_no_signals", State: "stuck"},
+		{Name: "hook_needs_input", Priority: 520, Mode: "interactive", When: classificationRuleWhen{Facts: map[string]bool{"hookNeedsInput": true}}, State: "waiting_input"},
+		{Name: "hook_turn_complete", Priority: 540, Mode: "interactive", When: classificationRuleWhen{Facts: map[string]bool{"hookTurnComplete": true}}, State: "waiting_input"},
+	}
+	for i := range rules {
+		if rules[i].Priority == 0 {
+			rules[i].Priority = (i + 1) * 100
+		}
+		rules[i].source = "default"
+	}
+	return rules
+}
+
+var classificationRulesCache = struct {
+	sync.Mutex
+	entries map[string]classificationRulesCacheEntry
+}{entries: map[string]classificationRulesCacheEntry{}}
+
+type classificationRulesCacheEntry struct {
+	checkedAt time.Time
+	modTime   time.Time
+	set       classificationRuleSet
+}
+
+// classificationRulesPath resolves the project rules file: LISA_CLASSIFY_RULES_FILE
+// wins, otherwise <projectRoot>/.lisa/classify-rules.json when present.
+func classificationRulesPath(projectRoot string) string {
+	if path := strings.TrimSpace(os.Getenv(classifyRulesFileEnv)); path != "" {
+		if expanded, err := expandAndCleanPath(path); err == nil {
+			return expanded
+		}
+		return path
+	}
+	if strings.TrimSpace(projectRoot) == "" {
+		return ""
+	}
+	return filepath.Join(projectRoot, ".lisa", "classify-rules.json")
+}
+
+func loadClassificationRules(projectRoot string) classificationRuleSet {
+	path := classificationRulesPath(projectRoot)
+	if path == "" {
+		return buildClassificationRuleSet(classificationRulesFile{}, "default")
+	}
+	classificationRulesCache.Lock()
+	defer classificationRulesCache.Unlock()
+	now := time.Now()
+	entry, cached := classificationRulesCache.entries[path]
+	if cached && now.Sub(entry.checkedAt) < classifyRulesCacheTTL {
+		return entry.set
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		set := buildClassificationRuleSet(classificationRulesFile{}, "default")
+		if !os.IsNotExist(err) || strings.TrimSpace(os.Getenv(classifyRulesFileEnv)) != "" {
+			set.Error = fmt.Sprintf("rules file unavailable: %v", err)
+		}
+		classificationRulesCache.entries[path] = classificationRulesCacheEntry{checkedAt: now, set: set}
+		return set
+	}
+	if cached && info.ModTime().Equal(entry.modTime) {
+		entry.checkedAt = now
+		classificationRulesCache.entries[path] = entry
+		return entry.set
+	}
+	set := parseClassificationRulesFile(path)
+	classificationRulesCache.entries[path] = classificationRulesCacheEntry{checkedAt: now, modTime: info.ModTime(), set: set}
+	return set
+}
+
+func parseClassificationRulesFile(path string) classificationRuleSet {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		set := buildClassificationRuleSet(classificationRulesFile{}, "default")
+		set.Error = fmt.Sprintf("rules file unreadable: %v", err)
+		return set
+	}
+	file := classificationRulesFile{}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		set := buildClassificationRuleSet(classificationRulesFile{}, "default")
+		set.Error = fmt.Sprintf("invalid rules file %s: %v", path, err)
+		return set
+	}
+	if err := validateClassificationRulesFile(file); err != nil {
+		set := buildClassificationRuleSet(classificationRulesFile{}, "default")
+		set.Error = fmt.Sprintf("invalid rules file %s: %v", path, err)
+		return set
+	}
+	return buildClassificationRuleSet(file, path)
+}
+
+// classificationFactNames lists the boolean facts rules may reference.
+var classificationFactNames = map[string]bool{
+	"paneCrashed": true, "paneExited": true, "paneExitZero": true, "paneIsShell": true,
+	"paneChildProcess": true, "paneBusy": true, "paneShellPrompt": true,
+	"doneFile": true, "doneFileExitZero": true, "doneFileRunMismatch": true, "doneFileReadError": true,
+	"agentPidAlive": true, "agentBusy": true, "agentScanError": true,
+	"interactive": true, "interactiveShellProbe": true, "heartbeatFresh": true, "transcriptTurnComplete": true,
+	"hookTurnComplete": true, "hookNeedsInput": true,
+}
+
+var classificationStates = map[string]bool{
+	"just_started": true, "in_progress": true, "waiting_input": true, "completed": true,
+	"crashed": true, "stuck": true, "degraded": true,
+}
+
+func validateClassificationRulesFile(file classificationRulesFile) error {
+	for i, rule := range file.Rules {
+		if strings.TrimSpace(rule.Name) == "" {
+			return fmt.Errorf("rules[%d]: name is required", i)
+		}
+		if rule.Disabled {
+			continue
+		}
+		if rule.State != "" && !classificationStates[rule.State] {
+			return fmt.Errorf("rule %s: unsupported state %q", rule.Name, rule.State)
+		}
+		if rule.Status != "" && rule.Status != "active" && rule.Status != "idle" {
+			return fmt.Errorf("rule %s: status must be active|idle", rule.Name)
+		}
+		for name := range rule.When.Facts {
+			if !classificationFactNames[name] {
+				return fmt.Errorf("rule %s: unknown fact %q", rule.Name, name)
+			}
+		}
+		if rule.When.PaneRegex != "" {
+			if _, err := regexp.Compile(rule.When.PaneRegex); err != nil {
+				return fmt.Errorf("rule %s: invalid paneRegex: %v", rule.Name, err)
+			}
+		}
+	}
+	for _, pattern := range file.ShellPromptPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid shellPromptPatterns entry %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// buildClassificationRuleSet merges file rules over the defaults: same-name
+// rules replace (or disable) the default, new names are added, and the final
+// list is ordered by priority with default order breaking ties.
+func buildClassificationRuleSet(file classificationRulesFile, source string) classificationRuleSet {
+	thresholds := defaultClassificationThresholds()
+	if file.Thresholds.AgentCPUBusy > 0 {
+		thresholds.AgentCPUBusy = file.Thresholds.AgentCPUBusy
+	}
+	if file.Thresholds.GracePolls > 0 {
+		thresholds.GracePolls = file.Thresholds.GracePolls
+	}
+	if file.Thresholds.InteractiveIdlePolls > 0 {
+		thresholds.InteractiveIdlePolls = file.Thresholds.InteractiveIdlePolls
+	}
+	if file.Thresholds.HeartbeatStaleSeconds > 0 {
+		thresholds.HeartbeatStaleSeconds = file.Thresholds.HeartbeatStaleSeconds
+	}
+
+	rules := []classificationRule{}
+	if !file.ReplaceDefaults {
+		rules = defaultClassificationRules(thresholds)
+	}
+	for _, override := range file.Rules {
+		override.source = "file"
+		if override.When.PaneRegex != "" {
+			override.paneRegex = regexp.MustCompile(override.When.PaneRegex)
+		}
+		replaced := false
+		for i := range rules {
+			if rules[i].Name != override.Name {
+				continue
+			}
+			if override.Priority == 0 {
+				override.Priority = rules[i].Priority
+			}
+			rules[i] = override
+			replaced = true
+			break
+		}
+		if !replaced {
+			rules = append(rules, override)
+		}
+	}
+	filtered := rules[:0]
+	for _, rule := range rules {
+		if !rule.Disabled {
+			filtered = append(filtered, rule)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].Priority < filtered[j].Priority })
+
+	prompts := make([]*regexp.Regexp, 0, len(file.ShellPromptPatterns))
+	for _, pattern := range file.ShellPromptPatterns {
+		prompts = append(prompts, regexp.MustCompile(pattern))
+	}
+	return classificationRuleSet{Source: source, Thresholds: thresholds, Rules: filtered, ShellPrompts: prompts}
+}
+
+func (set classificationRuleSet) needsPaneCapture() bool {
+	for _, rule := range set.Rules {
+		if rule.paneRegex != nil {
+			return true
+		}
+		if _, ok := rule.When.Facts["paneShellPrompt"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate returns the first matching rule plus a trace of every rule
+// considered up to and including it.
+func (set classificationRuleSet) evaluate(facts classificationFacts) (classificationRule, []classificationRuleTrace, bool) {
+	trace := make([]classificationRuleTrace, 0, len(set.Rules))
+	for _, rule := range set.Rules {
+		entry := classificationRuleTrace{Rule: rule.Name, Priority: rule.Priority, Source: rule.source}
+		if detail := rule.mismatch(facts); detail != "" {
+			entry.Detail = detail
+			trace = append(trace, entry)
+			continue
+		}
+		entry.Fired = true
+		trace = append(trace, entry)
+		return rule, trace, true
+	}
+	return classificationRule{}, trace, false
+}
+
+// mismatch reports the first failing condition, or "" when the rule matches.
+func (rule classificationRule) mismatch(facts classificationFacts) string {
+	if rule.Agent != "" && !strings.EqualFold(rule.Agent, facts.Agent) {
+		return "agent!=" + rule.Agent
+	}
+	if rule.Mode != "" && !strings.EqualFold(rule.Mode, facts.Mode) {
+		return "mode!=" + rule.Mode
+	}
+	if rule.When.MinPolls > 0 && facts.PollCount < rule.When.MinPolls {
+		return fmt.Sprintf("poll<%d", rule.When.MinPolls)
+	}
+	if rule.When.MaxPolls > 0 && facts.PollCount > rule.When.MaxPolls {
+		return fmt.Sprintf("poll>%d", rule.When.MaxPolls)
+	}
+	names := make([]string, 0, len(rule.When.Facts))
+	for name := range rule.When.Facts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		want := rule.When.Facts[name]
+		if facts.Bools[name] != want {
+			return fmt.Sprintf("%s!=%t", name, want)
+		}
+	}
+	if rule.paneRegex != nil {
+		lines := rule.When.PaneLines
+		if lines <= 0 {
+			lines = defaultClassifyPaneLines
+		}
+		if facts.paneTail == nil || !rule.paneRegex.MatchString(facts.paneTail(lines)) {
+			return "paneRegex no match"
+		}
+	}
+	return ""
+}
+
+func (rule classificationRule) outcome() (status, state, reason string) {
+	state = rule.State
+	if state == "" {
+		state = "in_progress"
+	}
+	status = rule.Status
+	if status == "" {
+		status = "idle"
+		if state == "in_progress" {
+			status = "active"
+		}
+	}
+	reason = rule.Reason
+	if reason == "" {
+		reason = rule.Name
+	}
+	return status, state, reason
+}
+
+func (facts classificationFacts) traceFacts() map[string]any {
+	out := make(map[string]any, len(facts.Bools)+3)
+	for name, value := range facts.Bools {
+		out[name] = value
+	}
+	out["agent"] = facts.Agent
+	out["mode"] = facts.Mode
+	out["pollCount"] = facts.PollCount
+	return out
+}
+
+// matchesCustomShellPrompt checks project shellPromptPatterns so new prompt
+// glyphs can be recognized without a release.
+func matchesCustomShellPrompt(line string) bool {
+	root := strings.TrimSpace(os.Getenv(lisaProjectRootEnv))
+	if root == "" && strings.TrimSpace(os.Getenv(classifyRulesFileEnv)) == "" {
+		return false
+	}
+	for _, re := range loadClassificationRules(root).ShellPrompts {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// classificationPaneTail returns a lazy, memoized pane reader so captures only
+// happen when a rule actually inspects pane text.
+func classificationPaneTail(session string, rules classificationRuleSet) func(lines int) string {
+	if !rules.needsPaneCapture() {
+		return nil
+	}
+	cache := map[int]string{}
+	return func(lines int) string {
+		if text, ok := cache[lines]; ok {
+			return text
+		}
+		capture, err := tmuxCapturePaneFn(session, lines)
+		if err != nil {
+			cache[lines] = ""
+			return ""
+		}
+		text := strings.Join(nonEmptyTailLines(capture, lines), "\n")
+		cache[lines] = text
+		return text
+	}
+}
+
+func classifySessionWithRules(status *sessionStatus, rules classificationRuleSet, facts classificationFacts) {
+	if facts.paneTail != nil {
+		tail := facts.paneTail(defaultClassifyPaneLines)
+		lines := strings.Split(tail, "\n")
+		facts.Bools["paneShellPrompt"] = tail != "" && isLikelyShellPromptLine(lines[len(lines)-1])
+	}
+	rule, trace, ok := rules.evaluate(facts)
+	status.ClassificationTrace = &classificationTrace{
+		RulesSource: rules.Source,
+		RulesError:  rules.Error,
+		Facts:       facts.traceFacts(),
+		Rules:       trace,
+	}
+	if !ok {
+		status.Status = "idle"
+		status.SessionState = "stuck"
+		status.WaitEstimate = 0
+		status.ClassificationReason = "no_rule_matched"
+		return
+	}
+	status.ClassificationTrace.Fired = rule.Name
+	status.Status, status.SessionState, status.ClassificationReason = rule.outcome()
+	status.WaitEstimate = 0
+
+	paneTerminal := facts.Bools["paneCrashed"] || facts.Bools["paneExited"]
+	if !paneTerminal && !facts.Bools["doneFile"] {
+		if facts.Bools["agentPidAlive"] {
+			status.Signals.ActiveProcessBusy = facts.Bools["agentBusy"]
+		} else if facts.Bools["interactiveShellProbe"] {
+			status.Signals.ActiveProcessBusy = facts.Bools["paneBusy"]
+		}
+	}
+	if status.SessionState == "waiting_input" {
+		status.Signals.InteractiveWaiting = true
+		if rule.paneRegex != nil {
+			status.Signals.PromptWaiting = true
+		}
+	}
+	if status.Status == "active" && status.ActiveTask == "" {
+		status.ActiveTask = fmt.Sprintf("%s running", agentDisplayName(facts.Agent))
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeClassifyRulesFixture(t *testing.T, body string) string {
+	t.Helper()
+	projectRoot := t.TempDir()
+	dir := filepath.Join(projectRoot, ".lisa")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "classify-rules.json"), []byte(body), 0o600); err != nil {
+		t.Fatalf("write rules failed: %v", err)
+	}
+	return projectRoot
+}
+
+func TestDefaultClassificationRulesOrder(t *testing.T) {
+	set := loadClassificationRules(t.TempDir())
+	if set.Source != "default" || set.Error != "" {
+		t.Fatalf("unexpected default rule set: source=%q error=%q", set.Source, set.Error)
+	}
+	if len(set.Rules) != 19 || set.Rules[0].Name != "pane_crashed" || set.Rules[18].Name != "stuck_no_signals" {
+		t.Fatalf("unexpected default rules: %+v", set.Rules)
+	}
+	if set.Rules[5].Name != "hook_needs_input" || set.Rules[6].Name != "hook_turn_complete" {
+		t.Fatalf("expected hook rules right after the terminal rules, got %s, %s", set.Rules[5].Name, set.Rules[6].Name)
+	}
+	for i, rule := range set.Rules {
+		if strings.HasPrefix(rule.Name, "hook_") {
+			continue
+		}
+		want := (i + 1) * 100
+		if i > 6 {
+			want = (i - 1) * 100
+		}
+		if rule.Priority != want {
+			t.Fatalf("rule %s has priority %d, want %d", rule.Name, rule.Priority, want)
+		}
+	}
+}
+
+func TestClassificationRulesFileOverridesAndPaneRegex(t *testing.T) {
+	projectRoot := writeClassifyRulesFixture(t, `{
+  "thresholds": {"gracePolls": 1},
+  "rules": [
+    {"name": "approval_prompt", "priority": 650, "agent": "claude", "when": {"paneRegex": "Do you want to proceed\\?"}, "state": "waiting_input"},
+    {"name": "stuck_no_signals", "state": "degraded", "reason": "custom_fallback"}
+  ]
+}`)
+	set := loadClassificationRules(projectRoot)
+	if set.Error != "" || set.Thresholds.GracePolls != 1 {
+		t.Fatalf("unexpected rule set: %+v", set)
+	}
+
+	origCapture := tmuxCapturePaneFn
+	t.Cleanup(func() { tmuxCapturePaneFn = origCapture })
+	captures := 0
+	tmuxCapturePaneFn = func(session string, lines int) (string, error) {
+		captures++
+		return "Edit main.go\nDo you want to proceed?\n", nil
+	}
+
+	status := sessionStatus{Session: "lisa-rules"}
+	classifySessionWithRules(&status, set, classificationFacts{
+		Agent:     "claude",
+		Mode:      "interactive",
+		PollCount: 5,
+		Bools:     map[string]bool{"agentPidAlive": true, "agentBusy": true, "paneIsShell": false},
+		paneTail:  classificationPaneTail("lisa-rules", set),
+	})
+	// agent_pid_alive (700) would fire, but approval_prompt (650) sits before it.
+	if status.SessionState != "waiting_input" || status.ClassificationReason != "approval_prompt" {
+		t.Fatalf("expected approval_prompt to fire, got state=%s reason=%s", status.SessionState, status.ClassificationReason)
+	}
+	if captures != 1 {
+		t.Fatalf("expected a single memoized pane capture, got %d", captures)
+	}
+	if status.ClassificationTrace == nil || status.ClassificationTrace.Fired != "approval_prompt" {
+		t.Fatalf("unexpected trace: %+v", status.ClassificationTrace)
+	}
+
+	status = sessionStatus{Session: "lisa-rules"}
+	classifySessionWithRules(&status, set, classificationFacts{Agent: "codex", PollCount: 5, Bools: map[string]bool{"paneIsShell": true}})
+	if status.SessionState != "degraded" || status.ClassificationReason != "custom_fallback" {
+		t.Fatalf("expected overridden fallback, got state=%s reason=%s", status.SessionState, status.ClassificationReason)
+	}
+}
+
+func TestClassificationRulesInvalidFileFallsBack(t *testing.T) {
+	projectRoot := writeClassifyRulesFixture(t, `{"rules":[{"name":"bad","when":{"facts":{"paneOnFire":true}},"state":"stuck"}]}`)
+	set := loadClassificationRules(projectRoot)
+	if set.Source != "default" || !strings.Contains(set.Error, `unknown fact "paneOnFire"`) {
+		t.Fatalf("expected fallback with rules error, got source=%q error=%q", set.Source, set.Error)
+	}
+	if len(set.Rules) != 19 {
+		t.Fatalf("expected default rules after fallback, got %d", len(set.Rules))
+	}
+
+	projectRoot = writeClassifyRulesFixture(t, `{"rules":[{"name":"bad","when":{"paneRegex":"("}}]}`)
+	if set := loadClassificationRules(projectRoot); !strings.Contains(set.Error, "paneRegex") {
+		t.Fatalf("expected regex validation error, got %q", set.Error)
+	}
+}
+
+func TestCmdSessionExplainTraceIncludesRuleEvaluation(t *testing.T) {
+	projectRoot := t.TempDir()
+	origCompute := computeSessionStatusFn
+	t.Cleanup(func() { computeSessionStatusFn = origCompute })
+	computeSessionStatusFn = func(session, projectRoot, agentHint, modeHint string, full bool, pollCount int) (sessionStatus, error) {
+		status := sessionStatus{Session: session, Agent: "claude", Mode: "interactive"}
+		classifySessionWithRules(&status, loadClassificationRules(projectRoot), classificationFacts{
+			Agent: "claude",
+			Mode:  "interactive",
+			Bools: map[string]bool{"paneExited": true, "paneExitZero": true},
+		})
+		return status, nil
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionExplain([]string{"--session", "lisa-trace", "--project-root", projectRoot, "--trace", "--json"})
+		if code != 0 {
+			t.Fatalf("expected success, got %d", code)
+		}
+	})
+	var payload struct {
+		Trace classificationTrace `json:"trace"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("failed to parse payload: %v (%q)", err, stdout)
+	}
+	if payload.Trace.Fired != "pane_exited_zero" || len(payload.Trace.Rules) != 2 {
+		t.Fatalf("unexpected trace: %+v", payload.Trace)
+	}
+	if payload.Trace.Rules[0].Rule != "pane_crashed" || payload.Trace.Rules[0].Detail != "paneCrashed!=true" {
+		t.Fatalf("unexpected skipped rule trace: %+v", payload.Trace.Rules[0])
+	}
+}
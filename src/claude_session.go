@@ -24,12 +24,24 @@ type claudeMessage struct {
 }
 
 type claudeContentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
 }
 
+// transcriptMessage is one normalized transcript entry. Index is the entry's
+// position in the full transcript (tool entries included) and is the unit for
+// capture --delta-from offsets in transcript mode.
 type transcriptMessage struct {
+	Index     int    `json:"index"`
 	Role      string `json:"role"`
+	Kind      string `json:"kind,omitempty"`
+	Tool      string `json:"tool,omitempty"`
+	CallID    string `json:"callId,omitempty"`
 	Text      string `json:"text"`
 	Timestamp string `json:"timestamp"`
 }
@@ -225,19 +237,76 @@ func readClaudeTranscript(jsonlPath string) ([]transcriptMessage, error) {
 		if entry.Type != "user" && entry.Type != "assistant" {
 			continue
 		}
-		text := extractMessageText(entry.Message, entry.Type)
-		if text == "" {
-			continue
+		if text := extractMessageText(entry.Message, entry.Type); text != "" {
+			messages = append(messages, transcriptMessage{
+				Index:     len(messages),
+				Role:      entry.Type,
+				Kind:      transcriptKindMessage,
+				Text:      text,
+				Timestamp: entry.Timestamp,
+			})
+		}
+		for _, tool := range extractClaudeToolEntries(entry.Message, entry.Type) {
+			tool.Index = len(messages)
+			tool.Timestamp = entry.Timestamp
+			messages = append(messages, tool)
 		}
-		messages = append(messages, transcriptMessage{
-			Role:      entry.Type,
-			Text:      text,
-			Timestamp: entry.Timestamp,
-		})
 	}
 	return messages, scanner.Err()
 }
 
+// extractClaudeToolEntries returns tool_use blocks (assistant) and tool_result
+// blocks (user) as transcript entries, in content order.
+func extractClaudeToolEntries(raw json.RawMessage, role string) []transcriptMessage {
+	var msg claudeMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil
+	}
+	var blocks []claudeContentBlock
+	if err := json.Unmarshal(msg.Content, &blocks); err != nil {
+		return nil
+	}
+	var out []transcriptMessage
+	for _, b := range blocks {
+		switch b.Type {
+		case "tool_use":
+			out = append(out, transcriptMessage{
+				Role:   role,
+				Kind:   transcriptKindToolCall,
+				Tool:   b.Name,
+				CallID: b.ID,
+				Text:   compactTranscriptJSON(b.Input),
+			})
+		case "tool_result":
+			out = append(out, transcriptMessage{
+				Role:   role,
+				Kind:   transcriptKindToolResult,
+				CallID: b.ToolUseID,
+				Text:   claudeToolResultText(b.Content),
+			})
+		}
+	}
+	return out
+}
+
+func claudeToolResultText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	var blocks []claudeContentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" && strings.TrimSpace(b.Text) != "" {
+			parts = append(parts, strings.TrimSpace(b.Text))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 func extractMessageText(raw json.RawMessage, role string) string {
 	var msg claudeMessage
 	if err := json.Unmarshal(raw, &msg); err != nil {
@@ -373,26 +442,27 @@ func formatTranscriptPlain(messages []transcriptMessage) string {
 	return sb.String()
 }
 
-func writeTranscriptCapture(session, sessionID string, messages []transcriptMessage, jsonOut, jsonMin bool) int {
+func writeTranscriptCapture(session, agent, sessionID string, messages []transcriptMessage, jsonOut, jsonMin bool) int {
+	messages = conversationTranscriptMessages(messages)
 	if jsonOut {
 		if jsonMin {
 			writeJSON(map[string]any{
-				"session":       session,
-				"claudeSession": sessionID,
-				"messageCount":  len(messages),
-				"capture":       formatTranscriptPlain(messages),
+				"session":                   session,
+				transcriptSessionKey(agent): sessionID,
+				"messageCount":              len(messages),
+				"capture":                   formatTranscriptPlain(messages),
 			})
 			return 0
 		}
 		writeJSON(map[string]any{
-			"session":       session,
-			"claudeSession": sessionID,
-			"messages":      messages,
+			"session":                   session,
+			transcriptSessionKey(agent): sessionID,
+			"messages":                  messages,
 		})
 		return 0
 	}
 
-	fmt.Print(formatTranscriptPlain(messages))
+	fmt.Print(redactOutputText(formatTranscriptPlain(messages)))
 	return 0
 }
 
@@ -414,6 +484,9 @@ func captureSessionTranscript(session, projectRoot string) (string, []transcript
 			return "", nil, fmt.Errorf("cannot load session metadata: %w", err)
 		}
 	}
+	if normalizeAgent(meta.Agent) == "codex" {
+		return captureCodexSessionTranscript(session, meta)
+	}
 	if strings.TrimSpace(meta.Prompt) == "" || strings.TrimSpace(meta.CreatedAt) == "" {
 		return "", nil, fmt.Errorf("cannot find Claude transcript: session metadata missing prompt/createdAt")
 	}
@@ -438,5 +511,5 @@ func cmdSessionCaptureTranscript(session, projectRoot string, jsonOut bool) int
 		fmt.Fprintln(os.Stderr, err.Error())
 		return 1
 	}
-	return writeTranscriptCapture(session, sessionID, messages, jsonOut, false)
+	return writeTranscriptCapture(session, transcriptCaptureAgent(session, projectRoot), sessionID, messages, jsonOut, false)
 }
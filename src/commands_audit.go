@@ -0,0 +1,244 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func cmdAudit(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: lisa audit <subcommand>")
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		return showHelp("audit")
+	}
+	if args[0] == "help" {
+		if len(args) > 1 {
+			return showHelp("audit " + args[1])
+		}
+		return showHelp("audit")
+	}
+
+	switch args[0] {
+	case "list":
+		return cmdAuditList(args[1:])
+	case "show":
+		return cmdAuditShow(args[1:])
+	case "verify":
+		return cmdAuditVerify(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown audit subcommand: %s\n", args[0])
+		return 1
+	}
+}
+
+func cmdAuditList(args []string) int {
+	session := ""
+	command := ""
+	caller := ""
+	var since time.Time
+	limit := auditDefaultListLimit
+	failedOnly := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("audit list")
+		case "--session", "--command", "--caller", "--since", "--limit":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--session":
+				session = value
+			case "--command":
+				command = strings.TrimSpace(value)
+			case "--caller":
+				caller = value
+			case "--since":
+				parsed, err := parseSinceFlag("--since", value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_since", err.Error())
+				}
+				since = parsed
+			case "--limit":
+				n, err := parseNonNegativeIntFlag(value, "--limit")
+				if err != nil {
+					return commandError(jsonOut, "invalid_limit", err.Error())
+				}
+				limit = n
+			}
+			i++
+		case "--failed":
+			failedOnly = true
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+
+	path, err := auditLogPath()
+	if err != nil {
+		return commandError(jsonOut, "audit_path_failed", err.Error())
+	}
+	entries, err := readAuditEntries(path)
+	if err != nil {
+		return commandErrorf(jsonOut, "audit_read_failed", "failed reading audit log: %v", err)
+	}
+	matched := []auditEntry{}
+	for _, entry := range entries {
+		if session != "" && entry.Session != session {
+			continue
+		}
+		if command != "" && entry.Command != command && !strings.HasSuffix(entry.Command, " "+command) {
+			continue
+		}
+		if caller != "" && entry.CallerSession != caller {
+			continue
+		}
+		if failedOnly && entry.OK {
+			continue
+		}
+		if !since.IsZero() {
+			at, parseErr := time.Parse(time.RFC3339Nano, entry.At)
+			if parseErr != nil || at.Before(since) {
+				continue
+			}
+		}
+		matched = append(matched, entry)
+	}
+	total := len(matched)
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+
+	if jsonOut {
+		writeJSON(map[string]any{
+			"path":    path,
+			"total":   total,
+			"count":   len(matched),
+			"entries": matched,
+		})
+		return 0
+	}
+	for _, entry := range matched {
+		fmt.Println(formatAuditLine(entry))
+	}
+	return 0
+}
+
+func cmdAuditShow(args []string) int {
+	seq := 0
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("audit show")
+		case "--seq":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --seq")
+			}
+			n, err := parsePositiveIntFlag(args[i+1], "--seq")
+			if err != nil {
+				return commandError(jsonOut, "invalid_seq", err.Error())
+			}
+			seq = n
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if seq == 0 {
+		return commandError(jsonOut, "missing_required_flag", "--seq is required")
+	}
+
+	path, err := auditLogPath()
+	if err != nil {
+		return commandError(jsonOut, "audit_path_failed", err.Error())
+	}
+	entries, err := readAuditEntries(path)
+	if err != nil {
+		return commandErrorf(jsonOut, "audit_read_failed", "failed reading audit log: %v", err)
+	}
+	entry, found := findAuditEntry(entries, seq)
+	if !found {
+		return commandErrorf(jsonOut, "audit_entry_not_found", "audit entry not found: %d", seq)
+	}
+	if jsonOut {
+		writeJSON(map[string]any{"path": path, "entry": entry})
+		return 0
+	}
+	fmt.Printf("seq: %d\n", entry.Seq)
+	fmt.Printf("at: %s\n", entry.At)
+	fmt.Printf("command: %s\n", entry.Command)
+	fmt.Printf("args: %s\n", strings.Join(entry.Args, " "))
+	if entry.Session != "" {
+		fmt.Printf("session: %s\n", entry.Session)
+	}
+	if entry.Text != "" {
+		fmt.Printf("text: %s\n", entry.Text)
+	}
+	if entry.CallerSession != "" {
+		fmt.Printf("caller: %s\n", entry.CallerSession)
+	}
+	fmt.Printf("cwd: %s\n", entry.Cwd)
+	fmt.Printf("user: %s\n", entry.User)
+	fmt.Printf("pid: %d\n", entry.PID)
+	fmt.Printf("exit: %d\n", entry.ExitCode)
+	fmt.Printf("hash: %s\n", entry.Hash)
+	return 0
+}
+
+func cmdAuditVerify(args []string) int {
+	jsonOut := hasJSONFlag(args)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("audit verify")
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+
+	path, err := auditLogPath()
+	if err != nil {
+		return commandError(jsonOut, "audit_path_failed", err.Error())
+	}
+	result := verifyAuditLog(path)
+	if jsonOut {
+		payload := map[string]any{
+			"ok":      result.OK,
+			"path":    result.Path,
+			"entries": result.Entries,
+			"headSeq": result.HeadSeq,
+		}
+		if !result.OK {
+			payload["errorCode"] = "audit_chain_broken"
+			payload["brokenAt"] = result.BrokenAt
+			payload["errors"] = result.Errors
+		}
+		writeJSON(payload)
+		return boolExit(result.OK)
+	}
+	if result.OK {
+		fmt.Printf("ok: %d entries verified\n", result.Entries)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "audit chain broken at seq %s\n", strconv.Itoa(result.BrokenAt))
+	for _, msg := range result.Errors {
+		fmt.Fprintln(os.Stderr, "  "+msg)
+	}
+	return 1
+}
@@ -27,7 +27,22 @@ var commandCapabilities = []commandCapability{
 	{
 		Name:        "cleanup",
 		Description: "Sweep stalled tmux sockets",
-		Flags:       []string{"--dry-run", "--include-tmux-default", "--json"},
+		Flags:       []string{"--dry-run", "--include-tmux-default", "--force", "--json"},
+	},
+	{
+		Name:        "classify",
+		Description: "Replay recorded status fixtures through the classifier",
+		Flags:       []string{"--fixture", "--trace", "--json"},
+	},
+	{
+		Name:        "fake-agent",
+		Description: "Scripted claude/codex simulator that writes agent-compatible transcripts",
+		Flags:       []string{"--persona", "--script"},
+	},
+	{
+		Name:        "fake-agent install",
+		Description: "Install claude/codex PATH shims that run fake-agent",
+		Flags:       []string{"--dir", "--script", "--persona", "--json"},
 	},
 	{
 		Name:        "version",
@@ -44,6 +59,7 @@ var commandCapabilities = []commandCapability{
 			"--agent",
 			"--mode",
 			"--lane",
+			"--label",
 			"--nested-policy",
 			"--nesting-intent",
 			"--session",
@@ -54,6 +70,10 @@ var commandCapabilities = []commandCapability{
 			"--project-root",
 			"--width",
 			"--height",
+			"--max-runtime",
+			"--idle-timeout",
+			"--memory-max",
+			"--cpu-quota",
 			"--cleanup-all-hashes",
 			"--dry-run",
 			"--detect-nested",
@@ -67,7 +87,15 @@ var commandCapabilities = []commandCapability{
 	},
 	{
 		Name:  "session send",
-		Flags: []string{"--session", "--project-root", "--text", "--keys", "--enter", "--json", "--json-min"},
+		Flags: []string{"--session", "--subtree", "--project-root", "--text", "--keys", "--enter", "--when", "--json", "--json-min"},
+	},
+	{
+		Name:  "session inbox",
+		Flags: []string{"--session", "--id", "--project-root", "--json"},
+	},
+	{
+		Name:  "session answer",
+		Flags: []string{"--session", "--project-root", "--agent", "--mode", "--option", "--text", "--policy", "--force", "--json"},
 	},
 	{
 		Name: "session snapshot",
@@ -95,18 +123,23 @@ var commandCapabilities = []commandCapability{
 			"--project-root",
 			"--full",
 			"--fail-not-found",
+			"--record",
 			"--json",
 			"--json-min",
 		},
 	},
 	{
 		Name:  "session explain",
-		Flags: []string{"--session", "--agent", "--mode", "--project-root", "--events", "--recent", "--since", "--json", "--json-min"},
+		Flags: []string{"--session", "--agent", "--mode", "--project-root", "--events", "--recent", "--since", "--trace", "--json", "--json-min"},
 	},
 	{
 		Name: "session monitor",
 		Flags: []string{
 			"--session",
+			"--sessions",
+			"--tree",
+			"--label",
+			"--until",
 			"--project-root",
 			"--agent",
 			"--mode",
@@ -140,6 +173,7 @@ var commandCapabilities = []commandCapability{
 			"--project-root",
 			"--lines",
 			"--raw",
+			"--format",
 			"--delta-from",
 			"--cursor-file",
 			"--markers",
@@ -155,9 +189,13 @@ var commandCapabilities = []commandCapability{
 			"--json-min",
 		},
 	},
+	{
+		Name:  "session tail",
+		Flags: []string{"--session", "--sessions", "--tree", "--label", "--all", "--project-root", "--follow", "--lines", "--since", "--grep", "--raw", "--poll-interval", "--max-polls", "--no-color", "--json"},
+	},
 	{
 		Name:  "session packet",
-		Flags: []string{"--session", "--project-root", "--agent", "--mode", "--lines", "--events", "--token-budget", "--summary-style", "--cursor-file", "--delta-json", "--fields", "--json", "--json-min"},
+		Flags: []string{"--session", "--label", "--project-root", "--agent", "--mode", "--lines", "--events", "--token-budget", "--summary-style", "--cursor-file", "--delta-json", "--fields", "--json", "--json-min"},
 	},
 	{
 		Name:  "session turn",
@@ -173,7 +211,7 @@ var commandCapabilities = []commandCapability{
 	},
 	{
 		Name:  "session checkpoint",
-		Flags: []string{"--action", "--session", "--file", "--project-root", "--events", "--lines", "--strategy", "--token-budget", "--json"},
+		Flags: []string{"--action", "--session", "--file", "--project-root", "--events", "--lines", "--strategy", "--token-budget", "--archive", "--include-diff", "--apply-diff", "--spawn", "--force", "--json"},
 	},
 	{
 		Name:  "session dedupe",
@@ -185,7 +223,7 @@ var commandCapabilities = []commandCapability{
 	},
 	{
 		Name:  "session aggregate",
-		Flags: []string{"--sessions", "--project-root", "--strategy", "--events", "--lines", "--token-budget", "--dedupe", "--delta-json", "--cursor-file", "--json", "--json-min"},
+		Flags: []string{"--sessions", "--label", "--project-root", "--strategy", "--events", "--lines", "--token-budget", "--dedupe", "--delta-json", "--cursor-file", "--json", "--json-min"},
 	},
 	{
 		Name:  "session prompt-lint",
@@ -261,7 +299,7 @@ var commandCapabilities = []commandCapability{
 	},
 	{
 		Name:  "session tree",
-		Flags: []string{"--session", "--project-root", "--all-hashes", "--active-only", "--delta", "--delta-json", "--cursor-file", "--flat", "--with-state", "--json", "--json-min"},
+		Flags: []string{"--session", "--label", "--project-root", "--all-hashes", "--active-only", "--delta", "--delta-json", "--cursor-file", "--flat", "--with-state", "--format", "--json", "--json-min"},
 	},
 	{
 		Name:  "session smoke",
@@ -273,19 +311,23 @@ var commandCapabilities = []commandCapability{
 	},
 	{
 		Name:  "session list",
-		Flags: []string{"--all-sockets", "--project-only", "--active-only", "--with-next-action", "--priority", "--stale", "--prune-preview", "--delta-json", "--cursor-file", "--watch-json", "--watch-interval", "--watch-cycles", "--project-root", "--json", "--json-min"},
+		Flags: []string{"--all-sockets", "--project-only", "--active-only", "--label", "--with-next-action", "--priority", "--stale", "--prune-preview", "--delta-json", "--cursor-file", "--watch-json", "--watch-interval", "--watch-cycles", "--project-root", "--json", "--json-min"},
 	},
 	{
 		Name:  "session exists",
 		Flags: []string{"--session", "--project-root", "--json"},
 	},
+	{
+		Name:  "session stop",
+		Flags: []string{"--session", "--project-root", "--grace", "--cleanup-all-hashes", "--json"},
+	},
 	{
 		Name:  "session kill",
-		Flags: []string{"--session", "--project-root", "--cleanup-all-hashes", "--json"},
+		Flags: []string{"--session", "--project-root", "--cleanup-all-hashes", "--recursive", "--grace", "--json"},
 	},
 	{
 		Name:  "session kill-all",
-		Flags: []string{"--project-root", "--cleanup-all-hashes", "--project-only", "--json"},
+		Flags: []string{"--project-root", "--cleanup-all-hashes", "--project-only", "--label", "--force", "--json"},
 	},
 	{
 		Name:  "agent build-cmd",
@@ -303,6 +345,86 @@ var commandCapabilities = []commandCapability{
 		Name:  "oauth remove",
 		Flags: []string{"--id", "--json"},
 	},
+	{
+		Name:  "queue add",
+		Flags: []string{"--prompt", "--agent", "--mode", "--lane", "--model", "--priority", "--task-hash", "--retries", "--project-root", "--json"},
+	},
+	{
+		Name:  "queue list",
+		Flags: []string{"--state", "--project-root", "--json"},
+	},
+	{
+		Name:  "queue cancel",
+		Flags: []string{"--id", "--all", "--project-root", "--json"},
+	},
+	{
+		Name:  "queue drain",
+		Flags: []string{"--cancel-queued", "--project-root", "--json"},
+	},
+	{
+		Name:  "queue work",
+		Flags: []string{"--concurrency", "--poll-interval", "--max-polls", "--retry-on", "--keep-sessions", "--watch", "--project-root", "--json"},
+	},
+	{
+		Name:  "audit list",
+		Flags: []string{"--session", "--command", "--caller", "--since", "--failed", "--limit", "--json"},
+	},
+	{
+		Name:  "audit show",
+		Flags: []string{"--seq", "--json"},
+	},
+	{
+		Name:  "audit verify",
+		Flags: []string{"--json"},
+	},
+	{
+		Name:  "history list",
+		Flags: []string{"--since", "--state", "--agent", "--project-root", "--limit", "--json"},
+	},
+	{
+		Name:  "history show",
+		Flags: []string{"--session", "--run-id", "--json"},
+	},
+	{
+		Name:  "history search",
+		Flags: []string{"--since", "--state", "--agent", "--project-root", "--limit", "--json"},
+	},
+	{
+		Name:  "events query",
+		Flags: []string{"--project-root", "--all-hashes", "--session-glob", "--type", "--state", "--reason", "--since", "--until", "--where", "--format", "--group-by", "--count", "--limit", "--json"},
+	},
+	{
+		Name:  "payload decode",
+		Flags: []string{"--json"},
+	},
+	{
+		Name:  "report",
+		Flags: []string{"--since", "--until", "--project-root", "--format", "--json"},
+	},
+	{
+		Name:  "result put",
+		Flags: []string{"--status", "--summary", "--artifact", "--session", "--run-id", "--project-root", "--json"},
+	},
+	{
+		Name:  "result get",
+		Flags: []string{"--session", "--project-root", "--json"},
+	},
+	{
+		Name:  "msg post",
+		Flags: []string{"--to", "--kind", "--body", "--from", "--project-root", "--json"},
+	},
+	{
+		Name:  "msg read",
+		Flags: []string{"--session", "--from", "--kind", "--limit", "--peek", "--all", "--project-root", "--json"},
+	},
+	{
+		Name:  "msg ack",
+		Flags: []string{"--session", "--seq", "--project-root", "--json"},
+	},
+	{
+		Name:  "hook",
+		Flags: []string{"--agent", "--event", "--session", "--project-root", "--json"},
+	},
 	{
 		Name:  "skills sync",
 		Flags: []string{"--from", "--path", "--repo-root", "--json"},
@@ -132,14 +132,38 @@ func TestCommandCapabilitiesCommandSet(t *testing.T) {
 
 	want := []string{
 		"agent build-cmd",
+		"audit list",
+		"audit show",
+		"audit verify",
 		"capabilities",
+		"classify",
 		"cleanup",
 		"doctor",
+		"events query",
+		"fake-agent",
+		"fake-agent install",
+		"history list",
+		"history search",
+		"history show",
+		"hook",
+		"msg ack",
+		"msg post",
+		"msg read",
 		"oauth add",
 		"oauth list",
 		"oauth remove",
+		"payload decode",
+		"queue add",
+		"queue cancel",
+		"queue drain",
+		"queue list",
+		"queue work",
+		"report",
+		"result get",
+		"result put",
 		"session capture",
 		"session anomaly",
+		"session answer",
 		"session aggregate",
 		"session budget-observe",
 		"session budget-enforce",
@@ -156,6 +180,7 @@ func TestCommandCapabilitiesCommandSet(t *testing.T) {
 		"session autopilot",
 		"session guard",
 		"session handoff",
+		"session inbox",
 		"session kill",
 		"session kill-all",
 		"session lane",
@@ -178,6 +203,8 @@ func TestCommandCapabilitiesCommandSet(t *testing.T) {
 		"session spawn",
 		"session state-sandbox",
 		"session status",
+		"session stop",
+		"session tail",
 		"session tree",
 		"session turn",
 		"skills doctor",
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// cmdClassify replays fixtures recorded by `session status --record` through
+// the classifier and compares the outcome with the fixture's expectation.
+func cmdClassify(args []string) int {
+	fixtureArgs := []string{}
+	trace := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("classify")
+		case "--fixture":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --fixture")
+			}
+			fixtureArgs = append(fixtureArgs, args[i+1])
+			i++
+		case "--trace":
+			trace = true
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if len(fixtureArgs) == 0 {
+		return commandError(jsonOut, "missing_required_flag", "--fixture is required")
+	}
+
+	fixtures := []string{}
+	for _, raw := range fixtureArgs {
+		dirs, err := resolveClassifyFixtureDirs(raw)
+		if err != nil {
+			return commandErrorf(jsonOut, "fixture_not_found", "%v", err)
+		}
+		fixtures = append(fixtures, dirs...)
+	}
+
+	rows := make([]map[string]any, 0, len(fixtures))
+	matched := 0
+	for _, dir := range fixtures {
+		result, err := replayClassifyFixture(dir)
+		if err != nil {
+			return commandErrorf(jsonOut, "fixture_replay_failed", "failed replaying %s: %v", dir, err)
+		}
+		if result.Match {
+			matched++
+		}
+		if !jsonOut {
+			printClassifyReplay(result, trace)
+			continue
+		}
+		row := map[string]any{
+			"fixture":              dir,
+			"session":              result.Manifest.Session,
+			"recordedAt":           result.Manifest.RecordedAt,
+			"status":               result.Status.Status,
+			"sessionState":         result.Status.SessionState,
+			"classificationReason": result.Status.ClassificationReason,
+			"expected":             result.Manifest.Expected,
+			"match":                result.Match,
+		}
+		if trace {
+			row["trace"] = explainClassificationTrace("", result.Status)
+		}
+		rows = append(rows, row)
+	}
+
+	ok := matched == len(fixtures)
+	if jsonOut {
+		payload := map[string]any{
+			"ok":       ok,
+			"total":    len(fixtures),
+			"matched":  matched,
+			"fixtures": rows,
+		}
+		if !ok {
+			payload["errorCode"] = "fixture_mismatch"
+		}
+		writeJSON(payload)
+	} else {
+		fmt.Printf("%d/%d fixtures matched\n", matched, len(fixtures))
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
+
+// resolveClassifyFixtureDirs accepts a fixture directory or a corpus directory
+// whose immediate children are fixtures.
+func resolveClassifyFixtureDirs(raw string) ([]string, error) {
+	dir, err := expandAndCleanPath(raw)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, classifyFixtureManifest)); err == nil {
+		return []string{dir}, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("fixture directory not found: %s", dir)
+	}
+	dirs := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		child := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(child, classifyFixtureManifest)); err == nil {
+			dirs = append(dirs, child)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no %s found in %s", classifyFixtureManifest, dir)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func printClassifyReplay(result classifyReplayResult, trace bool) {
+	verdict := "PASS"
+	if !result.Match {
+		verdict = "FAIL"
+	}
+	fmt.Printf("%s %s: %s/%s (%s)", verdict, result.Fixture, result.Status.SessionState, result.Status.Status, result.Status.ClassificationReason)
+	if !result.Match {
+		expected := result.Manifest.Expected
+		fmt.Printf(" expected %s/%s (%s)", expected.SessionState, expected.Status, expected.ClassificationReason)
+	}
+	fmt.Println()
+	if trace {
+		printClassificationTrace(explainClassificationTrace("", result.Status))
+	}
+}
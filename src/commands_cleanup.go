@@ -16,13 +16,18 @@ type tmuxSocketProbe struct {
 }
 
 type cleanupSummary struct {
-	DryRun       bool     `json:"dryRun"`
-	Scanned      int      `json:"scanned"`
-	Removed      int      `json:"removed"`
-	WouldRemove  int      `json:"wouldRemove"`
-	Killed       int      `json:"killedServers"`
-	WouldKill    int      `json:"wouldKillServers"`
-	KeptActive   int      `json:"keptActive"`
+	DryRun        bool     `json:"dryRun"`
+	Scanned       int      `json:"scanned"`
+	Removed       int      `json:"removed"`
+	WouldRemove   int      `json:"wouldRemove"`
+	Killed        int      `json:"killedServers"`
+	WouldKill     int      `json:"wouldKillServers"`
+	KeptActive    int      `json:"keptActive"`
+	KeptProtected int      `json:"keptProtected"`
+	Protected     []string `json:"protected,omitempty"`
+	// Mailboxes counts project mailboxes (messages, cursors, seq sidecar)
+	// removed, or that would be, because their project has no live server.
+	Mailboxes    int      `json:"mailboxesRemoved"`
 	SocketErrors []string `json:"errors,omitempty"`
 	ErrorCode    string   `json:"errorCode,omitempty"`
 }
@@ -32,10 +37,12 @@ var probeTmuxSocketFn = probeTmuxSocket
 var killTmuxSocketServerFn = killTmuxSocketServer
 var removeSocketPathFn = removeSocketPath
 var listTmuxSocketPathsFromProcessTableFn = listTmuxSocketPathsFromProcessTable
+var listTmuxSocketSessionsFn = listTmuxSocketSessions
 
 func cmdCleanup(args []string) int {
 	includeTmuxDefault := false
 	dryRun := false
+	force := false
 	jsonOut := hasJSONFlag(args)
 
 	for i := 0; i < len(args); i++ {
@@ -46,6 +53,8 @@ func cmdCleanup(args []string) int {
 			includeTmuxDefault = true
 		case "--dry-run":
 			dryRun = true
+		case "--force":
+			force = true
 		case "--json":
 			jsonOut = true
 		default:
@@ -62,10 +71,18 @@ func cmdCleanup(args []string) int {
 		DryRun:  dryRun,
 		Scanned: len(socketPaths),
 	}
+	protectedMetas := map[string]sessionMeta{}
+	if !force {
+		protectedMetas = loadProtectedSessionMetas()
+	}
+	// liveHashes are projects whose tmux server survives this sweep; their
+	// mailboxes stay.
+	liveHashes := map[string]bool{}
 	for _, socketPath := range socketPaths {
 		probe, err := probeTmuxSocketFn(socketPath)
 		if err != nil {
 			summary.SocketErrors = append(summary.SocketErrors, fmt.Sprintf("%s probe: %v", socketPath, err))
+			liveHashes[socketProjectHash(socketPath)] = true
 			continue
 		}
 
@@ -84,6 +101,13 @@ func cmdCleanup(args []string) int {
 
 		if probe.Clients > 0 {
 			summary.KeptActive++
+			liveHashes[socketProjectHash(socketPath)] = true
+			continue
+		}
+		if protected := protectedSessionsOnSocket(socketPath, protectedMetas); len(protected) > 0 {
+			liveHashes[socketProjectHash(socketPath)] = true
+			summary.KeptProtected++
+			summary.Protected = append(summary.Protected, protected...)
 			continue
 		}
 
@@ -96,6 +120,7 @@ func cmdCleanup(args []string) int {
 
 		if err := killTmuxSocketServerFn(socketPath); err != nil {
 			summary.SocketErrors = append(summary.SocketErrors, fmt.Sprintf("%s kill-server: %v", socketPath, err))
+			liveHashes[socketProjectHash(socketPath)] = true
 			continue
 		}
 		summary.Killed++
@@ -103,6 +128,7 @@ func cmdCleanup(args []string) int {
 		postProbe, postErr := probeTmuxSocketFn(socketPath)
 		if postErr != nil {
 			summary.SocketErrors = append(summary.SocketErrors, fmt.Sprintf("%s post-kill probe: %v", socketPath, postErr))
+			liveHashes[socketProjectHash(socketPath)] = true
 			continue
 		}
 		if !postProbe.Reachable {
@@ -114,8 +140,13 @@ func cmdCleanup(args []string) int {
 			continue
 		}
 		summary.KeptActive++
+		liveHashes[socketProjectHash(socketPath)] = true
 	}
 
+	removed, mailboxErrs := cleanupStaleMailboxes(liveHashes, dryRun)
+	summary.Mailboxes = removed
+	summary.SocketErrors = append(summary.SocketErrors, mailboxErrs...)
+
 	if len(summary.SocketErrors) > 0 {
 		summary.ErrorCode = "cleanup_socket_errors"
 		if jsonOut {
@@ -136,9 +167,60 @@ func cmdCleanup(args []string) int {
 		fmt.Printf("cleanup: scanned %d sockets, removed %d stale sockets, killed %d detached servers, kept %d active\n",
 			summary.Scanned, summary.Removed, summary.Killed, summary.KeptActive)
 	}
+	if !jsonOut && summary.Mailboxes > 0 {
+		if dryRun {
+			fmt.Printf("would remove %d mailboxes of projects without a live server\n", summary.Mailboxes)
+		} else {
+			fmt.Printf("removed %d mailboxes of projects without a live server\n", summary.Mailboxes)
+		}
+	}
+	if !jsonOut && summary.KeptProtected > 0 {
+		fmt.Printf("kept %d servers hosting protected sessions (%s); use --force to include them\n",
+			summary.KeptProtected, strings.Join(summary.Protected, ", "))
+	}
 	return 0
 }
 
+// socketProjectHash returns the project hash in a lisa-tmux-<slug>-<hash>.sock
+// name, or "" for other sockets.
+func socketProjectHash(socketPath string) string {
+	base := filepath.Base(socketPath)
+	if !strings.HasPrefix(base, "lisa-tmux-") || !strings.HasSuffix(base, ".sock") {
+		return ""
+	}
+	name := strings.TrimSuffix(base, ".sock")
+	return name[strings.LastIndex(name, "-")+1:]
+}
+
+var mailboxArtifactGlob = "/tmp/.lisa-*-mailbox*"
+
+// cleanupStaleMailboxes removes the mailbox files (log, lock, cursors, seq
+// sidecar) of every project with no live tmux server left. It returns the
+// number of projects whose mailbox was (or would be) removed.
+func cleanupStaleMailboxes(liveHashes map[string]bool, dryRun bool) (int, []string) {
+	paths, err := filepath.Glob(mailboxArtifactGlob)
+	if err != nil {
+		return 0, []string{fmt.Sprintf("mailbox glob: %v", err)}
+	}
+	projects := map[string]bool{}
+	var errs []string
+	for _, path := range paths {
+		base := strings.TrimPrefix(filepath.Base(path), ".lisa-")
+		hash, _, ok := strings.Cut(base, "-mailbox")
+		if !ok || hash == "" || liveHashes[hash] {
+			continue
+		}
+		projects[hash] = true
+		if dryRun {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Sprintf("%s remove: %v", path, err))
+		}
+	}
+	return len(projects), errs
+}
+
 func cleanupSocketCandidates(includeTmuxDefault bool) ([]string, error) {
 	patterns := []string{
 		filepath.Join(preferredTmuxSocketDir(), "lisa-tmux-*-*.sock"),
@@ -264,6 +346,54 @@ func probeTmuxSocket(socketPath string) (tmuxSocketProbe, error) {
 	return tmuxSocketProbe{Reachable: true, Sessions: sessionLines, Clients: clientLines}, nil
 }
 
+func listTmuxSocketSessions(socketPath string) ([]string, error) {
+	out, err := runTmuxWithSocket("", socketPath, "list-sessions", "-F", "#{session_name}")
+	if err != nil {
+		return nil, wrapTmuxCommandError(err, out)
+	}
+	return trimLines(out), nil
+}
+
+// loadProtectedSessionMetas returns metadata of sessions labeled
+// protected=true across all project hashes, keyed by session name.
+func loadProtectedSessionMetas() map[string]sessionMeta {
+	out := map[string]sessionMeta{}
+	metas, err := loadSessionMetasForProject("", true)
+	if err != nil {
+		return out
+	}
+	for _, meta := range metas {
+		if sessionLabelsProtected(meta.Labels) {
+			out[strings.TrimSpace(meta.Session)] = meta
+		}
+	}
+	return out
+}
+
+// protectedSessionsOnSocket lists protected sessions the server at socketPath
+// still hosts; stale metadata of already-killed sessions does not count.
+func protectedSessionsOnSocket(socketPath string, protectedMetas map[string]sessionMeta) []string {
+	if len(protectedMetas) == 0 {
+		return nil
+	}
+	sessions, err := listTmuxSocketSessionsFn(socketPath)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, session := range sessions {
+		meta, ok := protectedMetas[strings.TrimSpace(session)]
+		if !ok {
+			continue
+		}
+		if meta.SocketPath != "" && filepath.Clean(meta.SocketPath) != filepath.Clean(socketPath) {
+			continue
+		}
+		out = append(out, strings.TrimSpace(session))
+	}
+	return out
+}
+
 func killTmuxSocketServer(socketPath string) error {
 	out, err := runTmuxWithSocket("", socketPath, "kill-server")
 	if err != nil {
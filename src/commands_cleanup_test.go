@@ -2,6 +2,8 @@ package app
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
@@ -161,3 +163,57 @@ func TestCmdCleanupDryRunHasNoMutations(t *testing.T) {
 		t.Fatalf("dry-run should not mutate state; kill=%t remove=%t", killCalled, removeCalled)
 	}
 }
+
+func TestCmdCleanupRemovesMailboxesOfProjectsWithoutLiveServer(t *testing.T) {
+	origCandidates, origProbe, origKill, origRemove, origGlob := cleanupSocketCandidatesFn, probeTmuxSocketFn, killTmuxSocketServerFn, removeSocketPathFn, mailboxArtifactGlob
+	t.Cleanup(func() {
+		cleanupSocketCandidatesFn, probeTmuxSocketFn, killTmuxSocketServerFn, removeSocketPathFn, mailboxArtifactGlob = origCandidates, origProbe, origKill, origRemove, origGlob
+	})
+	dir := t.TempDir()
+	mailboxArtifactGlob = filepath.Join(dir, ".lisa-*-mailbox*")
+	for _, name := range []string{
+		".lisa-deadbeef-mailbox.jsonl", ".lisa-deadbeef-mailbox.jsonl.lock", ".lisa-deadbeef-mailbox-cursors.json", ".lisa-deadbeef-mailbox-seq.json",
+		".lisa-cafef00d-mailbox.jsonl", ".lisa-cafef00d-mailbox-cursors.json",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}\n"), 0o600); err != nil {
+			t.Fatalf("write fixture failed: %v", err)
+		}
+	}
+	cleanupSocketCandidatesFn = func(bool) ([]string, error) {
+		return []string{"/tmp/lisa-tmux-app-deadbeef.sock", "/tmp/lisa-tmux-web-cafef00d.sock"}, nil
+	}
+	probeTmuxSocketFn = func(socketPath string) (tmuxSocketProbe, error) {
+		if strings.Contains(socketPath, "cafef00d") {
+			return tmuxSocketProbe{Reachable: true, Sessions: 1, Clients: 1}, nil
+		}
+		return tmuxSocketProbe{Reachable: false}, nil
+	}
+	killTmuxSocketServerFn = func(string) error { return nil }
+	removeSocketPathFn = func(string) error { return nil }
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdCleanup([]string{"--dry-run", "--json"}); code != 0 {
+			t.Fatalf("expected dry-run success, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"mailboxesRemoved":1`) {
+		t.Fatalf("expected one mailbox counted, got %s", stdout)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 6 {
+		t.Fatalf("dry-run must not remove mailbox files, have %d", len(entries))
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := cmdCleanup([]string{"--json"}); code != 0 {
+			t.Fatalf("expected cleanup success, got %d", code)
+		}
+	})
+	left := []string{}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+	if !slices.Equal(left, []string{".lisa-cafef00d-mailbox-cursors.json", ".lisa-cafef00d-mailbox.jsonl"}) {
+		t.Fatalf("expected only the live project's mailbox to remain, got %v", left)
+	}
+}
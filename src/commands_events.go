@@ -0,0 +1,229 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+func cmdEvents(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: lisa events <subcommand>")
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		return showHelp("events")
+	}
+	if args[0] == "help" {
+		if len(args) > 1 {
+			return showHelp("events " + args[1])
+		}
+		return showHelp("events")
+	}
+
+	switch args[0] {
+	case "query":
+		return cmdEventsQuery(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown events subcommand: %s\n", args[0])
+		return 1
+	}
+}
+
+func cmdEventsQuery(args []string) int {
+	projectRoot := getPWD()
+	allHashes := false
+	query := eventQuery{}
+	format := "table"
+	groupBy := ""
+	countOnly := false
+	limit := 0
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("events query")
+		case "--project-root", "--session-glob", "--type", "--state", "--reason", "--since", "--until", "--where", "--format", "--group-by", "--limit":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--project-root":
+				projectRoot = value
+			case "--session-glob":
+				if _, err := path.Match(value, ""); err != nil {
+					return commandErrorf(jsonOut, "invalid_session_glob", "invalid --session-glob: %s", value)
+				}
+				query.SessionGlob = strings.TrimSpace(value)
+			case "--type":
+				query.Types = parseCommaValues(value)
+			case "--state":
+				query.States = parseCommaValues(value)
+			case "--reason":
+				query.Reasons = parseCommaValues(value)
+			case "--since", "--until":
+				at, err := parseSinceFlag(args[i], value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_"+strings.TrimPrefix(args[i], "--"), err.Error())
+				}
+				if args[i] == "--since" {
+					query.Since = at
+				} else {
+					query.Until = at
+				}
+			case "--where":
+				expr, err := parseEventWhereExpr(value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_where", err.Error())
+				}
+				query.Where = append(query.Where, expr)
+			case "--format":
+				format = strings.ToLower(strings.TrimSpace(value))
+			case "--group-by":
+				groupBy = strings.ToLower(strings.TrimSpace(value))
+			case "--limit":
+				n, err := parseNonNegativeIntFlag(value, "--limit")
+				if err != nil {
+					return commandError(jsonOut, "invalid_limit", err.Error())
+				}
+				limit = n
+			}
+			i++
+		case "--all-hashes":
+			allHashes = true
+		case "--count":
+			countOnly = true
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	switch format {
+	case "table", "ndjson", "csv":
+	default:
+		return commandErrorf(jsonOut, "invalid_format", "invalid --format: %s (expected ndjson|csv|table)", format)
+	}
+	switch groupBy {
+	case "", "reason", "state", "session", "type":
+	default:
+		return commandErrorf(jsonOut, "invalid_group_by", "invalid --group-by: %s (expected reason|state|session|type)", groupBy)
+	}
+	if groupBy != "" {
+		countOnly = true
+	}
+	if !allHashes {
+		projectRoot = canonicalProjectRoot(projectRoot)
+	}
+
+	paths, err := listSessionEventFiles(projectRoot, allHashes)
+	if err != nil {
+		return commandErrorf(jsonOut, "events_read_failed", "failed listing event logs: %v", err)
+	}
+
+	counts := map[string]int{}
+	rows := []sessionEvent{}
+	emit := func(event sessionEvent) {
+		if jsonOut {
+			rows = append(rows, event)
+			return
+		}
+		switch format {
+		case "ndjson":
+			raw, _ := json.Marshal(event)
+			fmt.Println(string(raw))
+		case "csv":
+			_ = writeCSVRecord(event.At, event.Type, event.Session, event.State, event.Status, event.Reason, strconv.Itoa(event.Poll))
+		default:
+			fmt.Printf("%-30s  %-16s  %-28s  %-14s  %-8s  %s\n", event.At, event.Type, event.Session, event.State, event.Status, event.Reason)
+		}
+	}
+	if !countOnly && !jsonOut {
+		switch format {
+		case "csv":
+			_ = writeCSVRecord("at", "type", "session", "state", "status", "reason", "poll")
+		case "table":
+			fmt.Printf("%-30s  %-16s  %-28s  %-14s  %-8s  %s\n", "AT", "TYPE", "SESSION", "STATE", "STATUS", "REASON")
+		}
+	}
+
+	var warnings []string
+	totalDropped := 0
+	found := []sessionEvent{}
+	for _, eventPath := range paths {
+		events, dropped, readErr := readSessionEventsFile(eventPath)
+		totalDropped += dropped
+		if readErr != nil {
+			if !errors.Is(readErr, os.ErrNotExist) {
+				warnings = append(warnings, fmt.Sprintf("%s: %v", eventPath, readErr))
+			}
+			continue
+		}
+		for _, event := range events {
+			if query.matches(event) {
+				found = append(found, event)
+			}
+		}
+	}
+	// Each log is already in time order; sorting the union interleaves
+	// sessions so --limit keeps the earliest events across all of them.
+	sortSessionEventsByTime(found)
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	matched := len(found)
+	for _, event := range found {
+		if countOnly {
+			counts[eventGroupKey(event, groupBy)]++
+		} else {
+			emit(event)
+		}
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "events warning: %s\n", warning)
+	}
+
+	if countOnly {
+		groups := sortedReportCounts(counts)
+		if jsonOut {
+			payload := map[string]any{"count": matched, "files": len(paths), "droppedLines": totalDropped}
+			if groupBy != "" {
+				payload["groupBy"] = groupBy
+				payload["groups"] = groups
+			}
+			writeJSON(payload)
+			return 0
+		}
+		if groupBy == "" {
+			fmt.Println(matched)
+			return 0
+		}
+		switch format {
+		case "ndjson":
+			for _, group := range groups {
+				raw, _ := json.Marshal(map[string]any{groupBy: group.Name, "count": group.Count})
+				fmt.Println(string(raw))
+			}
+		case "csv":
+			_ = writeCSVRecord(groupBy, "count")
+			for _, group := range groups {
+				_ = writeCSVRecord(group.Name, strconv.Itoa(group.Count))
+			}
+		default:
+			for _, group := range groups {
+				fmt.Printf("%8d  %s\n", group.Count, group.Name)
+			}
+		}
+		return 0
+	}
+	if jsonOut {
+		writeJSON(map[string]any{"count": matched, "files": len(paths), "droppedLines": totalDropped, "events": rows})
+	}
+	return 0
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cmdFakeAgent runs the built-in claude/codex simulator, or installs PATH
+// shims for it with `fake-agent install`.
+func cmdFakeAgent(args []string) int {
+	if len(args) > 0 && args[0] == "install" {
+		return cmdFakeAgentInstall(args[1:])
+	}
+	persona := ""
+	scriptPath := strings.TrimSpace(os.Getenv(fakeAgentScriptEnv))
+	agentArgs := []string{}
+
+parse:
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("fake-agent")
+		case "--persona":
+			if i+1 >= len(args) {
+				return commandErrorf(false, "missing_flag_value", "missing value for --persona")
+			}
+			persona = args[i+1]
+			i++
+		case "--script":
+			if i+1 >= len(args) {
+				return commandErrorf(false, "missing_flag_value", "missing value for --script")
+			}
+			scriptPath = args[i+1]
+			i++
+		case "--":
+			agentArgs = args[i+1:]
+			break parse
+		default:
+			// First foreign token starts the agent argv (e.g. `exec "prompt"`).
+			agentArgs = args[i:]
+			break parse
+		}
+	}
+
+	scenario, err := loadFakeAgentScenario(scriptPath)
+	if err != nil {
+		return commandErrorf(false, "invalid_script", "invalid --script: %v", err)
+	}
+	if persona == "" {
+		persona = scenario.Persona
+	}
+	persona = strings.ToLower(strings.TrimSpace(persona))
+	if persona != "claude" && persona != "codex" {
+		return commandErrorf(false, "invalid_persona", "invalid --persona: %q (expected claude|codex)", persona)
+	}
+
+	code, err := runFakeAgent(persona, scenario, parseFakeAgentArgs(persona, agentArgs), os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fake-agent: %v\n", err)
+	}
+	return code
+}
+
+func cmdFakeAgentInstall(args []string) int {
+	dir := ""
+	scriptPath := ""
+	personas := []string{"claude", "codex"}
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("fake-agent")
+		case "--dir":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --dir")
+			}
+			dir = args[i+1]
+			i++
+		case "--script":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --script")
+			}
+			scriptPath = args[i+1]
+			i++
+		case "--persona":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --persona")
+			}
+			switch strings.ToLower(strings.TrimSpace(args[i+1])) {
+			case "claude":
+				personas = []string{"claude"}
+			case "codex":
+				personas = []string{"codex"}
+			case "all":
+				personas = []string{"claude", "codex"}
+			default:
+				return commandErrorf(jsonOut, "invalid_persona", "invalid --persona: %s (expected claude|codex|all)", args[i+1])
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if strings.TrimSpace(dir) == "" {
+		return commandError(jsonOut, "missing_required_flag", "--dir is required")
+	}
+	dir, err := expandAndCleanPath(dir)
+	if err != nil {
+		return commandErrorf(jsonOut, "invalid_dir", "invalid --dir: %v", err)
+	}
+	if scriptPath != "" {
+		if scriptPath, err = expandAndCleanPath(scriptPath); err != nil {
+			return commandErrorf(jsonOut, "invalid_script", "invalid --script: %v", err)
+		}
+		if _, err := loadFakeAgentScenario(scriptPath); err != nil {
+			return commandErrorf(jsonOut, "invalid_script", "invalid --script: %v", err)
+		}
+	}
+	lisaPath, err := osExecutableFn()
+	if err != nil {
+		return commandErrorf(jsonOut, "executable_not_found", "cannot resolve lisa executable: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return commandErrorf(jsonOut, "install_failed", "failed creating %s: %v", dir, err)
+	}
+
+	shims := []string{}
+	for _, persona := range personas {
+		path := filepath.Join(dir, persona)
+		if err := os.WriteFile(path, []byte(fakeAgentShimScript(lisaPath, persona, scriptPath)), 0o755); err != nil {
+			return commandErrorf(jsonOut, "install_failed", "failed writing shim %s: %v", path, err)
+		}
+		shims = append(shims, path)
+	}
+
+	if jsonOut {
+		payload := map[string]any{
+			"dir":   dir,
+			"shims": shims,
+			"lisa":  lisaPath,
+		}
+		if scriptPath != "" {
+			payload["script"] = scriptPath
+		}
+		writeJSON(payload)
+		return 0
+	}
+	for _, shim := range shims {
+		fmt.Println(shim)
+	}
+	fmt.Fprintf(os.Stderr, "prepend to PATH: export PATH=%s:$PATH\n", shellQuote(dir))
+	return 0
+}
@@ -0,0 +1,293 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const historyDefaultListLimit = 50
+
+func cmdHistory(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: lisa history <subcommand>")
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		return showHelp("history")
+	}
+	if args[0] == "help" {
+		if len(args) > 1 {
+			return showHelp("history " + args[1])
+		}
+		return showHelp("history")
+	}
+
+	switch args[0] {
+	case "list":
+		return cmdHistoryList(args[1:], false)
+	case "search":
+		return cmdHistoryList(args[1:], true)
+	case "show":
+		return cmdHistoryShow(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown history subcommand: %s\n", args[0])
+		return 1
+	}
+}
+
+type historyFilter struct {
+	Since       time.Time
+	States      []string
+	Agent       string
+	ProjectRoot string
+	Query       string
+}
+
+func (f historyFilter) matches(entry historyEntry) bool {
+	if !f.Since.IsZero() {
+		ended, err := time.Parse(time.RFC3339, entry.EndedAt)
+		if err != nil || ended.Before(f.Since) {
+			return false
+		}
+	}
+	if len(f.States) > 0 && !slices.Contains(f.States, entry.FinalState) {
+		return false
+	}
+	if f.Agent != "" && entry.Agent != f.Agent {
+		return false
+	}
+	if f.ProjectRoot != "" && entry.ProjectRoot != f.ProjectRoot {
+		return false
+	}
+	if f.Query != "" && !historyEntryContains(entry, f.Query) {
+		return false
+	}
+	return true
+}
+
+// historyEntryContains does a case-insensitive substring match over the
+// human-meaningful fields of an entry.
+func historyEntryContains(entry historyEntry, query string) bool {
+	query = strings.ToLower(query)
+	fields := []string{entry.Session, entry.Prompt, entry.ObjectiveID, entry.ObjectiveGoal, entry.Lane, entry.Model, entry.FinalState, entry.LastState}
+	for key, value := range entry.Labels {
+		fields = append(fields, key+"="+value)
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func cmdHistoryList(args []string, search bool) int {
+	name := "history list"
+	if search {
+		name = "history search"
+	}
+	filter := historyFilter{}
+	limit := historyDefaultListLimit
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp(name)
+		case "--since", "--state", "--agent", "--project-root", "--limit":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--since":
+				parsed, err := parseSinceFlag("--since", value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_since", err.Error())
+				}
+				filter.Since = parsed
+			case "--state":
+				filter.States = parseCommaValues(value)
+			case "--agent":
+				agent, err := parseAgent(value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_agent", err.Error())
+				}
+				filter.Agent = agent
+			case "--project-root":
+				filter.ProjectRoot = canonicalProjectRoot(value)
+			case "--limit":
+				n, err := parseNonNegativeIntFlag(value, "--limit")
+				if err != nil {
+					return commandError(jsonOut, "invalid_limit", err.Error())
+				}
+				limit = n
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			if !search || strings.HasPrefix(args[i], "-") || filter.Query != "" {
+				return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+			}
+			filter.Query = strings.TrimSpace(args[i])
+		}
+	}
+	if search && filter.Query == "" {
+		return commandError(jsonOut, "missing_query", "search query is required")
+	}
+
+	entries, err := readHistoryEntries()
+	if err != nil {
+		return commandErrorf(jsonOut, "history_read_failed", "failed reading history index: %v", err)
+	}
+	matched := []historyEntry{}
+	for _, entry := range entries {
+		if filter.matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	total := len(matched)
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+
+	if jsonOut {
+		payload := map[string]any{
+			"total":   total,
+			"count":   len(matched),
+			"entries": matched,
+		}
+		if search {
+			payload["query"] = filter.Query
+		}
+		writeJSON(payload)
+		return 0
+	}
+	for _, entry := range matched {
+		fmt.Println(formatHistoryLine(entry))
+	}
+	return 0
+}
+
+func formatHistoryLine(entry historyEntry) string {
+	agent := entry.Agent
+	if entry.Model != "" {
+		agent += "/" + entry.Model
+	}
+	tokens := "-"
+	if entry.Tokens != nil {
+		tokens = fmt.Sprintf("%d", entry.Tokens.Total)
+	}
+	return strings.Join([]string{
+		entry.EndedAt,
+		entry.FinalState,
+		agent,
+		(time.Duration(entry.DurationSeconds) * time.Second).String(),
+		tokens,
+		entry.Session,
+	}, "\t")
+}
+
+func cmdHistoryShow(args []string) int {
+	session := ""
+	runID := ""
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("history show")
+		case "--session", "--run-id":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			if args[i] == "--session" {
+				session = strings.TrimSpace(args[i+1])
+			} else {
+				runID = strings.TrimSpace(args[i+1])
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if session == "" && runID == "" {
+		return commandError(jsonOut, "missing_required_flag", "--session or --run-id is required")
+	}
+
+	entries, err := readHistoryEntries()
+	if err != nil {
+		return commandErrorf(jsonOut, "history_read_failed", "failed reading history index: %v", err)
+	}
+	// The newest record wins when a session name was reused.
+	var entry *historyEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if (session == "" || entries[i].Session == session) && (runID == "" || entries[i].RunID == runID) {
+			entry = &entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return commandError(jsonOut, "history_entry_not_found", "history entry not found")
+	}
+	var manifest *historyArchiveManifest
+	if entry.ArchivePath != "" {
+		if raw, err := os.ReadFile(filepath.Join(entry.ArchivePath, "manifest.json")); err == nil {
+			parsed := historyArchiveManifest{}
+			if json.Unmarshal(raw, &parsed) == nil {
+				manifest = &parsed
+			}
+		}
+	}
+
+	if jsonOut {
+		payload := map[string]any{"entry": entry}
+		if manifest != nil {
+			payload["archive"] = manifest
+		}
+		writeJSON(payload)
+		return 0
+	}
+	fmt.Printf("session: %s\n", entry.Session)
+	fmt.Printf("agent: %s", entry.Agent)
+	if entry.Model != "" {
+		fmt.Printf(" (%s)", entry.Model)
+	}
+	fmt.Printf(" %s\n", entry.Mode)
+	fmt.Printf("project: %s\n", entry.ProjectRoot)
+	fmt.Printf("started: %s\n", entry.StartedAt)
+	fmt.Printf("ended: %s (%s)\n", entry.EndedAt, entry.EndReason)
+	fmt.Printf("duration: %s\n", time.Duration(entry.DurationSeconds)*time.Second)
+	fmt.Printf("state: %s", entry.FinalState)
+	if entry.ExitCode != nil {
+		fmt.Printf(" (exit %d)", *entry.ExitCode)
+	}
+	fmt.Println()
+	if entry.Lane != "" {
+		fmt.Printf("lane: %s\n", entry.Lane)
+	}
+	if entry.ObjectiveID != "" {
+		fmt.Printf("objective: %s %s\n", entry.ObjectiveID, entry.ObjectiveGoal)
+	}
+	if entry.Tokens != nil {
+		fmt.Printf("tokens: %d (in %d, out %d)\n", entry.Tokens.Total, entry.Tokens.Input, entry.Tokens.Output)
+	}
+	if entry.Prompt != "" {
+		fmt.Printf("prompt: %s\n", entry.Prompt)
+	}
+	if entry.TranscriptPath != "" {
+		fmt.Printf("transcript: %s\n", entry.TranscriptPath)
+	}
+	if entry.ArchivePath != "" {
+		fmt.Printf("archive: %s\n", entry.ArchivePath)
+	}
+	return 0
+}
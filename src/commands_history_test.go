@@ -0,0 +1,204 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryRecordsKilledSessionsAndFilters(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(historyDirEnv, t.TempDir())
+	root := canonicalProjectRoot(t.TempDir())
+	origHas, origKill, origNow := tmuxHasSessionFn, tmuxKillSessionFn, nowFn
+	t.Cleanup(func() { tmuxHasSessionFn, tmuxKillSessionFn, nowFn = origHas, origKill, origNow })
+	nowFn = func() time.Time { return time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC) }
+	alive := map[string]bool{"lisa-hist-fail": true, "lisa-hist-ok": true}
+	tmuxHasSessionFn = func(session string) bool { return alive[session] }
+	tmuxKillSessionFn = func(session string) error { alive[session] = false; return nil }
+
+	save := func(session, agent, startCmd, claudeID string, labels map[string]string) {
+		meta := sessionMeta{
+			Session: session, Agent: agent, Mode: "exec", RunID: "run-" + session, ProjectRoot: root,
+			StartCmd: startCmd, Prompt: "Fix the flaky test", Lane: "backend", Labels: labels,
+			CreatedAt: "2026-10-19T09:45:00Z",
+		}
+		if err := saveSessionMeta(root, session, meta); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+		if claudeID != "" {
+			if err := saveSessionState(sessionStateFile(root, session), sessionState{ClaudeSessionID: claudeID}); err != nil {
+				t.Fatalf("save state failed: %v", err)
+			}
+		}
+		t.Cleanup(func() { _ = os.Remove(sessionEventsFile(root, session)) })
+	}
+	save("lisa-hist-fail", "claude", "claude --model opus -p 'Fix the flaky test'", "sess-1", map[string]string{"task": "TICKET-12"})
+	save("lisa-hist-ok", "codex", "codex exec --full-auto", "", nil)
+
+	transcriptDir := claudeProjectDir(root)
+	if err := os.MkdirAll(transcriptDir, 0o700); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	transcript := strings.Join([]string{
+		`{"type":"assistant","message":{"id":"m1","usage":{"input_tokens":10,"cache_read_input_tokens":90,"output_tokens":5}}}`,
+		`{"type":"assistant","message":{"id":"m1","usage":{"input_tokens":10,"cache_read_input_tokens":90,"output_tokens":20}}}`,
+		`{"type":"user","message":{"content":"next"}}`,
+		`{"type":"assistant","message":{"id":"m2","usage":{"input_tokens":30,"cache_creation_input_tokens":5,"output_tokens":7}}}`,
+	}, "\n")
+	if err := os.WriteFile(filepath.Join(transcriptDir, "sess-1.jsonl"), []byte(transcript), 0o600); err != nil {
+		t.Fatalf("write transcript failed: %v", err)
+	}
+	if err := os.WriteFile(sessionDoneFile(root, "lisa-hist-fail"), []byte("run-lisa-hist-fail:2\n"), 0o600); err != nil {
+		t.Fatalf("write done file failed: %v", err)
+	}
+	if err := os.WriteFile(sessionDoneFile(root, "lisa-hist-ok"), []byte("run-lisa-hist-ok:0\n"), 0o600); err != nil {
+		t.Fatalf("write done file failed: %v", err)
+	}
+
+	_, _ = captureOutput(t, func() {
+		for _, session := range []string{"lisa-hist-fail", "lisa-hist-ok"} {
+			if code := cmdSessionKill([]string{"--session", session, "--project-root", root}); code != 0 {
+				t.Fatalf("kill %s failed: %d", session, code)
+			}
+		}
+	})
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdHistory([]string{"list", "--since", "1h", "--state", "crashed", "--json"}); code != 0 {
+			t.Fatalf("history list failed: %d", code)
+		}
+	})
+	listed := struct {
+		Total   int            `json:"total"`
+		Entries []historyEntry `json:"entries"`
+	}{}
+	if err := json.Unmarshal([]byte(stdout), &listed); err != nil {
+		t.Fatalf("invalid list JSON: %v (%q)", err, stdout)
+	}
+	if listed.Total != 1 {
+		t.Fatalf("expected one crashed session, got %+v", listed)
+	}
+	entry := listed.Entries[0]
+	if entry.Session != "lisa-hist-fail" || entry.Model != "opus" || entry.EndReason != "kill" || entry.DurationSeconds != 900 ||
+		entry.ExitCode == nil || *entry.ExitCode != 2 || entry.Lane != "backend" {
+		t.Fatalf("unexpected history entry: %+v", entry)
+	}
+	if entry.Tokens == nil || entry.Tokens.Input != 40 || entry.Tokens.CacheRead != 90 || entry.Tokens.CacheCreation != 5 ||
+		entry.Tokens.Output != 27 || entry.Tokens.Total != 162 {
+		t.Fatalf("unexpected token usage: %+v", entry.Tokens)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdHistory([]string{"search", "ticket-12", "--json"}); code != 0 {
+			t.Fatalf("history search failed: %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"count":1`) || !strings.Contains(stdout, `"lisa-hist-fail"`) {
+		t.Fatalf("unexpected search output: %s", stdout)
+	}
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdHistory([]string{"list", "--agent", "codex"}); code != 0 {
+			t.Fatalf("history list failed: %d", code)
+		}
+	})
+	if fields := strings.Split(strings.TrimSpace(stdout), "\t"); len(fields) != 6 || fields[1] != "completed" || fields[5] != "lisa-hist-ok" {
+		t.Fatalf("unexpected text list: %q", stdout)
+	}
+	_, _ = captureOutput(t, func() {
+		if code := cmdHistory([]string{"show", "--session", "lisa-missing", "--json"}); code == 0 {
+			t.Fatalf("expected missing history entry to fail")
+		}
+	})
+}
+
+func TestTranscriptTokenUsageReadsCodexTotals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollout.jsonl")
+	raw := strings.Join([]string{
+		`{"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":100,"output_tokens":10,"total_tokens":110}}}}`,
+		`{"type":"event_msg","payload":{"type":"agent_message","message":"done"}}`,
+		`{"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":400,"cached_input_tokens":300,"output_tokens":50,"total_tokens":450}}}}`,
+	}, "\n")
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	usage := transcriptTokenUsage("codex", path)
+	if usage == nil || usage.Input != 100 || usage.CacheRead != 300 || usage.Output != 50 || usage.Total != 450 {
+		t.Fatalf("unexpected codex usage: %+v", usage)
+	}
+	if transcriptTokenUsage("claude", path) != nil {
+		t.Fatalf("expected no claude usage in a codex rollout")
+	}
+	if got := sessionModelFromCommand("codex exec '--model=gpt-5.3-codex' --full-auto"); got != "gpt-5.3-codex" {
+		t.Fatalf("unexpected model: %q", got)
+	}
+}
+
+func TestHistoryRecordsOnlySuccessfulKillsOnce(t *testing.T) {
+	t.Setenv(historyDirEnv, t.TempDir())
+	t.Setenv(limitGraceEnv, "0")
+	root := canonicalProjectRoot(t.TempDir())
+	origHas, origKill := tmuxHasSessionFn, tmuxKillSessionFn
+	t.Cleanup(func() { tmuxHasSessionFn, tmuxKillSessionFn = origHas, origKill })
+	alive := map[string]bool{"lisa-hist-killfail": true, "lisa-hist-killed": true, "lisa-hist-limit": true}
+	failKill := true
+	tmuxHasSessionFn = func(session string) bool { return alive[session] }
+	tmuxKillSessionFn = func(session string) error {
+		if failKill {
+			return errors.New("tmux busy")
+		}
+		alive[session] = false
+		return nil
+	}
+	for session := range alive {
+		if err := saveSessionMeta(root, session, sessionMeta{Session: session, Agent: "codex", Mode: "exec", RunID: "run-" + session, ProjectRoot: root}); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+		session := session
+		t.Cleanup(func() {
+			_ = os.Remove(sessionMetaFile(root, session))
+			_ = os.Remove(sessionEventsFile(root, session))
+		})
+	}
+	endReasons := func() []string {
+		entries, err := readHistoryEntries()
+		if err != nil {
+			t.Fatalf("read history failed: %v", err)
+		}
+		reasons := []string{}
+		for _, entry := range entries {
+			reasons = append(reasons, entry.Session+":"+entry.EndReason)
+		}
+		return reasons
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := cmdSessionKill([]string{"--session", "lisa-hist-killfail", "--project-root", root}); code == 0 {
+			t.Fatalf("expected failed kill to fail")
+		}
+	})
+	if got := endReasons(); len(got) != 0 {
+		t.Fatalf("failed kill must not write history, got %v", got)
+	}
+	failKill = false
+	_, _ = captureOutput(t, func() {
+		if code := cmdSessionKill([]string{"--session", "lisa-hist-killed", "--project-root", root}); code != 0 {
+			t.Fatalf("kill failed: %d", code)
+		}
+		if !enforceSessionLimit(root, "lisa-hist-limit", nil, "max_runtime", "runtime over limit") {
+			t.Fatalf("expected limit to hard-kill the session")
+		}
+		// Cleaning up the limit-killed session afterwards must not add a second row.
+		if code := cmdSessionKill([]string{"--session", "lisa-hist-limit", "--project-root", root}); code == 0 {
+			t.Fatalf("expected kill of a gone session to report not found")
+		}
+	})
+	if got := strings.Join(endReasons(), ","); got != "lisa-hist-killed:kill,lisa-hist-limit:limit_max_runtime" {
+		t.Fatalf("unexpected history entries: %s", got)
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+const sessionHookPayloadMaxBytes = 1 << 20
+
+var hookReadStdinFn = func() ([]byte, error) {
+	return io.ReadAll(io.LimitReader(os.Stdin, sessionHookPayloadMaxBytes))
+}
+
+// cmdHook is the lifecycle callback spawn wires into agents. It runs inside
+// the agent's own turn loop, so after flag validation it never fails: a
+// broken state file must not surface as a hook error in the agent UI.
+func cmdHook(args []string) int {
+	agent := ""
+	event := ""
+	session := strings.TrimSpace(os.Getenv("LISA_SESSION_NAME"))
+	projectRoot := mailboxDefaultProjectRoot()
+	payloadArg := ""
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("hook")
+		case "--agent", "--event", "--session", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := strings.TrimSpace(args[i+1])
+			switch args[i] {
+			case "--agent":
+				parsed, err := parseAgent(value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_agent", err.Error())
+				}
+				agent = parsed
+			case "--event":
+				if !slices.Contains(sessionHookEvents, value) {
+					return commandErrorf(jsonOut, "invalid_hook_event", "invalid --event: %s (expected %s)", value, strings.Join(sessionHookEvents, "|"))
+				}
+				event = value
+			case "--session":
+				session = value
+			case "--project-root":
+				projectRoot = value
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			if strings.HasPrefix(args[i], "--") {
+				return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+			}
+			// Codex appends the notification JSON as the last argument.
+			payloadArg = args[i]
+		}
+	}
+	if agent == "" && event == "" {
+		return commandError(jsonOut, "missing_required_flag", "--agent or --event is required")
+	}
+
+	message := ""
+	if event == "" {
+		raw := []byte(payloadArg)
+		if payloadArg == "" && agent == "claude" {
+			raw, _ = hookReadStdinFn()
+		}
+		payload := map[string]any{}
+		if err := json.Unmarshal(raw, &payload); err == nil {
+			event, message = sessionHookEventFromPayload(payload)
+		}
+	}
+
+	recorded := false
+	if session != "" && event != "" {
+		if err := recordSessionHookEvent(canonicalProjectRoot(projectRoot), session, event, message, nowFn()); err != nil {
+			fmt.Fprintf(os.Stderr, "lisa hook warning: %v\n", err)
+		} else {
+			recorded = true
+		}
+	}
+	if jsonOut {
+		writeJSON(map[string]any{
+			"session":  session,
+			"event":    event,
+			"recorded": recorded,
+		})
+	}
+	return 0
+}
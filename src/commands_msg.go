@@ -0,0 +1,332 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func cmdMsg(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: lisa msg <subcommand>")
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		return showHelp("msg")
+	}
+	if args[0] == "help" {
+		if len(args) > 1 {
+			return showHelp("msg " + args[1])
+		}
+		return showHelp("msg")
+	}
+
+	switch args[0] {
+	case "post":
+		return cmdMsgPost(args[1:])
+	case "read":
+		return cmdMsgRead(args[1:])
+	case "ack":
+		return cmdMsgAck(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown msg subcommand: %s\n", args[0])
+		return 1
+	}
+}
+
+// mailboxDefaultIdentity is the caller's mailbox name: the pane's session
+// when running inside lisa, otherwise the orchestrator.
+func mailboxDefaultIdentity() string {
+	if name := strings.TrimSpace(os.Getenv("LISA_SESSION_NAME")); name != "" {
+		return name
+	}
+	return mailboxOrchestrator
+}
+
+// mailboxDefaultProjectRoot prefers the pane's project root so agents can
+// post from a nested working directory.
+func mailboxDefaultProjectRoot() string {
+	if root := strings.TrimSpace(os.Getenv(lisaProjectRootEnv)); root != "" {
+		return root
+	}
+	return getPWD()
+}
+
+// resolveMailboxRecipient maps --to parent onto the sender's recorded parent,
+// falling back to the orchestrator for top-level sessions.
+func resolveMailboxRecipient(projectRoot, from, to string) (string, error) {
+	switch to {
+	case mailboxOrchestrator:
+		return to, nil
+	case "parent":
+		if from == mailboxOrchestrator {
+			return "", fmt.Errorf("--to parent requires a sender session (set --from or run inside a lisa session)")
+		}
+		if meta, err := loadSessionMeta(projectRoot, from); err == nil && meta.ParentSession != "" {
+			return meta.ParentSession, nil
+		}
+		return mailboxOrchestrator, nil
+	}
+	if fileExists(sessionMetaFile(projectRoot, to)) || tmuxHasSessionFn(to) {
+		return to, nil
+	}
+	return "", fmt.Errorf("unknown recipient session: %s", to)
+}
+
+func cmdMsgPost(args []string) int {
+	to := ""
+	kind := "progress"
+	body := ""
+	bodySet := false
+	from := mailboxDefaultIdentity()
+	projectRoot := mailboxDefaultProjectRoot()
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("msg post")
+		case "--to", "--kind", "--body", "--from", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--to":
+				to = strings.TrimSpace(value)
+			case "--kind":
+				parsed, err := parseMailboxKind(value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_kind", err.Error())
+				}
+				kind = parsed
+			case "--body":
+				body = value
+				bodySet = true
+			case "--from":
+				from = strings.TrimSpace(value)
+			case "--project-root":
+				projectRoot = value
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if to == "" {
+		return commandError(jsonOut, "missing_required_flag", "--to is required")
+	}
+	if !bodySet || strings.TrimSpace(body) == "" {
+		return commandError(jsonOut, "missing_required_flag", "--body is required")
+	}
+	if from == "" {
+		from = mailboxOrchestrator
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+	recipient, err := resolveMailboxRecipient(projectRoot, from, to)
+	if err != nil {
+		return commandError(jsonOut, "msg_unknown_recipient", err.Error())
+	}
+	if recipient == from {
+		return commandError(jsonOut, "msg_self_recipient", "sender and recipient are the same: "+from)
+	}
+	message, err := appendMailboxMessage(projectRoot, mailboxMessage{From: from, To: recipient, Kind: kind, Body: body})
+	if err != nil {
+		return commandErrorf(jsonOut, "mailbox_write_failed", "failed posting message: %v", err)
+	}
+	if jsonOut {
+		writeJSON(map[string]any{
+			"ok":      true,
+			"message": message,
+		})
+		return 0
+	}
+	fmt.Printf("posted #%d %s -> %s (%s)\n", message.Seq, message.From, message.To, message.Kind)
+	return 0
+}
+
+func cmdMsgRead(args []string) int {
+	recipient := mailboxDefaultIdentity()
+	from := ""
+	kind := ""
+	limit := 0
+	peek := false
+	all := false
+	projectRoot := mailboxDefaultProjectRoot()
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("msg read")
+		case "--session", "--from", "--kind", "--limit", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--session":
+				recipient = strings.TrimSpace(value)
+			case "--from":
+				from = strings.TrimSpace(value)
+			case "--kind":
+				parsed, err := parseMailboxKind(value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_kind", err.Error())
+				}
+				kind = parsed
+			case "--limit":
+				n, err := parseNonNegativeIntFlag(value, "--limit")
+				if err != nil {
+					return commandError(jsonOut, "invalid_limit", err.Error())
+				}
+				limit = n
+			case "--project-root":
+				projectRoot = value
+			}
+			i++
+		case "--peek":
+			peek = true
+		case "--all":
+			all = true
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if recipient == "" {
+		return commandError(jsonOut, "missing_required_flag", "--session is required")
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+
+	matches := func(message mailboxMessage) bool {
+		return message.To == recipient &&
+			(from == "" || message.From == from) &&
+			(kind == "" || message.Kind == kind)
+	}
+	var messages []mailboxMessage
+	var err error
+	if all {
+		var everything []mailboxMessage
+		everything, err = readMailbox(projectRoot)
+		for _, message := range everything {
+			if matches(message) {
+				messages = append(messages, message)
+			}
+		}
+	} else {
+		messages, err = unreadMailboxMessages(projectRoot, matches)
+	}
+	if err != nil {
+		return commandErrorf(jsonOut, "mailbox_read_failed", "%v", err)
+	}
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+	if messages == nil {
+		messages = []mailboxMessage{}
+	}
+
+	acked := false
+	cursor := mailboxCursor{}
+	if !peek && len(messages) > 0 {
+		seqs := make([]int, 0, len(messages))
+		for _, message := range messages {
+			seqs = append(seqs, message.Seq)
+		}
+		cursor, err = ackMailboxMessages(projectRoot, recipient, seqs)
+		if err != nil {
+			return commandErrorf(jsonOut, "mailbox_write_failed", "failed acknowledging messages: %v", err)
+		}
+		acked = true
+	} else if store, loadErr := loadMailboxCursors(projectRoot); loadErr == nil {
+		cursor = store.Recipients[recipient]
+	}
+
+	if jsonOut {
+		writeJSON(map[string]any{
+			"session":  recipient,
+			"count":    len(messages),
+			"messages": messages,
+			"acked":    acked,
+			"cursor":   cursor.Cursor,
+		})
+		return 0
+	}
+	if len(messages) == 0 {
+		fmt.Println("no messages")
+		return 0
+	}
+	for _, message := range messages {
+		fmt.Println(redactOutputText(fmt.Sprintf("#%d\t%s\t%s\t%s\t%s", message.Seq, message.At, message.Kind, message.From, message.Body)))
+	}
+	return 0
+}
+
+// cmdMsgAck acknowledges messages read earlier with --peek.
+func cmdMsgAck(args []string) int {
+	recipient := mailboxDefaultIdentity()
+	seqs := []int{}
+	projectRoot := mailboxDefaultProjectRoot()
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("msg ack")
+		case "--session", "--seq", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--session":
+				recipient = strings.TrimSpace(value)
+			case "--seq":
+				for _, part := range strings.Split(value, ",") {
+					part = strings.TrimSpace(part)
+					if part == "" {
+						continue
+					}
+					n, err := strconv.Atoi(part)
+					if err != nil || n <= 0 {
+						return commandErrorf(jsonOut, "invalid_seq", "invalid --seq: %s", value)
+					}
+					seqs = append(seqs, n)
+				}
+			case "--project-root":
+				projectRoot = value
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if recipient == "" {
+		return commandError(jsonOut, "missing_required_flag", "--session is required")
+	}
+	if len(seqs) == 0 {
+		return commandError(jsonOut, "missing_required_flag", "--seq is required")
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+	cursor, err := ackMailboxMessages(projectRoot, recipient, seqs)
+	if err != nil {
+		return commandErrorf(jsonOut, "mailbox_write_failed", "failed acknowledging messages: %v", err)
+	}
+	if jsonOut {
+		writeJSON(map[string]any{
+			"session": recipient,
+			"acked":   seqs,
+			"cursor":  cursor.Cursor,
+		})
+		return 0
+	}
+	fmt.Printf("acked %d message(s), cursor=%d\n", len(seqs), cursor.Cursor)
+	return 0
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+var payloadReadStdinFn = func() ([]byte, error) {
+	return io.ReadAll(os.Stdin)
+}
+
+func cmdPayload(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: lisa payload <subcommand>")
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		return showHelp("payload")
+	}
+	if args[0] == "help" {
+		if len(args) > 1 {
+			return showHelp("payload " + args[1])
+		}
+		return showHelp("payload")
+	}
+
+	switch args[0] {
+	case "decode":
+		return cmdPayloadDecode(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown payload subcommand: %s\n", args[0])
+		return 1
+	}
+}
+
+// cmdPayloadDecode expands compressed handoff/packet JSON from stdin.
+func cmdPayloadDecode(args []string) int {
+	jsonOut := hasJSONFlag(args)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("payload decode")
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+
+	raw, err := payloadReadStdinFn()
+	if err != nil {
+		return commandErrorf(jsonOut, "payload_read_failed", "failed to read payload from stdin: %v", err)
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return commandErrorf(jsonOut, "invalid_payload_json", "invalid payload JSON: %v", err)
+	}
+	if _, err := expandCompressedPayloads(doc); err != nil {
+		return commandErrorf(jsonOut, "payload_decode_failed", "failed to decode compressed payload: %v", err)
+	}
+	writeJSON(doc)
+	return 0
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+)
+
+func TestCmdPayloadDecodeExpandsHandoffAndPacket(t *testing.T) {
+	origRead := payloadReadStdinFn
+	t.Cleanup(func() { payloadReadStdinFn = origRead })
+
+	inner := map[string]any{
+		"session":    "lisa-payload",
+		"nextAction": "session send",
+		"recent":     []any{map[string]any{"at": "t1", "type": "snapshot", "state": "in_progress"}},
+	}
+	encoded, _, _, err := compressJSONPayload(inner, "zstd")
+	if err != nil {
+		t.Fatalf("compress failed: %v", err)
+	}
+	packet := `{"session":"lisa-payload","handoff":{"session":"lisa-payload","nextAction":{"name":"session send"},` +
+		`"compression":"zstd","encoding":"base64-zstd","compressedPayload":"` + encoded + `","compressedBytes":10}}`
+	payloadReadStdinFn = func() ([]byte, error) { return []byte(packet), nil }
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdPayloadDecode(nil); code != 0 {
+			t.Fatalf("decode failed: %d", code)
+		}
+	})
+	payload := parseJSONMap(t, stdout)
+	handoff, ok := payload["handoff"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected handoff object, got %v", payload)
+	}
+	if _, ok := handoff["compressedPayload"]; ok {
+		t.Fatalf("expected envelope keys removed, got %v", handoff)
+	}
+	if recent, ok := handoff["recent"].([]any); !ok || len(recent) != 1 {
+		t.Fatalf("expected recent restored, got %v", handoff["recent"])
+	}
+	if next, ok := handoff["nextAction"].(map[string]any); !ok || next["name"] != "session send" {
+		t.Fatalf("expected existing nextAction object to win, got %v", handoff["nextAction"])
+	}
+
+	// Packets from before real zstd: compression "zstd", gzip body.
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(`{"recent":[]}`))
+	_ = zw.Close()
+	legacy := `{"compression":"zstd","encoding":"base64-gzip","compressedPayload":"` + base64.StdEncoding.EncodeToString(buf.Bytes()) + `"}`
+	payloadReadStdinFn = func() ([]byte, error) { return []byte(legacy), nil }
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdPayloadDecode(nil); code != 0 {
+			t.Fatalf("legacy decode failed: %d", code)
+		}
+	})
+	if payload := parseJSONMap(t, stdout); payload["recent"] == nil || payload["compression"] != nil {
+		t.Fatalf("expected legacy gzip payload expanded, got %v", payload)
+	}
+
+	payloadReadStdinFn = func() ([]byte, error) { return []byte(`{"compressedPayload":"AAAA","encoding":"base64-lz4"}`), nil }
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdPayloadDecode([]string{"--json"}); code == 0 {
+			t.Fatalf("expected unknown encoding to fail")
+		}
+	})
+	if payload := parseJSONMap(t, stdout); payload["errorCode"] != "payload_decode_failed" {
+		t.Fatalf("unexpected error payload: %v", payload)
+	}
+}
@@ -0,0 +1,653 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func cmdQueue(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: lisa queue <subcommand>")
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		return showHelp("queue")
+	}
+	if args[0] == "help" {
+		if len(args) > 1 {
+			return showHelp("queue " + args[1])
+		}
+		return showHelp("queue")
+	}
+
+	switch args[0] {
+	case "add":
+		return cmdQueueAdd(args[1:])
+	case "list":
+		return cmdQueueList(args[1:])
+	case "cancel":
+		return cmdQueueCancel(args[1:])
+	case "drain":
+		return cmdQueueDrain(args[1:])
+	case "work":
+		return cmdQueueWork(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown queue subcommand: %s\n", args[0])
+		return 1
+	}
+}
+
+func cmdQueueAdd(args []string) int {
+	job := queueJob{}
+	projectRoot := getPWD()
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("queue add")
+		case "--agent", "--mode", "--prompt", "--lane", "--model", "--task-hash", "--priority", "--retries", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--agent":
+				job.Agent = value
+			case "--mode":
+				job.Mode = value
+			case "--prompt":
+				job.Prompt = value
+			case "--lane":
+				job.Lane = strings.ToLower(strings.TrimSpace(value))
+			case "--model":
+				job.Model = value
+			case "--task-hash":
+				job.TaskHash = strings.TrimSpace(value)
+			case "--priority":
+				n, err := strconv.Atoi(strings.TrimSpace(value))
+				if err != nil {
+					return commandError(jsonOut, "invalid_priority", "invalid --priority")
+				}
+				job.Priority = n
+			case "--retries":
+				n, err := parseNonNegativeIntFlag(value, "--retries")
+				if err != nil {
+					return commandError(jsonOut, "invalid_retries", err.Error())
+				}
+				job.MaxRetries = n
+			case "--project-root":
+				projectRoot = value
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+
+	if strings.TrimSpace(job.Prompt) == "" {
+		return commandError(jsonOut, "missing_required_flag", "--prompt is required")
+	}
+	var err error
+	if job.Agent != "" {
+		if job.Agent, err = parseAgent(job.Agent); err != nil {
+			return commandError(jsonOut, "invalid_agent", err.Error())
+		}
+	}
+	if job.Mode != "" {
+		if job.Mode, err = parseMode(job.Mode); err != nil {
+			return commandError(jsonOut, "invalid_mode", err.Error())
+		}
+	}
+	if job.Model != "" {
+		if job.Model, err = parseModel(job.Model); err != nil {
+			return commandError(jsonOut, "invalid_model", err.Error())
+		}
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+	if job.Lane != "" {
+		if _, found, laneErr := loadLaneRecord(projectRoot, job.Lane); laneErr != nil {
+			return commandErrorf(jsonOut, "lane_load_failed", "failed loading lane %q: %v", job.Lane, laneErr)
+		} else if !found {
+			return commandErrorf(jsonOut, "lane_not_found", "lane not found: %s", job.Lane)
+		}
+	}
+
+	var duplicateOf string
+	err = updateQueueStore(projectRoot, func(store *queueStore) error {
+		if job.TaskHash != "" {
+			for _, existing := range store.Jobs {
+				if existing.TaskHash == job.TaskHash && !queueJobTerminal(existing.State) {
+					duplicateOf = existing.ID
+					return errQueueDuplicate
+				}
+			}
+		}
+		store.Seq++
+		now := nowFn().UTC().Format(time.RFC3339)
+		job.ID = fmt.Sprintf("q%d", store.Seq)
+		job.State = "queued"
+		job.CreatedAt = now
+		job.UpdatedAt = now
+		store.Jobs = append(store.Jobs, job)
+		return nil
+	})
+	if errors.Is(err, errQueueDuplicate) {
+		if jsonOut {
+			writeJSON(map[string]any{
+				"ok":          false,
+				"taskHash":    job.TaskHash,
+				"duplicateOf": duplicateOf,
+				"errorCode":   "task_duplicate_queued",
+				"error":       fmt.Sprintf("task hash %s already queued as %s", job.TaskHash, duplicateOf),
+			})
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "task hash %s already queued as %s\n", job.TaskHash, duplicateOf)
+		return 1
+	}
+	if err != nil {
+		return commandErrorf(jsonOut, "queue_write_failed", "failed writing queue: %v", err)
+	}
+
+	if jsonOut {
+		writeJSON(map[string]any{
+			"ok":          true,
+			"projectRoot": projectRoot,
+			"job":         job,
+		})
+		return 0
+	}
+	fmt.Println(job.ID)
+	return 0
+}
+
+var errQueueDuplicate = errors.New("duplicate task hash")
+
+func cmdQueueList(args []string) int {
+	projectRoot := getPWD()
+	stateFilter := ""
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("queue list")
+		case "--state":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --state")
+			}
+			stateFilter = strings.ToLower(strings.TrimSpace(args[i+1]))
+			i++
+		case "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --project-root")
+			}
+			projectRoot = args[i+1]
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	switch stateFilter {
+	case "", "queued", "running", "completed", "failed", "cancelled", "duplicate":
+	default:
+		return commandErrorf(jsonOut, "invalid_state", "invalid --state: %s (expected queued|running|completed|failed|cancelled|duplicate)", stateFilter)
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+	store, err := loadQueueStore(projectRoot)
+	if err != nil {
+		return commandErrorf(jsonOut, "queue_read_failed", "failed reading queue: %v", err)
+	}
+
+	jobs := []queueJob{}
+	counts := map[string]int{}
+	for _, job := range sortedQueueJobs(store.Jobs) {
+		counts[job.State]++
+		if stateFilter != "" && job.State != stateFilter {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	if jsonOut {
+		writeJSON(map[string]any{
+			"projectRoot": projectRoot,
+			"draining":    store.Draining,
+			"counts":      counts,
+			"count":       len(jobs),
+			"jobs":        jobs,
+		})
+		return 0
+	}
+	for _, job := range jobs {
+		agent, mode := resolveQueueJobProfile(projectRoot, job)
+		line := fmt.Sprintf("%s\t%s\tp=%d\t%s/%s", job.ID, job.State, job.Priority, agent, mode)
+		if job.Session != "" {
+			line += "\t" + job.Session
+		}
+		fmt.Println(line + "\t" + truncateQueuePrompt(job.Prompt, 60))
+	}
+	return 0
+}
+
+func truncateQueuePrompt(prompt string, max int) string {
+	prompt = strings.Join(strings.Fields(prompt), " ")
+	if len(prompt) <= max {
+		return prompt
+	}
+	return prompt[:max-3] + "..."
+}
+
+func cmdQueueCancel(args []string) int {
+	projectRoot := getPWD()
+	ids := []string{}
+	all := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("queue cancel")
+		case "--id":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --id")
+			}
+			ids = append(ids, strings.TrimSpace(args[i+1]))
+			i++
+		case "--all":
+			all = true
+		case "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --project-root")
+			}
+			projectRoot = args[i+1]
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if len(ids) == 0 && !all {
+		return commandError(jsonOut, "missing_required_flag", "--id or --all is required")
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+
+	cancelled, sessions, err := cancelQueueJobs(projectRoot, ids, all, false)
+	var notFound queueJobNotFoundError
+	if errors.As(err, &notFound) {
+		return commandErrorf(jsonOut, "job_not_found", "queue job not found: %s", string(notFound))
+	}
+	if err != nil {
+		return commandErrorf(jsonOut, "queue_write_failed", "failed writing queue: %v", err)
+	}
+	killed := killQueueSessions(projectRoot, sessions)
+
+	if jsonOut {
+		writeJSON(map[string]any{
+			"projectRoot":    projectRoot,
+			"cancelled":      cancelled,
+			"killedSessions": killed,
+		})
+		return 0
+	}
+	for _, id := range cancelled {
+		fmt.Println(id)
+	}
+	return 0
+}
+
+type queueJobNotFoundError string
+
+func (e queueJobNotFoundError) Error() string { return "queue job not found: " + string(e) }
+
+// cancelQueueJobs cancels the named jobs (or every open job with all). When
+// queuedOnly is set running jobs are left to finish. It returns the sessions
+// of cancelled running jobs so the caller can kill them outside the lock.
+func cancelQueueJobs(projectRoot string, ids []string, all, queuedOnly bool) ([]string, []string, error) {
+	cancelled := []string{}
+	sessions := []string{}
+	err := updateQueueStore(projectRoot, func(store *queueStore) error {
+		targets := map[string]bool{}
+		for _, id := range ids {
+			if store.find(id) == nil {
+				return queueJobNotFoundError(id)
+			}
+			targets[id] = true
+		}
+		now := nowFn().UTC().Format(time.RFC3339)
+		for i := range store.Jobs {
+			job := &store.Jobs[i]
+			if !all && !targets[job.ID] {
+				continue
+			}
+			if queueJobTerminal(job.State) || (queuedOnly && job.State != "queued") {
+				continue
+			}
+			if job.State == "running" && job.Session != "" {
+				sessions = append(sessions, job.Session)
+			}
+			job.State = "cancelled"
+			job.FinishedAt = now
+			job.UpdatedAt = now
+			cancelled = append(cancelled, job.ID)
+		}
+		return nil
+	})
+	return cancelled, sessions, err
+}
+
+func killQueueSessions(projectRoot string, sessions []string) []string {
+	killed := []string{}
+	if len(sessions) == 0 {
+		return killed
+	}
+	binPath, err := osExecutableFn()
+	if err != nil {
+		return killed
+	}
+	for _, session := range sessions {
+		if _, _, err := runLisaSubcommandFn(binPath, "session", "kill", "--session", session, "--project-root", projectRoot, "--json"); err == nil {
+			killed = append(killed, session)
+		}
+	}
+	return killed
+}
+
+func cmdQueueDrain(args []string) int {
+	projectRoot := getPWD()
+	cancelQueued := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("queue drain")
+		case "--cancel-queued":
+			cancelQueued = true
+		case "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --project-root")
+			}
+			projectRoot = args[i+1]
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+
+	cancelled := []string{}
+	if cancelQueued {
+		var err error
+		if cancelled, _, err = cancelQueueJobs(projectRoot, nil, true, true); err != nil {
+			return commandErrorf(jsonOut, "queue_write_failed", "failed writing queue: %v", err)
+		}
+	}
+	running := 0
+	queued := 0
+	err := updateQueueStore(projectRoot, func(store *queueStore) error {
+		store.Draining = true
+		for _, job := range store.Jobs {
+			switch job.State {
+			case "running":
+				running++
+			case "queued":
+				queued++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return commandErrorf(jsonOut, "queue_write_failed", "failed writing queue: %v", err)
+	}
+
+	if jsonOut {
+		writeJSON(map[string]any{
+			"projectRoot": projectRoot,
+			"draining":    true,
+			"running":     running,
+			"queued":      queued,
+			"cancelled":   cancelled,
+		})
+		return 0
+	}
+	fmt.Printf("draining: running=%d queued=%d cancelled=%d\n", running, queued, len(cancelled))
+	return 0
+}
+
+func cmdQueueWork(args []string) int {
+	projectRoot := getPWD()
+	concurrency := 1
+	pollInterval := defaultPollIntervalSeconds
+	maxPolls := defaultMaxPolls
+	retryOnRaw := strings.Join(defaultQueueRetryOn, ",")
+	keepSessions := false
+	watch := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("queue work")
+		case "--concurrency", "--poll-interval", "--max-polls":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			n, err := parsePositiveIntFlag(args[i+1], args[i])
+			if err != nil {
+				return commandError(jsonOut, "invalid_"+strings.ReplaceAll(strings.TrimPrefix(args[i], "--"), "-", "_"), err.Error())
+			}
+			switch args[i] {
+			case "--concurrency":
+				concurrency = n
+			case "--poll-interval":
+				pollInterval = n
+			case "--max-polls":
+				maxPolls = n
+			}
+			i++
+		case "--retry-on":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --retry-on")
+			}
+			retryOnRaw = args[i+1]
+			i++
+		case "--keep-sessions":
+			keepSessions = true
+		case "--watch":
+			watch = true
+		case "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --project-root")
+			}
+			projectRoot = args[i+1]
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	retryOn, err := parseQueueRetryOn(retryOnRaw)
+	if err != nil {
+		return commandError(jsonOut, "invalid_retry_on", err.Error())
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+	binPath, err := osExecutableFn()
+	if err != nil || strings.TrimSpace(binPath) == "" {
+		return commandErrorf(jsonOut, "binary_path_resolve_failed", "failed to resolve lisa binary path: %v", err)
+	}
+	opts := queueWorkOptions{
+		ProjectRoot:  projectRoot,
+		BinPath:      strings.TrimSpace(binPath),
+		PollInterval: pollInterval,
+		MaxPolls:     maxPolls,
+		RetryOn:      retryOn,
+		KeepSessions: keepSessions,
+	}
+
+	recovered := []string{}
+	err = updateQueueStore(projectRoot, func(store *queueStore) error {
+		// A drain applies to the workers running when it was issued.
+		store.Draining = false
+		recovered = recoverQueueJobs(store)
+		return nil
+	})
+	if err != nil {
+		return commandErrorf(jsonOut, "queue_write_failed", "failed writing queue: %v", err)
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		inFlight int
+		finished = map[string]bool{}
+		retried  = 0
+		errs     = []string{}
+		drained  = false
+	)
+	slots := make(chan struct{}, concurrency)
+	for {
+		slots <- struct{}{}
+		mu.Lock()
+		idle := inFlight == 0
+		mu.Unlock()
+
+		var claimed *queueJob
+		err := updateQueueStore(projectRoot, func(store *queueStore) error {
+			if store.Draining {
+				drained = true
+				return nil
+			}
+			idx := nextQueuedJob(*store)
+			if idx < 0 {
+				return nil
+			}
+			now := nowFn().UTC().Format(time.RFC3339)
+			job := &store.Jobs[idx]
+			job.State = "running"
+			job.WorkerPID = os.Getpid()
+			job.StartedAt = now
+			job.UpdatedAt = now
+			copied := *job
+			claimed = &copied
+			return nil
+		})
+		if err != nil {
+			<-slots
+			mu.Lock()
+			errs = append(errs, err.Error())
+			mu.Unlock()
+			break
+		}
+		if claimed == nil {
+			<-slots
+			if drained || (idle && !watch) {
+				break
+			}
+			queueSleepFn(queueClaimInterval)
+			continue
+		}
+
+		mu.Lock()
+		inFlight++
+		mu.Unlock()
+		if !jsonOut {
+			fmt.Fprintf(os.Stderr, "queue: started %s (attempt %d)\n", claimed.ID, len(claimed.Attempts)+1)
+		}
+		wg.Add(1)
+		go func(job queueJob) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			outcome := runQueueJob(opts, job)
+			var final queueJob
+			var wasRetried bool
+			updateErr := updateQueueStore(projectRoot, func(store *queueStore) error {
+				current := store.find(job.ID)
+				if current == nil {
+					return nil
+				}
+				wasRetried = applyQueueOutcome(current, outcome, opts.RetryOn, job.StartedAt)
+				final = *current
+				return nil
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			inFlight--
+			if updateErr != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", job.ID, updateErr))
+				return
+			}
+			finished[job.ID] = true
+			if wasRetried {
+				retried++
+			}
+			if !jsonOut {
+				line := fmt.Sprintf("%s\t%s\t%s", final.ID, final.State, final.Session)
+				if final.FinalState != "" && final.FinalState != final.State {
+					line += "\tfinalState=" + final.FinalState
+				}
+				fmt.Println(line)
+			}
+		}(*claimed)
+	}
+	wg.Wait()
+
+	store, err := loadQueueStore(projectRoot)
+	if err != nil {
+		return commandErrorf(jsonOut, "queue_read_failed", "failed reading queue: %v", err)
+	}
+	jobs := []queueJob{}
+	counts := map[string]int{}
+	for _, job := range store.Jobs {
+		if !finished[job.ID] {
+			continue
+		}
+		jobs = append(jobs, job)
+		counts[job.State]++
+	}
+	ok := counts["failed"] == 0 && len(errs) == 0
+
+	if jsonOut {
+		payload := map[string]any{
+			"ok":          ok,
+			"projectRoot": projectRoot,
+			"concurrency": concurrency,
+			"processed":   len(jobs),
+			"counts":      counts,
+			"retried":     retried,
+			"drained":     drained,
+			"recovered":   recovered,
+			"jobs":        jobs,
+		}
+		if len(errs) > 0 {
+			payload["errorCode"] = "queue_work_failed"
+			payload["errors"] = errs
+		} else if !ok {
+			payload["errorCode"] = "queue_jobs_failed"
+		}
+		writeJSON(payload)
+	} else {
+		for _, msg := range errs {
+			fmt.Fprintf(os.Stderr, "queue: %s\n", msg)
+		}
+		fmt.Fprintf(os.Stderr, "queue: processed=%d completed=%d failed=%d retried=%d\n", len(jobs), counts["completed"], counts["failed"], retried)
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,256 @@
+package app
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultReportWindow = 24 * time.Hour
+
+// reportCell is one table cell; Link renders the text as a file link.
+type reportCell struct {
+	Text string
+	Link string
+}
+
+type reportTable struct {
+	Title  string
+	Header []string
+	Rows   [][]reportCell
+	Empty  string
+}
+
+func cmdReport(args []string) int {
+	until := nowFn()
+	since := until.Add(-defaultReportWindow)
+	projectRoot := ""
+	format := "markdown"
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("report")
+		case "--since", "--until", "--project-root", "--format":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--since":
+				parsed, err := parseSinceFlag("--since", value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_since", err.Error())
+				}
+				since = parsed
+			case "--until":
+				parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+				if err != nil {
+					return commandErrorf(jsonOut, "invalid_until", "invalid --until: %s (expected RFC3339)", value)
+				}
+				until = parsed
+			case "--project-root":
+				projectRoot = canonicalProjectRoot(value)
+			case "--format":
+				format = strings.ToLower(strings.TrimSpace(value))
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if jsonOut {
+		format = "json"
+	}
+	switch format {
+	case "markdown", "md", "html", "json":
+	default:
+		return commandErrorf(jsonOut, "invalid_format", "invalid --format: %s (expected markdown|html|json)", format)
+	}
+	if until.Before(since) {
+		return commandError(jsonOut, "invalid_window", "--until is before --since")
+	}
+
+	report := buildFleetReport(since, until, projectRoot)
+	switch format {
+	case "json":
+		writeJSON(report)
+	case "html":
+		fmt.Print(renderReportHTML(report))
+	default:
+		fmt.Print(renderReportMarkdown(report))
+	}
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "report warning: %s\n", warning)
+	}
+	return 0
+}
+
+func formatReportSeconds(seconds int64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func reportSummaryRows(report fleetReport) [][]reportCell {
+	ttc := report.TimeToCompletion
+	rows := [][]string{
+		{"Sessions run", fmt.Sprintf("%d", report.SessionsRun)},
+		{"Sessions finished", fmt.Sprintf("%d", report.SessionsFinished)},
+		{"Time to completion", fmt.Sprintf("n=%d avg %s, median %s, p90 %s, max %s", ttc.Count,
+			formatReportSeconds(ttc.AvgSeconds), formatReportSeconds(ttc.MedianSeconds),
+			formatReportSeconds(ttc.P90Seconds), formatReportSeconds(ttc.MaxSeconds))},
+		{"Stuck sessions", fmt.Sprintf("%d", report.Stuck.Sessions)},
+		{"Crashed sessions", fmt.Sprintf("%d", report.Crashes.Sessions)},
+		{"Nudges", fmt.Sprintf("%d (%d prompt answers)", report.Interventions.Nudges, report.Interventions.PromptAnswers)},
+		{"Auto-recover attempts", fmt.Sprintf("%d", report.Interventions.AutoRecover)},
+		{"Tokens", fmt.Sprintf("%d (in %d, out %d, cache read %d, cache write %d)", report.Tokens.Total, report.Tokens.Input, report.Tokens.Output, report.Tokens.CacheRead, report.Tokens.CacheCreation)},
+	}
+	out := make([][]reportCell, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, []reportCell{{Text: row[0]}, {Text: row[1]}})
+	}
+	return out
+}
+
+func reportCountRows(counts []reportCount) [][]reportCell {
+	rows := make([][]reportCell, 0, len(counts))
+	for _, c := range counts {
+		rows = append(rows, []reportCell{{Text: c.Name}, {Text: fmt.Sprintf("%d", c.Count)}})
+	}
+	return rows
+}
+
+func reportRollupRows(rollups []reportRollup, objectives bool) [][]reportCell {
+	rows := make([][]reportCell, 0, len(rollups))
+	for _, r := range rollups {
+		row := []reportCell{{Text: r.Name}}
+		if objectives {
+			row = append(row, reportCell{Text: r.Goal}, reportCell{Text: r.Status})
+		}
+		row = append(row,
+			reportCell{Text: fmt.Sprintf("%d", r.Sessions)},
+			reportCell{Text: fmt.Sprintf("%d", r.Completed)},
+			reportCell{Text: fmt.Sprintf("%d", r.Failed)},
+			reportCell{Text: fmt.Sprintf("%d", r.Tokens)},
+			reportCell{Text: formatReportSeconds(r.DurationSeconds)},
+		)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func reportSessionRows(sessions []reportSession) [][]reportCell {
+	rows := make([][]reportCell, 0, len(sessions))
+	for _, s := range sessions {
+		state := s.FinalState
+		if s.ExitCode != nil {
+			state = fmt.Sprintf("%s (exit %d)", state, *s.ExitCode)
+		}
+		archive := reportCell{Text: "-"}
+		switch {
+		case filepath.IsAbs(s.ArchivePath):
+			archive = reportCell{Text: "archive", Link: (&url.URL{Scheme: "file", Path: s.ArchivePath}).String()}
+		case s.ArchivePath != "":
+			archive = reportCell{Text: s.ArchivePath}
+		}
+		rows = append(rows, []reportCell{
+			{Text: s.Session}, {Text: s.Agent}, {Text: state}, {Text: s.LastState},
+			{Text: formatReportSeconds(s.DurationSeconds)}, {Text: s.EndedAt}, archive,
+		})
+	}
+	return rows
+}
+
+func reportTables(report fleetReport) []reportTable {
+	rollupHeader := []string{"Sessions", "Completed", "Failed", "Tokens", "Time"}
+	sessionHeader := []string{"Session", "Agent", "State", "Last state", "Duration", "Ended", "Archive"}
+	return []reportTable{
+		{Title: "Summary", Header: []string{"Metric", "Value"}, Rows: reportSummaryRows(report)},
+		{Title: "Outcomes", Header: []string{"State", "Sessions"}, Rows: reportCountRows(report.Outcomes), Empty: "No finished sessions."},
+		{Title: "Stuck reasons", Header: []string{"Reason", "Sessions"}, Rows: reportCountRows(report.Stuck.Reasons), Empty: "No stuck sessions."},
+		{Title: "Crash reasons", Header: []string{"Reason", "Sessions"}, Rows: reportCountRows(report.Crashes.Reasons), Empty: "No crashes."},
+		{Title: "Lanes", Header: append([]string{"Lane"}, rollupHeader...), Rows: reportRollupRows(report.Lanes, false), Empty: "No lane sessions."},
+		{Title: "Objectives", Header: append([]string{"Objective", "Goal", "Status"}, rollupHeader...), Rows: reportRollupRows(report.Objectives, true), Empty: "No objective sessions."},
+		{Title: "Slowest sessions", Header: sessionHeader, Rows: reportSessionRows(report.Slowest), Empty: "No finished sessions."},
+		{Title: "Failed sessions", Header: sessionHeader, Rows: reportSessionRows(report.Failed), Empty: "No failed sessions."},
+	}
+}
+
+func reportTitle(report fleetReport) string {
+	title := fmt.Sprintf("Lisa report %s → %s", report.Since, report.Until)
+	if report.ProjectRoot != "" {
+		title += " (" + report.ProjectRoot + ")"
+	}
+	return title
+}
+
+func markdownReportCell(cell reportCell) string {
+	text := strings.ReplaceAll(cell.Text, "|", `\|`)
+	text = strings.ReplaceAll(text, "\n", " ")
+	if cell.Link != "" {
+		return "[" + text + "](" + cell.Link + ")"
+	}
+	return text
+}
+
+func renderReportMarkdown(report fleetReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", reportTitle(report))
+	for _, table := range reportTables(report) {
+		fmt.Fprintf(&b, "\n## %s\n\n", table.Title)
+		if len(table.Rows) == 0 {
+			fmt.Fprintf(&b, "%s\n", table.Empty)
+			continue
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(table.Header, " | "))
+		fmt.Fprintf(&b, "|%s\n", strings.Repeat(" --- |", len(table.Header)))
+		for _, row := range table.Rows {
+			cells := make([]string, 0, len(row))
+			for _, cell := range row {
+				cells = append(cells, markdownReportCell(cell))
+			}
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+	return b.String()
+}
+
+func renderReportHTML(report fleetReport) string {
+	var b strings.Builder
+	title := html.EscapeString(reportTitle(report))
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title>\n", title)
+	b.WriteString("<style>body{font-family:sans-serif}table{border-collapse:collapse;margin-bottom:1em}th,td{border:1px solid #ccc;padding:4px 8px;text-align:left}</style>\n")
+	fmt.Fprintf(&b, "</head><body>\n<h1>%s</h1>\n", title)
+	for _, table := range reportTables(report) {
+		fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(table.Title))
+		if len(table.Rows) == 0 {
+			fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(table.Empty))
+			continue
+		}
+		b.WriteString("<table>\n<tr>")
+		for _, h := range table.Header {
+			fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(h))
+		}
+		b.WriteString("</tr>\n")
+		for _, row := range table.Rows {
+			b.WriteString("<tr>")
+			for _, cell := range row {
+				if cell.Link != "" {
+					fmt.Fprintf(&b, "<td><a href=\"%s\">%s</a></td>", html.EscapeString(cell.Link), html.EscapeString(cell.Text))
+				} else {
+					fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(cell.Text))
+				}
+			}
+			b.WriteString("</tr>\n")
+		}
+		b.WriteString("</table>\n")
+	}
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
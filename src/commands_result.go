@@ -0,0 +1,215 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func cmdResult(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: lisa result <subcommand>")
+		return 1
+	}
+	if args[0] == "--help" || args[0] == "-h" {
+		return showHelp("result")
+	}
+	if args[0] == "help" {
+		if len(args) > 1 {
+			return showHelp("result " + args[1])
+		}
+		return showHelp("result")
+	}
+
+	switch args[0] {
+	case "put":
+		return cmdResultPut(args[1:])
+	case "get":
+		return cmdResultGet(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown result subcommand: %s\n", args[0])
+		return 1
+	}
+}
+
+func cmdResultPut(args []string) int {
+	session := ""
+	status := ""
+	summary := ""
+	runID := ""
+	artifacts := []string{}
+	projectRoot := mailboxDefaultProjectRoot()
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("result put")
+		case "--session", "--status", "--summary", "--artifact", "--run-id", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--session":
+				session = strings.TrimSpace(value)
+			case "--status":
+				parsed, err := parseSessionResultStatus(value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_status", err.Error())
+				}
+				status = parsed
+			case "--summary":
+				summary = value
+			case "--artifact":
+				artifacts = append(artifacts, resultArtifactPath(value))
+			case "--run-id":
+				runID = strings.TrimSpace(value)
+			case "--project-root":
+				projectRoot = value
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if status == "" {
+		return commandError(jsonOut, "missing_required_flag", "--status is required")
+	}
+	if session == "" {
+		// LISA_RUN_ID only describes the pane's own session, so it is only
+		// trusted when the session also comes from the pane environment.
+		session = strings.TrimSpace(os.Getenv("LISA_SESSION_NAME"))
+		if runID == "" {
+			runID = strings.TrimSpace(os.Getenv("LISA_RUN_ID"))
+		}
+	}
+	if session == "" {
+		return commandError(jsonOut, "missing_required_flag", "--session is required outside a lisa session")
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+	if runID == "" {
+		if meta, err := loadSessionMeta(projectRoot, session); err == nil {
+			runID = strings.TrimSpace(meta.RunID)
+		}
+	}
+
+	result := sessionResult{
+		Session:    session,
+		RunID:      runID,
+		Status:     status,
+		Summary:    redactOutputText(summary),
+		Artifacts:  artifacts,
+		ReportedAt: nowFn().UTC().Format(time.RFC3339Nano),
+	}
+	if err := saveSessionResult(projectRoot, result); err != nil {
+		return commandErrorf(jsonOut, "result_write_failed", "failed writing result: %v", err)
+	}
+	if err := appendSessionResultEvent(projectRoot, result); err != nil {
+		fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+	}
+	if jsonOut {
+		writeJSON(map[string]any{
+			"ok":     true,
+			"result": result,
+		})
+		return 0
+	}
+	fmt.Printf("result %s recorded for %s\n", result.Status, result.Session)
+	return 0
+}
+
+// resultArtifactPath anchors relative artifact paths at the caller's cwd so
+// the parent can open them from anywhere; URLs pass through.
+func resultArtifactPath(raw string) string {
+	value := strings.TrimSpace(raw)
+	if value == "" || filepath.IsAbs(value) || strings.Contains(value, "://") {
+		return value
+	}
+	if abs, err := filepath.Abs(value); err == nil {
+		return abs
+	}
+	return value
+}
+
+func cmdResultGet(args []string) int {
+	session := strings.TrimSpace(os.Getenv("LISA_SESSION_NAME"))
+	projectRoot := getPWD()
+	projectRootExplicit := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("result get")
+		case "--session", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			if args[i] == "--session" {
+				session = strings.TrimSpace(args[i+1])
+			} else {
+				projectRoot = args[i+1]
+				projectRootExplicit = true
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if session == "" {
+		return commandError(jsonOut, "missing_required_flag", "--session is required")
+	}
+	if !projectRootExplicit {
+		if root := strings.TrimSpace(os.Getenv(lisaProjectRootEnv)); root != "" {
+			projectRoot = root
+		}
+	}
+	resolvedRoot, resolveErr := resolveSessionProjectRootChecked(session, projectRoot, projectRootExplicit)
+	if resolveErr != nil {
+		return commandErrorf(jsonOut, "ambiguous_project_root", "%v", resolveErr)
+	}
+	projectRoot = resolvedRoot
+
+	result, err := loadSessionResult(projectRoot, session)
+	if err != nil {
+		return commandErrorf(jsonOut, "result_read_failed", "%v", err)
+	}
+	stale := false
+	if result != nil {
+		if meta, metaErr := loadSessionMeta(projectRoot, session); metaErr == nil {
+			stale = loadCurrentSessionResult(projectRoot, session, strings.TrimSpace(meta.RunID)) == nil
+		}
+	}
+	if result == nil {
+		if jsonOut {
+			writeJSONError("result_not_found", "no result reported", map[string]any{"session": session})
+		} else {
+			fmt.Fprintln(os.Stderr, "no result reported")
+		}
+		return 1
+	}
+	if jsonOut {
+		writeJSON(map[string]any{
+			"session": session,
+			"result":  result,
+			"stale":   stale,
+		})
+		return 0
+	}
+	line := fmt.Sprintf("%s\t%s\t%s", result.Status, result.ReportedAt, result.Summary)
+	if stale {
+		line += "\t(stale: earlier run)"
+	}
+	fmt.Println(redactOutputText(line))
+	for _, artifact := range result.Artifacts {
+		fmt.Println("artifact: " + artifact)
+	}
+	return 0
+}
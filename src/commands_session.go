@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -33,6 +34,10 @@ func cmdSession(args []string) int {
 		return cmdSessionDetectNested(args[1:])
 	case "send":
 		return cmdSessionSend(args[1:])
+	case "answer":
+		return cmdSessionAnswer(args[1:])
+	case "inbox":
+		return cmdSessionInbox(args[1:])
 	case "snapshot":
 		return cmdSessionSnapshot(args[1:])
 	case "status":
@@ -43,6 +48,8 @@ func cmdSession(args []string) int {
 		return cmdSessionMonitor(args[1:])
 	case "capture":
 		return cmdSessionCapture(args[1:])
+	case "tail":
+		return cmdSessionTail(args[1:])
 	case "packet":
 		return cmdSessionPacket(args[1:])
 	case "turn":
@@ -107,6 +114,8 @@ func cmdSession(args []string) int {
 		return cmdSessionList(args[1:])
 	case "exists":
 		return cmdSessionExists(args[1:])
+	case "stop":
+		return cmdSessionStop(args[1:])
 	case "kill":
 		return cmdSessionKill(args[1:])
 	case "kill-all":
@@ -202,6 +211,8 @@ func cmdSessionSpawn(args []string) int {
 	skipPermissions := true
 	dryRun := false
 	detectNested := false
+	limits := &sessionLimits{}
+	labelValues := []string{}
 	jsonOut := hasJSONFlag(args)
 	agentSet := false
 	modeSet := false
@@ -234,6 +245,12 @@ func cmdSessionSpawn(args []string) int {
 			}
 			lane = strings.ToLower(strings.TrimSpace(args[i+1]))
 			i++
+		case "--label", "-l":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			labelValues = append(labelValues, args[i+1])
+			i++
 		case "--nested-policy":
 			if i+1 >= len(args) {
 				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --nested-policy")
@@ -306,6 +323,41 @@ func cmdSessionSpawn(args []string) int {
 			}
 			height = n
 			i++
+		case "--max-runtime", "--idle-timeout":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			d, err := parseDurationFlag(args[i], args[i+1])
+			if err != nil {
+				return commandError(jsonOut, "invalid_limit", err.Error())
+			}
+			seconds := int(math.Ceil(d.Seconds()))
+			if args[i] == "--max-runtime" {
+				limits.MaxRuntimeSeconds = seconds
+			} else {
+				limits.IdleTimeoutSeconds = seconds
+			}
+			i++
+		case "--memory-max":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --memory-max")
+			}
+			n, err := parseMemoryLimit(args[i+1])
+			if err != nil {
+				return commandError(jsonOut, "invalid_limit", err.Error())
+			}
+			limits.MemoryMaxBytes = n
+			i++
+		case "--cpu-quota":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --cpu-quota")
+			}
+			n, err := parseCPUQuota(args[i+1])
+			if err != nil {
+				return commandError(jsonOut, "invalid_limit", err.Error())
+			}
+			limits.CPUQuotaPercent = n
+			i++
 		case "--cleanup-all-hashes":
 			cleanupAllHashes = true
 		case "--dry-run":
@@ -322,6 +374,13 @@ func cmdSessionSpawn(args []string) int {
 	}
 
 	projectRoot = canonicalProjectRoot(projectRoot)
+	if limits.empty() {
+		limits = nil
+	}
+	labels, labelErr := parseSpawnLabels(labelValues)
+	if labelErr != nil {
+		return commandError(jsonOut, "invalid_label", labelErr.Error())
+	}
 	if lane != "" {
 		laneRecord, found, laneErr := loadLaneRecord(projectRoot, lane)
 		if laneErr != nil {
@@ -406,6 +465,10 @@ func cmdSessionSpawn(args []string) int {
 		return commandError(jsonOut, "invalid_nested_policy_combination", nestedErr.Error())
 	}
 	agentArgs = adjustedArgs
+	agentHooks := false
+	if command == "" && agentHooksEnabled() {
+		agentArgs, agentHooks = applyAgentHookArgs(agent, projectRoot, session, agentArgs)
+	}
 	if command == "" {
 		command, err = buildAgentCommandWithOptions(agent, mode, prompt, agentArgs, skipPermissions)
 		if err != nil {
@@ -498,10 +561,14 @@ func cmdSessionSpawn(args []string) int {
 			"width":          width,
 			"height":         height,
 			"env":            envPayload,
+			"agentHooks":     agentHooks,
 		}
 		if oauthTokenPreviewID != "" {
 			payload["oauthTokenId"] = oauthTokenPreviewID
 		}
+		if len(labels) > 0 {
+			payload["labels"] = labels
+		}
 		if hasObjective {
 			payload["objective"] = map[string]any{
 				"id":         objective.ID,
@@ -513,6 +580,15 @@ func cmdSessionSpawn(args []string) int {
 		if detectNested {
 			payload["nestedDetection"] = nestedDetection
 		}
+		if limits != nil {
+			planned := *limits
+			var warnings []string
+			planned.Backend, planned.CgroupPath, warnings = planSessionLimits(projectRoot, session, limits)
+			payload["limits"] = planned
+			if len(warnings) > 0 {
+				payload["limitWarnings"] = warnings
+			}
+		}
 		if jsonOut {
 			writeJSON(payload)
 			return 0
@@ -535,6 +611,27 @@ func cmdSessionSpawn(args []string) int {
 		emitSpawnFailureEvent("spawn_heartbeat_prepare_error")
 		return commandErrorf(jsonOut, "spawn_heartbeat_prepare_failed", "failed to prepare heartbeat file: %v", err)
 	}
+	if agentHooks && agent == "claude" {
+		if err := writeClaudeHookSettings(projectRoot, session); err != nil {
+			emitSpawnFailureEvent("spawn_hook_settings_error")
+			return commandErrorf(jsonOut, "spawn_hook_settings_failed", "failed to write agent hook settings: %v", err)
+		}
+	}
+	var limitWarnings []string
+	if limits != nil {
+		limitPrefix, applyWarnings, limitErr := applySessionLimits(projectRoot, session, limits)
+		limitWarnings = applyWarnings
+		for _, warning := range limitWarnings {
+			fmt.Fprintf(os.Stderr, "limits warning: %s\n", warning)
+		}
+		if limitErr != nil {
+			emitSpawnFailureEvent("spawn_limits_error")
+			return commandErrorf(jsonOut, "spawn_limits_failed", "failed to apply limits: %v", limitErr)
+		}
+		if limitPrefix != "" && commandToSend != "" {
+			commandToSend = limitPrefix + " " + commandToSend
+		}
+	}
 
 	if err := tmuxNewSessionWithStartupFn(session, projectRoot, agent, mode, width, height, commandToSend); err != nil {
 		msg := fmt.Sprintf("failed to create tmux session: %v", err)
@@ -544,9 +641,14 @@ func cmdSessionSpawn(args []string) int {
 		if cleanupErr := cleanupSessionArtifactsWithOptions(projectRoot, session, cleanupOpts); cleanupErr != nil {
 			fmt.Fprintf(os.Stderr, "cleanup warning: %v\n", cleanupErr)
 		}
+		removeSessionCgroup(limits)
 		emitSpawnFailureEvent("spawn_tmux_new_error")
 		return commandError(jsonOut, "spawn_tmux_new_failed", msg)
 	}
+	for _, warning := range confirmSessionLimits(projectRoot, session, limits) {
+		fmt.Fprintf(os.Stderr, "limits warning: %s\n", warning)
+		limitWarnings = append(limitWarnings, warning)
+	}
 
 	meta := sessionMeta{
 		Session:       session,
@@ -560,6 +662,8 @@ func cmdSessionSpawn(args []string) int {
 		SocketPath:    tmuxSocketPathForProjectRoot(projectRoot),
 		StartCmd:      command,
 		Prompt:        prompt,
+		Labels:        labels,
+		Limits:        limits,
 		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 	}
 	if hasObjective {
@@ -578,6 +682,7 @@ func cmdSessionSpawn(args []string) int {
 		if cleanupErr != nil {
 			fmt.Fprintf(os.Stderr, "cleanup warning: %v\n", cleanupErr)
 		}
+		removeSessionCgroup(limits)
 		emitSpawnFailureEvent("spawn_meta_persist_error")
 		return commandError(jsonOut, "spawn_meta_persist_failed", msg)
 	}
@@ -605,6 +710,7 @@ func cmdSessionSpawn(args []string) int {
 		oauthTokenID = selection.ID
 	}
 	_ = os.Remove(sessionStateFile(projectRoot, session))
+	auditSessionNote = session
 	if err := appendLifecycleEvent(projectRoot, session, "lifecycle", "spawned", "active", "spawn_success"); err != nil {
 		fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
 	}
@@ -621,10 +727,20 @@ func cmdSessionSpawn(args []string) int {
 			"projectRoot":   projectRoot,
 			"socketPath":    tmuxSocketPathForProjectRoot(projectRoot),
 			"command":       command,
+			"agentHooks":    agentHooks,
 		}
 		if oauthTokenID != "" {
 			payload["oauthTokenId"] = oauthTokenID
 		}
+		if len(labels) > 0 {
+			payload["labels"] = labels
+		}
+		if limits != nil {
+			payload["limits"] = limits
+			if len(limitWarnings) > 0 {
+				payload["limitWarnings"] = limitWarnings
+			}
+		}
 		if hasObjective {
 			payload["objective"] = map[string]any{
 				"id":         objective.ID,
@@ -960,11 +1076,13 @@ func shouldSplitCodexInteractiveSubmit(meta sessionMeta, text string, enter bool
 
 func cmdSessionSend(args []string) int {
 	session := ""
+	subtreeRoot := ""
 	projectRoot := getPWD()
 	projectRootExplicit := false
 	text := ""
 	keys := ""
 	enter := false
+	when := ""
 	jsonOut := hasJSONFlag(args)
 	jsonMin := false
 
@@ -972,12 +1090,28 @@ func cmdSessionSend(args []string) int {
 		switch args[i] {
 		case "--help", "-h":
 			return showHelp("session send")
+		case "--when":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --when")
+			}
+			parsed, err := parseSessionInboxCondition(args[i+1])
+			if err != nil {
+				return commandError(jsonOut, "invalid_when", err.Error())
+			}
+			when = parsed
+			i++
 		case "--session":
 			if i+1 >= len(args) {
 				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --session")
 			}
 			session = args[i+1]
 			i++
+		case "--subtree":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --subtree")
+			}
+			subtreeRoot = args[i+1]
+			i++
 		case "--project-root":
 			if i+1 >= len(args) {
 				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --project-root")
@@ -1009,7 +1143,10 @@ func cmdSessionSend(args []string) int {
 		}
 	}
 
-	if session == "" {
+	if session != "" && subtreeRoot != "" {
+		return commandError(jsonOut, "subtree_session_conflict", "use either --session or --subtree, not both")
+	}
+	if session == "" && subtreeRoot == "" {
 		return commandError(jsonOut, "missing_required_flag", "--session is required")
 	}
 	if text == "" && keys == "" {
@@ -1025,6 +1162,12 @@ func cmdSessionSend(args []string) int {
 			return commandError(jsonOut, "empty_keys", "empty --keys")
 		}
 	}
+	if subtreeRoot != "" && when != "" {
+		return commandError(jsonOut, "when_subtree_conflict", "--when cannot be combined with --subtree")
+	}
+	if subtreeRoot != "" {
+		return cmdSessionSendSubtree(subtreeRoot, projectRoot, projectRootExplicit, text, keyList, enter, jsonOut, jsonMin)
+	}
 
 	resolvedRoot, resolveErr := resolveSessionProjectRootChecked(session, projectRoot, projectRootExplicit)
 	if resolveErr != nil {
@@ -1034,6 +1177,61 @@ func cmdSessionSend(args []string) int {
 	restoreRuntime := withProjectRuntimeEnv(projectRoot)
 	defer restoreRuntime()
 
+	if when != "" {
+		return cmdSessionSendDeferred(projectRoot, session, sessionInboxMessage{Text: text, Keys: keyList, Enter: enter, When: when}, jsonOut)
+	}
+
+	meta, errorCode, sendErr := deliverSessionSend(projectRoot, session, text, keyList, enter)
+	if sendErr != nil {
+		if errorCode == "session_not_found" {
+			if jsonOut {
+				writeJSONError("session_not_found", "session not found", map[string]any{
+					"session":     session,
+					"projectRoot": projectRoot,
+				})
+			} else {
+				fmt.Fprintln(os.Stderr, "session not found")
+			}
+			return 1
+		}
+		return commandError(jsonOut, errorCode, sendErr.Error())
+	}
+
+	if jsonOut {
+		objective := objectivePayloadFromMeta(meta)
+		if jsonMin {
+			payload := map[string]any{
+				"session": session,
+				"ok":      true,
+			}
+			if objective != nil {
+				payload["objective"] = objective
+			}
+			writeJSON(payload)
+			return 0
+		}
+		payload := map[string]any{
+			"session": session,
+			"ok":      true,
+			"enter":   enter,
+		}
+		if strings.TrimSpace(meta.Lane) != "" {
+			payload["lane"] = meta.Lane
+		}
+		if objective != nil {
+			payload["objective"] = objective
+		}
+		writeJSON(payload)
+		return 0
+	}
+	fmt.Println("ok")
+	return 0
+}
+
+// deliverSessionSend types text or keys into one session pane, applying the
+// objective reminder prefix and recording lifecycle/input observability. The
+// returned error code mirrors the session send JSON contract.
+func deliverSessionSend(projectRoot, session, text string, keyList []string, enter bool) (sessionMeta, string, error) {
 	meta, metaErr := loadSessionMeta(projectRoot, session)
 	if metaErr != nil {
 		meta = sessionMeta{Session: session, ProjectRoot: projectRoot}
@@ -1048,15 +1246,7 @@ func cmdSessionSend(args []string) int {
 		}
 	}
 	if !tmuxHasSessionFn(session) {
-		if jsonOut {
-			writeJSONError("session_not_found", "session not found", map[string]any{
-				"session":     session,
-				"projectRoot": projectRoot,
-			})
-		} else {
-			fmt.Fprintln(os.Stderr, "session not found")
-		}
-		return 1
+		return meta, "session_not_found", fmt.Errorf("session not found")
 	}
 	recordInputAt := shouldRecordInputTimestamp(text, keyList, enter)
 	sendAt := time.Now()
@@ -1064,7 +1254,7 @@ func cmdSessionSend(args []string) int {
 	if text != "" {
 		if shouldSplitCodexInteractiveSubmit(meta, text, enter) {
 			if err := tmuxSendTextFn(session, text, false); err != nil {
-				return commandErrorf(jsonOut, "send_text_failed", "failed sending text: %v", err)
+				return meta, "send_text_failed", fmt.Errorf("failed sending text: %v", err)
 			}
 			if err := appendLifecycleEvent(projectRoot, session, "lifecycle", "in_progress", "active", "send_text"); err != nil {
 				fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
@@ -1073,14 +1263,14 @@ func cmdSessionSend(args []string) int {
 			// `session send --text ...` then `session send --keys Enter`.
 			time.Sleep(2 * time.Second)
 			if err := tmuxSendKeysFn(session, []string{"Enter"}, false); err != nil {
-				return commandErrorf(jsonOut, "send_keys_failed", "failed sending keys: %v", err)
+				return meta, "send_keys_failed", fmt.Errorf("failed sending keys: %v", err)
 			}
 			if err := appendLifecycleEvent(projectRoot, session, "lifecycle", "in_progress", "active", "send_keys"); err != nil {
 				fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
 			}
 		} else {
 			if err := tmuxSendTextFn(session, text, enter); err != nil {
-				return commandErrorf(jsonOut, "send_text_failed", "failed sending text: %v", err)
+				return meta, "send_text_failed", fmt.Errorf("failed sending text: %v", err)
 			}
 			if err := appendLifecycleEvent(projectRoot, session, "lifecycle", "in_progress", "active", "send_text"); err != nil {
 				fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
@@ -1088,7 +1278,7 @@ func cmdSessionSend(args []string) int {
 		}
 	} else {
 		if err := tmuxSendKeysFn(session, keyList, enter); err != nil {
-			return commandErrorf(jsonOut, "send_keys_failed", "failed sending keys: %v", err)
+			return meta, "send_keys_failed", fmt.Errorf("failed sending keys: %v", err)
 		}
 		if err := appendLifecycleEvent(projectRoot, session, "lifecycle", "in_progress", "active", "send_keys"); err != nil {
 			fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
@@ -1099,34 +1289,5 @@ func cmdSessionSend(args []string) int {
 			fmt.Fprintf(os.Stderr, "observability warning: failed to record input timestamp: %v\n", err)
 		}
 	}
-
-	if jsonOut {
-		objective := objectivePayloadFromMeta(meta)
-		if jsonMin {
-			payload := map[string]any{
-				"session": session,
-				"ok":      true,
-			}
-			if objective != nil {
-				payload["objective"] = objective
-			}
-			writeJSON(payload)
-			return 0
-		}
-		payload := map[string]any{
-			"session": session,
-			"ok":      true,
-			"enter":   enter,
-		}
-		if strings.TrimSpace(meta.Lane) != "" {
-			payload["lane"] = meta.Lane
-		}
-		if objective != nil {
-			payload["objective"] = objective
-		}
-		writeJSON(payload)
-		return 0
-	}
-	fmt.Println("ok")
-	return 0
+	return meta, "", nil
 }
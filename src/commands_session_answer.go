@@ -0,0 +1,197 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func cmdSessionAnswer(args []string) int {
+	session := ""
+	projectRoot := getPWD()
+	projectRootExplicit := false
+	agentHint := "auto"
+	modeHint := "auto"
+	option := 0
+	text := ""
+	usePolicy := false
+	force := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("session answer")
+		case "--session":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --session")
+			}
+			session = args[i+1]
+			i++
+		case "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --project-root")
+			}
+			projectRoot = args[i+1]
+			projectRootExplicit = true
+			i++
+		case "--agent":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --agent")
+			}
+			agentHint = args[i+1]
+			i++
+		case "--mode":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --mode")
+			}
+			modeHint = args[i+1]
+			i++
+		case "--option":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --option")
+			}
+			n, err := parsePositiveIntFlag(args[i+1], "--option")
+			if err != nil {
+				return commandError(jsonOut, "invalid_option", err.Error())
+			}
+			option = n
+			i++
+		case "--text":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --text")
+			}
+			text = args[i+1]
+			i++
+		case "--policy":
+			usePolicy = true
+		case "--force":
+			force = true
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+
+	if session == "" {
+		return commandError(jsonOut, "missing_required_flag", "--session is required")
+	}
+	choices := 0
+	for _, set := range []bool{option > 0, text != "", usePolicy} {
+		if set {
+			choices++
+		}
+	}
+	if choices != 1 {
+		return commandError(jsonOut, "answer_choice_required", "provide exactly one of --option, --text, or --policy")
+	}
+	if usePolicy && force {
+		return commandError(jsonOut, "policy_force_conflict", "--force cannot be combined with --policy")
+	}
+	agentHint, err := parseAgentHint(agentHint)
+	if err != nil {
+		return commandError(jsonOut, "invalid_agent_hint", err.Error())
+	}
+	modeHint, err = parseModeHint(modeHint)
+	if err != nil {
+		return commandError(jsonOut, "invalid_mode_hint", err.Error())
+	}
+
+	resolvedRoot, resolveErr := resolveSessionProjectRootChecked(session, projectRoot, projectRootExplicit)
+	if resolveErr != nil {
+		return commandErrorf(jsonOut, "ambiguous_project_root", "%v", resolveErr)
+	}
+	projectRoot = resolvedRoot
+	restoreRuntime := withProjectRuntimeEnv(projectRoot)
+	defer restoreRuntime()
+
+	if !tmuxHasSessionFn(session) {
+		return commandError(jsonOut, "session_not_found", "session not found")
+	}
+	status, err := computeSessionStatusFn(session, projectRoot, agentHint, modeHint, false, 0)
+	if err != nil {
+		return commandError(jsonOut, "status_compute_failed", err.Error())
+	}
+	if status.PendingPrompt == nil {
+		// Explicit answers also work when the classifier has not reached
+		// waiting_input yet (e.g. a menu shown while the agent still burns CPU).
+		status.PendingPrompt = detectSessionPendingPrompt(session)
+	}
+	if status.PendingPrompt == nil && !force {
+		if jsonOut {
+			writeJSONError("no_pending_prompt", "no pending prompt detected in pane (use --force to send anyway)", map[string]any{
+				"session":      session,
+				"sessionState": status.SessionState,
+			})
+		} else {
+			fmt.Fprintln(os.Stderr, "no pending prompt detected in pane (use --force to send anyway)")
+		}
+		return 1
+	}
+
+	decision := promptDecision{Source: "manual", Action: "answer", Option: option, Text: text}
+	if usePolicy {
+		policy, policyErr := loadPromptPolicy(projectRoot)
+		if policyErr != nil {
+			return commandErrorf(jsonOut, "invalid_prompt_policy", "invalid prompt policy: %v", policyErr)
+		}
+		if policy == nil {
+			return commandErrorf(jsonOut, "prompt_policy_missing", "no prompt policy at %s", promptPolicyPath(projectRoot))
+		}
+		decision = policy.decide(status.Agent, status.PendingPrompt)
+		if decision.Action != "answer" {
+			if eventErr := appendPromptDecisionEvent(projectRoot, session, status, decision); eventErr != nil {
+				fmt.Fprintf(os.Stderr, "observability warning: %v\n", eventErr)
+			}
+			errorCode := "prompt_policy_" + decision.Action
+			if jsonOut {
+				writeJSON(map[string]any{
+					"session":       session,
+					"ok":            false,
+					"pendingPrompt": status.PendingPrompt,
+					"decision":      decision,
+					"errorCode":     errorCode,
+				})
+			} else {
+				fmt.Fprintf(os.Stderr, "prompt policy: %s\n", strings.ReplaceAll(decision.Action, "_", " "))
+			}
+			return 1
+		}
+	}
+	if decision.Text == "" && status.PendingPrompt != nil {
+		if _, keyErr := promptAnswerKeys(status.PendingPrompt, decision.Option); keyErr != nil {
+			return commandError(jsonOut, "invalid_option", keyErr.Error())
+		}
+	}
+
+	if sendErr := sendPromptDecision(session, status.PendingPrompt, &decision); sendErr != nil {
+		decision.Action = "answer_failed"
+		if eventErr := appendPromptDecisionEvent(projectRoot, session, status, decision); eventErr != nil {
+			fmt.Fprintf(os.Stderr, "observability warning: %v\n", eventErr)
+		}
+		return commandErrorf(jsonOut, "prompt_answer_failed", "failed answering prompt: %v", sendErr)
+	}
+	if eventErr := appendPromptDecisionEvent(projectRoot, session, status, decision); eventErr != nil {
+		fmt.Fprintf(os.Stderr, "observability warning: %v\n", eventErr)
+	}
+
+	if jsonOut {
+		payload := map[string]any{
+			"session":  session,
+			"ok":       true,
+			"decision": decision,
+		}
+		if status.PendingPrompt != nil {
+			payload["pendingPrompt"] = status.PendingPrompt
+		}
+		writeJSON(payload)
+		return 0
+	}
+	if decision.Text != "" {
+		fmt.Println("answered with text")
+		return 0
+	}
+	fmt.Printf("answered option %d\n", decision.Option)
+	return 0
+}
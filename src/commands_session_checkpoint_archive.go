@@ -0,0 +1,438 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func cmdSessionCheckpointExport(args []string) int {
+	session := ""
+	archivePath := ""
+	projectRoot := getPWD()
+	projectRootExplicit := false
+	includeDiff := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("session checkpoint")
+		case "--session", "--archive", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			switch args[i] {
+			case "--session":
+				session = strings.TrimSpace(args[i+1])
+			case "--archive":
+				archivePath = strings.TrimSpace(args[i+1])
+			case "--project-root":
+				projectRoot = args[i+1]
+				projectRootExplicit = true
+			}
+			i++
+		case "--include-diff":
+			includeDiff = true
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if session == "" {
+		return commandError(jsonOut, "missing_required_flag", "--session is required for export")
+	}
+	if archivePath == "" {
+		return commandError(jsonOut, "missing_required_flag", "--archive is required for export")
+	}
+	resolvedArchive, err := expandAndCleanPath(archivePath)
+	if err != nil {
+		return commandErrorf(jsonOut, "invalid_archive_path", "invalid --archive: %v", err)
+	}
+	resolvedRoot, resolveErr := resolveSessionProjectRootChecked(session, projectRoot, projectRootExplicit)
+	if resolveErr != nil {
+		return commandErrorf(jsonOut, "ambiguous_project_root", "%v", resolveErr)
+	}
+	projectRoot = resolvedRoot
+
+	archive, warnings, err := collectCheckpointArchive(projectRoot, session, includeDiff)
+	if err != nil {
+		return commandErrorf(jsonOut, "checkpoint_export_failed", "failed collecting checkpoint: %v", err)
+	}
+	if err := writeCheckpointArchive(resolvedArchive, archive); err != nil {
+		return commandErrorf(jsonOut, "checkpoint_write_failed", "failed writing archive: %v", err)
+	}
+	files := sortedCheckpointFileNames(archive)
+
+	if jsonOut {
+		payload := map[string]any{
+			"action":      "export",
+			"archive":     resolvedArchive,
+			"session":     session,
+			"projectRoot": projectRoot,
+			"agent":       archive.Manifest.Agent,
+			"files":       files,
+			"redactions":  archive.Manifest.Redactions,
+		}
+		if archive.Manifest.GitHead != "" {
+			payload["gitHead"] = archive.Manifest.GitHead
+		}
+		if len(archive.Manifest.UntrackedFiles) > 0 {
+			payload["untrackedFiles"] = archive.Manifest.UntrackedFiles
+			payload["untrackedOmitted"] = archive.Manifest.UntrackedOmitted
+		}
+		if len(warnings) > 0 {
+			payload["warnings"] = warnings
+		}
+		writeJSON(payload)
+		return 0
+	}
+	fmt.Printf("exported %s to %s (%s)\n", session, resolvedArchive, strings.Join(files, ","))
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	return 0
+}
+
+func cmdSessionCheckpointImport(args []string) int {
+	archivePath := ""
+	session := ""
+	projectRoot := getPWD()
+	applyDiff := false
+	spawn := false
+	force := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("session checkpoint")
+		case "--archive", "--session", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			switch args[i] {
+			case "--archive":
+				archivePath = strings.TrimSpace(args[i+1])
+			case "--session":
+				session = strings.TrimSpace(args[i+1])
+			case "--project-root":
+				projectRoot = args[i+1]
+			}
+			i++
+		case "--apply-diff":
+			applyDiff = true
+		case "--spawn":
+			spawn = true
+		case "--force":
+			force = true
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if archivePath == "" {
+		return commandError(jsonOut, "missing_required_flag", "--archive is required for import")
+	}
+	resolvedArchive, err := expandAndCleanPath(archivePath)
+	if err != nil {
+		return commandErrorf(jsonOut, "invalid_archive_path", "invalid --archive: %v", err)
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+
+	archive, err := readCheckpointArchive(resolvedArchive)
+	if err != nil {
+		return commandErrorf(jsonOut, "checkpoint_archive_invalid", "invalid checkpoint archive: %v", err)
+	}
+	manifest := archive.Manifest
+	if session == "" {
+		session = manifest.Session
+	}
+	if !strings.HasPrefix(session, "lisa-") {
+		return commandError(jsonOut, "invalid_session_name", `invalid --session: must start with "lisa-"`)
+	}
+	if fileExists(sessionMetaFile(projectRoot, session)) && !force {
+		return commandErrorf(jsonOut, "checkpoint_session_exists", "session %s already has metadata under %s (use --force to overwrite)", session, projectRoot)
+	}
+
+	meta := sessionMeta{}
+	if err := json.Unmarshal(archive.Files["meta.json"], &meta); err != nil {
+		return commandErrorf(jsonOut, "checkpoint_archive_invalid", "invalid meta.json: %v", err)
+	}
+	meta.Session = session
+	meta.ProjectRoot = projectRoot
+	meta.ParentSession = ""
+	meta.SocketPath = ""
+	if meta.Limits != nil {
+		meta.Limits.CgroupPath = ""
+	}
+	if err := saveSessionMeta(projectRoot, session, meta); err != nil {
+		return commandErrorf(jsonOut, "checkpoint_import_failed", "failed writing meta: %v", err)
+	}
+	restored := []string{"meta"}
+	warnings := []string{}
+
+	if raw, ok := archive.Files["state.json"]; ok {
+		state := sessionState{}
+		if err := json.Unmarshal(raw, &state); err != nil {
+			warnings = append(warnings, "state: "+err.Error())
+		} else if err := saveSessionState(sessionStateFile(projectRoot, session), state); err != nil {
+			return commandErrorf(jsonOut, "checkpoint_import_failed", "failed writing state: %v", err)
+		} else {
+			restored = append(restored, "state")
+		}
+	}
+	if raw, ok := archive.Files["events.jsonl"]; ok {
+		eventsPath := sessionEventsFile(projectRoot, session)
+		if err := writeFileAtomic(eventsPath, raw); err != nil {
+			return commandErrorf(jsonOut, "checkpoint_import_failed", "failed writing events: %v", err)
+		}
+		// The archived log is complete; stale segments would duplicate it.
+		_ = os.Remove(sessionEventCountFile(eventsPath))
+		_ = removeSessionEventSegments(eventsPath)
+		restored = append(restored, "events")
+	}
+	if raw, ok := archive.Files["memory.json"]; ok {
+		memory := sessionMemoryRecord{}
+		if err := json.Unmarshal(raw, &memory); err != nil {
+			warnings = append(warnings, "memory: "+err.Error())
+		} else if err := saveSessionMemory(projectRoot, session, memory); err != nil {
+			warnings = append(warnings, "memory: "+err.Error())
+		} else {
+			restored = append(restored, "memory")
+		}
+	}
+	if raw, ok := archive.Files["objective.json"]; ok {
+		if err := importCheckpointObjective(projectRoot, raw); err != nil {
+			warnings = append(warnings, "objective: "+err.Error())
+		} else {
+			restored = append(restored, "objective")
+		}
+	}
+	if raw, ok := archive.Files["lane.json"]; ok {
+		imported, err := importCheckpointLane(projectRoot, raw)
+		if err != nil {
+			warnings = append(warnings, "lane: "+err.Error())
+		} else if imported {
+			restored = append(restored, "lane")
+		} else {
+			warnings = append(warnings, "lane: kept existing lane "+meta.Lane)
+		}
+	}
+	if raw, ok := archive.Files["output.txt"]; ok {
+		if err := os.WriteFile(sessionOutputFile(projectRoot, session), raw, 0o600); err == nil {
+			restored = append(restored, "output")
+		}
+	}
+	transcriptPath := ""
+	if raw, ok := archive.Files["transcript.jsonl"]; ok {
+		transcriptPath, err = importCheckpointTranscript(projectRoot, manifest, raw)
+		if err != nil {
+			warnings = append(warnings, "transcript: "+err.Error())
+		} else {
+			restored = append(restored, "transcript")
+		}
+	}
+	diffPath := ""
+	diffApplied := false
+	if raw, ok := archive.Files["worktree.diff"]; ok {
+		diffPath = filepath.Join(projectRoot, ".lisa", "checkpoints", session+"-worktree.diff")
+		if err := os.MkdirAll(filepath.Dir(diffPath), 0o700); err != nil {
+			return commandErrorf(jsonOut, "checkpoint_import_failed", "failed writing diff: %v", err)
+		}
+		if err := writeFileAtomic(diffPath, raw); err != nil {
+			return commandErrorf(jsonOut, "checkpoint_import_failed", "failed writing diff: %v", err)
+		}
+		restored = append(restored, "worktree.diff")
+		if applyDiff && len(strings.TrimSpace(string(raw))) > 0 {
+			if out, applyErr := runCmd("git", "-C", projectRoot, "apply", "--whitespace=nowarn", diffPath); applyErr != nil {
+				warnings = append(warnings, "git apply failed: "+strings.TrimSpace(out))
+			} else {
+				diffApplied = true
+			}
+		}
+	}
+	_ = appendLifecycleEvent(projectRoot, session, "lifecycle", "imported", "idle", "checkpoint_imported")
+
+	resumeArgs := checkpointResumeSpawnArgs(projectRoot, session, manifest, meta, archive)
+	resume := map[string]any{
+		"args":    resumeArgs,
+		"command": "lisa " + shellJoin(resumeArgs),
+	}
+	spawnCode := 0
+	if spawn {
+		binPath, binErr := osExecutableFn()
+		if binErr != nil {
+			return commandErrorf(jsonOut, "checkpoint_spawn_failed", "failed resolving lisa binary: %v", binErr)
+		}
+		spawnPayload, code, errText := runQueueSubcommandJSON(binPath, append(resumeArgs, "--json")...)
+		resume["spawn"] = spawnPayload
+		if code != 0 {
+			resume["spawnError"] = errText
+			spawnCode = 1
+		}
+	}
+
+	if jsonOut {
+		payload := map[string]any{
+			"action":            "import",
+			"archive":           resolvedArchive,
+			"session":           session,
+			"sourceSession":     manifest.Session,
+			"projectRoot":       projectRoot,
+			"sourceProjectRoot": manifest.SourceProjectRoot,
+			"agent":             manifest.Agent,
+			"restored":          restored,
+			"resume":            resume,
+		}
+		if transcriptPath != "" {
+			payload["transcriptPath"] = transcriptPath
+		}
+		if diffPath != "" {
+			payload["diffPath"] = diffPath
+			payload["diffApplied"] = diffApplied
+		}
+		if len(warnings) > 0 {
+			payload["warnings"] = warnings
+		}
+		if spawnCode != 0 {
+			payload["errorCode"] = "checkpoint_spawn_failed"
+		}
+		writeJSON(payload)
+		return spawnCode
+	}
+	fmt.Printf("imported %s as %s under %s (%s)\n", manifest.Session, session, projectRoot, strings.Join(restored, ","))
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if spawn {
+		if spawnCode != 0 {
+			fmt.Fprintf(os.Stderr, "resume spawn failed: %v\n", resume["spawnError"])
+		} else {
+			fmt.Println("resumed: spawned " + session)
+		}
+		return spawnCode
+	}
+	fmt.Println("resume: " + resume["command"].(string))
+	return 0
+}
+
+func sortedCheckpointFileNames(archive checkpointArchive) []string {
+	names := make([]string, 0, len(archive.Manifest.Files))
+	for _, entry := range archive.Manifest.Files {
+		names = append(names, entry.Name)
+	}
+	if len(names) == 0 {
+		for name := range archive.Files {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// importCheckpointObjective adds the objective, making it current when the
+// target project has none.
+func importCheckpointObjective(projectRoot string, raw []byte) error {
+	record := sessionObjectiveRecord{}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return err
+	}
+	if strings.TrimSpace(record.ID) == "" {
+		return fmt.Errorf("objective has no id")
+	}
+	store, err := loadObjectiveStore(projectRoot)
+	if err != nil {
+		return err
+	}
+	record.UpdatedAt = nowFn().UTC().Format(time.RFC3339)
+	store.Objectives[record.ID] = record
+	if strings.TrimSpace(store.CurrentID) == "" {
+		store.CurrentID = record.ID
+	}
+	return saveObjectiveStore(projectRoot, store)
+}
+
+// importCheckpointLane adds the lane unless the target already defines it.
+func importCheckpointLane(projectRoot string, raw []byte) (bool, error) {
+	record := sessionLaneRecord{}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return false, err
+	}
+	name := strings.ToLower(strings.TrimSpace(record.Name))
+	if name == "" {
+		return false, fmt.Errorf("lane has no name")
+	}
+	store, err := loadLaneStore(projectRoot)
+	if err != nil {
+		return false, err
+	}
+	if _, exists := store.Lanes[name]; exists {
+		return false, nil
+	}
+	store.Lanes[name] = record
+	return true, saveLaneStore(projectRoot, store)
+}
+
+// importCheckpointTranscript places the transcript where the agent's own
+// resume lookup finds it: Claude's per-project dir, or Codex's sessions tree.
+func importCheckpointTranscript(projectRoot string, manifest checkpointArchiveManifest, raw []byte) (string, error) {
+	if strings.TrimSpace(manifest.TranscriptSessionID) == "" {
+		return "", fmt.Errorf("manifest has no transcript session id")
+	}
+	var path string
+	if manifest.Agent == "codex" {
+		home, err := osUserHomeDirFn()
+		if err != nil {
+			return "", err
+		}
+		name := filepath.Base(manifest.TranscriptName)
+		if name == "" || name == "." {
+			name = "rollout-imported-" + manifest.TranscriptSessionID + ".jsonl"
+		}
+		now := nowFn().UTC()
+		path = filepath.Join(home, ".codex", "sessions", now.Format("2006"), now.Format("01"), now.Format("02"), name)
+	} else {
+		path = filepath.Join(claudeProjectDir(projectRoot), manifest.TranscriptSessionID+".jsonl")
+	}
+	if fileExists(path) {
+		return path, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, raw, 0o600)
+}
+
+// checkpointResumeSpawnArgs builds the `session spawn` argv that continues
+// the imported work; Claude resumes its own conversation via --resume.
+func checkpointResumeSpawnArgs(projectRoot, session string, manifest checkpointArchiveManifest, meta sessionMeta, archive checkpointArchive) []string {
+	args := []string{
+		"session", "spawn",
+		"--session", session,
+		"--project-root", projectRoot,
+		"--agent", manifest.Agent,
+		"--mode", manifest.Mode,
+	}
+	if lane := strings.TrimSpace(meta.Lane); lane != "" {
+		args = append(args, "--lane", lane)
+	}
+	if manifest.Agent == "claude" && manifest.TranscriptSessionID != "" {
+		if _, ok := archive.Files["transcript.jsonl"]; ok {
+			args = append(args, "--agent-args", "--resume "+manifest.TranscriptSessionID)
+		}
+	}
+	return append(args, "--prompt", buildCheckpointResumePrompt(manifest, meta, archive))
+}
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
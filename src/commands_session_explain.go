@@ -25,6 +25,7 @@ func cmdSessionExplain(args []string) int {
 	modeHint := "auto"
 	eventLimit := 10
 	sinceRaw := ""
+	trace := false
 	jsonOut := hasJSONFlag(args)
 	jsonMin := false
 
@@ -83,6 +84,8 @@ func cmdSessionExplain(args []string) int {
 			}
 			sinceRaw = strings.TrimSpace(args[i+1])
 			i++
+		case "--trace":
+			trace = true
 		case "--json":
 			jsonOut = true
 		case "--json-min":
@@ -162,6 +165,9 @@ func cmdSessionExplain(args []string) int {
 			if sinceRaw != "" {
 				payload["since"] = sinceRaw
 			}
+			if trace {
+				payload["trace"] = explainClassificationTrace(projectRoot, status)
+			}
 			if status.SessionState == "not_found" {
 				payload["errorCode"] = "session_not_found"
 			}
@@ -178,6 +184,9 @@ func cmdSessionExplain(args []string) int {
 		if sinceRaw != "" {
 			payload["since"] = sinceRaw
 		}
+		if trace {
+			payload["trace"] = explainClassificationTrace(projectRoot, status)
+		}
 		if status.SessionState == "not_found" {
 			payload["errorCode"] = "session_not_found"
 		}
@@ -214,6 +223,9 @@ func cmdSessionExplain(args []string) int {
 	if status.Signals.TMUXReadError != "" {
 		fmt.Printf("tmux_read_error: %s\n", status.Signals.TMUXReadError)
 	}
+	if trace {
+		printClassificationTrace(explainClassificationTrace(projectRoot, status))
+	}
 	if len(events) == 0 {
 		fmt.Println("events: none")
 		if sinceRaw != "" {
@@ -268,46 +280,45 @@ func parseExplainSinceCursor(raw string) (explainSinceCursor, error) {
 	return explainSinceCursor{Raw: trimmed, Mode: "time", CutoffNS: parsed.UnixNano()}, nil
 }
 
+// readSessionEventsSince reads across rotated segments. Offset cursors are
+// absolute line offsets; lines already pruned by retention are skipped.
 func readSessionEventsSince(projectRoot, session string, cursor explainSinceCursor, max int) (sessionEventTail, error) {
 	result := sessionEventTail{}
 	path := sessionEventsFile(projectRoot, session)
-	raw, err := os.ReadFile(path)
+	lockTimeout := getIntEnv("LISA_EVENT_LOCK_TIMEOUT_MS", defaultEventLockTimeoutMS)
+	var lines []string
+	base := 0
+	err := withSharedFileLock(path+".lock", lockTimeout, func() error {
+		var readErr error
+		lines, base, readErr = readSessionEventLogLines(path)
+		return readErr
+	})
 	if err != nil {
 		return result, err
 	}
-	lines := trimLines(string(raw))
-	events := make([]sessionEvent, 0, len(lines))
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+	result.NextCursor = base + len(lines)
+	if cursor.Mode == "offset" {
+		start := cursor.Offset - base
+		if start < 0 {
+			start = 0
 		}
+		if start > len(lines) {
+			start = len(lines)
+		}
+		lines = lines[start:]
+	}
+	filtered := make([]sessionEvent, 0, len(lines))
+	for _, line := range lines {
 		event := sessionEvent{}
 		if err := json.Unmarshal([]byte(line), &event); err != nil {
 			continue
 		}
-		events = append(events, event)
-	}
-	result.NextCursor = len(events)
-	filtered := make([]sessionEvent, 0, len(events))
-	switch cursor.Mode {
-	case "offset":
-		start := cursor.Offset
-		if start < 0 {
-			start = 0
-		}
-		if start > len(events) {
-			start = len(events)
-		}
-		filtered = append(filtered, events[start:]...)
-	default:
-		for _, event := range events {
-			if ts, ok := parseEventTimestamp(event.At); ok {
-				if ts > cursor.CutoffNS {
-					filtered = append(filtered, event)
-				}
+		if cursor.Mode != "offset" {
+			if ts, ok := parseEventTimestamp(event.At); !ok || ts <= cursor.CutoffNS {
+				continue
 			}
 		}
+		filtered = append(filtered, event)
 	}
 	if max > 0 && len(filtered) > max {
 		result.DroppedLines = len(filtered) - max
@@ -324,3 +335,37 @@ func parseEventTimestamp(raw string) (int64, bool) {
 	}
 	return parsed.UnixNano(), true
 }
+
+// explainClassificationTrace returns the rule trace from the status compute,
+// or a stub naming the rules source when classification short-circuited
+// (session missing, tmux read errors, lock timeout).
+func explainClassificationTrace(projectRoot string, status sessionStatus) classificationTrace {
+	if status.ClassificationTrace != nil {
+		return *status.ClassificationTrace
+	}
+	rules := loadClassificationRules(projectRoot)
+	return classificationTrace{
+		RulesSource: rules.Source,
+		RulesError:  rules.Error,
+		Facts:       map[string]any{},
+		Rules:       []classificationRuleTrace{},
+	}
+}
+
+func printClassificationTrace(trace classificationTrace) {
+	fmt.Printf("trace: rules=%s\n", trace.RulesSource)
+	if trace.RulesError != "" {
+		fmt.Printf("trace_rules_error: %s\n", trace.RulesError)
+	}
+	if len(trace.Rules) == 0 {
+		fmt.Println("trace: no rules evaluated (classification short-circuited)")
+		return
+	}
+	for _, rule := range trace.Rules {
+		if rule.Fired {
+			fmt.Printf("  [fired] %s priority=%d source=%s\n", rule.Rule, rule.Priority, rule.Source)
+			continue
+		}
+		fmt.Printf("  [skip]  %s priority=%d source=%s: %s\n", rule.Rule, rule.Priority, rule.Source, rule.Detail)
+	}
+}
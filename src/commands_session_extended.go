@@ -1,6 +1,7 @@
 package app
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -521,6 +522,21 @@ func cmdSessionCheckpoint(args []string) int {
 		action = strings.ToLower(strings.TrimSpace(args[0]))
 		args = args[1:]
 	}
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--action" {
+			if next := strings.ToLower(strings.TrimSpace(args[i+1])); next == "export" || next == "import" {
+				action = next
+				args = append(append([]string{}, args[:i]...), args[i+2:]...)
+			}
+			break
+		}
+	}
+	switch action {
+	case "export":
+		return cmdSessionCheckpointExport(args)
+	case "import":
+		return cmdSessionCheckpointImport(args)
+	}
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
 		case "--help", "-h":
@@ -596,7 +612,7 @@ func cmdSessionCheckpoint(args []string) int {
 	switch action {
 	case "save", "resume":
 	default:
-		return commandErrorf(jsonOut, "invalid_action", "invalid action: %s (expected save|resume)", action)
+		return commandErrorf(jsonOut, "invalid_action", "invalid action: %s (expected save|resume|export|import)", action)
 	}
 	if strings.TrimSpace(filePath) == "" {
 		return commandError(jsonOut, "missing_required_flag", "--file is required")
@@ -697,10 +713,15 @@ func cmdSessionCheckpoint(args []string) int {
 		ContextPack:  pack,
 		CaptureTail:  captureTail,
 	}
-	data, err := json.MarshalIndent(bundle, "", "  ")
+	data, err := json.Marshal(bundle)
 	if err != nil {
 		return commandErrorf(jsonOut, "checkpoint_marshal_failed", "failed to encode checkpoint: %v", err)
 	}
+	data, redactions := redactOutputJSON(data)
+	indented := bytes.Buffer{}
+	if err := json.Indent(&indented, data, "", "  "); err == nil {
+		data = indented.Bytes()
+	}
 	if err := os.MkdirAll(filepath.Dir(filePath), 0o700); err != nil {
 		return commandErrorf(jsonOut, "checkpoint_write_failed", "failed to create checkpoint dir: %v", err)
 	}
@@ -716,6 +737,7 @@ func cmdSessionCheckpoint(args []string) int {
 			"projectRoot":  projectRoot,
 			"sessionState": bundle.SessionState,
 			"nextAction":   bundle.NextAction,
+			"redactions":   redactions,
 		})
 		return 0
 	}
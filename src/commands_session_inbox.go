@@ -0,0 +1,253 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// cmdSessionSendDeferred queues a send behind its --when condition and makes
+// one delivery attempt right away, so an already idle agent is not kept
+// waiting for the next monitor poll.
+func cmdSessionSendDeferred(projectRoot, session string, message sessionInboxMessage, jsonOut bool) int {
+	if !tmuxHasSessionFn(session) {
+		if jsonOut {
+			writeJSONError("session_not_found", "session not found", map[string]any{
+				"session":     session,
+				"projectRoot": projectRoot,
+			})
+		} else {
+			fmt.Fprintln(os.Stderr, "session not found")
+		}
+		return 1
+	}
+	queued, pending, err := enqueueSessionInboxMessage(projectRoot, session, message)
+	if err != nil {
+		return commandErrorf(jsonOut, "inbox_write_failed", "failed queueing message: %v", err)
+	}
+	delivered := false
+	deliveryErr := ""
+	if status, statusErr := computeSessionStatusFn(session, projectRoot, "auto", "auto", false, 0); statusErr == nil {
+		sent, sendErr := deliverSessionInbox(projectRoot, session, status)
+		if sendErr != nil {
+			deliveryErr = sendErr.Error()
+		}
+		if sent != nil {
+			pending--
+			delivered = sent.ID == queued.ID
+		}
+	}
+
+	if jsonOut {
+		payload := map[string]any{
+			"session":   session,
+			"ok":        true,
+			"id":        queued.ID,
+			"when":      queued.When,
+			"queued":    !delivered,
+			"delivered": delivered,
+			"pending":   pending,
+		}
+		if deliveryErr != "" {
+			payload["deliveryError"] = deliveryErr
+		}
+		writeJSON(payload)
+		return 0
+	}
+	if delivered {
+		fmt.Printf("delivered %s\n", queued.ID)
+		return 0
+	}
+	fmt.Printf("queued %s (when=%s, pending=%d)\n", queued.ID, queued.When, pending)
+	return 0
+}
+
+func cmdSessionInbox(args []string) int {
+	action := "list"
+	session := ""
+	messageID := ""
+	projectRoot := getPWD()
+	projectRootExplicit := false
+	jsonOut := hasJSONFlag(args)
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action = strings.ToLower(strings.TrimSpace(args[0]))
+		args = args[1:]
+	}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("session inbox")
+		case "--session", "--id", "--project-root":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			switch args[i] {
+			case "--session":
+				session = strings.TrimSpace(args[i+1])
+			case "--id":
+				messageID = strings.TrimSpace(args[i+1])
+			case "--project-root":
+				projectRoot = args[i+1]
+				projectRootExplicit = true
+			}
+			i++
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if action != "list" && action != "clear" && action != "deliver" {
+		return commandErrorf(jsonOut, "invalid_action", "invalid action: %s (expected list|clear|deliver)", action)
+	}
+	if session == "" {
+		return commandError(jsonOut, "missing_required_flag", "--session is required")
+	}
+	if messageID != "" && action != "clear" {
+		return commandError(jsonOut, "id_requires_clear", "--id is only valid with clear")
+	}
+	resolvedRoot, resolveErr := resolveSessionProjectRootChecked(session, projectRoot, projectRootExplicit)
+	if resolveErr != nil {
+		return commandErrorf(jsonOut, "ambiguous_project_root", "%v", resolveErr)
+	}
+	projectRoot = resolvedRoot
+
+	switch action {
+	case "clear":
+		return cmdSessionInboxClear(projectRoot, session, messageID, jsonOut)
+	case "deliver":
+		return cmdSessionInboxDeliver(projectRoot, session, jsonOut)
+	}
+	inbox, err := loadSessionInbox(projectRoot, session)
+	if err != nil {
+		return commandErrorf(jsonOut, "inbox_read_failed", "%v", err)
+	}
+	if jsonOut {
+		writeJSON(map[string]any{
+			"action":   "list",
+			"session":  session,
+			"count":    len(inbox.Messages),
+			"messages": inbox.Messages,
+		})
+		return 0
+	}
+	if len(inbox.Messages) == 0 {
+		fmt.Println("inbox empty")
+		return 0
+	}
+	for _, message := range inbox.Messages {
+		payload := message.Text
+		if payload == "" {
+			payload = "keys: " + strings.Join(message.Keys, " ")
+		}
+		line := fmt.Sprintf("%s\twhen=%s\tqueued=%s\t%s", message.ID, message.When, message.QueuedAt, payload)
+		if message.LastErr != "" {
+			line += fmt.Sprintf("\t(attempts=%d last_error=%s)", message.Attempts, message.LastErr)
+		}
+		fmt.Println(redactOutputText(line))
+	}
+	return 0
+}
+
+func cmdSessionInboxClear(projectRoot, session, messageID string, jsonOut bool) int {
+	removed := []sessionInboxMessage{}
+	remaining := 0
+	err := withSessionInboxLock(projectRoot, session, func() error {
+		inbox, err := loadSessionInbox(projectRoot, session)
+		if err != nil {
+			return err
+		}
+		kept := make([]sessionInboxMessage, 0, len(inbox.Messages))
+		for _, message := range inbox.Messages {
+			if messageID == "" || message.ID == messageID {
+				removed = append(removed, message)
+				continue
+			}
+			kept = append(kept, message)
+		}
+		inbox.Messages = kept
+		remaining = len(kept)
+		return saveSessionInbox(projectRoot, session, inbox)
+	})
+	if err != nil {
+		return commandErrorf(jsonOut, "inbox_write_failed", "%v", err)
+	}
+	if messageID != "" && len(removed) == 0 {
+		return commandErrorf(jsonOut, "inbox_message_not_found", "no queued message %s for %s", messageID, session)
+	}
+	for _, message := range removed {
+		appendSessionInboxEvent(projectRoot, session, "inbox_cleared", sessionStatus{}, message, remaining, "")
+	}
+	if jsonOut {
+		ids := make([]string, 0, len(removed))
+		for _, message := range removed {
+			ids = append(ids, message.ID)
+		}
+		writeJSON(map[string]any{
+			"action":  "clear",
+			"session": session,
+			"cleared": ids,
+			"pending": remaining,
+		})
+		return 0
+	}
+	fmt.Printf("cleared %d message(s), %d pending\n", len(removed), remaining)
+	return 0
+}
+
+// cmdSessionInboxDeliver runs one delivery pass; external loops (cron, queue
+// workers) use it when no monitor is watching the session.
+func cmdSessionInboxDeliver(projectRoot, session string, jsonOut bool) int {
+	restoreRuntime := withProjectRuntimeEnv(projectRoot)
+	defer restoreRuntime()
+	status, err := computeSessionStatusFn(session, projectRoot, "auto", "auto", false, 0)
+	if err != nil {
+		return commandErrorf(jsonOut, "status_compute_failed", "%v", err)
+	}
+	delivered, deliverErr := deliverSessionInbox(projectRoot, session, status)
+	inbox, _ := loadSessionInbox(projectRoot, session)
+	if jsonOut {
+		payload := map[string]any{
+			"action":       "deliver",
+			"session":      session,
+			"sessionState": status.SessionState,
+			"pending":      len(inbox.Messages),
+		}
+		if delivered != nil {
+			payload["delivered"] = delivered.ID
+		}
+		if deliverErr != nil {
+			payload["deliveryError"] = deliverErr.Error()
+			payload["errorCode"] = "inbox_delivery_failed"
+			writeJSON(payload)
+			return 1
+		}
+		writeJSON(payload)
+		return 0
+	}
+	if deliverErr != nil {
+		fmt.Fprintf(os.Stderr, "delivery failed: %v\n", deliverErr)
+		return 1
+	}
+	if delivered != nil {
+		fmt.Printf("delivered %s, %d pending\n", delivered.ID, len(inbox.Messages))
+		return 0
+	}
+	fmt.Printf("nothing delivered (state=%s), %d pending\n", status.SessionState, len(inbox.Messages))
+	return 0
+}
+
+// monitorDeliverInbox is the monitor-side delivery loop hook; a delivery means
+// the agent just received input, so the monitor should keep polling.
+func monitorDeliverInbox(projectRoot, session string, status sessionStatus, verbose bool, poll int) bool {
+	delivered, err := deliverSessionInbox(projectRoot, session, status)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "inbox warning: %s: %v\n", session, err)
+	}
+	if delivered != nil && verbose {
+		fmt.Fprintf(os.Stderr, "[%s] session=%s poll=%d delivered inbox %s (when=%s)\n", time.Now().Format("15:04:05"), session, poll, delivered.ID, delivered.When)
+	}
+	return delivered != nil
+}
@@ -47,6 +47,7 @@ func cmdSessionList(args []string) int {
 	watchInterval := 2
 	watchCycles := 0
 	cursorFile := ""
+	labelValues := []string{}
 	projectRoot := getPWD()
 	jsonOut := hasJSONFlag(args)
 	jsonMin := false
@@ -55,6 +56,12 @@ func cmdSessionList(args []string) int {
 		switch args[i] {
 		case "--help", "-h":
 			return showHelp("session list")
+		case "--label", "-l":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			labelValues = append(labelValues, args[i+1])
+			i++
 		case "--project-only":
 			projectOnly = true
 		case "--all-sockets":
@@ -119,6 +126,10 @@ func cmdSessionList(args []string) int {
 	}
 
 	projectRoot = canonicalProjectRoot(projectRoot)
+	selector, err := parseLabelSelector(labelValues)
+	if err != nil {
+		return commandError(jsonOut, "invalid_label", err.Error())
+	}
 	if watchJSON {
 		deltaJSON = true
 		jsonOut = true
@@ -149,7 +160,6 @@ func cmdSessionList(args []string) int {
 		return cmdSessionListWatch(args, watchInterval, watchCycles)
 	}
 	list := []string{}
-	var err error
 	if allSockets {
 		list, err = listSessionsAcrossSockets(projectRoot, projectOnly)
 	} else {
@@ -160,6 +170,7 @@ func cmdSessionList(args []string) int {
 	if err != nil {
 		return commandErrorf(jsonOut, "session_list_failed", "failed to list sessions: %v", err)
 	}
+	list = filterSessionsByLabels(projectRoot, list, selector)
 	items := []sessionListItem{}
 	ambiguousBySession := map[string]string{}
 	if withNextAction || activeOnly || priority {
@@ -338,6 +349,9 @@ func cmdSessionList(args []string) int {
 			payload["withNextAction"] = withNextAction
 			payload["priority"] = priority
 			payload["projectRoot"] = projectRoot
+			if len(selector) > 0 {
+				payload["labelSelector"] = selector.String()
+			}
 		}
 		if len(ambiguousWarnings) > 0 {
 			payload["warnings"] = ambiguousWarnings
@@ -675,6 +689,9 @@ func cmdSessionKill(args []string) int {
 	projectRoot := getPWD()
 	projectRootExplicit := false
 	cleanupAllHashes := false
+	recursive := false
+	grace := defaultSessionKillGrace
+	graceSet := false
 	jsonOut := hasJSONFlag(args)
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -695,6 +712,19 @@ func cmdSessionKill(args []string) int {
 			i++
 		case "--cleanup-all-hashes":
 			cleanupAllHashes = true
+		case "--recursive":
+			recursive = true
+		case "--grace":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --grace")
+			}
+			parsed, err := parseDurationFlag("--grace", args[i+1])
+			if err != nil {
+				return commandError(jsonOut, "invalid_grace", err.Error())
+			}
+			grace = parsed
+			graceSet = true
+			i++
 		case "--json":
 			jsonOut = true
 		default:
@@ -704,6 +734,9 @@ func cmdSessionKill(args []string) int {
 	if session == "" {
 		return commandError(jsonOut, "missing_required_flag", "--session is required")
 	}
+	if graceSet && !recursive {
+		return commandError(jsonOut, "grace_requires_recursive", "--grace requires --recursive")
+	}
 	resolvedRoot, resolveErr := resolveSessionProjectRootChecked(session, projectRoot, projectRootExplicit)
 	if resolveErr != nil {
 		return commandErrorf(jsonOut, "ambiguous_project_root", "%v", resolveErr)
@@ -715,58 +748,55 @@ func cmdSessionKill(args []string) int {
 		AllHashes:  cleanupAllHashes,
 		KeepEvents: true,
 	}
-	descendants, descendantsErr := listSessionDescendants(projectRoot, session, cleanupOpts.AllHashes)
-	if descendantsErr != nil {
-		fmt.Fprintf(os.Stderr, "descendant lookup warning: %v\n", descendantsErr)
+	killLevels := [][]string{}
+	if recursive {
+		levels, levelsErr := listSessionDescendantLevels(projectRoot, session, cleanupOpts.AllHashes)
+		if levelsErr != nil {
+			fmt.Fprintf(os.Stderr, "descendant lookup warning: %v\n", levelsErr)
+		}
+		for i := len(levels) - 1; i >= 0; i-- {
+			killLevels = append(killLevels, levels[i])
+		}
+		killLevels = append(killLevels, []string{session})
+	} else {
+		descendants, descendantsErr := listSessionDescendants(projectRoot, session, cleanupOpts.AllHashes)
+		if descendantsErr != nil {
+			fmt.Fprintf(os.Stderr, "descendant lookup warning: %v\n", descendantsErr)
+		}
+		targets := make([]string, 0, len(descendants)+1)
+		targets = append(targets, descendants...)
+		targets = append(targets, session)
+		killLevels = append(killLevels, targets)
 	}
 
-	targets := make([]string, 0, len(descendants)+1)
-	targets = append(targets, descendants...)
-	targets = append(targets, session)
-
 	rootFound := false
+	killed := []string{}
+	graceful := []string{}
 	var errs []string
-	for _, target := range targets {
-		isRoot := target == session
-		reasonPrefix := "kill_descendant"
-		if isRoot {
-			reasonPrefix = "kill"
+	for _, level := range killLevels {
+		if recursive {
+			graceful = append(graceful, interruptSessionsWithGrace(projectRoot, level, grace)...)
 		}
-
-		if !tmuxHasSessionFn(target) {
+		for _, target := range level {
+			isRoot := target == session
+			reasonPrefix := "kill_descendant"
 			if isRoot {
-				if !jsonOut {
+				reasonPrefix = "kill"
+			}
+			found, ok, targetErrs := killSessionTarget(projectRoot, target, reasonPrefix, cleanupOpts, nil)
+			errs = append(errs, targetErrs...)
+			if !found {
+				if isRoot && !jsonOut {
 					fmt.Fprintln(os.Stderr, "session not found")
 				}
+				continue
 			}
-			if err := cleanupSessionArtifactsWithOptions(projectRoot, target, cleanupOpts); err != nil {
-				errs = append(errs, fmt.Sprintf("%s cleanup: %v", target, err))
+			if isRoot {
+				rootFound = true
 			}
-			if err := appendLifecycleEvent(projectRoot, target, "lifecycle", "not_found", "idle", reasonPrefix+"_not_found"); err != nil {
-				errs = append(errs, fmt.Sprintf("%s observability: %v", target, err))
+			if ok {
+				killed = append(killed, target)
 			}
-			continue
-		}
-		if isRoot {
-			rootFound = true
-		}
-
-		killErr := tmuxKillSessionFn(target)
-		cleanupErr := cleanupSessionArtifactsWithOptions(projectRoot, target, cleanupOpts)
-		eventState := "terminated"
-		eventReason := reasonPrefix + "_success"
-		if killErr != nil {
-			eventState = "degraded"
-			eventReason = reasonPrefix + "_error"
-		}
-		if err := appendLifecycleEvent(projectRoot, target, "lifecycle", eventState, "idle", eventReason); err != nil {
-			errs = append(errs, fmt.Sprintf("%s observability: %v", target, err))
-		}
-		if killErr != nil {
-			errs = append(errs, fmt.Sprintf("%s kill: %v", target, killErr))
-		}
-		if cleanupErr != nil {
-			errs = append(errs, fmt.Sprintf("%s cleanup: %v", target, cleanupErr))
 		}
 	}
 
@@ -812,22 +842,70 @@ func cmdSessionKill(args []string) int {
 		return 1
 	}
 	if jsonOut {
-		writeJSON(map[string]any{
+		payload := map[string]any{
 			"session":     session,
 			"ok":          true,
 			"found":       true,
 			"projectRoot": projectRoot,
-		})
+		}
+		if recursive {
+			payload["recursive"] = true
+			payload["killed"] = killed
+			payload["graceful"] = graceful
+			payload["graceSeconds"] = grace.Seconds()
+		}
+		writeJSON(payload)
 		return 0
 	}
 	fmt.Println("ok")
 	return 0
 }
 
+// killSessionTarget kills one tmux session, records it in the history index
+// once it is gone, cleans its artifacts and records a
+// `<reasonPrefix>_success|_error|_not_found` lifecycle event. ok reports
+// whether tmux kill succeeded; errs collects kill/cleanup/event failures.
+func killSessionTarget(projectRoot, target, reasonPrefix string, opts cleanupOptions, archive *historyArchiveRef) (found, ok bool, errs []string) {
+	if !tmuxHasSessionFn(target) {
+		// Already exited on its own: record the run before cleanup drops its meta.
+		if err := recordSessionHistory(projectRoot, target, reasonPrefix, archive); err != nil {
+			fmt.Fprintf(os.Stderr, "history warning: %s: %v\n", target, err)
+		}
+		if err := cleanupSessionArtifactsWithOptions(projectRoot, target, opts); err != nil {
+			errs = append(errs, fmt.Sprintf("%s cleanup: %v", target, err))
+		}
+		if err := appendLifecycleEvent(projectRoot, target, "lifecycle", "not_found", "idle", reasonPrefix+"_not_found"); err != nil {
+			errs = append(errs, fmt.Sprintf("%s observability: %v", target, err))
+		}
+		return false, false, errs
+	}
+
+	killErr := killSessionWithHistory(projectRoot, target, reasonPrefix, archive)
+	cleanupErr := cleanupSessionArtifactsWithOptions(projectRoot, target, opts)
+	eventState := "terminated"
+	eventReason := reasonPrefix + "_success"
+	if killErr != nil {
+		eventState = "degraded"
+		eventReason = reasonPrefix + "_error"
+	}
+	if err := appendLifecycleEvent(projectRoot, target, "lifecycle", eventState, "idle", eventReason); err != nil {
+		errs = append(errs, fmt.Sprintf("%s observability: %v", target, err))
+	}
+	if killErr != nil {
+		errs = append(errs, fmt.Sprintf("%s kill: %v", target, killErr))
+	}
+	if cleanupErr != nil {
+		errs = append(errs, fmt.Sprintf("%s cleanup: %v", target, cleanupErr))
+	}
+	return true, killErr == nil, errs
+}
+
 func cmdSessionKillAll(args []string) int {
 	projectOnly := false
 	projectRoot := getPWD()
 	cleanupAllHashes := false
+	force := false
+	labelValues := []string{}
 	jsonOut := hasJSONFlag(args)
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -835,6 +913,14 @@ func cmdSessionKillAll(args []string) int {
 			return showHelp("session kill-all")
 		case "--project-only":
 			projectOnly = true
+		case "--label", "-l":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			labelValues = append(labelValues, args[i+1])
+			i++
+		case "--force":
+			force = true
 		case "--project-root":
 			if i+1 >= len(args) {
 				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --project-root")
@@ -851,6 +937,10 @@ func cmdSessionKillAll(args []string) int {
 	}
 
 	projectRoot = canonicalProjectRoot(projectRoot)
+	selector, err := parseLabelSelector(labelValues)
+	if err != nil {
+		return commandError(jsonOut, "invalid_label", err.Error())
+	}
 	restoreRuntime := withProjectRuntimeEnv(projectRoot)
 	defer restoreRuntime()
 	allHashes := cleanupAllHashes || !projectOnly
@@ -862,10 +952,20 @@ func cmdSessionKillAll(args []string) int {
 	if err != nil {
 		return commandErrorf(jsonOut, "session_list_failed", "failed to list sessions: %v", err)
 	}
+	sessions = filterSessionsByLabels(projectRoot, sessions, selector)
+	protected := []string{}
+	if !force {
+		sessions, protected = splitProtectedSessions(projectRoot, sessions)
+		if !jsonOut {
+			for _, s := range protected {
+				fmt.Fprintf(os.Stderr, "skipping protected session %s (use --force)\n", s)
+			}
+		}
+	}
 	var errs []string
 	killed := 0
 	for _, s := range sessions {
-		killErr := tmuxKillSessionFn(s)
+		killErr := killSessionWithHistory(resolveSessionProjectRoot(s, projectRoot, false), s, "kill_all", nil)
 		if killErr != nil {
 			errs = append(errs, fmt.Sprintf("%s kill: %v", s, killErr))
 		} else {
@@ -898,6 +998,7 @@ func cmdSessionKillAll(args []string) int {
 				"projectRoot": projectRoot,
 				"errors":      errs,
 				"errorCode":   "session_kill_all_failed",
+				"protected":   protected,
 			})
 		}
 		if !jsonOut {
@@ -915,6 +1016,7 @@ func cmdSessionKillAll(args []string) int {
 			"total":       len(sessions),
 			"projectOnly": projectOnly,
 			"projectRoot": projectRoot,
+			"protected":   protected,
 		})
 		return 0
 	}
@@ -0,0 +1,414 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// monitorFanInConfig drives `session monitor` over many sessions. Labels holds
+// raw -l/--label selectors; parseLabelSelector (session_labels.go) parses them
+// and they match sessionMeta.Labels.
+type monitorFanInConfig struct {
+	ProjectRoot         string
+	ProjectRootExplicit bool
+	SessionsRaw         string
+	TreeRoot            string
+	Labels              []string
+	Until               string
+	AgentHint           string
+	ModeHint            string
+	Expect              string
+	PollInterval        int
+	MaxPolls            int
+	AdaptivePoll        bool
+	Stop                monitorStopConfig
+	JSONOut             bool
+	JSONMin             bool
+	StreamJSON          bool
+	Verbose             bool
+}
+
+type monitorFanInSessionResult struct {
+	monitorResult
+	Succeeded bool `json:"succeeded"`
+}
+
+type monitorFanInPoll struct {
+	Session string
+	Status  sessionStatus
+	Reason  string
+	Until   bool
+	Err     error
+	StopErr error
+}
+
+// parseMonitorUntilQuorum parses fan-in --until (all|any|N) into the number of
+// sessions that must reach a stop reason before monitor exits.
+func parseMonitorUntilQuorum(raw string, total int) (int, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	switch value {
+	case "", "all":
+		return total, nil
+	case "any":
+		if total == 0 {
+			return 0, nil
+		}
+		return 1, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid --until: %s (expected all|any|N)", raw)
+	}
+	if n > total {
+		return 0, fmt.Errorf("invalid --until: %d exceeds matched session count %d", n, total)
+	}
+	return n, nil
+}
+
+func resolveMonitorFanInTargets(cfg monitorFanInConfig) ([]string, string, error) {
+	projectRoot := canonicalProjectRoot(cfg.ProjectRoot)
+	targets := parseCommaValues(cfg.SessionsRaw)
+	if cfg.TreeRoot != "" {
+		descendants, err := listSessionDescendants(projectRoot, cfg.TreeRoot, false)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to resolve --tree: %w", err)
+		}
+		targets = append(targets, cfg.TreeRoot)
+		targets = append(targets, descendants...)
+	}
+	if len(cfg.Labels) > 0 {
+		selector, err := parseLabelSelector(cfg.Labels)
+		if err != nil {
+			return nil, "", err
+		}
+		metas, err := loadSessionMetasForProject(projectRoot, false)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to resolve --label: %w", err)
+		}
+		for _, meta := range metas {
+			if selector.matches(meta.Labels) {
+				targets = append(targets, strings.TrimSpace(meta.Session))
+			}
+		}
+	}
+	targets = dedupeStrings(targets)
+	sort.Strings(targets)
+
+	// tmux runtime env is process-wide, so a fan-in run is pinned to one root.
+	resolvedRoot := ""
+	for _, target := range targets {
+		root, err := resolveSessionProjectRootChecked(target, projectRoot, cfg.ProjectRootExplicit)
+		if err != nil {
+			return nil, "", err
+		}
+		if resolvedRoot == "" {
+			resolvedRoot = root
+			continue
+		}
+		if root != resolvedRoot {
+			return nil, "", fmt.Errorf("sessions span multiple project roots (%s, %s); pass --project-root", resolvedRoot, root)
+		}
+	}
+	if resolvedRoot == "" {
+		resolvedRoot = projectRoot
+	}
+	return targets, resolvedRoot, nil
+}
+
+func cmdSessionMonitorFanIn(cfg monitorFanInConfig) int {
+	jsonOut := cfg.JSONOut
+	targets, projectRoot, err := resolveMonitorFanInTargets(cfg)
+	if err != nil {
+		return commandErrorf(jsonOut, "fan_in_target_resolve_failed", "%v", err)
+	}
+	if len(targets) == 0 {
+		return commandError(jsonOut, "fan_in_no_sessions", "no sessions matched --sessions/--tree/--label")
+	}
+	required, err := parseMonitorUntilQuorum(cfg.Until, len(targets))
+	if err != nil {
+		return commandError(jsonOut, "invalid_until", err.Error())
+	}
+	restoreRuntime := withProjectRuntimeEnv(projectRoot)
+	defer restoreRuntime()
+
+	results := make(map[string]*monitorFanInSessionResult, len(targets))
+	lastStatus := make(map[string]sessionStatus, len(targets))
+	surfacedMessages := map[int]bool{}
+	promptDecisionsLogged := make(map[string]map[string]bool, len(targets))
+	limitMetas := make(map[string]sessionMeta, len(targets))
+	limitTrackers := make(map[string]*sessionLimitTracker, len(targets))
+	for _, target := range targets {
+		promptDecisionsLogged[target] = map[string]bool{}
+		limitMetas[target], _ = loadSessionMeta(projectRoot, target)
+		limitTrackers[target] = &sessionLimitTracker{}
+	}
+	// Limit kills finish in the background; wait so none is cut off on exit.
+	var limitKills sync.WaitGroup
+	defer limitKills.Wait()
+	polls := 0
+	exitReason := "max_polls_exceeded"
+	for poll := 1; poll <= cfg.MaxPolls; poll++ {
+		polls = poll
+		pending := make([]string, 0, len(targets))
+		for _, target := range targets {
+			if _, done := results[target]; !done {
+				pending = append(pending, target)
+			}
+		}
+
+		// Warm the process cache once so concurrent classifiers share one scan.
+		_, _ = listProcessesCachedFn()
+		round := make([]monitorFanInPoll, len(pending))
+		var wg sync.WaitGroup
+		for i, target := range pending {
+			wg.Add(1)
+			go func(i int, target string) {
+				defer wg.Done()
+				entry := monitorFanInPoll{Session: target}
+				entry.Status, entry.Err = computeSessionStatusFn(target, projectRoot, cfg.AgentHint, cfg.ModeHint, true, poll)
+				if entry.Err == nil {
+					entry.Reason, entry.Until, entry.StopErr = monitorStopReason(target, projectRoot, &entry.Status, cfg.Stop)
+				}
+				round[i] = entry
+			}(i, target)
+		}
+		wg.Wait()
+
+		for _, entry := range round {
+			if entry.Err != nil {
+				return commandErrorf(jsonOut, "status_compute_failed", "%s: %v", entry.Session, entry.Err)
+			}
+			if entry.StopErr != nil {
+				return commandErrorf(jsonOut, "invalid_until_jsonpath", "invalid --until-jsonpath: %v", entry.StopErr)
+			}
+			status := entry.Status
+			if cfg.Verbose {
+				fmt.Fprintf(os.Stderr, "[%s] session=%s poll=%d state=%s status=%s active=%q\n",
+					time.Now().Format("15:04:05"), entry.Session, poll, status.SessionState,
+					normalizeMonitorFinalStatus(status.SessionState, status.Status), status.ActiveTask)
+			}
+			if cfg.StreamJSON {
+				writeMonitorStreamPoll(status, poll, cfg.JSONMin)
+			}
+			unreadMessages := monitorSurfaceMessages(projectRoot, entry.Session, poll, surfacedMessages, cfg.StreamJSON, cfg.Verbose)
+			promptAnswered, promptErr := monitorAutoAnswerPrompt(projectRoot, entry.Session, status, promptDecisionsLogged[entry.Session])
+			if promptErr != nil {
+				fmt.Fprintf(os.Stderr, "prompt policy warning: %v\n", promptErr)
+			}
+			if promptAnswered && cfg.Verbose {
+				fmt.Fprintf(os.Stderr, "[%s] session=%s poll=%d auto-answered %s prompt\n", time.Now().Format("15:04:05"), entry.Session, poll, status.PendingPrompt.Kind)
+			}
+			if promptAnswered || monitorDeliverInbox(projectRoot, entry.Session, status, cfg.Verbose, poll) {
+				// The agent is moving again; keep polling instead of stopping on waiting.
+				entry.Reason = ""
+			}
+			if limitReason := monitorStartSessionLimit(projectRoot, entry.Session, limitMetas[entry.Session], &status, limitTrackers[entry.Session], &limitKills); limitReason != "" {
+				entry.Reason = limitReason
+				entry.Until = false
+			}
+			lastStatus[entry.Session] = status
+			if entry.Reason == "" {
+				continue
+			}
+			expectationMet := monitorExpectationSatisfied(cfg.Expect, entry.Reason)
+			finalReason := entry.Reason
+			if !expectationMet {
+				finalReason = monitorExpectationMismatchReason(cfg.Expect, entry.Reason)
+			}
+			result := &monitorFanInSessionResult{
+				monitorResult: monitorResult{
+					FinalState:  status.SessionState,
+					Session:     entry.Session,
+					TodosDone:   status.TodosDone,
+					TodosTotal:  status.TodosTotal,
+					OutputFile:  status.OutputFile,
+					NextOffset:  computeSessionCaptureNextOffset(entry.Session),
+					ExitReason:  finalReason,
+					Polls:       poll,
+					FinalStatus: normalizeMonitorFinalStatus(status.SessionState, status.Status),
+
+					UnreadMessages: unreadMessages,
+					Result:         status.Result,
+				},
+				Succeeded: expectationMet && monitorReasonSucceeded(entry.Reason, entry.Until),
+			}
+			results[entry.Session] = result
+			if cfg.StreamJSON {
+				writeMonitorFanInStreamFinal(*result, cfg.JSONMin)
+			}
+			if err := appendLifecycleEvent(projectRoot, entry.Session, "lifecycle", result.FinalState, result.FinalStatus, "monitor_"+finalReason); err != nil {
+				fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+			}
+		}
+
+		if len(results) >= required {
+			exitReason = "quorum_reached"
+			if len(results) == len(targets) {
+				exitReason = "all_finished"
+			}
+			break
+		}
+		if poll < cfg.MaxPolls {
+			sleepDuration := time.Duration(cfg.PollInterval) * time.Second
+			if cfg.AdaptivePoll {
+				sleepDuration = monitorFanInSleepDuration(lastStatus, results, cfg.PollInterval)
+			}
+			monitorSleepFn(sleepDuration)
+		}
+	}
+
+	ordered := make([]monitorFanInSessionResult, 0, len(targets))
+	finished := 0
+	succeeded := 0
+	for _, target := range targets {
+		if result, ok := results[target]; ok {
+			finished++
+			if result.Succeeded {
+				succeeded++
+			}
+			ordered = append(ordered, *result)
+			continue
+		}
+		last := lastStatus[target]
+		timeoutResult := monitorFanInSessionResult{
+			monitorResult: monitorResult{
+				FinalState:  "timeout",
+				Session:     target,
+				TodosDone:   last.TodosDone,
+				TodosTotal:  last.TodosTotal,
+				OutputFile:  last.OutputFile,
+				NextOffset:  computeSessionCaptureNextOffset(target),
+				ExitReason:  "max_polls_exceeded",
+				Polls:       polls,
+				FinalStatus: "timeout",
+			},
+		}
+		if exitReason == "max_polls_exceeded" {
+			if err := appendLifecycleEvent(projectRoot, target, "lifecycle", "timeout", "timeout", "monitor_max_polls_exceeded"); err != nil {
+				fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+			}
+		} else {
+			timeoutResult.FinalState = normalizeMonitorPendingState(last.SessionState)
+			timeoutResult.FinalStatus = normalizeMonitorFinalStatus(last.SessionState, last.Status)
+			timeoutResult.ExitReason = "pending_" + exitReason
+		}
+		ordered = append(ordered, timeoutResult)
+	}
+
+	// Same contract as single-session monitor: any counted failure or a
+	// timeout exits 2.
+	ok := exitReason != "max_polls_exceeded" && succeeded == finished
+	errorCode := ""
+	switch {
+	case exitReason == "max_polls_exceeded":
+		errorCode = "monitor_timeout"
+	case !ok:
+		errorCode = "monitor_fan_in_failed"
+	}
+
+	if jsonOut {
+		writeMonitorFanInJSON(ordered, cfg, required, finished, succeeded, exitReason, polls, projectRoot, errorCode)
+	} else {
+		for _, result := range ordered {
+			if err := writeCSVRecord(
+				result.Session,
+				result.FinalState,
+				result.ExitReason,
+				strconv.Itoa(result.Polls),
+				result.FinalStatus,
+			); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to write monitor output: %v\n", err)
+				return 1
+			}
+		}
+	}
+	if ok {
+		return 0
+	}
+	return 2
+}
+
+func normalizeMonitorPendingState(state string) string {
+	if strings.TrimSpace(state) == "" {
+		return "unknown"
+	}
+	return state
+}
+
+func monitorFanInSleepDuration(lastStatus map[string]sessionStatus, results map[string]*monitorFanInSessionResult, pollInterval int) time.Duration {
+	shortest := time.Duration(0)
+	for session, status := range lastStatus {
+		if _, done := results[session]; done {
+			continue
+		}
+		candidate := monitorAdaptiveSleepDuration(status, pollInterval)
+		if shortest == 0 || candidate < shortest {
+			shortest = candidate
+		}
+	}
+	if shortest == 0 {
+		return time.Duration(pollInterval) * time.Second
+	}
+	return shortest
+}
+
+func writeMonitorFanInStreamFinal(result monitorFanInSessionResult, jsonMin bool) {
+	payload := map[string]any{
+		"type":       "session_final",
+		"session":    result.Session,
+		"finalState": result.FinalState,
+		"exitReason": result.ExitReason,
+		"polls":      result.Polls,
+		"succeeded":  result.Succeeded,
+	}
+	if !jsonMin {
+		payload["finalStatus"] = result.FinalStatus
+		if result.NextOffset > 0 {
+			payload["nextOffset"] = result.NextOffset
+		}
+	}
+	writeJSON(payload)
+}
+
+func writeMonitorFanInJSON(results []monitorFanInSessionResult, cfg monitorFanInConfig, required, finished, succeeded int, exitReason string, polls int, projectRoot, errorCode string) {
+	until := strings.ToLower(strings.TrimSpace(cfg.Until))
+	if until == "" {
+		until = "all"
+	}
+	var sessions any = results
+	if cfg.JSONMin {
+		minRows := make([]monitorResultMin, 0, len(results))
+		for _, result := range results {
+			minRows = append(minRows, monitorResultMin{
+				Session:    result.Session,
+				FinalState: result.FinalState,
+				ExitReason: result.ExitReason,
+				Polls:      result.Polls,
+				NextOffset: result.NextOffset,
+			})
+		}
+		sessions = minRows
+	}
+	payload := map[string]any{
+		"sessions":   sessions,
+		"until":      until,
+		"required":   required,
+		"total":      len(results),
+		"finished":   finished,
+		"succeeded":  succeeded,
+		"exitReason": exitReason,
+		"polls":      polls,
+	}
+	if !cfg.JSONMin {
+		payload["projectRoot"] = projectRoot
+	}
+	if errorCode != "" {
+		payload["errorCode"] = errorCode
+	}
+	writeJSON(payload)
+}
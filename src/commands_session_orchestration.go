@@ -1,10 +1,7 @@
 package app
 
 import (
-	"bytes"
-	"compress/gzip"
 	"crypto/sha1"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -55,6 +52,7 @@ type sessionAutopilotSummary struct {
 	Capture     sessionAutopilotStep `json:"capture"`
 	Handoff     sessionAutopilotStep `json:"handoff"`
 	Cleanup     sessionAutopilotStep `json:"cleanup,omitempty"`
+	Result      *sessionResult       `json:"result,omitempty"`
 	ErrorCode   string               `json:"errorCode,omitempty"`
 	Error       string               `json:"error,omitempty"`
 	FailedStep  string               `json:"failedStep,omitempty"`
@@ -294,10 +292,19 @@ func cmdSessionHandoff(args []string) int {
 	nextOffset := computeSessionCaptureNextOffset(session)
 	nextAction := nextActionForState(status.SessionState)
 	summary := fmt.Sprintf("state=%s reason=%s next=%s", status.SessionState, status.ClassificationReason, nextAction)
+	if status.Result != nil {
+		// A declared result is the agent's own account; prefer it to scraped state.
+		nextAction = nextActionForResult(status.Result.Status)
+		summary = fmt.Sprintf("result=%s next=%s", status.Result.Status, nextAction)
+		if strings.TrimSpace(status.Result.Summary) != "" {
+			summary += ": " + strings.TrimSpace(status.Result.Summary)
+		}
+	}
 	objective := objectivePayloadFromMeta(meta)
 	memoryPayload, hasMemory := loadSessionMemoryCompact(projectRoot, session, 8)
 	risks := deriveHandoffRisks(status, items)
 	openQuestions := deriveHandoffQuestions(status, items)
+	messages := unreadMessagesFromSession(projectRoot, session)
 	lanePayload := map[string]any(nil)
 	if laneName != "" {
 		lanePayload = map[string]any{"name": laneName}
@@ -339,6 +346,12 @@ func cmdSessionHandoff(args []string) int {
 		if hasMemory {
 			payload["memory"] = memoryPayload
 		}
+		if len(messages) > 0 {
+			payload["messages"] = messages
+		}
+		if status.Result != nil {
+			payload["result"] = status.Result
+		}
 		if !jsonMin {
 			payload["projectRoot"] = projectRoot
 			payload["recent"] = items
@@ -406,20 +419,35 @@ func cmdSessionHandoff(args []string) int {
 			if recent, ok := payload["recent"]; ok {
 				compressInput["recent"] = recent
 			}
+			if len(messages) > 0 {
+				compressInput["messages"] = messages
+			}
+			if status.Result != nil {
+				compressInput["result"] = status.Result
+			}
 			if deltaFrom >= 0 {
 				compressInput["deltaFrom"] = payload["deltaFrom"]
 				compressInput["nextDeltaOffset"] = payload["nextDeltaOffset"]
 				compressInput["deltaCount"] = payload["deltaCount"]
 			}
-			encoded, uncompressed, compressed, compressErr := compressJSONPayloadStdlib(compressInput)
+			// The packed body is opaque to writeJSON's redaction pass, so
+			// redact it before compressing.
+			redactedInput, redactions, redactErr := redactPayloadForEncoding(compressInput)
+			if redactErr != nil {
+				return commandErrorf(jsonOut, "handoff_compress_failed", "failed to compress handoff payload: %v", redactErr)
+			}
+			encoded, uncompressed, compressed, compressErr := compressJSONPayload(redactedInput, compressMode)
 			if compressErr != nil {
 				return commandErrorf(jsonOut, "handoff_compress_failed", "failed to compress handoff payload: %v", compressErr)
 			}
 			payload["compression"] = compressMode
-			payload["encoding"] = "base64-gzip"
+			payload["encoding"] = payloadEncodingForMode(compressMode)
 			payload["compressedPayload"] = encoded
 			payload["uncompressedBytes"] = uncompressed
 			payload["compressedBytes"] = compressed
+			if redactions > 0 {
+				payload["redactions"] = redactions
+			}
 			// Prefer compressed transfer packet when explicitly requested.
 			delete(payload, "recent")
 		}
@@ -430,13 +458,19 @@ func cmdSessionHandoff(args []string) int {
 		return 0
 	}
 
-	fmt.Println(summary)
+	fmt.Println(redactOutputText(summary))
 	if len(items) > 0 {
 		fmt.Println("recent:")
 		for _, item := range items {
 			fmt.Printf("- %s %s %s %s\n", item.At, item.Type, item.State, item.Reason)
 		}
 	}
+	if len(messages) > 0 {
+		fmt.Println("messages:")
+		for _, message := range messages {
+			fmt.Println(redactOutputText(fmt.Sprintf("- #%d %s -> %s: %s", message.Seq, message.Kind, message.To, message.Body)))
+		}
+	}
 	if deltaFrom >= 0 {
 		fmt.Printf("delta_from=%d next_delta_offset=%d\n", deltaFrom, nextDeltaOffset)
 	}
@@ -450,11 +484,10 @@ func parseHandoffCompressMode(raw string) (string, error) {
 	switch strings.ToLower(strings.TrimSpace(raw)) {
 	case "", "none":
 		return "none", nil
-	case "zstd":
-		// stdlib-only build: expose zstd contract but use gzip transport backend.
-		return "zstd", nil
+	case "zstd", "gzip":
+		return strings.ToLower(strings.TrimSpace(raw)), nil
 	default:
-		return "", fmt.Errorf("invalid --compress: %s (expected none|zstd)", raw)
+		return "", fmt.Errorf("invalid --compress: %s (expected none|zstd|gzip)", raw)
 	}
 }
 
@@ -467,24 +500,6 @@ func laneContractRequiresHandoffSchemaV2(contract string) bool {
 		strings.Contains(normalized, "handoff_schema_v2_required")
 }
 
-func compressJSONPayloadStdlib(payload map[string]any) (string, int, int, error) {
-	raw, err := json.Marshal(payload)
-	if err != nil {
-		return "", 0, 0, err
-	}
-	var buf bytes.Buffer
-	zw := gzip.NewWriter(&buf)
-	if _, err := zw.Write(raw); err != nil {
-		_ = zw.Close()
-		return "", 0, 0, err
-	}
-	if err := zw.Close(); err != nil {
-		return "", 0, 0, err
-	}
-	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
-	return encoded, len(raw), buf.Len(), nil
-}
-
 func cmdSessionContextPack(args []string) int {
 	session := ""
 	projectRoot := getPWD()
@@ -1186,7 +1201,16 @@ func cmdSessionGuard(args []string) int {
 		return commandErrorf(jsonOut, "invalid_machine_policy", "invalid --machine-policy: %s (expected strict|warn|off)", machinePolicy)
 	}
 	var policy *sessionGuardPolicy
-	if policyFile != "" {
+	if policyFile == "" {
+		loaded, path, loadErr := loadProjectGuardPolicy(projectRoot)
+		if loadErr != nil {
+			return commandErrorf(jsonOut, "policy_file_read_failed", "failed reading guard policy %s: %v", path, loadErr)
+		}
+		if loaded != nil {
+			policy = loaded
+			policyFile = path
+		}
+	} else {
 		resolvedPolicy, resolveErr := expandAndCleanPath(policyFile)
 		if resolveErr != nil {
 			return commandErrorf(jsonOut, "invalid_policy_file", "invalid --policy-file: %v", resolveErr)
@@ -1197,13 +1221,13 @@ func cmdSessionGuard(args []string) int {
 			return commandErrorf(jsonOut, "policy_file_read_failed", "failed reading --policy-file: %v", loadErr)
 		}
 		policy = loaded
-		if strings.TrimSpace(policy.MachinePolicy) != "" {
-			machinePolicy = strings.ToLower(strings.TrimSpace(policy.MachinePolicy))
-			switch machinePolicy {
-			case "strict", "warn", "off":
-			default:
-				return commandErrorf(jsonOut, "invalid_policy_machine_policy", "invalid policy machinePolicy: %s (expected strict|warn|off)", policy.MachinePolicy)
-			}
+	}
+	if policy != nil && strings.TrimSpace(policy.MachinePolicy) != "" {
+		machinePolicy = strings.ToLower(strings.TrimSpace(policy.MachinePolicy))
+		switch machinePolicy {
+		case "strict", "warn", "off":
+		default:
+			return commandErrorf(jsonOut, "invalid_policy_machine_policy", "invalid policy machinePolicy: %s (expected strict|warn|off)", policy.MachinePolicy)
 		}
 	}
 
@@ -1254,7 +1278,7 @@ func cmdSessionGuard(args []string) int {
 			riskReasons = append(riskReasons, "kill_without_project_root")
 		}
 		if policy != nil {
-			policyWarnings, policyReasons, policyRisk := evaluateGuardPolicy(*policy, lowerCommand)
+			policyWarnings, policyReasons, policyRisk := evaluateGuardPolicy(*policy, commandText)
 			if len(policyWarnings) > 0 {
 				warnings = append(warnings, policyWarnings...)
 			}
@@ -1336,12 +1360,13 @@ func cmdSessionGuard(args []string) int {
 }
 
 type sessionGuardPolicy struct {
-	MachinePolicy                  string   `json:"machinePolicy"`
-	AllowedCommands                []string `json:"allowedCommands"`
-	DeniedCommands                 []string `json:"deniedCommands"`
-	RequireProjectRoot             bool     `json:"requireProjectRoot"`
-	RequireProjectOnlyForKillAll   bool     `json:"requireProjectOnlyForKillAll"`
-	AllowCleanupIncludeTmuxDefault bool     `json:"allowCleanupIncludeTmuxDefault"`
+	MachinePolicy                  string      `json:"machinePolicy"`
+	AllowedCommands                []string    `json:"allowedCommands"`
+	DeniedCommands                 []string    `json:"deniedCommands"`
+	RequireProjectRoot             bool        `json:"requireProjectRoot"`
+	RequireProjectOnlyForKillAll   bool        `json:"requireProjectOnlyForKillAll"`
+	AllowCleanupIncludeTmuxDefault bool        `json:"allowCleanupIncludeTmuxDefault"`
+	Rules                          []guardRule `json:"rules,omitempty"`
 }
 
 func loadSessionGuardPolicy(path string) (*sessionGuardPolicy, error) {
@@ -1353,6 +1378,9 @@ func loadSessionGuardPolicy(path string) (*sessionGuardPolicy, error) {
 	if err := json.Unmarshal(raw, &policy); err != nil {
 		return nil, err
 	}
+	if err := compileGuardRules(policy.Rules); err != nil {
+		return nil, err
+	}
 	return &policy, nil
 }
 
@@ -1410,6 +1438,12 @@ func evaluateGuardPolicy(policy sessionGuardPolicy, commandText string) (warning
 		reasons = append(reasons, "policy_disallow_cleanup_include_tmux_default")
 		risk = mergeGuardRisk(risk, "high")
 	}
+	if len(policy.Rules) > 0 {
+		ruleWarnings, ruleReasons, ruleRisk := evaluateGuardRulesForText(policy.Rules, commandText)
+		warnings = append(warnings, ruleWarnings...)
+		reasons = append(reasons, ruleReasons...)
+		risk = mergeGuardRisk(risk, ruleRisk)
+	}
 	return warnings, reasons, risk
 }
 
@@ -1914,8 +1948,16 @@ func cmdSessionAutopilot(args []string) int {
 		}
 	}
 
+	summaryPayload.Result = loadCurrentSessionResult(projectRoot, spawnedSession, autopilotRunID(summaryPayload.Spawn))
+
 	finalCode := 0
-	if monitorCode != 0 {
+	if summaryPayload.Result != nil && summaryPayload.Result.Status != "success" {
+		// The agent said it failed; that outranks whatever the monitor scraped.
+		summaryPayload.ErrorCode = "autopilot_result_" + summaryPayload.Result.Status
+		summaryPayload.Error = summaryPayload.Result.Summary
+		summaryPayload.FailedStep = "monitor"
+		finalCode = 2
+	} else if monitorCode != 0 {
 		summaryPayload.ErrorCode = "autopilot_monitor_failed"
 		summaryPayload.Error = monitorErr
 		summaryPayload.FailedStep = "monitor"
@@ -2067,27 +2109,28 @@ func readSessionHandoffDelta(projectRoot, session string, offset, limit int) ([]
 	lockTimeout := getIntEnv("LISA_EVENT_LOCK_TIMEOUT_MS", defaultEventLockTimeoutMS)
 	lockPath := eventsPath + ".lock"
 
-	all := make([]sessionHandoffItem, 0)
+	// Offsets are absolute line offsets across rotated segments; lines
+	// already pruned by retention are skipped.
+	delta := make([]sessionHandoffItem, 0)
+	total := 0
 	err := withSharedFileLock(lockPath, lockTimeout, func() error {
-		raw, readErr := os.ReadFile(eventsPath)
+		lines, base, readErr := readSessionEventLogLines(eventsPath)
 		if readErr != nil {
 			if os.IsNotExist(readErr) {
 				return nil
 			}
 			return readErr
 		}
-		lines := trimLines(string(raw))
-		all = make([]sessionHandoffItem, 0, len(lines))
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" {
+		total = base + len(lines)
+		for i, line := range lines {
+			if base+i < offset {
 				continue
 			}
 			var event sessionEvent
 			if err := json.Unmarshal([]byte(line), &event); err != nil {
 				continue
 			}
-			all = append(all, sessionHandoffItem{
+			delta = append(delta, sessionHandoffItem{
 				At:     event.At,
 				Type:   event.Type,
 				State:  event.State,
@@ -2100,14 +2143,6 @@ func readSessionHandoffDelta(projectRoot, session string, offset, limit int) ([]
 	if err != nil {
 		return nil, 0, 0, err
 	}
-	total := len(all)
-	if offset < 0 {
-		offset = 0
-	}
-	if offset > total {
-		offset = total
-	}
-	delta := append([]sessionHandoffItem{}, all[offset:]...)
 	dropped := 0
 	if limit > 0 && len(delta) > limit {
 		dropped = len(delta) - limit
@@ -2179,6 +2214,17 @@ func buildRouteRunbook(projectRoot, agent, mode, nestedPolicy, nestingIntent, pr
 	}
 }
 
+func nextActionForResult(resultStatus string) string {
+	switch resultStatus {
+	case "success":
+		return "session capture"
+	case "blocked":
+		return "session send"
+	default:
+		return "session explain"
+	}
+}
+
 func nextActionForState(state string) string {
 	switch strings.TrimSpace(state) {
 	case "waiting_input":
@@ -2215,10 +2261,10 @@ func parseMonitorUntilState(raw string) (string, error) {
 	switch value {
 	case "":
 		return "", nil
-	case "waiting_input", "completed", "crashed", "stuck", "not_found", "in_progress", "degraded":
+	case "waiting_input", "completed", "crashed", "stuck", "not_found", "in_progress", "degraded", "limit_exceeded":
 		return value, nil
 	default:
-		return "", fmt.Errorf("invalid --until-state: %s (expected waiting_input|completed|crashed|stuck|not_found|in_progress|degraded)", raw)
+		return "", fmt.Errorf("invalid --until-state: %s (expected waiting_input|completed|crashed|stuck|not_found|in_progress|degraded|limit_exceeded)", raw)
 	}
 }
 
@@ -2316,6 +2362,14 @@ func commandExitCode(err error) int {
 	return 1
 }
 
+func autopilotRunID(spawn sessionAutopilotStep) string {
+	if spawn.Output == nil {
+		return ""
+	}
+	runID, _ := spawn.Output["runId"].(string)
+	return strings.TrimSpace(runID)
+}
+
 func autopilotFirstFailedStep(summary sessionAutopilotSummary) string {
 	step := strings.TrimSpace(summary.FailedStep)
 	switch step {
@@ -2390,6 +2444,9 @@ func loadHandoffInputPayload(from string) (*handoffInputPayload, error) {
 	if err != nil {
 		return nil, err
 	}
+	if raw, err = expandCompressedPayloadJSON(raw); err != nil {
+		return nil, err
+	}
 	payload := handoffInputPayload{}
 	if err := json.Unmarshal(raw, &payload); err == nil {
 		payload.Session = strings.TrimSpace(payload.Session)
@@ -36,6 +36,7 @@ func cmdSessionPacket(args []string) int {
 	cursorFile := ""
 	fieldsRaw := ""
 	deltaJSON := false
+	labelValues := []string{}
 	jsonOut := hasJSONFlag(args)
 	jsonMin := false
 
@@ -43,6 +44,12 @@ func cmdSessionPacket(args []string) int {
 		switch args[i] {
 		case "--help", "-h":
 			return showHelp("session packet")
+		case "--label", "-l":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			labelValues = append(labelValues, args[i+1])
+			i++
 		case "--session":
 			if i+1 >= len(args) {
 				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --session")
@@ -129,8 +136,17 @@ func cmdSessionPacket(args []string) int {
 		}
 	}
 
+	if len(labelValues) > 0 {
+		if session != "" {
+			return commandError(jsonOut, "label_session_conflict", "--label cannot be combined with --session")
+		}
+		if cursorFile != "" || deltaJSON {
+			return commandError(jsonOut, "label_cursor_conflict", "--label cannot be combined with --cursor-file or --delta-json")
+		}
+		return cmdSessionPacketByLabels(args, projectRoot, labelValues, jsonOut)
+	}
 	if session == "" {
-		return commandError(jsonOut, "missing_required_flag", "--session is required")
+		return commandError(jsonOut, "missing_required_flag", "--session or --label is required")
 	}
 	if deltaJSON && cursorFile == "" {
 		return commandError(jsonOut, "cursor_file_required_for_delta_json", "--delta-json requires --cursor-file")
@@ -300,10 +316,17 @@ func cmdSessionPacket(args []string) int {
 			payload["capture"] = map[string]any{
 				"lines": lines,
 			}
-			payload["handoff"] = map[string]any{
+			handoffPayload := map[string]any{
 				"events":        items,
 				"droppedRecent": droppedRecent,
 			}
+			if messages := unreadMessagesFromSession(projectRoot, session); len(messages) > 0 {
+				handoffPayload["messages"] = messages
+			}
+			if status.Result != nil {
+				handoffPayload["result"] = status.Result
+			}
+			payload["handoff"] = handoffPayload
 		} else {
 			payload["recent"] = items
 		}
@@ -329,7 +352,7 @@ func cmdSessionPacket(args []string) int {
 	}
 
 	fmt.Printf("state=%s status=%s reason=%s next=%s\n", status.SessionState, status.Status, status.ClassificationReason, nextAction)
-	fmt.Println(summaryText)
+	fmt.Println(redactOutputText(summaryText))
 	return boolExit(status.SessionState != "not_found")
 }
 
@@ -458,3 +481,38 @@ func sessionPacketDeltaValueSignature(value any) string {
 	}
 	return string(data)
 }
+
+// cmdSessionPacketByLabels builds one packet per session matching the
+// selector; JSON output is one packet per line.
+func cmdSessionPacketByLabels(args []string, projectRoot string, labelValues []string, jsonOut bool) int {
+	selector, err := parseLabelSelector(labelValues)
+	if err != nil {
+		return commandError(jsonOut, "invalid_label", err.Error())
+	}
+	projectRoot = canonicalProjectRoot(projectRoot)
+	sessions, err := tmuxListSessionsFn(false, projectRoot)
+	if err != nil {
+		return commandErrorf(jsonOut, "session_list_failed", "failed to list sessions: %v", err)
+	}
+	sessions = filterSessionsByLabels(projectRoot, sessions, selector)
+	if len(sessions) == 0 {
+		return commandErrorf(jsonOut, "no_sessions", "no sessions match --label %s", selector.String())
+	}
+	sort.Strings(sessions)
+
+	base := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--label" || args[i] == "-l" {
+			i++
+			continue
+		}
+		base = append(base, args[i])
+	}
+	exitCode := 0
+	for _, session := range sessions {
+		if code := cmdSessionPacket(append(append([]string{}, base...), "--session", session)); code > exitCode {
+			exitCode = code
+		}
+	}
+	return exitCode
+}
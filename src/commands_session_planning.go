@@ -191,6 +191,7 @@ func cmdSessionAggregate(args []string) int {
 	dedupe := false
 	deltaJSON := false
 	cursorFile := ""
+	labelValues := []string{}
 	jsonOut := hasJSONFlag(args)
 	jsonMin := false
 
@@ -198,6 +199,12 @@ func cmdSessionAggregate(args []string) int {
 		switch args[i] {
 		case "--help", "-h":
 			return showHelp("session aggregate")
+		case "--label", "-l":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			labelValues = append(labelValues, args[i+1])
+			i++
 		case "--sessions":
 			if i+1 >= len(args) {
 				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --sessions")
@@ -271,6 +278,10 @@ func cmdSessionAggregate(args []string) int {
 	if err != nil {
 		return commandError(jsonOut, "invalid_strategy", err.Error())
 	}
+	selector, err := parseLabelSelector(labelValues)
+	if err != nil {
+		return commandError(jsonOut, "invalid_label", err.Error())
+	}
 	projectRoot = canonicalProjectRoot(projectRoot)
 	if cursorFile != "" && !deltaJSON {
 		return commandError(jsonOut, "cursor_file_requires_delta_json", "--cursor-file requires --delta-json")
@@ -295,6 +306,7 @@ func cmdSessionAggregate(args []string) int {
 		}
 		sessions = list
 	}
+	sessions = filterSessionsByLabels(projectRoot, sessions, selector)
 	if len(sessions) == 0 {
 		return commandError(jsonOut, "no_sessions", "no sessions available for aggregation")
 	}
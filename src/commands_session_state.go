@@ -75,13 +75,13 @@ type sessionStatusMin struct {
 func normalizeStatusForSessionStatusOutput(status sessionStatus) sessionStatus {
 	normalized := status
 	switch normalized.SessionState {
-	case "completed", "crashed", "stuck", "not_found":
+	case "completed", "crashed", "stuck", "not_found", "limit_exceeded":
 		normalized.Status = normalized.SessionState
 	}
 	return normalized
 }
 
-func writeSessionStatusJSON(status sessionStatus, errorCode string, jsonMin bool) {
+func writeSessionStatusJSON(status sessionStatus, errorCode, recordDir string, jsonMin bool) {
 	if jsonMin {
 		minPayload := sessionStatusMin{
 			Session:      status.Session,
@@ -99,6 +99,15 @@ func writeSessionStatusJSON(status sessionStatus, errorCode string, jsonMin bool
 			"todosTotal":   minPayload.TodosTotal,
 			"waitEstimate": minPayload.WaitEstimate,
 		}
+		if status.PendingPrompt != nil {
+			payload["pendingPrompt"] = status.PendingPrompt
+		}
+		if status.Result != nil {
+			payload["result"] = status.Result
+		}
+		if recordDir != "" {
+			payload["recordDir"] = recordDir
+		}
 		if errorCode != "" {
 			payload["errorCode"] = errorCode
 		}
@@ -130,6 +139,18 @@ func writeSessionStatusJSON(status sessionStatus, errorCode string, jsonMin bool
 	if status.OutputFile != "" {
 		payload["outputFile"] = status.OutputFile
 	}
+	if status.PendingPrompt != nil {
+		payload["pendingPrompt"] = status.PendingPrompt
+	}
+	if status.Progress != nil {
+		payload["progress"] = status.Progress
+	}
+	if status.Result != nil {
+		payload["result"] = status.Result
+	}
+	if recordDir != "" {
+		payload["recordDir"] = recordDir
+	}
 	if errorCode != "" {
 		payload["errorCode"] = errorCode
 	}
@@ -154,6 +175,15 @@ func writeMonitorJSON(result monitorResult, jsonMin bool, errorCode string) {
 		if minPayload.NextOffset > 0 {
 			payload["nextOffset"] = minPayload.NextOffset
 		}
+		if result.PendingPrompt != nil {
+			payload["pendingPrompt"] = result.PendingPrompt
+		}
+		if result.UnreadMessages > 0 {
+			payload["unreadMessages"] = result.UnreadMessages
+		}
+		if result.Result != nil {
+			payload["result"] = result.Result
+		}
 		if errorCode != "" {
 			payload["errorCode"] = errorCode
 		}
@@ -177,6 +207,15 @@ func writeMonitorJSON(result monitorResult, jsonMin bool, errorCode string) {
 	if result.NextOffset <= 0 {
 		delete(payload, "nextOffset")
 	}
+	if result.PendingPrompt != nil {
+		payload["pendingPrompt"] = result.PendingPrompt
+	}
+	if result.UnreadMessages > 0 {
+		payload["unreadMessages"] = result.UnreadMessages
+	}
+	if result.Result != nil {
+		payload["result"] = result.Result
+	}
 	if errorCode != "" {
 		payload["errorCode"] = errorCode
 	}
@@ -280,7 +319,7 @@ func writeMonitorStreamHandoffDelta(status sessionStatus, poll int, jsonMin bool
 
 func normalizeMonitorFinalStatus(finalState, finalStatus string) string {
 	switch finalState {
-	case "completed", "crashed", "stuck", "not_found":
+	case "completed", "crashed", "stuck", "not_found", "limit_exceeded":
 		return finalState
 	default:
 		return finalStatus
@@ -302,6 +341,7 @@ func cmdSessionStatus(args []string) int {
 	modeHint := "auto"
 	full := false
 	failNotFound := false
+	recordDir := ""
 	jsonOut := hasJSONFlag(args)
 	jsonMin := false
 
@@ -338,6 +378,16 @@ func cmdSessionStatus(args []string) int {
 			full = true
 		case "--fail-not-found":
 			failNotFound = true
+		case "--record":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --record")
+			}
+			dir, err := expandAndCleanPath(args[i+1])
+			if err != nil {
+				return commandErrorf(jsonOut, "invalid_record_dir", "invalid --record: %v", err)
+			}
+			recordDir = dir
+			i++
 		case "--json":
 			jsonOut = true
 		case "--json-min":
@@ -367,9 +417,20 @@ func cmdSessionStatus(args []string) int {
 		return commandError(jsonOut, "invalid_mode_hint", err.Error())
 	}
 
-	status, err := computeSessionStatusFn(session, projectRoot, agentHint, modeHint, full, 0)
-	if err != nil {
-		return commandError(jsonOut, "status_compute_failed", err.Error())
+	var status sessionStatus
+	if recordDir != "" {
+		status, err = recordSessionStatusFixture(recordDir, session, projectRoot, agentHint, modeHint, full)
+		if err != nil {
+			return commandErrorf(jsonOut, "record_failed", "failed recording classifier fixture: %v", err)
+		}
+		if !jsonOut {
+			fmt.Fprintf(os.Stderr, "recorded classifier fixture: %s\n", recordDir)
+		}
+	} else {
+		status, err = computeSessionStatusFn(session, projectRoot, agentHint, modeHint, full, 0)
+		if err != nil {
+			return commandError(jsonOut, "status_compute_failed", err.Error())
+		}
 	}
 	status = normalizeStatusForSessionStatusOutput(status)
 
@@ -378,7 +439,7 @@ func cmdSessionStatus(args []string) int {
 		if failNotFound && status.SessionState == "not_found" {
 			errorCode = "session_not_found"
 		}
-		writeSessionStatusJSON(status, errorCode, jsonMin)
+		writeSessionStatusJSON(status, errorCode, recordDir, jsonMin)
 		if failNotFound && status.SessionState == "not_found" {
 			return 1
 		}
@@ -466,11 +527,39 @@ func cmdSessionMonitor(args []string) int {
 	recoverMax := 1
 	recoverBudget := 0
 	adaptivePoll := false
+	sessionsRaw := ""
+	treeRoot := ""
+	labelSelectors := []string{}
+	untilQuorum := ""
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
 		case "--help", "-h":
 			return showHelp("session monitor")
+		case "--sessions":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --sessions")
+			}
+			sessionsRaw = strings.TrimSpace(args[i+1])
+			i++
+		case "--tree":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --tree")
+			}
+			treeRoot = strings.TrimSpace(args[i+1])
+			i++
+		case "--label", "-l":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			labelSelectors = append(labelSelectors, args[i+1])
+			i++
+		case "--until":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --until")
+			}
+			untilQuorum = strings.TrimSpace(args[i+1])
+			i++
 		case "--session":
 			if i+1 >= len(args) {
 				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --session")
@@ -644,9 +733,19 @@ func cmdSessionMonitor(args []string) int {
 		}
 	}
 
-	if session == "" {
+	fanIn := sessionsRaw != "" || treeRoot != "" || len(labelSelectors) > 0
+	if fanIn && session != "" {
+		return commandError(jsonOut, "fan_in_session_conflict", "--session cannot be combined with --sessions/--tree/--label")
+	}
+	if !fanIn && untilQuorum != "" {
+		return commandError(jsonOut, "until_requires_fan_in", "--until requires --sessions, --tree, or --label")
+	}
+	if session == "" && !fanIn {
 		return commandError(jsonOut, "missing_required_flag", "--session is required")
 	}
+	if fanIn && (emitHandoff || autoRecover || webhook != "") {
+		return commandError(jsonOut, "fan_in_unsupported_flag", "--emit-handoff, --auto-recover, and --webhook require single --session monitor")
+	}
 	if untilMarkerSet && untilMarker == "" {
 		return commandError(jsonOut, "invalid_until_marker", "invalid --until-marker: cannot be empty")
 	}
@@ -696,6 +795,42 @@ func cmdSessionMonitor(args []string) int {
 			maxPolls = timeoutPolls
 		}
 	}
+	stopConfig := monitorStopConfig{
+		UntilState:                  untilState,
+		UntilJSONPath:               untilJSONPath,
+		UntilMarker:                 untilMarker,
+		StopOnWaiting:               stopOnWaiting,
+		WaitingRequiresTurnComplete: waitingRequiresTurnComplete,
+	}
+	if fanIn {
+		parsedAgent, err := parseAgentHint(agentHint)
+		if err != nil {
+			return commandError(jsonOut, "invalid_agent_hint", err.Error())
+		}
+		parsedMode, err := parseModeHint(modeHint)
+		if err != nil {
+			return commandError(jsonOut, "invalid_mode_hint", err.Error())
+		}
+		return cmdSessionMonitorFanIn(monitorFanInConfig{
+			ProjectRoot:         projectRoot,
+			ProjectRootExplicit: projectRootExplicit,
+			SessionsRaw:         sessionsRaw,
+			TreeRoot:            treeRoot,
+			Labels:              labelSelectors,
+			Until:               untilQuorum,
+			AgentHint:           parsedAgent,
+			ModeHint:            parsedMode,
+			Expect:              expect,
+			PollInterval:        pollInterval,
+			MaxPolls:            maxPolls,
+			AdaptivePoll:        adaptivePoll,
+			Stop:                stopConfig,
+			JSONOut:             jsonOut,
+			JSONMin:             jsonMin,
+			StreamJSON:          streamJSON,
+			Verbose:             verbose,
+		})
+	}
 	resolvedRoot, resolveErr := resolveSessionProjectRootChecked(session, projectRoot, projectRootExplicit)
 	if resolveErr != nil {
 		return commandErrorf(jsonOut, "ambiguous_project_root", "%v", resolveErr)
@@ -723,6 +858,11 @@ func cmdSessionMonitor(args []string) int {
 
 	recoveries := 0
 	remainingRecoverBudget := recoverBudget
+	promptDecisionsLogged := map[string]bool{}
+	surfacedMessages := map[int]bool{}
+	unreadMessages := 0
+	limitMeta, _ := loadSessionMeta(projectRoot, session)
+	limitTracker := &sessionLimitTracker{}
 	for {
 		last := sessionStatus{}
 		degradedPolls := 0
@@ -776,61 +916,29 @@ func cmdSessionMonitor(args []string) int {
 				}
 			}
 
-			reason := ""
-			untilStateMatched := untilState != "" && status.SessionState == untilState
-			if untilStateMatched {
-				reason = status.SessionState
+			unreadMessages = monitorSurfaceMessages(projectRoot, session, poll, surfacedMessages, streamJSON, verbose)
+
+			promptAnswered, promptErr := monitorAutoAnswerPrompt(projectRoot, session, status, promptDecisionsLogged)
+			if promptErr != nil {
+				fmt.Fprintf(os.Stderr, "prompt policy warning: %v\n", promptErr)
 			}
-			untilJSONPathMatched := false
-			if reason == "" && untilJSONPath.Expr != "" {
-				matched, _, evalErr := evaluateMonitorJSONPathExpr(status, untilJSONPath)
-				if evalErr != nil {
-					return commandErrorf(jsonOut, "invalid_until_jsonpath", "invalid --until-jsonpath: %v", evalErr)
-				}
-				if matched {
-					reason = "jsonpath_matched"
-					untilJSONPathMatched = true
-				}
+			if promptAnswered && verbose {
+				fmt.Fprintf(os.Stderr, "[%s] poll=%d auto-answered %s prompt\n", time.Now().Format("15:04:05"), poll, status.PendingPrompt.Kind)
 			}
-			if reason == "" && untilMarker != "" {
-				capture, captureErr := tmuxCapturePaneFn(session, 320)
-				if captureErr == nil && strings.Contains(capture, untilMarker) {
-					reason = "marker_found"
-				}
+
+			reason, untilMatched, stopErr := monitorStopReason(session, projectRoot, &status, stopConfig)
+			if stopErr != nil {
+				return commandErrorf(jsonOut, "invalid_until_jsonpath", "invalid --until-jsonpath: %v", stopErr)
 			}
-			switch status.SessionState {
-			case "completed":
-				if reason == "" {
-					reason = "completed"
-				}
-			case "crashed":
-				if reason == "" {
-					reason = "crashed"
-				}
-			case "not_found":
-				if reason == "" {
-					reason = "not_found"
-				}
-			case "stuck":
-				if reason == "" {
-					reason = "stuck"
-				}
-			case "waiting_input":
-				if reason == "" && stopOnWaiting {
-					if waitingRequiresTurnComplete {
-						waitingTurn := monitorWaitingTurnCompleteFn(session, projectRoot, status)
-						if waitingTurn.Ready {
-							status.Signals.TranscriptTurnComplete = true
-							status.Signals.TranscriptFileAge = waitingTurn.FileAge
-							if err := recordSessionTurnComplete(projectRoot, session, waitingTurn.InputAtNanos, waitingTurn.FileAge); err != nil {
-								fmt.Fprintf(os.Stderr, "observability warning: failed to persist turn-complete marker: %v\n", err)
-							}
-							reason = "waiting_input_turn_complete"
-						}
-					} else {
-						reason = "waiting_input"
-					}
-				}
+			if promptAnswered {
+				// The agent is moving again; keep polling instead of stopping on waiting.
+				reason = ""
+			} else if monitorDeliverInbox(projectRoot, session, status, verbose, poll) {
+				reason = ""
+			}
+			if limitReason := monitorEnforceSessionLimits(projectRoot, session, limitMeta, &status, limitTracker); limitReason != "" {
+				reason = limitReason
+				untilMatched = false
 			}
 			if reason != "" {
 				expectationMet := monitorExpectationSatisfied(expect, reason)
@@ -848,12 +956,16 @@ func cmdSessionMonitor(args []string) int {
 					ExitReason:  finalReason,
 					Polls:       poll,
 					FinalStatus: normalizeMonitorFinalStatus(status.SessionState, status.Status),
+
+					PendingPrompt:  status.PendingPrompt,
+					UnreadMessages: unreadMessages,
+					Result:         status.Result,
 				}
 				if jsonOut {
 					errorCode := ""
 					if !expectationMet {
 						errorCode = "monitor_expectation_mismatch"
-					} else if !untilStateMatched && !untilJSONPathMatched && !(reason == "completed" || reason == "marker_found" || strings.HasPrefix(reason, "waiting_input")) {
+					} else if !monitorReasonSucceeded(reason, untilMatched) {
 						errorCode = "monitor_" + reason
 					}
 					writeMonitorJSON(result, jsonMin, errorCode)
@@ -875,7 +987,7 @@ func cmdSessionMonitor(args []string) int {
 					errorCode := ""
 					if !expectationMet {
 						errorCode = "monitor_expectation_mismatch"
-					} else if !untilStateMatched && !untilJSONPathMatched && !(reason == "completed" || reason == "marker_found" || strings.HasPrefix(reason, "waiting_input")) {
+					} else if !monitorReasonSucceeded(reason, untilMatched) {
 						errorCode = "monitor_" + reason
 					}
 					finalPayload := map[string]any{
@@ -900,7 +1012,7 @@ func cmdSessionMonitor(args []string) int {
 				if !expectationMet {
 					return 2
 				}
-				if reason == "completed" || reason == "marker_found" || strings.HasPrefix(reason, "waiting_input") || untilStateMatched || untilJSONPathMatched {
+				if monitorReasonSucceeded(reason, untilMatched) {
 					return 0
 				}
 				return 2
@@ -925,6 +1037,8 @@ func cmdSessionMonitor(args []string) int {
 			ExitReason:  "max_polls_exceeded",
 			Polls:       maxPolls,
 			FinalStatus: "timeout",
+
+			UnreadMessages: unreadMessages,
 		}
 		if degradedPolls == maxPolls && maxPolls > 0 {
 			result.ExitReason = "degraded_max_polls_exceeded"
@@ -991,6 +1105,79 @@ func cmdSessionMonitor(args []string) int {
 	}
 }
 
+type monitorStopConfig struct {
+	UntilState                  string
+	UntilJSONPath               monitorJSONPathExpr
+	UntilMarker                 string
+	StopOnWaiting               bool
+	WaitingRequiresTurnComplete bool
+}
+
+// monitorStopReason evaluates one poll against the monitor stop conditions.
+// An empty reason means keep polling; untilMatched reports that an explicit
+// --until-state/--until-jsonpath condition fired, or that a declared result
+// was a success.
+func monitorStopReason(session, projectRoot string, status *sessionStatus, cfg monitorStopConfig) (string, bool, error) {
+	reason := ""
+	untilMatched := false
+	if cfg.UntilState != "" && status.SessionState == cfg.UntilState {
+		reason = status.SessionState
+		untilMatched = true
+	}
+	if reason == "" && cfg.UntilJSONPath.Expr != "" {
+		matched, _, evalErr := evaluateMonitorJSONPathExpr(*status, cfg.UntilJSONPath)
+		if evalErr != nil {
+			return "", false, evalErr
+		}
+		if matched {
+			reason = "jsonpath_matched"
+			untilMatched = true
+		}
+	}
+	if reason == "" && cfg.UntilMarker != "" {
+		capture, captureErr := tmuxCapturePaneFn(session, 320)
+		if captureErr == nil && strings.Contains(capture, cfg.UntilMarker) {
+			reason = "marker_found"
+		}
+	}
+	if reason != "" {
+		return reason, untilMatched, nil
+	}
+	if status.Result != nil {
+		return "result_reported", status.Result.Status == "success", nil
+	}
+	switch status.SessionState {
+	case "completed", "crashed", "not_found", "stuck":
+		reason = status.SessionState
+	case "waiting_input":
+		if cfg.StopOnWaiting {
+			if cfg.WaitingRequiresTurnComplete {
+				if status.Signals.HookEvent == sessionHookTurnComplete {
+					// The agent's own Stop/notify callback already confirmed the turn.
+					reason = "waiting_input_turn_complete"
+					break
+				}
+				waitingTurn := monitorWaitingTurnCompleteFn(session, projectRoot, *status)
+				if waitingTurn.Ready {
+					status.Signals.TranscriptTurnComplete = true
+					status.Signals.TranscriptFileAge = waitingTurn.FileAge
+					if err := recordSessionTurnComplete(projectRoot, session, waitingTurn.InputAtNanos, waitingTurn.FileAge); err != nil {
+						fmt.Fprintf(os.Stderr, "observability warning: failed to persist turn-complete marker: %v\n", err)
+					}
+					reason = "waiting_input_turn_complete"
+				}
+			} else {
+				reason = "waiting_input"
+			}
+		}
+	}
+	return reason, untilMatched, nil
+}
+
+func monitorReasonSucceeded(reason string, untilMatched bool) bool {
+	return untilMatched || reason == "completed" || reason == "marker_found" || strings.HasPrefix(reason, "waiting_input")
+}
+
 func monitorEventLimitFromBudget(eventBudget int) int {
 	if eventBudget <= 0 {
 		return 8
@@ -1046,6 +1233,13 @@ func emitMonitorWebhook(target string, payload map[string]any) error {
 	if err != nil {
 		return err
 	}
+	decoded := map[string]any{}
+	if json.Unmarshal(data, &decoded) == nil {
+		redactPayloadMap(decoded)
+		if redacted, marshalErr := json.Marshal(decoded); marshalErr == nil {
+			data = redacted
+		}
+	}
 	lower := strings.ToLower(strings.TrimSpace(target))
 	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
 		req, reqErr := http.NewRequest(http.MethodPost, target, bytes.NewReader(data))
@@ -1244,6 +1438,7 @@ func cmdSessionCapture(args []string) int {
 	summaryStyle := "terse"
 	stripNoise := true
 	stripBanner := false
+	format := ""
 	projectRoot := getPWD()
 	projectRootExplicit := false
 	for i := 0; i < len(args); i++ {
@@ -1316,6 +1511,16 @@ func cmdSessionCapture(args []string) int {
 			stripNoise = true
 		case "--strip-banner":
 			stripBanner = true
+		case "--format":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --format")
+			}
+			parsedFormat, formatErr := parseTranscriptFormat(args[i+1])
+			if formatErr != nil {
+				return commandError(jsonOut, "invalid_format", formatErr.Error())
+			}
+			format = parsedFormat
+			i++
 		case "--project-root":
 			if i+1 >= len(args) {
 				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --project-root")
@@ -1335,11 +1540,17 @@ func cmdSessionCapture(args []string) int {
 	if session == "" {
 		return commandError(jsonOut, "missing_required_flag", "--session is required")
 	}
-	if deltaFrom != "" && !raw {
-		return commandError(jsonOut, "delta_requires_raw_capture", "--delta-from requires --raw")
+	if format != "" && raw {
+		return commandError(jsonOut, "format_requires_transcript", "--format applies to transcript capture and cannot be combined with --raw")
+	}
+	if format != "" && (summary || len(markersRaw) > 0) {
+		return commandError(jsonOut, "format_mode_conflict", "--format cannot be combined with --summary or --markers")
 	}
-	if cursorFile != "" && !raw {
-		return commandError(jsonOut, "cursor_file_requires_raw_capture", "--cursor-file requires --raw")
+	if deltaFrom != "" && !raw && format == "" {
+		return commandError(jsonOut, "delta_requires_raw_capture", "--delta-from requires --raw (pane offsets) or --format (message indices)")
+	}
+	if cursorFile != "" && !raw && format == "" {
+		return commandError(jsonOut, "cursor_file_requires_raw_capture", "--cursor-file requires --raw (pane offsets) or --format (message indices)")
 	}
 	if semanticDelta && !raw {
 		return commandError(jsonOut, "semantic_delta_requires_raw_capture", "--semantic-delta requires --raw")
@@ -1396,30 +1607,50 @@ func cmdSessionCapture(args []string) int {
 	}
 
 	if !raw && shouldUseTranscriptCaptureFn(session, transcriptProjectRoot) {
+		transcriptAgent := transcriptCaptureAgent(session, transcriptProjectRoot)
 		sessionID, messages, err := captureSessionTranscriptFn(session, transcriptProjectRoot)
+		if err != nil && format != "" {
+			return commandErrorf(jsonOut, "transcript_capture_failed", "transcript capture failed: %v", err)
+		}
+		if err == nil && format != "" {
+			return writeFormattedTranscriptCapture(transcriptCaptureRequest{
+				Session:    session,
+				Agent:      transcriptAgent,
+				SessionID:  sessionID,
+				Messages:   messages,
+				Format:     format,
+				DeltaFrom:  deltaFrom,
+				CursorFile: cursorFile,
+				JSONOut:    jsonOut,
+				JSONMin:    jsonMin,
+			})
+		}
 		if err == nil {
 			if summary {
-				transcriptText := formatTranscriptPlain(messages)
+				transcriptText := formatTranscriptPlain(conversationTranscriptMessages(messages))
 				summaryText, truncated := summarizeCaptureTextByStyle(session, projectRoot, transcriptText, tokenBudget, summaryStyle)
 				if jsonOut {
 					payload := map[string]any{
-						"session":       session,
-						"claudeSession": sessionID,
-						"messageCount":  len(messages),
-						"summary":       summaryText,
-						"summaryStyle":  summaryStyle,
-						"tokenBudget":   tokenBudget,
-						"truncated":     truncated,
+						"session":                             session,
+						transcriptSessionKey(transcriptAgent): sessionID,
+						"messageCount":                        len(conversationTranscriptMessages(messages)),
+						"summary":                             summaryText,
+						"summaryStyle":                        summaryStyle,
+						"tokenBudget":                         tokenBudget,
+						"truncated":                           truncated,
 					}
 					writeJSON(payload)
 					return 0
 				}
-				fmt.Print(summaryText)
+				fmt.Print(redactOutputText(summaryText))
 				return 0
 			}
-			return writeTranscriptCapture(session, sessionID, messages, jsonOut, jsonMin)
+			return writeTranscriptCapture(session, transcriptAgent, sessionID, messages, jsonOut, jsonMin)
 		}
 	}
+	if format != "" {
+		return commandError(jsonOut, "transcript_unavailable", "--format requires a Claude or Codex session with transcript metadata")
+	}
 
 	if !tmuxHasSessionFn(session) {
 		if jsonOut {
@@ -1553,14 +1784,14 @@ func cmdSessionCapture(args []string) int {
 		return 0
 	}
 	if summary {
-		fmt.Print(summaryText)
+		fmt.Print(redactOutputText(summaryText))
 		return 0
 	}
 	if semanticDelta {
-		fmt.Print(semanticDeltaText)
+		fmt.Print(redactOutputText(semanticDeltaText))
 		return 0
 	}
-	fmt.Print(capture)
+	fmt.Print(redactOutputText(capture))
 	return 0
 }
 
@@ -1768,20 +1999,36 @@ func loadCaptureLastOutputAtNanos(projectRoot, session string) (int64, error) {
 }
 
 func shouldUseTranscriptCapture(session, projectRoot string) bool {
-	root := strings.TrimSpace(projectRoot)
-	if root != "" {
-		meta, err := loadSessionMeta(canonicalProjectRoot(root), session)
-		if err != nil {
-			return false
-		}
-		return normalizeAgent(meta.Agent) == "claude"
-	}
+	return transcriptCaptureAgent(session, projectRoot) != ""
+}
 
-	meta, err := loadSessionMetaByGlobFn(session)
+// transcriptCaptureAgent returns the agent whose transcript backs default
+// capture for a session ("claude" or "codex"), or "" when pane capture applies.
+func transcriptCaptureAgent(session, projectRoot string) string {
+	var (
+		meta sessionMeta
+		err  error
+	)
+	if root := strings.TrimSpace(projectRoot); root != "" {
+		meta, err = loadSessionMeta(canonicalProjectRoot(root), session)
+	} else {
+		meta, err = loadSessionMetaByGlobFn(session)
+	}
 	if err != nil {
-		return false
+		return ""
+	}
+	switch agent := normalizeAgent(meta.Agent); agent {
+	case "claude", "codex":
+		return agent
+	}
+	return ""
+}
+
+func transcriptSessionKey(agent string) string {
+	if agent == "codex" {
+		return "codexSession"
 	}
-	return normalizeAgent(meta.Agent) == "claude"
+	return "claudeSession"
 }
 
 func parseCaptureSummaryStyle(raw string) (string, error) {
@@ -1948,10 +2195,10 @@ func parseMonitorUntilStateRuntime(raw string) (string, error) {
 	switch value {
 	case "":
 		return "", nil
-	case "just_started", "waiting_input", "completed", "crashed", "stuck", "not_found", "in_progress", "degraded":
+	case "just_started", "waiting_input", "completed", "crashed", "stuck", "not_found", "in_progress", "degraded", "limit_exceeded":
 		return value, nil
 	default:
-		return "", fmt.Errorf("invalid --until-state: %s (expected just_started|waiting_input|completed|crashed|stuck|not_found|in_progress|degraded)", raw)
+		return "", fmt.Errorf("invalid --until-state: %s (expected just_started|waiting_input|completed|crashed|stuck|not_found|in_progress|degraded|limit_exceeded)", raw)
 	}
 }
 
@@ -1960,7 +2207,7 @@ func monitorExpectationSatisfied(expect, reason string) bool {
 	case "marker":
 		return reason == "marker_found"
 	case "terminal":
-		return reason == "completed" || reason == "crashed" || reason == "stuck" || reason == "not_found"
+		return reason == "completed" || reason == "crashed" || reason == "stuck" || reason == "not_found" || reason == "result_reported" || strings.HasPrefix(reason, "limit_")
 	default:
 		return true
 	}
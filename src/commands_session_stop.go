@@ -0,0 +1,243 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSessionStopGrace = 30 * time.Second
+	sessionStopCaptureLines = 2000
+)
+
+// sessionStopStep is one input of an agent's exit sequence: either tmux keys
+// or literal text followed by Enter.
+type sessionStopStep struct {
+	Keys []string
+	Text string
+}
+
+// sessionStopSequence returns the input that makes the agent exit on its own,
+// letting the session wrapper write the done marker. Exec sessions and custom
+// commands get a plain interrupt.
+func sessionStopSequence(agent, mode string) []sessionStopStep {
+	if mode != "interactive" {
+		return []sessionStopStep{{Keys: []string{"C-c"}}}
+	}
+	switch agent {
+	case "claude":
+		return []sessionStopStep{{Keys: []string{"Escape"}}, {Text: "/exit"}}
+	case "codex":
+		return []sessionStopStep{{Keys: []string{"Escape"}}, {Text: "/quit"}}
+	default:
+		return []sessionStopStep{{Keys: []string{"C-c"}}}
+	}
+}
+
+func sendSessionStopSequence(session string, steps []sessionStopStep) error {
+	for i, step := range steps {
+		if i > 0 {
+			sessionKillSleepFn(sessionKillGracePollPeriod)
+		}
+		var err error
+		if step.Text != "" {
+			err = tmuxSendTextFn(session, step.Text, true)
+		} else {
+			err = tmuxSendKeysFn(session, step.Keys, false)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitSessionStopped polls until the run's done marker appears or the tmux
+// session goes away. exitCode is nil when only the latter happened.
+func waitSessionStopped(projectRoot, session, runID string, grace time.Duration) (bool, *int) {
+	deadline := nowFn().Add(grace)
+	for {
+		if done, code, _, _, err := readSessionDoneFile(projectRoot, session, runID); err == nil && done {
+			return true, &code
+		}
+		if !tmuxHasSessionFn(session) {
+			return true, nil
+		}
+		if !nowFn().Before(deadline) {
+			return false, nil
+		}
+		sessionKillSleepFn(sessionKillGracePollPeriod)
+	}
+}
+
+func cmdSessionStop(args []string) int {
+	session := ""
+	projectRoot := getPWD()
+	projectRootExplicit := false
+	cleanupAllHashes := false
+	grace := defaultSessionStopGrace
+	jsonOut := hasJSONFlag(args)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("session stop")
+		case "--session", "--project-root", "--grace":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			switch args[i] {
+			case "--session":
+				session = args[i+1]
+			case "--project-root":
+				projectRoot = args[i+1]
+				projectRootExplicit = true
+			case "--grace":
+				parsed, err := parseDurationFlag("--grace", args[i+1])
+				if err != nil {
+					return commandError(jsonOut, "invalid_grace", err.Error())
+				}
+				grace = parsed
+			}
+			i++
+		case "--cleanup-all-hashes":
+			cleanupAllHashes = true
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if session == "" {
+		return commandError(jsonOut, "missing_required_flag", "--session is required")
+	}
+	resolvedRoot, resolveErr := resolveSessionProjectRootChecked(session, projectRoot, projectRootExplicit)
+	if resolveErr != nil {
+		return commandErrorf(jsonOut, "ambiguous_project_root", "%v", resolveErr)
+	}
+	projectRoot = resolvedRoot
+	restoreRuntime := withProjectRuntimeEnv(projectRoot)
+	defer restoreRuntime()
+
+	if !tmuxHasSessionFn(session) {
+		return commandError(jsonOut, "session_not_found", "session not found")
+	}
+	meta, _ := loadSessionMeta(projectRoot, session)
+	capture, _ := tmuxCapturePaneFn(session, sessionStopCaptureLines)
+	cleanupOpts := cleanupOptions{AllHashes: cleanupAllHashes, KeepEvents: true}
+	descendants, descendantsErr := listSessionDescendants(projectRoot, session, cleanupOpts.AllHashes)
+	if descendantsErr != nil {
+		fmt.Fprintf(os.Stderr, "descendant lookup warning: %v\n", descendantsErr)
+	}
+
+	graceful := false
+	var exitCode *int
+	if grace > 0 {
+		// Descendants get their own exit sequence and share the grace window,
+		// so the whole tree winds down before anything is killed.
+		runIDs := map[string]string{session: meta.RunID}
+		for _, target := range append([]string{session}, descendants...) {
+			targetMeta := meta
+			if target != session {
+				if !tmuxHasSessionFn(target) {
+					continue
+				}
+				targetMeta, _ = loadSessionMeta(projectRoot, target)
+				runIDs[target] = targetMeta.RunID
+			}
+			if err := sendSessionStopSequence(target, sessionStopSequence(targetMeta.Agent, targetMeta.Mode)); err != nil {
+				fmt.Fprintf(os.Stderr, "stop warning: %s: %v\n", target, err)
+			}
+			if err := appendLifecycleEvent(projectRoot, target, "lifecycle", "in_progress", "active", "stop_requested"); err != nil {
+				fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+			}
+		}
+		deadline := nowFn().Add(grace)
+		graceful, exitCode = waitSessionStopped(projectRoot, session, meta.RunID, grace)
+		for _, target := range descendants {
+			if runID, ok := runIDs[target]; ok {
+				waitSessionStopped(projectRoot, target, runID, deadline.Sub(nowFn()))
+			}
+		}
+	}
+	if tmuxHasSessionFn(session) {
+		if final, err := tmuxCapturePaneFn(session, sessionStopCaptureLines); err == nil && strings.TrimSpace(final) != "" {
+			capture = final
+		}
+	}
+	// Escalation means a graceful attempt was made and timed out; --grace 0
+	// skips the attempt and kills outright.
+	escalated := grace > 0 && !graceful
+	if escalated {
+		if err := appendLifecycleEvent(projectRoot, session, "lifecycle", "degraded", "active", "stop_grace_timeout"); err != nil {
+			fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+		}
+	}
+
+	archive, archiveErr := beginSessionHistoryArchive(projectRoot, session, capture, graceful, exitCode)
+	if archiveErr != nil {
+		// Killing now would drop the only copy of the artifacts.
+		return commandErrorf(jsonOut, "archive_failed", "archive failed: %v (session left in place; use session kill to force)", archiveErr)
+	}
+
+	killed := []string{}
+	var errs []string
+	for _, target := range append(descendants, session) {
+		reasonPrefix := "kill_descendant"
+		var ref *historyArchiveRef
+		if target == session {
+			reasonPrefix = "stop"
+			ref = &historyArchiveRef{Dir: archive.Dir, Graceful: graceful, ExitCode: exitCode}
+		}
+		found, ok, targetErrs := killSessionTarget(projectRoot, target, reasonPrefix, cleanupOpts, ref)
+		errs = append(errs, targetErrs...)
+		if found && ok {
+			killed = append(killed, target)
+		}
+	}
+	// The events are kept by cleanup, so the archive copy ends with the stop.
+	if err := archive.finish(projectRoot, session); err != nil {
+		errs = append(errs, fmt.Sprintf("%s archive: %v", session, err))
+	}
+	archiveDir := archive.Dir
+	if err := pruneStaleSessionEventArtifactsFn(); err != nil {
+		fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+	}
+
+	payload := map[string]any{
+		"session":      session,
+		"ok":           len(errs) == 0,
+		"graceful":     graceful,
+		"escalated":    escalated,
+		"graceSeconds": grace.Seconds(),
+		"archive":      archiveDir,
+		"killed":       killed,
+		"projectRoot":  projectRoot,
+	}
+	if exitCode != nil {
+		payload["exitCode"] = *exitCode
+	}
+	if len(errs) > 0 {
+		payload["errors"] = errs
+		payload["errorCode"] = "session_stop_failed"
+	}
+	if jsonOut {
+		writeJSON(payload)
+	} else {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		mode := "stopped"
+		if escalated {
+			mode = "killed after grace timeout"
+		} else if !graceful {
+			mode = "killed without a grace period"
+		}
+		fmt.Printf("%s %s; archived to %s\n", session, mode, archiveDir)
+	}
+	if len(errs) > 0 {
+		return 1
+	}
+	return 0
+}
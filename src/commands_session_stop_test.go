@@ -0,0 +1,243 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func stubSessionStopRuntime(t *testing.T) (root string, alive map[string]bool, sent *[]string) {
+	t.Helper()
+	origHas, origKill, origCapture := tmuxHasSessionFn, tmuxKillSessionFn, tmuxCapturePaneFn
+	origKeys, origText, origSleep, origNow := tmuxSendKeysFn, tmuxSendTextFn, sessionKillSleepFn, nowFn
+	t.Cleanup(func() {
+		tmuxHasSessionFn, tmuxKillSessionFn, tmuxCapturePaneFn = origHas, origKill, origCapture
+		tmuxSendKeysFn, tmuxSendTextFn, sessionKillSleepFn, nowFn = origKeys, origText, origSleep, origNow
+	})
+	t.Setenv(historyDirEnv, t.TempDir())
+	root = canonicalProjectRoot(t.TempDir())
+	alive = map[string]bool{}
+	sent = &[]string{}
+	clock := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return clock }
+	sessionKillSleepFn = func(d time.Duration) { clock = clock.Add(d) }
+	tmuxHasSessionFn = func(session string) bool { return alive[session] }
+	tmuxKillSessionFn = func(session string) error { alive[session] = false; return nil }
+	tmuxCapturePaneFn = func(session string, lines int) (string, error) {
+		return "final answer: token=sk-ant-REDACTED", nil
+	}
+	tmuxSendKeysFn = func(session string, keys []string, enter bool) error {
+		*sent = append(*sent, strings.Join(keys, "+"))
+		return nil
+	}
+	tmuxSendTextFn = func(session, text string, enter bool) error {
+		*sent = append(*sent, text)
+		return nil
+	}
+	return root, alive, sent
+}
+
+func TestSessionStopArchivesAfterGracefulExit(t *testing.T) {
+	root, alive, sent := stubSessionStopRuntime(t)
+	session := "lisa-stop-graceful"
+	alive[session] = true
+	t.Cleanup(func() { _ = os.Remove(sessionEventsFile(root, session)) })
+	meta := sessionMeta{Session: session, Agent: "claude", Mode: "interactive", RunID: "run-1", ProjectRoot: root, Labels: map[string]string{"team": "infra"}}
+	if err := saveSessionMeta(root, session, meta); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+	tmuxSendTextFn = func(s, text string, enter bool) error {
+		*sent = append(*sent, text)
+		// The wrapper writes the done marker once the agent exits.
+		return os.WriteFile(sessionDoneFile(root, session), []byte("run-1:0\n"), 0o600)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionStop([]string{"--session", session, "--project-root", root, "--json"}); code != 0 {
+			t.Fatalf("expected stop success, got %d", code)
+		}
+	})
+	payload := map[string]any{}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("invalid JSON: %v (%q)", err, stdout)
+	}
+	if payload["graceful"] != true || payload["escalated"] != false || payload["exitCode"] != float64(0) {
+		t.Fatalf("expected graceful stop, got %v", payload)
+	}
+	if !slices.Equal(*sent, []string{"Escape", "/exit"}) {
+		t.Fatalf("unexpected exit sequence: %v", *sent)
+	}
+	if alive[session] || fileExists(sessionMetaFile(root, session)) {
+		t.Fatalf("expected session killed and artifacts cleaned")
+	}
+
+	dir, _ := payload["archive"].(string)
+	raw, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("missing archive manifest: %v", err)
+	}
+	manifest := historyArchiveManifest{}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	names := []string{}
+	for _, file := range manifest.Files {
+		names = append(names, file.Name)
+	}
+	if manifest.Agent != "claude" || manifest.RunID != "run-1" || !manifest.Graceful || manifest.Labels["team"] != "infra" {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if !slices.Contains(names, "capture.txt") || !slices.Contains(names, "meta.json") || !slices.Contains(names, "events.jsonl") {
+		t.Fatalf("unexpected archive files: %v", names)
+	}
+	capture, _ := os.ReadFile(filepath.Join(dir, "capture.txt"))
+	if strings.Contains(string(capture), "sk-ant-abcdefghijklmnop") {
+		t.Fatalf("expected archived capture to be redacted: %q", capture)
+	}
+	archivedEvents, _ := os.ReadFile(filepath.Join(dir, "events.jsonl"))
+	if !strings.Contains(string(archivedEvents), `"reason":"stop_success"`) {
+		t.Fatalf("expected archived events to end with the stop: %s", archivedEvents)
+	}
+}
+
+func TestSessionStopSendsDescendantsExitSequenceWithinGrace(t *testing.T) {
+	root, alive, _ := stubSessionStopRuntime(t)
+	parent, child := "lisa-stop-parent", "lisa-stop-child"
+	alive[parent], alive[child] = true, true
+	for _, meta := range []sessionMeta{
+		{Session: parent, Agent: "claude", Mode: "interactive", RunID: "run-p", ProjectRoot: root},
+		{Session: child, Agent: "codex", Mode: "exec", RunID: "run-c", ProjectRoot: root, ParentSession: parent},
+	} {
+		if err := saveSessionMeta(root, meta.Session, meta); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+		session := meta.Session
+		t.Cleanup(func() {
+			_ = os.Remove(sessionMetaFile(root, session))
+			_ = os.Remove(sessionEventsFile(root, session))
+		})
+	}
+	steps := []string{}
+	tmuxSendKeysFn = func(session string, keys []string, enter bool) error {
+		steps = append(steps, session+":"+strings.Join(keys, "+"))
+		if session == child {
+			alive[child] = false
+		}
+		return nil
+	}
+	tmuxSendTextFn = func(session, text string, enter bool) error {
+		steps = append(steps, session+":"+text)
+		alive[parent] = false
+		return nil
+	}
+	tmuxKillSessionFn = func(session string) error {
+		steps = append(steps, "kill:"+session)
+		alive[session] = false
+		return nil
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionStop([]string{"--session", parent, "--project-root", root, "--grace", "5s", "--json"}); code != 0 {
+			t.Fatalf("expected stop success, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"graceful":true`) {
+		t.Fatalf("expected graceful stop, got %s", stdout)
+	}
+	want := []string{parent + ":Escape", parent + ":/exit", child + ":C-c"}
+	if !slices.Equal(steps, want) {
+		t.Fatalf("expected exit sequences for the whole tree and no kills, got %v", steps)
+	}
+}
+
+func TestSessionStopEscalatesToKillAfterGrace(t *testing.T) {
+	root, alive, sent := stubSessionStopRuntime(t)
+	session := "lisa-stop-timeout"
+	alive[session] = true
+	events := []sessionEvent{}
+	origAppend := appendSessionEventFn
+	t.Cleanup(func() { appendSessionEventFn = origAppend })
+	appendSessionEventFn = func(_ string, _ string, event sessionEvent) error { events = append(events, event); return nil }
+	if err := saveSessionMeta(root, session, sessionMeta{Session: session, Agent: "codex", Mode: "exec", ProjectRoot: root}); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionStop([]string{"--session", session, "--project-root", root, "--grace", "2s", "--json"}); code != 0 {
+			t.Fatalf("expected escalated stop to succeed, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"escalated":true`) || !strings.Contains(stdout, `"graceful":false`) {
+		t.Fatalf("expected escalation, got %s", stdout)
+	}
+	if !slices.Equal(*sent, []string{"C-c"}) || alive[session] {
+		t.Fatalf("expected C-c then kill, sent=%v alive=%v", *sent, alive[session])
+	}
+	reasons := []string{}
+	for _, event := range events {
+		reasons = append(reasons, event.Reason)
+	}
+	if !slices.Equal(reasons, []string{"stop_requested", "stop_grace_timeout", "stop_success"}) {
+		t.Fatalf("unexpected stop events: %v", reasons)
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := cmdSessionStop([]string{"--session", session, "--project-root", root, "--json"}); code == 0 {
+			t.Fatalf("expected missing session to fail")
+		}
+	})
+}
+
+func TestSessionStopWithZeroGraceDoesNotReportEscalation(t *testing.T) {
+	root, alive, sent := stubSessionStopRuntime(t)
+	session := "lisa-stop-nograce"
+	alive[session] = true
+	events := []sessionEvent{}
+	origAppend := appendSessionEventFn
+	t.Cleanup(func() { appendSessionEventFn = origAppend })
+	appendSessionEventFn = func(_ string, _ string, event sessionEvent) error { events = append(events, event); return nil }
+	if err := saveSessionMeta(root, session, sessionMeta{Session: session, Agent: "codex", Mode: "exec", ProjectRoot: root}); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionStop([]string{"--session", session, "--project-root", root, "--grace", "0", "--json"}); code != 0 {
+			t.Fatalf("expected forced stop to succeed, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"escalated":false`) || !strings.Contains(stdout, `"graceful":false`) {
+		t.Fatalf("--grace 0 must not report escalation, got %s", stdout)
+	}
+	if len(*sent) != 0 || alive[session] {
+		t.Fatalf("expected a direct kill without exit keys, sent=%v alive=%v", *sent, alive[session])
+	}
+	for _, event := range events {
+		if event.Reason == "stop_requested" || event.Reason == "stop_grace_timeout" {
+			t.Fatalf("unexpected graceful-stop event %q", event.Reason)
+		}
+	}
+}
+
+func TestSessionStopArchivesWithinOneSecondDoNotCollide(t *testing.T) {
+	root, _, _ := stubSessionStopRuntime(t)
+	session := "lisa-stop-twice"
+	first, err := beginSessionHistoryArchive(root, session, "first run", true, nil)
+	if err != nil {
+		t.Fatalf("begin first archive failed: %v", err)
+	}
+	second, err := beginSessionHistoryArchive(root, session, "second run", true, nil)
+	if err != nil {
+		t.Fatalf("begin second archive failed: %v", err)
+	}
+	if first.Dir == second.Dir {
+		t.Fatalf("expected distinct archive dirs for same-second stops, got %s", first.Dir)
+	}
+	raw, err := os.ReadFile(filepath.Join(first.Dir, "capture.txt"))
+	if err != nil || string(raw) != "first run" {
+		t.Fatalf("first archive was overwritten: %q (%v)", raw, err)
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSessionKillGrace    = 10 * time.Second
+	sessionKillGracePollPeriod = 250 * time.Millisecond
+)
+
+var sessionKillSleepFn = time.Sleep
+
+// interruptSessionsWithGrace sends Ctrl-C to every live session in one tree
+// level and waits up to grace for each to write its done file or exit. It
+// returns the sessions that stopped on their own before the deadline; the
+// caller force-kills whatever is left.
+func interruptSessionsWithGrace(projectRoot string, sessions []string, grace time.Duration) []string {
+	stopped := []string{}
+	if grace <= 0 {
+		return stopped
+	}
+	pending := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		if !tmuxHasSessionFn(session) {
+			continue
+		}
+		if err := tmuxSendKeysFn(session, []string{"C-c"}, false); err != nil {
+			fmt.Fprintf(os.Stderr, "interrupt warning: %s: %v\n", session, err)
+			continue
+		}
+		if err := appendLifecycleEvent(projectRoot, session, "lifecycle", "in_progress", "active", "kill_interrupt"); err != nil {
+			fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+		}
+		pending = append(pending, session)
+	}
+
+	deadline := nowFn().Add(grace)
+	for len(pending) > 0 {
+		next := pending[:0]
+		for _, session := range pending {
+			if sessionStoppedAfterInterrupt(projectRoot, session) {
+				stopped = append(stopped, session)
+				continue
+			}
+			next = append(next, session)
+		}
+		pending = next
+		if len(pending) == 0 || !nowFn().Before(deadline) {
+			break
+		}
+		sessionKillSleepFn(sessionKillGracePollPeriod)
+	}
+	return stopped
+}
+
+func sessionStoppedAfterInterrupt(projectRoot, session string) bool {
+	if !tmuxHasSessionFn(session) {
+		return true
+	}
+	done, _, _, _, err := readSessionDoneFile(projectRoot, session, "")
+	return err == nil && done
+}
+
+type subtreeSendResult struct {
+	Session   string `json:"session"`
+	OK        bool   `json:"ok"`
+	ErrorCode string `json:"errorCode,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// cmdSessionSendSubtree broadcasts one send payload to a root session and all
+// of its descendants, parents before children.
+func cmdSessionSendSubtree(root, projectRoot string, projectRootExplicit bool, text string, keyList []string, enter, jsonOut, jsonMin bool) int {
+	resolvedRoot, resolveErr := resolveSessionProjectRootChecked(root, projectRoot, projectRootExplicit)
+	if resolveErr != nil {
+		return commandErrorf(jsonOut, "ambiguous_project_root", "%v", resolveErr)
+	}
+	projectRoot = resolvedRoot
+	restoreRuntime := withProjectRuntimeEnv(projectRoot)
+	defer restoreRuntime()
+
+	levels, err := listSessionDescendantLevels(projectRoot, root, false)
+	if err != nil {
+		return commandErrorf(jsonOut, "subtree_lookup_failed", "failed to resolve subtree: %v", err)
+	}
+	targets := []string{root}
+	for _, level := range levels {
+		targets = append(targets, level...)
+	}
+
+	results := make([]subtreeSendResult, 0, len(targets))
+	sent := 0
+	for _, target := range targets {
+		result := subtreeSendResult{Session: target, OK: true}
+		if _, errorCode, sendErr := deliverSessionSend(projectRoot, target, text, keyList, enter); sendErr != nil {
+			result.OK = false
+			result.ErrorCode = errorCode
+			result.Error = sendErr.Error()
+		} else {
+			sent++
+		}
+		results = append(results, result)
+	}
+	ok := sent == len(targets)
+
+	if jsonOut {
+		payload := map[string]any{
+			"subtree":     root,
+			"ok":          ok,
+			"sent":        sent,
+			"total":       len(targets),
+			"projectRoot": projectRoot,
+		}
+		if !jsonMin {
+			payload["results"] = results
+		}
+		if !ok {
+			payload["errorCode"] = "subtree_send_failed"
+			if jsonMin {
+				payload["results"] = results
+			}
+		}
+		writeJSON(payload)
+	} else {
+		for _, result := range results {
+			if result.OK {
+				fmt.Printf("%s ok\n", result.Session)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%s failed: %s\n", result.Session, strings.TrimSpace(result.Error))
+		}
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,418 @@
+package app
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var tailSleepFn = time.Sleep
+var tailColorEnabledFn = stdoutSupportsColor
+
+var tailColorPalette = []string{"36", "32", "33", "35", "34", "31", "96", "92", "93", "95"}
+
+type sessionTailConfig struct {
+	ProjectRoot         string
+	ProjectRootExplicit bool
+	SessionsRaw         string
+	TreeRoot            string
+	Labels              []string
+	All                 bool
+	Follow              bool
+	Lines               int
+	Since               time.Time
+	Grep                *regexp.Regexp
+	Raw                 bool
+	PollInterval        time.Duration
+	MaxPolls            int
+	Color               bool
+	JSONOut             bool
+}
+
+// sessionTailCursor tracks how far into a pane's line stream tail has emitted.
+// Offset is an absolute line number (tmux history_size plus the row), so rows
+// an agent redraws in place are never printed twice. Lines is the stable part
+// of the last capture, used to re-anchor when the offset cannot be trusted.
+type sessionTailCursor struct {
+	Offset  int
+	History int
+	Tracked bool
+	Last    string
+	Lines   []string
+}
+
+func cmdSessionTail(args []string) int {
+	session := ""
+	cfg := sessionTailConfig{
+		ProjectRoot:  getPWD(),
+		Lines:        10,
+		PollInterval: time.Second,
+	}
+	noColor := false
+	jsonOut := hasJSONFlag(args)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			return showHelp("session tail")
+		case "--session", "--sessions", "--tree", "--label", "-l", "--project-root", "--lines", "--since", "--grep", "--poll-interval", "--max-polls":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			value := args[i+1]
+			switch args[i] {
+			case "--session":
+				session = strings.TrimSpace(value)
+			case "--sessions":
+				cfg.SessionsRaw = value
+			case "--tree":
+				cfg.TreeRoot = strings.TrimSpace(value)
+			case "--label", "-l":
+				cfg.Labels = append(cfg.Labels, value)
+			case "--project-root":
+				cfg.ProjectRoot = value
+				cfg.ProjectRootExplicit = true
+			case "--lines":
+				n, err := parseNonNegativeIntFlag(value, "--lines")
+				if err != nil {
+					return commandError(jsonOut, "invalid_lines", err.Error())
+				}
+				cfg.Lines = n
+			case "--since":
+				since, err := parseSinceFlag("--since", value)
+				if err != nil {
+					return commandError(jsonOut, "invalid_since", err.Error())
+				}
+				cfg.Since = since
+			case "--grep":
+				re, err := regexp.Compile(value)
+				if err != nil {
+					return commandErrorf(jsonOut, "invalid_grep", "invalid --grep: %v", err)
+				}
+				cfg.Grep = re
+			case "--poll-interval":
+				interval, err := parseDurationFlag("--poll-interval", value)
+				if err != nil || interval <= 0 {
+					return commandError(jsonOut, "invalid_poll_interval", "invalid --poll-interval")
+				}
+				cfg.PollInterval = interval
+			case "--max-polls":
+				n, err := parsePositiveIntFlag(value, "--max-polls")
+				if err != nil {
+					return commandError(jsonOut, "invalid_max_polls", err.Error())
+				}
+				cfg.MaxPolls = n
+			}
+			i++
+		case "--all":
+			cfg.All = true
+		case "--follow", "-f":
+			cfg.Follow = true
+		case "--raw":
+			cfg.Raw = true
+		case "--no-color":
+			noColor = true
+		case "--json":
+			jsonOut = true
+		default:
+			return commandErrorf(jsonOut, "unknown_flag", "unknown flag: %s", args[i])
+		}
+	}
+	if session != "" {
+		cfg.SessionsRaw = strings.Trim(session+","+cfg.SessionsRaw, ",")
+	}
+	if cfg.SessionsRaw == "" && cfg.TreeRoot == "" && len(cfg.Labels) == 0 && !cfg.All {
+		return commandError(jsonOut, "missing_required_flag", "one of --session, --sessions, --tree, --label, or --all is required")
+	}
+	if len(cfg.Labels) > 0 {
+		if _, err := parseLabelSelector(cfg.Labels); err != nil {
+			return commandError(jsonOut, "invalid_label", err.Error())
+		}
+	}
+	if !cfg.Follow {
+		cfg.MaxPolls = 1
+	}
+	cfg.JSONOut = jsonOut
+	cfg.Color = !jsonOut && !noColor && os.Getenv("NO_COLOR") == "" && tailColorEnabledFn()
+	return runSessionTail(cfg)
+}
+
+func runSessionTail(cfg sessionTailConfig) int {
+	jsonOut := cfg.JSONOut
+	targets, projectRoot, err := resolveSessionTailTargets(cfg)
+	if err != nil {
+		return commandErrorf(jsonOut, "tail_target_resolve_failed", "%v", err)
+	}
+	if len(targets) == 0 && !cfg.Follow {
+		return commandError(jsonOut, "tail_no_sessions", "no sessions matched --session/--sessions/--tree/--label/--all")
+	}
+	restoreRuntime := withProjectRuntimeEnv(projectRoot)
+	defer restoreRuntime()
+	// Later polls re-resolve against the pinned root so new children and newly
+	// labeled sessions join without re-resolving ownership.
+	cfg.ProjectRoot = projectRoot
+	cfg.ProjectRootExplicit = true
+
+	cursors := map[string]*sessionTailCursor{}
+	for poll := 1; cfg.MaxPolls == 0 || poll <= cfg.MaxPolls; poll++ {
+		if poll > 1 {
+			tailSleepFn(cfg.PollInterval)
+			if refreshed, _, resolveErr := resolveSessionTailTargets(cfg); resolveErr == nil {
+				targets = refreshed
+			} else if cfg.Follow {
+				fmt.Fprintf(os.Stderr, "tail warning: %v\n", resolveErr)
+			}
+		}
+		watched := append([]string{}, targets...)
+		for session := range cursors {
+			watched = append(watched, session)
+		}
+		watched = dedupeStrings(watched)
+		sort.Strings(watched)
+
+		for _, session := range watched {
+			cursor, known := cursors[session]
+			if !tmuxHasSessionFn(session) {
+				if known {
+					delete(cursors, session)
+					emitSessionTailEvent(cfg, "left", session, "")
+				}
+				continue
+			}
+			depth := sessionTailCaptureLines(cfg.Lines)
+			history, historyOK := sessionTailHistorySize(session)
+			capture, captureErr := tmuxCapturePaneFn(session, depth)
+			if captureErr != nil {
+				continue
+			}
+			stable := sessionTailStable(sessionTailWritten(trimLines(capture)))
+			start := history - min(depth, history)
+			capture = strings.Join(trimLines(capture), "\n")
+			if !cfg.Raw {
+				capture = filterCaptureNoise(capture)
+			}
+			current := trimLines(capture)
+			if !known {
+				if cfg.JSONOut || poll > 1 {
+					emitSessionTailEvent(cfg, "joined", session, "")
+				}
+				cursor = &sessionTailCursor{}
+				cursors[session] = cursor
+				backlog := sessionTailBacklog(sessionTailWritten(current), cfg.Lines, !cfg.Follow)
+				if !cfg.Since.IsZero() && poll == 1 {
+					lastOutputAt, _ := loadCaptureLastOutputAtNanos(projectRoot, session)
+					if lastOutputAt > 0 && lastOutputAt < cfg.Since.UnixNano() {
+						backlog = nil
+					}
+				}
+				emitSessionTailLines(cfg, session, backlog)
+			} else {
+				emitSessionTailLines(cfg, session, sessionTailFilter(cfg, sessionTailAppended(cursor, stable, start, history, historyOK)))
+			}
+			sessionTailAdvance(cursor, stable, start, history, historyOK)
+			if err := updateCaptureState(projectRoot, session, capture); err != nil && cfg.Follow {
+				fmt.Fprintf(os.Stderr, "observability warning: failed to update capture state: %v\n", err)
+			}
+		}
+	}
+	return 0
+}
+
+func resolveSessionTailTargets(cfg sessionTailConfig) ([]string, string, error) {
+	sessionsRaw := cfg.SessionsRaw
+	if cfg.All {
+		projectRoot := canonicalProjectRoot(cfg.ProjectRoot)
+		restore := withProjectRuntimeEnv(projectRoot)
+		listed, err := tmuxListSessionsFn(true, projectRoot)
+		restore()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list sessions: %w", err)
+		}
+		sessionsRaw = strings.Trim(sessionsRaw+","+strings.Join(listed, ","), ",")
+	}
+	return resolveMonitorFanInTargets(monitorFanInConfig{
+		ProjectRoot:         cfg.ProjectRoot,
+		ProjectRootExplicit: cfg.ProjectRootExplicit,
+		SessionsRaw:         sessionsRaw,
+		TreeRoot:            cfg.TreeRoot,
+		Labels:              cfg.Labels,
+	})
+}
+
+// sessionTailCaptureLines sizes the pane window so a busy poll interval does
+// not scroll output past the overlap used for delta detection.
+func sessionTailCaptureLines(backlog int) int {
+	if backlog < 200 {
+		return 400
+	}
+	return backlog * 2
+}
+
+func sessionTailBacklog(lines []string, n int, includeLast bool) []string {
+	if !includeLast {
+		lines = sessionTailStable(lines)
+	}
+	if n <= 0 {
+		return nil
+	}
+	if len(lines) > n {
+		return lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// sessionTailStable drops the final pane line: agents redraw it in place
+// (prompts, spinners), so it is only emitted once later output pushes it up.
+func sessionTailStable(lines []string) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+	return lines[:len(lines)-1]
+}
+
+// sessionTailWritten drops the blank rows below the last written pane line;
+// later output fills them, so they are not part of the stream yet.
+func sessionTailWritten(lines []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[:end]
+}
+
+func sessionTailHistorySize(session string) (int, bool) {
+	out, err := tmuxDisplayFn(session, "#{history_size}")
+	if err != nil {
+		return 0, false
+	}
+	history, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil || history < 0 {
+		return 0, false
+	}
+	return history, true
+}
+
+// sessionTailAppended returns the stable lines at or past the cursor offset.
+// start is the absolute line number of stable[0]. The offset is trusted only
+// while history did not shrink (tmux trims it at history-limit) and the line
+// before it still reads the same; otherwise the capture delta re-anchors.
+func sessionTailAppended(cursor *sessionTailCursor, stable []string, start, history int, historyOK bool) []string {
+	if historyOK && cursor.Tracked && history >= cursor.History {
+		from := cursor.Offset - start
+		switch {
+		case from <= 0:
+			return stable
+		case from > len(stable):
+			return nil
+		case stable[from-1] == cursor.Last:
+			return stable[from:]
+		}
+	}
+	return sessionTailNewLines(cursor.Lines, stable)
+}
+
+func sessionTailAdvance(cursor *sessionTailCursor, stable []string, start, history int, historyOK bool) {
+	end := start + len(stable)
+	if cursor.Tracked && historyOK && history >= cursor.History && end < cursor.Offset {
+		// The pane shrank below what was already emitted: keep the offset so
+		// redrawn rows above it stay suppressed.
+		end = cursor.Offset
+	} else if len(stable) > 0 {
+		cursor.Last = stable[len(stable)-1]
+	} else {
+		cursor.Last = ""
+	}
+	cursor.Offset = end
+	cursor.History = history
+	cursor.Tracked = historyOK
+	cursor.Lines = stable
+}
+
+func sessionTailFilter(cfg sessionTailConfig, lines []string) []string {
+	if cfg.Raw || len(lines) == 0 {
+		return lines
+	}
+	return trimLines(filterCaptureNoise(strings.Join(lines, "\n")))
+}
+
+// sessionTailNewLines returns the lines of cur that follow the longest suffix
+// of prev it still contains, i.e. what scrolled in since the previous capture.
+// Without any overlap the screen was redrawn or cleared, and nothing is
+// reported as new rather than re-emitting the whole screen.
+func sessionTailNewLines(prev, cur []string) []string {
+	if len(prev) == 0 {
+		return cur
+	}
+	for shift := 0; shift < len(prev); shift++ {
+		overlap := prev[shift:]
+		if len(overlap) > len(cur) {
+			continue
+		}
+		matched := true
+		for i := range overlap {
+			if overlap[i] != cur[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return cur[len(overlap):]
+		}
+	}
+	return nil
+}
+
+func emitSessionTailLines(cfg sessionTailConfig, session string, lines []string) {
+	for _, line := range lines {
+		if cfg.Grep != nil && !cfg.Grep.MatchString(line) {
+			continue
+		}
+		emitSessionTailEvent(cfg, "line", session, line)
+	}
+}
+
+func emitSessionTailEvent(cfg sessionTailConfig, eventType, session, line string) {
+	if cfg.JSONOut {
+		payload := map[string]any{
+			"type":    eventType,
+			"session": session,
+			"at":      nowFn().UTC().Format(time.RFC3339Nano),
+		}
+		if eventType == "line" {
+			payload["line"] = line
+		}
+		writeJSON(payload)
+		return
+	}
+	prefix := session
+	if cfg.Color {
+		prefix = "\x1b[" + sessionTailColor(session) + "m" + session + "\x1b[0m"
+	}
+	switch eventType {
+	case "joined":
+		fmt.Printf("%s │ --- session joined ---\n", prefix)
+	case "left":
+		fmt.Printf("%s │ --- session ended ---\n", prefix)
+	default:
+		fmt.Printf("%s │ %s\n", prefix, redactOutputText(line))
+	}
+}
+
+func sessionTailColor(session string) string {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(session))
+	return tailColorPalette[int(hasher.Sum32()%uint32(len(tailColorPalette)))]
+}
+
+func stdoutSupportsColor() bool {
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -19,6 +19,8 @@ type sessionTreeNode struct {
 	SessionState  string            `json:"sessionState,omitempty"`
 	ProjectRoot   string            `json:"projectRoot,omitempty"`
 	CreatedAt     string            `json:"createdAt,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Orphan        bool              `json:"orphan,omitempty"`
 	Children      []sessionTreeNode `json:"children,omitempty"`
 }
 
@@ -27,12 +29,15 @@ type sessionTreeResult struct {
 	ProjectRoot       string            `json:"projectRoot"`
 	AllHashes         bool              `json:"allHashes"`
 	ActiveOnly        bool              `json:"activeOnly,omitempty"`
+	LabelSelector     string            `json:"labelSelector,omitempty"`
 	DeltaOnly         bool              `json:"delta,omitempty"`
 	Flat              bool              `json:"flat,omitempty"`
 	WithState         bool              `json:"withState,omitempty"`
 	NodeCount         int               `json:"nodeCount"`
 	TotalNodeCount    int               `json:"totalNodeCount,omitempty"`
 	FilteredNodeCount int               `json:"filteredNodeCount,omitempty"`
+	OrphanCount       int               `json:"orphanCount,omitempty"`
+	Orphans           []string          `json:"orphans,omitempty"`
 	Rows              []sessionTreeRow  `json:"rows,omitempty"`
 	Roots             []sessionTreeNode `json:"roots"`
 	Delta             *sessionTreeDelta `json:"deltaResult,omitempty"`
@@ -47,6 +52,7 @@ type sessionTreeRow struct {
 	SessionState  string `json:"sessionState,omitempty"`
 	ProjectRoot   string `json:"projectRoot,omitempty"`
 	CreatedAt     string `json:"createdAt,omitempty"`
+	Orphan        bool   `json:"orphan,omitempty"`
 }
 
 type sessionTreeDelta struct {
@@ -70,6 +76,8 @@ func cmdSessionTree(args []string) int {
 	flat := false
 	withState := false
 	cursorFile := ""
+	format := "text"
+	labelValues := []string{}
 	jsonOut := hasJSONFlag(args)
 	jsonMin := false
 
@@ -77,6 +85,12 @@ func cmdSessionTree(args []string) int {
 		switch args[i] {
 		case "--help", "-h":
 			return showHelp("session tree")
+		case "--label", "-l":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for %s", args[i])
+			}
+			labelValues = append(labelValues, args[i+1])
+			i++
 		case "--session":
 			if i+1 >= len(args) {
 				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --session")
@@ -108,6 +122,12 @@ func cmdSessionTree(args []string) int {
 			}
 			cursorFile = strings.TrimSpace(args[i+1])
 			i++
+		case "--format":
+			if i+1 >= len(args) {
+				return commandErrorf(jsonOut, "missing_flag_value", "missing value for --format")
+			}
+			format = strings.ToLower(strings.TrimSpace(args[i+1]))
+			i++
 		case "--json":
 			jsonOut = true
 		case "--json-min":
@@ -124,6 +144,18 @@ func cmdSessionTree(args []string) int {
 	if cursorFile != "" && !deltaJSON {
 		return commandError(jsonOut, "cursor_file_requires_delta_json", "--cursor-file requires --delta-json")
 	}
+	switch format {
+	case "text", "dot", "mermaid":
+	default:
+		return commandErrorf(jsonOut, "invalid_format", "invalid --format: %s (expected text|dot|mermaid)", format)
+	}
+	if format != "text" && (jsonOut || flat || delta) {
+		return commandErrorf(jsonOut, "format_mode_conflict", "--format %s cannot be combined with --json, --flat, --delta, or --delta-json", format)
+	}
+	selector, err := parseLabelSelector(labelValues)
+	if err != nil {
+		return commandError(jsonOut, "invalid_label", err.Error())
+	}
 	projectRoot = canonicalProjectRoot(projectRoot)
 	if deltaJSON {
 		if cursorFile == "" {
@@ -150,6 +182,15 @@ func cmdSessionTree(args []string) int {
 	if activeOnly {
 		metas = filterActiveTreeMetas(projectRoot, metas)
 	}
+	if len(selector) > 0 {
+		matched := metas[:0]
+		for _, meta := range metas {
+			if selector.matches(meta.Labels) {
+				matched = append(matched, meta)
+			}
+		}
+		metas = matched
+	}
 
 	nodesBySession := make(map[string]*sessionTreeNode, len(metas))
 	childrenByParent := make(map[string][]string)
@@ -166,6 +207,7 @@ func cmdSessionTree(args []string) int {
 			Mode:          strings.TrimSpace(meta.Mode),
 			ProjectRoot:   strings.TrimSpace(meta.ProjectRoot),
 			CreatedAt:     strings.TrimSpace(meta.CreatedAt),
+			Labels:        meta.Labels,
 		}
 		nodesBySession[session] = node
 		if node.ParentSession != "" && node.ParentSession != session {
@@ -175,6 +217,7 @@ func cmdSessionTree(args []string) int {
 	for parent := range childrenByParent {
 		sort.Strings(childrenByParent[parent])
 	}
+	orphans := markSessionTreeOrphans(nodesBySession)
 
 	var rootSessions []string
 	if sessionFilter != "" {
@@ -206,12 +249,15 @@ func cmdSessionTree(args []string) int {
 		ProjectRoot:       projectRoot,
 		AllHashes:         allHashes,
 		ActiveOnly:        activeOnly,
+		LabelSelector:     selector.String(),
 		DeltaOnly:         delta,
 		Flat:              flat,
 		WithState:         withState,
 		NodeCount:         nodeCount,
 		TotalNodeCount:    totalNodeCount,
 		FilteredNodeCount: filteredNodeCount,
+		OrphanCount:       len(orphans),
+		Orphans:           orphans,
 		Roots:             roots,
 	}
 	if flat || withState {
@@ -269,6 +315,9 @@ func cmdSessionTree(args []string) int {
 				"totalNodeCount":    result.TotalNodeCount,
 				"filteredNodeCount": result.FilteredNodeCount,
 			}
+			if result.OrphanCount > 0 {
+				payload["orphans"] = result.Orphans
+			}
 			if delta && result.Delta != nil {
 				payload["added"] = flattenSessionTreeRowsMin(result.Delta.Added)
 				payload["removed"] = flattenSessionTreeRowsMin(result.Delta.Removed)
@@ -300,6 +349,7 @@ func cmdSessionTree(args []string) int {
 				"nodeCount":         result.NodeCount,
 				"totalNodeCount":    result.TotalNodeCount,
 				"filteredNodeCount": result.FilteredNodeCount,
+				"orphanCount":       result.OrphanCount,
 				"delta": map[string]any{
 					"added":   deltaAdded,
 					"removed": deltaRemoved,
@@ -365,6 +415,14 @@ func cmdSessionTree(args []string) int {
 		return 0
 	}
 
+	switch format {
+	case "dot":
+		fmt.Print(renderSessionTreeDOT(result.Roots))
+		return 0
+	case "mermaid":
+		fmt.Print(renderSessionTreeMermaid(result.Roots))
+		return 0
+	}
 	for _, root := range result.Roots {
 		printSessionTreeNode(root, "")
 	}
@@ -491,7 +549,8 @@ func sessionTreeRowsEqual(a, b sessionTreeRow) bool {
 		a.Status == b.Status &&
 		a.SessionState == b.SessionState &&
 		a.ProjectRoot == b.ProjectRoot &&
-		a.CreatedAt == b.CreatedAt
+		a.CreatedAt == b.CreatedAt &&
+		a.Orphan == b.Orphan
 }
 
 func flattenSessionTreeRowsMin(rows []sessionTreeRow) []map[string]any {
@@ -509,6 +568,9 @@ func flattenSessionTreeRowsMin(rows []sessionTreeRow) []map[string]any {
 		if strings.TrimSpace(row.SessionState) != "" {
 			item["sessionState"] = row.SessionState
 		}
+		if row.Orphan {
+			item["orphan"] = true
+		}
 		out = append(out, item)
 	}
 	return out
@@ -535,6 +597,9 @@ func minimizeSessionTreeNode(node sessionTreeNode) map[string]any {
 	if strings.TrimSpace(node.SessionState) != "" {
 		item["sessionState"] = node.SessionState
 	}
+	if node.Orphan {
+		item["orphan"] = true
+	}
 	if len(node.Children) > 0 {
 		children := make([]map[string]any, 0, len(node.Children))
 		for _, child := range node.Children {
@@ -594,6 +659,25 @@ func findTreeRoots(nodesBySession map[string]*sessionTreeNode) []string {
 	return roots
 }
 
+// markSessionTreeOrphans flags nodes whose recorded parent is no longer part of
+// the tree (metadata cleaned up, or filtered out by --active-only).
+func markSessionTreeOrphans(nodesBySession map[string]*sessionTreeNode) []string {
+	orphans := make([]string, 0)
+	for session, node := range nodesBySession {
+		parent := strings.TrimSpace(node.ParentSession)
+		if parent == "" || parent == session {
+			continue
+		}
+		if _, ok := nodesBySession[parent]; ok {
+			continue
+		}
+		node.Orphan = true
+		orphans = append(orphans, session)
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
 func buildSessionTreeNode(session string, nodesBySession map[string]*sessionTreeNode, childrenByParent map[string][]string, path map[string]bool) sessionTreeNode {
 	nodePtr, ok := nodesBySession[session]
 	if !ok {
@@ -616,10 +700,17 @@ func buildSessionTreeNode(session string, nodesBySession map[string]*sessionTree
 
 func printSessionTreeNode(node sessionTreeNode, indent string) {
 	descriptor := strings.TrimSpace(node.Agent + "/" + node.Mode)
+	suffix := ""
+	if len(node.Labels) > 0 {
+		suffix += " {" + formatSessionLabels(node.Labels) + "}"
+	}
+	if node.Orphan {
+		suffix += " [orphan: parent " + node.ParentSession + " gone]"
+	}
 	if descriptor == "/" || descriptor == "" {
-		fmt.Printf("%s%s\n", indent, node.Session)
+		fmt.Printf("%s%s%s\n", indent, node.Session, suffix)
 	} else {
-		fmt.Printf("%s%s (%s)\n", indent, node.Session, descriptor)
+		fmt.Printf("%s%s (%s)%s\n", indent, node.Session, descriptor, suffix)
 	}
 	for _, child := range node.Children {
 		printSessionTreeNode(child, indent+"  ")
@@ -639,6 +730,7 @@ func flattenSessionTreeRows(roots []sessionTreeNode) []sessionTreeRow {
 			SessionState:  node.SessionState,
 			ProjectRoot:   node.ProjectRoot,
 			CreatedAt:     node.CreatedAt,
+			Orphan:        node.Orphan,
 		})
 		for _, child := range node.Children {
 			walk(child)
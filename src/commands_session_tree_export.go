@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sessionTreeGraphLabel is shared by the DOT and Mermaid exporters so both
+// formats describe nodes identically.
+func sessionTreeGraphLabel(node sessionTreeNode) []string {
+	lines := []string{node.Session}
+	descriptor := strings.Trim(strings.TrimSpace(node.Agent+"/"+node.Mode), "/")
+	if descriptor != "" {
+		lines = append(lines, descriptor)
+	}
+	if strings.TrimSpace(node.SessionState) != "" {
+		lines = append(lines, node.SessionState)
+	}
+	return lines
+}
+
+func walkSessionTreeNodes(roots []sessionTreeNode, fn func(node sessionTreeNode)) {
+	var walk func(node sessionTreeNode)
+	walk = func(node sessionTreeNode) {
+		fn(node)
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+}
+
+func renderSessionTreeDOT(roots []sessionTreeNode) string {
+	// Backslashes are escaped first so a trailing one cannot swallow the
+	// closing quote; labels join escaped lines with DOT's \n.
+	escape := func(value string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), `"`, `\"`)
+	}
+	quote := func(value string) string {
+		return `"` + escape(value) + `"`
+	}
+	var b strings.Builder
+	b.WriteString("digraph lisa_sessions {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	goneParents := map[string]bool{}
+	walkSessionTreeNodes(roots, func(node sessionTreeNode) {
+		lines := sessionTreeGraphLabel(node)
+		for i, line := range lines {
+			lines[i] = escape(line)
+		}
+		fmt.Fprintf(&b, "  %s [label=\"%s\"];\n", quote(node.Session), strings.Join(lines, `\n`))
+		if node.Orphan && !goneParents[node.ParentSession] {
+			goneParents[node.ParentSession] = true
+			fmt.Fprintf(&b, "  %s [label=\"%s\\n(gone)\", style=dashed];\n", quote(node.ParentSession), escape(node.ParentSession))
+		}
+	})
+	walkSessionTreeNodes(roots, func(node sessionTreeNode) {
+		if node.Orphan {
+			fmt.Fprintf(&b, "  %s -> %s [style=dashed];\n", quote(node.ParentSession), quote(node.Session))
+		}
+		for _, child := range node.Children {
+			fmt.Fprintf(&b, "  %s -> %s;\n", quote(node.Session), quote(child.Session))
+		}
+	})
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func renderSessionTreeMermaid(roots []sessionTreeNode) string {
+	ids := map[string]string{}
+	nodeID := func(session string) string {
+		if id, ok := ids[session]; ok {
+			return id
+		}
+		id := fmt.Sprintf("n%d", len(ids))
+		ids[session] = id
+		return id
+	}
+	escape := func(value string) string {
+		return strings.ReplaceAll(value, `"`, "#quot;")
+	}
+	var b strings.Builder
+	b.WriteString("graph TD\n")
+	goneParents := map[string]bool{}
+	walkSessionTreeNodes(roots, func(node sessionTreeNode) {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", nodeID(node.Session), escape(strings.Join(sessionTreeGraphLabel(node), "<br/>")))
+		if node.Orphan && !goneParents[node.ParentSession] {
+			goneParents[node.ParentSession] = true
+			fmt.Fprintf(&b, "  %s[\"%s<br/>(gone)\"]:::gone\n", nodeID(node.ParentSession), escape(node.ParentSession))
+		}
+	})
+	walkSessionTreeNodes(roots, func(node sessionTreeNode) {
+		if node.Orphan {
+			fmt.Fprintf(&b, "  %s -.-> %s\n", nodeID(node.ParentSession), nodeID(node.Session))
+		}
+		for _, child := range node.Children {
+			fmt.Fprintf(&b, "  %s --> %s\n", nodeID(node.Session), nodeID(child.Session))
+		}
+	})
+	if len(goneParents) > 0 {
+		b.WriteString("  classDef gone stroke-dasharray: 5 5\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestE2EFakeAgentCodexExecSpawnMonitorCapture(t *testing.T) {
+	requireGoAndTmux(t)
+
+	repoRoot := findRepoRoot(t)
+	binPath := filepath.Join(t.TempDir(), "lisa")
+	runAndRequireSuccess(t, repoRoot, nil, "go", "build", "-o", binPath, ".")
+
+	home := t.TempDir()
+	projectRoot := t.TempDir()
+	shimDir := filepath.Join(t.TempDir(), "bin")
+	scenario := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(scenario, []byte(`{"steps":[
+  {"action":"think","seconds":0.2},
+  {"action":"tool","tool":"shell","input":{"command":["ls"]},"output":"main.go"},
+  {"action":"say","text":"FAKE_AGENT_DONE"}
+]}`), 0o600); err != nil {
+		t.Fatalf("write scenario failed: %v", err)
+	}
+	runAndRequireSuccess(t, repoRoot, nil, binPath, "fake-agent", "install", "--dir", shimDir, "--script", scenario, "--json")
+	env := []string{"HOME=" + home, "PATH=" + shimDir + string(os.PathListSeparator) + os.Getenv("PATH")}
+
+	session := fmt.Sprintf("lisa-e2e-fake-agent-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		_, _ = runCommand(repoRoot, env, binPath, "session", "kill", "--session", session, "--project-root", projectRoot)
+	})
+	runAndRequireSuccess(t, repoRoot, env,
+		binPath, "session", "spawn",
+		"--agent", "codex",
+		"--mode", "exec",
+		"--project-root", projectRoot,
+		"--session", session,
+		"--prompt", "list the files",
+		"--json",
+	)
+
+	monitorRaw := runAndRequireSuccess(t, repoRoot, env,
+		binPath, "session", "monitor",
+		"--session", session,
+		"--project-root", projectRoot,
+		"--poll-interval", "1",
+		"--max-polls", "30",
+		"--json",
+	)
+	var monitor struct {
+		FinalState string `json:"finalState"`
+	}
+	if err := json.Unmarshal([]byte(monitorRaw), &monitor); err != nil {
+		t.Fatalf("failed to parse monitor json: %v (%q)", err, monitorRaw)
+	}
+	if monitor.FinalState != "completed" {
+		t.Fatalf("expected completed, got %s (%s)", monitor.FinalState, monitorRaw)
+	}
+
+	captureRaw := runAndRequireSuccess(t, repoRoot, env,
+		binPath, "session", "capture",
+		"--session", session,
+		"--project-root", projectRoot,
+		"--format", "markdown",
+		"--json",
+	)
+	var capture struct {
+		CodexSession string `json:"codexSession"`
+		Capture      string `json:"capture"`
+	}
+	if err := json.Unmarshal([]byte(captureRaw), &capture); err != nil {
+		t.Fatalf("failed to parse capture json: %v (%q)", err, captureRaw)
+	}
+	if capture.CodexSession == "" || !strings.Contains(capture.Capture, "Tool call `shell`") || !strings.Contains(capture.Capture, "FAKE_AGENT_DONE") {
+		t.Fatalf("unexpected transcript capture: %s", captureRaw)
+	}
+}
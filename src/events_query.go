@@ -0,0 +1,303 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var eventWhereTermRe = regexp.MustCompile(`^(!?)\s*([A-Za-z0-9_$.]+)\s*(?:(==|!=|>=|<=|!~|=|~|>|<)\s*(.*))?$`)
+
+// eventWhereTerm is one comparison of a --where expression: a dotted path
+// into the event JSON, an optional operator and a literal. A bare path
+// tests truthiness; a leading ! negates it.
+type eventWhereTerm struct {
+	Path   []string
+	Negate bool
+	Op     string
+	Value  string
+	Regexp *regexp.Regexp
+}
+
+// eventWhereExpr is a disjunction of conjunctions: `a && b || c`.
+// There are no parentheses; && binds tighter than ||.
+type eventWhereExpr [][]eventWhereTerm
+
+func parseEventWhereExpr(raw string) (eventWhereExpr, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("invalid --where: expression cannot be empty")
+	}
+	expr := eventWhereExpr{}
+	clauses, err := splitEventWhere(raw, "||")
+	if err != nil {
+		return nil, err
+	}
+	for _, clause := range clauses {
+		rawTerms, err := splitEventWhere(clause, "&&")
+		if err != nil {
+			return nil, err
+		}
+		terms := []eventWhereTerm{}
+		for _, rawTerm := range rawTerms {
+			term, err := parseEventWhereTerm(strings.TrimSpace(rawTerm))
+			if err != nil {
+				return nil, err
+			}
+			terms = append(terms, term)
+		}
+		expr = append(expr, terms)
+	}
+	return expr, nil
+}
+
+// splitEventWhere splits raw on sep outside quoted values, so a quoted
+// regexp like reason~"a||b" stays one term. A quote opens a value only
+// right after an operator; inside it a backslash escapes the next character.
+func splitEventWhere(raw, sep string) ([]string, error) {
+	parts := []string{}
+	quote := byte(0)
+	start := 0
+	afterOp := false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+			continue
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case afterOp && (c == '"' || c == '\''):
+			quote = c
+		case strings.HasPrefix(raw[i:], sep):
+			parts = append(parts, raw[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+		if c != ' ' && c != '\t' {
+			afterOp = strings.IndexByte("=~<>", c) >= 0
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("invalid --where: unterminated %c quote in %q", quote, raw)
+	}
+	return append(parts, raw[start:]), nil
+}
+
+// unquoteEventWhereValue strips one pair of matching quotes; a quoted value
+// keeps its inner text as written apart from escaped quotes.
+func unquoteEventWhereValue(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') && raw[len(raw)-1] == raw[0] {
+		quote := string(raw[0])
+		return strings.ReplaceAll(raw[1:len(raw)-1], `\`+quote, quote)
+	}
+	return raw
+}
+
+func parseEventWhereTerm(raw string) (eventWhereTerm, error) {
+	match := eventWhereTermRe.FindStringSubmatch(raw)
+	if match == nil {
+		return eventWhereTerm{}, fmt.Errorf("invalid --where term: %q (expected path, !path or path OP value)", raw)
+	}
+	pathPart := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(match[2], "$."), "$"), ".")
+	term := eventWhereTerm{Negate: match[1] == "!", Op: match[3], Value: unquoteEventWhereValue(match[4])}
+	for _, segment := range strings.Split(pathPart, ".") {
+		if segment == "" {
+			return eventWhereTerm{}, fmt.Errorf("invalid --where path in %q", raw)
+		}
+		term.Path = append(term.Path, segment)
+	}
+	if term.Negate && term.Op != "" {
+		return eventWhereTerm{}, fmt.Errorf("invalid --where term: %q (use != or !~ to negate a comparison)", raw)
+	}
+	if term.Op != "" && term.Value == "" && term.Op != "=" && term.Op != "==" && term.Op != "!=" {
+		return eventWhereTerm{}, fmt.Errorf("invalid --where term: %q (missing value)", raw)
+	}
+	if term.Op == "~" || term.Op == "!~" {
+		re, err := regexp.Compile(term.Value)
+		if err != nil {
+			return eventWhereTerm{}, fmt.Errorf("invalid --where regexp %q: %v", term.Value, err)
+		}
+		term.Regexp = re
+	}
+	return term, nil
+}
+
+func (e eventWhereExpr) matches(payload map[string]any) bool {
+	for _, clause := range e {
+		ok := true
+		for _, term := range clause {
+			if !term.matches(payload) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (t eventWhereTerm) matches(payload map[string]any) bool {
+	current := any(payload)
+	found := true
+	for _, segment := range t.Path {
+		node, ok := current.(map[string]any)
+		if !ok {
+			found = false
+			break
+		}
+		if current, ok = node[segment]; !ok {
+			found = false
+			break
+		}
+	}
+	if t.Op == "" {
+		return (found && isTruthyJSONValue(current)) != t.Negate
+	}
+	if !found {
+		return t.Op == "!=" || t.Op == "!~"
+	}
+	text := fmt.Sprintf("%v", current)
+	if s, ok := current.(string); ok {
+		text = s
+	}
+	switch t.Op {
+	case "=", "==":
+		return compareJSONPathExpected(current, t.Value)
+	case "!=":
+		return !compareJSONPathExpected(current, t.Value)
+	case "~":
+		return t.Regexp.MatchString(text)
+	case "!~":
+		return !t.Regexp.MatchString(text)
+	}
+	// Ordering compares numbers numerically and everything else (including
+	// RFC3339 timestamps) as strings.
+	cmp := strings.Compare(text, t.Value)
+	if n, ok := current.(float64); ok {
+		if want, err := strconv.ParseFloat(t.Value, 64); err == nil {
+			switch {
+			case n < want:
+				cmp = -1
+			case n > want:
+				cmp = 1
+			default:
+				cmp = 0
+			}
+		}
+	}
+	switch t.Op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+type eventQuery struct {
+	SessionGlob string
+	Types       []string
+	States      []string
+	Reasons     []string
+	Since       time.Time
+	Until       time.Time
+	Where       []eventWhereExpr
+}
+
+// matchEventGlobs reports whether value matches any glob; an empty list
+// matches everything.
+func matchEventGlobs(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (q eventQuery) matches(event sessionEvent) bool {
+	if q.SessionGlob != "" && !matchEventGlobs([]string{q.SessionGlob}, event.Session) {
+		return false
+	}
+	if !matchEventGlobs(q.Types, event.Type) || !matchEventGlobs(q.States, event.State) || !matchEventGlobs(q.Reasons, event.Reason) {
+		return false
+	}
+	if !q.Since.IsZero() || !q.Until.IsZero() {
+		ts, ok := parseEventTimestamp(event.At)
+		if !ok {
+			return false
+		}
+		if !q.Since.IsZero() && ts < q.Since.UnixNano() {
+			return false
+		}
+		if !q.Until.IsZero() && ts > q.Until.UnixNano() {
+			return false
+		}
+	}
+	if len(q.Where) == 0 {
+		return true
+	}
+	payload := map[string]any{}
+	raw, err := json.Marshal(event)
+	if err != nil || json.Unmarshal(raw, &payload) != nil {
+		return false
+	}
+	for _, expr := range q.Where {
+		if !expr.matches(payload) {
+			return false
+		}
+	}
+	return true
+}
+
+func eventGroupKey(event sessionEvent, groupBy string) string {
+	switch groupBy {
+	case "reason":
+		return event.Reason
+	case "state":
+		return event.State
+	case "type":
+		return event.Type
+	default:
+		return event.Session
+	}
+}
+
+// sortSessionEventsByTime orders events from several logs by timestamp,
+// keeping log order for ties; unparseable timestamps sort first.
+func sortSessionEventsByTime(events []sessionEvent) {
+	keys := make([]int64, len(events))
+	for i, event := range events {
+		keys[i], _ = parseEventTimestamp(event.At)
+	}
+	sort.Stable(sessionEventsByTime{events: events, keys: keys})
+}
+
+type sessionEventsByTime struct {
+	events []sessionEvent
+	keys   []int64
+}
+
+func (s sessionEventsByTime) Len() int           { return len(s.events) }
+func (s sessionEventsByTime) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s sessionEventsByTime) Swap(i, j int) {
+	s.events[i], s.events[j] = s.events[j], s.events[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEventWhereExpr(t *testing.T) {
+	event := sessionEvent{At: "2026-10-19T10:00:00Z", Type: "poll", State: "stuck", Reason: "monitor_stuck", Poll: 5, Signals: statusSignals{PromptWaiting: true}}
+	raw, _ := json.Marshal(event)
+	payload := map[string]any{}
+	_ = json.Unmarshal(raw, &payload)
+
+	cases := map[string]bool{
+		"signals.promptWaiting":                    true,
+		"!signals.promptWaiting":                   false,
+		"poll > 3 && state = stuck":                true,
+		"poll >= 6 || reason ~ ^monitor_":          true,
+		"reason !~ stuck":                          false,
+		"state != 'stuck'":                         false,
+		"at < 2026-10-19T11:00:00Z":                true,
+		"missing.path = x":                         false,
+		"missing.path != x":                        true,
+		"type = poll && poll < 5 || state = stuck": true,
+		`reason ~ "^(idle||monitor_stuck)$"`:       true,
+		`reason ~ 'x&&y' || state = "stuck"`:       true,
+		`reason = "monitor_stuck" && type = poll`:  true,
+		"reason = it's":                            false,
+	}
+	for rawExpr, want := range cases {
+		expr, err := parseEventWhereExpr(rawExpr)
+		if err != nil {
+			t.Fatalf("parse %q failed: %v", rawExpr, err)
+		}
+		if got := expr.matches(payload); got != want {
+			t.Fatalf("%q: expected %v, got %v", rawExpr, want, got)
+		}
+	}
+	for _, bad := range []string{"", "poll >", "!poll = 3", "reason ~ (", `reason ~ "a||b`} {
+		if _, err := parseEventWhereExpr(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestEventsQueryFiltersAndGroupsAcrossSessions(t *testing.T) {
+	t.Setenv("LISA_EVENTS_MAX_LINES", "2")
+	root := canonicalProjectRoot(t.TempDir())
+	seed := map[string][]sessionEvent{
+		"lisa-q-api-1": {
+			{At: "2026-10-19T09:00:00Z", Type: "poll", State: "in_progress", Reason: "agent_pid_alive"},
+			{At: "2026-10-19T09:05:00Z", Type: "poll", State: "stuck", Reason: "stuck_no_signals", Poll: 4},
+			{At: "2026-10-19T09:06:00Z", Type: "lifecycle", State: "in_progress", Reason: "monitor_auto_recover"},
+			{At: "2026-10-19T09:10:00Z", Type: "lifecycle", State: "completed", Reason: "kill_success"},
+		},
+		"lisa-q-api-2": {
+			{At: "2026-10-19T09:20:00Z", Type: "poll", State: "stuck", Reason: "stuck_no_signals", Poll: 2},
+		},
+		"lisa-q-web": {
+			{At: "2026-10-19T09:30:00Z", Type: "poll", State: "stuck", Reason: "stuck_no_signals", Poll: 9},
+		},
+	}
+	for session, events := range seed {
+		path := sessionEventsFile(root, session)
+		t.Cleanup(func() {
+			_ = os.Remove(path)
+			_ = os.Remove(sessionEventCountFile(path))
+			_ = removeSessionEventSegments(path)
+		})
+		for _, event := range events {
+			event.Session = session
+			if err := appendSessionEvent(root, session, event); err != nil {
+				t.Fatalf("append failed: %v", err)
+			}
+		}
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdEventsQuery([]string{"--project-root", root, "--session-glob", "lisa-q-api-*", "--state", "stuck", "--format", "ndjson"}); code != 0 {
+			t.Fatalf("query failed: %d", code)
+		}
+	})
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"session":"lisa-q-api-1"`) {
+		t.Fatalf("expected two stuck api events (one from a rotated segment), got %q", stdout)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		args := []string{"--project-root", root, "--reason", "stuck_*", "--where", "poll > 3", "--since", "2026-10-19T09:00:00Z", "--format", "csv"}
+		if code := cmdEventsQuery(args); code != 0 {
+			t.Fatalf("csv query failed: %d", code)
+		}
+	})
+	if !strings.HasPrefix(stdout, "at,type,session,state,status,reason,poll\n") || len(strings.Split(strings.TrimSpace(stdout), "\n")) != 3 {
+		t.Fatalf("unexpected csv output: %q", stdout)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdEventsQuery([]string{"--project-root", root, "--group-by", "reason", "--until", "2026-10-19T09:25:00Z", "--json"}); code != 0 {
+			t.Fatalf("group query failed: %d", code)
+		}
+	})
+	payload := struct {
+		Count  int           `json:"count"`
+		Groups []reportCount `json:"groups"`
+	}{}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("invalid JSON: %v (%q)", err, stdout)
+	}
+	if payload.Count != 5 || len(payload.Groups) != 4 || payload.Groups[0].Name != "stuck_no_signals" || payload.Groups[0].Count != 2 {
+		t.Fatalf("unexpected groups: %+v", payload)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdEventsQuery([]string{"--project-root", root, "--state", "stuck", "--limit", "2", "--format", "ndjson"}); code != 0 {
+			t.Fatalf("limit query failed: %d", code)
+		}
+	})
+	lines = strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"at":"2026-10-19T09:05:00Z"`) || !strings.Contains(lines[1], `"at":"2026-10-19T09:20:00Z"`) {
+		t.Fatalf("expected --limit to keep the earliest events across sessions, got %q", stdout)
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := cmdEventsQuery([]string{"--project-root", root, "--group-by", "lane"}); code == 0 {
+			t.Fatalf("expected invalid --group-by to fail")
+		}
+	})
+}
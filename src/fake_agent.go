@@ -0,0 +1,486 @@
+package app
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const fakeAgentScriptEnv = "LISA_FAKE_AGENT_SCRIPT"
+
+var fakeAgentSleepFn = time.Sleep
+
+// fakeAgentScenario scripts a fake claude/codex run. Steps execute in order
+// after the initial prompt; once exhausted, exec mode exits 0 and interactive
+// mode idles at the prompt answering each input with Reply.
+type fakeAgentScenario struct {
+	Persona   string          `json:"persona,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Reply     string          `json:"reply,omitempty"`
+	Steps     []fakeAgentStep `json:"steps"`
+}
+
+type fakeAgentStep struct {
+	Action   string          `json:"action"`
+	Seconds  float64         `json:"seconds,omitempty"`
+	Text     string          `json:"text,omitempty"`
+	Tool     string          `json:"tool,omitempty"`
+	Input    json.RawMessage `json:"input,omitempty"`
+	Output   string          `json:"output,omitempty"`
+	ExitCode int             `json:"exitCode,omitempty"`
+}
+
+var fakeAgentActions = map[string]bool{
+	"think": true, "say": true, "tool": true, "wait_input": true, "hang": true, "crash": true, "exit": true,
+}
+
+func loadFakeAgentScenario(path string) (fakeAgentScenario, error) {
+	scenario := fakeAgentScenario{}
+	if strings.TrimSpace(path) == "" {
+		return scenario, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return scenario, err
+	}
+	if err := json.Unmarshal(raw, &scenario); err != nil {
+		return scenario, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	for i, step := range scenario.Steps {
+		if !fakeAgentActions[step.Action] {
+			return scenario, fmt.Errorf("steps[%d]: unsupported action %q (expected think|say|tool|wait_input|hang|crash|exit)", i, step.Action)
+		}
+		if step.Seconds < 0 {
+			return scenario, fmt.Errorf("steps[%d]: seconds must be >= 0", i)
+		}
+		if step.Action == "tool" && strings.TrimSpace(step.Tool) == "" {
+			return scenario, fmt.Errorf("steps[%d]: tool action requires tool", i)
+		}
+	}
+	return scenario, nil
+}
+
+// fakeAgentInvocation is what the fake learned from the real agent CLI argv.
+type fakeAgentInvocation struct {
+	Mode   string
+	Prompt string
+}
+
+// parseFakeAgentArgs reads the argv lisa builds for claude/codex (see
+// buildAgentCommand); unknown flags are ignored like a tolerant CLI would.
+func parseFakeAgentArgs(persona string, args []string) fakeAgentInvocation {
+	inv := fakeAgentInvocation{Mode: "interactive"}
+	valueFlags := map[string]bool{
+		"--model": true, "--output-format": true, "--permission-mode": true, "--append-system-prompt": true,
+		"--allowedTools": true, "--session-id": true, "--resume": true,
+	}
+	if persona == "codex" {
+		valueFlags = map[string]bool{
+			"-m": true, "--model": true, "-c": true, "--config": true, "-C": true, "--cd": true,
+			"-s": true, "--sandbox": true, "-a": true, "--ask-for-approval": true, "-p": true, "--profile": true,
+		}
+	}
+	positional := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case persona == "claude" && (arg == "-p" || arg == "--print"):
+			inv.Mode = "exec"
+		case valueFlags[arg]:
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if persona == "codex" && len(positional) > 0 && positional[0] == "exec" {
+		inv.Mode = "exec"
+		positional = positional[1:]
+	}
+	inv.Prompt = strings.Join(positional, " ")
+	return inv
+}
+
+type fakeAgent struct {
+	persona        string
+	mode           string
+	sessionID      string
+	cwd            string
+	home           string
+	transcriptPath string
+	reply          string
+	out            io.Writer
+	errOut         io.Writer
+	in             *bufio.Reader
+	callSeq        int
+}
+
+func newFakeAgentSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%032x", nowFn().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// runFakeAgent plays a scenario and returns the process exit code.
+func runFakeAgent(persona string, scenario fakeAgentScenario, inv fakeAgentInvocation, in io.Reader, out, errOut io.Writer) (int, error) {
+	home, err := osUserHomeDirFn()
+	if err != nil {
+		return 1, fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return 1, err
+	}
+	agent := &fakeAgent{
+		persona:   persona,
+		mode:      inv.Mode,
+		sessionID: strings.TrimSpace(scenario.SessionID),
+		cwd:       cwd,
+		home:      home,
+		reply:     scenario.Reply,
+		out:       out,
+		errOut:    errOut,
+		in:        bufio.NewReader(in),
+	}
+	if agent.sessionID == "" {
+		agent.sessionID = newFakeAgentSessionID()
+	}
+	if agent.reply == "" {
+		agent.reply = "ok"
+	}
+	if err := agent.openTranscript(); err != nil {
+		return 1, err
+	}
+	agent.renderBanner()
+	if strings.TrimSpace(inv.Prompt) != "" {
+		if err := agent.userTurn(inv.Prompt, true); err != nil {
+			return 1, err
+		}
+		if len(scenario.Steps) == 0 {
+			if err := agent.say(agent.reply); err != nil {
+				return 1, err
+			}
+		}
+	}
+
+	for _, step := range scenario.Steps {
+		switch step.Action {
+		case "think":
+			agent.renderThinking()
+			fakeAgentSleepFn(time.Duration(step.Seconds * float64(time.Second)))
+		case "say":
+			if err := agent.say(step.Text); err != nil {
+				return 1, err
+			}
+		case "tool":
+			if err := agent.tool(step); err != nil {
+				return 1, err
+			}
+		case "wait_input":
+			line, ok := agent.readInput()
+			if !ok {
+				return 0, nil
+			}
+			if err := agent.userTurn(line, false); err != nil {
+				return 1, err
+			}
+		case "hang":
+			agent.renderThinking()
+			if step.Seconds > 0 {
+				fakeAgentSleepFn(time.Duration(step.Seconds * float64(time.Second)))
+				continue
+			}
+			for {
+				fakeAgentSleepFn(time.Hour)
+			}
+		case "crash":
+			code := step.ExitCode
+			if code == 0 {
+				code = 1
+			}
+			text := step.Text
+			if text == "" {
+				text = fmt.Sprintf("fatal: simulated crash (exit %d)", code)
+			}
+			fmt.Fprintln(agent.errOut, text)
+			return code, nil
+		case "exit":
+			if step.Text != "" {
+				fmt.Fprintln(agent.out, step.Text)
+			}
+			return step.ExitCode, nil
+		}
+	}
+
+	if agent.mode == "exec" {
+		return 0, nil
+	}
+	for {
+		line, ok := agent.readInput()
+		if !ok {
+			return 0, nil
+		}
+		if strings.TrimSpace(line) == "/exit" {
+			return 0, nil
+		}
+		if err := agent.userTurn(line, false); err != nil {
+			return 1, err
+		}
+		if err := agent.say(agent.reply); err != nil {
+			return 1, err
+		}
+	}
+}
+
+func (a *fakeAgent) timestamp() string {
+	return nowFn().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func (a *fakeAgent) openTranscript() error {
+	now := nowFn()
+	if a.persona == "codex" {
+		dir := filepath.Join(a.home, ".codex", "sessions", now.Format("2006"), now.Format("01"), now.Format("02"))
+		a.transcriptPath = filepath.Join(dir, fmt.Sprintf("rollout-%s-%s.jsonl", now.Format("2006-01-02T15-04-05"), a.sessionID))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		return a.appendTranscript(map[string]any{
+			"timestamp": a.timestamp(),
+			"type":      "session_meta",
+			"payload": map[string]any{
+				"id":          a.sessionID,
+				"timestamp":   a.timestamp(),
+				"cwd":         a.cwd,
+				"originator":  "lisa_fake_agent",
+				"cli_version": "fake",
+			},
+		})
+	}
+	dir := claudeProjectDirForHome(a.home, a.cwd)
+	a.transcriptPath = filepath.Join(dir, a.sessionID+".jsonl")
+	return os.MkdirAll(dir, 0o755)
+}
+
+func claudeProjectDirForHome(home, projectRoot string) string {
+	return filepath.Join(home, ".claude", "projects", encodeClaudePath(projectRoot))
+}
+
+func (a *fakeAgent) appendTranscript(entries ...map[string]any) error {
+	return appendJSONLines(a.transcriptPath, entries...)
+}
+
+func appendJSONLines(path string, entries ...map[string]any) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *fakeAgent) claudeEntry(entryType string, content any) map[string]any {
+	return map[string]any{
+		"type":      entryType,
+		"sessionId": a.sessionID,
+		"timestamp": a.timestamp(),
+		"cwd":       a.cwd,
+		"message":   map[string]any{"role": entryType, "content": content},
+	}
+}
+
+func (a *fakeAgent) codexEntry(entryType string, payload map[string]any) map[string]any {
+	return map[string]any{"timestamp": a.timestamp(), "type": entryType, "payload": payload}
+}
+
+// userTurn records a user message; echo renders it for argv prompts, while
+// typed input has already been echoed by the terminal.
+func (a *fakeAgent) userTurn(text string, echo bool) error {
+	if echo {
+		a.renderUser(text)
+	}
+	if a.persona == "codex" {
+		if err := appendJSONLines(filepath.Join(a.home, ".codex", "history.jsonl"), map[string]any{
+			"session_id": a.sessionID,
+			"ts":         nowFn().Unix(),
+			"text":       text,
+		}); err != nil {
+			return err
+		}
+		return a.appendTranscript(
+			a.codexEntry("response_item", map[string]any{
+				"type":    "message",
+				"role":    "user",
+				"content": []map[string]any{{"type": "input_text", "text": text}},
+			}),
+			a.codexEntry("event_msg", map[string]any{"type": "user_message", "message": text}),
+			a.codexEntry("event_msg", map[string]any{"type": "task_started"}),
+		)
+	}
+	if err := os.MkdirAll(filepath.Join(a.home, ".claude"), 0o755); err != nil {
+		return err
+	}
+	if err := appendJSONLines(filepath.Join(a.home, ".claude", "history.jsonl"), map[string]any{
+		"display":   text,
+		"timestamp": nowFn().UnixMilli(),
+		"project":   a.cwd,
+		"sessionId": a.sessionID,
+	}); err != nil {
+		return err
+	}
+	return a.appendTranscript(a.claudeEntry("user", text))
+}
+
+func (a *fakeAgent) say(text string) error {
+	a.renderAssistant(text)
+	if a.persona == "codex" {
+		return a.appendTranscript(
+			a.codexEntry("response_item", map[string]any{
+				"type":    "message",
+				"role":    "assistant",
+				"content": []map[string]any{{"type": "output_text", "text": text}},
+			}),
+			a.codexEntry("event_msg", map[string]any{"type": "agent_message", "message": text}),
+			a.codexEntry("event_msg", map[string]any{"type": "task_complete"}),
+		)
+	}
+	return a.appendTranscript(a.claudeEntry("assistant", []map[string]any{{"type": "text", "text": text}}))
+}
+
+func (a *fakeAgent) tool(step fakeAgentStep) error {
+	a.callSeq++
+	input := step.Input
+	if len(input) == 0 {
+		input = json.RawMessage(`{}`)
+	}
+	a.renderTool(step.Tool, compactTranscriptJSON(input), step.Output)
+	if a.persona == "codex" {
+		callID := fmt.Sprintf("call_%d", a.callSeq)
+		return a.appendTranscript(
+			a.codexEntry("response_item", map[string]any{
+				"type":      "function_call",
+				"name":      step.Tool,
+				"arguments": string(input),
+				"call_id":   callID,
+			}),
+			a.codexEntry("response_item", map[string]any{
+				"type":    "function_call_output",
+				"call_id": callID,
+				"output":  step.Output,
+			}),
+		)
+	}
+	callID := fmt.Sprintf("toolu_fake_%d", a.callSeq)
+	return a.appendTranscript(
+		a.claudeEntry("assistant", []map[string]any{{"type": "tool_use", "id": callID, "name": step.Tool, "input": input}}),
+		a.claudeEntry("user", []map[string]any{{"type": "tool_result", "tool_use_id": callID, "content": step.Output}}),
+	)
+}
+
+func (a *fakeAgent) readInput() (string, bool) {
+	a.renderPrompt()
+	line, err := a.in.ReadString('\n')
+	line = strings.TrimRight(line, "\r\n")
+	if err != nil && line == "" {
+		return "", false
+	}
+	return line, true
+}
+
+func (a *fakeAgent) renderBanner() {
+	if a.mode == "exec" {
+		return
+	}
+	if a.persona == "codex" {
+		fmt.Fprintf(a.out, ">_ OpenAI Codex (lisa fake-agent)\n\n  directory: %s\n  session:   %s\n\n", a.cwd, a.sessionID)
+		return
+	}
+	fmt.Fprintf(a.out, "╭───────────────────────────────────────────╮\n")
+	fmt.Fprintf(a.out, "│ ✻ Welcome to Claude Code (lisa fake-agent) │\n")
+	fmt.Fprintf(a.out, "│   cwd: %s\n", a.cwd)
+	fmt.Fprintf(a.out, "╰───────────────────────────────────────────╯\n\n")
+}
+
+func (a *fakeAgent) renderUser(text string) {
+	if a.mode == "exec" {
+		return
+	}
+	if a.persona == "codex" {
+		fmt.Fprintf(a.out, "user\n%s\n\n", text)
+		return
+	}
+	fmt.Fprintf(a.out, "> %s\n\n", text)
+}
+
+func (a *fakeAgent) renderThinking() {
+	if a.mode == "exec" {
+		return
+	}
+	if a.persona == "codex" {
+		fmt.Fprintln(a.out, "• Working (esc to interrupt)")
+		return
+	}
+	fmt.Fprintln(a.out, "✻ Thinking… (esc to interrupt)")
+}
+
+func (a *fakeAgent) renderAssistant(text string) {
+	switch {
+	case a.mode == "exec":
+		fmt.Fprintln(a.out, text)
+	case a.persona == "codex":
+		fmt.Fprintf(a.out, "codex\n%s\n\n", text)
+	default:
+		fmt.Fprintf(a.out, "● %s\n\n", text)
+	}
+}
+
+func (a *fakeAgent) renderTool(tool, input, output string) {
+	if a.mode == "exec" && a.persona == "claude" {
+		return
+	}
+	if a.persona == "codex" {
+		fmt.Fprintf(a.out, "• Ran %s %s\n  └ %s\n\n", tool, input, output)
+		return
+	}
+	fmt.Fprintf(a.out, "● %s(%s)\n  ⎿  %s\n\n", tool, input, output)
+}
+
+func (a *fakeAgent) renderPrompt() {
+	if a.persona == "codex" {
+		fmt.Fprint(a.out, "▌ ")
+		return
+	}
+	fmt.Fprintln(a.out, "╭───────────────────────────────────────────╮")
+	fmt.Fprintln(a.out, "│ >                                         │")
+	fmt.Fprintln(a.out, "╰───────────────────────────────────────────╯")
+	fmt.Fprint(a.out, "> ")
+}
+
+// fakeAgentShimScript is installed as `claude`/`codex`; `exec -a` keeps the
+// agent name in argv[0] so lisa's process detection sees a real agent.
+func fakeAgentShimScript(lisaPath, persona, scriptPath string) string {
+	scriptArgs := ""
+	if scriptPath != "" {
+		scriptArgs = " --script " + shellQuote(scriptPath)
+	}
+	return fmt.Sprintf("#!/usr/bin/env bash\nexec -a %s %s fake-agent --persona %s%s -- \"$@\"\n",
+		persona, shellQuote(lisaPath), persona, scriptArgs)
+}
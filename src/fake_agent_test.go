@@ -0,0 +1,170 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fakeAgentTestScenario(t *testing.T, body string) fakeAgentScenario {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write scenario failed: %v", err)
+	}
+	scenario, err := loadFakeAgentScenario(path)
+	if err != nil {
+		t.Fatalf("load scenario failed: %v", err)
+	}
+	return scenario
+}
+
+func stubFakeAgentRuntime(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	origHome := osUserHomeDirFn
+	origSleep := fakeAgentSleepFn
+	t.Cleanup(func() {
+		osUserHomeDirFn = origHome
+		fakeAgentSleepFn = origSleep
+	})
+	osUserHomeDirFn = func() (string, error) { return home, nil }
+	fakeAgentSleepFn = func(time.Duration) {}
+	return home
+}
+
+func TestParseFakeAgentArgsMirrorsBuiltCommands(t *testing.T) {
+	cases := []struct {
+		persona string
+		args    []string
+		mode    string
+		prompt  string
+	}{
+		{"claude", []string{"--dangerously-skip-permissions", "fix the build"}, "interactive", "fix the build"},
+		{"claude", []string{"-p", "fix the build", "--model", "sonnet"}, "exec", "fix the build"},
+		{"codex", []string{"exec", "fix the build", "--full-auto", "--skip-git-repo-check", "-m", "gpt-5"}, "exec", "fix the build"},
+		{"codex", []string{"-c", "model_reasoning_effort=high", "fix the build"}, "interactive", "fix the build"},
+	}
+	for _, tc := range cases {
+		inv := parseFakeAgentArgs(tc.persona, tc.args)
+		if inv.Mode != tc.mode || inv.Prompt != tc.prompt {
+			t.Fatalf("%s %v: got %+v", tc.persona, tc.args, inv)
+		}
+	}
+}
+
+func TestFakeAgentCodexWritesDiscoverableTranscript(t *testing.T) {
+	stubFakeAgentRuntime(t)
+	scenario := fakeAgentTestScenario(t, `{"steps":[
+  {"action":"think","seconds":2},
+  {"action":"tool","tool":"shell","input":{"command":["ls"]},"output":"a.go"},
+  {"action":"say","text":"One file."},
+  {"action":"wait_input"},
+  {"action":"say","text":"Renamed."}
+]}`)
+	createdAt := time.Now().UTC().Format(time.RFC3339Nano)
+	var out bytes.Buffer
+	code, err := runFakeAgent("codex", scenario, fakeAgentInvocation{Mode: "interactive", Prompt: "list files"}, strings.NewReader("rename it\n"), &out, &out)
+	if err != nil || code != 0 {
+		t.Fatalf("fake agent failed: code=%d err=%v", code, err)
+	}
+	if !strings.Contains(out.String(), ">_ OpenAI Codex") || !strings.Contains(out.String(), "• Ran shell") {
+		t.Fatalf("unexpected render:\n%s", out.String())
+	}
+
+	sessionID, err := findCodexSessionID("list files", createdAt)
+	if err != nil {
+		t.Fatalf("codex session lookup failed: %v", err)
+	}
+	path, err := findCodexSessionFile(sessionID)
+	if err != nil {
+		t.Fatalf("codex session file lookup failed: %v", err)
+	}
+	messages, err := readCodexTranscript(path)
+	if err != nil {
+		t.Fatalf("read transcript failed: %v", err)
+	}
+	kinds := []string{}
+	for _, msg := range messages {
+		kinds = append(kinds, msg.Role+":"+msg.Kind)
+	}
+	want := "user:message,assistant:tool_call,user:tool_result,assistant:message,user:message,assistant:message"
+	if strings.Join(kinds, ",") != want {
+		t.Fatalf("unexpected transcript entries:\n got %s\nwant %s", strings.Join(kinds, ","), want)
+	}
+	if messages[4].Text != "rename it" {
+		t.Fatalf("expected typed input in transcript, got %+v", messages[4])
+	}
+}
+
+func TestFakeAgentClaudeTranscriptAndCrash(t *testing.T) {
+	home := stubFakeAgentRuntime(t)
+	scenario := fakeAgentTestScenario(t, `{"sessionId":"11111111-2222-4333-8444-555555555555","steps":[
+  {"action":"tool","tool":"Read","input":{"file_path":"main.go"},"output":"package main"},
+  {"action":"say","text":"Looks fine."},
+  {"action":"crash","exitCode":3,"text":"API Error: overloaded"}
+]}`)
+	createdAt := time.Now().UTC().Format(time.RFC3339Nano)
+	var out, errOut bytes.Buffer
+	code, err := runFakeAgent("claude", scenario, fakeAgentInvocation{Mode: "exec", Prompt: "review main.go"}, strings.NewReader(""), &out, &errOut)
+	if err != nil || code != 3 {
+		t.Fatalf("expected crash exit 3, got code=%d err=%v", code, err)
+	}
+	if strings.TrimSpace(out.String()) != "Looks fine." || !strings.Contains(errOut.String(), "API Error: overloaded") {
+		t.Fatalf("unexpected exec output: stdout=%q stderr=%q", out.String(), errOut.String())
+	}
+
+	cwd, _ := os.Getwd()
+	sessionID, err := findClaudeSessionID(cwd, "review main.go", createdAt)
+	if err != nil || sessionID != "11111111-2222-4333-8444-555555555555" {
+		t.Fatalf("claude session lookup failed: %q %v", sessionID, err)
+	}
+	messages, err := readClaudeTranscript(filepath.Join(claudeProjectDirForHome(home, cwd), sessionID+".jsonl"))
+	if err != nil {
+		t.Fatalf("read transcript failed: %v", err)
+	}
+	if len(messages) != 4 || messages[1].Kind != transcriptKindToolCall || messages[2].Text != "package main" || messages[3].Text != "Looks fine." {
+		t.Fatalf("unexpected claude transcript: %+v", messages)
+	}
+}
+
+func TestLoadFakeAgentScenarioRejectsUnknownAction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(path, []byte(`{"steps":[{"action":"dance"}]}`), 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, err := loadFakeAgentScenario(path); err == nil || !strings.Contains(err.Error(), `unsupported action "dance"`) {
+		t.Fatalf("expected unsupported action error, got %v", err)
+	}
+}
+
+func TestCmdFakeAgentInstallWritesShims(t *testing.T) {
+	origExe := osExecutableFn
+	t.Cleanup(func() { osExecutableFn = origExe })
+	osExecutableFn = func() (string, error) { return "/opt/lisa/bin/lisa", nil }
+	dir := filepath.Join(t.TempDir(), "shims")
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdFakeAgent([]string{"install", "--dir", dir, "--persona", "codex", "--json"}); code != 0 {
+			t.Fatalf("install failed: %d", code)
+		}
+	})
+	var payload struct {
+		Shims []string `json:"shims"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil || len(payload.Shims) != 1 {
+		t.Fatalf("unexpected install payload: %q (%v)", stdout, err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "codex"))
+	if err != nil {
+		t.Fatalf("read shim failed: %v", err)
+	}
+	if !strings.Contains(string(raw), "exec -a codex '/opt/lisa/bin/lisa' fake-agent --persona codex -- \"$@\"") {
+		t.Fatalf("unexpected shim:\n%s", raw)
+	}
+}
@@ -0,0 +1,484 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	guardPolicyFileEnv    = "LISA_GUARD_POLICY_FILE"
+	guardPolicyConfirmArg = "--policy-confirm"
+	guardMaxNestingDepth  = 16
+)
+
+var checkGuardPolicyFn = checkGuardPolicy
+
+// guardRule matches a parsed mutating command. Every non-empty condition must
+// hold; rules are evaluated in order and the first match decides.
+type guardRule struct {
+	ID           string            `json:"id,omitempty"`
+	Action       string            `json:"action"`
+	Command      string            `json:"command,omitempty"`
+	Flags        []string          `json:"flags,omitempty"`
+	MissingFlags []string          `json:"missingFlags,omitempty"`
+	FlagValues   map[string]string `json:"flagValues,omitempty"`
+	Text         []string          `json:"text,omitempty"`
+	Lane         string            `json:"lane,omitempty"`
+	Agent        string            `json:"agent,omitempty"`
+	MinDepth     *int              `json:"minDepth,omitempty"`
+	MaxDepth     *int              `json:"maxDepth,omitempty"`
+	Message      string            `json:"message,omitempty"`
+
+	flagValueRes map[string]*regexp.Regexp
+	textRes      []*regexp.Regexp
+}
+
+// guardInvocation is the parsed view of a command that rules match against.
+type guardInvocation struct {
+	Command string
+	Flags   map[string]string
+	Texts   []string
+	Lane    string
+	Agent   string
+	Depth   int
+}
+
+type guardDecision struct {
+	Rule    int    `json:"rule"`
+	ID      string `json:"id,omitempty"`
+	Action  string `json:"action"`
+	Message string `json:"message,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+// guardPolicyPath mirrors promptPolicyPath: LISA_GUARD_POLICY_FILE wins,
+// otherwise <projectRoot>/.lisa/guard-policy.json.
+func guardPolicyPath(projectRoot string) string {
+	if path := strings.TrimSpace(os.Getenv(guardPolicyFileEnv)); path != "" {
+		if expanded, err := expandAndCleanPath(path); err == nil {
+			return expanded
+		}
+		return path
+	}
+	if strings.TrimSpace(projectRoot) == "" {
+		return ""
+	}
+	return filepath.Join(projectRoot, ".lisa", "guard-policy.json")
+}
+
+// loadProjectGuardPolicy returns (nil, "", nil) when no policy is configured.
+func loadProjectGuardPolicy(projectRoot string) (*sessionGuardPolicy, string, error) {
+	path := guardPolicyPath(projectRoot)
+	if path == "" {
+		return nil, "", nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) && strings.TrimSpace(os.Getenv(guardPolicyFileEnv)) == "" {
+			return nil, "", nil
+		}
+		return nil, path, err
+	}
+	policy, err := loadSessionGuardPolicy(path)
+	return policy, path, err
+}
+
+func compileGuardRules(rules []guardRule) error {
+	for i := range rules {
+		rule := &rules[i]
+		rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+		switch rule.Action {
+		case "allow", "warn", "deny", "require-confirm":
+		default:
+			return fmt.Errorf("rules[%d]: invalid action %q (expected allow|warn|deny|require-confirm)", i, rule.Action)
+		}
+		rule.Command = strings.ToLower(strings.Join(strings.Fields(rule.Command), " "))
+		rule.flagValueRes = map[string]*regexp.Regexp{}
+		for flag, pattern := range rule.FlagValues {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("rules[%d]: invalid flagValues[%s]: %w", i, flag, err)
+			}
+			rule.flagValueRes[flag] = re
+		}
+		rule.textRes = rule.textRes[:0]
+		for _, pattern := range rule.Text {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("rules[%d]: invalid text pattern: %w", i, err)
+			}
+			rule.textRes = append(rule.textRes, re)
+		}
+	}
+	return nil
+}
+
+// matches reports whether every condition of the rule holds for inv.
+func (rule guardRule) matches(inv guardInvocation) bool {
+	if !guardCommandMatches(rule.Command, inv.Command) {
+		return false
+	}
+	for _, flag := range rule.Flags {
+		if _, ok := inv.Flags[flag]; !ok {
+			return false
+		}
+	}
+	for _, flag := range rule.MissingFlags {
+		if _, ok := inv.Flags[flag]; ok {
+			return false
+		}
+	}
+	for flag, re := range rule.flagValueRes {
+		value, ok := inv.Flags[flag]
+		if !ok || !re.MatchString(value) {
+			return false
+		}
+	}
+	if len(rule.textRes) > 0 {
+		matched := false
+		for _, re := range rule.textRes {
+			for _, text := range inv.Texts {
+				if re.MatchString(text) {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if rule.Lane != "" && rule.Lane != inv.Lane {
+		return false
+	}
+	if rule.Agent != "" && !strings.EqualFold(rule.Agent, inv.Agent) {
+		return false
+	}
+	if rule.MinDepth != nil && inv.Depth < *rule.MinDepth {
+		return false
+	}
+	if rule.MaxDepth != nil && inv.Depth > *rule.MaxDepth {
+		return false
+	}
+	return true
+}
+
+// guardCommandMatches accepts an exact name, "*", or a "session *" style
+// prefix wildcard.
+func guardCommandMatches(pattern, command string) bool {
+	switch {
+	case pattern == "" || pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, " *"):
+		return strings.HasPrefix(command, strings.TrimSuffix(pattern, "*"))
+	default:
+		return pattern == command
+	}
+}
+
+// decideGuardRules returns the first matching rule's decision, or nil.
+func decideGuardRules(rules []guardRule, inv guardInvocation) *guardDecision {
+	for i, rule := range rules {
+		if !rule.matches(inv) {
+			continue
+		}
+		return &guardDecision{Rule: i + 1, ID: rule.ID, Action: rule.Action, Message: rule.Message}
+	}
+	return nil
+}
+
+// guardTextFlags carry text that ends up in a pane or another agent's input:
+// sent text and keys, spawn prompts, spawn commands and agent args, and mail
+// bodies. Text rules match against all of them.
+var guardTextFlags = []string{"--text", "--keys", "--prompt", "--command", "--agent-args", "--body"}
+
+// parseGuardInvocation extracts flags and outgoing text from argv. A flag's
+// value is the next argument unless that argument is itself a flag; text flags
+// always take the next argument, since agent args usually start with "--".
+func parseGuardInvocation(command string, args []string) guardInvocation {
+	inv := guardInvocation{Command: command, Flags: map[string]string{}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			continue
+		}
+		if name, value, ok := strings.Cut(arg, "="); ok {
+			inv.Flags[name] = value
+			continue
+		}
+		value := ""
+		if i+1 < len(args) && (!strings.HasPrefix(args[i+1], "--") || isGuardTextFlag(arg)) {
+			value = args[i+1]
+			i++
+		}
+		inv.Flags[arg] = value
+	}
+	for _, flag := range guardTextFlags {
+		if value, ok := inv.Flags[flag]; ok && value != "" {
+			inv.Texts = append(inv.Texts, value)
+		}
+	}
+	inv.Lane = inv.Flags["--lane"]
+	inv.Agent = inv.Flags["--agent"]
+	return inv
+}
+
+func isGuardTextFlag(flag string) bool {
+	for _, candidate := range guardTextFlags {
+		if flag == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// guardInboxTexts returns the queued text of a session inbox so `session inbox
+// deliver` is checked against what it will actually type into the pane.
+func guardInboxTexts(projectRoot, session string) []string {
+	inbox, err := loadSessionInbox(projectRoot, session)
+	if err != nil {
+		return nil
+	}
+	texts := []string{}
+	for _, message := range inbox.Messages {
+		if message.Text != "" {
+			texts = append(texts, message.Text)
+		}
+		if len(message.Keys) > 0 {
+			texts = append(texts, strings.Join(message.Keys, " "))
+		}
+	}
+	return texts
+}
+
+// guardPromptAnswer checks a prompt-policy auto-answer as a `session answer`
+// whose text is the answer plus the prompt's question and context (the command
+// or edit being approved). It returns the decision when the answer must not be
+// sent: nobody is there to confirm, so require-confirm refuses like deny.
+func guardPromptAnswer(projectRoot, session, agent string, prompt *pendingPrompt, answer promptDecision) (*guardDecision, error) {
+	policy, path, err := loadProjectGuardPolicy(projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading guard policy %s: %w", path, err)
+	}
+	if policy == nil || len(policy.Rules) == 0 {
+		return nil, nil
+	}
+	inv := guardInvocation{Command: "session answer", Flags: map[string]string{"--session": session}, Agent: agent}
+	if answer.Text != "" {
+		inv.Flags["--text"] = answer.Text
+		inv.Texts = append(inv.Texts, answer.Text)
+	}
+	if prompt != nil {
+		for _, text := range []string{prompt.Question, prompt.Context} {
+			if strings.TrimSpace(text) != "" {
+				inv.Texts = append(inv.Texts, text)
+			}
+		}
+	}
+	if meta, metaErr := loadSessionMeta(projectRoot, session); metaErr == nil {
+		inv.Lane = meta.Lane
+		if inv.Agent == "" {
+			inv.Agent = meta.Agent
+		}
+	}
+	inv.Depth = guardCallerDepth(projectRoot)
+	decision := decideGuardRules(policy.Rules, inv)
+	if decision == nil {
+		return nil, nil
+	}
+	decision.Path = path
+	switch decision.Action {
+	case "deny", "require-confirm":
+		return decision, nil
+	case "warn":
+		fmt.Fprintf(os.Stderr, "warning: guard policy rule %d: warn prompt auto-answer for %s\n", decision.Rule, session)
+	}
+	return nil, nil
+}
+
+// guardCallerDepth counts how deeply the calling process is nested: 0 for a
+// top-level shell, 1 inside a root lisa session, and so on up the parent chain.
+func guardCallerDepth(projectRoot string) int {
+	current := strings.TrimSpace(os.Getenv("LISA_SESSION_NAME"))
+	depth := 0
+	for current != "" && depth < guardMaxNestingDepth {
+		depth++
+		meta, err := loadSessionMeta(projectRoot, current)
+		if err != nil {
+			break
+		}
+		current = strings.TrimSpace(meta.ParentSession)
+	}
+	return depth
+}
+
+// stripGuardConfirm removes --policy-confirm so commands never see it.
+func stripGuardConfirm(args []string) ([]string, bool) {
+	out := make([]string, 0, len(args))
+	confirmed := false
+	for _, arg := range args {
+		if arg == guardPolicyConfirmArg {
+			confirmed = true
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out, confirmed
+}
+
+// checkGuardPolicy evaluates the project guard policy for a mutating command
+// before it runs. It returns blocked=true with an exit code when the command
+// must not proceed; warn decisions print to stderr and let it run.
+func checkGuardPolicy(command string, args []string, confirmed bool) (int, bool) {
+	jsonOut := hasJSONFlag(args)
+	rest := args[1:]
+	if strings.Contains(command, " ") {
+		rest = args[2:]
+	}
+	inv := parseGuardInvocation(command, rest)
+	projectRoot := canonicalProjectRoot(getPWD())
+	if root := strings.TrimSpace(inv.Flags["--project-root"]); root != "" {
+		projectRoot = canonicalProjectRoot(root)
+	}
+	policy, path, err := loadProjectGuardPolicy(projectRoot)
+	if err != nil {
+		return commandErrorf(jsonOut, "guard_policy_invalid", "failed loading guard policy %s: %v", path, err), true
+	}
+	if policy == nil || len(policy.Rules) == 0 {
+		return 0, false
+	}
+	if session := strings.TrimSpace(inv.Flags["--session"]); session != "" && (inv.Lane == "" || inv.Agent == "") {
+		if meta, metaErr := loadSessionMeta(projectRoot, session); metaErr == nil {
+			if inv.Lane == "" {
+				inv.Lane = meta.Lane
+			}
+			if inv.Agent == "" {
+				inv.Agent = meta.Agent
+			}
+		}
+	}
+	if session := strings.TrimSpace(inv.Flags["--session"]); session != "" && inv.Command == "session inbox deliver" {
+		inv.Texts = append(inv.Texts, guardInboxTexts(projectRoot, session)...)
+	}
+	inv.Depth = guardCallerDepth(projectRoot)
+
+	decision := decideGuardRules(policy.Rules, inv)
+	if decision == nil {
+		return 0, false
+	}
+	decision.Path = path
+	label := "rule " + strconv.Itoa(decision.Rule)
+	if decision.ID != "" {
+		label = "rule " + decision.ID
+	}
+	message := fmt.Sprintf("guard policy %s: %s %s", label, decision.Action, command)
+	if decision.Message != "" {
+		message += ": " + decision.Message
+	}
+	details := map[string]any{"policy": decision}
+	switch decision.Action {
+	case "warn":
+		fmt.Fprintln(os.Stderr, "warning: "+message)
+	case "deny":
+		return guardPolicyError(jsonOut, "guard_policy_denied", message, details), true
+	case "require-confirm":
+		if !confirmed {
+			return guardPolicyError(jsonOut, "guard_policy_confirmation_required", message+" (re-run with "+guardPolicyConfirmArg+")", details), true
+		}
+	}
+	return 0, false
+}
+
+func guardPolicyError(jsonOut bool, errorCode, message string, details map[string]any) int {
+	if jsonOut {
+		writeJSONError(errorCode, message, details)
+		return 1
+	}
+	fmt.Fprintln(os.Stderr, message)
+	return 1
+}
+
+// evaluateGuardRulesForText applies policy rules to a command line given to
+// `session guard --command`, mapping actions onto guard risk levels.
+func evaluateGuardRulesForText(rules []guardRule, commandText string) (warnings []string, reasons []string, risk string) {
+	risk = "low"
+	words := splitGuardCommandWords(commandText)
+	if len(words) > 0 && (words[0] == "lisa" || strings.HasSuffix(words[0], "/lisa")) {
+		words = words[1:]
+	}
+	command := auditCommandName(words)
+	if command == "" {
+		return warnings, reasons, risk
+	}
+	rest := words[1:]
+	if strings.Contains(command, " ") {
+		rest = words[2:]
+	}
+	decision := decideGuardRules(rules, parseGuardInvocation(command, rest))
+	if decision == nil || decision.Action == "allow" {
+		return warnings, reasons, risk
+	}
+	name := decision.ID
+	if name == "" {
+		name = strconv.Itoa(decision.Rule)
+	}
+	warning := "policy rule " + name + " " + decision.Action + "s " + command
+	if decision.Message != "" {
+		warning += ": " + decision.Message
+	}
+	warnings = append(warnings, warning)
+	reasons = append(reasons, "policy_rule_"+strings.ReplaceAll(decision.Action, "-", "_"))
+	if decision.Action == "warn" {
+		return warnings, reasons, "medium"
+	}
+	return warnings, reasons, "high"
+}
+
+// splitGuardCommandWords splits a command line into words, honoring single
+// and double quotes and backslash escapes.
+func splitGuardCommandWords(input string) []string {
+	words := []string{}
+	var current strings.Builder
+	inWord := false
+	var quote byte
+	for i := 0; i < len(input); i++ {
+		ch := input[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else if ch == '\\' && quote == '"' && i+1 < len(input) {
+				i++
+				current.WriteByte(input[i])
+			} else {
+				current.WriteByte(ch)
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+			inWord = true
+		case ch == '\\' && i+1 < len(input):
+			i++
+			current.WriteByte(input[i])
+			inWord = true
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words
+}
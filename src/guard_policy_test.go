@@ -0,0 +1,183 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGuardPolicy(t *testing.T, projectRoot, body string) {
+	t.Helper()
+	dir := filepath.Join(projectRoot, ".lisa")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "guard-policy.json"), []byte(body), 0o644); err != nil {
+		t.Fatalf("write guard policy failed: %v", err)
+	}
+}
+
+const testGuardPolicy = `{"rules": [
+  {"id": "ok-docs", "command": "session send", "text": ["(?i)rm -rf ./docs/tmp"], "action": "allow"},
+  {"id": "no-rm-rf", "command": "session *", "text": ["(?i)rm\\s+-rf"], "action": "deny", "message": "destructive shell"},
+  {"id": "push", "command": "session send", "text": ["git push --force"], "action": "require-confirm"},
+  {"id": "deep", "command": "session spawn", "minDepth": 2, "action": "deny"},
+  {"id": "review-codex", "command": "session spawn", "lane": "review", "agent": "codex", "action": "warn"},
+  {"id": "kill-all", "command": "session kill-all", "missingFlags": ["--project-only"], "action": "deny"},
+  {"id": "sonnet-only", "command": "session spawn", "flagValues": {"--model": "^opus"}, "action": "deny"}
+]}`
+
+func TestDecideGuardRulesMatchesParsedCommands(t *testing.T) {
+	root := t.TempDir()
+	writeGuardPolicy(t, root, testGuardPolicy)
+	policy, _, err := loadProjectGuardPolicy(root)
+	if err != nil || policy == nil {
+		t.Fatalf("load policy failed: %v", err)
+	}
+	cases := []struct {
+		command string
+		args    []string
+		depth   int
+		want    string
+	}{
+		{"session send", []string{"--session", "s", "--text", "run rm -rf ./docs/tmp now"}, 0, "allow"},
+		{"session send", []string{"--session", "s", "--text", "then RM  -rf /", "--enter"}, 0, "deny"},
+		{"session spawn", []string{"--prompt", "clean with rm -rf build"}, 0, "deny"},
+		{"session send", []string{"--text", "git push --force origin"}, 0, "require-confirm"},
+		{"session spawn", []string{"--prompt", "hi"}, 2, "deny"},
+		{"session spawn", []string{"--prompt", "hi"}, 1, ""},
+		{"session spawn", []string{"--lane", "review", "--agent", "Codex"}, 0, "warn"},
+		{"session kill-all", []string{"--json"}, 0, "deny"},
+		{"session kill-all", []string{"--project-only"}, 0, ""},
+		{"session spawn", []string{"--model=opus-x"}, 0, "deny"},
+		{"cleanup", []string{"--text", "rm -rf"}, 0, ""},
+		{"session spawn", []string{"--agent", "claude", "--command", "bash -c 'rm -rf ~'"}, 0, "deny"},
+		{"session spawn", []string{"--agent-args", "--append-system-prompt rm -rf everything"}, 0, "deny"},
+		{"session send", []string{"--session", "s", "--text", "--force"}, 0, ""},
+	}
+	for _, tc := range cases {
+		inv := parseGuardInvocation(tc.command, tc.args)
+		inv.Depth = tc.depth
+		got := ""
+		if decision := decideGuardRules(policy.Rules, inv); decision != nil {
+			got = decision.Action
+		}
+		if got != tc.want {
+			t.Fatalf("%s %v depth=%d: got %q want %q", tc.command, tc.args, tc.depth, got, tc.want)
+		}
+	}
+
+	writeGuardPolicy(t, root, `{"rules":[{"action":"maybe"}]}`)
+	if _, _, err := loadProjectGuardPolicy(root); err == nil || !strings.Contains(err.Error(), "invalid action") {
+		t.Fatalf("expected invalid action error, got %v", err)
+	}
+}
+
+func TestRunEnforcesGuardPolicyBeforeMutatingCommands(t *testing.T) {
+	auditPath := auditTestLog(t)
+	root := t.TempDir()
+	writeGuardPolicy(t, root, testGuardPolicy)
+
+	origSend := tmuxSendKeysFn
+	t.Cleanup(func() { tmuxSendKeysFn = origSend })
+	sent := false
+	tmuxSendKeysFn = func(string, []string, bool) error { sent = true; return nil }
+
+	stdout, _ := captureOutput(t, func() {
+		code := Run([]string{"session", "send", "--session", "lisa-guarded", "--project-root", root, "--text", "rm -rf /", "--enter", "--json"})
+		if code != 1 {
+			t.Fatalf("expected denied send to exit 1, got %d", code)
+		}
+	})
+	if sent {
+		t.Fatalf("denied send must not reach tmux")
+	}
+	if !strings.Contains(stdout, `"errorCode":"guard_policy_denied"`) || !strings.Contains(stdout, `"id":"no-rm-rf"`) {
+		t.Fatalf("unexpected denial payload: %q", stdout)
+	}
+	entries, err := readAuditEntries(auditPath)
+	if err != nil || len(entries) != 1 || entries[0].OK {
+		t.Fatalf("expected blocked command in audit log, got %+v (%v)", entries, err)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		Run([]string{"session", "send", "--session", "lisa-guarded", "--project-root", root, "--text", "git push --force", "--json"})
+	})
+	if !strings.Contains(stdout, `"errorCode":"guard_policy_confirmation_required"`) {
+		t.Fatalf("expected confirmation requirement, got %q", stdout)
+	}
+	args, confirmed := stripGuardConfirm([]string{"session", "send", "--session", "lisa-guarded", "--project-root", root, "--text", "git push --force", guardPolicyConfirmArg})
+	if !confirmed || strings.Contains(strings.Join(args, " "), guardPolicyConfirmArg) {
+		t.Fatalf("expected --policy-confirm to be stripped, got %v", args)
+	}
+	if _, blocked := checkGuardPolicy("session send", args, confirmed); blocked {
+		t.Fatalf("confirmed command should not be blocked")
+	}
+}
+
+func TestGuardPolicyUsesSessionMetaAndCallerDepth(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	writeGuardPolicy(t, root, `{"rules":[
+  {"id":"review-lane","command":"session send","lane":"review","action":"deny"},
+  {"id":"nested-kill","command":"session kill","minDepth":2,"action":"deny"}
+]}`)
+	for _, meta := range []sessionMeta{
+		{Session: "lisa-root", Agent: "claude", Mode: "interactive", ProjectRoot: root},
+		{Session: "lisa-child", ParentSession: "lisa-root", Agent: "codex", Mode: "exec", Lane: "review", ProjectRoot: root},
+	} {
+		if err := saveSessionMeta(root, meta.Session, meta); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+		name := meta.Session
+		t.Cleanup(func() { _ = os.Remove(sessionMetaFile(root, name)) })
+	}
+
+	if code, blocked := checkGuardPolicy("session send", []string{"session", "send", "--session", "lisa-child", "--project-root", root, "--text", "hi"}, false); !blocked || code != 1 {
+		t.Fatalf("expected lane rule from session meta to deny, got code=%d blocked=%t", code, blocked)
+	}
+
+	t.Setenv("LISA_SESSION_NAME", "lisa-root")
+	if depth := guardCallerDepth(root); depth != 1 {
+		t.Fatalf("expected depth 1 inside root session, got %d", depth)
+	}
+	killArgs := []string{"session", "kill", "--session", "lisa-root", "--project-root", root}
+	if _, blocked := checkGuardPolicy("session kill", killArgs, false); blocked {
+		t.Fatalf("depth 1 caller should not match minDepth 2")
+	}
+	t.Setenv("LISA_SESSION_NAME", "lisa-child")
+	if _, blocked := checkGuardPolicy("session kill", killArgs, false); !blocked {
+		t.Fatalf("depth 2 caller should be denied")
+	}
+}
+
+func TestSessionGuardAppliesProjectPolicyRules(t *testing.T) {
+	root := t.TempDir()
+	writeGuardPolicy(t, root, testGuardPolicy)
+	stdout, _ := captureOutput(t, func() {
+		cmdSessionGuard([]string{"--shared-tmux", "--advice-only", "--project-root", root, "--command", `lisa session send --session s --text "please rm -rf /" --enter`, "--json"})
+	})
+	if !strings.Contains(stdout, `"commandRisk":"high"`) || !strings.Contains(stdout, "policy_rule_deny") || !strings.Contains(stdout, "guard-policy.json") {
+		t.Fatalf("expected project policy rule to raise risk, got %q", stdout)
+	}
+	if words := splitGuardCommandWords(`a "b c" 'd e' f\ g`); strings.Join(words, "|") != "a|b c|d e|f g" {
+		t.Fatalf("unexpected word split: %q", words)
+	}
+}
+
+func TestGuardPolicyScansQueuedInboxTextOnDeliver(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	writeGuardPolicy(t, root, testGuardPolicy)
+	session := "lisa-guard-inbox"
+	t.Cleanup(func() { _ = os.Remove(sessionInboxFile(root, session)) })
+	args := []string{"session", "inbox", "deliver", "--session", session, "--project-root", root}
+	if _, blocked := checkGuardPolicy("session inbox deliver", args, false); blocked {
+		t.Fatalf("empty inbox should not be blocked")
+	}
+	if err := saveSessionInbox(root, session, sessionInbox{Session: session, Messages: []sessionInboxMessage{{ID: "m1", Text: "now rm -rf /", When: "idle"}}}); err != nil {
+		t.Fatalf("save inbox failed: %v", err)
+	}
+	if code, blocked := checkGuardPolicy("session inbox deliver", args, false); !blocked || code != 1 {
+		t.Fatalf("expected queued text to be denied on deliver, got code=%d blocked=%t", code, blocked)
+	}
+}
@@ -430,7 +430,7 @@ func TestCmdSessionExistsSupportsProjectRootFlag(t *testing.T) {
 	}
 }
 
-func TestTrimSessionEventFileEnforcesLineCapEvenWhenBytesSmall(t *testing.T) {
+func TestAppendSessionEventRotatesOnLineCapEvenWhenBytesSmall(t *testing.T) {
 	origMaxBytes := os.Getenv("LISA_EVENTS_MAX_BYTES")
 	origMaxLines := os.Getenv("LISA_EVENTS_MAX_LINES")
 	t.Cleanup(func() {
@@ -440,31 +440,29 @@ func TestTrimSessionEventFileEnforcesLineCapEvenWhenBytesSmall(t *testing.T) {
 	_ = os.Setenv("LISA_EVENTS_MAX_BYTES", "100000")
 	_ = os.Setenv("LISA_EVENTS_MAX_LINES", "3")
 
-	path := filepath.Join(t.TempDir(), "events.jsonl")
-	lines := []string{
-		`{"poll":1}`,
-		`{"poll":2}`,
-		`{"poll":3}`,
-		`{"poll":4}`,
-		`{"poll":5}`,
-	}
-	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
-		t.Fatalf("failed to seed event file: %v", err)
-	}
-
-	if err := trimSessionEventFile(path); err != nil {
-		t.Fatalf("trimSessionEventFile failed: %v", err)
+	projectRoot := t.TempDir()
+	session := "lisa-events-line-cap"
+	path := sessionEventsFile(projectRoot, session)
+	t.Cleanup(func() {
+		_ = os.Remove(path)
+		_ = os.Remove(sessionEventCountFile(path))
+		_ = removeSessionEventSegments(path)
+	})
+	for i := 0; i < 5; i++ {
+		if err := appendSessionEvent(projectRoot, session, sessionEvent{At: time.Now().UTC().Format(time.RFC3339Nano), Type: "snapshot", Session: session, Poll: i + 1}); err != nil {
+			t.Fatalf("appendSessionEvent failed: %v", err)
+		}
 	}
-	raw, err := os.ReadFile(path)
+	active, err := readSessionEventActiveLines(path)
 	if err != nil {
-		t.Fatalf("failed to read trimmed file: %v", err)
+		t.Fatalf("failed to read active file: %v", err)
 	}
-	trimmed := strings.Split(strings.TrimSpace(string(raw)), "\n")
-	if len(trimmed) != 3 {
-		t.Fatalf("expected line cap 3, got %d", len(trimmed))
+	if len(active) != 1 {
+		t.Fatalf("expected rotation at line cap to leave one active line, got %d", len(active))
 	}
-	if trimmed[0] != `{"poll":3}` || trimmed[2] != `{"poll":5}` {
-		t.Fatalf("unexpected retained lines: %v", trimmed)
+	manifest, err := loadSessionEventManifest(path)
+	if err != nil || len(manifest.Segments) != 1 || manifest.Segments[0].Lines != 4 {
+		t.Fatalf("expected one 4-line segment, got %+v (%v)", manifest, err)
 	}
 }
 
@@ -515,8 +513,8 @@ func TestAppendSessionEventConcurrentWritersRemainValidJSONL(t *testing.T) {
 	if tail.DroppedLines != 0 {
 		t.Fatalf("expected no malformed lines after concurrent writes, dropped=%d", tail.DroppedLines)
 	}
-	if len(tail.Events) == 0 || len(tail.Events) > 20 {
-		t.Fatalf("expected bounded retained events (1..20), got %d", len(tail.Events))
+	if len(tail.Events) != 50 || tail.NextCursor != 64 {
+		t.Fatalf("expected last 50 of 64 events across segments, got %d (cursor %d)", len(tail.Events), tail.NextCursor)
 	}
 }
 
@@ -582,9 +580,9 @@ func TestListProcessesParsesOutput(t *testing.T) {
 	script := strings.Join([]string{
 		"#!/usr/bin/env sh",
 		"cat <<'EOF'",
-		"123 1 0.0 /usr/bin/claude exec",
+		"123 1 0.0 2048 /usr/bin/claude exec",
 		"bad line",
-		"456 123 3.5 codex exec --full-auto",
+		"456 123 3.5 10240 codex exec --full-auto",
 		"EOF",
 	}, "\n")
 	if err := os.WriteFile(psPath, []byte(script), 0o700); err != nil {
@@ -604,7 +602,7 @@ func TestListProcessesParsesOutput(t *testing.T) {
 	if len(procs) != 2 {
 		t.Fatalf("expected 2 parsed processes, got %d (%v)", len(procs), procs)
 	}
-	if procs[1].PID != 456 || procs[1].PPID != 123 {
+	if procs[1].PID != 456 || procs[1].PPID != 123 || procs[1].RSSKB != 10240 {
 		t.Fatalf("unexpected parsed process: %+v", procs[1])
 	}
 	if !strings.Contains(procs[1].Command, "codex exec --full-auto") {
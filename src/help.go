@@ -13,17 +13,23 @@ var helpFuncs = map[string]func(){
 	"":                       helpTop,
 	"doctor":                 helpDoctor,
 	"cleanup":                helpCleanup,
+	"classify":               helpClassify,
+	"fake-agent":             helpFakeAgent,
+	"fake-agent install":     helpFakeAgent,
 	"version":                helpVersion,
 	"session":                helpSession,
 	"session name":           helpSessionName,
 	"session spawn":          helpSessionSpawn,
 	"session detect-nested":  helpSessionDetectNested,
 	"session send":           helpSessionSend,
+	"session answer":         helpSessionAnswer,
+	"session inbox":          helpSessionInbox,
 	"session snapshot":       helpSessionSnapshot,
 	"session status":         helpSessionStatus,
 	"session explain":        helpSessionExplain,
 	"session monitor":        helpSessionMonitor,
 	"session capture":        helpSessionCapture,
+	"session tail":           helpSessionTail,
 	"session packet":         helpSessionPacket,
 	"session turn":           helpSessionTurn,
 	"session contract-check": helpSessionContractCheck,
@@ -54,6 +60,7 @@ var helpFuncs = map[string]func(){
 	"session smoke":          helpSessionSmoke,
 	"session preflight":      helpSessionPreflight,
 	"session exists":         helpSessionExists,
+	"session stop":           helpSessionStop,
 	"session kill":           helpSessionKill,
 	"session kill-all":       helpSessionKillAll,
 	"agent":                  helpAgent,
@@ -68,6 +75,33 @@ var helpFuncs = map[string]func(){
 	"oauth add":              helpOAuthAdd,
 	"oauth list":             helpOAuthList,
 	"oauth remove":           helpOAuthRemove,
+	"queue":                  helpQueue,
+	"queue add":              helpQueueAdd,
+	"queue list":             helpQueueList,
+	"queue cancel":           helpQueueCancel,
+	"queue drain":            helpQueueDrain,
+	"queue work":             helpQueueWork,
+	"audit":                  helpAudit,
+	"audit list":             helpAuditList,
+	"audit show":             helpAuditShow,
+	"audit verify":           helpAuditVerify,
+	"history":                helpHistory,
+	"history list":           helpHistoryList,
+	"history show":           helpHistoryShow,
+	"history search":         helpHistorySearch,
+	"report":                 helpReport,
+	"events":                 helpEvents,
+	"events query":           helpEventsQuery,
+	"payload":                helpPayload,
+	"payload decode":         helpPayloadDecode,
+	"result":                 helpResult,
+	"result put":             helpResultPut,
+	"result get":             helpResultGet,
+	"msg":                    helpMsg,
+	"msg post":               helpMsgPost,
+	"msg read":               helpMsgRead,
+	"msg ack":                helpMsgAck,
+	"hook":                   helpHook,
 }
 
 func showHelp(cmdPath string) int {
@@ -86,21 +120,27 @@ func helpTop() {
 	fmt.Fprintln(os.Stderr, "Commands:")
 	fmt.Fprintln(os.Stderr, "  doctor               Check prerequisites (tmux, claude, codex)")
 	fmt.Fprintln(os.Stderr, "  cleanup              Clean stale tmux socket residue")
+	fmt.Fprintln(os.Stderr, "  classify             Replay recorded status fixtures through the classifier")
+	fmt.Fprintln(os.Stderr, "  fake-agent           Scripted claude/codex simulator for hermetic tests")
+	fmt.Fprintln(os.Stderr, "  fake-agent install   Install claude/codex PATH shims for fake-agent")
 	fmt.Fprintln(os.Stderr, "  version              Print version info")
 	fmt.Fprintln(os.Stderr, "  session name          Generate unique session name")
 	fmt.Fprintln(os.Stderr, "  session spawn         Create and start an agent session")
 	fmt.Fprintln(os.Stderr, "  session detect-nested Inspect nested-codex bypass detection")
 	fmt.Fprintln(os.Stderr, "  session send          Send text or keys to a running session")
+	fmt.Fprintln(os.Stderr, "  session answer        Answer a detected pending prompt (menu/approval)")
+	fmt.Fprintln(os.Stderr, "  session inbox         List/clear/deliver deferred sends (send --when)")
 	fmt.Fprintln(os.Stderr, "  session snapshot      One-shot status + capture + nextOffset")
 	fmt.Fprintln(os.Stderr, "  session status        Get current session status")
 	fmt.Fprintln(os.Stderr, "  session explain       Detailed session diagnostics")
 	fmt.Fprintln(os.Stderr, "  session monitor       Poll session until terminal state")
 	fmt.Fprintln(os.Stderr, "  session capture       Capture session pane output or transcript")
+	fmt.Fprintln(os.Stderr, "  session tail          Stream new output lines from one or many sessions")
 	fmt.Fprintln(os.Stderr, "  session packet        Build status+capture+handoff packet")
 	fmt.Fprintln(os.Stderr, "  session turn          Run send->monitor->packet one-shot turn")
 	fmt.Fprintln(os.Stderr, "  session contract-check Validate schema/docs command+flag contract sync")
 	fmt.Fprintln(os.Stderr, "  session schema        Emit JSON schemas for session command payloads")
-	fmt.Fprintln(os.Stderr, "  session checkpoint    Save/resume orchestration state bundles")
+	fmt.Fprintln(os.Stderr, "  session checkpoint    Save/resume/export/import orchestration state bundles")
 	fmt.Fprintln(os.Stderr, "  session dedupe        Prevent duplicate work via task-hash claims")
 	fmt.Fprintln(os.Stderr, "  session next          Recommend executable next command for a session")
 	fmt.Fprintln(os.Stderr, "  session aggregate     Build multi-session context pack")
@@ -127,6 +167,7 @@ func helpTop() {
 	fmt.Fprintln(os.Stderr, "  session preflight     Validate env + contract assumptions")
 	fmt.Fprintln(os.Stderr, "  session list          List lisa sessions")
 	fmt.Fprintln(os.Stderr, "  session exists        Check if a session exists")
+	fmt.Fprintln(os.Stderr, "  session stop          Stop gracefully, archive to history, then kill")
 	fmt.Fprintln(os.Stderr, "  session kill          Kill a session and clean artifacts")
 	fmt.Fprintln(os.Stderr, "  session kill-all      Kill all lisa sessions")
 	fmt.Fprintln(os.Stderr, "  capabilities          Describe lisa CLI commands and flags")
@@ -134,6 +175,26 @@ func helpTop() {
 	fmt.Fprintln(os.Stderr, "  oauth add             Add Claude OAuth token to local pool")
 	fmt.Fprintln(os.Stderr, "  oauth list            List Claude OAuth token pool entries")
 	fmt.Fprintln(os.Stderr, "  oauth remove          Remove Claude OAuth token from pool")
+	fmt.Fprintln(os.Stderr, "  queue add             Queue a spawn job (agent/mode/prompt/lane/priority)")
+	fmt.Fprintln(os.Stderr, "  queue list            List queued/running/finished jobs")
+	fmt.Fprintln(os.Stderr, "  queue cancel          Cancel queued or running jobs")
+	fmt.Fprintln(os.Stderr, "  queue drain           Stop workers from starting new jobs")
+	fmt.Fprintln(os.Stderr, "  queue work            Run queued jobs with a concurrency limit")
+	fmt.Fprintln(os.Stderr, "  audit list            List audit log entries of mutating commands")
+	fmt.Fprintln(os.Stderr, "  audit show            Show one audit entry")
+	fmt.Fprintln(os.Stderr, "  audit verify          Verify the audit hash chain")
+	fmt.Fprintln(os.Stderr, "  history list          List finished sessions from the history index")
+	fmt.Fprintln(os.Stderr, "  history show          Show one finished session")
+	fmt.Fprintln(os.Stderr, "  history search        Search finished sessions by text")
+	fmt.Fprintln(os.Stderr, "  report                Summarize fleet activity (markdown|html|json)")
+	fmt.Fprintln(os.Stderr, "  events query          Query session events across sessions and projects")
+	fmt.Fprintln(os.Stderr, "  payload decode        Expand compressed handoff/packet JSON from stdin")
+	fmt.Fprintln(os.Stderr, "  result put            Declare a session's outcome (success|failed|blocked)")
+	fmt.Fprintln(os.Stderr, "  result get            Show a session's declared result")
+	fmt.Fprintln(os.Stderr, "  msg post              Post a message to a parent/child session mailbox")
+	fmt.Fprintln(os.Stderr, "  msg read              Read (and acknowledge) unread mailbox messages")
+	fmt.Fprintln(os.Stderr, "  msg ack               Acknowledge messages read with --peek")
+	fmt.Fprintln(os.Stderr, "  hook                  Record an agent lifecycle callback (wired by spawn)")
 	fmt.Fprintln(os.Stderr, "  skills sync           Sync lisa skill into repo skills/lisa")
 	fmt.Fprintln(os.Stderr, "  skills doctor         Verify installed lisa skill drift")
 	fmt.Fprintln(os.Stderr, "  skills install        Install repo lisa skill to codex/claude/project")
@@ -158,9 +219,39 @@ func helpCleanup() {
 	fmt.Fprintln(os.Stderr, "Flags:")
 	fmt.Fprintln(os.Stderr, "  --dry-run                Show what would be removed/killed without mutating")
 	fmt.Fprintln(os.Stderr, "  --include-tmux-default   Also sweep tmux default sockets (/tmp/tmux-*)")
+	fmt.Fprintln(os.Stderr, "  --force                  Also kill servers hosting protected=true sessions")
 	fmt.Fprintln(os.Stderr, "  --json                   JSON output")
 }
 
+func helpClassify() {
+	fmt.Fprintln(os.Stderr, "lisa classify — replay recorded status fixtures through the classifier")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa classify --fixture DIR [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --fixture DIR         Fixture from 'session status --record', or a corpus dir of fixtures (repeatable)")
+	fmt.Fprintln(os.Stderr, "  --trace               Show classification rule evaluation per fixture")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Exits 1 when any replayed state differs from the fixture's expected state.")
+}
+
+func helpFakeAgent() {
+	fmt.Fprintln(os.Stderr, "lisa fake-agent — scripted claude/codex simulator for hermetic tests")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa fake-agent --persona claude|codex [--script FILE] [--] [agent args...]")
+	fmt.Fprintln(os.Stderr, "       lisa fake-agent install --dir DIR [--script FILE] [--persona claude|codex|all] [--json]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --persona NAME        Agent to imitate: claude|codex (default: scenario persona)")
+	fmt.Fprintln(os.Stderr, "  --script FILE         Scenario JSON (default: $LISA_FAKE_AGENT_SCRIPT; none = reply \"ok\")")
+	fmt.Fprintln(os.Stderr, "  --dir DIR             install: directory for claude/codex shims (prepend to PATH)")
+	fmt.Fprintln(os.Stderr, "  --json                install: JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Scenario steps: think|say|tool|wait_input|hang|crash|exit.")
+	fmt.Fprintln(os.Stderr, "Agent args follow the real CLI: claude [-p] PROMPT, codex [exec] PROMPT.")
+}
+
 func helpVersion() {
 	fmt.Fprintln(os.Stderr, "lisa version — print version info")
 	fmt.Fprintln(os.Stderr, "")
@@ -244,6 +335,8 @@ func helpSessionSpawn() {
 	fmt.Fprintln(os.Stderr, "  --agent NAME          AI agent: claude|codex (default: claude)")
 	fmt.Fprintln(os.Stderr, "  --mode MODE           Session mode: interactive|exec (default: interactive)")
 	fmt.Fprintln(os.Stderr, "  --lane NAME           Apply lane defaults/contracts before spawn")
+	fmt.Fprintln(os.Stderr, "  -l, --label KEY=VALUE Session label stored in metadata (repeatable; protected=true")
+	fmt.Fprintln(os.Stderr, "                        makes kill-all/cleanup skip it without --force)")
 	fmt.Fprintln(os.Stderr, "  --nested-policy MODE  Nested codex bypass policy: auto|force|off (default: auto)")
 	fmt.Fprintln(os.Stderr, "  --nesting-intent MODE Nested intent override: auto|nested|neutral (default: auto)")
 	fmt.Fprintln(os.Stderr, "  --session NAME        Override session name (must start with \"lisa-\")")
@@ -254,6 +347,10 @@ func helpSessionSpawn() {
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory for isolation (default: cwd)")
 	fmt.Fprintln(os.Stderr, "  --width N             Tmux pane width (default: 220)")
 	fmt.Fprintln(os.Stderr, "  --height N            Tmux pane height (default: 60)")
+	fmt.Fprintln(os.Stderr, "  --max-runtime DUR     Wall-clock limit (e.g. 45m); enforced by monitor")
+	fmt.Fprintln(os.Stderr, "  --idle-timeout DUR    Stop after DUR without pane output; enforced by monitor")
+	fmt.Fprintln(os.Stderr, "  --memory-max SIZE     Memory cap for the agent tree (e.g. 4G; cgroup v2, prlimit fallback)")
+	fmt.Fprintln(os.Stderr, "  --cpu-quota PCT       CPU cap (e.g. 200% or 2 cores; cgroup v2, else monitor sampling)")
 	fmt.Fprintln(os.Stderr, "  --cleanup-all-hashes  Clean artifacts across all project hashes")
 	fmt.Fprintln(os.Stderr, "  --dry-run             Print resolved spawn plan without creating session")
 	fmt.Fprintln(os.Stderr, "  --detect-nested       Include nested-bypass detection diagnostics in JSON output")
@@ -262,6 +359,8 @@ func helpSessionSpawn() {
 	fmt.Fprintln(os.Stderr, "  note                  Nested codex exec prompts (./lisa, lisa session spawn)")
 	fmt.Fprintln(os.Stderr, "                        auto-enable '--dangerously-bypass-approvals-and-sandbox'")
 	fmt.Fprintln(os.Stderr, "                        and omit --full-auto")
+	fmt.Fprintln(os.Stderr, "  note                  Claude gets --settings with Stop/Notification hooks and codex")
+	fmt.Fprintln(os.Stderr, "                        gets -c notify=... calling 'lisa hook' (LISA_AGENT_HOOKS=off skips)")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 }
 
@@ -290,15 +389,54 @@ func helpSessionSend() {
 	fmt.Fprintln(os.Stderr, "Usage: lisa session send [flags]")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Flags:")
-	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required)")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required unless --subtree)")
+	fmt.Fprintln(os.Stderr, "  --subtree ROOT        Broadcast to ROOT and all descendant sessions")
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
 	fmt.Fprintln(os.Stderr, "  --text TEXT            Text to send (mutually exclusive with --keys)")
 	fmt.Fprintln(os.Stderr, "  --keys \"KEYS...\"      Tmux keys to send (mutually exclusive with --text)")
 	fmt.Fprintln(os.Stderr, "  --enter               Press Enter after sending")
+	fmt.Fprintln(os.Stderr, "  --when COND           Queue until idle|turn-complete|state=X (see session inbox)")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 	fmt.Fprintln(os.Stderr, "  --json-min            Minimal JSON ack: session/ok")
 }
 
+func helpSessionInbox() {
+	fmt.Fprintln(os.Stderr, "lisa session inbox — inspect and manage deferred sends")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa session inbox [list|clear|deliver] --session NAME [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Actions:")
+	fmt.Fprintln(os.Stderr, "  list                  Show queued messages in delivery order (default)")
+	fmt.Fprintln(os.Stderr, "  clear                 Drop all queued messages, or one with --id")
+	fmt.Fprintln(os.Stderr, "  deliver               Run one delivery pass now")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required)")
+	fmt.Fprintln(os.Stderr, "  --id ID               clear: only drop this message")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+}
+
+func helpSessionAnswer() {
+	fmt.Fprintln(os.Stderr, "lisa session answer — answer a detected pending prompt in a running session")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa session answer [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required)")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --agent AGENT         Agent hint: auto|claude|codex (default: auto)")
+	fmt.Fprintln(os.Stderr, "  --mode MODE           Mode hint: auto|interactive|exec (default: auto)")
+	fmt.Fprintln(os.Stderr, "  --option N            Select option N of the pending prompt")
+	fmt.Fprintln(os.Stderr, "  --text TEXT           Type TEXT and press Enter instead of selecting")
+	fmt.Fprintln(os.Stderr, "  --policy              Answer via prompt policy (LISA_PROMPT_POLICY_FILE or .lisa/prompt-policy.json)")
+	fmt.Fprintln(os.Stderr, "  --force               Send even when no pending prompt is detected")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Exactly one of --option, --text, --policy is required. Decisions are")
+	fmt.Fprintln(os.Stderr, "logged as prompt_decision events; monitor auto-answers when a policy exists.")
+}
+
 func helpSessionSnapshot() {
 	fmt.Fprintln(os.Stderr, "lisa session snapshot — one-shot status + raw capture + nextOffset")
 	fmt.Fprintln(os.Stderr, "")
@@ -331,6 +469,7 @@ func helpSessionStatus() {
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
 	fmt.Fprintln(os.Stderr, "  --full                Include classification/signal columns")
 	fmt.Fprintln(os.Stderr, "  --fail-not-found      Exit 1 when session resolves to not_found")
+	fmt.Fprintln(os.Stderr, "  --record DIR          Dump every classifier input to DIR for 'lisa classify --fixture'")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 	fmt.Fprintln(os.Stderr, "  --json-min            Minimal JSON output: session/status/state/todos/wait")
 }
@@ -348,6 +487,7 @@ func helpSessionExplain() {
 	fmt.Fprintln(os.Stderr, "  --events N            Number of recent events to show (default: 10)")
 	fmt.Fprintln(os.Stderr, "  --recent N            Alias for --events with compact intent")
 	fmt.Fprintln(os.Stderr, "  --since VALUE         Event cursor: offset integer, @unix timestamp, or RFC3339")
+	fmt.Fprintln(os.Stderr, "  --trace               Show classification rule evaluation (facts, rules, fired rule)")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 	fmt.Fprintln(os.Stderr, "  --json-min            Minimal JSON output: session/state/reason/recent events")
 }
@@ -358,7 +498,11 @@ func helpSessionMonitor() {
 	fmt.Fprintln(os.Stderr, "Usage: lisa session monitor [flags]")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Flags:")
-	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required)")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required unless fan-in selectors are set)")
+	fmt.Fprintln(os.Stderr, "  --sessions CSV        Fan-in: monitor several sessions concurrently")
+	fmt.Fprintln(os.Stderr, "  --tree ROOT           Fan-in: monitor ROOT and all descendant sessions")
+	fmt.Fprintln(os.Stderr, "  -l, --label SELECTOR  Fan-in: monitor sessions matching k=v,k!=v,k,!k (repeatable)")
+	fmt.Fprintln(os.Stderr, "  --until all|any|N     Fan-in: stop once all/any/N sessions reach a stop reason (default: all)")
 	fmt.Fprintln(os.Stderr, "  --agent NAME          Agent hint: auto|claude|codex (default: auto)")
 	fmt.Fprintln(os.Stderr, "  --mode MODE           Mode hint: auto|interactive|exec (default: auto)")
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
@@ -393,8 +537,10 @@ func helpSessionCapture() {
 	fmt.Fprintln(os.Stderr, "Flags:")
 	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required)")
 	fmt.Fprintln(os.Stderr, "  --raw                 Raw tmux pane capture instead of transcript")
+	fmt.Fprintln(os.Stderr, "  --format FMT          Transcript render with tool calls: plain|markdown|jsonl")
 	fmt.Fprintln(os.Stderr, "  --delta-from VALUE    Delta start: offset integer, @unix timestamp, or RFC3339")
-	fmt.Fprintln(os.Stderr, "  --cursor-file PATH    Persist/reuse delta offset cursor (raw capture only)")
+	fmt.Fprintln(os.Stderr, "                        (with --format: transcript message index)")
+	fmt.Fprintln(os.Stderr, "  --cursor-file PATH    Persist/reuse delta cursor (--raw offsets or --format indices)")
 	fmt.Fprintln(os.Stderr, "  --markers CSV         Marker-only extraction (comma-separated)")
 	fmt.Fprintln(os.Stderr, "  --markers-json        Include structured marker hit offsets/lines")
 	fmt.Fprintln(os.Stderr, "  --summary             Return bounded summary instead of full capture")
@@ -410,13 +556,37 @@ func helpSessionCapture() {
 	fmt.Fprintln(os.Stderr, "  --json-min            Minimal JSON output for compact polling workflows")
 }
 
+func helpSessionTail() {
+	fmt.Fprintln(os.Stderr, "lisa session tail — stream new output lines from one or many sessions")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa session tail [--session NAME|--sessions CSV|--tree ROOT|--label k=v|--all] [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session to tail")
+	fmt.Fprintln(os.Stderr, "  --sessions CSV        Comma-separated sessions to tail")
+	fmt.Fprintln(os.Stderr, "  --tree ROOT           Tail ROOT and all of its descendants")
+	fmt.Fprintln(os.Stderr, "  -l, --label SELECTOR  Tail sessions matching k=v,k!=v,k,!k (repeatable)")
+	fmt.Fprintln(os.Stderr, "  --all                 Tail every lisa session of the project")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory context (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --follow, -f          Keep streaming; sessions may join and leave")
+	fmt.Fprintln(os.Stderr, "  --lines N             Backlog lines per session on first sight (default: 10)")
+	fmt.Fprintln(os.Stderr, "  --since WHEN          Skip backlog of sessions idle since WHEN (RFC3339 or duration)")
+	fmt.Fprintln(os.Stderr, "  --grep REGEX          Only emit lines matching REGEX")
+	fmt.Fprintln(os.Stderr, "  --raw                 Keep Codex/MCP startup noise")
+	fmt.Fprintln(os.Stderr, "  --poll-interval DUR   Poll interval with --follow (default: 1s)")
+	fmt.Fprintln(os.Stderr, "  --max-polls N         Stop after N polls (default: unbounded with --follow)")
+	fmt.Fprintln(os.Stderr, "  --no-color            Disable per-session prefix colors")
+	fmt.Fprintln(os.Stderr, "  --json                Emit one JSON event per line (type: line|joined|left)")
+}
+
 func helpSessionPacket() {
 	fmt.Fprintln(os.Stderr, "lisa session packet — build status+capture+handoff packet")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Usage: lisa session packet [flags]")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Flags:")
-	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required)")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required unless --label)")
+	fmt.Fprintln(os.Stderr, "  -l, --label SELECTOR  One packet per matching session (JSON: one per line)")
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
 	fmt.Fprintln(os.Stderr, "  --agent NAME          Agent hint: auto|claude|codex (default: auto)")
 	fmt.Fprintln(os.Stderr, "  --mode MODE           Mode hint: auto|interactive|exec (default: auto)")
@@ -488,12 +658,14 @@ func helpSessionSchema() {
 }
 
 func helpSessionCheckpoint() {
-	fmt.Fprintln(os.Stderr, "lisa session checkpoint — save/resume orchestration state bundles")
+	fmt.Fprintln(os.Stderr, "lisa session checkpoint — save/resume/export/import orchestration state bundles")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Usage: lisa session checkpoint [save|resume] [flags]")
+	fmt.Fprintln(os.Stderr, "       lisa session checkpoint export --session NAME --archive PATH [--include-diff]")
+	fmt.Fprintln(os.Stderr, "       lisa session checkpoint import --archive PATH [--session NAME] [--apply-diff] [--spawn]")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Flags:")
-	fmt.Fprintln(os.Stderr, "  --action MODE         save|resume (default: save)")
+	fmt.Fprintln(os.Stderr, "  --action MODE         save|resume|export|import (default: save)")
 	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required for save)")
 	fmt.Fprintln(os.Stderr, "  --file PATH           Checkpoint file path (required)")
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory context (default: cwd)")
@@ -501,6 +673,11 @@ func helpSessionCheckpoint() {
 	fmt.Fprintln(os.Stderr, "  --lines N             Capture tail lines to include in bundle")
 	fmt.Fprintln(os.Stderr, "  --strategy MODE       Context-pack strategy: terse|balanced|full")
 	fmt.Fprintln(os.Stderr, "  --token-budget N      Context-pack token budget")
+	fmt.Fprintln(os.Stderr, "  --archive PATH        Portable .tar.gz bundle (export/import)")
+	fmt.Fprintln(os.Stderr, "  --include-diff        export: include `git diff HEAD` of the project")
+	fmt.Fprintln(os.Stderr, "  --apply-diff          import: git apply the bundled diff to --project-root")
+	fmt.Fprintln(os.Stderr, "  --spawn               import: spawn the resumed session immediately")
+	fmt.Fprintln(os.Stderr, "  --force               import: overwrite existing session metadata")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 }
 
@@ -536,6 +713,7 @@ func helpSessionAggregate() {
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Flags:")
 	fmt.Fprintln(os.Stderr, "  --sessions CSV        Optional explicit session list (default: all active)")
+	fmt.Fprintln(os.Stderr, "  -l, --label SELECTOR  Label selector: k=v,k!=v,k,!k (repeatable; terms AND)")
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory context (default: cwd)")
 	fmt.Fprintln(os.Stderr, "  --strategy MODE       Pack strategy: terse|balanced|full (default: balanced)")
 	fmt.Fprintln(os.Stderr, "  --events N            Recent event tail per session (default: 8)")
@@ -581,7 +759,7 @@ func helpSessionDiffPack() {
 	fmt.Fprintln(os.Stderr, "  --lines N             Capture lines for pack tail (default: 120)")
 	fmt.Fprintln(os.Stderr, "  --token-budget N      Pack token budget (default: 700)")
 	fmt.Fprintln(os.Stderr, "  --cursor-file PATH    Cursor file to diff previous/next packs")
-	fmt.Fprintln(os.Stderr, "  --redact CSV          Redaction rules: none|all|paths|emails|secrets|numbers|tokens")
+	fmt.Fprintln(os.Stderr, "  --redact CSV          Redaction rules: none|all|paths|emails|secrets|numbers|tokens|keys|entropy")
 	fmt.Fprintln(os.Stderr, "  --semantic-only       Diff semantic lines only (suppresses boilerplate churn)")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 	fmt.Fprintln(os.Stderr, "  --json-min            Minimal JSON output")
@@ -714,7 +892,7 @@ func helpSessionHandoff() {
 	fmt.Fprintln(os.Stderr, "  --events N            Number of recent events to include (default: 8)")
 	fmt.Fprintln(os.Stderr, "  --delta-from N        Incremental event offset (non-negative integer)")
 	fmt.Fprintln(os.Stderr, "  --cursor-file PATH    Persist/reuse incremental event offset")
-	fmt.Fprintln(os.Stderr, "  --compress MODE       Payload compression: none|zstd (default: none)")
+	fmt.Fprintln(os.Stderr, "  --compress MODE       Payload compression: none|zstd|gzip (default: none)")
 	fmt.Fprintln(os.Stderr, "  --schema MODE         Handoff schema: v1|v2|v3|v4 (default: v1)")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 	fmt.Fprintln(os.Stderr, "  --json-min            Minimal JSON output")
@@ -735,7 +913,7 @@ func helpSessionContextPack() {
 	fmt.Fprintln(os.Stderr, "  --token-budget N      Approx token budget for pack body (default: 700)")
 	fmt.Fprintln(os.Stderr, "  --strategy MODE       Pack strategy: terse|balanced|full (default: balanced)")
 	fmt.Fprintln(os.Stderr, "  --from-handoff PATH   Build from handoff JSON payload (use '-' for stdin)")
-	fmt.Fprintln(os.Stderr, "  --redact CSV          Redaction rules: none|all|paths|emails|secrets|numbers|tokens")
+	fmt.Fprintln(os.Stderr, "  --redact CSV          Redaction rules: none|all|paths|emails|secrets|numbers|tokens|keys|entropy")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 	fmt.Fprintln(os.Stderr, "  --json-min            Minimal JSON output")
 }
@@ -807,6 +985,10 @@ func helpSessionGuard() {
 	fmt.Fprintln(os.Stderr, "  --policy-file PATH    Optional JSON policy contract for guard evaluation")
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory context (default: cwd)")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Policy: without --policy-file, .lisa/guard-policy.json (or LISA_GUARD_POLICY_FILE) is used.")
+	fmt.Fprintln(os.Stderr, "Its rules also run before every mutating command; allow|warn|deny|require-confirm.")
+	fmt.Fprintln(os.Stderr, "Pass --policy-confirm to any mutating command to satisfy require-confirm rules.")
 }
 
 func helpSessionPreflight() {
@@ -891,6 +1073,7 @@ func helpSessionList() {
 	fmt.Fprintln(os.Stderr, "  --all-sockets         Discover active sessions across project sockets")
 	fmt.Fprintln(os.Stderr, "  --project-only        Only show sessions for current project")
 	fmt.Fprintln(os.Stderr, "  --active-only         Filter out sessions that resolve to not_found")
+	fmt.Fprintln(os.Stderr, "  -l, --label SELECTOR  Label selector: k=v,k!=v,k,!k (repeatable; terms AND)")
 	fmt.Fprintln(os.Stderr, "  --with-next-action    Include nextAction/status/sessionState per session")
 	fmt.Fprintln(os.Stderr, "  --priority            Include + sort by priority score")
 	fmt.Fprintln(os.Stderr, "  --stale               Include stale metadata-only session counts/list")
@@ -915,11 +1098,13 @@ func helpSessionTree() {
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
 	fmt.Fprintln(os.Stderr, "  --all-hashes          Include metadata from all project hashes")
 	fmt.Fprintln(os.Stderr, "  --active-only         Include only sessions currently active in tmux")
+	fmt.Fprintln(os.Stderr, "  -l, --label SELECTOR  Label selector: k=v,k!=v,k,!k (repeatable; terms AND)")
 	fmt.Fprintln(os.Stderr, "  --delta               Output topology changes since previous tree snapshot")
 	fmt.Fprintln(os.Stderr, "  --delta-json          Output added/removed/changed rows since cursor snapshot")
 	fmt.Fprintln(os.Stderr, "  --cursor-file PATH    Cursor file for --delta-json snapshots")
 	fmt.Fprintln(os.Stderr, "  --flat                Print machine-friendly parent/child rows")
 	fmt.Fprintln(os.Stderr, "  --with-state          Enrich rows with status/sessionState snapshots")
+	fmt.Fprintln(os.Stderr, "  --format FMT          Output format: text|dot|mermaid (default: text)")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 	fmt.Fprintln(os.Stderr, "  --json-min            Minimal JSON output: nodeCount + total/filtered counts")
 }
@@ -971,6 +1156,25 @@ func helpSessionExists() {
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 }
 
+func helpSessionStop() {
+	fmt.Fprintln(os.Stderr, "lisa session stop — graceful stop with final archive")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa session stop [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Sends the agent's exit sequence (Ctrl-C for exec; Esc + /exit or /quit for")
+	fmt.Fprintln(os.Stderr, "interactive), waits for the done marker, archives capture, events, meta,")
+	fmt.Fprintln(os.Stderr, "state and transcript path to the history store, then kills like session kill.")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required)")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --grace DURATION      Wait for the agent to exit before killing (default: 30s)")
+	fmt.Fprintln(os.Stderr, "  --cleanup-all-hashes  Clean artifacts across all project hashes")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Archives go to $LISA_HISTORY_DIR (default ~/.lisa/history)/archives/<hash>/.")
+}
+
 func helpSessionKill() {
 	fmt.Fprintln(os.Stderr, "lisa session kill — kill a session and clean artifacts")
 	fmt.Fprintln(os.Stderr, "")
@@ -980,6 +1184,8 @@ func helpSessionKill() {
 	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (required)")
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
 	fmt.Fprintln(os.Stderr, "  --cleanup-all-hashes  Clean artifacts across all project hashes")
+	fmt.Fprintln(os.Stderr, "  --recursive           Stop descendants children-first with Ctrl-C before killing")
+	fmt.Fprintln(os.Stderr, "  --grace DURATION      Per-level wait after Ctrl-C with --recursive (default: 10s)")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 }
 
@@ -990,6 +1196,8 @@ func helpSessionKillAll() {
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Flags:")
 	fmt.Fprintln(os.Stderr, "  --project-only        Only kill sessions for current project")
+	fmt.Fprintln(os.Stderr, "  -l, --label SELECTOR  Label selector: k=v,k!=v,k,!k (repeatable; terms AND)")
+	fmt.Fprintln(os.Stderr, "  --force               Also kill sessions labeled protected=true")
 	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
 	fmt.Fprintln(os.Stderr, "  --cleanup-all-hashes  Clean artifacts across all project hashes")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
@@ -1073,6 +1281,95 @@ func helpOAuthRemove() {
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 }
 
+func helpQueue() {
+	fmt.Fprintln(os.Stderr, "lisa queue — persistent per-project job queue")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa queue <subcommand> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Subcommands:")
+	fmt.Fprintln(os.Stderr, "  add      Queue a spawn job")
+	fmt.Fprintln(os.Stderr, "  list     List jobs (running, queued by priority, then finished)")
+	fmt.Fprintln(os.Stderr, "  cancel   Cancel jobs (kills sessions of running jobs)")
+	fmt.Fprintln(os.Stderr, "  drain    Let running workers finish in-flight jobs and exit")
+	fmt.Fprintln(os.Stderr, "  work     Spawn/monitor/handoff jobs as slots free up")
+}
+
+func helpQueueAdd() {
+	fmt.Fprintln(os.Stderr, "lisa queue add — queue a spawn job")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa queue add --prompt TEXT [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --prompt TEXT         Prompt for the spawned agent (required)")
+	fmt.Fprintln(os.Stderr, "  --agent NAME          claude|codex (default: lane, then claude)")
+	fmt.Fprintln(os.Stderr, "  --mode MODE           interactive|exec (default: lane, then interactive)")
+	fmt.Fprintln(os.Stderr, "  --lane NAME           Spawn with lane defaults")
+	fmt.Fprintln(os.Stderr, "  --model NAME          Model override")
+	fmt.Fprintln(os.Stderr, "  --priority N          Higher runs first (default: 0)")
+	fmt.Fprintln(os.Stderr, "  --task-hash HASH      Dedupe key; rejected if already open in queue")
+	fmt.Fprintln(os.Stderr, "  --retries N           Extra attempts on retryable failures (default: 0)")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+}
+
+func helpQueueList() {
+	fmt.Fprintln(os.Stderr, "lisa queue list — list queue jobs")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa queue list [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --state STATE         queued|running|completed|failed|cancelled|duplicate")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+}
+
+func helpQueueCancel() {
+	fmt.Fprintln(os.Stderr, "lisa queue cancel — cancel queue jobs")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa queue cancel (--id ID... | --all) [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --id ID               Job id (repeatable)")
+	fmt.Fprintln(os.Stderr, "  --all                 Cancel every queued and running job")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+}
+
+func helpQueueDrain() {
+	fmt.Fprintln(os.Stderr, "lisa queue drain — stop workers from starting new jobs")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa queue drain [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Running workers finish in-flight jobs and exit; queued jobs stay queued")
+	fmt.Fprintln(os.Stderr, "for the next 'queue work'.")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --cancel-queued       Also cancel every queued job")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+}
+
+func helpQueueWork() {
+	fmt.Fprintln(os.Stderr, "lisa queue work — run queued jobs with a concurrency limit")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa queue work [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Each job: claim --task-hash via session dedupe, spawn, monitor to a")
+	fmt.Fprintln(os.Stderr, "terminal state (interactive: until waiting), handoff, kill. Exits when")
+	fmt.Fprintln(os.Stderr, "the queue is empty unless --watch.")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --concurrency N       Max concurrent sessions (default: 1)")
+	fmt.Fprintln(os.Stderr, "  --poll-interval N     Monitor poll interval seconds (default: 30)")
+	fmt.Fprintln(os.Stderr, "  --max-polls N         Monitor max polls per job (default: 120)")
+	fmt.Fprintln(os.Stderr, "  --retry-on STATES     CSV of final states to retry (default:")
+	fmt.Fprintln(os.Stderr, "                        crashed,stuck,not_found,timeout,spawn_failed)")
+	fmt.Fprintln(os.Stderr, "  --keep-sessions       Do not kill sessions after handoff")
+	fmt.Fprintln(os.Stderr, "  --watch               Keep waiting for new jobs when queue is empty")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project directory (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON summary output")
+}
+
 func helpSkills() {
 	fmt.Fprintln(os.Stderr, "lisa skills — manage lisa skill files")
 	fmt.Fprintln(os.Stderr, "")
@@ -1127,3 +1424,313 @@ func helpSkillsDoctor() {
 	fmt.Fprintln(os.Stderr, "  --sync-plan           Include machine-readable sync/install plan")
 	fmt.Fprintln(os.Stderr, "  --json                JSON output")
 }
+
+func helpAudit() {
+	fmt.Fprintln(os.Stderr, "lisa audit — per-user, hash-chained log of mutating commands")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa audit <subcommand> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Subcommands:")
+	fmt.Fprintln(os.Stderr, "  list     List entries (newest last)")
+	fmt.Fprintln(os.Stderr, "  show     Show one entry by sequence number")
+	fmt.Fprintln(os.Stderr, "  verify   Recompute the hash chain and report tampering")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Audited: session spawn/send/answer/turn/stop/kill/kill-all, session checkpoint import,")
+	fmt.Fprintln(os.Stderr, "  session inbox clear/deliver, queue add/cancel/drain/work, msg post/ack, result put,")
+	fmt.Fprintln(os.Stderr, "  cleanup, oauth add/remove; plus inbox delivery, prompt auto-answer and limit")
+	fmt.Fprintln(os.Stderr, "  interrupt/kill entries for actions lisa takes on its own.")
+	fmt.Fprintln(os.Stderr, "Log: ~/.lisa/audit.jsonl (override with LISA_AUDIT_LOG).")
+}
+
+func helpHistory() {
+	fmt.Fprintln(os.Stderr, "lisa history — browse finished sessions")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa history <subcommand> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Subcommands:")
+	fmt.Fprintln(os.Stderr, "  list     List finished sessions (newest last)")
+	fmt.Fprintln(os.Stderr, "  show     Show one finished session and its archive")
+	fmt.Fprintln(os.Stderr, "  search   Filter by text in session, prompt, objective, lane, model, labels")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "session stop/kill/kill-all and limit kills append to $LISA_HISTORY_DIR/index.jsonl")
+	fmt.Fprintln(os.Stderr, "(default ~/.lisa/history) once the kill succeeds, one entry per run.")
+}
+
+func helpHistoryListFlags() {
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --since WHEN          RFC3339 time or duration ago (e.g. 24h)")
+	fmt.Fprintln(os.Stderr, "  --state CSV           Final states: completed,crashed,stopped,killed")
+	fmt.Fprintln(os.Stderr, "  --agent NAME          Only claude or codex sessions")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Only sessions of this project (default: all)")
+	fmt.Fprintln(os.Stderr, "  --limit N             Keep the newest N matches (default: 50, 0 = all)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Text output: endedAt<TAB>state<TAB>agent[/model]<TAB>duration<TAB>tokens<TAB>session")
+}
+
+func helpHistoryList() {
+	fmt.Fprintln(os.Stderr, "lisa history list — list finished sessions")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa history list [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	helpHistoryListFlags()
+}
+
+func helpHistorySearch() {
+	fmt.Fprintln(os.Stderr, "lisa history search — search finished sessions")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa history search QUERY [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "QUERY is a case-insensitive substring of session, prompt, objective,")
+	fmt.Fprintln(os.Stderr, "lane, model, state or a key=value label.")
+	fmt.Fprintln(os.Stderr, "")
+	helpHistoryListFlags()
+}
+
+func helpHistoryShow() {
+	fmt.Fprintln(os.Stderr, "lisa history show — show one finished session")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa history show --session NAME [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session name (newest record wins)")
+	fmt.Fprintln(os.Stderr, "  --run-id ID           Select by run id instead of (or with) --session")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output (adds the archive manifest when present)")
+}
+
+func helpReport() {
+	fmt.Fprintln(os.Stderr, "lisa report — summarize agent work over a time window")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa report [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Built from the history index, session event logs and objectives: sessions run,")
+	fmt.Fprintln(os.Stderr, "outcomes, time to completion, stuck/crash reasons, nudges, auto-recover,")
+	fmt.Fprintln(os.Stderr, "tokens, lane/objective rollups, and slowest/failed sessions with archive links.")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --since WHEN          RFC3339 time or duration ago (default: 24h)")
+	fmt.Fprintln(os.Stderr, "  --until TIME          RFC3339 end of the window (default: now)")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Only this project (default: all projects)")
+	fmt.Fprintln(os.Stderr, "  --format FMT          markdown|html|json (default: markdown)")
+	fmt.Fprintln(os.Stderr, "  --json                Same as --format json")
+}
+
+func helpEvents() {
+	fmt.Fprintln(os.Stderr, "lisa events — query session event logs")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa events <subcommand> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Subcommands:")
+	fmt.Fprintln(os.Stderr, "  query    Filter events across sessions (rotated segments included)")
+}
+
+func helpEventsQuery() {
+	fmt.Fprintln(os.Stderr, "lisa events query — filter session events across sessions")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa events query [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --project-root PATH   Project whose event logs to read (default: cwd)")
+	fmt.Fprintln(os.Stderr, "  --all-hashes          Read event logs of every project")
+	fmt.Fprintln(os.Stderr, "  --session-glob GLOB   Only sessions matching GLOB (e.g. 'lisa-api-*')")
+	fmt.Fprintln(os.Stderr, "  --type CSV            Event types (globs allowed)")
+	fmt.Fprintln(os.Stderr, "  --state CSV           Session states (globs allowed)")
+	fmt.Fprintln(os.Stderr, "  --reason CSV          Reasons (globs allowed, e.g. 'kill_*')")
+	fmt.Fprintln(os.Stderr, "  --since WHEN          RFC3339 time or duration ago (e.g. 2h)")
+	fmt.Fprintln(os.Stderr, "  --until WHEN          RFC3339 time or duration ago")
+	fmt.Fprintln(os.Stderr, "  --where EXPR          Filter expression (repeatable, ANDed)")
+	fmt.Fprintln(os.Stderr, "  --format FMT          ndjson|csv|table (default: table)")
+	fmt.Fprintln(os.Stderr, "  --group-by FIELD      Count per reason|state|session|type (implies --count)")
+	fmt.Fprintln(os.Stderr, "  --count               Print only the number of matches")
+	fmt.Fprintln(os.Stderr, "  --limit N             Keep the earliest N matches by time (default: 0 = all)")
+	fmt.Fprintln(os.Stderr, "  --json                One JSON object instead of streamed rows")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "EXPR: terms joined by && and ||; a term is a dotted path into the event")
+	fmt.Fprintln(os.Stderr, "JSON, optionally negated (!path) or compared with = != ~ !~ > >= < <=,")
+	fmt.Fprintln(os.Stderr, "e.g. 'signals.promptWaiting && poll > 3' or 'reason ~ ^monitor_'.")
+	fmt.Fprintln(os.Stderr, "Rows stream per session log in file order.")
+}
+
+func helpPayload() {
+	fmt.Fprintln(os.Stderr, "lisa payload — work with compressed transfer payloads")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa payload <subcommand> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Subcommands:")
+	fmt.Fprintln(os.Stderr, "  decode   Expand compressedPayload fields in handoff/packet JSON")
+}
+
+func helpPayloadDecode() {
+	fmt.Fprintln(os.Stderr, "lisa payload decode — expand compressed handoff/packet JSON")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa session handoff --session NAME --compress zstd --json | lisa payload decode")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --json                JSON error envelopes")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Reads JSON on stdin and replaces every compressedPayload (encoding")
+	fmt.Fprintln(os.Stderr, "base64-zstd or base64-gzip) with its fields; fields already present win.")
+	fmt.Fprintln(os.Stderr, "Input without a compressed payload is printed unchanged.")
+}
+
+func helpAuditList() {
+	fmt.Fprintln(os.Stderr, "lisa audit list — list audit log entries")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa audit list [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Only entries acting on NAME")
+	fmt.Fprintln(os.Stderr, "  --command NAME        Only this command (e.g. \"session send\" or \"send\")")
+	fmt.Fprintln(os.Stderr, "  --caller NAME         Only entries issued from inside session NAME")
+	fmt.Fprintln(os.Stderr, "  --since WHEN          RFC3339 time or duration ago (e.g. 24h)")
+	fmt.Fprintln(os.Stderr, "  --failed              Only non-zero exits")
+	fmt.Fprintln(os.Stderr, "  --limit N             Keep the newest N matches (default: 50, 0 = all)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Text output: seq<TAB>at<TAB>command<TAB>session<TAB>exit")
+}
+
+func helpAuditShow() {
+	fmt.Fprintln(os.Stderr, "lisa audit show — show one audit entry")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa audit show --seq N [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --seq N               Entry sequence number (required)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+}
+
+func helpAuditVerify() {
+	fmt.Fprintln(os.Stderr, "lisa audit verify — verify the audit hash chain")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa audit verify [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Exit 1 with errorCode audit_chain_broken on edited, reordered, removed,")
+	fmt.Fprintln(os.Stderr, "or truncated entries.")
+}
+
+func helpMsg() {
+	fmt.Fprintln(os.Stderr, "lisa msg — per-project mailbox between parent and child sessions")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa msg <subcommand> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Subcommands:")
+	fmt.Fprintln(os.Stderr, "  post     Post a result/question/progress message")
+	fmt.Fprintln(os.Stderr, "  read     Read unread messages for a recipient (acknowledges them)")
+	fmt.Fprintln(os.Stderr, "  ack      Acknowledge messages by sequence number")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Inside a lisa pane the sender, recipient and project root default to")
+	fmt.Fprintln(os.Stderr, "LISA_SESSION_NAME and LISA_PROJECT_ROOT; outside a pane they default to")
+	fmt.Fprintln(os.Stderr, "\"orchestrator\" and the current directory. Monitors surface unread messages")
+	fmt.Fprintln(os.Stderr, "from watched sessions; handoff packets list them under \"messages\".")
+}
+
+func helpMsgPost() {
+	fmt.Fprintln(os.Stderr, "lisa msg post — post a mailbox message")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa msg post --to parent|orchestrator|SESSION --body TEXT [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --to TARGET           parent (sender's parent session, else orchestrator),")
+	fmt.Fprintln(os.Stderr, "                        orchestrator, or a session name (required)")
+	fmt.Fprintln(os.Stderr, "  --kind KIND           result|question|progress (default: progress)")
+	fmt.Fprintln(os.Stderr, "  --body TEXT           Message body (required)")
+	fmt.Fprintln(os.Stderr, "  --from NAME           Sender (default: LISA_SESSION_NAME or orchestrator)")
+	fmt.Fprintln(os.Stderr, "  --project-root DIR    Project root (default: LISA_PROJECT_ROOT or cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+}
+
+func helpMsgRead() {
+	fmt.Fprintln(os.Stderr, "lisa msg read — read unread mailbox messages")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa msg read [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Recipient (default: LISA_SESSION_NAME or orchestrator)")
+	fmt.Fprintln(os.Stderr, "  --from NAME           Only messages from NAME")
+	fmt.Fprintln(os.Stderr, "  --kind KIND           Only result|question|progress messages")
+	fmt.Fprintln(os.Stderr, "  --limit N             Return at most N messages (oldest first; 0 = all)")
+	fmt.Fprintln(os.Stderr, "  --peek                Do not acknowledge returned messages")
+	fmt.Fprintln(os.Stderr, "  --all                 Include already acknowledged messages")
+	fmt.Fprintln(os.Stderr, "  --project-root DIR    Project root (default: LISA_PROJECT_ROOT or cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Text output: #seq<TAB>at<TAB>kind<TAB>from<TAB>body")
+}
+
+func helpMsgAck() {
+	fmt.Fprintln(os.Stderr, "lisa msg ack — acknowledge mailbox messages")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa msg ack --seq N[,N...] [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --seq CSV             Message sequence numbers (required)")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Recipient (default: LISA_SESSION_NAME or orchestrator)")
+	fmt.Fprintln(os.Stderr, "  --project-root DIR    Project root (default: LISA_PROJECT_ROOT or cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+}
+
+func helpResult() {
+	fmt.Fprintln(os.Stderr, "lisa result — explicit completion reports from agents")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa result <subcommand> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Subcommands:")
+	fmt.Fprintln(os.Stderr, "  put      Record the session's declared outcome")
+	fmt.Fprintln(os.Stderr, "  get      Show the declared outcome")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "status, monitor (exitReason=result_reported), handoff and autopilot prefer")
+	fmt.Fprintln(os.Stderr, "a declared result over scraped pane text. Agents can also print")
+	fmt.Fprintln(os.Stderr, "\"LISA_PROGRESS 3/7 <step>\" lines, which feed todosDone/todosTotal.")
+}
+
+func helpResultPut() {
+	fmt.Fprintln(os.Stderr, "lisa result put — declare a session's outcome")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa result put --status success|failed|blocked [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --status STATUS       success|failed|blocked (required)")
+	fmt.Fprintln(os.Stderr, "  --summary TEXT        One-line outcome summary")
+	fmt.Fprintln(os.Stderr, "  --artifact PATH       Produced artifact (repeatable; relative paths are made absolute)")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session (default: LISA_SESSION_NAME)")
+	fmt.Fprintln(os.Stderr, "  --run-id ID           Run the result belongs to (default: LISA_RUN_ID inside a pane)")
+	fmt.Fprintln(os.Stderr, "  --project-root DIR    Project root (default: LISA_PROJECT_ROOT or cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+}
+
+func helpResultGet() {
+	fmt.Fprintln(os.Stderr, "lisa result get — show a session's declared result")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa result get --session NAME [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session (default: LISA_SESSION_NAME)")
+	fmt.Fprintln(os.Stderr, "  --project-root DIR    Project root")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Exit 1 with errorCode result_not_found when nothing was reported.")
+}
+
+func helpHook() {
+	fmt.Fprintln(os.Stderr, "lisa hook — record an agent lifecycle callback")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: lisa hook --agent claude|codex [flags] [PAYLOAD]")
+	fmt.Fprintln(os.Stderr, "       lisa hook --event turn_complete|needs_input|prompt_submitted [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Spawn wires this into Claude Stop/Notification/UserPromptSubmit hooks (payload")
+	fmt.Fprintln(os.Stderr, "on stdin) and the codex notify program (payload as last argument). Events land in")
+	fmt.Fprintln(os.Stderr, "session state; the classifier treats a fresh one as its strongest live signal.")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	fmt.Fprintln(os.Stderr, "  --agent NAME          Payload format: claude|codex")
+	fmt.Fprintln(os.Stderr, "  --event NAME          Record NAME directly instead of parsing a payload")
+	fmt.Fprintln(os.Stderr, "  --session NAME        Session (default: LISA_SESSION_NAME)")
+	fmt.Fprintln(os.Stderr, "  --project-root DIR    Project root (default: LISA_PROJECT_ROOT or cwd)")
+	fmt.Fprintln(os.Stderr, "  --json                JSON output")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Always exits 0 once flags parse, so a state write error never breaks the agent.")
+}
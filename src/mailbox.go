@@ -0,0 +1,389 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	mailboxOrchestrator       = "orchestrator"
+	defaultMailboxMaxMessages = 1000
+	mailboxTailReadBytes      = 64 * 1024
+)
+
+var mailboxKinds = []string{"result", "question", "progress"}
+
+// mailboxMessage is one agent-to-agent note. Seq is project-wide and
+// monotonically increasing, which is what read cursors are built on.
+type mailboxMessage struct {
+	Seq  int    `json:"seq"`
+	At   string `json:"at"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	Body string `json:"body"`
+}
+
+// mailboxCursor tracks what a recipient has acknowledged: everything up to
+// Cursor, plus out-of-order acks above it (from filtered reads).
+type mailboxCursor struct {
+	Cursor int   `json:"cursor"`
+	Acked  []int `json:"acked,omitempty"`
+}
+
+type mailboxCursorStore struct {
+	Recipients map[string]mailboxCursor `json:"recipients"`
+	UpdatedAt  string                   `json:"updatedAt"`
+}
+
+func mailboxFile(projectRoot string) string {
+	return fmt.Sprintf("/tmp/.lisa-%s-mailbox.jsonl", projectHash(projectRoot))
+}
+
+func mailboxCursorFile(projectRoot string) string {
+	return fmt.Sprintf("/tmp/.lisa-%s-mailbox-cursors.json", projectHash(projectRoot))
+}
+
+// mailboxSeqFile is a sidecar holding the last seq and the line count, so a
+// post neither re-reads the whole log nor has to count it for retention.
+func mailboxSeqFile(projectRoot string) string {
+	return fmt.Sprintf("/tmp/.lisa-%s-mailbox-seq.json", projectHash(projectRoot))
+}
+
+type mailboxSeqState struct {
+	Seq   int `json:"seq"`
+	Lines int `json:"lines"`
+}
+
+func withMailboxLock(projectRoot string, fn func() error) error {
+	lockTimeout := getIntEnv("LISA_EVENT_LOCK_TIMEOUT_MS", defaultEventLockTimeoutMS)
+	return withExclusiveFileLock(mailboxFile(projectRoot)+".lock", lockTimeout, fn)
+}
+
+func parseMailboxKind(raw string) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	for _, kind := range mailboxKinds {
+		if value == kind {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid --kind: %s (expected %s)", raw, strings.Join(mailboxKinds, "|"))
+}
+
+func readMailbox(projectRoot string) ([]mailboxMessage, error) {
+	file, err := os.Open(mailboxFile(projectRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	messages := []mailboxMessage{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		message := mailboxMessage{}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			continue
+		}
+		messages = append(messages, message)
+	}
+	return messages, scanner.Err()
+}
+
+func appendMailboxMessage(projectRoot string, message mailboxMessage) (mailboxMessage, error) {
+	err := withMailboxLock(projectRoot, func() error {
+		seqState, err := loadMailboxSeqState(projectRoot)
+		if err != nil {
+			return err
+		}
+		message.Seq = seqState.Seq + 1
+		message.At = nowFn().UTC().Format(time.RFC3339Nano)
+		message.Body = redactOutputText(message.Body)
+		data, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+		file, err := os.OpenFile(mailboxFile(projectRoot), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(append(data, '\n')); err != nil {
+			_ = file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		seqState = mailboxSeqState{Seq: message.Seq, Lines: seqState.Lines + 1}
+		maxMessages := getIntEnv("LISA_MAILBOX_MAX_MESSAGES", defaultMailboxMaxMessages)
+		if maxMessages <= 0 {
+			maxMessages = defaultMailboxMaxMessages
+		}
+		if seqState.Lines > maxMessages {
+			kept, err := compactMailbox(projectRoot, maxMessages)
+			if err != nil {
+				return err
+			}
+			seqState.Lines = kept
+		}
+		return saveMailboxSeqState(projectRoot, seqState)
+	})
+	return message, err
+}
+
+// loadMailboxSeqState reads the sidecar. Without it (first post, or a log
+// from before the sidecar existed) the last seq comes from the log's tail
+// and the line count from one full scan.
+func loadMailboxSeqState(projectRoot string) (mailboxSeqState, error) {
+	state := mailboxSeqState{}
+	raw, err := os.ReadFile(mailboxSeqFile(projectRoot))
+	if err == nil && json.Unmarshal(raw, &state) == nil && state.Seq >= 0 && state.Lines >= 0 {
+		return state, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return state, err
+	}
+	path := mailboxFile(projectRoot)
+	if !fileExists(path) {
+		return mailboxSeqState{}, nil
+	}
+	seq, err := readMailboxLastSeq(path)
+	if err != nil {
+		return state, err
+	}
+	lines, err := countSessionEventLines(path)
+	if err != nil {
+		return state, err
+	}
+	return mailboxSeqState{Seq: seq, Lines: lines}, nil
+}
+
+func saveMailboxSeqState(projectRoot string, state mailboxSeqState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(mailboxSeqFile(projectRoot), raw)
+}
+
+// readMailboxLastSeq returns the seq of the last parseable message, reading
+// only the end of the log.
+func readMailboxLastSeq(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	offset := info.Size() - mailboxTailReadBytes
+	if offset < 0 {
+		offset = 0
+	}
+	buf := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(buf, offset); err != nil && err != io.EOF {
+		return 0, err
+	}
+	lines := strings.Split(string(buf), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if offset > 0 && i == 0 {
+			break // partial line at the start of the window
+		}
+		message := mailboxMessage{}
+		if json.Unmarshal([]byte(strings.TrimSpace(lines[i])), &message) == nil && message.Seq > 0 {
+			return message.Seq, nil
+		}
+	}
+	return 0, nil
+}
+
+// compactMailbox drops the oldest messages their recipient already acked
+// once the log holds more than maxMessages. Unread messages always stay, and
+// so does the newest one, so the seq survives a lost sidecar. Callers hold
+// the mailbox lock.
+func compactMailbox(projectRoot string, maxMessages int) (int, error) {
+	messages, err := readMailbox(projectRoot)
+	if err != nil {
+		return 0, err
+	}
+	store, err := loadMailboxCursors(projectRoot)
+	if err != nil {
+		return 0, err
+	}
+	excess := len(messages) - maxMessages
+	var out bytes.Buffer
+	kept := 0
+	for i, message := range messages {
+		if excess > 0 && i < len(messages)-1 && store.Recipients[message.To].has(message.Seq) {
+			excess--
+			continue
+		}
+		data, err := json.Marshal(message)
+		if err != nil {
+			return 0, err
+		}
+		out.Write(append(data, '\n'))
+		kept++
+	}
+	if kept == len(messages) {
+		return kept, nil
+	}
+	return kept, writeFileAtomic(mailboxFile(projectRoot), out.Bytes())
+}
+
+func loadMailboxCursors(projectRoot string) (mailboxCursorStore, error) {
+	store := mailboxCursorStore{Recipients: map[string]mailboxCursor{}}
+	raw, err := os.ReadFile(mailboxCursorFile(projectRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return store, err
+	}
+	if err := json.Unmarshal(raw, &store); err != nil {
+		return mailboxCursorStore{Recipients: map[string]mailboxCursor{}}, err
+	}
+	if store.Recipients == nil {
+		store.Recipients = map[string]mailboxCursor{}
+	}
+	return store, nil
+}
+
+func saveMailboxCursors(projectRoot string, store mailboxCursorStore) error {
+	store.UpdatedAt = nowFn().UTC().Format(time.RFC3339)
+	raw, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(mailboxCursorFile(projectRoot), raw)
+}
+
+func (c mailboxCursor) has(seq int) bool {
+	if seq <= c.Cursor {
+		return true
+	}
+	for _, acked := range c.Acked {
+		if acked == seq {
+			return true
+		}
+	}
+	return false
+}
+
+// ack records seqs and folds contiguous acks into Cursor.
+func (c mailboxCursor) ack(seqs []int) mailboxCursor {
+	set := map[int]bool{}
+	for _, seq := range c.Acked {
+		set[seq] = true
+	}
+	for _, seq := range seqs {
+		if seq > c.Cursor {
+			set[seq] = true
+		}
+	}
+	for set[c.Cursor+1] {
+		delete(set, c.Cursor+1)
+		c.Cursor++
+	}
+	c.Acked = c.Acked[:0]
+	for seq := range set {
+		c.Acked = append(c.Acked, seq)
+	}
+	sort.Ints(c.Acked)
+	if len(c.Acked) == 0 {
+		c.Acked = nil
+	}
+	return c
+}
+
+func ackMailboxMessages(projectRoot, recipient string, seqs []int) (mailboxCursor, error) {
+	var cursor mailboxCursor
+	err := withMailboxLock(projectRoot, func() error {
+		store, err := loadMailboxCursors(projectRoot)
+		if err != nil {
+			return err
+		}
+		cursor = store.Recipients[recipient].ack(seqs)
+		store.Recipients[recipient] = cursor
+		return saveMailboxCursors(projectRoot, store)
+	})
+	return cursor, err
+}
+
+// unreadMailboxMessages returns messages not yet acknowledged by their own
+// recipient, filtered by match.
+func unreadMailboxMessages(projectRoot string, match func(mailboxMessage) bool) ([]mailboxMessage, error) {
+	if !fileExists(mailboxFile(projectRoot)) {
+		return nil, nil
+	}
+	messages, err := readMailbox(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	store, err := loadMailboxCursors(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	out := []mailboxMessage{}
+	for _, message := range messages {
+		if store.Recipients[message.To].has(message.Seq) {
+			continue
+		}
+		if match == nil || match(message) {
+			out = append(out, message)
+		}
+	}
+	return out, nil
+}
+
+// unreadMessagesFromSession lists what a session has posted that its
+// recipients have not read yet; monitor and handoff surface these.
+func unreadMessagesFromSession(projectRoot, session string) []mailboxMessage {
+	messages, err := unreadMailboxMessages(projectRoot, func(message mailboxMessage) bool {
+		return message.From == session
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mailbox warning: %v\n", err)
+		return nil
+	}
+	return messages
+}
+
+// monitorSurfaceMessages reports each unread message from session once per
+// monitor run: as a stream event with --stream-json, or on stderr with
+// --verbose. It returns the number of unread messages.
+func monitorSurfaceMessages(projectRoot, session string, poll int, surfaced map[int]bool, streamJSON, verbose bool) int {
+	unread := unreadMessagesFromSession(projectRoot, session)
+	for _, message := range unread {
+		if surfaced[message.Seq] {
+			continue
+		}
+		surfaced[message.Seq] = true
+		if streamJSON {
+			writeJSON(map[string]any{
+				"type":    "message",
+				"poll":    poll,
+				"session": session,
+				"message": message,
+			})
+		} else if verbose {
+			fmt.Fprintf(os.Stderr, "[%s] session=%s poll=%d message #%d %s -> %s: %s\n", time.Now().Format("15:04:05"), session, poll, message.Seq, message.Kind, message.To, redactOutputText(message.Body))
+		}
+	}
+	return len(unread)
+}
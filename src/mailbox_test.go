@@ -0,0 +1,164 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMsgPostToParentAndReadAcks(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	parent, child := "lisa-msg-parent", "lisa-msg-child"
+	t.Cleanup(func() {
+		for _, path := range []string{mailboxFile(root), mailboxFile(root) + ".lock", mailboxCursorFile(root), mailboxSeqFile(root), sessionMetaFile(root, child)} {
+			_ = os.Remove(path)
+		}
+	})
+	if err := saveSessionMeta(root, child, sessionMeta{Session: child, ParentSession: parent}); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+	t.Setenv("LISA_SESSION_NAME", child)
+	t.Setenv(lisaProjectRootEnv, root)
+
+	for _, args := range [][]string{
+		{"--to", "parent", "--kind", "progress", "--body", "halfway"},
+		{"--to", "parent", "--kind", "result", "--body", "done token=abc123"},
+	} {
+		stdout, _ := captureOutput(t, func() {
+			if code := cmdMsg(append(append([]string{"post"}, args...), "--json")); code != 0 {
+				t.Fatalf("expected post to succeed, got %d", code)
+			}
+		})
+		if !strings.Contains(stdout, `"to":"`+parent+`"`) {
+			t.Fatalf("expected --to parent to resolve to %s: %s", parent, stdout)
+		}
+	}
+
+	t.Setenv("LISA_SESSION_NAME", parent)
+	read := func(args ...string) (out struct {
+		Messages []mailboxMessage `json:"messages"`
+		Acked    bool             `json:"acked"`
+		Cursor   int              `json:"cursor"`
+	}) {
+		stdout, _ := captureOutput(t, func() { cmdMsg(append(append([]string{"read"}, args...), "--json")) })
+		if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+			t.Fatalf("invalid read JSON %q: %v", stdout, err)
+		}
+		return out
+	}
+	if got := read("--kind", "result", "--peek"); len(got.Messages) != 1 || got.Acked || got.Messages[0].Seq != 2 {
+		t.Fatalf("expected peeked result message, got %+v", got)
+	}
+	if got := read("--kind", "result"); len(got.Messages) != 1 || !got.Acked || got.Cursor != 0 {
+		t.Fatalf("expected out-of-order ack to leave cursor at 0, got %+v", got)
+	}
+	if strings.Contains(read("--all", "--peek").Messages[1].Body, "abc123") {
+		t.Fatalf("expected body to be redacted at rest")
+	}
+	if got := read(); len(got.Messages) != 1 || got.Messages[0].Body != "halfway" || got.Cursor != 2 {
+		t.Fatalf("expected remaining progress message and cursor folded to 2, got %+v", got)
+	}
+	if got := read(); len(got.Messages) != 0 {
+		t.Fatalf("expected nothing unread, got %+v", got.Messages)
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := cmdMsg([]string{"post", "--to", "lisa-msg-missing", "--body", "x", "--json"}); code == 0 {
+			t.Fatalf("expected unknown recipient to fail")
+		}
+	})
+}
+
+func TestSessionMonitorSurfacesChildMessagesOnce(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	session := "lisa-msg-monitor"
+	origCompute, origAppend, origSleep := computeSessionStatusFn, appendSessionEventFn, monitorSleepFn
+	t.Cleanup(func() {
+		computeSessionStatusFn, appendSessionEventFn, monitorSleepFn = origCompute, origAppend, origSleep
+		for _, path := range []string{mailboxFile(root), mailboxFile(root) + ".lock", mailboxCursorFile(root), mailboxSeqFile(root)} {
+			_ = os.Remove(path)
+		}
+	})
+	appendSessionEventFn = func(string, string, sessionEvent) error { return nil }
+	monitorSleepFn = func(time.Duration) {}
+	polls := 0
+	computeSessionStatusFn = func(name, projectRoot, agentHint, modeHint string, full bool, poll int) (sessionStatus, error) {
+		polls++
+		if polls == 2 {
+			if _, err := appendMailboxMessage(root, mailboxMessage{From: session, To: mailboxOrchestrator, Kind: "question", Body: "which db?"}); err != nil {
+				t.Fatalf("append failed: %v", err)
+			}
+		}
+		return sessionStatus{Session: name, Agent: "claude", Mode: "interactive", Status: "active", SessionState: "in_progress"}, nil
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		cmdSessionMonitor([]string{"--session", session, "--project-root", root, "--poll-interval", "1", "--max-polls", "4", "--stream-json", "--json"})
+	})
+	surfaced := 0
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		if strings.Contains(line, `"type":"message"`) {
+			surfaced++
+			if !strings.Contains(line, `"poll":2`) || !strings.Contains(line, `"body":"which db?"`) {
+				t.Fatalf("unexpected message event: %s", line)
+			}
+		}
+	}
+	if surfaced != 1 || !strings.Contains(stdout, `"unreadMessages":1`) {
+		t.Fatalf("expected one surfaced message and unreadMessages=1, got:\n%s", stdout)
+	}
+	if unread := unreadMessagesFromSession(root, session); len(unread) != 1 {
+		t.Fatalf("monitor must not ack messages, got %d unread", len(unread))
+	}
+}
+
+func TestMailboxSeqSidecarAndRetention(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	t.Cleanup(func() {
+		for _, path := range []string{mailboxFile(root), mailboxFile(root) + ".lock", mailboxCursorFile(root), mailboxSeqFile(root)} {
+			_ = os.Remove(path)
+		}
+	})
+	t.Setenv("LISA_MAILBOX_MAX_MESSAGES", "4")
+	post := func(to string) mailboxMessage {
+		t.Helper()
+		message, err := appendMailboxMessage(root, mailboxMessage{From: "lisa-a", To: to, Kind: "progress", Body: "x"})
+		if err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		return message
+	}
+	for i := 0; i < 3; i++ {
+		post("lisa-b")
+	}
+	// Without the sidecar the seq is recovered from the log tail.
+	if err := os.Remove(mailboxSeqFile(root)); err != nil {
+		t.Fatalf("remove sidecar failed: %v", err)
+	}
+	if message := post("lisa-c"); message.Seq != 4 {
+		t.Fatalf("expected seq 4 recovered from tail, got %d", message.Seq)
+	}
+	if _, err := ackMailboxMessages(root, "lisa-b", []int{1, 2}); err != nil {
+		t.Fatalf("ack failed: %v", err)
+	}
+	post("lisa-c")
+	post("lisa-c")
+	messages, err := readMailbox(root)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	seqs := []int{}
+	for _, message := range messages {
+		seqs = append(seqs, message.Seq)
+	}
+	// Acked 1 and 2 are dropped; unread 3 stays even past the limit.
+	if fmt.Sprint(seqs) != "[3 4 5 6]" {
+		t.Fatalf("unexpected retained seqs %v", seqs)
+	}
+	if message := post("lisa-b"); message.Seq != 7 {
+		t.Fatalf("expected seq to keep increasing after compaction, got %d", message.Seq)
+	}
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Compressed transfer payloads carry `compression` (the requested mode) and
+// `encoding` (how compressedPayload is actually packed). Decoders trust
+// encoding, so packets written before zstd was real (compression "zstd",
+// encoding "base64-gzip") still expand.
+const (
+	payloadEncodingZstd = "base64-zstd"
+	payloadEncodingGzip = "base64-gzip"
+)
+
+var compressedPayloadEnvelopeKeys = []string{"compression", "encoding", "compressedPayload", "uncompressedBytes", "compressedBytes"}
+
+func payloadEncodingForMode(mode string) string {
+	if mode == "gzip" {
+		return payloadEncodingGzip
+	}
+	return payloadEncodingZstd
+}
+
+// compressJSONPayload marshals payload and packs it for mode (zstd|gzip),
+// returning the base64 body plus uncompressed and compressed sizes.
+func compressJSONPayload(payload map[string]any, mode string) (string, int, int, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	var compressed []byte
+	switch mode {
+	case "zstd":
+		compressed = zstdCompress(raw)
+	case "gzip":
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(raw); err != nil {
+			_ = zw.Close()
+			return "", 0, 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return "", 0, 0, err
+		}
+		compressed = buf.Bytes()
+	default:
+		return "", 0, 0, fmt.Errorf("unsupported compression: %s", mode)
+	}
+	return base64.StdEncoding.EncodeToString(compressed), len(raw), len(compressed), nil
+}
+
+// decodeCompressedPayload unpacks a compressedPayload body. An empty
+// encoding falls back to sniffing the zstd or gzip magic bytes.
+func decodeCompressedPayload(encoding, encoded string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 payload: %w", err)
+	}
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	if encoding == "" {
+		switch {
+		case bytes.HasPrefix(compressed, []byte{0x28, 0xB5, 0x2F, 0xFD}):
+			encoding = payloadEncodingZstd
+		case bytes.HasPrefix(compressed, []byte{0x1F, 0x8B}):
+			encoding = payloadEncodingGzip
+		}
+	}
+	switch encoding {
+	case payloadEncodingZstd:
+		return zstdDecompress(compressed)
+	case payloadEncodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(io.LimitReader(zr, zstdMaxDecodedBytes))
+	default:
+		return nil, fmt.Errorf("unsupported payload encoding: %q (expected %s|%s)", encoding, payloadEncodingZstd, payloadEncodingGzip)
+	}
+}
+
+// expandCompressedPayloads walks a decoded JSON document and replaces every
+// object's compressedPayload with its contents. Expanded keys only fill
+// gaps, so richer fields already on the envelope (v2+ nextAction objects)
+// win. It returns how many payloads were expanded.
+func expandCompressedPayloads(value any) (int, error) {
+	expanded := 0
+	switch typed := value.(type) {
+	case map[string]any:
+		for _, child := range typed {
+			n, err := expandCompressedPayloads(child)
+			if err != nil {
+				return expanded, err
+			}
+			expanded += n
+		}
+		encoded, ok := typed["compressedPayload"].(string)
+		if !ok {
+			return expanded, nil
+		}
+		raw, err := decodeCompressedPayload(mapStringValue(typed, "encoding"), encoded)
+		if err != nil {
+			return expanded, err
+		}
+		inner := map[string]any{}
+		if err := json.Unmarshal(raw, &inner); err != nil {
+			return expanded, fmt.Errorf("compressed payload is not a JSON object: %w", err)
+		}
+		for _, key := range compressedPayloadEnvelopeKeys {
+			delete(typed, key)
+		}
+		for key, innerValue := range inner {
+			if _, exists := typed[key]; !exists {
+				typed[key] = innerValue
+			}
+		}
+		expanded++
+	case []any:
+		for _, child := range typed {
+			n, err := expandCompressedPayloads(child)
+			if err != nil {
+				return expanded, err
+			}
+			expanded += n
+		}
+	}
+	return expanded, nil
+}
+
+// expandCompressedPayloadJSON is expandCompressedPayloads for raw JSON;
+// documents without a compressedPayload are returned untouched.
+func expandCompressedPayloadJSON(raw []byte) ([]byte, error) {
+	if !bytes.Contains(raw, []byte(`"compressedPayload"`)) {
+		return raw, nil
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if _, err := expandCompressedPayloads(doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
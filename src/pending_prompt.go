@@ -0,0 +1,453 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	promptPolicyFileEnv      = "LISA_PROMPT_POLICY_FILE"
+	defaultPromptDetectLines = 40
+)
+
+// pendingPrompt is a menu or confirmation the agent TUI is blocked on, parsed
+// from the pane while the session is waiting_input.
+type pendingPrompt struct {
+	Kind     string                `json:"kind"`
+	Question string                `json:"question,omitempty"`
+	Context  string                `json:"context,omitempty"`
+	Options  []pendingPromptOption `json:"options,omitempty"`
+	Selected int                   `json:"selected,omitempty"`
+}
+
+type pendingPromptOption struct {
+	Index int    `json:"index"`
+	Label string `json:"label"`
+	Key   string `json:"key,omitempty"`
+}
+
+// promptDecision records how a pending prompt was (or was not) answered.
+type promptDecision struct {
+	Source string   `json:"source"`
+	Action string   `json:"action"`
+	Rule   int      `json:"rule,omitempty"`
+	Option int      `json:"option,omitempty"`
+	Label  string   `json:"label,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Keys   []string `json:"keys,omitempty"`
+}
+
+var (
+	promptOptionRe  = regexp.MustCompile(`^([❯›>▶→]\s*)?(\d{1,2})[.)]\s+(.+)$`)
+	promptConfirmRe = regexp.MustCompile(`(?i)(\((y/n|yes/no)\)|\[(y/n|Y/n|y/N)\])\s*[:?]?\s*$`)
+	promptBorderRe  = regexp.MustCompile(`^[\s│┃║|╭╮╰╯─━═┌┐└┘]+|[\s│┃║|╭╮╰╯─━═┌┐└┘]+$`)
+)
+
+// detectPendingPrompt finds a numbered selection menu (or a y/n confirmation)
+// at the bottom of the pane. Hint lines under the menu are tolerated.
+func detectPendingPrompt(capture string) *pendingPrompt {
+	lines := []string{}
+	for _, line := range nonEmptyTailLines(capture, defaultPromptDetectLines) {
+		line = strings.TrimSpace(promptBorderRe.ReplaceAllString(line, ""))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	// Scan upward for the option block, allowing footer hints and option
+	// descriptions (at most two consecutive non-option lines).
+	first := -1
+	gap := 0
+	options := []pendingPromptOption{}
+	selected := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		m := promptOptionRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			if strings.HasSuffix(lines[i], "?") && first >= 0 {
+				break
+			}
+			gap++
+			if (first >= 0 && gap > 2) || (first < 0 && gap > 4) {
+				break
+			}
+			continue
+		}
+		gap = 0
+		index, _ := strconv.Atoi(m[2])
+		if len(options) > 0 && index != options[0].Index-1 {
+			break
+		}
+		options = append([]pendingPromptOption{{Index: index, Label: strings.TrimSpace(m[3]), Key: m[2]}}, options...)
+		if strings.TrimSpace(m[1]) != "" {
+			selected = index
+		}
+		first = i
+	}
+	// Real menus always show a cursor; plain numbered lists in agent output do not.
+	if len(options) >= 2 && options[0].Index == 1 && selected > 0 {
+		question, context := promptQuestionAbove(lines, first)
+		prompt := &pendingPrompt{
+			Question: question,
+			Context:  context,
+			Options:  options,
+			Selected: selected,
+		}
+		prompt.Kind = classifyPendingPrompt(question, context)
+		return prompt
+	}
+
+	for i := len(lines) - 1; i >= 0 && i >= len(lines)-2; i-- {
+		if promptConfirmRe.MatchString(lines[i]) {
+			_, context := promptQuestionAbove(lines, i)
+			return &pendingPrompt{
+				Kind:     "confirm",
+				Question: lines[i],
+				Context:  context,
+				Options: []pendingPromptOption{
+					{Index: 1, Label: "Yes", Key: "y"},
+					{Index: 2, Label: "No", Key: "n"},
+				},
+			}
+		}
+	}
+	return nil
+}
+
+// promptQuestionAbove returns the question line closest above the options
+// (falling back to the nearest line) plus up to six lines of context above it.
+func promptQuestionAbove(lines []string, first int) (string, string) {
+	questionAt := -1
+	for i := first - 1; i >= 0 && i >= first-8; i-- {
+		if strings.HasSuffix(lines[i], "?") {
+			questionAt = i
+			break
+		}
+	}
+	if questionAt < 0 && first > 0 {
+		questionAt = first - 1
+	}
+	if questionAt < 0 {
+		return "", ""
+	}
+	start := questionAt - 6
+	if start < 0 {
+		start = 0
+	}
+	return lines[questionAt], strings.Join(lines[start:questionAt], "\n")
+}
+
+// Permission prompt headings and questions as Claude Code and Codex print
+// them. Headings are matched against whole context lines and questions by
+// prefix, so a command that merely mentions creating or editing a file is not
+// taken for an edit approval.
+var (
+	commandApprovalHeadings  = []string{"bash command", "allow command?"}
+	commandApprovalQuestions = []string{"would you like to run the following command", "allow command"}
+	editApprovalHeadings     = []string{"edit file", "create file", "write file"}
+	editApprovalQuestions    = []string{"do you want to make this edit", "do you want to apply this edit", "do you want to create ", "would you like to make the following edit", "would you like to apply"}
+)
+
+func classifyPendingPrompt(question, context string) string {
+	q := strings.ToLower(strings.TrimSpace(question))
+	all := strings.ToLower(context + "\n" + question)
+	switch {
+	case strings.Contains(all, "trust the files") || strings.Contains(all, "trust the contents") ||
+		strings.Contains(all, "trust this folder") || strings.Contains(q, "do you trust"):
+		return "trust_folder"
+	case strings.Contains(all, "select model") || strings.Contains(all, "choose model") ||
+		strings.Contains(all, "switch model") || strings.Contains(all, "select a model"):
+		return "model_picker"
+	case promptHasHeading(context, commandApprovalHeadings) || promptHasPrefix(q, commandApprovalQuestions):
+		return "command_approval"
+	case promptHasHeading(context, editApprovalHeadings) || promptHasPrefix(q, editApprovalQuestions):
+		return "edit_approval"
+	case q == "do you want to proceed?":
+		return "command_approval"
+	}
+	return "menu"
+}
+
+func promptHasHeading(context string, headings []string) bool {
+	for _, line := range strings.Split(context, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		for _, heading := range headings {
+			if line == heading {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func promptHasPrefix(question string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(question, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectSessionPendingPrompt captures the pane tail and parses it.
+func detectSessionPendingPrompt(session string) *pendingPrompt {
+	capture, err := tmuxCapturePaneFn(session, defaultPromptDetectLines*2)
+	if err != nil {
+		return nil
+	}
+	return detectPendingPrompt(capture)
+}
+
+// promptAnswerKeys returns the tmux keys that pick option n: arrow navigation
+// from the highlighted option when known, otherwise the option's own key.
+func promptAnswerKeys(prompt *pendingPrompt, n int) ([]string, error) {
+	if prompt == nil {
+		return []string{strconv.Itoa(n)}, nil
+	}
+	var option *pendingPromptOption
+	for i := range prompt.Options {
+		if prompt.Options[i].Index == n {
+			option = &prompt.Options[i]
+		}
+	}
+	if option == nil {
+		return nil, fmt.Errorf("option %d not offered (have %d options)", n, len(prompt.Options))
+	}
+	if prompt.Kind == "confirm" {
+		return []string{option.Key, "Enter"}, nil
+	}
+	if prompt.Selected <= 0 {
+		return []string{option.Key}, nil
+	}
+	keys := []string{}
+	for i := prompt.Selected; i < n; i++ {
+		keys = append(keys, "Down")
+	}
+	for i := prompt.Selected; i > n; i-- {
+		keys = append(keys, "Up")
+	}
+	return append(keys, "Enter"), nil
+}
+
+// sendPromptDecision delivers an answer decision to the pane.
+func sendPromptDecision(session string, prompt *pendingPrompt, decision *promptDecision) error {
+	if decision.Text != "" {
+		decision.Keys = nil
+		return tmuxSendTextFn(session, decision.Text, true)
+	}
+	keys, err := promptAnswerKeys(prompt, decision.Option)
+	if err != nil {
+		return err
+	}
+	decision.Keys = keys
+	if prompt != nil {
+		for _, option := range prompt.Options {
+			if option.Index == decision.Option {
+				decision.Label = option.Label
+			}
+		}
+	}
+	return tmuxSendKeysFn(session, keys, false)
+}
+
+func appendPromptDecisionEvent(projectRoot, session string, status sessionStatus, decision promptDecision) error {
+	return appendSessionEventFn(projectRoot, session, sessionEvent{
+		At:            nowFn().UTC().Format(time.RFC3339Nano),
+		Type:          "prompt_decision",
+		Session:       session,
+		State:         status.SessionState,
+		Status:        status.Status,
+		Reason:        "prompt_" + decision.Action,
+		Signals:       status.Signals,
+		PendingPrompt: status.PendingPrompt,
+		Decision:      &decision,
+	})
+}
+
+type promptPolicyRule struct {
+	Kind        string `json:"kind"`
+	Agent       string `json:"agent,omitempty"`
+	Match       string `json:"match,omitempty"`
+	Action      string `json:"action,omitempty"`
+	Option      int    `json:"option,omitempty"`
+	OptionLabel string `json:"optionLabel,omitempty"`
+	Text        string `json:"text,omitempty"`
+
+	matchRe *regexp.Regexp
+	labelRe *regexp.Regexp
+}
+
+type promptPolicy struct {
+	Path  string             `json:"-"`
+	Rules []promptPolicyRule `json:"rules"`
+}
+
+// promptPolicyPath mirrors classificationRulesPath: LISA_PROMPT_POLICY_FILE
+// wins, otherwise <projectRoot>/.lisa/prompt-policy.json.
+func promptPolicyPath(projectRoot string) string {
+	if path := strings.TrimSpace(os.Getenv(promptPolicyFileEnv)); path != "" {
+		if expanded, err := expandAndCleanPath(path); err == nil {
+			return expanded
+		}
+		return path
+	}
+	if strings.TrimSpace(projectRoot) == "" {
+		return ""
+	}
+	return filepath.Join(projectRoot, ".lisa", "prompt-policy.json")
+}
+
+// loadPromptPolicy returns (nil, nil) when no policy is configured.
+func loadPromptPolicy(projectRoot string) (*promptPolicy, error) {
+	path := promptPolicyPath(projectRoot)
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && strings.TrimSpace(os.Getenv(promptPolicyFileEnv)) == "" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	policy := promptPolicy{}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return nil, fmt.Errorf("invalid prompt policy %s: %w", path, err)
+	}
+	policy.Path = path
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		rule.Kind = strings.ToLower(strings.TrimSpace(rule.Kind))
+		if rule.Kind == "" {
+			return nil, fmt.Errorf("rules[%d]: kind is required (use \"*\" for any)", i)
+		}
+		rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+		if rule.Action == "" {
+			rule.Action = "answer"
+		}
+		switch rule.Action {
+		case "answer":
+			if rule.Option <= 0 && rule.OptionLabel == "" && rule.Text == "" {
+				return nil, fmt.Errorf("rules[%d]: answer needs option, optionLabel, or text", i)
+			}
+		case "ignore":
+		default:
+			return nil, fmt.Errorf("rules[%d]: invalid action %q (expected answer|ignore)", i, rule.Action)
+		}
+		if rule.Match != "" {
+			if rule.matchRe, err = regexp.Compile(rule.Match); err != nil {
+				return nil, fmt.Errorf("rules[%d]: invalid match: %w", i, err)
+			}
+		}
+		if rule.OptionLabel != "" {
+			if rule.labelRe, err = regexp.Compile(rule.OptionLabel); err != nil {
+				return nil, fmt.Errorf("rules[%d]: invalid optionLabel: %w", i, err)
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// decide returns the first matching rule's decision, or a no_match decision.
+func (p *promptPolicy) decide(agent string, prompt *pendingPrompt) promptDecision {
+	decision := promptDecision{Source: "policy", Action: "no_match"}
+	if p == nil || prompt == nil {
+		return decision
+	}
+	subject := prompt.Context + "\n" + prompt.Question
+	for i, rule := range p.Rules {
+		if rule.Kind != "*" && rule.Kind != prompt.Kind {
+			continue
+		}
+		if rule.Agent != "" && !strings.EqualFold(rule.Agent, agent) {
+			continue
+		}
+		if rule.matchRe != nil && !rule.matchRe.MatchString(subject) {
+			continue
+		}
+		option := rule.Option
+		if rule.labelRe != nil {
+			option = 0
+			for _, candidate := range prompt.Options {
+				if rule.labelRe.MatchString(candidate.Label) {
+					option = candidate.Index
+					break
+				}
+			}
+			if option == 0 {
+				continue
+			}
+		}
+		decision.Rule = i + 1
+		decision.Action = rule.Action
+		if rule.Action == "answer" {
+			decision.Option = option
+			decision.Text = rule.Text
+		}
+		return decision
+	}
+	return decision
+}
+
+func pendingPromptSignature(prompt *pendingPrompt) string {
+	if prompt == nil {
+		return ""
+	}
+	labels := make([]string, 0, len(prompt.Options))
+	for _, option := range prompt.Options {
+		labels = append(labels, option.Label)
+	}
+	return prompt.Kind + "\x00" + prompt.Question + "\x00" + strings.Join(labels, "\x00")
+}
+
+// monitorAutoAnswerPrompt applies the prompt policy during `session monitor`.
+// It returns true when an answer was sent, so the poll is not a stop point.
+// Ignore/no-match decisions are logged once per distinct prompt.
+func monitorAutoAnswerPrompt(projectRoot, session string, status sessionStatus, logged map[string]bool) (bool, error) {
+	if status.PendingPrompt == nil {
+		return false, nil
+	}
+	policy, err := loadPromptPolicy(projectRoot)
+	if err != nil || policy == nil {
+		return false, err
+	}
+	decision := policy.decide(status.Agent, status.PendingPrompt)
+	signature := pendingPromptSignature(status.PendingPrompt)
+	if decision.Action != "answer" {
+		if !logged[signature] {
+			logged[signature] = true
+			return false, appendPromptDecisionEvent(projectRoot, session, status, decision)
+		}
+		return false, nil
+	}
+	denied, err := guardPromptAnswer(projectRoot, session, status.Agent, status.PendingPrompt, decision)
+	if err != nil {
+		return false, err
+	}
+	if denied != nil {
+		// Leave the prompt for a human and log the refusal once per prompt.
+		if !logged[signature] {
+			logged[signature] = true
+			decision.Action = "guard_denied"
+			return false, appendPromptDecisionEvent(projectRoot, session, status, decision)
+		}
+		return false, nil
+	}
+	err = sendPromptDecision(session, status.PendingPrompt, &decision)
+	recordAutomaticAudit("prompt auto-answer", session, decision.Text, decision.Keys, err)
+	if err != nil {
+		decision.Action = "answer_failed"
+		_ = appendPromptDecisionEvent(projectRoot, session, status, decision)
+		return false, err
+	}
+	delete(logged, signature)
+	return true, appendPromptDecisionEvent(projectRoot, session, status, decision)
+}
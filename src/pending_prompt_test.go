@@ -0,0 +1,366 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectPendingPromptParsesMenusAndConfirms(t *testing.T) {
+	claudeTrust := strings.Join([]string{
+		"╭──────────────────────────────────────────────╮",
+		"│ Do you trust the files in this folder?       │",
+		"│                                              │",
+		"│ /tmp/project                                 │",
+		"│                                              │",
+		"│ ❯ 1. Yes, proceed                            │",
+		"│   2. No, exit                                │",
+		"╰──────────────────────────────────────────────╯",
+		"   Enter to confirm · Esc to exit",
+	}, "\n")
+	prompt := detectPendingPrompt(claudeTrust)
+	if prompt == nil || prompt.Kind != "trust_folder" || len(prompt.Options) != 2 || prompt.Selected != 1 {
+		t.Fatalf("unexpected trust prompt: %+v", prompt)
+	}
+	if prompt.Options[1].Label != "No, exit" || prompt.Question != "Do you trust the files in this folder?" {
+		t.Fatalf("unexpected trust prompt details: %+v", prompt)
+	}
+
+	codexApproval := strings.Join([]string{
+		"Allow command?",
+		"$ rm -rf build",
+		"Do you want to proceed?",
+		"  1. Yes",
+		"› 2. Yes, and don't ask again for this command",
+		"  3. No, and tell Codex what to do differently",
+		"Press enter to confirm or esc to cancel",
+	}, "\n")
+	prompt = detectPendingPrompt(codexApproval)
+	if prompt == nil || prompt.Kind != "command_approval" || len(prompt.Options) != 3 || prompt.Selected != 2 {
+		t.Fatalf("unexpected approval prompt: %+v", prompt)
+	}
+	if !strings.Contains(prompt.Context, "rm -rf build") {
+		t.Fatalf("expected command in context, got %q", prompt.Context)
+	}
+	keys, err := promptAnswerKeys(prompt, 3)
+	if err != nil || strings.Join(keys, ",") != "Down,Enter" {
+		t.Fatalf("unexpected answer keys %v (%v)", keys, err)
+	}
+	if _, err := promptAnswerKeys(prompt, 4); err == nil {
+		t.Fatalf("expected out-of-range option error")
+	}
+
+	prompt = detectPendingPrompt("Overwrite existing config? (y/n)")
+	if prompt == nil || prompt.Kind != "confirm" {
+		t.Fatalf("unexpected confirm prompt: %+v", prompt)
+	}
+	if keys, _ := promptAnswerKeys(prompt, 2); strings.Join(keys, ",") != "n,Enter" {
+		t.Fatalf("unexpected confirm keys %v", keys)
+	}
+
+	plainList := "Summary of changes:\n1. Added parser\n2. Fixed tests\n3. Updated docs\n> "
+	if prompt := detectPendingPrompt(plainList); prompt != nil {
+		t.Fatalf("numbered output without cursor must not be a prompt: %+v", prompt)
+	}
+}
+
+func TestClassifyPendingPromptPrefersCommandHeadings(t *testing.T) {
+	menu := func(lines ...string) string {
+		return strings.Join(append(lines, "❯ 1. Yes", "  2. Yes, and don't ask again", "  3. No, and tell the agent what to do differently (esc)"), "\n")
+	}
+	cases := []struct {
+		name    string
+		capture string
+		want    string
+	}{
+		{"claude bash creating a file", menu(
+			"Bash command",
+			"  touch notes.md && echo draft > notes.md",
+			"  Create file notes.md and edit file list",
+			"Do you want to proceed?"), "command_approval"},
+		{"codex command writing a file", menu(
+			"Would you like to run the following command?",
+			"$ echo ok > create.txt  # write to create.txt"), "command_approval"},
+		{"codex command question mentioning create", menu(
+			"$ mkdir build",
+			"Would you like to run the following command to create build/?"), "command_approval"},
+		{"claude edit", menu(
+			"Edit file",
+			"  main.go",
+			"Do you want to make this edit to main.go?"), "edit_approval"},
+		{"claude create", menu(
+			"Create file",
+			"  notes.md",
+			"Do you want to create notes.md?"), "edit_approval"},
+		{"codex edits", menu(
+			"Would you like to make the following edits?"), "edit_approval"},
+		{"menu mentioning approval", menu(
+			"Please approve the release plan below.",
+			"Which release should ship first?"), "menu"},
+	}
+	for _, tc := range cases {
+		prompt := detectPendingPrompt(tc.capture)
+		if prompt == nil || prompt.Kind != tc.want {
+			t.Fatalf("%s: expected %s, got %+v", tc.name, tc.want, prompt)
+		}
+	}
+}
+
+func TestPromptPolicyDecideMatchesFirstRule(t *testing.T) {
+	projectRoot := t.TempDir()
+	t.Setenv(promptPolicyFileEnv, "")
+	if policy, err := loadPromptPolicy(projectRoot); policy != nil || err != nil {
+		t.Fatalf("expected no policy, got %+v %v", policy, err)
+	}
+	writePromptPolicy(t, projectRoot, `{"rules":[
+		{"kind":"command_approval","agent":"codex","match":"rm -rf","action":"ignore"},
+		{"kind":"command_approval","optionLabel":"^Yes, and"},
+		{"kind":"*","text":"staging"}
+	]}`)
+	policy, err := loadPromptPolicy(projectRoot)
+	if err != nil || policy == nil {
+		t.Fatalf("load policy failed: %v", err)
+	}
+	approval := &pendingPrompt{
+		Kind:     "command_approval",
+		Question: "Do you want to proceed?",
+		Context:  "$ rm -rf build",
+		Options:  []pendingPromptOption{{Index: 1, Label: "Yes"}, {Index: 2, Label: "Yes, and don't ask again"}},
+		Selected: 1,
+	}
+	if decision := policy.decide("codex", approval); decision.Action != "ignore" || decision.Rule != 1 {
+		t.Fatalf("expected codex rm -rf ignored, got %+v", decision)
+	}
+	if decision := policy.decide("claude", approval); decision.Action != "answer" || decision.Option != 2 || decision.Rule != 2 {
+		t.Fatalf("expected label match answer, got %+v", decision)
+	}
+	if decision := policy.decide("claude", &pendingPrompt{Kind: "menu"}); decision.Text != "staging" || decision.Rule != 3 {
+		t.Fatalf("expected wildcard text answer, got %+v", decision)
+	}
+
+	writePromptPolicy(t, projectRoot, `{"rules":[{"kind":"menu","action":"answer"}]}`)
+	if _, err := loadPromptPolicy(projectRoot); err == nil || !strings.Contains(err.Error(), "answer needs") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func TestCmdSessionAnswerSendsKeysAndLogsDecision(t *testing.T) {
+	projectRoot := t.TempDir()
+	t.Setenv(promptPolicyFileEnv, "")
+	stubPromptSession(t, &pendingPrompt{
+		Kind:     "model_picker",
+		Question: "Select model",
+		Options:  []pendingPromptOption{{Index: 1, Label: "opus", Key: "1"}, {Index: 2, Label: "sonnet", Key: "2"}},
+		Selected: 1,
+	})
+	var sentKeys []string
+	tmuxSendKeysFn = func(session string, keys []string, enter bool) error {
+		sentKeys = keys
+		return nil
+	}
+	var events []sessionEvent
+	appendSessionEventFn = func(projectRoot, session string, event sessionEvent) error {
+		events = append(events, event)
+		return nil
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionAnswer([]string{"--session", "lisa-answer", "--option", "2", "--project-root", projectRoot, "--json"}); code != 0 {
+			t.Fatalf("expected answer success, got %d", code)
+		}
+	})
+	var payload struct {
+		OK       bool           `json:"ok"`
+		Decision promptDecision `json:"decision"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("parse answer payload failed: %v (%q)", err, stdout)
+	}
+	if !payload.OK || payload.Decision.Label != "sonnet" || strings.Join(sentKeys, ",") != "Down,Enter" {
+		t.Fatalf("unexpected answer: %s keys=%v", stdout, sentKeys)
+	}
+	if len(events) != 1 || events[0].Type != "prompt_decision" || events[0].Decision.Source != "manual" || events[0].PendingPrompt == nil {
+		t.Fatalf("unexpected decision events: %+v", events)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdSessionAnswer([]string{"--session", "lisa-answer", "--option", "5", "--project-root", projectRoot, "--json"}); code == 0 {
+			t.Fatalf("expected invalid option failure")
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"invalid_option"`) {
+		t.Fatalf("unexpected invalid option payload: %q", stdout)
+	}
+
+	writePromptPolicy(t, projectRoot, `{"rules":[{"kind":"trust_folder","option":1}]}`)
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdSessionAnswer([]string{"--session", "lisa-answer", "--policy", "--project-root", projectRoot, "--json"}); code != 1 {
+			t.Fatalf("expected policy no_match exit 1, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"prompt_policy_no_match"`) || events[len(events)-1].Reason != "prompt_no_match" {
+		t.Fatalf("unexpected no_match result: %q events=%+v", stdout, events)
+	}
+}
+
+func TestCmdSessionAnswerRequiresPendingPromptUnlessForced(t *testing.T) {
+	projectRoot := t.TempDir()
+	stubPromptSession(t, nil)
+	sentText := ""
+	tmuxSendTextFn = func(session, text string, enter bool) error {
+		sentText = text
+		return nil
+	}
+	appendSessionEventFn = func(projectRoot, session string, event sessionEvent) error { return nil }
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionAnswer([]string{"--session", "lisa-answer", "--text", "go", "--project-root", projectRoot, "--json"}); code != 1 {
+			t.Fatalf("expected no prompt failure, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"no_pending_prompt"`) || sentText != "" {
+		t.Fatalf("unexpected no prompt result: %q sent=%q", stdout, sentText)
+	}
+	_, _ = captureOutput(t, func() {
+		if code := cmdSessionAnswer([]string{"--session", "lisa-answer", "--text", "go", "--force", "--project-root", projectRoot}); code != 0 {
+			t.Fatalf("expected forced answer success, got %d", code)
+		}
+	})
+	if sentText != "go" {
+		t.Fatalf("expected forced text send, got %q", sentText)
+	}
+	_, _ = captureOutput(t, func() {
+		if code := cmdSessionAnswer([]string{"--session", "lisa-answer", "--option", "1", "--text", "go"}); code == 0 {
+			t.Fatalf("expected exclusive choice failure")
+		}
+	})
+}
+
+func TestCmdSessionMonitorAutoAnswersPromptAndKeepsPolling(t *testing.T) {
+	projectRoot := t.TempDir()
+	t.Setenv(promptPolicyFileEnv, "")
+	writePromptPolicy(t, projectRoot, `{"rules":[{"kind":"trust_folder","option":1}]}`)
+	stubPromptSession(t, nil)
+	polls := 0
+	computeSessionStatusFn = func(session, projectRoot, agentHint, modeHint string, full bool, pollCount int) (sessionStatus, error) {
+		polls++
+		if polls == 1 {
+			return sessionStatus{
+				Session:      session,
+				Agent:        "claude",
+				Mode:         "interactive",
+				Status:       "idle",
+				SessionState: "waiting_input",
+				PendingPrompt: &pendingPrompt{
+					Kind:     "trust_folder",
+					Options:  []pendingPromptOption{{Index: 1, Label: "Yes", Key: "1"}, {Index: 2, Label: "No", Key: "2"}},
+					Selected: 1,
+				},
+			}, nil
+		}
+		return sessionStatus{Session: session, Agent: "claude", Mode: "interactive", Status: "idle", SessionState: "completed"}, nil
+	}
+	var sentKeys []string
+	tmuxSendKeysFn = func(session string, keys []string, enter bool) error {
+		sentKeys = keys
+		return nil
+	}
+	reasons := []string{}
+	appendSessionEventFn = func(projectRoot, session string, event sessionEvent) error {
+		reasons = append(reasons, event.Reason)
+		return nil
+	}
+	audits := stubAuditRecords(t)
+
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionMonitor([]string{"--session", "lisa-auto-answer", "--project-root", projectRoot, "--poll-interval", "1", "--max-polls", "3", "--json"})
+		if code != 0 {
+			t.Fatalf("expected monitor success, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"finalState":"completed"`) || polls != 2 {
+		t.Fatalf("expected monitor to continue past the answered prompt: %q polls=%d", stdout, polls)
+	}
+	if strings.Join(sentKeys, ",") != "Enter" || !strings.Contains(strings.Join(reasons, ","), "prompt_answer") {
+		t.Fatalf("expected auto-answer keys and event, got keys=%v reasons=%v", sentKeys, reasons)
+	}
+	if strings.Join(*audits, ",") != "prompt auto-answer lisa-auto-answer Enter" {
+		t.Fatalf("expected the auto-answer to be audited, got %v", *audits)
+	}
+}
+
+func writePromptPolicy(t *testing.T, projectRoot, content string) {
+	t.Helper()
+	dir := filepath.Join(projectRoot, ".lisa")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "prompt-policy.json"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy failed: %v", err)
+	}
+}
+
+// stubPromptSession stubs the tmux/status seams used by session answer; the
+// status reports waiting_input with the given pending prompt.
+func stubPromptSession(t *testing.T, prompt *pendingPrompt) {
+	t.Helper()
+	origHas := tmuxHasSessionFn
+	origCompute := computeSessionStatusFn
+	origCapture := tmuxCapturePaneFn
+	origKeys := tmuxSendKeysFn
+	origText := tmuxSendTextFn
+	origAppend := appendSessionEventFn
+	t.Cleanup(func() {
+		tmuxHasSessionFn = origHas
+		computeSessionStatusFn = origCompute
+		tmuxCapturePaneFn = origCapture
+		tmuxSendKeysFn = origKeys
+		tmuxSendTextFn = origText
+		appendSessionEventFn = origAppend
+	})
+	tmuxHasSessionFn = func(string) bool { return true }
+	tmuxCapturePaneFn = func(string, int) (string, error) { return "", nil }
+	computeSessionStatusFn = func(session, projectRoot, agentHint, modeHint string, full bool, pollCount int) (sessionStatus, error) {
+		return sessionStatus{Session: session, Agent: "claude", Mode: "interactive", Status: "idle", SessionState: "waiting_input", PendingPrompt: prompt}, nil
+	}
+}
+
+func TestMonitorAutoAnswerPromptRefusesGuardDeniedApproval(t *testing.T) {
+	projectRoot := t.TempDir()
+	t.Setenv(promptPolicyFileEnv, "")
+	t.Setenv(guardPolicyFileEnv, "")
+	writePromptPolicy(t, projectRoot, `{"rules":[{"kind":"command_approval","option":1}]}`)
+	writeGuardPolicy(t, projectRoot, testGuardPolicy)
+	stubPromptSession(t, nil)
+	sent := false
+	tmuxSendKeysFn = func(string, []string, bool) error { sent = true; return nil }
+	events := []sessionEvent{}
+	appendSessionEventFn = func(_, _ string, event sessionEvent) error {
+		events = append(events, event)
+		return nil
+	}
+	status := sessionStatus{
+		Session: "lisa-guard-answer", Agent: "claude", SessionState: "waiting_input",
+		PendingPrompt: &pendingPrompt{
+			Kind:     "command_approval",
+			Question: "Do you want to proceed?",
+			Context:  "Bash command\nrm -rf build/",
+			Options:  []pendingPromptOption{{Index: 1, Label: "Yes", Key: "1"}, {Index: 2, Label: "No", Key: "2"}},
+		},
+	}
+	logged := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		answered, err := monitorAutoAnswerPrompt(projectRoot, status.Session, status, logged)
+		if answered || err != nil {
+			t.Fatalf("expected guard to refuse the answer, got answered=%t err=%v", answered, err)
+		}
+	}
+	if sent {
+		t.Fatalf("guard-denied answer must not reach the pane")
+	}
+	if len(events) != 1 || events[0].Reason != "prompt_guard_denied" {
+		t.Fatalf("expected one guard_denied decision event, got %+v", events)
+	}
+}
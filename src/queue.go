@@ -0,0 +1,434 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var queueSleepFn = time.Sleep
+
+const (
+	queueLockTimeoutMS = 5000
+	// queueClaimInterval is how often a worker with no free job rechecks the queue.
+	queueClaimInterval = time.Second
+)
+
+var defaultQueueRetryOn = []string{"crashed", "stuck", "not_found", "timeout", "spawn_failed"}
+
+type queueAttempt struct {
+	Attempt    int    `json:"attempt"`
+	Session    string `json:"session,omitempty"`
+	FinalState string `json:"finalState,omitempty"`
+	ExitReason string `json:"exitReason,omitempty"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+}
+
+// queueJob is one queued spawn. Empty agent/mode/model fall back to the lane
+// profile (if any) and then to `session spawn` defaults.
+type queueJob struct {
+	ID         string         `json:"id"`
+	State      string         `json:"state"`
+	Agent      string         `json:"agent,omitempty"`
+	Mode       string         `json:"mode,omitempty"`
+	Prompt     string         `json:"prompt"`
+	Lane       string         `json:"lane,omitempty"`
+	Model      string         `json:"model,omitempty"`
+	Priority   int            `json:"priority"`
+	TaskHash   string         `json:"taskHash,omitempty"`
+	MaxRetries int            `json:"maxRetries"`
+	Session    string         `json:"session,omitempty"`
+	FinalState string         `json:"finalState,omitempty"`
+	ExitReason string         `json:"exitReason,omitempty"`
+	ErrorCode  string         `json:"errorCode,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Handoff    map[string]any `json:"handoff,omitempty"`
+	Attempts   []queueAttempt `json:"attempts,omitempty"`
+	WorkerPID  int            `json:"workerPid,omitempty"`
+	CreatedAt  string         `json:"createdAt"`
+	StartedAt  string         `json:"startedAt,omitempty"`
+	FinishedAt string         `json:"finishedAt,omitempty"`
+	UpdatedAt  string         `json:"updatedAt"`
+}
+
+type queueStore struct {
+	Seq       int        `json:"seq"`
+	Draining  bool       `json:"draining,omitempty"`
+	Jobs      []queueJob `json:"jobs"`
+	UpdatedAt string     `json:"updatedAt"`
+}
+
+func queueFile(projectRoot string) string {
+	return fmt.Sprintf("/tmp/.lisa-%s-queue.json", projectHash(projectRoot))
+}
+
+func loadQueueStore(projectRoot string) (queueStore, error) {
+	path := queueFile(projectRoot)
+	if !fileExists(path) {
+		return queueStore{Jobs: []queueJob{}}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return queueStore{}, err
+	}
+	store := queueStore{}
+	if err := json.Unmarshal(raw, &store); err != nil {
+		return queueStore{}, err
+	}
+	if store.Jobs == nil {
+		store.Jobs = []queueJob{}
+	}
+	return store, nil
+}
+
+func saveQueueStore(projectRoot string, store queueStore) error {
+	if store.Jobs == nil {
+		store.Jobs = []queueJob{}
+	}
+	store.UpdatedAt = nowFn().UTC().Format(time.RFC3339)
+	raw, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(queueFile(projectRoot), raw)
+}
+
+// updateQueueStore runs fn on the queue under an exclusive lock and saves the
+// result, so concurrent `queue add`/`cancel` and worker slots never race.
+func updateQueueStore(projectRoot string, fn func(store *queueStore) error) error {
+	return withExclusiveFileLock(queueFile(projectRoot)+".lock", queueLockTimeoutMS, func() error {
+		store, err := loadQueueStore(projectRoot)
+		if err != nil {
+			return err
+		}
+		if err := fn(&store); err != nil {
+			return err
+		}
+		return saveQueueStore(projectRoot, store)
+	})
+}
+
+func (s *queueStore) find(id string) *queueJob {
+	for i := range s.Jobs {
+		if s.Jobs[i].ID == id {
+			return &s.Jobs[i]
+		}
+	}
+	return nil
+}
+
+func queueJobTerminal(state string) bool {
+	switch state {
+	case "completed", "failed", "cancelled", "duplicate":
+		return true
+	}
+	return false
+}
+
+// nextQueuedJob picks the highest-priority queued job, oldest first on ties.
+func nextQueuedJob(store queueStore) int {
+	best := -1
+	for i, job := range store.Jobs {
+		if job.State != "queued" {
+			continue
+		}
+		if best < 0 || job.Priority > store.Jobs[best].Priority {
+			best = i
+		}
+	}
+	return best
+}
+
+func sortedQueueJobs(jobs []queueJob) []queueJob {
+	out := append([]queueJob(nil), jobs...)
+	rank := map[string]int{"running": 0, "queued": 1}
+	sort.SliceStable(out, func(i, j int) bool {
+		ri, iok := rank[out[i].State]
+		rj, jok := rank[out[j].State]
+		if !iok {
+			ri = 2
+		}
+		if !jok {
+			rj = 2
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		if ri == 1 && out[i].Priority != out[j].Priority {
+			return out[i].Priority > out[j].Priority
+		}
+		return false
+	})
+	return out
+}
+
+func queueWorkerAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// recoverQueueJobs requeues jobs left running by a worker that no longer
+// exists. Their sessions are left alone; dedupe claims prevent double runs.
+func recoverQueueJobs(store *queueStore) []string {
+	recovered := []string{}
+	for i := range store.Jobs {
+		job := &store.Jobs[i]
+		if job.State != "running" || job.WorkerPID == os.Getpid() || queueWorkerAlive(job.WorkerPID) {
+			continue
+		}
+		job.State = "queued"
+		job.WorkerPID = 0
+		job.UpdatedAt = nowFn().UTC().Format(time.RFC3339)
+		recovered = append(recovered, job.ID)
+	}
+	return recovered
+}
+
+type queueWorkOptions struct {
+	ProjectRoot  string
+	BinPath      string
+	PollInterval int
+	MaxPolls     int
+	RetryOn      map[string]bool
+	KeepSessions bool
+}
+
+type queueJobOutcome struct {
+	Session    string
+	FinalState string
+	ExitReason string
+	ErrorCode  string
+	Error      string
+	Handoff    map[string]any
+	Duplicate  bool
+}
+
+// resolveQueueJobProfile returns the agent/mode a job will spawn with, so the
+// worker can name the session (and claim its task hash) before spawning.
+func resolveQueueJobProfile(projectRoot string, job queueJob) (string, string) {
+	agent := job.Agent
+	mode := job.Mode
+	if job.Lane != "" && (agent == "" || mode == "") {
+		if lane, found, err := loadLaneRecord(projectRoot, job.Lane); err == nil && found {
+			if agent == "" {
+				agent = lane.Agent
+			}
+			if mode == "" {
+				mode = lane.Mode
+			}
+		}
+	}
+	if parsed, err := parseAgent(agent); err == nil && strings.TrimSpace(agent) != "" {
+		agent = parsed
+	} else {
+		agent = "claude"
+	}
+	if parsed, err := parseMode(mode); err == nil && strings.TrimSpace(mode) != "" {
+		mode = parsed
+	} else {
+		mode = "interactive"
+	}
+	return agent, mode
+}
+
+func runQueueSubcommandJSON(binPath string, args ...string) (map[string]any, int, string) {
+	stdout, stderrText, err := runLisaSubcommandFn(binPath, args...)
+	payload := map[string]any{}
+	if strings.TrimSpace(stdout) != "" {
+		_ = json.Unmarshal([]byte(stdout), &payload)
+	}
+	if err != nil {
+		msg := strings.TrimSpace(stderrText)
+		if msg == "" {
+			msg = mapStringValue(payload, "error")
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return payload, commandExitCode(err), msg
+	}
+	return payload, 0, ""
+}
+
+// runQueueJob drives one attempt: claim task hash, spawn, monitor, handoff,
+// then kill the session and release the claim.
+func runQueueJob(opts queueWorkOptions, job queueJob) queueJobOutcome {
+	root := opts.ProjectRoot
+	agent, mode := resolveQueueJobProfile(root, job)
+	session := generateSessionName(root, agent, mode, job.ID)
+	out := queueJobOutcome{Session: session}
+
+	if job.TaskHash != "" {
+		claim, code, errText := runQueueSubcommandJSON(opts.BinPath,
+			"session", "dedupe", "--task-hash", job.TaskHash, "--session", session, "--project-root", root, "--json")
+		if code != 0 {
+			if duplicate, _ := claim["duplicate"].(bool); duplicate {
+				out.Duplicate = true
+				out.Session = mapStringValue(claim, "existingSession")
+				out.ErrorCode = "task_duplicate_detected"
+				out.Error = fmt.Sprintf("task hash %s already claimed by %s", job.TaskHash, out.Session)
+				return out
+			}
+			out.FinalState = "spawn_failed"
+			out.ErrorCode = "queue_dedupe_failed"
+			out.Error = errText
+			return out
+		}
+		if !opts.KeepSessions {
+			// Kept sessions stay alive, so their claim must stay too.
+			defer func() {
+				_, _, _ = runLisaSubcommandFn(opts.BinPath, "session", "dedupe", "--task-hash", job.TaskHash, "--release", "--project-root", root, "--json")
+			}()
+		}
+	}
+
+	// Record the session before spawning so `queue cancel` can kill it, and
+	// skip the spawn if the job was cancelled after being claimed.
+	cancelled := false
+	if err := updateQueueStore(root, func(store *queueStore) error {
+		if current := store.find(job.ID); current != nil {
+			cancelled = current.State == "cancelled"
+			current.Session = session
+		}
+		return nil
+	}); err != nil {
+		out.FinalState = "spawn_failed"
+		out.ErrorCode = "queue_write_failed"
+		out.Error = err.Error()
+		return out
+	}
+	if cancelled {
+		out.FinalState = "cancelled"
+		return out
+	}
+
+	spawnArgs := []string{"session", "spawn", "--session", session, "--project-root", root, "--prompt", job.Prompt, "--json"}
+	if job.Agent != "" {
+		spawnArgs = append(spawnArgs, "--agent", job.Agent)
+	}
+	if job.Mode != "" {
+		spawnArgs = append(spawnArgs, "--mode", job.Mode)
+	}
+	if job.Lane != "" {
+		spawnArgs = append(spawnArgs, "--lane", job.Lane)
+	}
+	if job.Model != "" {
+		spawnArgs = append(spawnArgs, "--model", job.Model)
+	}
+	if _, code, errText := runQueueSubcommandJSON(opts.BinPath, spawnArgs...); code != 0 {
+		out.FinalState = "spawn_failed"
+		out.ErrorCode = "queue_spawn_failed"
+		out.Error = errText
+		return out
+	}
+
+	monitorArgs := []string{
+		"session", "monitor",
+		"--session", session,
+		"--project-root", root,
+		"--poll-interval", strconv.Itoa(opts.PollInterval),
+		"--max-polls", strconv.Itoa(opts.MaxPolls),
+		"--json",
+	}
+	if mode == "interactive" {
+		monitorArgs = append(monitorArgs, "--stop-on-waiting", "true")
+	} else {
+		monitorArgs = append(monitorArgs, "--expect", "terminal")
+	}
+	monitor, code, errText := runQueueSubcommandJSON(opts.BinPath, monitorArgs...)
+	out.FinalState = mapStringValue(monitor, "finalState")
+	out.ExitReason = mapStringValue(monitor, "exitReason")
+	if out.FinalState == "" {
+		out.FinalState = "monitor_failed"
+	}
+	if code != 0 && !queueOutcomeSucceeded(out.FinalState) {
+		out.ErrorCode = "queue_monitor_failed"
+		out.Error = errText
+	}
+
+	if handoff, code, _ := runQueueSubcommandJSON(opts.BinPath,
+		"session", "handoff", "--session", session, "--project-root", root, "--json"); code == 0 {
+		out.Handoff = handoff
+	}
+	if !opts.KeepSessions {
+		_, _, _ = runLisaSubcommandFn(opts.BinPath, "session", "kill", "--session", session, "--project-root", root, "--json")
+	}
+	return out
+}
+
+func queueOutcomeSucceeded(finalState string) bool {
+	return finalState == "completed" || finalState == "waiting_input"
+}
+
+// applyQueueOutcome records an attempt and decides the job's next state:
+// completed, requeued for retry, or failed. Cancelled jobs stay cancelled.
+func applyQueueOutcome(job *queueJob, outcome queueJobOutcome, retryOn map[string]bool, startedAt string) (retried bool) {
+	now := nowFn().UTC().Format(time.RFC3339)
+	job.Attempts = append(job.Attempts, queueAttempt{
+		Attempt:    len(job.Attempts) + 1,
+		Session:    outcome.Session,
+		FinalState: outcome.FinalState,
+		ExitReason: outcome.ExitReason,
+		Error:      outcome.Error,
+		StartedAt:  startedAt,
+		FinishedAt: now,
+	})
+	job.Session = outcome.Session
+	job.FinalState = outcome.FinalState
+	job.ExitReason = outcome.ExitReason
+	job.ErrorCode = outcome.ErrorCode
+	job.Error = outcome.Error
+	if outcome.Handoff != nil {
+		job.Handoff = outcome.Handoff
+	}
+	job.WorkerPID = 0
+	job.UpdatedAt = now
+	if job.State == "cancelled" {
+		return false
+	}
+	switch {
+	case outcome.Duplicate:
+		job.State = "duplicate"
+	case queueOutcomeSucceeded(outcome.FinalState):
+		job.State = "completed"
+		job.ErrorCode = ""
+		job.Error = ""
+	case retryOn[outcome.FinalState] && len(job.Attempts) <= job.MaxRetries:
+		job.State = "queued"
+		return true
+	default:
+		job.State = "failed"
+		if job.ErrorCode == "" {
+			job.ErrorCode = "queue_job_failed"
+		}
+	}
+	job.FinishedAt = now
+	return false
+}
+
+func parseQueueRetryOn(raw string) (map[string]bool, error) {
+	out := map[string]bool{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		switch item {
+		case "crashed", "stuck", "not_found", "timeout", "degraded", "limit_exceeded", "spawn_failed", "monitor_failed":
+			out[item] = true
+		default:
+			return nil, fmt.Errorf("invalid --retry-on state: %s (expected crashed|stuck|not_found|timeout|degraded|limit_exceeded|spawn_failed|monitor_failed)", item)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,235 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func queueTestRoot(t *testing.T) string {
+	t.Helper()
+	projectRoot := canonicalProjectRoot(t.TempDir())
+	t.Cleanup(func() {
+		_ = os.Remove(queueFile(projectRoot))
+		_ = os.Remove(queueFile(projectRoot) + ".lock")
+	})
+	return projectRoot
+}
+
+func queueArgValue(args []string, flag string) string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func TestQueueAddListOrdersByPriorityAndRejectsOpenTaskHash(t *testing.T) {
+	projectRoot := queueTestRoot(t)
+	for _, args := range [][]string{
+		{"--prompt", "low", "--task-hash", "t-low"},
+		{"--prompt", "high", "--priority", "5", "--agent", "codex", "--mode", "exec"},
+	} {
+		_, _ = captureOutput(t, func() {
+			if code := cmdQueueAdd(append(args, "--project-root", projectRoot, "--json")); code != 0 {
+				t.Fatalf("queue add %v failed: %d", args, code)
+			}
+		})
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdQueueAdd([]string{"--prompt", "again", "--task-hash", "t-low", "--project-root", projectRoot, "--json"}); code != 1 {
+			t.Fatalf("expected duplicate task hash rejection, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"task_duplicate_queued"`) || !strings.Contains(stdout, `"duplicateOf":"q1"`) {
+		t.Fatalf("unexpected duplicate payload: %q", stdout)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdQueueList([]string{"--project-root", projectRoot, "--json"}); code != 0 {
+			t.Fatalf("queue list failed: %d", code)
+		}
+	})
+	var payload struct {
+		Count int        `json:"count"`
+		Jobs  []queueJob `json:"jobs"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("parse list failed: %v (%q)", err, stdout)
+	}
+	if payload.Count != 2 || payload.Jobs[0].ID != "q2" || payload.Jobs[1].ID != "q1" {
+		t.Fatalf("expected priority ordering q2,q1, got %+v", payload.Jobs)
+	}
+}
+
+func TestQueueWorkRunsJobsWithinConcurrencyAndRetries(t *testing.T) {
+	projectRoot := queueTestRoot(t)
+	add := func(args ...string) {
+		_, _ = captureOutput(t, func() {
+			if code := cmdQueueAdd(append(args, "--project-root", projectRoot, "--json")); code != 0 {
+				t.Fatalf("queue add %v failed: %d", args, code)
+			}
+		})
+	}
+	add("--prompt", "ok-1", "--mode", "exec")
+	add("--prompt", "flaky", "--mode", "exec", "--retries", "1")
+	add("--prompt", "ok-2", "--mode", "exec", "--task-hash", "shared")
+	add("--prompt", "claimed-elsewhere", "--mode", "exec", "--task-hash", "taken")
+	add("--prompt", "broken", "--mode", "exec")
+
+	origRun := runLisaSubcommandFn
+	origExe := osExecutableFn
+	origSleep := queueSleepFn
+	t.Cleanup(func() {
+		runLisaSubcommandFn = origRun
+		osExecutableFn = origExe
+		queueSleepFn = origSleep
+	})
+	osExecutableFn = func() (string, error) { return "/bin/lisa", nil }
+	queueSleepFn = func(time.Duration) {}
+
+	var mu sync.Mutex
+	live := map[string]string{}
+	maxLive := 0
+	flakyAttempts := 0
+	calls := []string{}
+	runLisaSubcommandFn = func(binPath string, args ...string) (string, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		session := queueArgValue(args, "--session")
+		calls = append(calls, args[1])
+		switch args[1] {
+		case "dedupe":
+			if queueArgValue(args, "--task-hash") == "taken" && session != "" {
+				return `{"duplicate":true,"existingSession":"lisa-other","errorCode":"task_duplicate_detected"}`, "", fmt.Errorf("exit status 1")
+			}
+			return `{"duplicate":false}`, "", nil
+		case "spawn":
+			prompt := queueArgValue(args, "--prompt")
+			if queueArgValue(args, "--mode") != "exec" || !strings.HasPrefix(session, "lisa-") {
+				return "", "bad spawn args", fmt.Errorf("exit status 1")
+			}
+			live[session] = prompt
+			if len(live) > maxLive {
+				maxLive = len(live)
+			}
+			return fmt.Sprintf(`{"session":%q}`, session), "", nil
+		case "monitor":
+			if queueArgValue(args, "--expect") != "terminal" {
+				return "", "expected terminal monitor", fmt.Errorf("exit status 1")
+			}
+			switch live[session] {
+			case "flaky":
+				flakyAttempts++
+				if flakyAttempts == 1 {
+					return `{"finalState":"crashed","exitReason":"agent_exit_nonzero"}`, "", fmt.Errorf("exit status 2")
+				}
+			case "broken":
+				return `{"finalState":"stuck","exitReason":"stuck_no_output"}`, "", fmt.Errorf("exit status 2")
+			}
+			return `{"finalState":"completed","exitReason":"completed"}`, "", nil
+		case "handoff":
+			return fmt.Sprintf(`{"session":%q,"nextAction":"session capture"}`, session), "", nil
+		case "kill":
+			delete(live, session)
+			return `{"ok":true}`, "", nil
+		}
+		return "", "", fmt.Errorf("unexpected subcommand %v", args)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdQueueWork([]string{"--concurrency", "2", "--project-root", projectRoot, "--json"}); code != 1 {
+			t.Fatalf("expected exit 1 for the failed job, got %d", code)
+		}
+	})
+	var payload struct {
+		Processed int            `json:"processed"`
+		Counts    map[string]int `json:"counts"`
+		Retried   int            `json:"retried"`
+		ErrorCode string         `json:"errorCode"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("parse work payload failed: %v (%q)", err, stdout)
+	}
+	if payload.Processed != 5 || payload.Counts["completed"] != 3 || payload.Counts["failed"] != 1 || payload.Counts["duplicate"] != 1 || payload.Retried != 1 || payload.ErrorCode != "queue_jobs_failed" {
+		t.Fatalf("unexpected work summary: %s", stdout)
+	}
+	if maxLive > 2 || len(live) != 0 {
+		t.Fatalf("expected at most 2 live sessions and none left, got max=%d live=%v", maxLive, live)
+	}
+
+	store, err := loadQueueStore(projectRoot)
+	if err != nil {
+		t.Fatalf("load store failed: %v", err)
+	}
+	flaky := store.find("q2")
+	if flaky.State != "completed" || len(flaky.Attempts) != 2 || flaky.Attempts[0].FinalState != "crashed" || flaky.Handoff["nextAction"] != "session capture" {
+		t.Fatalf("unexpected retried job: %+v", flaky)
+	}
+	if dup := store.find("q4"); dup.State != "duplicate" || dup.Session != "lisa-other" || dup.ErrorCode != "task_duplicate_detected" {
+		t.Fatalf("unexpected duplicate job: %+v", dup)
+	}
+	if broken := store.find("q5"); broken.State != "failed" || broken.FinalState != "stuck" || len(broken.Attempts) != 1 {
+		t.Fatalf("unexpected failed job: %+v", broken)
+	}
+	if !strings.Contains(strings.Join(calls, ","), "dedupe") {
+		t.Fatalf("expected dedupe claims, got %v", calls)
+	}
+}
+
+func TestQueueCancelDrainAndRecoverStaleRunning(t *testing.T) {
+	projectRoot := queueTestRoot(t)
+	for _, prompt := range []string{"a", "b", "c"} {
+		_, _ = captureOutput(t, func() {
+			if code := cmdQueueAdd([]string{"--prompt", prompt, "--project-root", projectRoot}); code != 0 {
+				t.Fatalf("queue add failed: %d", code)
+			}
+		})
+	}
+	if err := updateQueueStore(projectRoot, func(store *queueStore) error {
+		store.Jobs[2].State = "running"
+		store.Jobs[2].WorkerPID = 1 << 30
+		return nil
+	}); err != nil {
+		t.Fatalf("update store failed: %v", err)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdQueueCancel([]string{"--id", "q1", "--project-root", projectRoot, "--json"}); code != 0 {
+			t.Fatalf("cancel failed: %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"cancelled":["q1"]`) {
+		t.Fatalf("unexpected cancel payload: %q", stdout)
+	}
+	_, _ = captureOutput(t, func() {
+		if code := cmdQueueCancel([]string{"--id", "q9", "--project-root", projectRoot, "--json"}); code == 0 {
+			t.Fatalf("expected unknown job failure")
+		}
+	})
+
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdQueueDrain([]string{"--cancel-queued", "--project-root", projectRoot, "--json"}); code != 0 {
+			t.Fatalf("drain failed: %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"cancelled":["q2"]`) || !strings.Contains(stdout, `"running":1`) {
+		t.Fatalf("unexpected drain payload: %q", stdout)
+	}
+
+	store, err := loadQueueStore(projectRoot)
+	if err != nil {
+		t.Fatalf("load store failed: %v", err)
+	}
+	if !store.Draining {
+		t.Fatalf("expected draining flag")
+	}
+	if recovered := recoverQueueJobs(&store); len(recovered) != 1 || recovered[0] != "q3" || store.find("q3").State != "queued" {
+		t.Fatalf("expected dead worker job requeued, got %v %+v", recovered, store.Jobs)
+	}
+}
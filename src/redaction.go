@@ -0,0 +1,446 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	redactionFileEnv         = "LISA_REDACTION_FILE"
+	redactionDisableEnv      = "LISA_REDACT"
+	defaultEntropyMinLength  = 32
+	defaultEntropyThreshold  = 4.2
+	redactionKeyPlaceholder  = "[REDACTED_KEY]"
+	redactionHighEntropyMark = "[REDACTED_ENTROPY]"
+)
+
+// defaultOutputRedactionRules are applied to every outward payload unless the
+// project redaction config overrides them.
+var defaultOutputRedactionRules = []string{"secrets", "tokens", "keys", "entropy"}
+
+var (
+	knownKeyRes = []*regexp.Regexp{
+		// OpenAI and Anthropic (sk-, sk-proj-, sk-ant-...).
+		regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{20,}`),
+		// GitHub classic and fine-grained tokens.
+		regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{30,}`),
+		regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}`),
+		// AWS access key ids and secret key assignments.
+		regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		regexp.MustCompile(`(?i)\baws_secret_access_key\b\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`),
+	}
+	entropyCandidateRe = regexp.MustCompile(`[A-Za-z0-9+_=\-]{16,}`)
+
+	// opaquePayloadKeys hold encoded bodies (base64 zstd/gzip) that rules
+	// would corrupt rather than clean; their content is redacted before it
+	// is encoded.
+	opaquePayloadKeys = map[string]bool{"compressedPayload": true}
+
+	outputRedactorMu   sync.Mutex
+	outputRedactor     *redactor
+	outputRedactorRoot string
+)
+
+type redactionPattern struct {
+	Name        string `json:"name"`
+	Regex       string `json:"regex"`
+	Replacement string `json:"replacement,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// redactionConfig is the optional <projectRoot>/.lisa/redaction.json file.
+type redactionConfig struct {
+	Enabled          *bool              `json:"enabled,omitempty"`
+	Rules            []string           `json:"rules,omitempty"`
+	Patterns         []redactionPattern `json:"patterns,omitempty"`
+	Allow            []string           `json:"allow,omitempty"`
+	EntropyMinLength int                `json:"entropyMinLength,omitempty"`
+	EntropyThreshold float64            `json:"entropyThreshold,omitempty"`
+}
+
+type redactor struct {
+	Path             string
+	rules            []string
+	patterns         []redactionPattern
+	allow            []*regexp.Regexp
+	entropyMinLength int
+	entropyThreshold float64
+}
+
+// redactionConfigPath mirrors guardPolicyPath: LISA_REDACTION_FILE wins,
+// otherwise <projectRoot>/.lisa/redaction.json.
+func redactionConfigPath(projectRoot string) string {
+	if path := strings.TrimSpace(os.Getenv(redactionFileEnv)); path != "" {
+		if expanded, err := expandAndCleanPath(path); err == nil {
+			return expanded
+		}
+		return path
+	}
+	if strings.TrimSpace(projectRoot) == "" {
+		return ""
+	}
+	return filepath.Join(projectRoot, ".lisa", "redaction.json")
+}
+
+// loadRedactor builds the project redactor. It returns (nil, nil) when
+// redaction is disabled via LISA_REDACT=off or the config's enabled=false.
+func loadRedactor(projectRoot string) (*redactor, error) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(redactionDisableEnv))) {
+	case "off", "0", "false", "none":
+		return nil, nil
+	}
+	r := &redactor{
+		rules:            defaultOutputRedactionRules,
+		entropyMinLength: defaultEntropyMinLength,
+		entropyThreshold: defaultEntropyThreshold,
+	}
+	path := redactionConfigPath(projectRoot)
+	if path == "" {
+		return r, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && strings.TrimSpace(os.Getenv(redactionFileEnv)) == "" {
+			return r, nil
+		}
+		return r, err
+	}
+	cfg := redactionConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return r, fmt.Errorf("invalid redaction config %s: %w", path, err)
+	}
+	if cfg.Enabled != nil && !*cfg.Enabled {
+		return nil, nil
+	}
+	r.Path = path
+	if cfg.Rules != nil {
+		rules, ruleErr := parseRedactionRules(strings.Join(cfg.Rules, ","))
+		if ruleErr != nil {
+			return r, fmt.Errorf("invalid redaction config %s: %w", path, ruleErr)
+		}
+		r.rules = rules
+	}
+	for i, pattern := range cfg.Patterns {
+		re, reErr := regexp.Compile(pattern.Regex)
+		if reErr != nil {
+			return r, fmt.Errorf("invalid redaction config %s: patterns[%d]: %w", path, i, reErr)
+		}
+		pattern.re = re
+		if pattern.Replacement == "" {
+			name := strings.ToUpper(strings.TrimSpace(pattern.Name))
+			if name == "" {
+				name = "CUSTOM"
+			}
+			pattern.Replacement = "[REDACTED_" + name + "]"
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	for i, expr := range cfg.Allow {
+		re, reErr := regexp.Compile(expr)
+		if reErr != nil {
+			return r, fmt.Errorf("invalid redaction config %s: allow[%d]: %w", path, i, reErr)
+		}
+		r.allow = append(r.allow, re)
+	}
+	if cfg.EntropyMinLength > 0 {
+		r.entropyMinLength = cfg.EntropyMinLength
+	}
+	if cfg.EntropyThreshold > 0 {
+		r.entropyThreshold = cfg.EntropyThreshold
+	}
+	return r, nil
+}
+
+// configureOutputRedaction selects the project whose redaction config applies
+// to this process's outputs.
+func configureOutputRedaction(projectRoot string) {
+	outputRedactorMu.Lock()
+	defer outputRedactorMu.Unlock()
+	projectRoot = canonicalProjectRoot(projectRoot)
+	if outputRedactorRoot != "" && outputRedactorRoot == projectRoot {
+		return
+	}
+	r, err := loadRedactor(projectRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "redaction warning: %v (using default rules)\n", err)
+	}
+	outputRedactor = r
+	outputRedactorRoot = projectRoot
+}
+
+// redactionProjectRoot picks --project-root from argv, else the cwd.
+func redactionProjectRoot(args []string) string {
+	if root := strings.TrimSpace(auditFlagValue(args, "--project-root")); root != "" {
+		return root
+	}
+	return getPWD()
+}
+
+func activeOutputRedactor() *redactor {
+	outputRedactorMu.Lock()
+	configured := outputRedactorRoot != ""
+	outputRedactorMu.Unlock()
+	if !configured {
+		configureOutputRedaction(getPWD())
+	}
+	outputRedactorMu.Lock()
+	defer outputRedactorMu.Unlock()
+	return outputRedactor
+}
+
+// redactOutputText applies the active redactor to free-form text output.
+func redactOutputText(text string) string {
+	out, _ := activeOutputRedactor().redactString(text)
+	return out
+}
+
+// resetOutputRedaction forgets the configured project so the next output
+// reloads redaction settings.
+func resetOutputRedaction() {
+	outputRedactorMu.Lock()
+	defer outputRedactorMu.Unlock()
+	outputRedactor = nil
+	outputRedactorRoot = ""
+}
+
+// redactPayloadMap redacts a decoded JSON object in place and adds the count
+// to its "redactions" field.
+func redactPayloadMap(payload map[string]any) {
+	_, count := activeOutputRedactor().redactValue(payload)
+	if count == 0 {
+		return
+	}
+	if existing, ok := payload["redactions"].(float64); ok {
+		count += int(existing)
+	}
+	payload["redactions"] = count
+}
+
+// redactPayloadForEncoding returns a redacted JSON copy of payload, for
+// content about to be packed into an opaque field.
+func redactPayloadForEncoding(payload map[string]any) (map[string]any, int, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, 0, err
+	}
+	copied := map[string]any{}
+	if err := json.Unmarshal(raw, &copied); err != nil {
+		return nil, 0, err
+	}
+	_, count := activeOutputRedactor().redactValue(copied)
+	return copied, count, nil
+}
+
+// redactOutputJSON redacts every string value in encoded JSON.
+func redactOutputJSON(data []byte) ([]byte, int) {
+	r := activeOutputRedactor()
+	if r == nil {
+		return data, 0
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return data, 0
+	}
+	redacted, count := r.redactValue(decoded)
+	if count == 0 {
+		return data, 0
+	}
+	out, err := json.Marshal(redacted)
+	if err != nil {
+		return data, 0
+	}
+	return out, count
+}
+
+func (r *redactor) redactValue(value any) (any, int) {
+	if r == nil {
+		return value, 0
+	}
+	switch typed := value.(type) {
+	case string:
+		return r.redactString(typed)
+	case map[string]any:
+		total := 0
+		for key, item := range typed {
+			if opaquePayloadKeys[key] {
+				continue
+			}
+			redacted, count := r.redactValue(item)
+			if count > 0 {
+				typed[key] = redacted
+				total += count
+			}
+		}
+		return typed, total
+	case []any:
+		total := 0
+		for i, item := range typed {
+			redacted, count := r.redactValue(item)
+			if count > 0 {
+				typed[i] = redacted
+				total += count
+			}
+		}
+		return typed, total
+	default:
+		return value, 0
+	}
+}
+
+func (r *redactor) redactString(text string) (string, int) {
+	if r == nil || text == "" {
+		return text, 0
+	}
+	out, count := redactWithRules(text, r.rules, r.entropyMinLength, r.entropyThreshold, r.allow)
+	for _, pattern := range r.patterns {
+		out = replaceCounting(pattern.re, out, r.allow, &count, func(string) string { return pattern.Replacement })
+	}
+	return out, count
+}
+
+// replaceCounting replaces matches not covered by an allow pattern and adds
+// the number of replacements to count.
+func replaceCounting(re *regexp.Regexp, text string, allow []*regexp.Regexp, count *int, replace func(string) string) string {
+	return re.ReplaceAllStringFunc(text, func(match string) string {
+		for _, allowed := range allow {
+			if allowed.MatchString(match) {
+				return match
+			}
+		}
+		*count++
+		return replace(match)
+	})
+}
+
+// redactWithRules is the counting core behind applyRedactionRules.
+func redactWithRules(text string, rules []string, entropyMinLength int, entropyThreshold float64, allow []*regexp.Regexp) (string, int) {
+	if len(rules) == 0 {
+		return text, 0
+	}
+	enabled := map[string]bool{}
+	for _, rule := range rules {
+		enabled[rule] = true
+	}
+	if enabled["none"] {
+		return text, 0
+	}
+	if enabled["all"] {
+		// "all" keeps its original five rules; keys and entropy are opt-in
+		// by name so existing `--redact all` output does not change.
+		for _, rule := range []string{"paths", "emails", "secrets", "numbers", "tokens"} {
+			enabled[rule] = true
+		}
+	}
+	count := 0
+	out := text
+	if enabled["keys"] {
+		for _, re := range knownKeyRes {
+			out = replaceCounting(re, out, allow, &count, func(string) string { return redactionKeyPlaceholder })
+		}
+	}
+	if enabled["emails"] {
+		out = replaceCounting(emailRedactRe, out, allow, &count, func(string) string { return "[REDACTED_EMAIL]" })
+	}
+	if enabled["secrets"] {
+		out = replaceCounting(secretAssignRe, out, allow, &count, func(match string) string {
+			return secretAssignRe.ReplaceAllString(match, "$1=[REDACTED_SECRET]")
+		})
+	}
+	if enabled["tokens"] {
+		out = replaceCounting(bearerTokenRedact, out, allow, &count, func(string) string { return "Bearer [REDACTED_TOKEN]" })
+	}
+	if enabled["entropy"] {
+		out = redactHighEntropy(out, entropyMinLength, entropyThreshold, allow, &count)
+	}
+	if enabled["numbers"] {
+		out = replaceCounting(longNumberRedact, out, allow, &count, func(string) string { return "[REDACTED_NUMBER]" })
+	}
+	if enabled["paths"] {
+		out = replaceCounting(pathRedactRe, out, allow, &count, func(match string) string {
+			prefix := ""
+			if strings.HasPrefix(match, " ") || strings.HasPrefix(match, "\"") || strings.HasPrefix(match, "'") || strings.HasPrefix(match, "=") {
+				prefix = match[:1]
+			}
+			return prefix + "[REDACTED_PATH]"
+		})
+	}
+	return out, count
+}
+
+// redactHighEntropy masks long mixed-case alphanumeric runs whose Shannon
+// entropy suggests a generated credential rather than a word or hex digest.
+// Path components are left alone so temp dirs and file names survive.
+func redactHighEntropy(text string, minLength int, threshold float64, allow []*regexp.Regexp, count *int) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range entropyCandidateRe.FindAllStringIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+		if len(match) < minLength || !looksLikeGeneratedSecret(match, threshold) {
+			continue
+		}
+		if (loc[0] > 0 && text[loc[0]-1] == '/') || (loc[1] < len(text) && text[loc[1]] == '/') {
+			continue
+		}
+		allowed := false
+		for _, re := range allow {
+			if re.MatchString(match) {
+				allowed = true
+				break
+			}
+		}
+		if allowed {
+			continue
+		}
+		out.WriteString(text[last:loc[0]])
+		out.WriteString(redactionHighEntropyMark)
+		last = loc[1]
+		*count++
+	}
+	if last == 0 {
+		return text
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+func looksLikeGeneratedSecret(candidate string, threshold float64) bool {
+	hasUpper, hasLower, hasDigit := false, false, false
+	for _, ch := range candidate {
+		switch {
+		case ch >= 'A' && ch <= 'Z':
+			hasUpper = true
+		case ch >= 'a' && ch <= 'z':
+			hasLower = true
+		case ch >= '0' && ch <= '9':
+			hasDigit = true
+		}
+	}
+	if !hasUpper || !hasLower || !hasDigit {
+		return false
+	}
+	return shannonEntropy(candidate) >= threshold
+}
+
+func shannonEntropy(text string) float64 {
+	if text == "" {
+		return 0
+	}
+	freq := map[rune]int{}
+	for _, ch := range text {
+		freq[ch]++
+	}
+	total := float64(len(text))
+	entropy := 0.0
+	for _, n := range freq {
+		p := float64(n) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
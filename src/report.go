@@ -0,0 +1,322 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const reportTopSessions = 5
+
+type reportCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type reportDurationStats struct {
+	Count         int   `json:"count"`
+	AvgSeconds    int64 `json:"avgSeconds"`
+	MedianSeconds int64 `json:"medianSeconds"`
+	P90Seconds    int64 `json:"p90Seconds"`
+	MaxSeconds    int64 `json:"maxSeconds"`
+}
+
+type reportIncidents struct {
+	Sessions int           `json:"sessions"`
+	Reasons  []reportCount `json:"reasons"`
+}
+
+type reportInterventions struct {
+	Nudges        int `json:"nudges"`
+	PromptAnswers int `json:"promptAnswers"`
+	AutoRecover   int `json:"autoRecover"`
+	Sessions      int `json:"sessions"`
+}
+
+type reportRollup struct {
+	Name            string `json:"name"`
+	Goal            string `json:"goal,omitempty"`
+	Status          string `json:"status,omitempty"`
+	Sessions        int    `json:"sessions"`
+	Completed       int    `json:"completed"`
+	Failed          int    `json:"failed"`
+	Tokens          int    `json:"tokens"`
+	DurationSeconds int64  `json:"durationSeconds"`
+}
+
+type reportSession struct {
+	Session         string `json:"session"`
+	Agent           string `json:"agent"`
+	FinalState      string `json:"finalState"`
+	LastState       string `json:"lastState,omitempty"`
+	ExitCode        *int   `json:"exitCode,omitempty"`
+	DurationSeconds int64  `json:"durationSeconds"`
+	EndedAt         string `json:"endedAt"`
+	ArchivePath     string `json:"archivePath,omitempty"`
+}
+
+// fleetReport summarizes agent work in a time window from the history index
+// (finished sessions) and event logs (everything that ran).
+type fleetReport struct {
+	GeneratedAt      string              `json:"generatedAt"`
+	Since            string              `json:"since"`
+	Until            string              `json:"until"`
+	ProjectRoot      string              `json:"projectRoot,omitempty"`
+	SessionsRun      int                 `json:"sessionsRun"`
+	SessionsFinished int                 `json:"sessionsFinished"`
+	Outcomes         []reportCount       `json:"outcomes"`
+	TimeToCompletion reportDurationStats `json:"timeToCompletion"`
+	Stuck            reportIncidents     `json:"stuck"`
+	Crashes          reportIncidents     `json:"crashes"`
+	Interventions    reportInterventions `json:"interventions"`
+	Tokens           historyTokenUsage   `json:"tokens"`
+	Lanes            []reportRollup      `json:"lanes"`
+	Objectives       []reportRollup      `json:"objectives"`
+	Slowest          []reportSession     `json:"slowest"`
+	Failed           []reportSession     `json:"failed"`
+	Warnings         []string            `json:"warnings,omitempty"`
+}
+
+func reportSessionFailed(entry historyEntry) bool {
+	return entry.FinalState == "crashed"
+}
+
+func buildFleetReport(since, until time.Time, projectRoot string) fleetReport {
+	report := fleetReport{
+		GeneratedAt: nowFn().UTC().Format(time.RFC3339),
+		Since:       since.UTC().Format(time.RFC3339),
+		Until:       until.UTC().Format(time.RFC3339),
+		ProjectRoot: projectRoot,
+		Outcomes:    []reportCount{},
+		Stuck:       reportIncidents{Reasons: []reportCount{}},
+		Crashes:     reportIncidents{Reasons: []reportCount{}},
+		Lanes:       []reportRollup{},
+		Objectives:  []reportRollup{},
+		Slowest:     []reportSession{},
+		Failed:      []reportSession{},
+	}
+	inWindow := func(raw string) bool {
+		at, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(raw))
+		return err == nil && !at.Before(since) && !at.After(until)
+	}
+	ran := map[string]bool{}
+
+	entries, err := readHistoryEntries()
+	if err != nil {
+		report.Warnings = append(report.Warnings, "history: "+err.Error())
+	}
+	finished := []historyEntry{}
+	for _, entry := range entries {
+		if (projectRoot == "" || entry.ProjectRoot == projectRoot) && inWindow(entry.EndedAt) {
+			finished = append(finished, entry)
+			ran[entry.Session] = true
+		}
+	}
+	report.SessionsFinished = len(finished)
+	addFinishedSessions(&report, finished)
+
+	paths, err := listSessionEventFiles(projectRoot, projectRoot == "")
+	if err != nil {
+		report.Warnings = append(report.Warnings, "events: "+err.Error())
+	}
+	stuck := map[string]map[string]bool{}
+	crashed := map[string]map[string]bool{}
+	intervened := map[string]bool{}
+	for _, path := range paths {
+		events, _, readErr := readSessionEventsFile(path)
+		if readErr != nil {
+			report.Warnings = append(report.Warnings, "events: "+readErr.Error())
+			continue
+		}
+		for _, event := range events {
+			if !inWindow(event.At) {
+				continue
+			}
+			ran[event.Session] = true
+			switch event.State {
+			case "stuck":
+				markReportIncident(stuck, event.Session, event.Reason)
+			case "crashed":
+				markReportIncident(crashed, event.Session, event.Reason)
+			}
+			switch {
+			case event.Reason == "monitor_auto_recover":
+				report.Interventions.AutoRecover++
+			case event.Type == "prompt_decision":
+				report.Interventions.PromptAnswers++
+			case event.Reason == "send_text" || event.Reason == "send_keys" || event.Type == "inbox":
+				report.Interventions.Nudges++
+			default:
+				continue
+			}
+			intervened[event.Session] = true
+		}
+	}
+	// kill and cleanup delete event logs, so finished sessions also count
+	// from their history entry when their events are gone.
+	for _, entry := range finished {
+		if entry.LastState == "stuck" && stuck[entry.Session] == nil {
+			markReportIncident(stuck, entry.Session, "ended_stuck_"+entry.EndReason)
+		}
+		if entry.FinalState == "crashed" && crashed[entry.Session] == nil {
+			reason := ""
+			if entry.ExitCode != nil {
+				reason = fmt.Sprintf("exit_%d", *entry.ExitCode)
+			}
+			markReportIncident(crashed, entry.Session, reason)
+		}
+	}
+	report.SessionsRun = len(ran)
+	report.Stuck = summarizeReportIncidents(stuck)
+	report.Crashes = summarizeReportIncidents(crashed)
+	report.Interventions.Sessions = len(intervened)
+	return report
+}
+
+func addFinishedSessions(report *fleetReport, finished []historyEntry) {
+	outcomes := map[string]int{}
+	completedDurations := []int64{}
+	lanes := map[string]*reportRollup{}
+	objectives := map[string]*reportRollup{}
+	objectiveStatus := map[string]map[string]sessionObjectiveRecord{}
+	sessions := make([]reportSession, 0, len(finished))
+
+	for _, entry := range finished {
+		outcomes[entry.FinalState]++
+		if entry.FinalState == "completed" {
+			completedDurations = append(completedDurations, entry.DurationSeconds)
+		}
+		tokens := 0
+		if entry.Tokens != nil {
+			report.Tokens.add(*entry.Tokens)
+			tokens = entry.Tokens.Total
+		}
+		if entry.Lane != "" {
+			addReportRollup(lanes, entry.Lane, entry, tokens)
+		}
+		if entry.ObjectiveID != "" {
+			rollup := addReportRollup(objectives, entry.ObjectiveID, entry, tokens)
+			if rollup.Goal == "" {
+				rollup.Goal = entry.ObjectiveGoal
+			}
+			if _, ok := objectiveStatus[entry.ProjectRoot]; !ok {
+				store, _ := loadObjectiveStore(entry.ProjectRoot)
+				objectiveStatus[entry.ProjectRoot] = store.Objectives
+			}
+			if record, ok := objectiveStatus[entry.ProjectRoot][entry.ObjectiveID]; ok {
+				rollup.Status = record.Status
+			}
+		}
+		session := reportSession{
+			Session:         entry.Session,
+			Agent:           entry.Agent,
+			FinalState:      entry.FinalState,
+			LastState:       entry.LastState,
+			ExitCode:        entry.ExitCode,
+			DurationSeconds: entry.DurationSeconds,
+			EndedAt:         entry.EndedAt,
+			ArchivePath:     entry.ArchivePath,
+		}
+		sessions = append(sessions, session)
+		if reportSessionFailed(entry) {
+			report.Failed = append(report.Failed, session)
+		}
+	}
+
+	report.Outcomes = sortedReportCounts(outcomes)
+	report.TimeToCompletion = reportDurations(completedDurations)
+	report.Lanes = sortedReportRollups(lanes)
+	report.Objectives = sortedReportRollups(objectives)
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].DurationSeconds > sessions[j].DurationSeconds })
+	if len(sessions) > reportTopSessions {
+		sessions = sessions[:reportTopSessions]
+	}
+	report.Slowest = sessions
+}
+
+func addReportRollup(rollups map[string]*reportRollup, name string, entry historyEntry, tokens int) *reportRollup {
+	rollup, ok := rollups[name]
+	if !ok {
+		rollup = &reportRollup{Name: name}
+		rollups[name] = rollup
+	}
+	rollup.Sessions++
+	rollup.Tokens += tokens
+	rollup.DurationSeconds += entry.DurationSeconds
+	if entry.FinalState == "completed" {
+		rollup.Completed++
+	}
+	if reportSessionFailed(entry) {
+		rollup.Failed++
+	}
+	return rollup
+}
+
+func markReportIncident(incidents map[string]map[string]bool, session, reason string) {
+	if incidents[session] == nil {
+		incidents[session] = map[string]bool{}
+	}
+	if strings.TrimSpace(reason) == "" {
+		reason = "unknown"
+	}
+	incidents[session][reason] = true
+}
+
+// summarizeReportIncidents counts each reason once per session, so a stuck
+// session polled fifty times does not dominate the tally.
+func summarizeReportIncidents(incidents map[string]map[string]bool) reportIncidents {
+	reasons := map[string]int{}
+	for _, sessionReasons := range incidents {
+		for reason := range sessionReasons {
+			reasons[reason]++
+		}
+	}
+	return reportIncidents{Sessions: len(incidents), Reasons: sortedReportCounts(reasons)}
+}
+
+func sortedReportCounts(counts map[string]int) []reportCount {
+	out := make([]reportCount, 0, len(counts))
+	for name, count := range counts {
+		out = append(out, reportCount{Name: name, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func sortedReportRollups(rollups map[string]*reportRollup) []reportRollup {
+	out := make([]reportRollup, 0, len(rollups))
+	for _, rollup := range rollups {
+		out = append(out, *rollup)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Sessions != out[j].Sessions {
+			return out[i].Sessions > out[j].Sessions
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func reportDurations(values []int64) reportDurationStats {
+	stats := reportDurationStats{Count: len(values)}
+	if len(values) == 0 {
+		return stats
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	total := int64(0)
+	for _, v := range sorted {
+		total += v
+	}
+	stats.AvgSeconds = total / int64(len(sorted))
+	stats.MedianSeconds = sorted[len(sorted)/2]
+	stats.P90Seconds = sorted[(len(sorted)*9)/10]
+	stats.MaxSeconds = sorted[len(sorted)-1]
+	return stats
+}
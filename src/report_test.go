@@ -0,0 +1,130 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportSummarizesHistoryEventsAndObjectives(t *testing.T) {
+	t.Setenv(historyDirEnv, t.TempDir())
+	root := canonicalProjectRoot(t.TempDir())
+	origNow := nowFn
+	t.Cleanup(func() { nowFn = origNow })
+	nowFn = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+
+	exit0, exit2 := 0, 2
+	entries := []historyEntry{
+		{Session: "lisa-rep-ok", ProjectRoot: root, Agent: "claude", FinalState: "completed", ExitCode: &exit0, Lane: "backend",
+			ObjectiveID: "obj-1", ObjectiveGoal: "Ship auth", EndedAt: "2026-10-19T10:00:00Z", DurationSeconds: 600,
+			Tokens: &historyTokenUsage{Input: 100, Output: 20, Total: 120}},
+		{Session: "lisa-rep-fail", ProjectRoot: root, Agent: "codex", FinalState: "crashed", ExitCode: &exit2, Lane: "backend",
+			ObjectiveID: "obj-1", EndedAt: "2026-10-19T11:00:00Z", DurationSeconds: 1800, LastState: "stuck",
+			ArchivePath: "/tmp/archives/lisa-rep-fail", Tokens: &historyTokenUsage{Input: 50, Output: 5, Total: 55}},
+		{Session: "lisa-rep-gone", ProjectRoot: root, Agent: "claude", FinalState: "crashed", ExitCode: &exit2, LastState: "stuck",
+			EndReason: "kill", EndedAt: "2026-10-19T09:00:00Z", DurationSeconds: 30, ArchivePath: "/tmp/archives/lisa rep#gone"},
+		{Session: "lisa-rep-old", ProjectRoot: root, Agent: "claude", FinalState: "completed", EndedAt: "2026-10-17T10:00:00Z", DurationSeconds: 60},
+		{Session: "lisa-rep-other", ProjectRoot: "/elsewhere", Agent: "claude", FinalState: "completed", EndedAt: "2026-10-19T10:00:00Z"},
+	}
+	for _, entry := range entries {
+		if err := appendHistoryEntry(entry); err != nil {
+			t.Fatalf("append history failed: %v", err)
+		}
+	}
+	if err := saveObjectiveStore(root, sessionObjectiveStore{Objectives: map[string]sessionObjectiveRecord{"obj-1": {ID: "obj-1", Goal: "Ship auth", Status: "open"}}}); err != nil {
+		t.Fatalf("save objectives failed: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Remove(objectivesRegistryFile(root)) })
+
+	events := map[string][]sessionEvent{
+		"lisa-rep-fail": {
+			{At: "2026-10-19T10:30:00Z", Type: "poll", State: "stuck", Reason: "no_output_timeout"},
+			{At: "2026-10-19T10:31:00Z", Type: "poll", State: "stuck", Reason: "no_output_timeout"},
+			{At: "2026-10-19T10:32:00Z", Type: "lifecycle", State: "in_progress", Reason: "send_text"},
+			{At: "2026-10-19T10:33:00Z", Type: "lifecycle", State: "in_progress", Reason: "monitor_auto_recover"},
+			{At: "2026-10-19T10:59:00Z", Type: "poll", State: "crashed", Reason: "exit_nonzero"},
+		},
+		"lisa-rep-live": {
+			{At: "2026-10-19T11:30:00Z", Type: "prompt_decision", State: "waiting_input", Reason: "prompt_approve"},
+			{At: "2026-10-17T11:30:00Z", Type: "poll", State: "crashed", Reason: "old_crash"},
+		},
+	}
+	for session, list := range events {
+		t.Cleanup(func() { _ = os.Remove(sessionEventsFile(root, session)) })
+		for _, event := range list {
+			event.Session = session
+			if err := appendSessionEvent(root, session, event); err != nil {
+				t.Fatalf("append event failed: %v", err)
+			}
+		}
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdReport([]string{"--project-root", root, "--json"}); code != 0 {
+			t.Fatalf("report failed: %d", code)
+		}
+	})
+	report := fleetReport{}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("invalid report JSON: %v (%q)", err, stdout)
+	}
+	if report.SessionsRun != 4 || report.SessionsFinished != 3 || report.Tokens.Total != 175 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if report.TimeToCompletion.Count != 1 || report.TimeToCompletion.MaxSeconds != 600 {
+		t.Fatalf("unexpected time to completion: %+v", report.TimeToCompletion)
+	}
+	if report.Stuck.Sessions != 2 || len(report.Stuck.Reasons) != 2 || report.Stuck.Reasons[0].Count != 1 ||
+		!strings.Contains(reportCountNames(report.Stuck.Reasons), "ended_stuck_kill") {
+		t.Fatalf("expected stuck reasons counted once per session: %+v", report.Stuck)
+	}
+	if report.Crashes.Sessions != 2 || reportCountNames(report.Crashes.Reasons) != "exit_2,exit_nonzero" {
+		t.Fatalf("unexpected crashes: %+v", report.Crashes)
+	}
+	if report.Interventions.Nudges != 1 || report.Interventions.AutoRecover != 1 || report.Interventions.PromptAnswers != 1 || report.Interventions.Sessions != 2 {
+		t.Fatalf("unexpected interventions: %+v", report.Interventions)
+	}
+	if len(report.Objectives) != 1 || report.Objectives[0].Status != "open" || report.Objectives[0].Goal != "Ship auth" ||
+		report.Objectives[0].Sessions != 2 || report.Objectives[0].Failed != 1 {
+		t.Fatalf("unexpected objective rollup: %+v", report.Objectives)
+	}
+	if len(report.Lanes) != 1 || report.Lanes[0].Tokens != 175 || report.Lanes[0].Completed != 1 {
+		t.Fatalf("unexpected lane rollup: %+v", report.Lanes)
+	}
+	if len(report.Failed) != 2 || report.Failed[0].ArchivePath == "" || report.Slowest[0].Session != "lisa-rep-fail" {
+		t.Fatalf("unexpected slowest/failed: %+v %+v", report.Slowest, report.Failed)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdReport([]string{"--project-root", root, "--format", "markdown"}); code != 0 {
+			t.Fatalf("markdown report failed: %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "## Failed sessions") || !strings.Contains(stdout, "[archive](file:///tmp/archives/lisa-rep-fail)") {
+		t.Fatalf("unexpected markdown report: %s", stdout)
+	}
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdReport([]string{"--project-root", root, "--format", "html"}); code != 0 {
+			t.Fatalf("html report failed: %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `<a href="file:///tmp/archives/lisa-rep-fail">archive</a>`) ||
+		!strings.Contains(stdout, `<a href="file:///tmp/archives/lisa%20rep%23gone">archive</a>`) {
+		t.Fatalf("unexpected html report: %s", stdout)
+	}
+	_, _ = captureOutput(t, func() {
+		if code := cmdReport([]string{"--format", "pdf"}); code == 0 {
+			t.Fatalf("expected invalid format to fail")
+		}
+	})
+}
+
+func reportCountNames(counts []reportCount) string {
+	names := make([]string, 0, len(counts))
+	for _, count := range counts {
+		names = append(names, count.Name)
+	}
+	return strings.Join(names, ",")
+}
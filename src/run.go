@@ -12,9 +12,22 @@ func Run(args []string) int {
 		return 1
 	}
 
-	cmd := args[0]
-	rest := args[1:]
+	configureOutputRedaction(redactionProjectRoot(args))
+	if name := auditCommandName(args); name != "" {
+		auditSessionNote = ""
+		args, confirmed := stripGuardConfirm(args)
+		if code, blocked := checkGuardPolicyFn(name, args, confirmed); blocked {
+			recordAuditFn(name, args, code)
+			return code
+		}
+		code := dispatchCommand(args[0], args[1:])
+		recordAuditFn(name, args, code)
+		return code
+	}
+	return dispatchCommand(args[0], args[1:])
+}
 
+func dispatchCommand(cmd string, rest []string) int {
 	switch cmd {
 	case "doctor":
 		return cmdDoctor(rest)
@@ -22,6 +35,10 @@ func Run(args []string) int {
 		return cmdCleanup(rest)
 	case "capabilities":
 		return cmdCapabilities(rest)
+	case "classify":
+		return cmdClassify(rest)
+	case "fake-agent":
+		return cmdFakeAgent(rest)
 	case "version", "--version", "-version", "-v":
 		fmt.Printf("lisa %s (commit %s, built %s)\n", BuildVersion, BuildCommit, BuildDate)
 		return 0
@@ -33,6 +50,24 @@ func Run(args []string) int {
 		return cmdSkills(rest)
 	case "oauth":
 		return cmdOAuth(rest)
+	case "queue":
+		return cmdQueue(rest)
+	case "result":
+		return cmdResult(rest)
+	case "msg":
+		return cmdMsg(rest)
+	case "hook":
+		return cmdHook(rest)
+	case "audit":
+		return cmdAudit(rest)
+	case "history":
+		return cmdHistory(rest)
+	case "report":
+		return cmdReport(rest)
+	case "events":
+		return cmdEvents(rest)
+	case "payload":
+		return cmdPayload(rest)
 	case "help", "--help", "-h":
 		return showHelp(strings.Join(rest, " "))
 	default:
@@ -0,0 +1,302 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const sessionEventManifestVersion = 1
+
+type sessionEventSegment struct {
+	Seq             int    `json:"seq"`
+	File            string `json:"file"`
+	Lines           int    `json:"lines"`
+	Bytes           int64  `json:"bytes"`
+	CompressedBytes int64  `json:"compressedBytes"`
+	FirstAt         string `json:"firstAt,omitempty"`
+	LastAt          string `json:"lastAt,omitempty"`
+	RotatedAt       string `json:"rotatedAt"`
+}
+
+// sessionEventManifest lists the gzip segments rotated out of an event log,
+// oldest first. PrunedLines counts lines dropped by retention so offsets
+// handed out as cursors stay absolute.
+type sessionEventManifest struct {
+	Version     int                   `json:"version"`
+	NextSeq     int                   `json:"nextSeq"`
+	PrunedLines int                   `json:"prunedLines"`
+	Segments    []sessionEventSegment `json:"segments"`
+}
+
+func (m sessionEventManifest) segmentLines() int {
+	total := 0
+	for _, segment := range m.Segments {
+		total += segment.Lines
+	}
+	return total
+}
+
+func sessionEventManifestFile(path string) string {
+	return path + ".manifest.json"
+}
+
+func sessionEventSegmentFile(path string, seq int) string {
+	return fmt.Sprintf("%s.%06d.gz", path, seq)
+}
+
+func sessionEventSegmentPattern(path string) string {
+	return path + ".*.gz"
+}
+
+func loadSessionEventManifest(path string) (sessionEventManifest, error) {
+	manifest := sessionEventManifest{Version: sessionEventManifestVersion, NextSeq: 1, Segments: []sessionEventSegment{}}
+	raw, err := os.ReadFile(sessionEventManifestFile(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return manifest, nil
+		}
+		return manifest, err
+	}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid event manifest %s: %w", sessionEventManifestFile(path), err)
+	}
+	return manifest, nil
+}
+
+func saveSessionEventManifest(path string, manifest sessionEventManifest) error {
+	manifest.Version = sessionEventManifestVersion
+	if manifest.Segments == nil {
+		manifest.Segments = []sessionEventSegment{}
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(sessionEventManifestFile(path), raw)
+}
+
+// removeSessionEventSegments drops the manifest and every segment of an
+// event log. Callers hold the event lock.
+func removeSessionEventSegments(path string) error {
+	targets, err := filepath.Glob(sessionEventSegmentPattern(path))
+	if err != nil {
+		return err
+	}
+	targets = append(targets, sessionEventManifestFile(path))
+	for _, target := range targets {
+		if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func eventLineTimestamp(line string) string {
+	var probe struct {
+		At string `json:"at"`
+	}
+	if json.Unmarshal([]byte(line), &probe) != nil {
+		return ""
+	}
+	return probe.At
+}
+
+// rotateSessionEventFile compresses the active log into the next segment,
+// applies retention and truncates the active file. Callers hold the
+// exclusive event lock. The manifest is saved before truncation so a crash
+// in between duplicates lines instead of losing them.
+func rotateSessionEventFile(path string) error {
+	lines, err := readSessionEventActiveLines(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	manifest, err := loadSessionEventManifest(path)
+	if err != nil {
+		return err
+	}
+	seq := manifest.NextSeq
+	if n := len(manifest.Segments); n > 0 && manifest.Segments[n-1].Seq >= seq {
+		seq = manifest.Segments[n-1].Seq + 1
+	}
+	if seq < 1 {
+		seq = 1
+	}
+
+	data := strings.Join(lines, "\n") + "\n"
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	segmentPath := sessionEventSegmentFile(path, seq)
+	if err := writeFileAtomic(segmentPath, compressed.Bytes()); err != nil {
+		return err
+	}
+	manifest.Segments = append(manifest.Segments, sessionEventSegment{
+		Seq:             seq,
+		File:            filepath.Base(segmentPath),
+		Lines:           len(lines),
+		Bytes:           int64(len(data)),
+		CompressedBytes: int64(compressed.Len()),
+		FirstAt:         eventLineTimestamp(lines[0]),
+		LastAt:          eventLineTimestamp(lines[len(lines)-1]),
+		RotatedAt:       nowFn().UTC().Format(time.RFC3339),
+	})
+	manifest.NextSeq = seq + 1
+	retentionErr := applySessionEventRetention(path, &manifest)
+	if err := saveSessionEventManifest(path, manifest); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, []byte{}); err != nil {
+		return err
+	}
+	return retentionErr
+}
+
+// applySessionEventRetention drops the oldest segments until the compressed
+// total fits LISA_EVENTS_RETAIN_BYTES and none is older than
+// LISA_EVENT_RETENTION_DAYS. Callers save the manifest afterwards.
+func applySessionEventRetention(path string, manifest *sessionEventManifest) error {
+	retainBytes := getIntEnv("LISA_EVENTS_RETAIN_BYTES", defaultEventsRetainBytes)
+	if retainBytes <= 0 {
+		retainBytes = defaultEventsRetainBytes
+	}
+	var cutoff time.Time
+	if retentionDays := getIntEnv("LISA_EVENT_RETENTION_DAYS", defaultEventRetentionDays); retentionDays > 0 {
+		cutoff = nowFn().Add(-time.Duration(retentionDays) * 24 * time.Hour)
+	}
+
+	total := int64(0)
+	for _, segment := range manifest.Segments {
+		total += segment.CompressedBytes
+	}
+	drop := 0
+	for drop < len(manifest.Segments) {
+		segment := manifest.Segments[drop]
+		if total <= int64(retainBytes) && !sessionEventSegmentStale(segment, cutoff) {
+			break
+		}
+		target := filepath.Join(filepath.Dir(path), segment.File)
+		if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+			manifest.Segments = manifest.Segments[drop:]
+			return err
+		}
+		total -= segment.CompressedBytes
+		manifest.PrunedLines += segment.Lines
+		drop++
+	}
+	manifest.Segments = manifest.Segments[drop:]
+	return nil
+}
+
+func sessionEventSegmentStale(segment sessionEventSegment, cutoff time.Time) bool {
+	if cutoff.IsZero() {
+		return false
+	}
+	for _, raw := range []string{segment.LastAt, segment.RotatedAt} {
+		if at, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(raw)); err == nil {
+			return at.Before(cutoff)
+		}
+	}
+	return false
+}
+
+func readEventLines(r io.Reader) ([]string, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	lines := []string{}
+	for {
+		line, err := br.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return lines, nil
+			}
+			return lines, err
+		}
+	}
+}
+
+func readSessionEventActiveLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readEventLines(f)
+}
+
+func readSessionEventSegment(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("event segment %s: %w", path, err)
+	}
+	defer zr.Close()
+	return readEventLines(zr)
+}
+
+// readSessionEventLogLines returns every retained line of an event log,
+// segments first, with the absolute offset of the first line. Callers hold
+// the event lock. A log with neither segments nor an active file returns
+// os.ErrNotExist.
+func readSessionEventLogLines(path string) ([]string, int, error) {
+	manifest, err := loadSessionEventManifest(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	lines := []string{}
+	for _, segment := range manifest.Segments {
+		segmentLines, err := readSessionEventSegment(filepath.Join(filepath.Dir(path), segment.File))
+		if err != nil {
+			return nil, 0, err
+		}
+		lines = append(lines, segmentLines...)
+	}
+	active, err := readSessionEventActiveLines(path)
+	if err != nil && !(errors.Is(err, os.ErrNotExist) && len(manifest.Segments) > 0) {
+		return nil, 0, err
+	}
+	return append(lines, active...), manifest.PrunedLines, nil
+}
+
+// readSessionEventLog returns the retained log as one JSONL document, for
+// archives and checkpoints.
+func readSessionEventLog(path string) ([]byte, error) {
+	lockTimeout := getIntEnv("LISA_EVENT_LOCK_TIMEOUT_MS", defaultEventLockTimeoutMS)
+	var raw []byte
+	err := withSharedFileLock(path+".lock", lockTimeout, func() error {
+		lines, _, err := readSessionEventLogLines(path)
+		if err != nil {
+			return err
+		}
+		if len(lines) > 0 {
+			raw = []byte(strings.Join(lines, "\n") + "\n")
+		}
+		return nil
+	})
+	return raw, err
+}
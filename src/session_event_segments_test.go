@@ -0,0 +1,127 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func appendSegmentTestEvents(t *testing.T, projectRoot, session string, from, to int, at time.Time) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		err := appendSessionEvent(projectRoot, session, sessionEvent{
+			At:      at.Add(time.Duration(i) * time.Second).Format(time.RFC3339Nano),
+			Type:    "snapshot",
+			Session: session,
+			State:   "in_progress",
+			Reason:  fmt.Sprintf("poll_%d", i),
+			Poll:    i,
+		})
+		if err != nil {
+			t.Fatalf("appendSessionEvent %d failed: %v", i, err)
+		}
+	}
+}
+
+func cleanupSegmentTestLog(t *testing.T, path string) {
+	t.Cleanup(func() {
+		_ = os.Remove(path)
+		_ = os.Remove(sessionEventCountFile(path))
+		_ = removeSessionEventSegments(path)
+	})
+}
+
+func TestSessionEventLogReadsAcrossSegments(t *testing.T) {
+	t.Setenv("LISA_EVENTS_MAX_LINES", "4")
+	projectRoot := t.TempDir()
+	session := "lisa-events-segments"
+	path := sessionEventsFile(projectRoot, session)
+	cleanupSegmentTestLog(t, path)
+	start := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	appendSegmentTestEvents(t, projectRoot, session, 1, 12, start)
+
+	manifest, err := loadSessionEventManifest(path)
+	if err != nil {
+		t.Fatalf("load manifest failed: %v", err)
+	}
+	if len(manifest.Segments) != 2 || manifest.Segments[0].Lines != 5 || manifest.Segments[0].FirstAt == "" {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	for _, segment := range manifest.Segments {
+		if _, err := readSessionEventSegment(filepath.Join(filepath.Dir(path), segment.File)); err != nil {
+			t.Fatalf("segment %s is not readable gzip: %v", segment.File, err)
+		}
+	}
+
+	events, _, err := readSessionEventsFile(path)
+	if err != nil || len(events) != 12 || events[0].Poll != 1 || events[11].Poll != 12 {
+		t.Fatalf("expected all 12 events in order, got %d (%v)", len(events), err)
+	}
+	since, err := readSessionEventsSince(projectRoot, session, explainSinceCursor{Mode: "offset", Offset: 3}, 0)
+	if err != nil || len(since.Events) != 9 || since.Events[0].Poll != 4 || since.NextCursor != 12 {
+		t.Fatalf("unexpected offset read: %+v (%v)", since, err)
+	}
+	cutoff := start.Add(10 * time.Second).UnixNano()
+	since, err = readSessionEventsSince(projectRoot, session, explainSinceCursor{Mode: "time", CutoffNS: cutoff}, 0)
+	if err != nil || len(since.Events) != 2 || since.Events[0].Poll != 11 {
+		t.Fatalf("unexpected time read: %+v (%v)", since, err)
+	}
+	delta, _, next, err := readSessionHandoffDelta(projectRoot, session, 2, 0)
+	if err != nil || len(delta) != 10 || delta[0].Reason != "poll_3" || next != 12 {
+		t.Fatalf("unexpected handoff delta: %d items next=%d (%v)", len(delta), next, err)
+	}
+	tail, err := readSessionEventTail(projectRoot, session, 7)
+	if err != nil || len(tail.Events) != 7 || tail.Events[0].Poll != 6 || tail.NextCursor != 12 {
+		t.Fatalf("unexpected tail: %+v (%v)", tail, err)
+	}
+	raw, err := readSessionEventLog(path)
+	if err != nil || strings.Count(string(raw), "\n") != 12 {
+		t.Fatalf("expected archived log with 12 lines, got %d bytes (%v)", len(raw), err)
+	}
+}
+
+func TestSessionEventRetentionKeepsCursorsAbsolute(t *testing.T) {
+	t.Setenv("LISA_EVENTS_MAX_LINES", "2")
+	t.Setenv("LISA_EVENTS_RETAIN_BYTES", "1")
+	projectRoot := t.TempDir()
+	session := "lisa-events-retention"
+	path := sessionEventsFile(projectRoot, session)
+	cleanupSegmentTestLog(t, path)
+	appendSegmentTestEvents(t, projectRoot, session, 1, 10, time.Now().UTC())
+
+	manifest, err := loadSessionEventManifest(path)
+	if err != nil {
+		t.Fatalf("load manifest failed: %v", err)
+	}
+	// A 1-byte budget keeps no segments; only the active file survives.
+	if len(manifest.Segments) != 0 || manifest.PrunedLines != 9 {
+		t.Fatalf("expected every segment pruned, got %+v", manifest)
+	}
+	if matches, _ := filepath.Glob(sessionEventSegmentPattern(path)); len(matches) != 0 {
+		t.Fatalf("expected pruned segment files removed, got %v", matches)
+	}
+	delta, _, next, err := readSessionHandoffDelta(projectRoot, session, 4, 0)
+	if err != nil || len(delta) != 1 || delta[0].Reason != "poll_10" || next != 10 {
+		t.Fatalf("expected delta past pruned lines to resume at the active file, got %+v next=%d (%v)", delta, next, err)
+	}
+
+	t.Setenv("LISA_EVENTS_RETAIN_BYTES", "")
+	t.Setenv("LISA_EVENT_RETENTION_DAYS", "1")
+	appendSegmentTestEvents(t, projectRoot, session, 11, 13, time.Now().UTC().Add(-72*time.Hour))
+	appendSegmentTestEvents(t, projectRoot, session, 14, 16, time.Now().UTC())
+	if err := pruneStaleSessionEventArtifacts(); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	manifest, _ = loadSessionEventManifest(path)
+	for _, segment := range manifest.Segments {
+		if sessionEventSegmentStale(segment, time.Now().Add(-24*time.Hour)) {
+			t.Fatalf("expected stale segment pruned by age: %+v", segment)
+		}
+	}
+	if manifest.PrunedLines <= 9 || !fileExists(path) {
+		t.Fatalf("expected age retention to prune old segments and keep the log: %+v", manifest)
+	}
+}
@@ -95,6 +95,12 @@ func sessionDoneFile(projectRoot, session string) string {
 	return fmt.Sprintf("/tmp/.lisa-%s-session-%s-done.txt", projectHash(projectRoot), sessionArtifactID(session))
 }
 
+// sessionLimitsFile records which limits backend the pane shell actually
+// applied at startup.
+func sessionLimitsFile(projectRoot, session string) string {
+	return fmt.Sprintf("/tmp/.lisa-%s-session-%s-limits.txt", projectHash(projectRoot), sessionArtifactID(session))
+}
+
 func sessionEventsFile(projectRoot, session string) string {
 	return fmt.Sprintf("/tmp/.lisa-%s-session-%s-events.jsonl", projectHash(projectRoot), sessionArtifactID(session))
 }
@@ -334,6 +340,23 @@ func loadSessionMetasForProject(projectRoot string, allHashes bool) ([]sessionMe
 }
 
 func listSessionDescendants(projectRoot, session string, allHashes bool) ([]string, error) {
+	levels, err := listSessionDescendantLevels(projectRoot, session, allHashes)
+	if err != nil {
+		return nil, err
+	}
+	order := make([]string, 0)
+	for _, level := range levels {
+		order = append(order, level...)
+	}
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order, nil
+}
+
+// listSessionDescendantLevels returns descendants grouped by depth below the
+// root session: index 0 holds direct children, index 1 grandchildren, etc.
+func listSessionDescendantLevels(projectRoot, session string, allHashes bool) ([][]string, error) {
 	metas, err := loadSessionMetasForProject(projectRoot, allHashes)
 	if err != nil {
 		return nil, err
@@ -353,27 +376,28 @@ func listSessionDescendants(projectRoot, session string, allHashes bool) ([]stri
 
 	root := strings.TrimSpace(session)
 	if root == "" {
-		return []string{}, nil
+		return [][]string{}, nil
 	}
-	queue := []string{root}
+	current := []string{root}
 	visited := map[string]bool{root: true}
-	order := make([]string, 0)
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-		for _, child := range childrenByParent[current] {
-			if visited[child] {
-				continue
+	levels := make([][]string, 0)
+	for len(current) > 0 {
+		next := make([]string, 0)
+		for _, parent := range current {
+			for _, child := range childrenByParent[parent] {
+				if visited[child] {
+					continue
+				}
+				visited[child] = true
+				next = append(next, child)
 			}
-			visited[child] = true
-			order = append(order, child)
-			queue = append(queue, child)
 		}
+		if len(next) > 0 {
+			levels = append(levels, next)
+		}
+		current = next
 	}
-	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
-		order[i], order[j] = order[j], order[i]
-	}
-	return order, nil
+	return levels, nil
 }
 
 func cleanupSessionArtifacts(projectRoot, session string) error {
@@ -384,6 +408,9 @@ func cleanupSessionArtifacts(projectRoot, session string) error {
 
 func cleanupSessionArtifactsWithOptions(projectRoot, session string, opts cleanupOptions) error {
 	var errs []string
+	if meta, err := loadSessionMeta(projectRoot, session); err == nil {
+		removeSessionCgroup(meta.Limits)
+	}
 	files := make(map[string]struct{}, 8)
 	for _, path := range []string{
 		sessionStateFile(projectRoot, session),
@@ -392,6 +419,11 @@ func cleanupSessionArtifactsWithOptions(projectRoot, session string, opts cleanu
 		sessionHeartbeatFile(projectRoot, session),
 		sessionDoneFile(projectRoot, session),
 		sessionStateLockFile(projectRoot, session),
+		sessionInboxFile(projectRoot, session),
+		sessionInboxFile(projectRoot, session) + ".lock",
+		sessionResultFile(projectRoot, session),
+		sessionClaudeSettingsFile(projectRoot, session),
+		sessionLimitsFile(projectRoot, session),
 	} {
 		files[path] = struct{}{}
 	}
@@ -399,6 +431,7 @@ func cleanupSessionArtifactsWithOptions(projectRoot, session string, opts cleanu
 		eventsPath := sessionEventsFile(projectRoot, session)
 		files[eventsPath] = struct{}{}
 		files[sessionEventCountFile(eventsPath)] = struct{}{}
+		files[sessionEventManifestFile(eventsPath)] = struct{}{}
 	}
 
 	sid := sessionArtifactID(session)
@@ -406,6 +439,9 @@ func cleanupSessionArtifactsWithOptions(projectRoot, session string, opts cleanu
 		sessionCommandScriptPattern(projectRoot, session),
 		fmt.Sprintf("/tmp/lisa-cmd-%s-*.sh", sid), // legacy pattern
 	}
+	if !opts.KeepEvents {
+		globPatterns = append(globPatterns, sessionEventSegmentPattern(sessionEventsFile(projectRoot, session)))
+	}
 	if opts.AllHashes {
 		globPatterns = append(globPatterns,
 			fmt.Sprintf("/tmp/.lisa-*-session-%s-state.json", sid),
@@ -413,6 +449,7 @@ func cleanupSessionArtifactsWithOptions(projectRoot, session string, opts cleanu
 			fmt.Sprintf("/tmp/lisa-*-output-%s.txt", sid),
 			fmt.Sprintf("/tmp/.lisa-*-session-%s-heartbeat.txt", sid),
 			fmt.Sprintf("/tmp/.lisa-*-session-%s-done.txt", sid),
+			fmt.Sprintf("/tmp/.lisa-*-session-%s-limits.txt", sid),
 			fmt.Sprintf("/tmp/.lisa-*-session-%s-state.json.lock", sid),
 			fmt.Sprintf("/tmp/lisa-cmd-*-%s-*.sh", sid),
 		)
@@ -420,6 +457,8 @@ func cleanupSessionArtifactsWithOptions(projectRoot, session string, opts cleanu
 			globPatterns = append(globPatterns,
 				fmt.Sprintf("/tmp/.lisa-*-session-%s-events.jsonl", sid),
 				fmt.Sprintf("/tmp/.lisa-*-session-%s-events.jsonl.lines", sid),
+				fmt.Sprintf("/tmp/.lisa-*-session-%s-events.jsonl.manifest.json", sid),
+				fmt.Sprintf("/tmp/.lisa-*-session-%s-events.jsonl.*.gz", sid),
 			)
 		}
 	}
@@ -0,0 +1,480 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	historyDirEnv         = "LISA_HISTORY_DIR"
+	historyArchiveVersion = "1"
+)
+
+// historyArchiveManifest describes one archived session. It is written last,
+// so a directory without manifest.json is an interrupted archive.
+type historyArchiveManifest struct {
+	Version             string                  `json:"version"`
+	ArchivedAt          string                  `json:"archivedAt"`
+	Session             string                  `json:"session"`
+	Agent               string                  `json:"agent,omitempty"`
+	Mode                string                  `json:"mode,omitempty"`
+	RunID               string                  `json:"runId,omitempty"`
+	ProjectRoot         string                  `json:"projectRoot"`
+	Labels              map[string]string       `json:"labels,omitempty"`
+	TranscriptPath      string                  `json:"transcriptPath,omitempty"`
+	TranscriptSessionID string                  `json:"transcriptSessionId,omitempty"`
+	Graceful            bool                    `json:"graceful"`
+	ExitCode            *int                    `json:"exitCode,omitempty"`
+	Files               []checkpointArchiveFile `json:"files"`
+}
+
+// historyDir is the root of the persistent session history store. Unlike the
+// /tmp session artifacts it survives cleanup and reboots.
+func historyDir() (string, error) {
+	if path := strings.TrimSpace(os.Getenv(historyDirEnv)); path != "" {
+		return expandAndCleanPath(path)
+	}
+	home, err := osUserHomeDirFn()
+	if err != nil || strings.TrimSpace(home) == "" {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".lisa", "history"), nil
+}
+
+// createHistoryArchiveDir makes a fresh `<session>-<stamp>-<random>` archive
+// directory. The random suffix keeps two stops of one session within the
+// same second from sharing a directory.
+func createHistoryArchiveDir(projectRoot, session string, at time.Time) (string, error) {
+	root, err := historyDir()
+	if err != nil {
+		return "", err
+	}
+	parent := filepath.Join(root, "archives", projectHash(projectRoot))
+	if err := os.MkdirAll(parent, 0o700); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(parent, sessionArtifactID(session)+"-"+at.UTC().Format("20060102T150405Z")+"-")
+}
+
+// sessionHistoryArchive is a `session stop` archive in progress. Begin copies
+// what cleanup removes (capture, meta, state, output, transcript pointer)
+// before the kill; finish adds the event log afterwards, so it ends with the
+// final stop and cleanup events, and writes the manifest.
+type sessionHistoryArchive struct {
+	Dir      string
+	manifest historyArchiveManifest
+}
+
+func beginSessionHistoryArchive(projectRoot, session, capture string, graceful bool, exitCode *int) (*sessionHistoryArchive, error) {
+	at := nowFn()
+	dir, err := createHistoryArchiveDir(projectRoot, session, at)
+	if err != nil {
+		return nil, err
+	}
+	archive := &sessionHistoryArchive{Dir: dir, manifest: historyArchiveManifest{
+		Version:     historyArchiveVersion,
+		ArchivedAt:  at.UTC().Format(time.RFC3339),
+		Session:     session,
+		ProjectRoot: projectRoot,
+		Graceful:    graceful,
+		ExitCode:    exitCode,
+		Files:       []checkpointArchiveFile{},
+	}}
+	files := map[string][]byte{}
+	meta, metaErr := loadSessionMeta(projectRoot, session)
+	state, _ := loadSessionStateWithError(sessionStateFile(projectRoot, session))
+	if metaErr == nil {
+		archive.manifest.Agent = meta.Agent
+		archive.manifest.Mode = meta.Mode
+		archive.manifest.RunID = meta.RunID
+		archive.manifest.Labels = meta.Labels
+		if path, sessionID, err := sessionTranscriptFile(projectRoot, session, meta, state); err == nil && fileExists(path) {
+			archive.manifest.TranscriptPath = path
+			archive.manifest.TranscriptSessionID = sessionID
+		}
+	}
+	for name, path := range map[string]string{
+		"meta.json":  sessionMetaFile(projectRoot, session),
+		"state.json": sessionStateFile(projectRoot, session),
+		"output.txt": sessionOutputFile(projectRoot, session),
+	} {
+		if raw, err := os.ReadFile(path); err == nil && len(raw) > 0 {
+			files[name] = raw
+		}
+	}
+	if raw, ok := files["output.txt"]; ok {
+		files["output.txt"] = []byte(redactOutputText(string(raw)))
+	}
+	if strings.TrimSpace(capture) != "" {
+		files["capture.txt"] = []byte(redactOutputText(capture))
+	}
+	for name, raw := range files {
+		if err := archive.writeFile(name, raw); err != nil {
+			return archive, err
+		}
+	}
+	return archive, nil
+}
+
+func (a *sessionHistoryArchive) writeFile(name string, raw []byte) error {
+	if err := os.WriteFile(filepath.Join(a.Dir, name), raw, 0o600); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(raw)
+	a.manifest.Files = append(a.manifest.Files, checkpointArchiveFile{Name: name, Bytes: len(raw), SHA256: hex.EncodeToString(sum[:])})
+	return nil
+}
+
+func (a *sessionHistoryArchive) finish(projectRoot, session string) error {
+	if raw, err := readSessionEventLog(sessionEventsFile(projectRoot, session)); err == nil && len(raw) > 0 {
+		if err := a.writeFile("events.jsonl", raw); err != nil {
+			return err
+		}
+	}
+	sort.Slice(a.manifest.Files, func(i, j int) bool { return a.manifest.Files[i].Name < a.manifest.Files[j].Name })
+	raw, err := json.MarshalIndent(a.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(a.Dir, "manifest.json"), raw)
+}
+
+const (
+	historyIndexLockTimeoutMS = 2500
+	historyPromptMaxRunes     = 400
+)
+
+type historyTokenUsage struct {
+	Input         int `json:"input"`
+	Output        int `json:"output"`
+	CacheRead     int `json:"cacheRead,omitempty"`
+	CacheCreation int `json:"cacheCreation,omitempty"`
+	Total         int `json:"total"`
+}
+
+func (u *historyTokenUsage) add(other historyTokenUsage) {
+	u.Input += other.Input
+	u.Output += other.Output
+	u.CacheRead += other.CacheRead
+	u.CacheCreation += other.CacheCreation
+	u.Total += other.Total
+}
+
+// historyEntry is one finished session in the history index.
+type historyEntry struct {
+	Session         string             `json:"session"`
+	ParentSession   string             `json:"parentSession,omitempty"`
+	ProjectRoot     string             `json:"projectRoot"`
+	Agent           string             `json:"agent"`
+	Mode            string             `json:"mode"`
+	Model           string             `json:"model,omitempty"`
+	Prompt          string             `json:"prompt,omitempty"`
+	ObjectiveID     string             `json:"objectiveId,omitempty"`
+	ObjectiveGoal   string             `json:"objectiveGoal,omitempty"`
+	Lane            string             `json:"lane,omitempty"`
+	Labels          map[string]string  `json:"labels,omitempty"`
+	RunID           string             `json:"runId,omitempty"`
+	StartedAt       string             `json:"startedAt,omitempty"`
+	EndedAt         string             `json:"endedAt"`
+	DurationSeconds int64              `json:"durationSeconds"`
+	FinalState      string             `json:"finalState"`
+	LastState       string             `json:"lastState,omitempty"`
+	ExitCode        *int               `json:"exitCode,omitempty"`
+	EndReason       string             `json:"endReason"`
+	Tokens          *historyTokenUsage `json:"tokens,omitempty"`
+	TranscriptPath  string             `json:"transcriptPath,omitempty"`
+	ArchivePath     string             `json:"archivePath,omitempty"`
+}
+
+// historyArchiveRef carries what session stop learned before killing.
+type historyArchiveRef struct {
+	Dir      string
+	Graceful bool
+	ExitCode *int
+}
+
+func historyIndexFile() (string, error) {
+	root, err := historyDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "index.jsonl"), nil
+}
+
+// killSessionWithHistory kills the tmux session and records it in the
+// history index only once the kill succeeds. The entry is built first, while
+// meta, state and the done file still exist. Every path that kills a session
+// goes through it, so a failed kill leaves no entry behind.
+func killSessionWithHistory(projectRoot, session, endReason string, archive *historyArchiveRef) error {
+	entry, historyErr := pendingSessionHistory(projectRoot, session, endReason, archive)
+	if err := tmuxKillSessionFn(session); err != nil {
+		return err
+	}
+	if historyErr == nil && entry != nil {
+		historyErr = appendHistoryEntry(*entry)
+	}
+	if historyErr != nil {
+		fmt.Fprintf(os.Stderr, "history warning: %s: %v\n", session, historyErr)
+	}
+	return nil
+}
+
+// recordSessionHistory appends a session that has already ended to the
+// history index.
+func recordSessionHistory(projectRoot, session, endReason string, archive *historyArchiveRef) error {
+	entry, err := pendingSessionHistory(projectRoot, session, endReason, archive)
+	if err != nil || entry == nil {
+		return err
+	}
+	return appendHistoryEntry(*entry)
+}
+
+// pendingSessionHistory builds the history entry for a session. It returns
+// nil for sessions without meta and for runs that already have an entry, so
+// a later cleanup of the same run does not duplicate it.
+func pendingSessionHistory(projectRoot, session, endReason string, archive *historyArchiveRef) (*historyEntry, error) {
+	meta, err := loadSessionMeta(projectRoot, session)
+	if err != nil {
+		return nil, nil
+	}
+	if meta.RunID != "" {
+		recorded, err := historyHasRun(session, meta.RunID)
+		if err != nil || recorded {
+			return nil, err
+		}
+	}
+	state, _ := loadSessionStateWithError(sessionStateFile(projectRoot, session))
+	entry := buildHistoryEntry(projectRoot, session, meta, state, endReason, archive)
+	return &entry, nil
+}
+
+func buildHistoryEntry(projectRoot, session string, meta sessionMeta, state sessionState, endReason string, archive *historyArchiveRef) historyEntry {
+	ended := nowFn().UTC()
+	prompt := strings.TrimSpace(meta.Prompt)
+	if runes := []rune(prompt); len(runes) > historyPromptMaxRunes {
+		prompt = string(runes[:historyPromptMaxRunes]) + "..."
+	}
+	entry := historyEntry{
+		Session:       session,
+		ParentSession: meta.ParentSession,
+		ProjectRoot:   projectRoot,
+		Agent:         meta.Agent,
+		Mode:          meta.Mode,
+		Model:         sessionModelFromCommand(meta.StartCmd),
+		Prompt:        redactOutputText(prompt),
+		ObjectiveID:   meta.ObjectiveID,
+		ObjectiveGoal: meta.ObjectiveGoal,
+		Lane:          meta.Lane,
+		Labels:        meta.Labels,
+		RunID:         meta.RunID,
+		StartedAt:     meta.CreatedAt,
+		EndedAt:       ended.Format(time.RFC3339),
+		EndReason:     endReason,
+	}
+	if started, err := time.Parse(time.RFC3339, meta.CreatedAt); err == nil && ended.After(started) {
+		entry.DurationSeconds = int64(ended.Sub(started) / time.Second)
+	}
+	if tail, err := readSessionEventTail(projectRoot, session, 20); err == nil {
+		// Skip the events stop/kill itself just wrote.
+		for i := len(tail.Events) - 1; i >= 0; i-- {
+			reason := tail.Events[i].Reason
+			if strings.HasPrefix(reason, "stop_") || strings.HasPrefix(reason, "kill") {
+				continue
+			}
+			entry.LastState = tail.Events[i].State
+			break
+		}
+	}
+
+	exitCode := (*int)(nil)
+	if done, code, _, _, err := readSessionDoneFile(projectRoot, session, meta.RunID); err == nil && done {
+		exitCode = &code
+	}
+	if archive != nil {
+		entry.ArchivePath = archive.Dir
+		if archive.ExitCode != nil {
+			exitCode = archive.ExitCode
+		}
+	}
+	entry.ExitCode = exitCode
+	switch {
+	case exitCode != nil && *exitCode == 0:
+		entry.FinalState = "completed"
+	case archive != nil && archive.Graceful:
+		// A non-zero exit after our own interrupt is not a crash.
+		entry.FinalState = "stopped"
+	case exitCode == nil:
+		entry.FinalState = "killed"
+	default:
+		entry.FinalState = "crashed"
+	}
+
+	if path, _, err := sessionTranscriptFile(projectRoot, session, meta, state); err == nil && fileExists(path) {
+		entry.TranscriptPath = path
+		entry.Tokens = transcriptTokenUsage(meta.Agent, path)
+	}
+	return entry
+}
+
+func appendHistoryEntry(entry historyEntry) error {
+	path, err := historyIndexFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return withExclusiveFileLock(path+".lock", historyIndexLockTimeoutMS, func() error {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write(append(line, '\n'))
+		return err
+	})
+}
+
+// readHistoryEntries returns the index oldest first; corrupt lines are skipped.
+func readHistoryEntries() ([]historyEntry, error) {
+	path, err := historyIndexFile()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []historyEntry{}, nil
+		}
+		return nil, err
+	}
+	entries := []historyEntry{}
+	for _, line := range strings.Split(string(raw), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry := historyEntry{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// historyHasRun reports whether the index already holds an entry for the run.
+func historyHasRun(session, runID string) (bool, error) {
+	entries, err := readHistoryEntries()
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if entry.Session == session && entry.RunID == runID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sessionModelFromCommand extracts the --model value from a start command.
+func sessionModelFromCommand(command string) string {
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		field = strings.Trim(field, `'"`)
+		if value, ok := strings.CutPrefix(field, "--model="); ok {
+			return strings.Trim(value, `'"`)
+		}
+		if field == "--model" && i+1 < len(fields) {
+			return strings.Trim(fields[i+1], `'"`)
+		}
+	}
+	return ""
+}
+
+// transcriptTokenUsage sums token usage from an agent transcript: Claude
+// assistant message usage (deduplicated by message id) or the last Codex
+// token_count total. Claude repeats a message's usage on every streamed
+// content line, so the last line per message id wins.
+func transcriptTokenUsage(agent, path string) *historyTokenUsage {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	usage := historyTokenUsage{}
+	found := false
+	byMessage := map[string]historyTokenUsage{}
+	for _, line := range strings.Split(string(raw), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if normalizeAgent(agent) == "codex" {
+			var entry struct {
+				Type    string `json:"type"`
+				Payload struct {
+					Type string `json:"type"`
+					Info struct {
+						Total struct {
+							Input  int `json:"input_tokens"`
+							Cached int `json:"cached_input_tokens"`
+							Output int `json:"output_tokens"`
+							Total  int `json:"total_tokens"`
+						} `json:"total_token_usage"`
+					} `json:"info"`
+				} `json:"payload"`
+			}
+			if json.Unmarshal([]byte(line), &entry) != nil || entry.Type != "event_msg" || entry.Payload.Type != "token_count" {
+				continue
+			}
+			total := entry.Payload.Info.Total
+			// Codex counts cached tokens inside input_tokens; split them out
+			// so input means uncached input for both agents.
+			usage = historyTokenUsage{Input: total.Input - total.Cached, Output: total.Output, CacheRead: total.Cached, Total: total.Total}
+			found = true
+			continue
+		}
+		var entry struct {
+			Type    string `json:"type"`
+			Message struct {
+				ID    string `json:"id"`
+				Usage *struct {
+					Input         int `json:"input_tokens"`
+					Output        int `json:"output_tokens"`
+					CacheCreation int `json:"cache_creation_input_tokens"`
+					CacheRead     int `json:"cache_read_input_tokens"`
+				} `json:"usage"`
+			} `json:"message"`
+		}
+		if json.Unmarshal([]byte(line), &entry) != nil || entry.Type != "assistant" || entry.Message.Usage == nil {
+			continue
+		}
+		u := entry.Message.Usage
+		messageUsage := historyTokenUsage{Input: u.Input, Output: u.Output, CacheRead: u.CacheRead, CacheCreation: u.CacheCreation}
+		found = true
+		if entry.Message.ID != "" {
+			byMessage[entry.Message.ID] = messageUsage
+			continue
+		}
+		usage.add(messageUsage)
+	}
+	for _, messageUsage := range byMessage {
+		usage.add(messageUsage)
+	}
+	if !found {
+		return nil
+	}
+	if usage.Total == 0 {
+		usage.Total = usage.Input + usage.CacheRead + usage.CacheCreation + usage.Output
+	}
+	return &usage
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHookRecordsAgentEventsUntilNextInput(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	session := "lisa-hook-events"
+	origStdin, origAppend := hookReadStdinFn, appendSessionEventFn
+	t.Cleanup(func() {
+		hookReadStdinFn, appendSessionEventFn = origStdin, origAppend
+		_ = os.Remove(sessionStateFile(root, session))
+		_ = os.Remove(sessionStateLockFile(root, session))
+	})
+	events := []sessionEvent{}
+	appendSessionEventFn = func(_ string, _ string, event sessionEvent) error { events = append(events, event); return nil }
+	t.Setenv("LISA_SESSION_NAME", session)
+	t.Setenv(lisaProjectRootEnv, root)
+
+	hookReadStdinFn = func() ([]byte, error) {
+		return []byte(`{"hook_event_name":"Notification","message":"Claude needs your permission to use Bash"}`), nil
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdHook([]string{"--agent", "claude", "--json"}); code != 0 {
+			t.Fatalf("expected claude hook to exit 0, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"event":"needs_input"`) || !strings.Contains(stdout, `"recorded":true`) {
+		t.Fatalf("unexpected hook output: %s", stdout)
+	}
+	state, _ := loadSessionStateWithError(sessionStateFile(root, session))
+	if freshSessionHookEvent(state) != sessionHookNeedsInput || !strings.Contains(state.LastHookMessage, "permission") {
+		t.Fatalf("expected needs_input in state, got %+v", state)
+	}
+
+	if err := recordSessionInputTimestamp(root, session, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("record input failed: %v", err)
+	}
+	state, _ = loadSessionStateWithError(sessionStateFile(root, session))
+	if freshSessionHookEvent(state) != "" {
+		t.Fatalf("input sent after the hook must make it stale, got %q", freshSessionHookEvent(state))
+	}
+
+	nowFn = func() time.Time { return time.Now().Add(2 * time.Second) }
+	t.Cleanup(func() { nowFn = time.Now })
+	payload := `{"type":"agent-turn-complete","turn-id":"1","last-assistant-message":"All tests pass."}`
+	_, _ = captureOutput(t, func() {
+		if code := cmdHook([]string{"--agent", "codex", "--session", session, "--project-root", root, payload}); code != 0 {
+			t.Fatalf("expected codex notify to exit 0, got %d", code)
+		}
+	})
+	state, _ = loadSessionStateWithError(sessionStateFile(root, session))
+	if freshSessionHookEvent(state) != sessionHookTurnComplete || state.LastTurnCompleteAtNanos != state.LastHookAtNanos {
+		t.Fatalf("expected turn_complete to refresh the turn marker, got %+v", state)
+	}
+	status := sessionStatus{}
+	applyCachedTurnCompleteSignals(&status, state)
+	if !status.Signals.TranscriptTurnComplete {
+		t.Fatalf("expected cached turn-complete signal from hook")
+	}
+	if len(events) != 2 || events[1].Type != "hook" || events[1].Reason != "hook_turn_complete" {
+		t.Fatalf("unexpected hook events: %+v", events)
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := cmdHook([]string{"--agent", "codex", `{"type":"something-else"}`}); code != 0 {
+			t.Fatalf("unknown payloads must not fail the agent, got %d", code)
+		}
+		if code := cmdHook([]string{"--event", "bogus"}); code == 0 {
+			t.Fatalf("expected invalid --event to fail")
+		}
+	})
+	if len(events) != 2 {
+		t.Fatalf("unknown payload must not record an event, got %+v", events)
+	}
+}
+
+func TestHookEventsOutrankHeuristicsAndSpawnWiresHooks(t *testing.T) {
+	rules := loadClassificationRules(t.TempDir())
+	busy := map[string]bool{"agentPidAlive": true, "agentBusy": true, "interactive": true, "hookTurnComplete": true}
+	status := sessionStatus{}
+	classifySessionWithRules(&status, rules, classificationFacts{Agent: "claude", Mode: "interactive", PollCount: 9, Bools: busy})
+	if status.SessionState != "waiting_input" || status.ClassificationReason != "hook_turn_complete" {
+		t.Fatalf("expected hook to outrank busy CPU, got %s/%s", status.SessionState, status.ClassificationReason)
+	}
+	status = sessionStatus{}
+	classifySessionWithRules(&status, rules, classificationFacts{Agent: "codex", Mode: "exec", PollCount: 9, Bools: busy})
+	if status.ClassificationReason == "hook_turn_complete" {
+		t.Fatalf("hook rules only apply to interactive sessions")
+	}
+
+	root := canonicalProjectRoot(t.TempDir())
+	session := "lisa-hook-spawn"
+	origExe := osExecutableFn
+	t.Cleanup(func() {
+		osExecutableFn = origExe
+		_ = os.Remove(sessionClaudeSettingsFile(root, session))
+	})
+	osExecutableFn = func() (string, error) { return "/opt/lisa bin/lisa", nil }
+
+	args, wired := applyAgentHookArgs("claude", root, session, "--model opus")
+	if !wired || !strings.HasPrefix(args, "--model opus --settings ") || !strings.Contains(args, sessionClaudeSettingsFile(root, session)) {
+		t.Fatalf("unexpected claude hook args: %q", args)
+	}
+	if args, wired := applyAgentHookArgs("claude", root, session, "--settings mine.json"); wired || args != "--settings mine.json" {
+		t.Fatalf("caller settings must win, got %q", args)
+	}
+	args, wired = applyAgentHookArgs("codex", root, session, "")
+	want := `-c 'notify=["/opt/lisa bin/lisa","hook","--agent","codex","--session","lisa-hook-spawn","--project-root","` + root + `"]'`
+	if !wired || args != want {
+		t.Fatalf("unexpected codex notify args:\n got %s\nwant %s", args, want)
+	}
+
+	if err := writeClaudeHookSettings(root, session); err != nil {
+		t.Fatalf("write settings failed: %v", err)
+	}
+	raw, err := os.ReadFile(sessionClaudeSettingsFile(root, session))
+	if err != nil {
+		t.Fatalf("read settings failed: %v", err)
+	}
+	settings := struct {
+		Hooks map[string][]struct {
+			Hooks []struct {
+				Type    string `json:"type"`
+				Command string `json:"command"`
+			} `json:"hooks"`
+		} `json:"hooks"`
+	}{}
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		t.Fatalf("invalid settings JSON: %v", err)
+	}
+	for _, name := range []string{"Stop", "Notification", "UserPromptSubmit"} {
+		entries := settings.Hooks[name]
+		if len(entries) != 1 || len(entries[0].Hooks) != 1 || entries[0].Hooks[0].Command != "'/opt/lisa bin/lisa' hook --agent claude --session 'lisa-hook-spawn' --project-root '"+root+"'" {
+			t.Fatalf("unexpected %s hook: %+v", name, entries)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionInboxMessage is one deferred `session send` waiting for its
+// delivery condition.
+type sessionInboxMessage struct {
+	ID       string   `json:"id"`
+	Text     string   `json:"text,omitempty"`
+	Keys     []string `json:"keys,omitempty"`
+	Enter    bool     `json:"enter,omitempty"`
+	When     string   `json:"when"`
+	QueuedAt string   `json:"queuedAt"`
+	Attempts int      `json:"attempts,omitempty"`
+	LastErr  string   `json:"lastError,omitempty"`
+}
+
+type sessionInbox struct {
+	Session  string                `json:"session"`
+	NextSeq  int                   `json:"nextSeq"`
+	Messages []sessionInboxMessage `json:"messages"`
+}
+
+// sessionInboxAck is attached to inbox events so orchestrators can match a
+// delivery to the message id returned by `send --when`.
+type sessionInboxAck struct {
+	ID       string `json:"id"`
+	When     string `json:"when"`
+	QueuedAt string `json:"queuedAt,omitempty"`
+	Pending  int    `json:"pending"`
+	Error    string `json:"error,omitempty"`
+}
+
+func sessionInboxFile(projectRoot, session string) string {
+	return fmt.Sprintf("/tmp/.lisa-%s-session-%s-inbox.json", projectHash(projectRoot), sessionArtifactID(session))
+}
+
+// parseSessionInboxCondition validates --when: idle, turn-complete, or
+// state=<session state>.
+func parseSessionInboxCondition(raw string) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	switch value {
+	case "idle", "turn-complete":
+		return value, nil
+	}
+	if state, ok := strings.CutPrefix(value, "state="); ok {
+		parsed, err := parseMonitorUntilStateRuntime(state)
+		if err != nil || parsed == "" {
+			return "", fmt.Errorf("invalid --when: %s (unknown state %q)", raw, state)
+		}
+		return "state=" + parsed, nil
+	}
+	return "", fmt.Errorf("invalid --when: %s (expected idle|turn-complete|state=X)", raw)
+}
+
+func loadSessionInbox(projectRoot, session string) (sessionInbox, error) {
+	inbox := sessionInbox{Session: session}
+	raw, err := os.ReadFile(sessionInboxFile(projectRoot, session))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return inbox, nil
+		}
+		return inbox, err
+	}
+	if err := json.Unmarshal(raw, &inbox); err != nil {
+		return sessionInbox{Session: session}, fmt.Errorf("invalid inbox %s: %w", sessionInboxFile(projectRoot, session), err)
+	}
+	inbox.Session = session
+	return inbox, nil
+}
+
+func saveSessionInbox(projectRoot, session string, inbox sessionInbox) error {
+	path := sessionInboxFile(projectRoot, session)
+	if len(inbox.Messages) == 0 && inbox.NextSeq == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	raw, err := json.MarshalIndent(inbox, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, raw)
+}
+
+func withSessionInboxLock(projectRoot, session string, fn func() error) error {
+	lockTimeout := getIntEnv("LISA_EVENT_LOCK_TIMEOUT_MS", defaultEventLockTimeoutMS)
+	return withExclusiveFileLock(sessionInboxFile(projectRoot, session)+".lock", lockTimeout, fn)
+}
+
+func enqueueSessionInboxMessage(projectRoot, session string, message sessionInboxMessage) (sessionInboxMessage, int, error) {
+	pending := 0
+	err := withSessionInboxLock(projectRoot, session, func() error {
+		inbox, err := loadSessionInbox(projectRoot, session)
+		if err != nil {
+			return err
+		}
+		inbox.NextSeq++
+		message.ID = "m" + strconv.Itoa(inbox.NextSeq)
+		message.QueuedAt = nowFn().UTC().Format(time.RFC3339Nano)
+		inbox.Messages = append(inbox.Messages, message)
+		pending = len(inbox.Messages)
+		return saveSessionInbox(projectRoot, session, inbox)
+	})
+	if err != nil {
+		return message, 0, err
+	}
+	appendSessionInboxEvent(projectRoot, session, "inbox_queued", sessionStatus{}, message, pending, "")
+	return message, pending, nil
+}
+
+// sessionInboxConditionHolds reports whether the agent can take input now.
+// turn-complete additionally requires the transcript to show a finished turn.
+func sessionInboxConditionHolds(projectRoot, session, when string, status sessionStatus) bool {
+	switch when {
+	case "idle":
+		return status.SessionState == "waiting_input"
+	case "turn-complete":
+		if status.SessionState != "waiting_input" {
+			return false
+		}
+		if status.Signals.TranscriptTurnComplete {
+			return true
+		}
+		return monitorWaitingTurnCompleteFn(session, projectRoot, status).Ready
+	}
+	if state, ok := strings.CutPrefix(when, "state="); ok {
+		return status.SessionState == state
+	}
+	return false
+}
+
+// deliverSessionInbox submits at most one queued message per call, in order:
+// after a delivery the agent is busy again, so the next message must wait for
+// a fresh status. Callers hold the project runtime env.
+func deliverSessionInbox(projectRoot, session string, status sessionStatus) (*sessionInboxMessage, error) {
+	inboxPath := sessionInboxFile(projectRoot, session)
+	if !fileExists(inboxPath) {
+		return nil, nil
+	}
+	var delivered *sessionInboxMessage
+	pending := 0
+	var deliverErr error
+	err := withSessionInboxLock(projectRoot, session, func() error {
+		inbox, err := loadSessionInbox(projectRoot, session)
+		if err != nil || len(inbox.Messages) == 0 {
+			return err
+		}
+		head := inbox.Messages[0]
+		if !sessionInboxConditionHolds(projectRoot, session, head.When, status) {
+			return nil
+		}
+		_, _, sendErr := deliverSessionSend(projectRoot, session, head.Text, head.Keys, head.Enter)
+		recordAutomaticAudit("inbox delivery", session, head.Text, head.Keys, sendErr)
+		if sendErr != nil {
+			inbox.Messages[0].Attempts++
+			inbox.Messages[0].LastErr = sendErr.Error()
+			deliverErr = sendErr
+			appendSessionInboxEvent(projectRoot, session, "inbox_delivery_failed", status, inbox.Messages[0], len(inbox.Messages), sendErr.Error())
+			return saveSessionInbox(projectRoot, session, inbox)
+		}
+		inbox.Messages = inbox.Messages[1:]
+		pending = len(inbox.Messages)
+		delivered = &head
+		return saveSessionInbox(projectRoot, session, inbox)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if delivered != nil {
+		appendSessionInboxEvent(projectRoot, session, "inbox_delivered", status, *delivered, pending, "")
+	}
+	return delivered, deliverErr
+}
+
+func appendSessionInboxEvent(projectRoot, session, reason string, status sessionStatus, message sessionInboxMessage, pending int, errText string) {
+	event := sessionEvent{
+		At:      nowFn().UTC().Format(time.RFC3339Nano),
+		Type:    "inbox",
+		Session: session,
+		State:   status.SessionState,
+		Status:  status.Status,
+		Reason:  reason,
+		Inbox: &sessionInboxAck{
+			ID:       message.ID,
+			When:     message.When,
+			QueuedAt: message.QueuedAt,
+			Pending:  pending,
+			Error:    errText,
+		},
+	}
+	if err := appendSessionEventFn(projectRoot, session, event); err != nil {
+		fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func stubInboxSession(t *testing.T, root, session string, state *string) (*[]string, *[]sessionEvent) {
+	t.Helper()
+	origHas, origText, origKeys := tmuxHasSessionFn, tmuxSendTextFn, tmuxSendKeysFn
+	origCompute, origAppend, origSleep := computeSessionStatusFn, appendSessionEventFn, monitorSleepFn
+	t.Cleanup(func() {
+		tmuxHasSessionFn, tmuxSendTextFn, tmuxSendKeysFn = origHas, origText, origKeys
+		computeSessionStatusFn, appendSessionEventFn, monitorSleepFn = origCompute, origAppend, origSleep
+		_ = os.Remove(sessionInboxFile(root, session))
+		_ = os.Remove(sessionInboxFile(root, session) + ".lock")
+		_ = os.Remove(sessionStateFile(root, session))
+	})
+	sent := []string{}
+	events := []sessionEvent{}
+	tmuxHasSessionFn = func(string) bool { return true }
+	tmuxSendTextFn = func(_ string, text string, _ bool) error { sent = append(sent, text); return nil }
+	tmuxSendKeysFn = func(_ string, keys []string, _ bool) error {
+		sent = append(sent, "keys:"+strings.Join(keys, " "))
+		return nil
+	}
+	computeSessionStatusFn = func(name, projectRoot, agentHint, modeHint string, full bool, poll int) (sessionStatus, error) {
+		return sessionStatus{Session: name, Agent: "claude", Mode: "interactive", Status: "idle", SessionState: *state}, nil
+	}
+	appendSessionEventFn = func(_ string, _ string, event sessionEvent) error { events = append(events, event); return nil }
+	monitorSleepFn = func(time.Duration) {}
+	return &sent, &events
+}
+
+func TestSessionSendWhenQueuesUntilConditionHolds(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	session := "lisa-inbox-send"
+	state := "in_progress"
+	sent, events := stubInboxSession(t, root, session, &state)
+
+	for _, text := range []string{"first", "second"} {
+		stdout, _ := captureOutput(t, func() {
+			if code := cmdSessionSend([]string{"--session", session, "--project-root", root, "--text", text, "--enter", "--when", "idle", "--json"}); code != 0 {
+				t.Fatalf("expected queued send to succeed, got %d", code)
+			}
+		})
+		if !strings.Contains(stdout, `"queued":true`) || !strings.Contains(stdout, `"delivered":false`) {
+			t.Fatalf("expected message to stay queued while in progress: %s", stdout)
+		}
+	}
+	if len(*sent) != 0 {
+		t.Fatalf("nothing should reach tmux while busy, got %v", *sent)
+	}
+
+	stdout, _ := captureOutput(t, func() { cmdSessionInbox([]string{"list", "--session", session, "--project-root", root, "--json"}) })
+	listed := struct {
+		Messages []sessionInboxMessage `json:"messages"`
+	}{}
+	if err := json.Unmarshal([]byte(stdout), &listed); err != nil || len(listed.Messages) != 2 || listed.Messages[0].ID != "m1" || listed.Messages[1].When != "idle" {
+		t.Fatalf("unexpected inbox list %q (%v)", stdout, err)
+	}
+
+	state = "waiting_input"
+	stdout, _ = captureOutput(t, func() { cmdSessionInbox([]string{"deliver", "--session", session, "--project-root", root, "--json"}) })
+	if !strings.Contains(stdout, `"delivered":"m1"`) || !strings.Contains(stdout, `"pending":1`) || strings.Join(*sent, ",") != "first" {
+		t.Fatalf("expected only the head message delivered, got %s sent=%v", stdout, *sent)
+	}
+	acks := []string{}
+	for _, event := range *events {
+		if event.Type == "inbox" && event.Inbox != nil {
+			acks = append(acks, event.Reason+":"+event.Inbox.ID)
+		}
+	}
+	if strings.Join(acks, ",") != "inbox_queued:m1,inbox_queued:m2,inbox_delivered:m1" {
+		t.Fatalf("unexpected inbox events: %v", acks)
+	}
+
+	stdout, _ = captureOutput(t, func() { cmdSessionInbox([]string{"clear", "--session", session, "--project-root", root, "--json"}) })
+	if !strings.Contains(stdout, `"cleared":["m2"]`) || len(mustLoadInbox(t, root, session).Messages) != 0 {
+		t.Fatalf("expected inbox cleared, got %s", stdout)
+	}
+
+	if _, err := parseSessionInboxCondition("state=bogus"); err == nil {
+		t.Fatalf("expected unknown state to be rejected")
+	}
+	_, _ = captureOutput(t, func() {
+		if code := cmdSessionSend([]string{"--subtree", session, "--text", "x", "--when", "idle", "--json"}); code == 0 {
+			t.Fatalf("expected --when with --subtree to fail")
+		}
+	})
+}
+
+func TestSessionMonitorDeliversInboxInOrder(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	session := "lisa-inbox-monitor"
+	state := "in_progress"
+	sent, _ := stubInboxSession(t, root, session, &state)
+	for _, text := range []string{"one", "two"} {
+		if _, _, err := enqueueSessionInboxMessage(root, session, sessionInboxMessage{Text: text, Enter: true, When: "idle"}); err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+	}
+	if _, _, err := enqueueSessionInboxMessage(root, session, sessionInboxMessage{Keys: []string{"C-c"}, When: "state=completed"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	polls := 0
+	computeSessionStatusFn = func(name, projectRoot, agentHint, modeHint string, full bool, poll int) (sessionStatus, error) {
+		polls++
+		current := "waiting_input"
+		if polls == 1 {
+			current = "in_progress"
+		}
+		return sessionStatus{Session: name, Agent: "claude", Mode: "interactive", Status: "idle", SessionState: current}, nil
+	}
+	audits := stubAuditRecords(t)
+	stdout, _ := captureOutput(t, func() {
+		cmdSessionMonitor([]string{"--session", session, "--project-root", root, "--poll-interval", "1", "--max-polls", "6", "--json"})
+	})
+	if strings.Join(*sent, ",") != "one,two" {
+		t.Fatalf("expected idle messages delivered in order, got %v", *sent)
+	}
+	if strings.Join(*audits, ",") != "inbox delivery lisa-inbox-monitor one,inbox delivery lisa-inbox-monitor two" {
+		t.Fatalf("expected each delivery to be audited, got %v", *audits)
+	}
+	if !strings.Contains(stdout, `"exitReason":"waiting_input"`) || !strings.Contains(stdout, `"polls":4`) {
+		t.Fatalf("expected monitor to keep polling through deliveries, got %s", stdout)
+	}
+	if inbox := mustLoadInbox(t, root, session); len(inbox.Messages) != 1 || inbox.Messages[0].When != "state=completed" {
+		t.Fatalf("expected state-gated message to stay queued, got %+v", inbox.Messages)
+	}
+}
+
+func mustLoadInbox(t *testing.T, root, session string) sessionInbox {
+	t.Helper()
+	inbox, err := loadSessionInbox(root, session)
+	if err != nil {
+		t.Fatalf("load inbox failed: %v", err)
+	}
+	return inbox
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// sessionProtectedLabel marks sessions kill-all and cleanup leave alone
+// unless --force is given.
+const sessionProtectedLabel = "protected"
+
+var (
+	sessionLabelKeyRe   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	sessionLabelValueRe = regexp.MustCompile(`^[^\s,=!]*$`)
+)
+
+// labelRequirement is one comma-separated term of a selector:
+// `key=value`, `key!=value`, `key` (present) or `!key` (absent).
+type labelRequirement struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+type labelSelector []labelRequirement
+
+// parseSpawnLabels parses repeatable spawn `--label key=value` flags.
+func parseSpawnLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	labels := map[string]string{}
+	for _, raw := range values {
+		key, value, ok := strings.Cut(strings.TrimSpace(raw), "=")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !ok || !sessionLabelKeyRe.MatchString(key) {
+			return nil, fmt.Errorf("invalid --label: %s (expected key=value)", raw)
+		}
+		if !sessionLabelValueRe.MatchString(value) {
+			return nil, fmt.Errorf("invalid --label value for %s: %q (no spaces, commas, '=' or '!')", key, value)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// parseLabelSelector parses `-l` values; terms across values are ANDed.
+func parseLabelSelector(values []string) (labelSelector, error) {
+	selector := labelSelector{}
+	for _, value := range values {
+		for _, term := range parseCommaValues(value) {
+			req := labelRequirement{}
+			switch {
+			case strings.Contains(term, "!="):
+				key, val, _ := strings.Cut(term, "!=")
+				req = labelRequirement{Key: strings.TrimSpace(key), Op: "!=", Value: strings.TrimSpace(val)}
+			case strings.Contains(term, "="):
+				key, val, _ := strings.Cut(term, "=")
+				req = labelRequirement{Key: strings.TrimSpace(key), Op: "=", Value: strings.TrimSpace(strings.TrimPrefix(val, "="))}
+			case strings.HasPrefix(term, "!"):
+				req = labelRequirement{Key: strings.TrimSpace(term[1:]), Op: "!"}
+			default:
+				req = labelRequirement{Key: term, Op: "exists"}
+			}
+			if !sessionLabelKeyRe.MatchString(req.Key) {
+				return nil, fmt.Errorf("invalid --label selector: %s (expected key=value, key!=value, key or !key)", term)
+			}
+			selector = append(selector, req)
+		}
+	}
+	return selector, nil
+}
+
+func (s labelSelector) matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.Key]
+		switch req.Op {
+		case "=":
+			if !ok || value != req.Value {
+				return false
+			}
+		case "!=":
+			if ok && value == req.Value {
+				return false
+			}
+		case "!":
+			if ok {
+				return false
+			}
+		default:
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s labelSelector) String() string {
+	parts := make([]string, 0, len(s))
+	for _, req := range s {
+		switch req.Op {
+		case "=", "!=":
+			parts = append(parts, req.Key+req.Op+req.Value)
+		case "!":
+			parts = append(parts, "!"+req.Key)
+		default:
+			parts = append(parts, req.Key)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func sessionLabelsFor(projectRoot, session string) map[string]string {
+	root := resolveSessionProjectRoot(session, projectRoot, false)
+	meta, err := loadSessionMeta(root, session)
+	if err != nil {
+		return nil
+	}
+	return meta.Labels
+}
+
+// filterSessionsByLabels keeps sessions whose metadata matches selector.
+// Sessions without readable metadata only match selectors of pure negations.
+func filterSessionsByLabels(projectRoot string, sessions []string, selector labelSelector) []string {
+	if len(selector) == 0 {
+		return sessions
+	}
+	out := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		if selector.matches(sessionLabelsFor(projectRoot, session)) {
+			out = append(out, session)
+		}
+	}
+	return out
+}
+
+func sessionLabelsProtected(labels map[string]string) bool {
+	return strings.EqualFold(strings.TrimSpace(labels[sessionProtectedLabel]), "true")
+}
+
+// splitProtectedSessions separates sessions labeled protected=true.
+func splitProtectedSessions(projectRoot string, sessions []string) (unprotected, protected []string) {
+	for _, session := range sessions {
+		if sessionLabelsProtected(sessionLabelsFor(projectRoot, session)) {
+			protected = append(protected, session)
+			continue
+		}
+		unprotected = append(unprotected, session)
+	}
+	return unprotected, protected
+}
+
+func formatSessionLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+labels[key])
+	}
+	return strings.Join(parts, ",")
+}
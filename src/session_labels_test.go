@@ -0,0 +1,132 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLabelSelectorParseAndMatch(t *testing.T) {
+	selector, err := parseLabelSelector([]string{"team=infra,task!=TICKET-9", "!draft", "owner"})
+	if err != nil {
+		t.Fatalf("unexpected selector error: %v", err)
+	}
+	if got := selector.String(); got != "team=infra,task!=TICKET-9,!draft,owner" {
+		t.Fatalf("unexpected selector string: %q", got)
+	}
+	cases := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{map[string]string{"team": "infra", "owner": "ann"}, true},
+		{map[string]string{"team": "infra", "owner": "ann", "task": "TICKET-12"}, true},
+		{map[string]string{"team": "infra", "owner": "ann", "task": "TICKET-9"}, false},
+		{map[string]string{"team": "infra", "owner": "ann", "draft": ""}, false},
+		{map[string]string{"team": "web", "owner": "ann"}, false},
+		{map[string]string{"team": "infra"}, false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		if got := selector.matches(tc.labels); got != tc.want {
+			t.Fatalf("matches(%v) = %v, want %v", tc.labels, got, tc.want)
+		}
+	}
+	if sel, _ := parseLabelSelector([]string{"team==infra"}); !sel.matches(map[string]string{"team": "infra"}) {
+		t.Fatalf("expected == to behave like =")
+	}
+	for _, bad := range []string{"=infra", "bad key=x", "!"} {
+		if _, err := parseLabelSelector([]string{bad}); err == nil {
+			t.Fatalf("expected selector %q to fail", bad)
+		}
+	}
+
+	labels, err := parseSpawnLabels([]string{"team=infra", "task=TICKET-12", "protected=true"})
+	if err != nil || len(labels) != 3 || labels["task"] != "TICKET-12" || !sessionLabelsProtected(labels) {
+		t.Fatalf("unexpected spawn labels: %v (%v)", labels, err)
+	}
+	if got := formatSessionLabels(labels); got != "protected=true,task=TICKET-12,team=infra" {
+		t.Fatalf("unexpected formatted labels: %q", got)
+	}
+	for _, bad := range []string{"team", "team=a,b", "team=a b", "=x"} {
+		if _, err := parseSpawnLabels([]string{bad}); err == nil {
+			t.Fatalf("expected spawn label %q to fail", bad)
+		}
+	}
+}
+
+func TestKillAllAndCleanupSkipProtectedSessions(t *testing.T) {
+	origList, origKill := tmuxListSessionsFn, tmuxKillSessionFn
+	origCandidates, origProbe := cleanupSocketCandidatesFn, probeTmuxSocketFn
+	origKillServer, origRemove, origSocketSessions := killTmuxSocketServerFn, removeSocketPathFn, listTmuxSocketSessionsFn
+	root := canonicalProjectRoot(t.TempDir())
+	sessions := []string{"lisa-labels-keep", "lisa-labels-infra", "lisa-labels-web"}
+	t.Cleanup(func() {
+		tmuxListSessionsFn, tmuxKillSessionFn = origList, origKill
+		cleanupSocketCandidatesFn, probeTmuxSocketFn = origCandidates, origProbe
+		killTmuxSocketServerFn, removeSocketPathFn, listTmuxSocketSessionsFn = origKillServer, origRemove, origSocketSessions
+		for _, session := range sessions {
+			_ = os.Remove(sessionMetaFile(root, session))
+		}
+	})
+	labels := []map[string]string{
+		{"team": "infra", "protected": "true"},
+		{"team": "infra"},
+		{"team": "web"},
+	}
+	for i, session := range sessions {
+		meta := sessionMeta{Session: session, Agent: "claude", Mode: "interactive", ProjectRoot: root, Labels: labels[i]}
+		if err := saveSessionMeta(root, session, meta); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+	}
+
+	tmuxListSessionsFn = func(bool, string) ([]string, error) { return sessions, nil }
+	killed := []string{}
+	tmuxKillSessionFn = func(session string) error { killed = append(killed, session); return nil }
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionKillAll([]string{"--project-root", root, "-l", "team=infra", "--json"}); code != 0 {
+			t.Fatalf("expected kill-all success, got %d", code)
+		}
+	})
+	payload := map[string]any{}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("invalid kill-all JSON: %v (%q)", err, stdout)
+	}
+	if !slices.Equal(killed, []string{"lisa-labels-infra"}) || !strings.Contains(stdout, `"protected":["lisa-labels-keep"]`) {
+		t.Fatalf("expected protected session to be skipped, killed=%v out=%s", killed, stdout)
+	}
+
+	if err := saveSessionMeta(root, sessions[0], sessionMeta{Session: sessions[0], ProjectRoot: root, Labels: labels[0]}); err != nil {
+		t.Fatalf("restore meta failed: %v", err)
+	}
+	cleanupSocketCandidatesFn = func(bool) ([]string, error) { return []string{"guarded.sock"}, nil }
+	probeTmuxSocketFn = func(string) (tmuxSocketProbe, error) {
+		return tmuxSocketProbe{Reachable: true, Sessions: 1, Clients: 0}, nil
+	}
+	listTmuxSocketSessionsFn = func(string) ([]string, error) { return []string{sessions[0]}, nil }
+	serverKills := 0
+	killTmuxSocketServerFn = func(string) error { serverKills++; return nil }
+	removeSocketPathFn = func(string) error { return nil }
+	stdout, _ = captureOutput(t, func() {
+		if code := cmdCleanup([]string{"--json"}); code != 0 {
+			t.Fatalf("expected cleanup success, got %d", code)
+		}
+	})
+	summary := cleanupSummary{}
+	if err := json.Unmarshal([]byte(stdout), &summary); err != nil {
+		t.Fatalf("invalid cleanup JSON: %v (%q)", err, stdout)
+	}
+	if serverKills != 0 || summary.KeptProtected != 1 || !slices.Equal(summary.Protected, []string{sessions[0]}) {
+		t.Fatalf("expected protected server to be kept, kills=%d summary=%+v", serverKills, summary)
+	}
+	_, _ = captureOutput(t, func() {
+		if code := cmdCleanup([]string{"--force", "--json"}); code != 0 {
+			t.Fatalf("expected forced cleanup success, got %d", code)
+		}
+	})
+	if serverKills != 1 {
+		t.Fatalf("expected --force to kill the protected server, kills=%d", serverKills)
+	}
+}
@@ -0,0 +1,465 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cgroupRootEnv      = "LISA_CGROUP_ROOT"
+	limitGraceEnv      = "LISA_LIMIT_GRACE_SECONDS"
+	limitCPUOverPolls  = 3
+	limitBackendCgroup = "cgroup"
+	limitBackendRlimit = "prlimit"
+	limitBackendNone   = "monitor"
+)
+
+var (
+	cgroupFSRoot          = "/sys/fs/cgroup"
+	readSelfCgroupFn      = func() ([]byte, error) { return os.ReadFile("/proc/self/cgroup") }
+	checkSessionLimitsFn  = checkSessionLimits
+	enforceSessionLimitFn = enforceSessionLimit
+
+	sessionLimitsConfirmTimeout = 3 * time.Second
+)
+
+// sessionLimits is persisted in session meta so monitor can enforce it.
+// Backend records how memory/CPU caps were applied at spawn:
+// cgroup (kernel-enforced), prlimit (RLIMIT_DATA backstop), or monitor
+// (sampling only).
+type sessionLimits struct {
+	MaxRuntimeSeconds  int    `json:"maxRuntimeSeconds,omitempty"`
+	IdleTimeoutSeconds int    `json:"idleTimeoutSeconds,omitempty"`
+	MemoryMaxBytes     int64  `json:"memoryMaxBytes,omitempty"`
+	CPUQuotaPercent    int    `json:"cpuQuotaPercent,omitempty"`
+	Backend            string `json:"backend,omitempty"`
+	CgroupPath         string `json:"cgroupPath,omitempty"`
+}
+
+func (l *sessionLimits) empty() bool {
+	return l == nil || (l.MaxRuntimeSeconds <= 0 && l.IdleTimeoutSeconds <= 0 && l.MemoryMaxBytes <= 0 && l.CPUQuotaPercent <= 0)
+}
+
+func (l *sessionLimits) hasResourceCaps() bool {
+	return l != nil && (l.MemoryMaxBytes > 0 || l.CPUQuotaPercent > 0)
+}
+
+// parseMemoryLimit accepts bytes or a K/M/G/T suffix (binary units).
+func parseMemoryLimit(raw string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(raw))
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "IB"), "B")
+	multiplier := int64(1)
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid --memory-max: %s (expected bytes or K/M/G/T suffix)", raw)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// parseCPUQuota accepts a percentage of one CPU ("200%") or a core count ("1.5").
+func parseCPUQuota(raw string) (int, error) {
+	value := strings.TrimSpace(raw)
+	percent := strings.HasSuffix(value, "%")
+	n, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid --cpu-quota: %s (expected N%% or cores)", raw)
+	}
+	if !percent {
+		n *= 100
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("invalid --cpu-quota: %s (minimum 1%%)", raw)
+	}
+	return int(math.Round(n)), nil
+}
+
+// cgroupParentDir picks where per-session cgroups are created: LISA_CGROUP_ROOT
+// when set, otherwise the parent of the caller's own cgroup (the caller's
+// group usually holds processes and cannot take children with controllers).
+func cgroupParentDir() (string, error) {
+	if root := strings.TrimSpace(os.Getenv(cgroupRootEnv)); root != "" {
+		return root, nil
+	}
+	if _, err := os.Stat(filepath.Join(cgroupFSRoot, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 not mounted at %s", cgroupFSRoot)
+	}
+	raw, err := readSelfCgroupFn()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		if rel, ok := strings.CutPrefix(strings.TrimSpace(line), "0::"); ok {
+			return filepath.Dir(filepath.Join(cgroupFSRoot, rel)), nil
+		}
+	}
+	return "", fmt.Errorf("no cgroup v2 entry in /proc/self/cgroup")
+}
+
+// cgroupSupportsLimits reports whether parent delegates the controllers the
+// limits need.
+func cgroupSupportsLimits(parent string, limits *sessionLimits) error {
+	raw, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return err
+	}
+	enabled := map[string]bool{}
+	for _, controller := range strings.Fields(string(raw)) {
+		enabled[controller] = true
+	}
+	if limits.MemoryMaxBytes > 0 && !enabled["memory"] {
+		return fmt.Errorf("memory controller not delegated to %s", parent)
+	}
+	if limits.CPUQuotaPercent > 0 && !enabled["cpu"] {
+		return fmt.Errorf("cpu controller not delegated to %s", parent)
+	}
+	return nil
+}
+
+func sessionCgroupName(projectRoot, session string) string {
+	return sessionArtifactID(session) + "." + projectHash(projectRoot)
+}
+
+// planSessionLimits chooses the backend without touching the system (used by
+// --dry-run and as the first step of applySessionLimits).
+func planSessionLimits(projectRoot, session string, limits *sessionLimits) (string, string, []string) {
+	if !limits.hasResourceCaps() {
+		return "", "", nil
+	}
+	warnings := []string{}
+	parent, err := cgroupParentDir()
+	if err == nil {
+		err = cgroupSupportsLimits(parent, limits)
+	}
+	if err == nil {
+		return limitBackendCgroup, filepath.Join(parent, sessionCgroupName(projectRoot, session)), nil
+	}
+	warnings = append(warnings, fmt.Sprintf("cgroup v2 unavailable: %v", err))
+	if limits.MemoryMaxBytes > 0 {
+		if _, lookErr := lookPathFn("prlimit"); lookErr == nil {
+			if limits.CPUQuotaPercent > 0 {
+				warnings = append(warnings, "cpu quota is enforced by monitor sampling only")
+			}
+			return limitBackendRlimit, "", warnings
+		}
+		warnings = append(warnings, "prlimit not found; memory cap is enforced by monitor sampling only")
+	}
+	return limitBackendNone, "", warnings
+}
+
+// applySessionLimits creates the session cgroup (or prepares the prlimit
+// fallback) and returns a shell prefix that moves the pane shell into it
+// before the agent starts, so every child inherits the caps.
+func applySessionLimits(projectRoot, session string, limits *sessionLimits) (string, []string, error) {
+	backend, cgroupPath, warnings := planSessionLimits(projectRoot, session, limits)
+	limits.Backend = backend
+	switch backend {
+	case limitBackendCgroup:
+		if err := os.MkdirAll(cgroupPath, 0o755); err != nil {
+			return "", warnings, fmt.Errorf("failed creating cgroup %s: %w", cgroupPath, err)
+		}
+		if limits.MemoryMaxBytes > 0 {
+			if err := os.WriteFile(filepath.Join(cgroupPath, "memory.max"), []byte(strconv.FormatInt(limits.MemoryMaxBytes, 10)), 0o644); err != nil {
+				_ = os.Remove(cgroupPath)
+				return "", warnings, fmt.Errorf("failed setting memory.max: %w", err)
+			}
+			// Keep the cap real: without this the group can spill into swap.
+			_ = os.WriteFile(filepath.Join(cgroupPath, "memory.swap.max"), []byte("0"), 0o644)
+		}
+		if limits.CPUQuotaPercent > 0 {
+			quota := fmt.Sprintf("%d 100000", limits.CPUQuotaPercent*1000)
+			if err := os.WriteFile(filepath.Join(cgroupPath, "cpu.max"), []byte(quota), 0o644); err != nil {
+				_ = os.Remove(cgroupPath)
+				return "", warnings, fmt.Errorf("failed setting cpu.max: %w", err)
+			}
+		}
+		limits.CgroupPath = cgroupPath
+		return sessionLimitsShellPrefix(projectRoot, session, limits), warnings, nil
+	case limitBackendRlimit:
+		return sessionLimitsShellPrefix(projectRoot, session, limits), warnings, nil
+	}
+	return "", warnings, nil
+}
+
+// sessionLimitsShellPrefix joins the pane shell to the planned backend,
+// falling back cgroup -> prlimit -> monitor sampling, and records the backend
+// that actually took in sessionLimitsFile for confirmSessionLimits.
+func sessionLimitsShellPrefix(projectRoot, session string, limits *sessionLimits) string {
+	marker := shellQuote(sessionLimitsFile(projectRoot, session))
+	record := func(backend string) string {
+		return fmt.Sprintf("printf '%s\\n' > %s", backend, marker)
+	}
+	prlimit := ""
+	if limits.MemoryMaxBytes > 0 {
+		if _, err := lookPathFn("prlimit"); err == nil {
+			prlimit = fmt.Sprintf("prlimit --pid \"$BASHPID\" --data=%d:%d >/dev/null 2>&1", limits.MemoryMaxBytes, limits.MemoryMaxBytes)
+		}
+	}
+	var b strings.Builder
+	if limits.Backend == limitBackendCgroup {
+		fmt.Fprintf(&b, "if echo \"$BASHPID\" > %s 2>/dev/null; then %s; ", shellQuote(filepath.Join(limits.CgroupPath, "cgroup.procs")), record(limitBackendCgroup))
+		if prlimit != "" {
+			fmt.Fprintf(&b, "elif %s; then %s; ", prlimit, record(limitBackendRlimit))
+		}
+	} else if prlimit != "" {
+		fmt.Fprintf(&b, "if %s; then %s; ", prlimit, record(limitBackendRlimit))
+	} else {
+		return record(limitBackendNone) + ";"
+	}
+	fmt.Fprintf(&b, "else %s; fi;", record(limitBackendNone))
+	return b.String()
+}
+
+// confirmSessionLimits waits for the pane shell to report which backend it
+// applied and downgrades limits to match, so a failed cgroup join or prlimit
+// call is a recorded fallback instead of a silent no-op. The monitor keeps
+// sampling RSS/CPU whatever the outcome.
+func confirmSessionLimits(projectRoot, session string, limits *sessionLimits) []string {
+	if limits == nil || (limits.Backend != limitBackendCgroup && limits.Backend != limitBackendRlimit) {
+		return nil
+	}
+	planned := limits.Backend
+	applied := ""
+	deadline := time.Now().Add(sessionLimitsConfirmTimeout)
+	for {
+		if raw, err := os.ReadFile(sessionLimitsFile(projectRoot, session)); err == nil && strings.TrimSpace(string(raw)) != "" {
+			applied = strings.TrimSpace(string(raw))
+			break
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	var warnings []string
+	switch applied {
+	case planned:
+		return nil
+	case "":
+		warnings = append(warnings, fmt.Sprintf("%s limits not confirmed within %s; enforcing by monitor sampling", planned, sessionLimitsConfirmTimeout))
+		applied = limitBackendNone
+	default:
+		warnings = append(warnings, fmt.Sprintf("%s limits failed to apply; fell back to %s", planned, applied))
+	}
+	limits.Backend = applied
+	if applied != limitBackendCgroup {
+		removeSessionCgroup(limits)
+		limits.CgroupPath = ""
+	}
+	return warnings
+}
+
+func removeSessionCgroup(limits *sessionLimits) {
+	if limits == nil || limits.CgroupPath == "" {
+		return
+	}
+	_ = os.Remove(limits.CgroupPath)
+}
+
+// sessionLimitTracker carries per-monitor sampling state across polls.
+type sessionLimitTracker struct {
+	cpuOverPolls int
+}
+
+// checkSessionLimits returns the exceeded limit kind (max_runtime,
+// idle_timeout, memory_max, cpu_quota) and a human-readable detail.
+func checkSessionLimits(projectRoot, session string, meta sessionMeta, status sessionStatus, tracker *sessionLimitTracker) (string, string) {
+	limits := meta.Limits
+	if limits.empty() {
+		return "", ""
+	}
+	switch status.SessionState {
+	case "completed", "crashed", "not_found":
+		return "", ""
+	}
+	if limits.MaxRuntimeSeconds > 0 {
+		if created, err := time.Parse(time.RFC3339, meta.CreatedAt); err == nil {
+			runtime := nowFn().Sub(created)
+			if runtime >= time.Duration(limits.MaxRuntimeSeconds)*time.Second {
+				return "max_runtime", fmt.Sprintf("runtime %s >= %ds", runtime.Round(time.Second), limits.MaxRuntimeSeconds)
+			}
+		}
+	}
+	if limits.IdleTimeoutSeconds > 0 && status.OutputAgeSeconds >= limits.IdleTimeoutSeconds {
+		return "idle_timeout", fmt.Sprintf("no output for %ds >= %ds", status.OutputAgeSeconds, limits.IdleTimeoutSeconds)
+	}
+	if limits.Backend == limitBackendCgroup && limits.MemoryMaxBytes > 0 && cgroupOOMKills(limits.CgroupPath) > 0 {
+		return "memory_max", fmt.Sprintf("oom kill in cgroup (memory.max=%d)", limits.MemoryMaxBytes)
+	}
+	// Sampling stays on for every backend: it is the backstop when the
+	// kernel caps were not applied as planned.
+	if !limits.hasResourceCaps() {
+		return "", ""
+	}
+	cpu, rssBytes, err := sessionProcessTreeUsage(session)
+	if err != nil {
+		return "", ""
+	}
+	if limits.MemoryMaxBytes > 0 && rssBytes > limits.MemoryMaxBytes {
+		return "memory_max", fmt.Sprintf("rss %d > %d bytes", rssBytes, limits.MemoryMaxBytes)
+	}
+	if limits.CPUQuotaPercent > 0 {
+		if cpu > float64(limits.CPUQuotaPercent) {
+			tracker.cpuOverPolls++
+		} else {
+			tracker.cpuOverPolls = 0
+		}
+		if tracker.cpuOverPolls >= limitCPUOverPolls {
+			return "cpu_quota", fmt.Sprintf("cpu %.0f%% > %d%% for %d polls", cpu, limits.CPUQuotaPercent, tracker.cpuOverPolls)
+		}
+	}
+	return "", ""
+}
+
+func cgroupOOMKills(cgroupPath string) int {
+	raw, err := os.ReadFile(filepath.Join(cgroupPath, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			n, _ := strconv.Atoi(fields[1])
+			return n
+		}
+	}
+	return 0
+}
+
+// sessionProcessTreeUsage sums %CPU and RSS over the pane process tree.
+func sessionProcessTreeUsage(session string) (float64, int64, error) {
+	raw, err := tmuxDisplayFn(session, "#{pane_pid}")
+	if err != nil {
+		return 0, 0, err
+	}
+	panePID, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, 0, err
+	}
+	procs, err := listProcessesCachedFn()
+	if err != nil {
+		return 0, 0, err
+	}
+	children := map[int][]processInfo{}
+	cpu := 0.0
+	rssKB := int64(0)
+	for _, p := range procs {
+		children[p.PPID] = append(children[p.PPID], p)
+		if p.PID == panePID {
+			cpu += p.CPU
+			rssKB += p.RSSKB
+		}
+	}
+	queue := []int{panePID}
+	seen := map[int]bool{panePID: true}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, child := range children[cur] {
+			if seen[child.PID] {
+				continue
+			}
+			seen[child.PID] = true
+			cpu += child.CPU
+			rssKB += child.RSSKB
+			queue = append(queue, child.PID)
+		}
+	}
+	return cpu, rssKB * 1024, nil
+}
+
+// enforceSessionLimit interrupts the session, waits LISA_LIMIT_GRACE_SECONDS,
+// then kills whatever is left. It reports whether a hard kill was needed.
+func enforceSessionLimit(projectRoot, session string, limits *sessionLimits, kind, detail string) bool {
+	if err := appendLifecycleEvent(projectRoot, session, "lifecycle", "limit_exceeded", "active", "limit_"+kind); err != nil {
+		fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "limit exceeded: %s: %s (%s)\n", session, kind, detail)
+	grace := time.Duration(getIntEnv(limitGraceEnv, int(defaultSessionKillGrace/time.Second))) * time.Second
+	if grace > 0 {
+		recordAutomaticAudit("limit interrupt", session, "", []string{"C-c"}, nil)
+	}
+	stopped := interruptSessionsWithGrace(projectRoot, []string{session}, grace)
+	killed := false
+	if len(stopped) > 0 {
+		if err := recordSessionHistory(projectRoot, session, "limit_"+kind, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "history warning: %s: %v\n", session, err)
+		}
+	} else if tmuxHasSessionFn(session) {
+		err := killSessionWithHistory(projectRoot, session, "limit_"+kind, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "limit kill warning: %s: %v\n", session, err)
+		}
+		recordAutomaticAudit("limit kill", session, "", nil, err)
+		killed = true
+	}
+	if limits != nil && limits.CgroupPath != "" {
+		// Reap anything that escaped the pane (cgroup.kill needs Linux 5.14+).
+		_ = os.WriteFile(filepath.Join(limits.CgroupPath, "cgroup.kill"), []byte("1"), 0o644)
+		removeSessionCgroup(limits)
+	}
+	reason := "limit_" + kind + "_interrupted"
+	if killed {
+		reason = "limit_" + kind + "_killed"
+	}
+	if err := appendLifecycleEvent(projectRoot, session, "lifecycle", "limit_exceeded", "idle", reason); err != nil {
+		fmt.Fprintf(os.Stderr, "observability warning: %v\n", err)
+	}
+	return killed
+}
+
+// monitorEnforceSessionLimits checks the limits recorded in session meta and,
+// when one is exceeded, stops the session and rewrites status to
+// limit_exceeded. It returns the monitor exit reason ("" when within limits).
+func monitorEnforceSessionLimits(projectRoot, session string, meta sessionMeta, status *sessionStatus, tracker *sessionLimitTracker) string {
+	kind, detail := checkSessionLimitsFn(projectRoot, session, meta, *status, tracker)
+	if kind == "" {
+		return ""
+	}
+	enforceSessionLimitFn(projectRoot, session, meta.Limits, kind, detail)
+	return markSessionLimitExceeded(status, kind)
+}
+
+// monitorStartSessionLimit is monitorEnforceSessionLimits for fan-in: the
+// interrupt, grace wait and kill run in the background on wg, so one runaway
+// session does not stall polling of the others. Callers wait on wg before
+// exiting.
+func monitorStartSessionLimit(projectRoot, session string, meta sessionMeta, status *sessionStatus, tracker *sessionLimitTracker, wg *sync.WaitGroup) string {
+	kind, detail := checkSessionLimitsFn(projectRoot, session, meta, *status, tracker)
+	if kind == "" {
+		return ""
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		enforceSessionLimitFn(projectRoot, session, meta.Limits, kind, detail)
+	}()
+	return markSessionLimitExceeded(status, kind)
+}
+
+func markSessionLimitExceeded(status *sessionStatus, kind string) string {
+	status.SessionState = "limit_exceeded"
+	status.Status = "idle"
+	status.ClassificationReason = "limit_" + kind
+	return "limit_" + kind
+}
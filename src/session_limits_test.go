@@ -0,0 +1,305 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSessionLimitValues(t *testing.T) {
+	for raw, want := range map[string]int64{"4G": 4 << 30, "512M": 512 << 20, "1.5GiB": 3 << 29, "2048": 2048} {
+		got, err := parseMemoryLimit(raw)
+		if err != nil || got != want {
+			t.Fatalf("parseMemoryLimit(%q) = %d, %v; want %d", raw, got, err, want)
+		}
+	}
+	if _, err := parseMemoryLimit("lots"); err == nil {
+		t.Fatalf("expected invalid memory error")
+	}
+	for raw, want := range map[string]int{"200%": 200, "1.5": 150, "50%": 50} {
+		got, err := parseCPUQuota(raw)
+		if err != nil || got != want {
+			t.Fatalf("parseCPUQuota(%q) = %d, %v; want %d", raw, got, err, want)
+		}
+	}
+	if _, err := parseCPUQuota("0"); err == nil {
+		t.Fatalf("expected invalid cpu quota error")
+	}
+}
+
+func TestApplySessionLimitsPrefersCgroupAndFallsBack(t *testing.T) {
+	projectRoot := t.TempDir()
+	parent := t.TempDir()
+	t.Setenv(cgroupRootEnv, parent)
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("cpu io memory pids\n"), 0o644); err != nil {
+		t.Fatalf("write subtree_control failed: %v", err)
+	}
+
+	limits := &sessionLimits{MemoryMaxBytes: 4 << 30, CPUQuotaPercent: 200}
+	prefix, warnings, err := applySessionLimits(projectRoot, "lisa-limits", limits)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("apply cgroup limits failed: %v %v", err, warnings)
+	}
+	if limits.Backend != limitBackendCgroup || !strings.HasPrefix(limits.CgroupPath, parent) || !strings.Contains(prefix, "cgroup.procs") {
+		t.Fatalf("unexpected cgroup plan: %+v prefix=%q", limits, prefix)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(limits.CgroupPath, "cpu.max")); string(raw) != "200000 100000" {
+		t.Fatalf("unexpected cpu.max %q", raw)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(limits.CgroupPath, "memory.max")); string(raw) != fmt.Sprint(4<<30) {
+		t.Fatalf("unexpected memory.max %q", raw)
+	}
+
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("pids\n"), 0o644); err != nil {
+		t.Fatalf("write subtree_control failed: %v", err)
+	}
+	origLookPath := lookPathFn
+	t.Cleanup(func() { lookPathFn = origLookPath })
+	lookPathFn = func(string) (string, error) { return "/usr/bin/prlimit", nil }
+	limits = &sessionLimits{MemoryMaxBytes: 1 << 30, CPUQuotaPercent: 100}
+	prefix, warnings, err = applySessionLimits(projectRoot, "lisa-limits-2", limits)
+	if err != nil || limits.Backend != limitBackendRlimit || !strings.Contains(prefix, "--data=1073741824:1073741824") {
+		t.Fatalf("unexpected prlimit fallback: %+v prefix=%q err=%v", limits, prefix, err)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "memory controller") {
+		t.Fatalf("expected cgroup and cpu sampling warnings, got %v", warnings)
+	}
+
+	lookPathFn = func(string) (string, error) { return "", fmt.Errorf("not found") }
+	limits = &sessionLimits{MemoryMaxBytes: 1 << 30}
+	if prefix, _, _ := applySessionLimits(projectRoot, "lisa-limits-3", limits); prefix != "" || limits.Backend != limitBackendNone {
+		t.Fatalf("expected monitor-only backend, got %+v prefix=%q", limits, prefix)
+	}
+}
+
+func TestSessionLimitsPrefixRecordsFallbackWhenCgroupJoinFails(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	projectRoot := t.TempDir()
+	session := "lisa-limits-fallback"
+	t.Cleanup(func() { _ = os.Remove(sessionLimitsFile(projectRoot, session)) })
+	origLookPath, origTimeout := lookPathFn, sessionLimitsConfirmTimeout
+	t.Cleanup(func() { lookPathFn, sessionLimitsConfirmTimeout = origLookPath, origTimeout })
+	lookPathFn = func(string) (string, error) { return "", fmt.Errorf("not found") }
+	sessionLimitsConfirmTimeout = 200 * time.Millisecond
+
+	// A directory named cgroup.procs makes the join fail like EACCES would.
+	cgroupPath := filepath.Join(t.TempDir(), "lisa-limits-fallback.x")
+	if err := os.MkdirAll(filepath.Join(cgroupPath, "cgroup.procs"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	limits := &sessionLimits{MemoryMaxBytes: 1 << 30, Backend: limitBackendCgroup, CgroupPath: cgroupPath}
+	prefix := sessionLimitsShellPrefix(projectRoot, session, limits)
+	if strings.Contains(prefix, "|| true") {
+		t.Fatalf("limit failures must not be swallowed: %q", prefix)
+	}
+	if out, err := exec.Command("bash", "-c", prefix+" echo started").CombinedOutput(); err != nil || !strings.Contains(string(out), "started") {
+		t.Fatalf("prefix failed: %v (%s)", err, out)
+	}
+
+	warnings := confirmSessionLimits(projectRoot, session, limits)
+	if limits.Backend != limitBackendNone || limits.CgroupPath != "" {
+		t.Fatalf("expected downgrade to monitor sampling, got %+v", limits)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "cgroup limits failed to apply; fell back to monitor") {
+		t.Fatalf("expected recorded fallback warning, got %v", warnings)
+	}
+
+	_ = os.Remove(sessionLimitsFile(projectRoot, session))
+	limits = &sessionLimits{MemoryMaxBytes: 1 << 30, Backend: limitBackendRlimit}
+	if warnings := confirmSessionLimits(projectRoot, session, limits); len(warnings) != 1 || limits.Backend != limitBackendNone || !strings.Contains(warnings[0], "not confirmed") {
+		t.Fatalf("expected unconfirmed backend to fall back, got %v %+v", warnings, limits)
+	}
+}
+
+func TestCheckSessionLimitsSamplesProcessTree(t *testing.T) {
+	origDisplay := tmuxDisplayFn
+	origList := listProcessesCachedFn
+	origNow := nowFn
+	t.Cleanup(func() {
+		tmuxDisplayFn = origDisplay
+		listProcessesCachedFn = origList
+		nowFn = origNow
+	})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return now }
+	tmuxDisplayFn = func(string, string) (string, error) { return "100", nil }
+	cpu := 150.0
+	listProcessesCachedFn = func() ([]processInfo, error) {
+		return []processInfo{
+			{PID: 100, PPID: 1, CPU: 0, RSSKB: 1024, Command: "bash"},
+			{PID: 101, PPID: 100, CPU: cpu, RSSKB: 2048, Command: "node claude"},
+			{PID: 200, PPID: 1, CPU: 99, RSSKB: 1 << 30, Command: "unrelated"},
+		}, nil
+	}
+
+	meta := sessionMeta{
+		CreatedAt: now.Add(-10 * time.Minute).Format(time.RFC3339),
+		Limits:    &sessionLimits{MaxRuntimeSeconds: 3600, IdleTimeoutSeconds: 300, CPUQuotaPercent: 100, MemoryMaxBytes: 4 << 20, Backend: limitBackendRlimit},
+	}
+	status := sessionStatus{SessionState: "in_progress", OutputAgeSeconds: 10}
+	tracker := &sessionLimitTracker{}
+	for poll := 1; poll < limitCPUOverPolls; poll++ {
+		if kind, _ := checkSessionLimits("/tmp/p", "lisa-x", meta, status, tracker); kind != "" {
+			t.Fatalf("poll %d: cpu quota must need %d consecutive polls, got %q", poll, limitCPUOverPolls, kind)
+		}
+	}
+	if kind, detail := checkSessionLimits("/tmp/p", "lisa-x", meta, status, tracker); kind != "cpu_quota" {
+		t.Fatalf("expected cpu_quota, got %q (%s)", kind, detail)
+	}
+
+	meta.Limits.MemoryMaxBytes = 2 << 20
+	if kind, _ := checkSessionLimits("/tmp/p", "lisa-x", meta, status, &sessionLimitTracker{}); kind != "memory_max" {
+		t.Fatalf("expected memory_max from pane tree rss, got %q", kind)
+	}
+	meta.Limits.Backend, meta.Limits.CgroupPath = limitBackendCgroup, t.TempDir()
+	if kind, _ := checkSessionLimits("/tmp/p", "lisa-x", meta, status, &sessionLimitTracker{}); kind != "memory_max" {
+		t.Fatalf("expected rss sampling to stay on for the cgroup backend, got %q", kind)
+	}
+	meta.Limits.Backend, meta.Limits.CgroupPath = limitBackendRlimit, ""
+	meta.Limits.MaxRuntimeSeconds = 60
+	if kind, _ := checkSessionLimits("/tmp/p", "lisa-x", meta, status, &sessionLimitTracker{}); kind != "max_runtime" {
+		t.Fatalf("expected max_runtime, got %q", kind)
+	}
+	status.SessionState = "completed"
+	if kind, _ := checkSessionLimits("/tmp/p", "lisa-x", meta, status, &sessionLimitTracker{}); kind != "" {
+		t.Fatalf("finished sessions are never limited, got %q", kind)
+	}
+}
+
+func TestComputeSessionStatusFeedsIdleTimeoutFromPaneOutput(t *testing.T) {
+	origHas, origDisplay, origShowEnv, origDetect, origCapture, origNow := tmuxHasSessionFn, tmuxDisplayFn, tmuxShowEnvironmentFn, detectAgentProcessFn, tmuxCapturePaneFn, nowFn
+	t.Cleanup(func() {
+		tmuxHasSessionFn, tmuxDisplayFn, tmuxShowEnvironmentFn, detectAgentProcessFn, tmuxCapturePaneFn, nowFn = origHas, origDisplay, origShowEnv, origDetect, origCapture, origNow
+	})
+	tmuxHasSessionFn = func(session string) bool { return true }
+	tmuxDisplayFn = func(session, format string) (string, error) {
+		if format == "#{pane_dead}\t#{pane_dead_status}\t#{pane_current_command}\t#{pane_pid}" {
+			return "0\t0\tnode\t123", nil
+		}
+		return "", nil
+	}
+	tmuxShowEnvironmentFn = func(session, key string) (string, error) { return "", fmt.Errorf("missing") }
+	detectAgentProcessFn = func(panePID int, agent string) (int, float64, error) { return 987, 5, nil }
+	pane := "working on step 1"
+	tmuxCapturePaneFn = func(session string, lines int) (string, error) { return pane, nil }
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return now }
+
+	projectRoot := t.TempDir()
+	session := "lisa-limit-idle-status"
+	meta := sessionMeta{
+		Session:     session,
+		Agent:       "claude",
+		Mode:        "exec",
+		ProjectRoot: projectRoot,
+		CreatedAt:   now.Format(time.RFC3339),
+		Limits:      &sessionLimits{IdleTimeoutSeconds: 300, Backend: limitBackendNone},
+	}
+	if err := saveSessionMeta(projectRoot, session, meta); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Remove(sessionMetaFile(projectRoot, session))
+		_ = os.Remove(sessionStateFile(projectRoot, session))
+		_ = os.Remove(sessionEventsFile(projectRoot, session))
+		_ = os.Remove(sessionEventCountFile(sessionEventsFile(projectRoot, session)))
+	})
+
+	poll := func(wantAge int) sessionStatus {
+		t.Helper()
+		status, err := computeSessionStatus(session, projectRoot, "auto", "auto", false, 0)
+		if err != nil {
+			t.Fatalf("status failed: %v", err)
+		}
+		if status.OutputAgeSeconds != wantAge {
+			t.Fatalf("expected output age %d, got %d", wantAge, status.OutputAgeSeconds)
+		}
+		return status
+	}
+	status := poll(0)
+	if kind, _ := checkSessionLimits(projectRoot, session, meta, status, &sessionLimitTracker{}); kind != "" {
+		t.Fatalf("fresh output must not trip idle timeout, got %q", kind)
+	}
+	now = now.Add(301 * time.Second)
+	status = poll(301)
+	if kind, _ := checkSessionLimits(projectRoot, session, meta, status, &sessionLimitTracker{}); kind != "idle_timeout" {
+		t.Fatalf("expected idle_timeout after 301s without output, got %q", kind)
+	}
+	pane = "working on step 2"
+	now = now.Add(10 * time.Second)
+	poll(0)
+}
+
+func TestCmdSessionMonitorStopsSessionOnLimitExceeded(t *testing.T) {
+	projectRoot := t.TempDir()
+	session := "lisa-limit-monitor"
+	t.Cleanup(func() { _ = os.Remove(sessionMetaFile(projectRoot, session)) })
+	if err := saveSessionMeta(projectRoot, session, sessionMeta{
+		Session:     session,
+		Agent:       "codex",
+		Mode:        "exec",
+		ProjectRoot: projectRoot,
+		CreatedAt:   time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+		Limits:      &sessionLimits{MaxRuntimeSeconds: 3600},
+	}); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+
+	origCompute := computeSessionStatusFn
+	origAppend := appendSessionEventFn
+	origHas := tmuxHasSessionFn
+	origKeys := tmuxSendKeysFn
+	origKill := tmuxKillSessionFn
+	t.Cleanup(func() {
+		computeSessionStatusFn = origCompute
+		appendSessionEventFn = origAppend
+		tmuxHasSessionFn = origHas
+		tmuxSendKeysFn = origKeys
+		tmuxKillSessionFn = origKill
+	})
+	computeSessionStatusFn = func(session, projectRoot, agentHint, modeHint string, full bool, pollCount int) (sessionStatus, error) {
+		return sessionStatus{Session: session, Agent: "codex", Mode: "exec", Status: "active", SessionState: "in_progress"}, nil
+	}
+	alive := true
+	tmuxHasSessionFn = func(string) bool { return alive }
+	tmuxSendKeysFn = func(_ string, keys []string, _ bool) error {
+		if strings.Join(keys, ",") == "C-c" {
+			alive = false
+		}
+		return nil
+	}
+	killed := false
+	tmuxKillSessionFn = func(string) error { killed = true; return nil }
+	reasons := []string{}
+	appendSessionEventFn = func(_, _ string, event sessionEvent) error {
+		reasons = append(reasons, event.State+":"+event.Reason)
+		return nil
+	}
+	audits := stubAuditRecords(t)
+
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionMonitor([]string{"--session", session, "--project-root", projectRoot, "--expect", "terminal", "--poll-interval", "1", "--max-polls", "3", "--json"})
+		if code != 2 {
+			t.Fatalf("expected exit 2 on limit breach, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"finalState":"limit_exceeded"`) || !strings.Contains(stdout, `"exitReason":"limit_max_runtime"`) {
+		t.Fatalf("unexpected monitor payload: %q", stdout)
+	}
+	joined := strings.Join(reasons, ",")
+	if !strings.Contains(joined, "limit_exceeded:limit_max_runtime,") || !strings.Contains(joined, "limit_exceeded:limit_max_runtime_interrupted") {
+		t.Fatalf("expected limit events, got %v", reasons)
+	}
+	if killed {
+		t.Fatalf("session stopped during grace; hard kill should be skipped")
+	}
+	if strings.Join(*audits, ",") != "limit interrupt lisa-limit-monitor C-c" {
+		t.Fatalf("expected the limit interrupt to be audited, got %v", *audits)
+	}
+}
@@ -0,0 +1,284 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func stubFanInMonitorStatuses(t *testing.T, states map[string][]string) {
+	t.Helper()
+	origCompute := computeSessionStatusFn
+	origSleep := monitorSleepFn
+	t.Cleanup(func() {
+		computeSessionStatusFn = origCompute
+		monitorSleepFn = origSleep
+	})
+	var mu sync.Mutex
+	computeSessionStatusFn = func(session, projectRoot, agentHint, modeHint string, full bool, pollCount int) (sessionStatus, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		seq := states[session]
+		state := "in_progress"
+		if len(seq) > 0 {
+			idx := pollCount - 1
+			if idx >= len(seq) {
+				idx = len(seq) - 1
+			}
+			state = seq[idx]
+		}
+		return sessionStatus{Session: session, Status: "active", SessionState: state}, nil
+	}
+	monitorSleepFn = func(time.Duration) {}
+}
+
+func TestParseMonitorUntilQuorum(t *testing.T) {
+	cases := []struct {
+		raw   string
+		total int
+		want  int
+		ok    bool
+	}{
+		{"", 3, 3, true},
+		{"all", 3, 3, true},
+		{"any", 3, 1, true},
+		{"2", 3, 2, true},
+		{"4", 3, 0, false},
+		{"0", 3, 0, false},
+		{"most", 3, 0, false},
+	}
+	for _, tc := range cases {
+		got, err := parseMonitorUntilQuorum(tc.raw, tc.total)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Fatalf("parseMonitorUntilQuorum(%q,%d) = %d,%v; want %d ok=%t", tc.raw, tc.total, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestCmdSessionMonitorFanInAllCompleted(t *testing.T) {
+	stubFanInMonitorStatuses(t, map[string][]string{
+		"lisa-fanin-a": {"in_progress", "completed"},
+		"lisa-fanin-b": {"completed"},
+	})
+
+	stdout, stderr := captureOutput(t, func() {
+		code := cmdSessionMonitor([]string{
+			"--sessions", "lisa-fanin-a,lisa-fanin-b",
+			"--project-root", t.TempDir(),
+			"--poll-interval", "1",
+			"--max-polls", "3",
+			"--stream-json",
+		})
+		if code != 0 {
+			t.Fatalf("expected fan-in success, got %d", code)
+		}
+	})
+	if stderr != "" {
+		t.Fatalf("unexpected stderr: %q", stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	var final map[string]any
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &final); err != nil {
+		t.Fatalf("failed to parse final payload: %v (%q)", err, stdout)
+	}
+	if final["exitReason"] != "all_finished" || final["succeeded"] != float64(2) || final["polls"] != float64(2) {
+		t.Fatalf("unexpected fan-in final payload: %v", final)
+	}
+	if !strings.Contains(stdout, `"type":"session_final"`) || !strings.Contains(stdout, `"session":"lisa-fanin-b"`) {
+		t.Fatalf("expected session-tagged stream rows, got %q", stdout)
+	}
+}
+
+func TestCmdSessionMonitorFanInAnyCrashedExitsTwo(t *testing.T) {
+	stubFanInMonitorStatuses(t, map[string][]string{
+		"lisa-fanin-crash": {"crashed"},
+		"lisa-fanin-slow":  {"in_progress"},
+	})
+
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionMonitor([]string{
+			"--sessions", "lisa-fanin-crash,lisa-fanin-slow",
+			"--project-root", t.TempDir(),
+			"--until", "any",
+			"--max-polls", "3",
+			"--json",
+		})
+		if code != 2 {
+			t.Fatalf("expected exit 2 when first finisher crashed, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"monitor_fan_in_failed"`) || !strings.Contains(stdout, `"exitReason":"pending_quorum_reached"`) {
+		t.Fatalf("unexpected fan-in any payload: %q", stdout)
+	}
+}
+
+func TestCmdSessionMonitorFanInEnforcesSessionLimits(t *testing.T) {
+	stubFanInMonitorStatuses(t, map[string][]string{
+		"lisa-fanin-limited": {"in_progress"},
+		"lisa-fanin-free":    {"in_progress"},
+	})
+	projectRoot := t.TempDir()
+	for _, meta := range []sessionMeta{
+		{Session: "lisa-fanin-limited", ProjectRoot: projectRoot, Limits: &sessionLimits{MaxRuntimeSeconds: 60}},
+		{Session: "lisa-fanin-free", ProjectRoot: projectRoot},
+	} {
+		if err := saveSessionMeta(projectRoot, meta.Session, meta); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+		session := meta.Session
+		t.Cleanup(func() { _ = os.Remove(sessionMetaFile(projectRoot, session)) })
+	}
+	origCheck, origEnforce := checkSessionLimitsFn, enforceSessionLimitFn
+	t.Cleanup(func() { checkSessionLimitsFn, enforceSessionLimitFn = origCheck, origEnforce })
+	checkSessionLimitsFn = func(_, session string, meta sessionMeta, _ sessionStatus, _ *sessionLimitTracker) (string, string) {
+		if meta.Limits != nil {
+			return "max_runtime", "runtime over limit"
+		}
+		return "", ""
+	}
+	enforced := []string{}
+	enforceSessionLimitFn = func(_, session string, _ *sessionLimits, kind, _ string) bool {
+		enforced = append(enforced, session+":"+kind)
+		return false
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionMonitor([]string{
+			"--sessions", "lisa-fanin-limited,lisa-fanin-free",
+			"--project-root", projectRoot,
+			"--until", "any",
+			"--max-polls", "3",
+			"--json",
+		})
+		if code != 2 {
+			t.Fatalf("expected exit 2 when a limit stops a session, got %d", code)
+		}
+	})
+	if strings.Join(enforced, ",") != "lisa-fanin-limited:max_runtime" {
+		t.Fatalf("expected only the limited session enforced, got %v", enforced)
+	}
+	if !strings.Contains(stdout, `"exitReason":"limit_max_runtime"`) || !strings.Contains(stdout, `"finalState":"limit_exceeded"`) {
+		t.Fatalf("expected limit result in fan-in payload: %q", stdout)
+	}
+}
+
+func TestCmdSessionMonitorFanInKeepsPollingDuringLimitGrace(t *testing.T) {
+	stubFanInMonitorStatuses(t, map[string][]string{
+		"lisa-fanin-runaway": {"in_progress"},
+		"lisa-fanin-steady":  {"in_progress", "in_progress", "completed"},
+	})
+	projectRoot := t.TempDir()
+	for _, meta := range []sessionMeta{
+		{Session: "lisa-fanin-runaway", ProjectRoot: projectRoot, Limits: &sessionLimits{MaxRuntimeSeconds: 60}},
+		{Session: "lisa-fanin-steady", ProjectRoot: projectRoot},
+	} {
+		if err := saveSessionMeta(projectRoot, meta.Session, meta); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+		session := meta.Session
+		t.Cleanup(func() { _ = os.Remove(sessionMetaFile(projectRoot, session)) })
+	}
+	stubbedCompute := computeSessionStatusFn
+	steadyDone := make(chan struct{})
+	computeSessionStatusFn = func(session, projectRoot, agentHint, modeHint string, full bool, pollCount int) (sessionStatus, error) {
+		if session == "lisa-fanin-steady" && pollCount == 3 {
+			close(steadyDone)
+		}
+		return stubbedCompute(session, projectRoot, agentHint, modeHint, full, pollCount)
+	}
+	origCheck, origEnforce := checkSessionLimitsFn, enforceSessionLimitFn
+	t.Cleanup(func() { checkSessionLimitsFn, enforceSessionLimitFn = origCheck, origEnforce })
+	checkSessionLimitsFn = func(_, _ string, meta sessionMeta, _ sessionStatus, _ *sessionLimitTracker) (string, string) {
+		if meta.Limits != nil {
+			return "max_runtime", "runtime over limit"
+		}
+		return "", ""
+	}
+	pollsDuringGrace := false
+	enforceSessionLimitFn = func(string, string, *sessionLimits, string, string) bool {
+		// Stand-in for the grace wait: only returns early if the other
+		// session keeps being polled meanwhile.
+		select {
+		case <-steadyDone:
+			pollsDuringGrace = true
+		case <-time.After(5 * time.Second):
+		}
+		return false
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		cmdSessionMonitor([]string{"--sessions", "lisa-fanin-runaway,lisa-fanin-steady", "--project-root", projectRoot, "--max-polls", "5", "--json"})
+	})
+	if !pollsDuringGrace {
+		t.Fatalf("limit grace blocked polling of the other session")
+	}
+	if !strings.Contains(stdout, `"exitReason":"limit_max_runtime"`) || !strings.Contains(stdout, `"exitReason":"all_finished"`) {
+		t.Fatalf("unexpected fan-in payload: %q", stdout)
+	}
+}
+
+func TestCmdSessionMonitorFanInTreeResolvesDescendants(t *testing.T) {
+	projectRoot := t.TempDir()
+	for _, meta := range []sessionMeta{
+		{Session: "lisa-fanin-tree-root", ProjectRoot: projectRoot},
+		{Session: "lisa-fanin-tree-child", ParentSession: "lisa-fanin-tree-root", ProjectRoot: projectRoot},
+		{Session: "lisa-fanin-tree-other", ProjectRoot: projectRoot},
+	} {
+		if err := saveSessionMeta(projectRoot, meta.Session, meta); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+	}
+	targets, _, err := resolveMonitorFanInTargets(monitorFanInConfig{
+		ProjectRoot:         projectRoot,
+		ProjectRootExplicit: true,
+		TreeRoot:            "lisa-fanin-tree-root",
+	})
+	if err != nil {
+		t.Fatalf("resolve targets failed: %v", err)
+	}
+	if strings.Join(targets, ",") != "lisa-fanin-tree-child,lisa-fanin-tree-root" {
+		t.Fatalf("unexpected tree targets: %v", targets)
+	}
+}
+
+func TestCmdSessionMonitorFanInLabelSelectorResolvesTargets(t *testing.T) {
+	projectRoot := t.TempDir()
+	for _, meta := range []sessionMeta{
+		{Session: "lisa-fanin-label-api", ProjectRoot: projectRoot, Labels: map[string]string{"team": "infra", "task": "T-1"}},
+		{Session: "lisa-fanin-label-draft", ProjectRoot: projectRoot, Labels: map[string]string{"team": "infra", "draft": "true"}},
+		{Session: "lisa-fanin-label-web", ProjectRoot: projectRoot, Labels: map[string]string{"team": "web"}},
+	} {
+		if err := saveSessionMeta(projectRoot, meta.Session, meta); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+	}
+	targets, _, err := resolveMonitorFanInTargets(monitorFanInConfig{
+		ProjectRoot:         projectRoot,
+		ProjectRootExplicit: true,
+		Labels:              []string{"team=infra", "!draft"},
+	})
+	if err != nil {
+		t.Fatalf("resolve targets failed: %v", err)
+	}
+	if strings.Join(targets, ",") != "lisa-fanin-label-api" {
+		t.Fatalf("unexpected label targets: %v", targets)
+	}
+	if _, _, err := resolveMonitorFanInTargets(monitorFanInConfig{ProjectRoot: projectRoot, Labels: []string{"=bad"}}); err == nil {
+		t.Fatalf("expected invalid selector error")
+	}
+}
+
+func TestCmdSessionMonitorFanInRejectsSessionConflict(t *testing.T) {
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionMonitor([]string{"--session", "lisa-a", "--sessions", "lisa-b", "--json"})
+		if code != 1 {
+			t.Fatalf("expected conflict failure, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"fan_in_session_conflict"`) {
+		t.Fatalf("unexpected payload: %q", stdout)
+	}
+}
@@ -2,13 +2,12 @@ package app
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -166,6 +165,7 @@ func appendSessionEvent(projectRoot, session string, event sessionEvent) error {
 			_ = f.Close()
 			return err
 		}
+		data, _ = redactOutputJSON(data)
 		if _, err := f.Write(append(data, '\n')); err != nil {
 			_ = f.Close()
 			return err
@@ -191,17 +191,18 @@ func appendSessionEvent(projectRoot, session string, event sessionEvent) error {
 			}
 		}
 
-		needsTrim := info.Size() > int64(maxBytes)
-		if !needsTrim && countKnown && lineCount > maxLines {
-			needsTrim = true
+		// Full active files rotate into a gzip segment instead of losing
+		// their oldest lines.
+		needsRotate := info.Size() > int64(maxBytes)
+		if !needsRotate && countKnown && lineCount > maxLines {
+			needsRotate = true
 		}
 
-		if needsTrim {
-			keptLines, err := trimSessionEventFileAndCount(path)
-			if err != nil {
+		if needsRotate {
+			if err := rotateSessionEventFile(path); err != nil {
 				return err
 			}
-			return writeSessionEventCount(countPath, keptLines)
+			return writeSessionEventCount(countPath, 0)
 		}
 
 		if countKnown {
@@ -211,101 +212,9 @@ func appendSessionEvent(projectRoot, session string, event sessionEvent) error {
 	})
 }
 
-func trimSessionEventFile(path string) error {
-	_, err := trimSessionEventFileAndCount(path)
-	return err
-}
-
-func trimSessionEventFileAndCount(path string) (int, error) {
-	maxBytes := getIntEnv("LISA_EVENTS_MAX_BYTES", defaultEventsMaxBytes)
-	if maxBytes <= 0 {
-		maxBytes = defaultEventsMaxBytes
-	}
-
-	raw, err := readTrimCandidateBytes(path, maxBytes)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return 0, nil
-		}
-		return 0, err
-	}
-
-	maxLines := getIntEnv("LISA_EVENTS_MAX_LINES", defaultEventsMaxLines)
-	if maxLines <= 0 {
-		maxLines = defaultEventsMaxLines
-	}
-
-	lines := make([]string, 0, maxLines)
-	for _, line := range trimLines(string(raw)) {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-		lines = append(lines, line)
-	}
-	lines = tailLines(lines, maxLines)
-
-	for len(lines) > 0 {
-		data := strings.Join(lines, "\n") + "\n"
-		if len(data) <= maxBytes {
-			if err := writeFileAtomic(path, []byte(data)); err != nil {
-				return 0, err
-			}
-			return len(lines), nil
-		}
-		lines = lines[1:]
-	}
-
-	if err := writeFileAtomic(path, []byte{}); err != nil {
-		return 0, err
-	}
-	return 0, nil
-}
-
-func readTrimCandidateBytes(path string, maxBytes int) ([]byte, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	info, err := f.Stat()
-	if err != nil {
-		return nil, err
-	}
-	size := info.Size()
-	if size <= 0 {
-		return []byte{}, nil
-	}
-
-	window := int64(maxBytes * 2)
-	if window < int64(maxBytes) {
-		window = int64(maxBytes)
-	}
-	if window < 64*1024 {
-		window = 64 * 1024
-	}
-	if window > size {
-		window = size
-	}
-	start := size - window
-	if _, err := f.Seek(start, io.SeekStart); err != nil {
-		return nil, err
-	}
-
-	raw, err := io.ReadAll(io.LimitReader(f, window))
-	if err != nil {
-		return nil, err
-	}
-	if start > 0 {
-		if idx := bytes.IndexByte(raw, '\n'); idx >= 0 {
-			raw = raw[idx+1:]
-		} else {
-			raw = []byte{}
-		}
-	}
-	return raw, nil
-}
-
+// readSessionEventTail returns the last max events of a log, reaching back
+// into rotated segments when the active file holds fewer. NextCursor is the
+// absolute line offset after the newest event.
 func readSessionEventTail(projectRoot, session string, max int) (sessionEventTail, error) {
 	if max <= 0 {
 		max = 1
@@ -316,50 +225,26 @@ func readSessionEventTail(projectRoot, session string, max int) (sessionEventTai
 
 	var tail sessionEventTail
 	err := withSharedFileLock(lockPath, lockTimeout, func() error {
-		f, err := os.Open(path)
+		manifest, err := loadSessionEventManifest(path)
 		if err != nil {
 			return err
 		}
-		defer f.Close()
-
-		r := bufio.NewReaderSize(f, 64*1024)
-		ring := make([]string, max)
-		readLines := 0
-		for {
-			line, readErr := r.ReadString('\n')
-			if line == "" && errors.Is(readErr, io.EOF) {
-				break
-			}
-			line = strings.TrimRight(line, "\r\n")
-			ring[readLines%max] = line
-			readLines++
-			if readErr != nil {
-				if errors.Is(readErr, io.EOF) {
-					break
-				}
-				return readErr
-			}
-		}
-
-		kept := readLines
-		if kept > max {
-			kept = max
-		}
-		lines := make([]string, 0, kept)
-		start := 0
-		if readLines > max {
-			start = readLines % max
+		active, err := readSessionEventActiveLines(path)
+		if err != nil && !(errors.Is(err, os.ErrNotExist) && len(manifest.Segments) > 0) {
+			return err
 		}
-		for i := 0; i < kept; i++ {
-			lines = append(lines, ring[(start+i)%max])
+		lines := tailLines(active, max)
+		for i := len(manifest.Segments) - 1; i >= 0 && len(lines) < max; i-- {
+			segmentLines, err := readSessionEventSegment(filepath.Join(filepath.Dir(path), manifest.Segments[i].File))
+			if err != nil {
+				return err
+			}
+			lines = append(tailLines(segmentLines, max-len(lines)), lines...)
 		}
 
 		events := make([]sessionEvent, 0, len(lines))
 		dropped := 0
 		for _, line := range lines {
-			if line == "" {
-				continue
-			}
 			var event sessionEvent
 			if err := json.Unmarshal([]byte(line), &event); err != nil {
 				dropped++
@@ -367,7 +252,8 @@ func readSessionEventTail(projectRoot, session string, max int) (sessionEventTai
 			}
 			events = append(events, event)
 		}
-		tail = sessionEventTail{Events: events, DroppedLines: dropped, NextCursor: readLines}
+		nextCursor := manifest.PrunedLines + manifest.segmentLines() + len(active)
+		tail = sessionEventTail{Events: events, DroppedLines: dropped, NextCursor: nextCursor}
 		return nil
 	})
 	if err != nil {
@@ -376,6 +262,44 @@ func readSessionEventTail(projectRoot, session string, max int) (sessionEventTai
 	return tail, nil
 }
 
+// listSessionEventFiles returns event logs of one project, or of every
+// project hash when allHashes is set.
+func listSessionEventFiles(projectRoot string, allHashes bool) ([]string, error) {
+	hash := "*"
+	if !allHashes {
+		hash = projectHash(projectRoot)
+	}
+	paths, err := filepath.Glob(fmt.Sprintf("/tmp/.lisa-%s-session-*-events.jsonl", hash))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// readSessionEventsFile reads a whole event log, rotated segments included,
+// under its shared lock. Undecodable lines are counted in dropped instead of
+// failing the read.
+func readSessionEventsFile(path string) (events []sessionEvent, dropped int, err error) {
+	lockTimeout := getIntEnv("LISA_EVENT_LOCK_TIMEOUT_MS", defaultEventLockTimeoutMS)
+	err = withSharedFileLock(path+".lock", lockTimeout, func() error {
+		lines, _, readErr := readSessionEventLogLines(path)
+		if readErr != nil {
+			return readErr
+		}
+		for _, line := range lines {
+			var event sessionEvent
+			if json.Unmarshal([]byte(line), &event) != nil {
+				dropped++
+				continue
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	return events, dropped, err
+}
+
 func sessionEventCountFile(path string) string {
 	return path + ".lines"
 }
@@ -437,6 +361,9 @@ func appendLifecycleEvent(projectRoot, session, eventType, state, status, reason
 	})
 }
 
+// pruneStaleSessionEventArtifacts removes logs whose active file has not
+// been written within LISA_EVENT_RETENTION_DAYS and applies segment
+// retention (age and LISA_EVENTS_RETAIN_BYTES) to the rest.
 func pruneStaleSessionEventArtifacts() error {
 	retentionDays := getIntEnv("LISA_EVENT_RETENTION_DAYS", defaultEventRetentionDays)
 	if retentionDays <= 0 {
@@ -460,15 +387,29 @@ func pruneStaleSessionEventArtifacts() error {
 				}
 				return statErr
 			}
-			if !info.ModTime().Before(cutoff) {
+			if info.ModTime().Before(cutoff) {
+				for _, target := range []string{path, sessionEventCountFile(path)} {
+					if removeErr := os.Remove(target); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+						return removeErr
+					}
+				}
+				return removeSessionEventSegments(path)
+			}
+			if !fileExists(sessionEventManifestFile(path)) {
 				return nil
 			}
-			for _, target := range []string{path, sessionEventCountFile(path)} {
-				if removeErr := os.Remove(target); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
-					return removeErr
+			manifest, loadErr := loadSessionEventManifest(path)
+			if loadErr != nil {
+				return loadErr
+			}
+			kept := len(manifest.Segments)
+			retentionErr := applySessionEventRetention(path, &manifest)
+			if len(manifest.Segments) != kept {
+				if saveErr := saveSessionEventManifest(path, manifest); saveErr != nil {
+					return saveErr
 				}
 			}
-			return nil
+			return retentionErr
 		})
 		if err != nil {
 			errs = append(errs, err.Error())
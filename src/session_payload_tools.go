@@ -113,9 +113,9 @@ func parseRedactionRules(raw string) ([]string, error) {
 			continue
 		}
 		switch rule {
-		case "none", "all", "paths", "emails", "secrets", "numbers", "tokens":
+		case "none", "all", "paths", "emails", "secrets", "numbers", "tokens", "keys", "entropy":
 		default:
-			return nil, fmt.Errorf("invalid --redact rule: %s (expected none|all|paths|emails|secrets|numbers|tokens)", rule)
+			return nil, fmt.Errorf("invalid --redact rule: %s (expected none|all|paths|emails|secrets|numbers|tokens|keys|entropy)", rule)
 		}
 		if _, ok := seen[rule]; ok {
 			continue
@@ -135,45 +135,7 @@ func parseRedactionRules(raw string) ([]string, error) {
 }
 
 func applyRedactionRules(text string, rules []string) string {
-	if len(rules) == 0 {
-		return text
-	}
-	enabled := map[string]bool{}
-	for _, rule := range rules {
-		enabled[rule] = true
-	}
-	if enabled["none"] {
-		return text
-	}
-	if enabled["all"] {
-		enabled["paths"] = true
-		enabled["emails"] = true
-		enabled["secrets"] = true
-		enabled["numbers"] = true
-		enabled["tokens"] = true
-	}
-	out := text
-	if enabled["emails"] {
-		out = emailRedactRe.ReplaceAllString(out, "[REDACTED_EMAIL]")
-	}
-	if enabled["secrets"] {
-		out = secretAssignRe.ReplaceAllString(out, "$1=[REDACTED_SECRET]")
-	}
-	if enabled["tokens"] {
-		out = bearerTokenRedact.ReplaceAllString(out, "Bearer [REDACTED_TOKEN]")
-	}
-	if enabled["numbers"] {
-		out = longNumberRedact.ReplaceAllString(out, "[REDACTED_NUMBER]")
-	}
-	if enabled["paths"] {
-		out = pathRedactRe.ReplaceAllStringFunc(out, func(match string) string {
-			prefix := ""
-			if strings.HasPrefix(match, " ") || strings.HasPrefix(match, "\"") || strings.HasPrefix(match, "'") || strings.HasPrefix(match, "=") {
-				prefix = match[:1]
-			}
-			return prefix + "[REDACTED_PATH]"
-		})
-	}
+	out, _ := redactWithRules(text, rules, defaultEntropyMinLength, defaultEntropyThreshold, nil)
 	return out
 }
 
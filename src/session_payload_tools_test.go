@@ -113,6 +113,13 @@ func TestApplyRedactionRulesNoneAndAll(t *testing.T) {
 			t.Fatalf("expected %q in all-rules output: %q", marker, out)
 		}
 	}
+	const keyed = "key sk-ant-REDACTED blob Zx9Qw3Er7Ty1Ui5Op0As4Df8Gh2Jk6LzXc"
+	if out := applyRedactionRules(keyed, []string{"all"}); out != keyed {
+		t.Fatalf("expected all to keep its original rules without keys/entropy, got %q", out)
+	}
+	if out := applyRedactionRules(keyed, []string{"all", "keys", "entropy"}); !strings.Contains(out, redactionKeyPlaceholder) || !strings.Contains(out, redactionHighEntropyMark) {
+		t.Fatalf("expected keys and entropy when named, got %q", out)
+	}
 }
 
 func TestParseTopologyRolesValidInvalidAndDuplicates(t *testing.T) {
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const sessionProgressScanLines = 80
+
+// sessionProgressLineRe matches `LISA_PROGRESS 3/7 step` on its own line,
+// tolerating the gutter glyphs agent TUIs put in front of tool output. The
+// anchor keeps an echoed `echo LISA_PROGRESS ...` command line from matching.
+var sessionProgressLineRe = regexp.MustCompile(`^[\s⎿│>●•*-]*LISA_PROGRESS\s+(\d+)\s*/\s*(\d+)(?:\s+(.*))?$`)
+
+var sessionResultStatuses = []string{"success", "failed", "blocked"}
+
+// sessionResult is the outcome an agent declares with `lisa result put`.
+// It outranks exit markers and pane heuristics for the run it names.
+type sessionResult struct {
+	Session    string   `json:"session"`
+	RunID      string   `json:"runId,omitempty"`
+	Status     string   `json:"status"`
+	Summary    string   `json:"summary,omitempty"`
+	Artifacts  []string `json:"artifacts,omitempty"`
+	ReportedAt string   `json:"reportedAt"`
+}
+
+type sessionProgress struct {
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+	Step  string `json:"step,omitempty"`
+}
+
+func sessionResultFile(projectRoot, session string) string {
+	return fmt.Sprintf("/tmp/.lisa-%s-session-%s-result.json", projectHash(projectRoot), sessionArtifactID(session))
+}
+
+func parseSessionResultStatus(raw string) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	for _, status := range sessionResultStatuses {
+		if value == status {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid --status: %s (expected %s)", raw, strings.Join(sessionResultStatuses, "|"))
+}
+
+func saveSessionResult(projectRoot string, result sessionResult) error {
+	raw, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(sessionResultFile(projectRoot, result.Session), raw)
+}
+
+func loadSessionResult(projectRoot, session string) (*sessionResult, error) {
+	raw, err := os.ReadFile(sessionResultFile(projectRoot, session))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	result := sessionResult{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid result file %s: %w", sessionResultFile(projectRoot, session), err)
+	}
+	return &result, nil
+}
+
+// loadCurrentSessionResult drops results that do not describe the current
+// turn: those from an earlier run of a reused session name (or without a run
+// id once the session has one), and those reported before the last input
+// sent to the session.
+func loadCurrentSessionResult(projectRoot, session, runID string) *sessionResult {
+	result, err := loadSessionResult(projectRoot, session)
+	if err != nil || result == nil {
+		return nil
+	}
+	if runID != "" && result.RunID != runID {
+		return nil
+	}
+	if state, stateErr := loadSessionStateWithError(sessionStateFile(projectRoot, session)); stateErr == nil {
+		if lastInput := stateLastInputAtNanos(state); lastInput > 0 {
+			reportedAt, parseErr := time.Parse(time.RFC3339Nano, result.ReportedAt)
+			if parseErr != nil || reportedAt.UnixNano() < lastInput {
+				return nil
+			}
+		}
+	}
+	return result
+}
+
+// parseSessionProgressMarker returns the last LISA_PROGRESS marker in capture.
+func parseSessionProgressMarker(capture string) *sessionProgress {
+	lines := strings.Split(capture, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		match := sessionProgressLineRe.FindStringSubmatch(strings.TrimRight(stripANSIEscape(lines[i]), " \t\r"))
+		if match == nil {
+			continue
+		}
+		done, doneErr := strconv.Atoi(match[1])
+		total, totalErr := strconv.Atoi(match[2])
+		if doneErr != nil || totalErr != nil || total <= 0 || done > total {
+			continue
+		}
+		return &sessionProgress{Done: done, Total: total, Step: strings.TrimSpace(match[3])}
+	}
+	return nil
+}
+
+// applyDeclaredSessionSignals layers agent-declared progress and results over
+// the heuristic classification. A declared result replaces a scraped terminal
+// outcome; live states are kept so monitors can still see the agent working.
+// Terminal sessions skip the progress scan here: the full status path parses
+// its own capture instead of paying for a second one.
+func applyDeclaredSessionSignals(status *sessionStatus, projectRoot, session string, paneTail func(lines int) string) {
+	switch status.SessionState {
+	case "completed", "crashed", "stuck", "not_found", "degraded":
+	default:
+		if paneTail != nil {
+			applySessionProgress(status, paneTail(defaultClassifyPaneLines))
+		} else if capture, err := tmuxCapturePaneFn(session, sessionProgressScanLines); err == nil {
+			applySessionProgress(status, capture)
+		}
+	}
+
+	result := loadCurrentSessionResult(projectRoot, session, status.Signals.RunID)
+	if result == nil {
+		return
+	}
+	status.Result = result
+	status.Signals.ResultReported = true
+	switch status.SessionState {
+	case "completed", "crashed":
+		// A declared failed/blocked result is an outcome, not a process death:
+		// the state stays as the process ended and the reason carries the
+		// result. Only success overrides a non-zero exit.
+		status.Status = "idle"
+		status.WaitEstimate = 0
+		if result.Status == "success" {
+			status.SessionState = "completed"
+		}
+		status.ClassificationReason = "result_reported_" + result.Status
+	}
+}
+
+func applySessionProgress(status *sessionStatus, capture string) {
+	progress := parseSessionProgressMarker(capture)
+	if progress == nil {
+		return
+	}
+	status.Progress = progress
+	status.TodosDone = progress.Done
+	status.TodosTotal = progress.Total
+	if progress.Step != "" && status.Status == "active" {
+		status.ActiveTask = progress.Step
+	}
+}
+
+func appendSessionResultEvent(projectRoot string, result sessionResult) error {
+	return appendSessionEventFn(projectRoot, result.Session, sessionEvent{
+		At:      nowFn().UTC().Format(time.RFC3339Nano),
+		Type:    "result",
+		Session: result.Session,
+		Status:  result.Status,
+		Reason:  "result_reported_" + result.Status,
+	})
+}
@@ -0,0 +1,188 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultPutFromPaneOverridesScrapedOutcome(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	session := "lisa-result-put"
+	origCapture, origAppend := tmuxCapturePaneFn, appendSessionEventFn
+	t.Cleanup(func() {
+		tmuxCapturePaneFn, appendSessionEventFn = origCapture, origAppend
+		_ = os.Remove(sessionResultFile(root, session))
+		_ = os.Remove(sessionMetaFile(root, session))
+	})
+	events := []sessionEvent{}
+	appendSessionEventFn = func(_ string, _ string, event sessionEvent) error { events = append(events, event); return nil }
+	if err := saveSessionMeta(root, session, sessionMeta{Session: session, RunID: "run-2"}); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+	t.Setenv("LISA_SESSION_NAME", session)
+	t.Setenv("LISA_RUN_ID", "run-2")
+	t.Setenv(lisaProjectRootEnv, root)
+
+	stdout, _ := captureOutput(t, func() {
+		code := cmdResult([]string{"put", "--status", "failed", "--summary", "2 tests red", "--artifact", "/tmp/report.md", "--json"})
+		if code != 0 {
+			t.Fatalf("expected result put to succeed, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"runId":"run-2"`) || len(events) != 1 || events[0].Reason != "result_reported_failed" {
+		t.Fatalf("unexpected put output %s events=%+v", stdout, events)
+	}
+
+	tmuxCapturePaneFn = func(string, int) (string, error) {
+		return "working\n  ⎿  LISA_PROGRESS 2/5 write tests\n$ echo LISA_PROGRESS 9/9 nope\n", nil
+	}
+	status := sessionStatus{Status: "active", SessionState: "in_progress", Signals: statusSignals{RunID: "run-2"}}
+	applyDeclaredSessionSignals(&status, root, session, nil)
+	if status.Progress == nil || status.TodosDone != 2 || status.TodosTotal != 5 || status.ActiveTask != "write tests" {
+		t.Fatalf("expected progress marker to fill todos, got %+v", status)
+	}
+	if status.Result == nil || status.SessionState != "in_progress" {
+		t.Fatalf("live session should keep its state and carry the result, got %+v", status)
+	}
+
+	status = sessionStatus{Status: "idle", SessionState: "completed", ClassificationReason: "pane_exited_zero", Signals: statusSignals{RunID: "run-2"}}
+	applyDeclaredSessionSignals(&status, root, session, nil)
+	if status.SessionState != "completed" || status.ClassificationReason != "result_reported_failed" || !status.Signals.ResultReported {
+		t.Fatalf("expected declared failure in the reason without a crash state, got %+v", status)
+	}
+
+	status = sessionStatus{Status: "idle", SessionState: "crashed", ClassificationReason: "pane_exited_nonzero", Signals: statusSignals{RunID: "run-2"}}
+	applyDeclaredSessionSignals(&status, root, session, nil)
+	if status.SessionState != "crashed" || status.ClassificationReason != "result_reported_failed" {
+		t.Fatalf("a real crash must stay crashed, got %+v", status)
+	}
+
+	status = sessionStatus{Status: "idle", SessionState: "completed", Signals: statusSignals{RunID: "run-3"}}
+	applyDeclaredSessionSignals(&status, root, session, nil)
+	if status.Result != nil || status.SessionState != "completed" {
+		t.Fatalf("result from an earlier run must be ignored, got %+v", status)
+	}
+}
+
+func TestMonitorAndHandoffPreferDeclaredResult(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	session := "lisa-result-monitor"
+	origCompute, origAppend, origSleep, origHas := computeSessionStatusFn, appendSessionEventFn, monitorSleepFn, tmuxHasSessionFn
+	t.Cleanup(func() {
+		computeSessionStatusFn, appendSessionEventFn, monitorSleepFn, tmuxHasSessionFn = origCompute, origAppend, origSleep, origHas
+	})
+	appendSessionEventFn = func(string, string, sessionEvent) error { return nil }
+	monitorSleepFn = func(time.Duration) {}
+	tmuxHasSessionFn = func(string) bool { return false }
+	declared := &sessionResult{Session: session, Status: "blocked", Summary: "need credentials"}
+	polls := 0
+	computeSessionStatusFn = func(name, projectRoot, agentHint, modeHint string, full bool, poll int) (sessionStatus, error) {
+		polls++
+		status := sessionStatus{Session: name, Agent: "claude", Mode: "interactive", Status: "active", SessionState: "in_progress"}
+		if polls >= 2 {
+			status.Result = declared
+		}
+		return status, nil
+	}
+
+	var code int
+	stdout, _ := captureOutput(t, func() {
+		code = cmdSessionMonitor([]string{"--session", session, "--project-root", root, "--poll-interval", "1", "--max-polls", "5", "--json"})
+	})
+	if code != 2 || !strings.Contains(stdout, `"exitReason":"result_reported"`) || !strings.Contains(stdout, `"errorCode":"monitor_result_reported"`) || !strings.Contains(stdout, `"polls":2`) {
+		t.Fatalf("expected blocked result to stop monitor with exit 2, got %d: %s", code, stdout)
+	}
+
+	declared.Status = "success"
+	polls = 0
+	stdout, _ = captureOutput(t, func() {
+		code = cmdSessionMonitor([]string{"--session", session, "--project-root", root, "--poll-interval", "1", "--max-polls", "5", "--expect", "terminal", "--json"})
+	})
+	if code != 0 || !strings.Contains(stdout, `"result":{`) {
+		t.Fatalf("expected successful result to satisfy --expect terminal, got %d: %s", code, stdout)
+	}
+
+	declared.Status = "blocked"
+	stdout, _ = captureOutput(t, func() {
+		cmdSessionHandoff([]string{"--session", session, "--project-root", root, "--json"})
+	})
+	payload := map[string]any{}
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("invalid handoff JSON %q: %v", stdout, err)
+	}
+	if payload["nextAction"] != "session send" || payload["summary"] != "result=blocked next=session send: need credentials" || payload["result"] == nil {
+		t.Fatalf("expected handoff to prefer the declared result, got %v", payload)
+	}
+}
+
+func TestDeclaredResultDoesNotLeakIntoLaterTurns(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	session := "lisa-result-turns"
+	origCapture, origAppend, origNow := tmuxCapturePaneFn, appendSessionEventFn, nowFn
+	t.Cleanup(func() {
+		tmuxCapturePaneFn, appendSessionEventFn, nowFn = origCapture, origAppend, origNow
+		for _, path := range []string{sessionResultFile(root, session), sessionMetaFile(root, session), sessionStateFile(root, session)} {
+			_ = os.Remove(path)
+		}
+	})
+	tmuxCapturePaneFn = func(string, int) (string, error) { return "", nil }
+	appendSessionEventFn = func(string, string, sessionEvent) error { return nil }
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return now }
+	if err := saveSessionMeta(root, session, sessionMeta{Session: session, RunID: "run-1"}); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+	poll := func() (sessionStatus, string) {
+		t.Helper()
+		status := sessionStatus{Session: session, Status: "active", SessionState: "in_progress", Signals: statusSignals{RunID: "run-1"}}
+		applyDeclaredSessionSignals(&status, root, session, nil)
+		reason, _, err := monitorStopReason(session, root, &status, monitorStopConfig{})
+		if err != nil {
+			t.Fatalf("stop reason failed: %v", err)
+		}
+		return status, reason
+	}
+	put := func(status string) {
+		t.Helper()
+		_, _ = captureOutput(t, func() {
+			if code := cmdResult([]string{"put", "--session", session, "--project-root", root, "--status", status, "--json"}); code != 0 {
+				t.Fatalf("result put failed: %d", code)
+			}
+		})
+	}
+
+	// Turn 1 reports success and stops its monitor.
+	if err := recordSessionInputTimestamp(root, session, now); err != nil {
+		t.Fatalf("record input failed: %v", err)
+	}
+	now = now.Add(time.Minute)
+	put("success")
+	if status, reason := poll(); reason != "result_reported" || status.Result == nil {
+		t.Fatalf("expected turn 1 result to stop the monitor, got %q %+v", reason, status.Result)
+	}
+
+	// Turn 2 starts: the turn 1 result must not stop the next monitor.
+	now = now.Add(time.Minute)
+	if err := recordSessionInputTimestamp(root, session, now); err != nil {
+		t.Fatalf("record input failed: %v", err)
+	}
+	if status, reason := poll(); reason != "" || status.Result != nil {
+		t.Fatalf("stale turn 1 result leaked into turn 2: %q %+v", reason, status.Result)
+	}
+	now = now.Add(time.Minute)
+	put("failed")
+	if status, reason := poll(); reason != "result_reported" || status.Result == nil || status.Result.Status != "failed" {
+		t.Fatalf("expected turn 2 result, got %q %+v", reason, status.Result)
+	}
+
+	// Results without a run id never count once the session has one.
+	if err := saveSessionResult(root, sessionResult{Session: session, Status: "success", ReportedAt: now.Add(time.Minute).Format(time.RFC3339Nano)}); err != nil {
+		t.Fatalf("save result failed: %v", err)
+	}
+	if status, reason := poll(); reason != "" || status.Result != nil {
+		t.Fatalf("expected run-less result ignored, got %q %+v", reason, status.Result)
+	}
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func saveSubtreeTestMetas(t *testing.T, projectRoot string, metas ...sessionMeta) {
+	t.Helper()
+	for _, meta := range metas {
+		meta.ProjectRoot = projectRoot
+		if err := saveSessionMeta(projectRoot, meta.Session, meta); err != nil {
+			t.Fatalf("save meta failed: %v", err)
+		}
+	}
+}
+
+func TestListSessionDescendantLevelsGroupsByDepth(t *testing.T) {
+	projectRoot := t.TempDir()
+	saveSubtreeTestMetas(t, projectRoot,
+		sessionMeta{Session: "lisa-levels-root"},
+		sessionMeta{Session: "lisa-levels-b", ParentSession: "lisa-levels-root"},
+		sessionMeta{Session: "lisa-levels-a", ParentSession: "lisa-levels-root"},
+		sessionMeta{Session: "lisa-levels-a1", ParentSession: "lisa-levels-a"},
+	)
+	levels, err := listSessionDescendantLevels(projectRoot, "lisa-levels-root", false)
+	if err != nil {
+		t.Fatalf("levels failed: %v", err)
+	}
+	if len(levels) != 2 || strings.Join(levels[0], ",") != "lisa-levels-a,lisa-levels-b" || strings.Join(levels[1], ",") != "lisa-levels-a1" {
+		t.Fatalf("unexpected levels: %v", levels)
+	}
+	flat, err := listSessionDescendants(projectRoot, "lisa-levels-root", false)
+	if err != nil {
+		t.Fatalf("descendants failed: %v", err)
+	}
+	if strings.Join(flat, ",") != "lisa-levels-a1,lisa-levels-b,lisa-levels-a" {
+		t.Fatalf("unexpected descendant order: %v", flat)
+	}
+}
+
+func TestCmdSessionKillRecursiveChildrenFirstWithGrace(t *testing.T) {
+	projectRoot := t.TempDir()
+	saveSubtreeTestMetas(t, projectRoot,
+		sessionMeta{Session: "lisa-rk-root"},
+		sessionMeta{Session: "lisa-rk-child", ParentSession: "lisa-rk-root"},
+		sessionMeta{Session: "lisa-rk-grand", ParentSession: "lisa-rk-child"},
+	)
+
+	origHas := tmuxHasSessionFn
+	origKill := tmuxKillSessionFn
+	origKeys := tmuxSendKeysFn
+	origSleep := sessionKillSleepFn
+	origNow := nowFn
+	origPrune := pruneStaleSessionEventArtifactsFn
+	t.Cleanup(func() {
+		tmuxHasSessionFn = origHas
+		tmuxKillSessionFn = origKill
+		tmuxSendKeysFn = origKeys
+		sessionKillSleepFn = origSleep
+		nowFn = origNow
+		pruneStaleSessionEventArtifactsFn = origPrune
+	})
+
+	alive := map[string]bool{"lisa-rk-root": true, "lisa-rk-child": true, "lisa-rk-grand": true}
+	events := []string{}
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return clock }
+	sessionKillSleepFn = func(d time.Duration) { clock = clock.Add(d) }
+	pruneStaleSessionEventArtifactsFn = func() error { return nil }
+	tmuxHasSessionFn = func(session string) bool { return alive[session] }
+	tmuxSendKeysFn = func(session string, keys []string, enter bool) error {
+		events = append(events, "interrupt:"+session)
+		if session == "lisa-rk-grand" {
+			// The grandchild honors Ctrl-C and writes its done file.
+			return os.WriteFile(sessionDoneFile(projectRoot, session), []byte("run-1:130\n"), 0o600)
+		}
+		return nil
+	}
+	tmuxKillSessionFn = func(session string) error {
+		events = append(events, "kill:"+session)
+		alive[session] = false
+		return nil
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionKill([]string{"--session", "lisa-rk-root", "--project-root", projectRoot, "--recursive", "--grace", "2s", "--json"})
+		if code != 0 {
+			t.Fatalf("expected recursive kill success, got %d", code)
+		}
+	})
+	want := "interrupt:lisa-rk-grand,kill:lisa-rk-grand,interrupt:lisa-rk-child,kill:lisa-rk-child,interrupt:lisa-rk-root,kill:lisa-rk-root"
+	if strings.Join(events, ",") != want {
+		t.Fatalf("unexpected teardown order:\n got %v\nwant %s", events, want)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("failed to parse payload: %v (%q)", err, stdout)
+	}
+	graceful, _ := payload["graceful"].([]any)
+	if len(graceful) != 1 || graceful[0] != "lisa-rk-grand" || payload["graceSeconds"] != float64(2) {
+		t.Fatalf("unexpected recursive kill payload: %v", payload)
+	}
+}
+
+func TestCmdSessionKillGraceRequiresRecursive(t *testing.T) {
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionKill([]string{"--session", "lisa-x", "--grace", "5", "--json"}); code != 1 {
+			t.Fatalf("expected failure, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"grace_requires_recursive"`) {
+		t.Fatalf("unexpected payload: %q", stdout)
+	}
+}
+
+func TestCmdSessionSendSubtreeBroadcastsParentsFirst(t *testing.T) {
+	projectRoot := t.TempDir()
+	saveSubtreeTestMetas(t, projectRoot,
+		sessionMeta{Session: "lisa-bc-root"},
+		sessionMeta{Session: "lisa-bc-child", ParentSession: "lisa-bc-root"},
+		sessionMeta{Session: "lisa-bc-gone", ParentSession: "lisa-bc-root"},
+	)
+	origHas := tmuxHasSessionFn
+	origText := tmuxSendTextFn
+	t.Cleanup(func() {
+		tmuxHasSessionFn = origHas
+		tmuxSendTextFn = origText
+	})
+	tmuxHasSessionFn = func(session string) bool { return session != "lisa-bc-gone" }
+	sent := []string{}
+	tmuxSendTextFn = func(session, text string, enter bool) error {
+		sent = append(sent, session)
+		return nil
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionSend([]string{"--subtree", "lisa-bc-root", "--project-root", projectRoot, "--text", "wrap up", "--enter", "--json"})
+		if code != 1 {
+			t.Fatalf("expected partial failure exit 1, got %d", code)
+		}
+	})
+	if strings.Join(sent, ",") != "lisa-bc-root,lisa-bc-child" {
+		t.Fatalf("unexpected broadcast order: %v", sent)
+	}
+	if !strings.Contains(stdout, `"sent":2`) || !strings.Contains(stdout, `"total":3`) || !strings.Contains(stdout, `"errorCode":"subtree_send_failed"`) {
+		t.Fatalf("unexpected subtree payload: %q", stdout)
+	}
+}
+
+func TestCmdSessionTreeFlagsOrphansAndExportsGraphs(t *testing.T) {
+	projectRoot := t.TempDir()
+	saveSubtreeTestMetas(t, projectRoot,
+		sessionMeta{Session: "lisa-graph-root", Agent: "claude", Mode: "interactive"},
+		sessionMeta{Session: "lisa-graph-child", ParentSession: "lisa-graph-root"},
+		sessionMeta{Session: "lisa-graph-orphan", ParentSession: "lisa-graph-dead"},
+	)
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionTree([]string{"--project-root", projectRoot, "--json"}); code != 0 {
+			t.Fatalf("tree json failed: %d", code)
+		}
+	})
+	var result sessionTreeResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse tree payload: %v", err)
+	}
+	if result.OrphanCount != 1 || len(result.Orphans) != 1 || result.Orphans[0] != "lisa-graph-orphan" {
+		t.Fatalf("unexpected orphans: %+v", result)
+	}
+
+	dot, _ := captureOutput(t, func() {
+		if code := cmdSessionTree([]string{"--project-root", projectRoot, "--format", "dot"}); code != 0 {
+			t.Fatalf("tree dot failed: %d", code)
+		}
+	})
+	for _, want := range []string{
+		"digraph lisa_sessions {",
+		`"lisa-graph-root" -> "lisa-graph-child";`,
+		`"lisa-graph-dead" -> "lisa-graph-orphan" [style=dashed];`,
+		`label="lisa-graph-root\nclaude/interactive"`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("dot output missing %q:\n%s", want, dot)
+		}
+	}
+
+	mermaid, _ := captureOutput(t, func() {
+		if code := cmdSessionTree([]string{"--project-root", projectRoot, "--format", "mermaid"}); code != 0 {
+			t.Fatalf("tree mermaid failed: %d", code)
+		}
+	})
+	if !strings.HasPrefix(mermaid, "graph TD\n") || !strings.Contains(mermaid, "-.->") || !strings.Contains(mermaid, ":::gone") {
+		t.Fatalf("unexpected mermaid output:\n%s", mermaid)
+	}
+}
+
+func TestRenderSessionTreeDOTEscapesBackslashesBeforeQuotes(t *testing.T) {
+	roots := []sessionTreeNode{{
+		Session: `lisa-dot\`, Agent: `cl"aude`, Mode: "exec",
+		Children: []sessionTreeNode{{Session: `lisa-dot-"child"`, ParentSession: `lisa-dot\`}},
+	}, {Session: "lisa-dot-orphan", ParentSession: `lisa-gone\"x`, Orphan: true}}
+
+	dot := renderSessionTreeDOT(roots)
+	for _, want := range []string{
+		`"lisa-dot\\" [label="lisa-dot\\\ncl\"aude/exec"];`,
+		`"lisa-dot\\" -> "lisa-dot-\"child\"";`,
+		`"lisa-gone\\\"x" [label="lisa-gone\\\"x\n(gone)", style=dashed];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("dot output missing %q:\n%s", want, dot)
+		}
+	}
+}
+
+func TestCmdSessionTreeFormatRejectsJSON(t *testing.T) {
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionTree([]string{"--format", "dot", "--json"}); code != 1 {
+			t.Fatalf("expected conflict, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"format_mode_conflict"`) {
+		t.Fatalf("unexpected payload: %q", stdout)
+	}
+}
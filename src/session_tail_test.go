@@ -0,0 +1,139 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionTailNewLinesFollowsScrolledCapture(t *testing.T) {
+	cases := []struct {
+		prev []string
+		cur  []string
+		want string
+	}{
+		{nil, []string{"a", "b"}, "a|b"},
+		{[]string{"a", "b"}, []string{"a", "b", "c"}, "c"},
+		{[]string{"a", "b", "c"}, []string{"b", "c", "d", "e"}, "d|e"},
+		{[]string{"x", "x"}, []string{"x", "x", "x"}, "x"},
+		{[]string{"a", "b"}, []string{"q", "r"}, ""},
+		{[]string{"a", "b"}, []string{"a", "b"}, ""},
+	}
+	for _, tc := range cases {
+		if got := strings.Join(sessionTailNewLines(tc.prev, tc.cur), "|"); got != tc.want {
+			t.Fatalf("prev=%v cur=%v: got %q want %q", tc.prev, tc.cur, got, tc.want)
+		}
+	}
+}
+
+func TestSessionTailFollowStreamsJoinsAndLeaves(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	origHas, origCapture, origSleep := tmuxHasSessionFn, tmuxCapturePaneFn, tailSleepFn
+	t.Cleanup(func() {
+		tmuxHasSessionFn, tmuxCapturePaneFn, tailSleepFn = origHas, origCapture, origSleep
+		for _, session := range []string{"lisa-tail-a", "lisa-tail-b"} {
+			_ = os.Remove(sessionStateFile(root, session))
+		}
+	})
+	poll := 0
+	tailSleepFn = func(time.Duration) { poll++ }
+	frames := map[string][]string{
+		"lisa-tail-a": {"boot\nstep 1\n> ", "boot\nstep 1\nstep 2\nFAIL test x\n> ", "step 2\nFAIL test x\n> "},
+		"lisa-tail-b": {"", "hello from b\n> ", ""},
+	}
+	tmuxHasSessionFn = func(session string) bool {
+		switch session {
+		case "lisa-tail-a":
+			return true
+		case "lisa-tail-b":
+			return poll == 1
+		}
+		return false
+	}
+	tmuxCapturePaneFn = func(session string, lines int) (string, error) {
+		return frames[session][poll], nil
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		code := cmdSessionTail([]string{"--sessions", "lisa-tail-a,lisa-tail-b", "--project-root", root, "--follow", "--max-polls", "3", "--json"})
+		if code != 0 {
+			t.Fatalf("expected tail success, got %d", code)
+		}
+	})
+	events := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		event := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid JSONL event %q: %v", line, err)
+		}
+		events = append(events, event["type"].(string)+":"+mapStringValue(event, "session")+":"+mapStringValue(event, "line"))
+	}
+	want := []string{
+		"joined:lisa-tail-a:",
+		"line:lisa-tail-a:boot",
+		"line:lisa-tail-a:step 1",
+		"line:lisa-tail-a:step 2",
+		"line:lisa-tail-a:FAIL test x",
+		"joined:lisa-tail-b:",
+		"line:lisa-tail-b:hello from b",
+		"left:lisa-tail-b:",
+	}
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected events:\n%s", strings.Join(events, "\n"))
+	}
+
+	poll = 1
+	stdout, _ = captureOutput(t, func() {
+		cmdSessionTail([]string{"--session", "lisa-tail-a", "--project-root", root, "--grep", "FAIL", "--no-color"})
+	})
+	if strings.TrimSpace(stdout) != "lisa-tail-a │ FAIL test x" {
+		t.Fatalf("unexpected grep output: %q", stdout)
+	}
+}
+
+func TestSessionTailFollowDoesNotReemitRedrawnScreen(t *testing.T) {
+	root := canonicalProjectRoot(t.TempDir())
+	session := "lisa-tail-redraw"
+	origHas, origCapture, origDisplay, origSleep := tmuxHasSessionFn, tmuxCapturePaneFn, tmuxDisplayFn, tailSleepFn
+	t.Cleanup(func() {
+		tmuxHasSessionFn, tmuxCapturePaneFn, tmuxDisplayFn, tailSleepFn = origHas, origCapture, origDisplay, origSleep
+		_ = os.Remove(sessionStateFile(root, session))
+	})
+	poll := 0
+	tailSleepFn = func(time.Duration) { poll++ }
+	tmuxHasSessionFn = func(string) bool { return true }
+	frames := []struct {
+		history int
+		capture string
+	}{
+		{0, "$ run\nstep 1\nstep 2\n⠋ working\n\n\n"},
+		// A full repaint rewrites earlier rows and the spinner in place.
+		{0, "$ run\nstep 1 ✓\nstep 2\n⠙ working\n\n\n"},
+		{2, "$ run\nstep 1 ✓\nstep 2\nstep 3\nstep 4\n⠹ working\n"},
+		// history-limit trimmed the oldest line: re-anchor on content.
+		{1, "step 1 ✓\nstep 2\nstep 3\nstep 4\nstep 5\n⠸ working\n"},
+	}
+	tmuxDisplayFn = func(_, format string) (string, error) {
+		if format != "#{history_size}" {
+			t.Fatalf("unexpected display format %q", format)
+		}
+		return strconv.Itoa(frames[poll].history), nil
+	}
+	tmuxCapturePaneFn = func(string, int) (string, error) { return frames[poll].capture, nil }
+
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionTail([]string{"--session", session, "--project-root", root, "--follow", "--max-polls", "4", "--no-color"}); code != 0 {
+			t.Fatalf("expected tail success, got %d", code)
+		}
+	})
+	want := []string{"$ run", "step 1", "step 2", "step 3", "step 4", "step 5"}
+	for i := range want {
+		want[i] = session + " │ " + want[i]
+	}
+	if got := strings.TrimSpace(stdout); got != strings.Join(want, "\n") {
+		t.Fatalf("redraw re-emitted or dropped lines:\n%s", got)
+	}
+}
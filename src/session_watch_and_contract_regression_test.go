@@ -114,12 +114,16 @@ func TestCmdSessionHandoffCompressZstdOmitsRecent(t *testing.T) {
 	if payload["compression"] != "zstd" {
 		t.Fatalf("expected zstd compression marker, got %v", payload["compression"])
 	}
-	if payload["encoding"] != "base64-gzip" {
-		t.Fatalf("expected base64-gzip encoding, got %v", payload["encoding"])
+	if payload["encoding"] != "base64-zstd" {
+		t.Fatalf("expected base64-zstd encoding, got %v", payload["encoding"])
 	}
 	if strings.TrimSpace(fmt.Sprintf("%v", payload["compressedPayload"])) == "" {
 		t.Fatalf("expected compressed payload body, got %v", payload["compressedPayload"])
 	}
+	decoded, err := decodeCompressedPayload("base64-zstd", fmt.Sprintf("%v", payload["compressedPayload"]))
+	if err != nil || !strings.Contains(string(decoded), `"recent":[`) {
+		t.Fatalf("expected zstd body carrying recent events, got %q (%v)", decoded, err)
+	}
 	if payload["uncompressedBytes"].(float64) <= 0 || payload["compressedBytes"].(float64) <= 0 {
 		t.Fatalf("expected byte counts > 0, got %v", payload)
 	}
@@ -178,6 +178,11 @@ func computeSessionStatus(session, projectRoot, agentHint, modeHint string, full
 		status.ClassificationReason = "session_not_found"
 		return status, nil
 	}
+	rules := loadClassificationRules(projectRoot)
+	if strings.TrimSpace(os.Getenv("LISA_HEARTBEAT_STALE_SECONDS")) == "" {
+		status.HeartbeatFreshSecs = rules.Thresholds.HeartbeatStaleSeconds
+	}
+	status.Signals.RulesError = rules.Error
 
 	meta, metaErr := loadSessionMeta(projectRoot, session)
 	metaReadable := metaErr == nil || errors.Is(metaErr, os.ErrNotExist)
@@ -293,6 +298,9 @@ func computeSessionStatus(session, projectRoot, agentHint, modeHint string, full
 		}
 	}
 
+	interactiveShellProbe := interactiveModeKnown && paneIsShell && heartbeatFresh &&
+		effectivePollCount > rules.Thresholds.InteractiveIdlePolls && paneTreeReady
+
 	var pendingEvent sessionEvent
 	pendingEventReady := false
 
@@ -315,107 +323,46 @@ func computeSessionStatus(session, projectRoot, agentHint, modeHint string, full
 		if !useCachedProcessScan {
 			state.LastAgentProbeAt = now
 		}
-
-		switch {
-		case strings.HasPrefix(paneStatus, "crashed:"):
-			status.Status = "idle"
-			status.SessionState = "crashed"
-			status.WaitEstimate = 0
-			status.ClassificationReason = "pane_crashed"
-		case strings.HasPrefix(paneStatus, "exited:"):
-			exitCode := strings.TrimPrefix(paneStatus, "exited:")
-			status.Status = "idle"
-			status.WaitEstimate = 0
-			if exitCode == "0" {
-				status.SessionState = "completed"
-				status.ClassificationReason = "pane_exited_zero"
-			} else {
-				status.SessionState = "crashed"
-				status.ClassificationReason = "pane_exited_nonzero"
-			}
-		default:
-			status.Status = "active"
-			status.SessionState = "in_progress"
-
-			switch {
-			case doneFileDone:
-				status.Status = "idle"
-				status.WaitEstimate = 0
-				status.ClassificationReason = "done_file"
-				if doneFileExitCode == 0 {
-					status.SessionState = "completed"
-				} else {
-					status.SessionState = "crashed"
-				}
-			case agentPID > 0:
-				activeProcessBusy := agentCPU >= agentCPUBusyThreshold
-				status.Signals.ActiveProcessBusy = activeProcessBusy
-				if mode == "interactive" && !activeProcessBusy && effectivePollCount > 3 {
-					status.Status = "idle"
-					status.SessionState = "waiting_input"
-					status.WaitEstimate = 0
-					status.ClassificationReason = "interactive_idle_cpu"
-					status.Signals.InteractiveWaiting = true
-				} else {
-					status.Status = "active"
-					status.SessionState = "in_progress"
-					status.ClassificationReason = "agent_pid_alive"
-				}
-			case interactiveModeKnown && paneIsShell && heartbeatFresh && effectivePollCount > 3 && paneTreeReady:
-				activeProcessBusy := paneCPU >= agentCPUBusyThreshold
-				status.Signals.ActiveProcessBusy = activeProcessBusy
-				switch {
-				case paneHasNonShellDescendant:
-					status.Status = "active"
-					status.SessionState = "in_progress"
-					status.ClassificationReason = "interactive_child_process"
-				case activeProcessBusy:
-					status.Status = "active"
-					status.SessionState = "in_progress"
-					status.ClassificationReason = "interactive_shell_busy"
-				default:
-					status.Status = "idle"
-					status.SessionState = "waiting_input"
-					status.WaitEstimate = 0
-					status.ClassificationReason = "interactive_shell_idle"
-					status.Signals.InteractiveWaiting = true
-				}
-			case heartbeatFresh:
-				status.Status = "active"
-				status.SessionState = "in_progress"
-				status.ClassificationReason = "heartbeat_fresh_agent_pid_unresolved"
-			case !paneIsShell:
-				status.Status = "active"
-				status.SessionState = "in_progress"
-				status.ClassificationReason = "non_shell_command"
-			case status.Signals.DoneFileReadError != "":
-				status.Status = "idle"
-				status.SessionState = "degraded"
-				status.WaitEstimate = 0
-				status.ClassificationReason = "done_file_read_error"
-			case agentScanErr != nil:
-				status.Status = "idle"
-				status.SessionState = "degraded"
-				status.WaitEstimate = 0
-				status.ClassificationReason = "agent_scan_error"
-			default:
-				status.Status = "idle"
-				status.WaitEstimate = 0
-				if effectivePollCount > 0 && effectivePollCount <= 3 {
-					status.SessionState = "just_started"
-					status.ClassificationReason = "grace_period_just_started"
-				} else {
-					status.SessionState = "stuck"
-					if doneFileRunMismatch {
-						status.ClassificationReason = "stuck_marker_run_mismatch"
-					} else {
-						status.ClassificationReason = "stuck_no_signals"
-					}
-				}
-			}
-			if status.Status == "active" && status.ActiveTask == "" {
-				status.ActiveTask = fmt.Sprintf("%s running", agentDisplayName(agent))
-			}
+		if meta.Limits != nil && meta.Limits.IdleTimeoutSeconds > 0 {
+			// Idle limits need a real output clock even when nobody runs
+			// capture, so fingerprint the pane on every poll.
+			trackPaneOutputChange(&state, session)
+		}
+		status.OutputAgeSeconds = sessionOutputAgeSeconds(state, nowFn())
+
+		hookEvent := freshSessionHookEvent(state)
+		status.Signals.HookEvent = hookEvent
+		paneTail := classificationPaneTail(session, rules)
+		classifySessionWithRules(&status, rules, classificationFacts{
+			Agent:     agent,
+			Mode:      mode,
+			PollCount: effectivePollCount,
+			Bools: map[string]bool{
+				"paneCrashed":            strings.HasPrefix(paneStatus, "crashed:"),
+				"paneExited":             strings.HasPrefix(paneStatus, "exited:"),
+				"paneExitZero":           paneStatus == "exited:0",
+				"doneFile":               doneFileDone,
+				"doneFileExitZero":       doneFileDone && doneFileExitCode == 0,
+				"doneFileRunMismatch":    doneFileRunMismatch,
+				"doneFileReadError":      status.Signals.DoneFileReadError != "",
+				"agentPidAlive":          agentPID > 0,
+				"agentBusy":              agentCPU >= rules.Thresholds.AgentCPUBusy,
+				"interactive":            mode == "interactive",
+				"interactiveShellProbe":  interactiveShellProbe,
+				"paneChildProcess":       paneHasNonShellDescendant,
+				"paneBusy":               paneCPU >= rules.Thresholds.AgentCPUBusy,
+				"heartbeatFresh":         heartbeatFresh,
+				"paneIsShell":            paneIsShell,
+				"agentScanError":         agentScanErr != nil,
+				"transcriptTurnComplete": status.Signals.TranscriptTurnComplete,
+				"hookTurnComplete":       hookEvent == sessionHookTurnComplete,
+				"hookNeedsInput":         hookEvent == sessionHookNeedsInput,
+			},
+			paneTail: paneTail,
+		})
+		applyDeclaredSessionSignals(&status, projectRoot, session, paneTail)
+		if status.SessionState == "waiting_input" {
+			status.PendingPrompt = detectSessionPendingPrompt(session)
 		}
 
 		if status.Status == "active" {
@@ -439,6 +386,8 @@ func computeSessionStatus(session, projectRoot, agentHint, modeHint string, full
 			Reason:  status.ClassificationReason,
 			Poll:    currentPoll,
 			Signals: status.Signals,
+
+			PendingPrompt: status.PendingPrompt,
 		}
 		pendingEventReady = true
 
@@ -483,6 +432,9 @@ func computeSessionStatus(session, projectRoot, agentHint, modeHint string, full
 			}
 			return status, nil
 		}
+		if status.Progress == nil {
+			applySessionProgress(&status, capture)
+		}
 		capture = filterInputBox(capture)
 		capture = strings.Join(trimLines(capture), "\n")
 		outputFile, err := writeSessionOutputFileFromCapture(projectRoot, session, capture)
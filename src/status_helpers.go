@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var doneFileCompletionLineRe = regexp.MustCompile(`^([A-Za-z0-9._-]+):(-?\d+)\s*$`)
@@ -214,6 +215,40 @@ func sessionHeartbeatAge(projectRoot, session string, now int64) (int, bool) {
 	return age, true
 }
 
+// trackPaneOutputChange bumps LastOutputAt when the pane content changed
+// since the last fingerprint (capture and status share LastOutputHash).
+func trackPaneOutputChange(state *sessionState, session string) {
+	capture, err := tmuxCapturePaneFn(session, 220)
+	if err != nil {
+		return
+	}
+	hash := md5Hex8(strings.Join(trimLines(filterInputBox(capture)), "\n"))
+	if state.LastOutputHash == hash {
+		return
+	}
+	now := nowFn()
+	state.LastOutputHash = hash
+	state.LastOutputAt = now.Unix()
+	state.LastOutputAtNanos = now.UnixNano()
+}
+
+// sessionOutputAgeSeconds is the time since the pane last produced output,
+// or 0 when no output has been observed yet.
+func sessionOutputAgeSeconds(state sessionState, now time.Time) int {
+	last := state.LastOutputAtNanos
+	if last <= 0 {
+		last = state.LastOutputAt * int64(time.Second)
+	}
+	if last <= 0 {
+		return 0
+	}
+	age := int((now.UnixNano() - last) / int64(time.Second))
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
 func isAgeFresh(age, staleAfter int) bool {
 	if age < 0 {
 		return false
@@ -230,6 +265,9 @@ func isLikelyShellPromptLine(line string) bool {
 	if shellPromptTrailerRe.MatchString(line) {
 		return true
 	}
+	if matchesCustomShellPrompt(line) {
+		return true
+	}
 	if strings.HasSuffix(line, "$") ||
 		strings.HasSuffix(line, "#") ||
 		strings.HasSuffix(line, "%") ||
@@ -1040,7 +1040,7 @@ func isTokenChar(r rune) bool {
 }
 
 func listProcesses() ([]processInfo, error) {
-	out, err := runCmd("ps", "-axo", "pid=,ppid=,%cpu=,command=")
+	out, err := runCmd("ps", "-axo", "pid=,ppid=,%cpu=,rss=,command=")
 	if err != nil {
 		return nil, err
 	}
@@ -1051,21 +1051,23 @@ func listProcesses() ([]processInfo, error) {
 		if line == "" {
 			continue
 		}
-		parts := splitNWhitespace(line, 4)
-		if len(parts) < 4 {
+		parts := splitNWhitespace(line, 5)
+		if len(parts) < 5 {
 			continue
 		}
 		pid, err1 := strconv.Atoi(parts[0])
 		ppid, err2 := strconv.Atoi(parts[1])
 		cpu, err3 := strconv.ParseFloat(parts[2], 64)
-		if err1 != nil || err2 != nil || err3 != nil {
+		rss, err4 := strconv.ParseInt(parts[3], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
 			continue
 		}
 		procs = append(procs, processInfo{
 			PID:     pid,
 			PPID:    ppid,
 			CPU:     cpu,
-			Command: parts[3],
+			RSSKB:   rss,
+			Command: parts[4],
 		})
 	}
 	return procs, nil
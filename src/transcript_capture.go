@@ -0,0 +1,331 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	transcriptKindMessage    = "message"
+	transcriptKindToolCall   = "tool_call"
+	transcriptKindToolResult = "tool_result"
+)
+
+var findCodexSessionIDFn = findCodexSessionID
+var findCodexSessionFileFn = findCodexSessionFile
+var readCodexTranscriptFn = readCodexTranscript
+
+type codexTranscriptPayload struct {
+	Type      string              `json:"type"`
+	Role      string              `json:"role"`
+	Content   []codexContentBlock `json:"content"`
+	Name      string              `json:"name"`
+	Arguments string              `json:"arguments"`
+	Input     string              `json:"input"`
+	CallID    string              `json:"call_id"`
+	Output    json.RawMessage     `json:"output"`
+	Action    json.RawMessage     `json:"action"`
+}
+
+func captureCodexSessionTranscript(session string, meta sessionMeta) (string, []transcriptMessage, error) {
+	projectRoot := canonicalProjectRoot(meta.ProjectRoot)
+	sessionID := ""
+	if state, err := loadSessionStateWithError(sessionStateFile(projectRoot, session)); err == nil {
+		sessionID = strings.TrimSpace(state.CodexSessionID)
+	}
+	if sessionID == "" {
+		if strings.TrimSpace(meta.Prompt) == "" || strings.TrimSpace(meta.CreatedAt) == "" {
+			return "", nil, fmt.Errorf("cannot find Codex transcript: session metadata missing prompt/createdAt")
+		}
+		found, err := findCodexSessionIDFn(meta.Prompt, meta.CreatedAt)
+		if err != nil {
+			return "", nil, fmt.Errorf("cannot find Codex session: %w", err)
+		}
+		sessionID = found
+	}
+	path, err := findCodexSessionFileFn(sessionID)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot find Codex session: %w", err)
+	}
+	messages, err := readCodexTranscriptFn(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot read Codex transcript: %w", err)
+	}
+	return sessionID, messages, nil
+}
+
+// readCodexTranscript normalizes a Codex rollout file into transcript entries.
+// Only response_item records are used; event_msg records duplicate them.
+// Legacy rollouts without the {type,payload} envelope are read as bare items.
+func readCodexTranscript(jsonlPath string) ([]transcriptMessage, error) {
+	f, err := os.Open(jsonlPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var messages []transcriptMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 256*1024), 8*1024*1024)
+	for scanner.Scan() {
+		var entry codexJSONLEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		raw := entry.Payload
+		switch entry.Type {
+		case "response_item":
+		case "message", "function_call", "function_call_output", "custom_tool_call", "custom_tool_call_output", "local_shell_call":
+			raw = scanner.Bytes()
+		default:
+			continue
+		}
+		var payload codexTranscriptPayload
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			continue
+		}
+		item, ok := codexTranscriptEntry(payload)
+		if !ok {
+			continue
+		}
+		item.Index = len(messages)
+		item.Timestamp = entry.Timestamp
+		messages = append(messages, item)
+	}
+	return messages, scanner.Err()
+}
+
+func codexTranscriptEntry(payload codexTranscriptPayload) (transcriptMessage, bool) {
+	switch payload.Type {
+	case "message":
+		if payload.Role != "user" && payload.Role != "assistant" {
+			return transcriptMessage{}, false
+		}
+		var parts []string
+		for _, block := range payload.Content {
+			text := strings.TrimSpace(block.Text)
+			if text == "" || codexInjectedContext(text) {
+				continue
+			}
+			parts = append(parts, text)
+		}
+		if len(parts) == 0 {
+			return transcriptMessage{}, false
+		}
+		return transcriptMessage{Role: payload.Role, Kind: transcriptKindMessage, Text: strings.Join(parts, "\n\n")}, true
+	case "function_call":
+		return transcriptMessage{Role: "assistant", Kind: transcriptKindToolCall, Tool: payload.Name, CallID: payload.CallID, Text: compactTranscriptJSON(json.RawMessage(payload.Arguments))}, true
+	case "custom_tool_call":
+		return transcriptMessage{Role: "assistant", Kind: transcriptKindToolCall, Tool: payload.Name, CallID: payload.CallID, Text: strings.TrimSpace(payload.Input)}, true
+	case "local_shell_call":
+		return transcriptMessage{Role: "assistant", Kind: transcriptKindToolCall, Tool: "local_shell", CallID: payload.CallID, Text: compactTranscriptJSON(payload.Action)}, true
+	case "function_call_output", "custom_tool_call_output":
+		return transcriptMessage{Role: "user", Kind: transcriptKindToolResult, CallID: payload.CallID, Text: codexToolOutputText(payload.Output)}, true
+	}
+	return transcriptMessage{}, false
+}
+
+// codexInjectedContext reports Codex-injected user blocks (AGENTS.md
+// instructions, environment context) that are not part of the conversation.
+func codexInjectedContext(text string) bool {
+	for _, prefix := range []string{"<environment_context>", "<user_instructions>", "# AGENTS.md instructions"} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func codexToolOutputText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return compactTranscriptJSON(raw)
+	}
+	// Exec outputs are often a JSON string wrapping {"output": "...", "metadata": {...}}.
+	var wrapped struct {
+		Output *string `json:"output"`
+	}
+	if err := json.Unmarshal([]byte(s), &wrapped); err == nil && wrapped.Output != nil {
+		return strings.TrimSpace(*wrapped.Output)
+	}
+	return strings.TrimSpace(s)
+}
+
+func compactTranscriptJSON(raw json.RawMessage) string {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, trimmed); err != nil {
+		return strings.TrimSpace(string(trimmed))
+	}
+	return buf.String()
+}
+
+// conversationTranscriptMessages drops tool entries, keeping the legacy
+// text-only view used when capture runs without --format.
+func conversationTranscriptMessages(messages []transcriptMessage) []transcriptMessage {
+	out := make([]transcriptMessage, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Kind == "" || msg.Kind == transcriptKindMessage {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func parseTranscriptFormat(raw string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "plain":
+		return "plain", nil
+	case "markdown", "md":
+		return "markdown", nil
+	case "jsonl":
+		return "jsonl", nil
+	default:
+		return "", fmt.Errorf("invalid --format: %s (expected plain|markdown|jsonl)", raw)
+	}
+}
+
+// sliceTranscriptFromIndex returns entries with Index >= from plus the next
+// cursor index to resume from.
+func sliceTranscriptFromIndex(messages []transcriptMessage, from int) ([]transcriptMessage, int) {
+	out := make([]transcriptMessage, 0, len(messages))
+	next := from
+	for _, msg := range messages {
+		if msg.Index >= from {
+			out = append(out, msg)
+		}
+		if msg.Index+1 > next {
+			next = msg.Index + 1
+		}
+	}
+	return out, next
+}
+
+func formatTranscript(messages []transcriptMessage, format string) string {
+	switch format {
+	case "markdown":
+		return formatTranscriptMarkdown(messages)
+	case "jsonl":
+		return formatTranscriptJSONL(messages)
+	default:
+		return formatTranscriptPlainWithTools(messages)
+	}
+}
+
+func formatTranscriptPlainWithTools(messages []transcriptMessage) string {
+	var sb strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		switch msg.Kind {
+		case transcriptKindToolCall:
+			fmt.Fprintf(&sb, "[tool call %s] %s\n", msg.Tool, msg.Text)
+		case transcriptKindToolResult:
+			fmt.Fprintf(&sb, "[tool result] %s\n", msg.Text)
+		default:
+			if msg.Role == "user" {
+				sb.WriteString("> ")
+			}
+			sb.WriteString(msg.Text)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func formatTranscriptMarkdown(messages []transcriptMessage) string {
+	var sb strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		switch msg.Kind {
+		case transcriptKindToolCall:
+			fmt.Fprintf(&sb, "#### Tool call `%s` (#%d)\n\n```json\n%s\n```\n", msg.Tool, msg.Index, msg.Text)
+		case transcriptKindToolResult:
+			fmt.Fprintf(&sb, "#### Tool result (#%d)\n\n```\n%s\n```\n", msg.Index, msg.Text)
+		default:
+			role := "Assistant"
+			if msg.Role == "user" {
+				role = "User"
+			}
+			fmt.Fprintf(&sb, "### %s (#%d)\n\n%s\n", role, msg.Index, msg.Text)
+		}
+	}
+	return sb.String()
+}
+
+func formatTranscriptJSONL(messages []transcriptMessage) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			continue
+		}
+		sb.Write(data)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+type transcriptCaptureRequest struct {
+	Session    string
+	Agent      string
+	SessionID  string
+	Messages   []transcriptMessage
+	Format     string
+	DeltaFrom  string
+	CursorFile string
+	JSONOut    bool
+	JSONMin    bool
+}
+
+func writeFormattedTranscriptCapture(req transcriptCaptureRequest) int {
+	from := 0
+	if req.DeltaFrom != "" {
+		n, err := strconv.Atoi(strings.TrimSpace(req.DeltaFrom))
+		if err != nil || n < 0 {
+			return commandError(req.JSONOut, "invalid_delta_from", "invalid --delta-from: transcript mode expects a message index >= 0")
+		}
+		from = n
+	}
+	selected, nextIndex := sliceTranscriptFromIndex(req.Messages, from)
+	if req.CursorFile != "" {
+		if err := writeCursorOffset(req.CursorFile, nextIndex); err != nil {
+			return commandErrorf(req.JSONOut, "cursor_file_write_failed", "failed writing --cursor-file: %v", err)
+		}
+	}
+	rendered := formatTranscript(selected, req.Format)
+	if !req.JSONOut {
+		fmt.Print(redactOutputText(rendered))
+		return 0
+	}
+	payload := map[string]any{
+		"session":                       req.Session,
+		transcriptSessionKey(req.Agent): req.SessionID,
+		"format":                        req.Format,
+		"messageCount":                  len(selected),
+		"nextIndex":                     nextIndex,
+		"capture":                       rendered,
+	}
+	if !req.JSONMin {
+		payload["totalMessages"] = len(req.Messages)
+		if req.DeltaFrom != "" {
+			payload["deltaFrom"] = from
+		}
+		if req.CursorFile != "" {
+			payload["cursorFile"] = req.CursorFile
+		}
+	}
+	writeJSON(payload)
+	return 0
+}
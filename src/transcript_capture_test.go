@@ -0,0 +1,215 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTranscriptFixture(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestReadCodexTranscriptRendersMessagesAndToolCalls(t *testing.T) {
+	path := writeTranscriptFixture(t,
+		`{"timestamp":"2026-01-01T00:00:00Z","type":"session_meta","payload":{"id":"abc"}}`,
+		`{"timestamp":"2026-01-01T00:00:01Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"<environment_context>cwd</environment_context>"}]}}`,
+		`{"timestamp":"2026-01-01T00:00:02Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"list files"}]}}`,
+		`{"timestamp":"2026-01-01T00:00:02Z","type":"event_msg","payload":{"type":"user_message","message":"list files"}}`,
+		`{"timestamp":"2026-01-01T00:00:03Z","type":"response_item","payload":{"type":"reasoning","summary":[]}}`,
+		`{"timestamp":"2026-01-01T00:00:04Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\": [\"ls\"]}","call_id":"call_1"}}`,
+		`{"timestamp":"2026-01-01T00:00:05Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_1","output":"{\"output\":\"a.go\\nb.go\\n\",\"metadata\":{\"exit_code\":0}}"}}`,
+		`{"timestamp":"2026-01-01T00:00:06Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Two files."}]}}`,
+	)
+	messages, err := readCodexTranscript(path)
+	if err != nil {
+		t.Fatalf("readCodexTranscript failed: %v", err)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 entries, got %d: %+v", len(messages), messages)
+	}
+	if messages[0].Text != "list files" || messages[0].Index != 0 {
+		t.Fatalf("unexpected user entry: %+v", messages[0])
+	}
+	if messages[1].Kind != transcriptKindToolCall || messages[1].Tool != "shell" || messages[1].Text != `{"command":["ls"]}` {
+		t.Fatalf("unexpected tool call: %+v", messages[1])
+	}
+	if messages[2].Kind != transcriptKindToolResult || messages[2].CallID != "call_1" || messages[2].Text != "a.go\nb.go" {
+		t.Fatalf("unexpected tool result: %+v", messages[2])
+	}
+	if messages[3].Role != "assistant" || messages[3].Index != 3 {
+		t.Fatalf("unexpected assistant entry: %+v", messages[3])
+	}
+}
+
+func TestReadClaudeTranscriptIncludesToolEntries(t *testing.T) {
+	path := writeTranscriptFixture(t,
+		`{"type":"user","timestamp":"2026-01-01T00:00:01Z","message":{"role":"user","content":"read main.go"}}`,
+		`{"type":"assistant","timestamp":"2026-01-01T00:00:02Z","message":{"role":"assistant","content":[{"type":"text","text":"Reading."},{"type":"tool_use","id":"tu_1","name":"Read","input":{"file_path":"main.go"}}]}}`,
+		`{"type":"user","timestamp":"2026-01-01T00:00:03Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_1","content":"package main"}]}}`,
+	)
+	messages, err := readClaudeTranscript(path)
+	if err != nil {
+		t.Fatalf("readClaudeTranscript failed: %v", err)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 entries, got %+v", messages)
+	}
+	if messages[2].Kind != transcriptKindToolCall || messages[2].Tool != "Read" || messages[2].CallID != "tu_1" {
+		t.Fatalf("unexpected tool_use entry: %+v", messages[2])
+	}
+	if messages[3].Kind != transcriptKindToolResult || messages[3].Text != "package main" {
+		t.Fatalf("unexpected tool_result entry: %+v", messages[3])
+	}
+	if got := len(conversationTranscriptMessages(messages)); got != 2 {
+		t.Fatalf("expected legacy view to keep 2 text messages, got %d", got)
+	}
+}
+
+func TestFormatTranscriptMarkdownAndJSONL(t *testing.T) {
+	messages := []transcriptMessage{
+		{Index: 0, Role: "user", Kind: transcriptKindMessage, Text: "go"},
+		{Index: 1, Role: "assistant", Kind: transcriptKindToolCall, Tool: "shell", Text: `{"command":["ls"]}`},
+		{Index: 2, Role: "user", Kind: transcriptKindToolResult, Text: "a.go"},
+	}
+	md := formatTranscript(messages, "markdown")
+	for _, want := range []string{"### User (#0)\n\ngo\n", "#### Tool call `shell` (#1)", "#### Tool result (#2)\n\n```\na.go\n```"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	jsonl := strings.Split(strings.TrimSpace(formatTranscript(messages, "jsonl")), "\n")
+	if len(jsonl) != 3 {
+		t.Fatalf("expected 3 jsonl rows, got %d", len(jsonl))
+	}
+	var row transcriptMessage
+	if err := json.Unmarshal([]byte(jsonl[1]), &row); err != nil || row.Tool != "shell" || row.Index != 1 {
+		t.Fatalf("unexpected jsonl row %q (%v)", jsonl[1], err)
+	}
+}
+
+func TestCmdSessionCaptureCodexFormatDeltaUsesMessageIndices(t *testing.T) {
+	projectRoot := t.TempDir()
+	session := "lisa-codex-transcript"
+	if err := saveSessionMeta(projectRoot, session, sessionMeta{
+		Session:     session,
+		Agent:       "codex",
+		Mode:        "interactive",
+		ProjectRoot: canonicalProjectRoot(projectRoot),
+		Prompt:      "list files",
+		CreatedAt:   "2026-01-01T00:00:00Z",
+	}); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Remove(sessionMetaFile(projectRoot, session)) })
+
+	origFindID := findCodexSessionIDFn
+	origFindFile := findCodexSessionFileFn
+	origRead := readCodexTranscriptFn
+	t.Cleanup(func() {
+		findCodexSessionIDFn = origFindID
+		findCodexSessionFileFn = origFindFile
+		readCodexTranscriptFn = origRead
+	})
+	findCodexSessionIDFn = func(prompt, createdAt string) (string, error) { return "codex-123", nil }
+	findCodexSessionFileFn = func(sessionID string) (string, error) { return "/dev/null", nil }
+	readCodexTranscriptFn = func(path string) ([]transcriptMessage, error) {
+		return []transcriptMessage{
+			{Index: 0, Role: "user", Kind: transcriptKindMessage, Text: "list files"},
+			{Index: 1, Role: "assistant", Kind: transcriptKindToolCall, Tool: "shell", Text: "ls"},
+			{Index: 2, Role: "user", Kind: transcriptKindToolResult, Text: "a.go"},
+			{Index: 3, Role: "assistant", Kind: transcriptKindMessage, Text: "One file."},
+		}, nil
+	}
+
+	cursor := filepath.Join(t.TempDir(), "transcript.cursor")
+	if err := writeCursorOffset(cursor, 2); err != nil {
+		t.Fatalf("seed cursor failed: %v", err)
+	}
+	stdout, stderr := captureOutput(t, func() {
+		code := cmdSessionCapture([]string{"--session", session, "--project-root", projectRoot, "--format", "plain", "--cursor-file", cursor, "--json"})
+		if code != 0 {
+			t.Fatalf("expected success, got %d", code)
+		}
+	})
+	if stderr != "" {
+		t.Fatalf("unexpected stderr: %q", stderr)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(stdout), &payload); err != nil {
+		t.Fatalf("failed to parse payload: %v (%q)", err, stdout)
+	}
+	if payload["codexSession"] != "codex-123" || payload["messageCount"] != float64(2) || payload["nextIndex"] != float64(4) {
+		t.Fatalf("unexpected payload: %v", payload)
+	}
+	if capture, _ := payload["capture"].(string); capture != "[tool result] a.go\n\nOne file.\n" {
+		t.Fatalf("unexpected rendered capture: %q", capture)
+	}
+	if next, err := loadCursorOffset(cursor); err != nil || next != 4 {
+		t.Fatalf("expected cursor advanced to 4, got %d (%v)", next, err)
+	}
+}
+
+func TestCmdSessionCaptureRedactsTranscriptText(t *testing.T) {
+	projectRoot := t.TempDir()
+	session := "lisa-codex-redact"
+	if err := saveSessionMeta(projectRoot, session, sessionMeta{
+		Session:     session,
+		Agent:       "codex",
+		Mode:        "interactive",
+		ProjectRoot: canonicalProjectRoot(projectRoot),
+		Prompt:      "print the key",
+		CreatedAt:   "2026-01-01T00:00:00Z",
+	}); err != nil {
+		t.Fatalf("save meta failed: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Remove(sessionMetaFile(projectRoot, session)) })
+	useOutputRedaction(t, projectRoot)
+
+	origFindID := findCodexSessionIDFn
+	origFindFile := findCodexSessionFileFn
+	origRead := readCodexTranscriptFn
+	t.Cleanup(func() {
+		findCodexSessionIDFn = origFindID
+		findCodexSessionFileFn = origFindFile
+		readCodexTranscriptFn = origRead
+	})
+	findCodexSessionIDFn = func(prompt, createdAt string) (string, error) { return "codex-456", nil }
+	findCodexSessionFileFn = func(sessionID string) (string, error) { return "/dev/null", nil }
+	readCodexTranscriptFn = func(path string) ([]transcriptMessage, error) {
+		return []transcriptMessage{
+			{Index: 0, Role: "user", Kind: transcriptKindMessage, Text: "print the key"},
+			{Index: 1, Role: "assistant", Kind: transcriptKindMessage, Text: "OPENAI_API_KEY=sk-proj-0123456789abcdefghijKLMN"},
+		}, nil
+	}
+
+	for _, extra := range [][]string{nil, {"--format", "markdown"}, {"--summary"}} {
+		args := append([]string{"--session", session, "--project-root", projectRoot}, extra...)
+		stdout, _ := captureOutput(t, func() {
+			if code := cmdSessionCapture(args); code != 0 {
+				t.Fatalf("capture %v failed: %d", extra, code)
+			}
+		})
+		if strings.Contains(stdout, "sk-proj-0123456789") || !strings.Contains(stdout, redactionKeyPlaceholder) {
+			t.Fatalf("capture %v leaked the key: %q", extra, stdout)
+		}
+	}
+}
+
+func TestCmdSessionCaptureFormatRejectsRaw(t *testing.T) {
+	stdout, _ := captureOutput(t, func() {
+		if code := cmdSessionCapture([]string{"--session", "lisa-x", "--raw", "--format", "jsonl", "--json"}); code != 1 {
+			t.Fatalf("expected failure, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, `"errorCode":"format_requires_transcript"`) {
+		t.Fatalf("unexpected payload: %q", stdout)
+	}
+}
@@ -10,6 +10,7 @@ const (
 	defaultEventsMaxBytes      = 1_000_000
 	defaultEventsMaxLines      = 2000
 	defaultEventRetentionDays  = 14
+	defaultEventsRetainBytes   = 20_000_000
 	defaultProcessScanInterval = 8
 	defaultProcessListCacheMS  = 500
 	defaultCmdTimeoutSeconds   = 20
@@ -22,22 +23,24 @@ const (
 )
 
 type sessionMeta struct {
-	Session             string `json:"session"`
-	ParentSession       string `json:"parentSession,omitempty"`
-	Agent               string `json:"agent"`
-	Mode                string `json:"mode"`
-	Lane                string `json:"lane,omitempty"`
-	OAuthTokenID        string `json:"oauthTokenId,omitempty"`
-	RunID               string `json:"runId,omitempty"`
-	ProjectRoot         string `json:"projectRoot"`
-	SocketPath          string `json:"socketPath,omitempty"`
-	StartCmd            string `json:"startCommand"`
-	Prompt              string `json:"prompt,omitempty"`
-	ObjectiveID         string `json:"objectiveId,omitempty"`
-	ObjectiveGoal       string `json:"objectiveGoal,omitempty"`
-	ObjectiveAcceptance string `json:"objectiveAcceptance,omitempty"`
-	ObjectiveBudget     int    `json:"objectiveBudget,omitempty"`
-	CreatedAt           string `json:"createdAt"`
+	Session             string            `json:"session"`
+	ParentSession       string            `json:"parentSession,omitempty"`
+	Agent               string            `json:"agent"`
+	Mode                string            `json:"mode"`
+	Lane                string            `json:"lane,omitempty"`
+	OAuthTokenID        string            `json:"oauthTokenId,omitempty"`
+	RunID               string            `json:"runId,omitempty"`
+	ProjectRoot         string            `json:"projectRoot"`
+	SocketPath          string            `json:"socketPath,omitempty"`
+	StartCmd            string            `json:"startCommand"`
+	Prompt              string            `json:"prompt,omitempty"`
+	ObjectiveID         string            `json:"objectiveId,omitempty"`
+	ObjectiveGoal       string            `json:"objectiveGoal,omitempty"`
+	ObjectiveAcceptance string            `json:"objectiveAcceptance,omitempty"`
+	ObjectiveBudget     int               `json:"objectiveBudget,omitempty"`
+	Labels              map[string]string `json:"labels,omitempty"`
+	Limits              *sessionLimits    `json:"limits,omitempty"`
+	CreatedAt           string            `json:"createdAt"`
 }
 
 type sessionState struct {
@@ -56,6 +59,9 @@ type sessionState struct {
 	LastTurnCompleteAtNanos    int64   `json:"lastTurnCompleteAtNanos,omitempty"`
 	LastTurnCompleteInputNanos int64   `json:"lastTurnCompleteInputNanos,omitempty"`
 	LastTurnCompleteFileAge    int     `json:"lastTurnCompleteFileAge,omitempty"`
+	LastHookEvent              string  `json:"lastHookEvent,omitempty"`
+	LastHookAtNanos            int64   `json:"lastHookAtNanos,omitempty"`
+	LastHookMessage            string  `json:"lastHookMessage,omitempty"`
 	OAuthTokenPruned           bool    `json:"oauthTokenPruned,omitempty"`
 	OAuthTokenPruneReason      string  `json:"oauthTokenPruneReason,omitempty"`
 	LastSessionState           string  `json:"lastSessionState,omitempty"`
@@ -67,26 +73,31 @@ type sessionState struct {
 }
 
 type sessionStatus struct {
-	Session              string        `json:"session"`
-	Agent                string        `json:"agent"`
-	Mode                 string        `json:"mode"`
-	Status               string        `json:"status"`
-	TodosDone            int           `json:"todosDone"`
-	TodosTotal           int           `json:"todosTotal"`
-	ActiveTask           string        `json:"activeTask"`
-	WaitEstimate         int           `json:"waitEstimate"`
-	SessionState         string        `json:"sessionState"`
-	PaneStatus           string        `json:"paneStatus"`
-	PaneCommand          string        `json:"paneCommand"`
-	AgentPID             int           `json:"agentPid"`
-	AgentCPU             float64       `json:"agentCpu"`
-	OutputAgeSeconds     int           `json:"outputAgeSeconds"`
-	OutputFreshSeconds   int           `json:"outputFreshSeconds"`
-	HeartbeatAge         int           `json:"heartbeatAgeSeconds"`
-	HeartbeatFreshSecs   int           `json:"heartbeatFreshSeconds"`
-	ClassificationReason string        `json:"classificationReason"`
-	Signals              statusSignals `json:"signals"`
-	OutputFile           string        `json:"outputFile,omitempty"`
+	Session              string           `json:"session"`
+	Agent                string           `json:"agent"`
+	Mode                 string           `json:"mode"`
+	Status               string           `json:"status"`
+	TodosDone            int              `json:"todosDone"`
+	TodosTotal           int              `json:"todosTotal"`
+	ActiveTask           string           `json:"activeTask"`
+	WaitEstimate         int              `json:"waitEstimate"`
+	SessionState         string           `json:"sessionState"`
+	PaneStatus           string           `json:"paneStatus"`
+	PaneCommand          string           `json:"paneCommand"`
+	AgentPID             int              `json:"agentPid"`
+	AgentCPU             float64          `json:"agentCpu"`
+	OutputAgeSeconds     int              `json:"outputAgeSeconds"`
+	OutputFreshSeconds   int              `json:"outputFreshSeconds"`
+	HeartbeatAge         int              `json:"heartbeatAgeSeconds"`
+	HeartbeatFreshSecs   int              `json:"heartbeatFreshSeconds"`
+	ClassificationReason string           `json:"classificationReason"`
+	Signals              statusSignals    `json:"signals"`
+	OutputFile           string           `json:"outputFile,omitempty"`
+	PendingPrompt        *pendingPrompt   `json:"pendingPrompt,omitempty"`
+	Progress             *sessionProgress `json:"progress,omitempty"`
+	Result               *sessionResult   `json:"result,omitempty"`
+
+	ClassificationTrace *classificationTrace `json:"-"`
 }
 
 type statusSignals struct {
@@ -112,6 +123,8 @@ type statusSignals struct {
 	PaneIsShell              bool   `json:"paneIsShell"`
 	AgentScanCached          bool   `json:"agentScanCached"`
 	AgentScanError           string `json:"agentScanError,omitempty"`
+	ResultReported           bool   `json:"resultReported"`
+	HookEvent                string `json:"hookEvent,omitempty"`
 	TranscriptTurnComplete   bool   `json:"transcriptTurnComplete"`
 	TranscriptFileAge        int    `json:"transcriptFileAge"`
 	TranscriptError          string `json:"transcriptError,omitempty"`
@@ -121,6 +134,7 @@ type statusSignals struct {
 	MetaReadError            string `json:"metaReadError,omitempty"`
 	StateReadError           string `json:"stateReadError,omitempty"`
 	EventsWriteError         string `json:"eventsWriteError,omitempty"`
+	RulesError               string `json:"rulesError,omitempty"`
 }
 
 type sessionEvent struct {
@@ -132,6 +146,10 @@ type sessionEvent struct {
 	Reason  string        `json:"reason"`
 	Poll    int           `json:"poll"`
 	Signals statusSignals `json:"signals"`
+
+	PendingPrompt *pendingPrompt   `json:"pendingPrompt,omitempty"`
+	Decision      *promptDecision  `json:"decision,omitempty"`
+	Inbox         *sessionInboxAck `json:"inbox,omitempty"`
 }
 
 type monitorResult struct {
@@ -144,11 +162,16 @@ type monitorResult struct {
 	ExitReason  string `json:"exitReason"`
 	Polls       int    `json:"polls"`
 	FinalStatus string `json:"finalStatus"`
+
+	PendingPrompt  *pendingPrompt `json:"pendingPrompt,omitempty"`
+	UnreadMessages int            `json:"unreadMessages,omitempty"`
+	Result         *sessionResult `json:"result,omitempty"`
 }
 
 type processInfo struct {
 	PID     int
 	PPID    int
 	CPU     float64
+	RSSKB   int64
 	Command string
 }
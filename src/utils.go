@@ -19,14 +19,19 @@ import (
 )
 
 func runCmd(name string, args ...string) (string, error) {
-	return runCmdInternal("", name, args...)
+	return runCmdInternal("", nil, name, args...)
 }
 
 func runCmdInput(input, name string, args ...string) (string, error) {
-	return runCmdInternal(input, name, args...)
+	return runCmdInternal(input, nil, name, args...)
 }
 
-func runCmdInternal(input, name string, args ...string) (string, error) {
+// runCmdEnv is runCmd with extra KEY=VALUE entries appended to the environment.
+func runCmdEnv(env []string, name string, args ...string) (string, error) {
+	return runCmdInternal("", env, name, args...)
+}
+
+func runCmdInternal(input string, extraEnv []string, name string, args ...string) (string, error) {
 	timeout := time.Duration(getIntEnv("LISA_CMD_TIMEOUT_SECONDS", defaultCmdTimeoutSeconds)) * time.Second
 	if timeout <= 0 {
 		timeout = time.Duration(defaultCmdTimeoutSeconds) * time.Second
@@ -36,7 +41,7 @@ func runCmdInternal(input, name string, args ...string) (string, error) {
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Env = commandExecEnv(name)
+	cmd.Env = append(commandExecEnv(name), extraEnv...)
 	var out bytes.Buffer
 	if input != "" {
 		cmd.Stdin = strings.NewReader(input)
@@ -94,6 +99,7 @@ func writeJSON(v any) {
 	}
 	var payload map[string]any
 	if err := json.Unmarshal(b, &payload); err == nil {
+		redactPayloadMap(payload)
 		if _, ok := payload["stderrPolicy"]; !ok {
 			payload["stderrPolicy"] = "diagnostic"
 		}
@@ -447,6 +453,41 @@ func parseBoolFlag(raw string) (bool, error) {
 	}
 }
 
+// parseSinceFlag accepts an RFC3339 timestamp or a duration ago ("24h",
+// "90m", bare seconds) for --since/--until style flags.
+func parseSinceFlag(flag, raw string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(raw)); err == nil {
+		return at, nil
+	}
+	duration, err := parseDurationFlag(flag, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %s (expected RFC3339 or duration)", flag, raw)
+	}
+	return nowFn().Add(-duration), nil
+}
+
+// parseDurationFlag accepts Go durations ("30s", "2m") or bare integer seconds.
+func parseDurationFlag(flag, raw string) (time.Duration, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return 0, fmt.Errorf("invalid %s: empty duration", flag)
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("invalid %s: must be >= 0", flag)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", flag, raw)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("invalid %s: must be >= 0", flag)
+	}
+	return duration, nil
+}
+
 func writeCSVRecord(fields ...string) error {
 	writer := csv.NewWriter(os.Stdout)
 	if err := writer.Write(fields); err != nil {
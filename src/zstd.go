@@ -0,0 +1,1296 @@
+package app
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// In-tree Zstandard (RFC 8878) codec so compressed handoff payloads stay
+// interoperable without adding a module dependency. The decoder handles
+// every frame feature except dictionaries. The encoder emits single-segment
+// frames with a content checksum, Huffman or raw literals, greedy hash-chain
+// matches and the predefined sequence tables.
+
+const (
+	zstdMagic           = 0xFD2FB528
+	zstdSkippableMagic  = 0x184D2A50
+	zstdBlockMaxSize    = 128 << 10
+	zstdMaxDecodedBytes = 64 << 20
+	zstdMinMatch        = 4
+	zstdHashLog         = 15
+	zstdChainDepth      = 32
+	zstdMaxOffset       = 1<<28 - 1
+	zstdHuffmanMaxBits  = 11
+)
+
+var errZstdCorrupt = errors.New("zstd: corrupt input")
+
+var (
+	zstdLLBase = [36]int{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096,
+		8192, 16384, 32768, 65536,
+	}
+	zstdLLBits = [36]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+	zstdMLBase = [53]int{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+		19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051,
+		4099, 8195, 16387, 32771, 65539,
+	}
+	zstdMLBits = [53]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11,
+		12, 13, 14, 15, 16,
+	}
+
+	zstdPredefinedLL = mustBuildZstdFSETable([]int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}, 6)
+	zstdPredefinedML = mustBuildZstdFSETable([]int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}, 6)
+	zstdPredefinedOF = mustBuildZstdFSETable([]int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}, 5)
+)
+
+// zstdFSEEntry is one decoding state: the symbol it emits and how to reach
+// the next state (base + nbBits read from the stream).
+type zstdFSEEntry struct {
+	symbol uint8
+	nbBits uint8
+	base   uint16
+}
+
+type zstdFSETable struct {
+	log     uint8
+	entries []zstdFSEEntry
+	// encode[symbol][target] is the state emitting symbol whose transition
+	// range covers target; built lazily for the encoder.
+	encode [][]uint16
+}
+
+func mustBuildZstdFSETable(norm []int16, log uint8) *zstdFSETable {
+	table, err := buildZstdFSETable(norm, log)
+	if err != nil {
+		panic(err)
+	}
+	table.buildEncode(len(norm))
+	return table
+}
+
+func buildZstdFSETable(norm []int16, log uint8) (*zstdFSETable, error) {
+	size := 1 << log
+	entries := make([]zstdFSEEntry, size)
+	next := make([]int, len(norm))
+	high := size - 1
+	for s, count := range norm {
+		switch {
+		case count == -1:
+			entries[high].symbol = uint8(s)
+			high--
+			next[s] = 1
+		case count > 0:
+			next[s] = int(count)
+		}
+	}
+	step := size>>1 + size>>3 + 3
+	mask := size - 1
+	pos := 0
+	for s, count := range norm {
+		for i := 0; i < int(count); i++ {
+			entries[pos].symbol = uint8(s)
+			pos = (pos + step) & mask
+			for pos > high {
+				pos = (pos + step) & mask
+			}
+		}
+	}
+	if pos != 0 {
+		return nil, errZstdCorrupt
+	}
+	for u := range entries {
+		s := entries[u].symbol
+		state := next[s]
+		next[s]++
+		nbBits := int(log) - (bits.Len(uint(state)) - 1)
+		entries[u].nbBits = uint8(nbBits)
+		entries[u].base = uint16(state<<nbBits - size)
+	}
+	return &zstdFSETable{log: log, entries: entries}, nil
+}
+
+func (t *zstdFSETable) buildEncode(symbols int) {
+	t.encode = make([][]uint16, symbols)
+	for u, entry := range t.entries {
+		if t.encode[entry.symbol] == nil {
+			t.encode[entry.symbol] = make([]uint16, len(t.entries))
+		}
+		for target := int(entry.base); target < int(entry.base)+1<<entry.nbBits; target++ {
+			t.encode[entry.symbol][target] = uint16(u)
+		}
+	}
+}
+
+func zstdRLETable(symbol uint8) *zstdFSETable {
+	return &zstdFSETable{entries: []zstdFSEEntry{{symbol: symbol}}}
+}
+
+// zstdForwardBits reads the little-endian, LSB-first bitstream used by FSE
+// table descriptions. Bits past the end read as zero; callers check the
+// consumed length.
+type zstdForwardBits struct {
+	src []byte
+	pos int
+}
+
+func (r *zstdForwardBits) peek(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		bit := r.pos + i
+		if bit>>3 < len(r.src) && r.src[bit>>3]&(1<<(bit&7)) != 0 {
+			v |= 1 << i
+		}
+	}
+	return v
+}
+
+func (r *zstdForwardBits) read(n int) int {
+	v := r.peek(n)
+	r.pos += n
+	return v
+}
+
+// readZstdFSETableDesc parses a normalized-count table description and
+// returns the decoding table and the bytes consumed.
+func readZstdFSETableDesc(src []byte, maxLog uint8, maxSymbol int) (*zstdFSETable, int, error) {
+	if len(src) == 0 {
+		return nil, 0, errZstdCorrupt
+	}
+	br := &zstdForwardBits{src: src}
+	log := uint8(br.read(4)) + 5
+	if log > maxLog {
+		return nil, 0, fmt.Errorf("zstd: FSE accuracy log %d exceeds %d", log, maxLog)
+	}
+	remaining := 1<<log + 1
+	threshold := 1 << log
+	nbBits := int(log) + 1
+	norm := make([]int16, 0, maxSymbol+1)
+	previous0 := false
+	for remaining > 1 && len(norm) <= maxSymbol {
+		if previous0 {
+			for {
+				repeat := br.read(2)
+				for i := 0; i < repeat; i++ {
+					norm = append(norm, 0)
+				}
+				if repeat != 3 {
+					break
+				}
+			}
+			if len(norm) > maxSymbol {
+				return nil, 0, errZstdCorrupt
+			}
+		}
+		limit := 2*threshold - 1 - remaining
+		v := br.peek(nbBits)
+		count := v & (threshold - 1)
+		if count < limit {
+			br.pos += nbBits - 1
+		} else {
+			count = v
+			if count >= threshold {
+				count -= limit
+			}
+			br.pos += nbBits
+		}
+		count--
+		if count < 0 {
+			remaining--
+		} else {
+			remaining -= count
+		}
+		if remaining < 1 {
+			return nil, 0, errZstdCorrupt
+		}
+		norm = append(norm, int16(count))
+		previous0 = count == 0
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+	used := (br.pos + 7) / 8
+	if remaining != 1 || used > len(src) {
+		return nil, 0, errZstdCorrupt
+	}
+	table, err := buildZstdFSETable(norm, log)
+	if err != nil {
+		return nil, 0, err
+	}
+	return table, used, nil
+}
+
+// zstdReverseBits reads a backward bitstream: the last byte carries a
+// closing 1 bit and reading starts from the highest remaining bit. Reads
+// past the start yield zeros and leave pos negative.
+type zstdReverseBits struct {
+	src []byte
+	pos int
+}
+
+func newZstdReverseBits(src []byte) (*zstdReverseBits, error) {
+	if len(src) == 0 || src[len(src)-1] == 0 {
+		return nil, errZstdCorrupt
+	}
+	return &zstdReverseBits{src: src, pos: (len(src)-1)*8 + bits.Len8(src[len(src)-1]) - 1}, nil
+}
+
+func (r *zstdReverseBits) extract(start, n int) uint64 {
+	idx := start >> 3
+	var v uint64
+	for i := 0; i < 8 && idx+i < len(r.src); i++ {
+		v |= uint64(r.src[idx+i]) << (8 * i)
+	}
+	return (v >> (start & 7)) & (1<<n - 1)
+}
+
+func (r *zstdReverseBits) peek(n int) uint64 {
+	if n == 0 {
+		return 0
+	}
+	start := r.pos - n
+	if start >= 0 {
+		return r.extract(start, n)
+	}
+	if r.pos <= 0 {
+		return 0
+	}
+	return r.extract(0, r.pos) << (-start)
+}
+
+func (r *zstdReverseBits) read(n int) uint64 {
+	v := r.peek(n)
+	r.pos -= n
+	return v
+}
+
+type zstdHuffmanEntry struct {
+	symbol uint8
+	nbBits uint8
+}
+
+type zstdHuffmanTable struct {
+	maxBits int
+	entries []zstdHuffmanEntry
+}
+
+// decodeZstdHuffmanWeights reads a Huffman tree description, either direct
+// 4-bit weights or FSE-compressed ones, and returns the listed weights and
+// the bytes consumed.
+func decodeZstdHuffmanWeights(src []byte) ([]uint8, int, error) {
+	if len(src) == 0 {
+		return nil, 0, errZstdCorrupt
+	}
+	header := int(src[0])
+	if header >= 128 {
+		n := header - 127
+		size := (n + 1) / 2
+		if 1+size > len(src) {
+			return nil, 0, errZstdCorrupt
+		}
+		weights := make([]uint8, n)
+		for i := range weights {
+			b := src[1+i/2]
+			if i%2 == 0 {
+				weights[i] = b >> 4
+			} else {
+				weights[i] = b & 0x0F
+			}
+		}
+		return weights, 1 + size, nil
+	}
+	if 1+header > len(src) {
+		return nil, 0, errZstdCorrupt
+	}
+	body := src[1 : 1+header]
+	table, used, err := readZstdFSETableDesc(body, 6, zstdHuffmanMaxBits+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	br, err := newZstdReverseBits(body[used:])
+	if err != nil {
+		return nil, 0, err
+	}
+	state1 := int(br.read(int(table.log)))
+	state2 := int(br.read(int(table.log)))
+	weights := []uint8{}
+	for {
+		if len(weights) > 253 {
+			return nil, 0, errZstdCorrupt
+		}
+		entry := table.entries[state1]
+		weights = append(weights, entry.symbol)
+		state1 = int(entry.base) + int(br.read(int(entry.nbBits)))
+		if br.pos < 0 {
+			weights = append(weights, table.entries[state2].symbol)
+			break
+		}
+		entry = table.entries[state2]
+		weights = append(weights, entry.symbol)
+		state2 = int(entry.base) + int(br.read(int(entry.nbBits)))
+		if br.pos < 0 {
+			weights = append(weights, table.entries[state1].symbol)
+			break
+		}
+	}
+	return weights, 1 + header, nil
+}
+
+func buildZstdHuffmanTable(weights []uint8) (*zstdHuffmanTable, error) {
+	if len(weights) == 0 || len(weights) > 255 {
+		return nil, errZstdCorrupt
+	}
+	total := 0
+	for _, w := range weights {
+		if w > zstdHuffmanMaxBits {
+			return nil, errZstdCorrupt
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 {
+		return nil, errZstdCorrupt
+	}
+	maxBits := bits.Len(uint(total))
+	if maxBits > zstdHuffmanMaxBits {
+		return nil, errZstdCorrupt
+	}
+	rest := 1<<maxBits - total
+	if rest&(rest-1) != 0 {
+		return nil, errZstdCorrupt
+	}
+	all := append(append([]uint8{}, weights...), uint8(bits.Len(uint(rest))))
+
+	rankStart := make([]int, maxBits+1)
+	pos := 0
+	for w := 1; w <= maxBits; w++ {
+		rankStart[w] = pos
+		for _, sw := range all {
+			if int(sw) == w {
+				pos += 1 << (w - 1)
+			}
+		}
+	}
+	entries := make([]zstdHuffmanEntry, 1<<maxBits)
+	for s, w := range all {
+		if w == 0 {
+			continue
+		}
+		length := 1 << (w - 1)
+		for i := rankStart[w]; i < rankStart[w]+length; i++ {
+			entries[i] = zstdHuffmanEntry{symbol: uint8(s), nbBits: uint8(maxBits + 1 - int(w))}
+		}
+		rankStart[w] += length
+	}
+	return &zstdHuffmanTable{maxBits: maxBits, entries: entries}, nil
+}
+
+func (t *zstdHuffmanTable) decodeStream(src []byte, n int, out []byte) ([]byte, error) {
+	br, err := newZstdReverseBits(src)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		entry := t.entries[br.peek(t.maxBits)]
+		out = append(out, entry.symbol)
+		br.pos -= int(entry.nbBits)
+	}
+	if br.pos != 0 {
+		return nil, errZstdCorrupt
+	}
+	return out, nil
+}
+
+// zstdFrameState is what blocks of one frame share: the last Huffman and
+// sequence tables (for repeat modes) and the repeat offsets.
+type zstdFrameState struct {
+	huffman    *zstdHuffmanTable
+	ll, of, ml *zstdFSETable
+	rep        [3]int
+}
+
+// zstdDecompress decodes every frame in src, skipping skippable frames.
+func zstdDecompress(src []byte) ([]byte, error) {
+	out := []byte{}
+	frames := 0
+	for len(src) > 0 {
+		if len(src) < 4 {
+			return nil, errZstdCorrupt
+		}
+		magic := binary.LittleEndian.Uint32(src)
+		if magic&0xFFFFFFF0 == zstdSkippableMagic {
+			if len(src) < 8 {
+				return nil, errZstdCorrupt
+			}
+			size := int(binary.LittleEndian.Uint32(src[4:]))
+			if size > len(src)-8 {
+				return nil, errZstdCorrupt
+			}
+			src = src[8+size:]
+			continue
+		}
+		if magic != zstdMagic {
+			return nil, fmt.Errorf("zstd: unknown frame magic 0x%08x", magic)
+		}
+		var used int
+		var err error
+		out, used, err = decodeZstdFrame(src[4:], out)
+		if err != nil {
+			return nil, err
+		}
+		src = src[4+used:]
+		frames++
+	}
+	if frames == 0 {
+		return nil, fmt.Errorf("zstd: no frame found")
+	}
+	return out, nil
+}
+
+func decodeZstdFrame(src []byte, out []byte) ([]byte, int, error) {
+	if len(src) < 1 {
+		return nil, 0, errZstdCorrupt
+	}
+	descriptor := src[0]
+	p := 1
+	if descriptor&0x08 != 0 {
+		return nil, 0, errZstdCorrupt
+	}
+	singleSegment := descriptor&0x20 != 0
+	if !singleSegment {
+		p++
+	}
+	dictIDSize := [4]int{0, 1, 2, 4}[descriptor&0x03]
+	fcsSize := [4]int{0, 2, 4, 8}[descriptor>>6]
+	if fcsSize == 0 && singleSegment {
+		fcsSize = 1
+	}
+	if len(src) < p+dictIDSize+fcsSize {
+		return nil, 0, errZstdCorrupt
+	}
+	if readZstdLE(src[p:p+dictIDSize]) != 0 {
+		return nil, 0, fmt.Errorf("zstd: dictionaries are not supported")
+	}
+	p += dictIDSize
+	contentSize := -1
+	if fcsSize > 0 {
+		size := readZstdLE(src[p : p+fcsSize])
+		if fcsSize == 2 {
+			size += 256
+		}
+		if size > zstdMaxDecodedBytes {
+			return nil, 0, fmt.Errorf("zstd: content size %d exceeds limit", size)
+		}
+		contentSize = int(size)
+		p += fcsSize
+	}
+
+	frameStart := len(out)
+	state := &zstdFrameState{rep: [3]int{1, 4, 8}}
+	for {
+		if len(src) < p+3 {
+			return nil, 0, errZstdCorrupt
+		}
+		header := int(src[p]) | int(src[p+1])<<8 | int(src[p+2])<<16
+		p += 3
+		last := header&1 != 0
+		size := header >> 3
+		if size > zstdBlockMaxSize {
+			return nil, 0, errZstdCorrupt
+		}
+		switch (header >> 1) & 0x03 {
+		case 0:
+			if len(src) < p+size {
+				return nil, 0, errZstdCorrupt
+			}
+			out = append(out, src[p:p+size]...)
+			p += size
+		case 1:
+			if len(src) < p+1 {
+				return nil, 0, errZstdCorrupt
+			}
+			for i := 0; i < size; i++ {
+				out = append(out, src[p])
+			}
+			p++
+		case 2:
+			if len(src) < p+size {
+				return nil, 0, errZstdCorrupt
+			}
+			var err error
+			if out, err = state.decodeBlock(src[p:p+size], out, frameStart); err != nil {
+				return nil, 0, err
+			}
+			p += size
+		default:
+			return nil, 0, errZstdCorrupt
+		}
+		if len(out) > zstdMaxDecodedBytes {
+			return nil, 0, fmt.Errorf("zstd: decoded size exceeds limit")
+		}
+		if last {
+			break
+		}
+	}
+	if contentSize >= 0 && len(out)-frameStart != contentSize {
+		return nil, 0, errZstdCorrupt
+	}
+	if descriptor&0x04 != 0 {
+		if len(src) < p+4 {
+			return nil, 0, errZstdCorrupt
+		}
+		if uint32(xxhash64(out[frameStart:])) != binary.LittleEndian.Uint32(src[p:]) {
+			return nil, 0, fmt.Errorf("zstd: content checksum mismatch")
+		}
+		p += 4
+	}
+	return out, p, nil
+}
+
+func readZstdLE(b []byte) uint64 {
+	v := uint64(0)
+	for i, c := range b {
+		v |= uint64(c) << (8 * i)
+	}
+	return v
+}
+
+func (st *zstdFrameState) decodeBlock(src []byte, out []byte, frameStart int) ([]byte, error) {
+	literals, used, err := st.decodeLiterals(src)
+	if err != nil {
+		return nil, err
+	}
+	return st.decodeSequences(src[used:], literals, out, frameStart)
+}
+
+func (st *zstdFrameState) decodeLiterals(src []byte) ([]byte, int, error) {
+	if len(src) == 0 {
+		return nil, 0, errZstdCorrupt
+	}
+	kind := src[0] & 0x03
+	sizeFormat := (src[0] >> 2) & 0x03
+	if kind < 2 {
+		size, header := 0, 1
+		switch sizeFormat {
+		case 0, 2:
+			size = int(src[0] >> 3)
+		case 1:
+			header = 2
+		case 3:
+			header = 3
+		}
+		if len(src) < header {
+			return nil, 0, errZstdCorrupt
+		}
+		if header > 1 {
+			size = int(readZstdLE(src[:header]) >> 4)
+		}
+		if kind == 0 {
+			if len(src) < header+size {
+				return nil, 0, errZstdCorrupt
+			}
+			return src[header : header+size], header + size, nil
+		}
+		if len(src) < header+1 || size > zstdBlockMaxSize {
+			return nil, 0, errZstdCorrupt
+		}
+		literals := make([]byte, size)
+		for i := range literals {
+			literals[i] = src[header]
+		}
+		return literals, header + 1, nil
+	}
+
+	header, streams, sizeBits := 3, 4, 10
+	switch sizeFormat {
+	case 0:
+		streams = 1
+	case 2:
+		header, sizeBits = 4, 14
+	case 3:
+		header, sizeBits = 5, 18
+	}
+	if len(src) < header {
+		return nil, 0, errZstdCorrupt
+	}
+	h := readZstdLE(src[:header]) >> 4
+	mask := uint64(1)<<sizeBits - 1
+	regenerated := int(h & mask)
+	compressed := int((h >> sizeBits) & mask)
+	if regenerated > zstdBlockMaxSize || len(src) < header+compressed {
+		return nil, 0, errZstdCorrupt
+	}
+	body := src[header : header+compressed]
+	if kind == 2 {
+		weights, used, err := decodeZstdHuffmanWeights(body)
+		if err != nil {
+			return nil, 0, err
+		}
+		if st.huffman, err = buildZstdHuffmanTable(weights); err != nil {
+			return nil, 0, err
+		}
+		body = body[used:]
+	} else if st.huffman == nil {
+		return nil, 0, errZstdCorrupt
+	}
+
+	literals := make([]byte, 0, regenerated)
+	var err error
+	if streams == 1 {
+		literals, err = st.huffman.decodeStream(body, regenerated, literals)
+		return literals, header + compressed, err
+	}
+	if len(body) < 6 {
+		return nil, 0, errZstdCorrupt
+	}
+	sizes := [4]int{
+		int(binary.LittleEndian.Uint16(body[0:])),
+		int(binary.LittleEndian.Uint16(body[2:])),
+		int(binary.LittleEndian.Uint16(body[4:])),
+	}
+	body = body[6:]
+	sizes[3] = len(body) - sizes[0] - sizes[1] - sizes[2]
+	perStream := (regenerated + 3) / 4
+	if sizes[3] < 1 || regenerated < 3*perStream {
+		return nil, 0, errZstdCorrupt
+	}
+	for i, size := range sizes {
+		n := perStream
+		if i == 3 {
+			n = regenerated - 3*perStream
+		}
+		if literals, err = st.huffman.decodeStream(body[:size], n, literals); err != nil {
+			return nil, 0, err
+		}
+		body = body[size:]
+	}
+	return literals, header + compressed, nil
+}
+
+func (st *zstdFrameState) selectTable(mode byte, src []byte, previous, predefined *zstdFSETable, maxLog uint8, maxSymbol int) (*zstdFSETable, int, error) {
+	switch mode {
+	case 0:
+		return predefined, 0, nil
+	case 1:
+		if len(src) < 1 || int(src[0]) > maxSymbol {
+			return nil, 0, errZstdCorrupt
+		}
+		return zstdRLETable(src[0]), 1, nil
+	case 2:
+		return readZstdFSETableDesc(src, maxLog, maxSymbol)
+	default:
+		if previous == nil {
+			return nil, 0, errZstdCorrupt
+		}
+		return previous, 0, nil
+	}
+}
+
+func (st *zstdFrameState) decodeSequences(src []byte, literals []byte, out []byte, frameStart int) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, errZstdCorrupt
+	}
+	count := int(src[0])
+	p := 1
+	switch {
+	case count == 0:
+		if len(src) != 1 {
+			return nil, errZstdCorrupt
+		}
+		return append(out, literals...), nil
+	case count == 255:
+		if len(src) < 3 {
+			return nil, errZstdCorrupt
+		}
+		count = int(src[1]) + int(src[2])<<8 + 0x7F00
+		p = 3
+	case count >= 128:
+		if len(src) < 2 {
+			return nil, errZstdCorrupt
+		}
+		count = (count-128)<<8 + int(src[1])
+		p = 2
+	}
+	if len(src) < p+1 || src[p]&0x03 != 0 {
+		return nil, errZstdCorrupt
+	}
+	modes := src[p]
+	p++
+	var used int
+	var err error
+	if st.ll, used, err = st.selectTable(modes>>6, src[p:], st.ll, zstdPredefinedLL, 9, 35); err != nil {
+		return nil, err
+	}
+	p += used
+	if st.of, used, err = st.selectTable((modes>>4)&0x03, src[p:], st.of, zstdPredefinedOF, 8, 31); err != nil {
+		return nil, err
+	}
+	p += used
+	if st.ml, used, err = st.selectTable((modes>>2)&0x03, src[p:], st.ml, zstdPredefinedML, 9, 52); err != nil {
+		return nil, err
+	}
+	p += used
+
+	br, err := newZstdReverseBits(src[p:])
+	if err != nil {
+		return nil, err
+	}
+	llState := int(br.read(int(st.ll.log)))
+	ofState := int(br.read(int(st.of.log)))
+	mlState := int(br.read(int(st.ml.log)))
+	blockStart := len(out)
+	litPos := 0
+	for i := 0; i < count; i++ {
+		llEntry, ofEntry, mlEntry := st.ll.entries[llState], st.of.entries[ofState], st.ml.entries[mlState]
+		ofCode := int(ofEntry.symbol)
+		offsetValue := 1<<ofCode + int(br.read(ofCode))
+		matchLen := zstdMLBase[mlEntry.symbol] + int(br.read(int(zstdMLBits[mlEntry.symbol])))
+		litLen := zstdLLBase[llEntry.symbol] + int(br.read(int(zstdLLBits[llEntry.symbol])))
+
+		offset := 0
+		if offsetValue > 3 {
+			offset = offsetValue - 3
+			st.rep = [3]int{offset, st.rep[0], st.rep[1]}
+		} else {
+			idx := offsetValue
+			if litLen == 0 {
+				idx++
+			}
+			switch idx {
+			case 1:
+				offset = st.rep[0]
+			case 2:
+				offset = st.rep[1]
+				st.rep[0], st.rep[1] = st.rep[1], st.rep[0]
+			case 3:
+				offset = st.rep[2]
+				st.rep = [3]int{offset, st.rep[0], st.rep[1]}
+			default:
+				offset = st.rep[0] - 1
+				st.rep = [3]int{offset, st.rep[0], st.rep[1]}
+			}
+		}
+
+		if litPos+litLen > len(literals) {
+			return nil, errZstdCorrupt
+		}
+		out = append(out, literals[litPos:litPos+litLen]...)
+		litPos += litLen
+		if offset <= 0 || offset > len(out)-frameStart || len(out)-blockStart+matchLen > zstdBlockMaxSize {
+			return nil, errZstdCorrupt
+		}
+		from := len(out) - offset
+		if offset >= matchLen {
+			out = append(out, out[from:from+matchLen]...)
+		} else {
+			for j := 0; j < matchLen; j++ {
+				out = append(out, out[from+j])
+			}
+		}
+
+		if i < count-1 {
+			llState = int(llEntry.base) + int(br.read(int(llEntry.nbBits)))
+			mlState = int(mlEntry.base) + int(br.read(int(mlEntry.nbBits)))
+			ofState = int(ofEntry.base) + int(br.read(int(ofEntry.nbBits)))
+		}
+	}
+	if br.pos != 0 {
+		return nil, errZstdCorrupt
+	}
+	out = append(out, literals[litPos:]...)
+	if len(out)-blockStart > zstdBlockMaxSize {
+		return nil, errZstdCorrupt
+	}
+	return out, nil
+}
+
+// zstdBitWriter produces backward bitstreams: bits are appended LSB-first
+// and the decoder reads the last written bits first.
+type zstdBitWriter struct {
+	out []byte
+	acc uint64
+	n   uint
+}
+
+func (w *zstdBitWriter) write(v uint64, n uint) {
+	if n == 0 {
+		return
+	}
+	w.acc |= (v & (1<<n - 1)) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc >>= 8
+		w.n -= 8
+	}
+}
+
+func (w *zstdBitWriter) close() []byte {
+	w.write(1, 1)
+	if w.n > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
+
+type zstdSequence struct {
+	litLen   int
+	matchLen int
+	offset   int
+}
+
+// zstdMatcher finds matches anywhere earlier in the frame; single-segment
+// frames make the whole input the window.
+type zstdMatcher struct {
+	src   []byte
+	head  []int32
+	chain []int32
+}
+
+func zstdHash4(b []byte) uint32 {
+	return (binary.LittleEndian.Uint32(b) * 2654435761) >> (32 - zstdHashLog)
+}
+
+func (m *zstdMatcher) insert(pos int) {
+	if pos+4 > len(m.src) {
+		return
+	}
+	h := zstdHash4(m.src[pos:])
+	m.chain[pos] = m.head[h]
+	m.head[h] = int32(pos + 1)
+}
+
+func (m *zstdMatcher) sequences(start, end int) ([]zstdSequence, []byte) {
+	seqs := []zstdSequence{}
+	literals := make([]byte, 0, end-start)
+	anchor := start
+	pos := start
+	for pos+zstdMinMatch <= end {
+		bestLen, bestOffset := 0, 0
+		candidate := int(m.head[zstdHash4(m.src[pos:])]) - 1
+		for depth := 0; candidate >= 0 && depth < zstdChainDepth && pos-candidate <= zstdMaxOffset; depth++ {
+			n := 0
+			for pos+n < end && m.src[candidate+n] == m.src[pos+n] {
+				n++
+			}
+			if n > bestLen {
+				bestLen, bestOffset = n, pos-candidate
+			}
+			candidate = int(m.chain[candidate]) - 1
+		}
+		m.insert(pos)
+		if bestLen < zstdMinMatch {
+			pos++
+			continue
+		}
+		seqs = append(seqs, zstdSequence{litLen: pos - anchor, matchLen: bestLen, offset: bestOffset})
+		literals = append(literals, m.src[anchor:pos]...)
+		for i := pos + 1; i < pos+bestLen; i++ {
+			m.insert(i)
+		}
+		pos += bestLen
+		anchor = pos
+	}
+	return seqs, append(literals, m.src[anchor:end]...)
+}
+
+// zstdCompress encodes src as one single-segment frame with a checksum.
+// Blocks that do not shrink are stored raw.
+func zstdCompress(src []byte) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, zstdMagic)
+	n := len(src)
+	switch {
+	case n < 256:
+		out = append(out, 0x24, byte(n))
+	case n < 65536+256:
+		out = binary.LittleEndian.AppendUint16(append(out, 0x64), uint16(n-256))
+	case uint64(n) < 1<<32:
+		out = binary.LittleEndian.AppendUint32(append(out, 0xA4), uint32(n))
+	default:
+		out = binary.LittleEndian.AppendUint64(append(out, 0xE4), uint64(n))
+	}
+	if n == 0 {
+		out = append(out, 0x01, 0x00, 0x00)
+	}
+	matcher := &zstdMatcher{src: src, head: make([]int32, 1<<zstdHashLog), chain: make([]int32, n)}
+	for start := 0; start < n; start += zstdBlockMaxSize {
+		end := min(start+zstdBlockMaxSize, n)
+		last := 0
+		if end == n {
+			last = 1
+		}
+		seqs, literals := matcher.sequences(start, end)
+		block := append(encodeZstdLiterals(literals), encodeZstdSequences(seqs)...)
+		kind, body := 2, block
+		if len(block) >= end-start {
+			kind, body = 0, src[start:end]
+		}
+		header := last | kind<<1 | len(body)<<3
+		out = append(out, byte(header), byte(header>>8), byte(header>>16))
+		out = append(out, body...)
+	}
+	return binary.LittleEndian.AppendUint32(out, uint32(xxhash64(src)))
+}
+
+func zstdLiteralsHeader(kind byte, size int) []byte {
+	switch {
+	case size < 32:
+		return []byte{kind | byte(size)<<3}
+	case size < 4096:
+		return []byte{kind | 1<<2 | byte(size)<<4, byte(size >> 4)}
+	default:
+		return []byte{kind | 3<<2 | byte(size)<<4, byte(size >> 4), byte(size >> 12)}
+	}
+}
+
+func encodeZstdLiterals(literals []byte) []byte {
+	raw := append(zstdLiteralsHeader(0, len(literals)), literals...)
+	if len(literals) > 1 {
+		same := true
+		for _, b := range literals[1:] {
+			if b != literals[0] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return append(zstdLiteralsHeader(1, len(literals)), literals[0])
+		}
+	}
+	if compressed := encodeZstdHuffmanLiterals(literals); compressed != nil && len(compressed) < len(raw) {
+		return compressed
+	}
+	return raw
+}
+
+// encodeZstdHuffmanLiterals returns a Huffman literals section, or nil when
+// the alphabet does not fit the direct weight representation (symbols above
+// 128) or has fewer than two symbols.
+func encodeZstdHuffmanLiterals(literals []byte) []byte {
+	freq := make([]int, 256)
+	distinct, maxSymbol := 0, 0
+	for _, b := range literals {
+		freq[b]++
+	}
+	for s, f := range freq {
+		if f > 0 {
+			distinct++
+			maxSymbol = s
+		}
+	}
+	if distinct < 2 || maxSymbol > 128 {
+		return nil
+	}
+	lengths := zstdHuffmanLengths(freq[:maxSymbol+1], zstdHuffmanMaxBits)
+	maxBits := 0
+	for _, l := range lengths {
+		maxBits = max(maxBits, int(l))
+	}
+	weight := func(s int) byte {
+		if s >= len(lengths) || lengths[s] == 0 {
+			return 0
+		}
+		return byte(maxBits + 1 - int(lengths[s]))
+	}
+	desc := []byte{byte(127 + maxSymbol)}
+	for s := 0; s < maxSymbol; s += 2 {
+		low := byte(0)
+		if s+1 < maxSymbol {
+			low = weight(s + 1)
+		}
+		desc = append(desc, weight(s)<<4|low)
+	}
+
+	rankStart := make([]int, maxBits+1)
+	pos := 0
+	for w := 1; w <= maxBits; w++ {
+		rankStart[w] = pos
+		for s := range lengths {
+			if int(weight(s)) == w {
+				pos += 1 << (w - 1)
+			}
+		}
+	}
+	codes := make([]uint64, len(lengths))
+	for s := range lengths {
+		if w := int(weight(s)); w > 0 {
+			codes[s] = uint64(rankStart[w] >> (w - 1))
+			rankStart[w] += 1 << (w - 1)
+		}
+	}
+	encodeStream := func(chunk []byte) []byte {
+		w := &zstdBitWriter{}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			w.write(codes[chunk[i]], uint(lengths[chunk[i]]))
+		}
+		return w.close()
+	}
+
+	n := len(literals)
+	if n <= 1023 {
+		stream := encodeStream(literals)
+		compressed := len(desc) + len(stream)
+		if compressed > 1023 {
+			return nil
+		}
+		h := 2 | n<<4 | compressed<<14
+		out := []byte{byte(h), byte(h >> 8), byte(h >> 16)}
+		return append(append(out, desc...), stream...)
+	}
+	perStream := (n + 3) / 4
+	streams := [][]byte{
+		encodeStream(literals[:perStream]),
+		encodeStream(literals[perStream : 2*perStream]),
+		encodeStream(literals[2*perStream : 3*perStream]),
+		encodeStream(literals[3*perStream:]),
+	}
+	body := append([]byte{}, desc...)
+	for _, stream := range streams[:3] {
+		body = binary.LittleEndian.AppendUint16(body, uint16(len(stream)))
+	}
+	for _, stream := range streams {
+		body = append(body, stream...)
+	}
+	var header []byte
+	if n <= 16383 && len(body) <= 16383 {
+		h := uint32(2 | 2<<2 | n<<4 | len(body)<<18)
+		header = binary.LittleEndian.AppendUint32(nil, h)
+	} else {
+		h := uint64(2 | 3<<2 | n<<4 | len(body)<<22)
+		header = []byte{byte(h), byte(h >> 8), byte(h >> 16), byte(h >> 24), byte(h >> 32)}
+	}
+	return append(header, body...)
+}
+
+// zstdHuffmanLengths builds Huffman code lengths, halving the frequencies
+// until the longest code fits limit.
+func zstdHuffmanLengths(freq []int, limit int) []uint8 {
+	weights := append([]int{}, freq...)
+	for {
+		lengths := huffmanCodeLengths(weights)
+		longest := 0
+		for _, l := range lengths {
+			longest = max(longest, int(l))
+		}
+		if longest <= limit {
+			return lengths
+		}
+		for i, f := range weights {
+			if f > 0 {
+				weights[i] = (f + 1) / 2
+			}
+		}
+	}
+}
+
+func huffmanCodeLengths(freq []int) []uint8 {
+	type node struct {
+		weight int
+		parent int
+	}
+	nodes := []node{}
+	leaf := make([]int, len(freq))
+	for s, f := range freq {
+		leaf[s] = -1
+		if f > 0 {
+			leaf[s] = len(nodes)
+			nodes = append(nodes, node{weight: f, parent: -1})
+		}
+	}
+	alive := make([]int, len(nodes))
+	for i := range alive {
+		alive[i] = i
+	}
+	for len(alive) > 1 {
+		a, b := 0, 1
+		if nodes[alive[b]].weight < nodes[alive[a]].weight {
+			a, b = b, a
+		}
+		for i := 2; i < len(alive); i++ {
+			switch w := nodes[alive[i]].weight; {
+			case w < nodes[alive[a]].weight:
+				a, b = i, a
+			case w < nodes[alive[b]].weight:
+				b = i
+			}
+		}
+		parent := len(nodes)
+		nodes = append(nodes, node{weight: nodes[alive[a]].weight + nodes[alive[b]].weight, parent: -1})
+		nodes[alive[a]].parent = parent
+		nodes[alive[b]].parent = parent
+		if a < b {
+			a, b = b, a
+		}
+		alive = append(alive[:a], alive[a+1:]...)
+		alive = append(alive[:b], alive[b+1:]...)
+		alive = append(alive, parent)
+	}
+	lengths := make([]uint8, len(freq))
+	for s, idx := range leaf {
+		for idx >= 0 && nodes[idx].parent >= 0 {
+			lengths[s]++
+			idx = nodes[idx].parent
+		}
+	}
+	return lengths
+}
+
+func zstdLengthCode(value int, base []int) int {
+	for code := len(base) - 1; code > 0; code-- {
+		if base[code] <= value {
+			return code
+		}
+	}
+	return 0
+}
+
+// encodeZstdSequences writes the sequences section with the predefined
+// tables. Offsets are always sent as new offsets, never repeat codes.
+func encodeZstdSequences(seqs []zstdSequence) []byte {
+	n := len(seqs)
+	var out []byte
+	switch {
+	case n == 0:
+		return []byte{0}
+	case n < 128:
+		out = []byte{byte(n)}
+	case n < 0x7F00:
+		out = []byte{byte(n>>8) + 128, byte(n)}
+	default:
+		out = []byte{255, byte(n - 0x7F00), byte((n - 0x7F00) >> 8)}
+	}
+	out = append(out, 0x00)
+
+	type coded struct {
+		llCode, mlCode, ofCode int
+		ofValue                int
+	}
+	codes := make([]coded, n)
+	for i, seq := range seqs {
+		ofValue := seq.offset + 3
+		codes[i] = coded{
+			llCode:  zstdLengthCode(seq.litLen, zstdLLBase[:]),
+			mlCode:  zstdLengthCode(seq.matchLen, zstdMLBase[:]),
+			ofCode:  bits.Len(uint(ofValue)) - 1,
+			ofValue: ofValue,
+		}
+	}
+	tables := [3]*zstdFSETable{zstdPredefinedLL, zstdPredefinedML, zstdPredefinedOF}
+	symbol := func(i, table int) int {
+		return [3]int{codes[i].llCode, codes[i].mlCode, codes[i].ofCode}[table]
+	}
+	states := make([][3]int, n)
+	for t, table := range tables {
+		states[n-1][t] = int(table.encode[symbol(n-1, t)][0])
+		for i := n - 2; i >= 0; i-- {
+			states[i][t] = int(table.encode[symbol(i, t)][states[i+1][t]])
+		}
+	}
+
+	w := &zstdBitWriter{}
+	for i := n - 1; i >= 0; i-- {
+		if i < n-1 {
+			for _, t := range []int{2, 1, 0} {
+				entry := tables[t].entries[states[i][t]]
+				w.write(uint64(states[i+1][t]-int(entry.base)), uint(entry.nbBits))
+			}
+		}
+		seq, c := seqs[i], codes[i]
+		w.write(uint64(seq.litLen-zstdLLBase[c.llCode]), uint(zstdLLBits[c.llCode]))
+		w.write(uint64(seq.matchLen-zstdMLBase[c.mlCode]), uint(zstdMLBits[c.mlCode]))
+		w.write(uint64(c.ofValue-1<<c.ofCode), uint(c.ofCode))
+	}
+	for _, t := range []int{1, 2, 0} {
+		w.write(uint64(states[0][t]), uint(tables[t].log))
+	}
+	return append(out, w.close()...)
+}
+
+const (
+	xxh64Prime1 uint64 = 11400714785074694791
+	xxh64Prime2 uint64 = 14029467366897019727
+	xxh64Prime3 uint64 = 1609587929392839161
+	xxh64Prime4 uint64 = 9650029242287828579
+	xxh64Prime5 uint64 = 2870177450012600261
+)
+
+func xxh64Round(acc, input uint64) uint64 {
+	return bits.RotateLeft64(acc+input*xxh64Prime2, 31) * xxh64Prime1
+}
+
+func xxh64Merge(acc, v uint64) uint64 {
+	return (acc^xxh64Round(0, v))*xxh64Prime1 + xxh64Prime4
+}
+
+// xxhash64 is XXH64 with seed 0, the zstd content checksum.
+func xxhash64(b []byte) uint64 {
+	n := len(b)
+	var h uint64
+	if n >= 32 {
+		prime1 := xxh64Prime1
+		v1 := prime1 + xxh64Prime2
+		v2 := xxh64Prime2
+		v3 := uint64(0)
+		v4 := -prime1
+		for len(b) >= 32 {
+			v1 = xxh64Round(v1, binary.LittleEndian.Uint64(b[0:]))
+			v2 = xxh64Round(v2, binary.LittleEndian.Uint64(b[8:]))
+			v3 = xxh64Round(v3, binary.LittleEndian.Uint64(b[16:]))
+			v4 = xxh64Round(v4, binary.LittleEndian.Uint64(b[24:]))
+			b = b[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxh64Merge(h, v1)
+		h = xxh64Merge(h, v2)
+		h = xxh64Merge(h, v3)
+		h = xxh64Merge(h, v4)
+	} else {
+		h = xxh64Prime5
+	}
+	h += uint64(n)
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxh64Round(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*xxh64Prime1 + xxh64Prime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * xxh64Prime1
+		h = bits.RotateLeft64(h, 23)*xxh64Prime2 + xxh64Prime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * xxh64Prime5
+		h = bits.RotateLeft64(h, 11) * xxh64Prime1
+	}
+	h ^= h >> 33
+	h *= xxh64Prime2
+	h ^= h >> 29
+	h *= xxh64Prime3
+	h ^= h >> 32
+	return h
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func zstdReferenceText() string {
+	var b strings.Builder
+	states := []string{"in_progress", "waiting_input", "stuck"}
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, `{"at":"2026-10-19T09:%02d:00Z","type":"poll","state":"%s","poll":%d}`+"\n", i, states[i%3], i*i)
+	}
+	return b.String()
+}
+
+func TestZstdDecodesReferenceEncoderFrame(t *testing.T) {
+	// `zstd -19` output for zstdReferenceText: Huffman literals with
+	// FSE-compressed weights, FSE sequence tables and a content checksum.
+	frame, err := hex.DecodeString("" +
+		"28b52ffd64ce0a0d0900728e271a804d3a4028b438ee114f83a7cc0c48e4de52a69dc4801be8dc1e80497df95f25a284" +
+		"693cfbf0daa7b2a2dbea62d62c0df52aaf924aa78409f18c87e99e3e7475f7afcd6e2ea2bc914ae584673cfb309f88fe" +
+		"daad59ad557954f984f07cf844571c1eab71d783612c850463598b0152c0c494c0691a8423d1703005c6b20cab3981b3" +
+		"583498a31c041359277008668a999236c80aac02b4f92867198e2452a81100264cf5ac9f014027497512601060104408" +
+		"7e87838d0892f04ef303531217c7552b3d3add81ee15853d675dc2684b8650b5b2e05400b295b0210059593f60821a98" +
+		"1129c8b7143d00f07a448b7985ce967b3d03214fa413389e9a8936c6db2a3dc5940449988b2c04084e0586900571a132" +
+		"16ccb1d804da544100a30a1b351f7a")
+	if err != nil {
+		t.Fatalf("bad fixture: %v", err)
+	}
+	got, err := zstdDecompress(frame)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if string(got) != zstdReferenceText() {
+		t.Fatalf("decoded text mismatch: %q", got)
+	}
+
+	corrupt := append([]byte{}, frame...)
+	corrupt[len(corrupt)-1] ^= 0xFF
+	if _, err := zstdDecompress(corrupt); err == nil {
+		t.Fatalf("expected checksum mismatch to be rejected")
+	}
+	if _, err := zstdDecompress([]byte("not zstd")); err == nil {
+		t.Fatalf("expected bad magic to be rejected")
+	}
+}
+
+func TestZstdRoundTrip(t *testing.T) {
+	binary := make([]byte, 200_000)
+	for i := range binary {
+		binary[i] = byte((i*i*7 + i/13) % 251)
+	}
+	inputs := map[string][]byte{
+		"empty":      {},
+		"one byte":   []byte("a"),
+		"reference":  []byte(zstdReferenceText()),
+		"utf8":       []byte(strings.Repeat("état — naïve ✓ ", 50)),
+		"run":        bytes.Repeat([]byte("x"), 300_000),
+		"multiblock": binary,
+	}
+	for name, input := range inputs {
+		frame := zstdCompress(input)
+		got, err := zstdDecompress(frame)
+		if err != nil {
+			t.Fatalf("%s: decode failed: %v", name, err)
+		}
+		if !bytes.Equal(got, input) {
+			t.Fatalf("%s: round trip mismatch", name)
+		}
+	}
+	if text := []byte(zstdReferenceText()); len(zstdCompress(text)) > len(text)/3 {
+		t.Fatalf("expected repetitive JSON to compress at least 3x")
+	}
+	// Concatenated frames decode back to back.
+	twoFrames := append(zstdCompress([]byte("first,")), zstdCompress([]byte("second"))...)
+	if got, err := zstdDecompress(twoFrames); err != nil || string(got) != "first,second" {
+		t.Fatalf("unexpected multi-frame decode: %q (%v)", got, err)
+	}
+}
+
+func TestXXHash64(t *testing.T) {
+	cases := map[string]uint64{
+		"":    0xEF46DB3751D8E999,
+		"abc": 0x44BC2CF5AD770999,
+	}
+	for input, want := range cases {
+		if got := xxhash64([]byte(input)); got != want {
+			t.Fatalf("xxhash64(%q) = %x, want %x", input, got, want)
+		}
+	}
+}